@@ -0,0 +1,108 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// AppliedFormat is the format of the list of applied migrations read by ImportApplied.
+type AppliedFormat string
+
+const (
+	// AppliedFormatCSV is a header with the column names followed by a row per applied migration, as exported by
+	// `\copy (SELECT ...) TO 'file' CSV HEADER` of psql.
+	AppliedFormatCSV AppliedFormat = "csv"
+	// AppliedFormatJSON is a JSON array of the IDs, or of objects with the column, as exported by json_agg of Postgres
+	// or JSON_ARRAYAGG of MySQL. Numeric IDs are converted to strings as they are.
+	AppliedFormatJSON AppliedFormat = "json"
+)
+
+// ErrInvalidAppliedList is returned by ImportApplied when the list of applied migrations cannot be parsed, or a
+// migration of it has no ID.
+var ErrInvalidAppliedList = errors.New("invalid list of applied migrations")
+
+// ImportApplied records the migrations of the list read from r as finished, for the teams moving the tracking of their
+// migrations from a SQL database (Postgres, MySQL, ...) to DynamoDB. The list is a dump of the migrations table of the
+// database, and the IDs are read from the given column (e.g. "version" for golang-migrate and Rails, or "name" for
+// Knex). The list is validated before anything is written.
+//
+// The migrations already recorded are kept as they are, so the import can be run again. It returns the IDs of the
+// migrations added, sorted.
+//
+// ImportApplied does not lock the migrations, the caller should hold the lock while importing.
+func (t *Target) ImportApplied(ctx context.Context, r io.Reader, format AppliedFormat, column string) (_ []string, err error) {
+	defer t.observe(ctx, operationImportApplied, t.clock.Now(), &err)
+	defer wrapError(operationImportApplied, t.tableName, &err)
+
+	ids, err := parseApplied(r, format, column)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+	return t.baseline(ctx, slices.Compact(ids))
+}
+
+func parseApplied(r io.Reader, format AppliedFormat, column string) ([]string, error) {
+	switch format {
+	case AppliedFormatCSV:
+		return parseAppliedCSV(r, column)
+	case AppliedFormatJSON:
+		return parseAppliedJSON(r, column)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidAppliedList, format)
+	}
+}
+
+func parseAppliedCSV(r io.Reader, column string) ([]string, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAppliedList, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	index := slices.Index(rows[0], column)
+	if index < 0 {
+		return nil, fmt.Errorf("%w: no column %s", ErrInvalidAppliedList, column)
+	}
+	ids := make([]string, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if row[index] == "" {
+			return nil, fmt.Errorf("%w: row %d has no %s", ErrInvalidAppliedList, i+1, column)
+		}
+		ids = append(ids, row[index])
+	}
+	return ids, nil
+}
+
+func parseAppliedJSON(r io.Reader, column string) ([]string, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var values []any
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAppliedList, err)
+	}
+	ids := make([]string, 0, len(values))
+	for i, value := range values {
+		if row, ok := value.(map[string]any); ok {
+			value = row[column]
+		}
+		var id string
+		switch value := value.(type) {
+		case string:
+			id = value
+		case json.Number:
+			id = value.String()
+		}
+		if id == "" {
+			return nil, fmt.Errorf("%w: item %d has no %s", ErrInvalidAppliedList, i, column)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
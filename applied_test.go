@@ -0,0 +1,58 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImportApplied", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should record the migrations of a CSV dump as finished", func() {
+		Expect(target.Add(ctx, "20240102")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "20240102")).To(Succeed())
+
+		added, err := target.ImportApplied(ctx, strings.NewReader("version,dirty\n20240103,f\n20240101,f\n20240102,f\n"), AppliedFormatCSV, "version")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(Equal([]string{"20240101", "20240103"}))
+
+		Expect(target.Done(ctx)).To(Equal([]string{"20240101", "20240102", "20240103"}))
+	})
+
+	It("should record the migrations of a JSON dump as finished", func() {
+		added, err := target.ImportApplied(ctx, strings.NewReader(`[{"name": "0002_orders.js", "batch": 1}, {"name": "0001_users.js", "batch": 1}]`), AppliedFormatJSON, "name")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(Equal([]string{"0001_users.js", "0002_orders.js"}))
+	})
+
+	It("should read arrays of numeric IDs", func() {
+		added, err := target.ImportApplied(ctx, strings.NewReader(`[20240101, 20240102]`), AppliedFormatJSON, "version")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(Equal([]string{"20240101", "20240102"}))
+	})
+
+	It("should not record anything when a migration has no ID", func() {
+		_, err := target.ImportApplied(ctx, strings.NewReader("version\n20240101\n\"\"\n"), AppliedFormatCSV, "version")
+		Expect(err).To(MatchError(ErrInvalidAppliedList))
+
+		_, err = target.ImportApplied(ctx, strings.NewReader("name\n0001\n"), AppliedFormatCSV, "version")
+		Expect(err).To(MatchError(ContainSubstring("no column version")))
+
+		Expect(target.Done(ctx)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,256 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// approvalSuffix is appended to the lock ID to make the key of the item requesting the approval of a plan, in the lock
+// table.
+const approvalSuffix = "#approval"
+
+var (
+	// ErrApprovalPending is returned by AwaitApproval, when not waiting, while the plan is not approved.
+	ErrApprovalPending = errors.New("migrations plan waiting for approval")
+
+	// ErrApprovalNotFound is returned by Approve when there is no request with the ID, e.g. because the plan changed
+	// since it was read.
+	ErrApprovalNotFound = errors.New("approval request not found")
+
+	// ErrSelfApproval is returned by Approve when the approver is the one that requested the approval.
+	ErrSelfApproval = errors.New("approval request cannot be approved by its requester")
+)
+
+// ApprovalRequest is the plan of a run waiting to be approved, as written by AwaitApproval.
+type ApprovalRequest struct {
+	// ID identifies the request. It changes whenever the plan changes, so an approval is never given to a plan other
+	// than the one read.
+	ID string
+	// Planned are the actions planned, as "<action>:<migration ID>" (e.g. "do:0002"), in order.
+	Planned     []string
+	RequestedAt time.Time
+	RequestedBy string
+	Approved    bool
+	ApprovedAt  time.Time
+	ApprovedBy  string
+}
+
+type ddbApprovalRequest struct {
+	RequestID   string   `dynamodbav:"request_id"`
+	Planned     []string `dynamodbav:"planned"`
+	RequestedAt string   `dynamodbav:"requested_at"`
+	RequestedBy string   `dynamodbav:"requested_by"`
+	Approved    bool     `dynamodbav:"approved"`
+	ApprovedAt  string   `dynamodbav:"approved_at"`
+	ApprovedBy  string   `dynamodbav:"approved_by"`
+}
+
+// PlannedActions returns the actions of the plan as recorded in an ApprovalRequest.
+func PlannedActions(plan migrations.Plan) []string {
+	planned := make([]string, 0, len(plan))
+	for _, action := range plan {
+		planned = append(planned, string(action.Action)+":"+action.Migration.ID())
+	}
+	return planned
+}
+
+// PendingApproval returns the approval request written by AwaitApproval, approved or not, or nil if there is none.
+func (t *Target) PendingApproval(ctx context.Context) (_ *ApprovalRequest, err error) {
+	defer wrapError(operationApproval, t.lockTableName, &err)
+
+	item, err := t.lockTableItem(ctx, operationApproval, t.lockID+approvalSuffix)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	var r ddbApprovalRequest
+	if err := t.codec.unmarshalRecord(item, &r); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the approval request: %w", err)
+	}
+	return &ApprovalRequest{
+		ID:          r.RequestID,
+		Planned:     r.Planned,
+		RequestedAt: t.codec.parseTimestamp(r.RequestedAt),
+		RequestedBy: r.RequestedBy,
+		Approved:    r.Approved,
+		ApprovedAt:  t.codec.parseTimestamp(r.ApprovedAt),
+		ApprovedBy:  r.ApprovedBy,
+	}, nil
+}
+
+// Approve approves the request with the ID, as read from PendingApproval, by a human or a ChatOps bot. If the request
+// was replaced by another plan, or consumed by the run, it returns an ErrApprovalNotFound. The request cannot be
+// approved by its requester (see ApprovalRequest.RequestedBy): it returns an ErrSelfApproval.
+func (t *Target) Approve(ctx context.Context, requestID, approver string) (err error) {
+	defer wrapError(operationApprove, t.lockTableName, &err)
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.
+			Set(expression.Name("approved"), expression.Value(true)).
+			Set(expression.Name("approved_at"), expression.Value(t.codec.formatTimestamp(t.clock.Now()))).
+			Set(expression.Name("approved_by"), expression.Value(approver))).
+		WithCondition(expression.Name("request_id").Equal(expression.Value(requestID)).
+			And(expression.Name("requested_by").NotEqual(expression.Value(approver)))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the approval expression: %w", err)
+	}
+	output, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &t.lockTableName,
+		Key:                       t.approvalKey(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		// tells a request approved by its requester from one that does not exist anymore.
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}, t.lockCallOptions()...)
+	var conditionalCheckFailedException *types.ConditionalCheckFailedException
+	switch {
+	case errors.As(err, &conditionalCheckFailedException) && isRequest(conditionalCheckFailedException.Item, requestID):
+		return fmt.Errorf("%w: %s", ErrSelfApproval, requestID)
+	case errors.As(err, &conditionalCheckFailedException):
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, requestID)
+	case err != nil:
+		return fmt.Errorf("failed to approve the migrations: %w", err)
+	}
+	t.capacity.record(operationApprove, output.ConsumedCapacity)
+	t.logger.Log(ctx, t.eventLogLevel, "migrations approved", "request_id", requestID, "approver", approver)
+	return nil
+}
+
+// isRequest checks if the item is the approval request with the ID.
+func isRequest(item map[string]types.AttributeValue, requestID string) bool {
+	id, ok := item["request_id"].(*types.AttributeValueMemberS)
+	return ok && id.Value == requestID
+}
+
+// AwaitApproval checks that the planned actions (see PlannedActions) were approved, consuming the approval, so the
+// next plan must be approved again. If they were not, it writes an ApprovalRequest with them, replacing the request of
+// any other plan, and, if interval is greater than 0, checks it again every interval until it is approved or the
+// context is done. With an interval of 0, it returns an ErrApprovalPending instead of waiting.
+func (t *Target) AwaitApproval(ctx context.Context, planned []string, interval time.Duration) (err error) {
+	defer wrapError(operationApproval, t.lockTableName, &err)
+
+	for {
+		request, err := t.PendingApproval(ctx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case request != nil && slices.Equal(request.Planned, planned) && request.Approved:
+			return t.consumeApproval(ctx, request)
+		case request == nil || !slices.Equal(request.Planned, planned):
+			if err := t.requestApproval(ctx, planned); err != nil {
+				return err
+			}
+		}
+		if interval <= 0 {
+			return ErrApprovalPending
+		}
+
+		select {
+		case <-t.clock.After(interval):
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrApprovalPending, ctx.Err())
+		}
+	}
+}
+
+// ApprovalPlanner wraps the planner so the plan is only run once approved, with target.AwaitApproval. As the plan is
+// made while the lock is held, the run waits for the approval holding it.
+func ApprovalPlanner(planner migrations.ActionPLanner, target *Target, interval time.Duration) migrations.ActionPLanner {
+	return func(source migrations.Source, t migrations.Target) migrations.Planner {
+		return &approvalPlanner{planner: planner(source, t), target: target, interval: interval}
+	}
+}
+
+type approvalPlanner struct {
+	planner  migrations.Planner
+	target   *Target
+	interval time.Duration
+}
+
+func (p *approvalPlanner) Plan(ctx context.Context) (migrations.Plan, error) {
+	plan, err := p.planner.Plan(ctx)
+	if err != nil || len(plan) == 0 {
+		return plan, err
+	}
+	if err := p.target.AwaitApproval(ctx, PlannedActions(plan), p.interval); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (t *Target) approvalKey() map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: t.lockID + approvalSuffix},
+	}
+}
+
+// requestApproval writes a new approval request for the planned actions.
+func (t *Target) requestApproval(ctx context.Context, planned []string) error {
+	requestID := randomID()
+	output, err := t.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &t.lockTableName,
+		Item: map[string]types.AttributeValue{
+			"id":           &types.AttributeValueMemberS{Value: t.lockID + approvalSuffix},
+			"request_id":   &types.AttributeValueMemberS{Value: requestID},
+			"planned":      plannedAttribute(planned),
+			"requested_at": &types.AttributeValueMemberS{Value: t.codec.formatTimestamp(t.clock.Now())},
+			"requested_by": &types.AttributeValueMemberS{Value: t.auditActor},
+			"approved":     &types.AttributeValueMemberBOOL{Value: false},
+		},
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to request the approval: %w", err)
+	}
+	t.capacity.record(operationApproval, output.ConsumedCapacity)
+	t.logger.WarnContext(ctx, "migrations waiting for approval", "request_id", requestID, "planned", planned)
+	return nil
+}
+
+// consumeApproval removes the approved request, unless it was replaced in the meantime.
+func (t *Target) consumeApproval(ctx context.Context, request *ApprovalRequest) error {
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.Name("request_id").Equal(expression.Value(request.ID))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the approval expression: %w", err)
+	}
+	output, err := t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 &t.lockTableName,
+		Key:                       t.approvalKey(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	var conditionalCheckFailedException *types.ConditionalCheckFailedException
+	switch {
+	case errors.As(err, &conditionalCheckFailedException):
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, request.ID)
+	case err != nil:
+		return fmt.Errorf("failed to consume the approval: %w", err)
+	}
+	t.capacity.record(operationApproval, output.ConsumedCapacity)
+	t.logger.Log(ctx, t.eventLogLevel, "migrations approval consumed", "request_id", request.ID, "approver", request.ApprovedBy)
+	return nil
+}
+
+func plannedAttribute(planned []string) types.AttributeValue {
+	values := make([]types.AttributeValue, 0, len(planned))
+	for _, action := range planned {
+		values = append(values, &types.AttributeValueMemberS{Value: action})
+	}
+	return &types.AttributeValueMemberL{Value: values}
+}
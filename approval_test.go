@@ -0,0 +1,99 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Approval", func() {
+	var (
+		ctx    context.Context
+		source migrations.Source
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		source = migrations.NewMemorySource()
+		for _, id := range []string{"0001", "0002"} {
+			Expect(source.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+				return nil
+			}, nil))).To(Succeed())
+		}
+		target = NewTarget(dynamoDBClient, WithAuditActor("ci"))
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should fail while the plan is not approved, recording it for approval", func() {
+		_, err := RunMigrations(ctx, dynamoDBClient, source, WithApproval(0))
+		Expect(err).To(MatchError(ErrApprovalPending))
+		Expect(ErrorCodeOf(err)).To(Equal(CodeApprovalPending))
+
+		request, err := target.PendingApproval(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request).ToNot(BeNil())
+		Expect(request.Planned).To(Equal([]string{"do:0001", "do:0002"}))
+		Expect(request.RequestedBy).To(Equal("ci"))
+		Expect(request.Approved).To(BeFalse())
+		Expect(target.Done(ctx)).To(BeEmpty())
+	})
+
+	It("should run the plan once approved, consuming the approval", func() {
+		_, err := RunMigrations(ctx, dynamoDBClient, source, WithApproval(0))
+		Expect(err).To(MatchError(ErrApprovalPending))
+		request, err := target.PendingApproval(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Approve(ctx, request.ID, "reviewer")).To(Succeed())
+
+		_, err = RunMigrations(ctx, dynamoDBClient, source, WithApproval(0))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Done(ctx)).To(Equal([]string{"0001", "0002"}))
+		Expect(target.PendingApproval(ctx)).To(BeNil())
+	})
+
+	It("should wait for the approval", func() {
+		done := make(chan error, 1)
+		go func() {
+			_, err := RunMigrations(ctx, dynamoDBClient, source, WithApproval(10*time.Millisecond))
+			done <- err
+		}()
+
+		var request *ApprovalRequest
+		Eventually(func() (*ApprovalRequest, error) {
+			var err error
+			request, err = target.PendingApproval(ctx)
+			return request, err
+		}).ShouldNot(BeNil())
+		Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+
+		Expect(target.Approve(ctx, request.ID, "reviewer")).To(Succeed())
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(target.Done(ctx)).To(Equal([]string{"0001", "0002"}))
+	})
+
+	It("should not approve a request by its requester", func() {
+		Expect(target.AwaitApproval(ctx, []string{"do:0001"}, 0)).To(MatchError(ErrApprovalPending))
+		request, err := target.PendingApproval(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(target.Approve(ctx, request.ID, "ci")).To(MatchError(ErrSelfApproval))
+		Expect(target.PendingApproval(ctx)).To(HaveField("Approved", false))
+		Expect(target.Approve(ctx, request.ID, "reviewer")).To(Succeed())
+	})
+
+	It("should not approve a request replaced by another plan", func() {
+		Expect(target.AwaitApproval(ctx, []string{"do:0001"}, 0)).To(MatchError(ErrApprovalPending))
+		request, err := target.PendingApproval(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.AwaitApproval(ctx, []string{"do:0001", "do:0002"}, 0)).To(MatchError(ErrApprovalPending))
+
+		Expect(target.Approve(ctx, request.ID, "reviewer")).To(MatchError(ErrApprovalNotFound))
+	})
+})
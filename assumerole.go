@@ -0,0 +1,32 @@
+package migrations_dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// AssumeRoleSessionName is the name of the session of the role assumed by NewTargetAssumingRole.
+const AssumeRoleSessionName = "migrations-dynamodb"
+
+// NewTargetAssumingRole creates a Target recording the migrations in another account, for when the migration state
+// lives in a central account. The DynamoDB client is built from the cfg, with the credentials of the role, assumed
+// through STS with the credentials of the cfg. The externalID is sent when assuming the role, unless empty. The
+// credentials are cached and refreshed before they expire.
+func NewTargetAssumingRole(cfg aws.Config, roleARN, externalID string, opts ...Option) *Target {
+	return NewTarget(dynamodb.NewFromConfig(assumeRoleConfig(cfg, roleARN, externalID)), opts...)
+}
+
+// assumeRoleConfig returns a copy of the cfg with the cached credentials of the role, assumed with the ones of the cfg.
+func assumeRoleConfig(cfg aws.Config, roleARN, externalID string) aws.Config {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = AssumeRoleSessionName
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	cfg = cfg.Copy()
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+	return cfg
+}
@@ -0,0 +1,93 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewTargetAssumingRole", func() {
+	var (
+		ctx    context.Context
+		server *httptest.Server
+
+		mu            sync.Mutex
+		assumeRoles   []url.Values
+		authorization []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		assumeRoles, authorization = nil, nil
+		dynamoDBURL, err := url.Parse("http://localhost:8000")
+		Expect(err).ToNot(HaveOccurred())
+		proxy := httputil.NewSingleHostReverseProxy(dynamoDBURL)
+		// The server answers the AssumeRole of STS, and proxies the calls to DynamoDB to the local DynamoDB.
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if r.Header.Get("X-Amz-Target") != "" {
+				authorization = append(authorization, r.Header.Get("Authorization"))
+				proxy.ServeHTTP(w, r)
+				return
+			}
+			Expect(r.ParseForm()).To(Succeed())
+			assumeRoles = append(assumeRoles, r.PostForm)
+			w.Header().Set("Content-Type", "text/xml")
+			_, _ = w.Write([]byte(`<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASSUMEDKEY</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/migrations/migrations-dynamodb</Arn>
+      <AssumedRoleId>AROA:migrations-dynamodb</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should call DynamoDB with the credentials of the role assumed", func() {
+		cfg := aws.Config{
+			Region:       "sa-region-1",
+			Credentials:  credentials.NewStaticCredentialsProvider("SERVICEKEY", "secret", ""),
+			BaseEndpoint: aws.String(server.URL),
+		}
+
+		target := NewTargetAssumingRole(cfg, "arn:aws:iam::123456789012:role/migrations", "tooling")
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "0001")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "0001")).To(Succeed())
+		Expect(target.Done(ctx)).To(Equal([]string{"0001"}))
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(assumeRoles).To(HaveLen(1))
+		Expect(assumeRoles[0].Get("RoleArn")).To(Equal("arn:aws:iam::123456789012:role/migrations"))
+		Expect(assumeRoles[0].Get("ExternalId")).To(Equal("tooling"))
+		Expect(assumeRoles[0].Get("RoleSessionName")).To(Equal(AssumeRoleSessionName))
+		Expect(authorization).ToNot(BeEmpty())
+		for _, header := range authorization {
+			Expect(strings.Contains(header, "Credential=ASSUMEDKEY/")).To(BeTrue(), header)
+		}
+	})
+})
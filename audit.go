@@ -0,0 +1,141 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// createAuditTable creates the audit table, if it was set by WithAuditTable and does not exist.
+func (t *Target) createAuditTable(ctx context.Context, tables map[string]struct{}, wait bool) error {
+	if t.auditTableName == "" {
+		return nil
+	}
+	if _, ok := tables[t.auditTableName]; !ok {
+		if err := t.createTable(ctx, t.auditTableName, wait); err != nil {
+			return fmt.Errorf("failed to create migrations audit table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// auditPut returns the put appending an entry to the audit table recording that the given operation changed the
+// migration from before to after. A nil before (or after) means the migration did not exist before (or after) the
+// operation. Entries are never overwritten. If the audit table was not set, it returns nil.
+func (t *Target) auditPut(ctx context.Context, operation, id string, before, after map[string]types.AttributeValue) (*types.Put, error) {
+	if t.auditTableName == "" {
+		return nil, nil
+	}
+
+	item := map[string]types.AttributeValue{
+		"id":           &types.AttributeValueMemberS{Value: randomID()},
+		"migration_id": &types.AttributeValueMemberS{Value: id},
+		"operation":    &types.AttributeValueMemberS{Value: operation},
+		"actor":        &types.AttributeValueMemberS{Value: t.auditActor},
+		"owner":        &types.AttributeValueMemberS{Value: t.ownerID},
+		"timestamp":    &types.AttributeValueMemberS{Value: t.codec.formatTimestamp(t.clock.Now())},
+	}
+	if correlationID := t.correlationID(ctx); correlationID != "" {
+		item["correlation_id"] = &types.AttributeValueMemberS{Value: correlationID}
+	}
+	if before != nil {
+		item["before"] = &types.AttributeValueMemberM{Value: before}
+	}
+	if after != nil {
+		item["after"] = &types.AttributeValueMemberM{Value: after}
+	}
+
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.AttributeNotExists(expression.Name("id"))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the audit expression: %w", err)
+	}
+	return &types.Put{
+		TableName:                 &t.auditTableName,
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}, nil
+}
+
+// audit appends an entry to the audit table, as auditPut, after the change was written. The change cannot be undone
+// at this point, so failing to write the entry does not fail the operation: it is logged and recorded as a failed
+// Audit operation by the metrics instead (see Stats.Errors). If the audit table was not set, it does nothing.
+func (t *Target) audit(ctx context.Context, operation, id string, before, after map[string]types.AttributeValue) {
+	if t.auditTableName == "" {
+		return
+	}
+	startedAt := t.clock.Now()
+	put, err := t.auditPut(ctx, operation, id, before, after)
+	if err == nil {
+		var output *dynamodb.PutItemOutput
+		output, err = t.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 put.TableName,
+			Item:                      put.Item,
+			ConditionExpression:       put.ConditionExpression,
+			ExpressionAttributeNames:  put.ExpressionAttributeNames,
+			ExpressionAttributeValues: put.ExpressionAttributeValues,
+			ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		})
+		if err == nil {
+			t.capacity.record(operation, output.ConsumedCapacity)
+		}
+	}
+	t.metrics.RecordOperation(ctx, operationAudit, t.clock.Now().Sub(startedAt), err)
+	if err != nil {
+		t.logger.ErrorContext(ctx, "failed to write the audit entry", "operation", operation, "id", id, "error", err)
+	}
+}
+
+// withAudit appends the put of the audit entry to the writes of a transaction, so the change and its entry are
+// written together. If the audit table was not set, the writes are returned as they are.
+func (t *Target) withAudit(ctx context.Context, operation, id string, before, after map[string]types.AttributeValue, writes ...types.TransactWriteItem) ([]types.TransactWriteItem, error) {
+	put, err := t.auditPut(ctx, operation, id, before, after)
+	if err != nil || put == nil {
+		return writes, err
+	}
+	return append(writes, types.TransactWriteItem{Put: put}), nil
+}
+
+// auditBefore reads the migration before a transactional write changes it, as DynamoDB transactions do not return the
+// values they overwrite. The lock is checked by the transaction, so the migration cannot change in between. It returns
+// nil if the audit table was not set.
+func (t *Target) auditBefore(ctx context.Context, operation, id string) (map[string]types.AttributeValue, error) {
+	if t.auditTableName == "" {
+		return nil, nil
+	}
+	return t.getItem(ctx, operation, t.tableName, id)
+}
+
+// withAttributes returns a copy of the given migration item with the given attributes set.
+func withAttributes(item, attributes map[string]types.AttributeValue) map[string]types.AttributeValue {
+	r := make(map[string]types.AttributeValue, len(item)+len(attributes))
+	for k, v := range item {
+		r[k] = v
+	}
+	for k, v := range attributes {
+		r[k] = v
+	}
+	return r
+}
+
+// defaultAuditActor identifies the user and host running the Target, as in "user@host".
+func defaultAuditActor() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return name + "@" + host
+}
@@ -0,0 +1,143 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit table", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit"), WithAuditActor("deployer"))
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	listAuditEntries := func() []map[string]types.AttributeValue {
+		GinkgoHelper()
+
+		scanResponse, err := dynamoDBClient.Scan(ctx, &dynamodb.ScanInput{
+			TableName: aws.String("_migrations-audit"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		entries := scanResponse.Items
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i]["timestamp"].(*types.AttributeValueMemberS).Value < entries[j]["timestamp"].(*types.AttributeValueMemberS).Value
+		})
+		return entries
+	}
+
+	dirtyOf := func(entry map[string]types.AttributeValue, field string) bool {
+		return entry[field].(*types.AttributeValueMemberM).Value["dirty"].(*types.AttributeValueMemberBOOL).Value
+	}
+
+	It("should record every change of the migrations", func() {
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		time.Sleep(time.Millisecond)
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		time.Sleep(time.Millisecond)
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+		time.Sleep(time.Millisecond)
+		Expect(target.Remove(ctx, "1")).To(Succeed())
+
+		entries := listAuditEntries()
+		Expect(entries).To(HaveLen(4))
+
+		operations := make([]string, len(entries))
+		for i, entry := range entries {
+			operations[i] = entry["operation"].(*types.AttributeValueMemberS).Value
+			Expect(entry["migration_id"]).To(Equal(&types.AttributeValueMemberS{Value: "1"}))
+			Expect(entry["actor"]).To(Equal(&types.AttributeValueMemberS{Value: "deployer"}))
+			Expect(entry["owner"]).To(Equal(&types.AttributeValueMemberS{Value: target.ownerID}))
+		}
+		Expect(operations).To(Equal([]string{operationAdd, operationFinishMigration, operationStartMigration, operationRemove}))
+
+		Expect(entries[0]).ToNot(HaveKey("before"))
+		Expect(dirtyOf(entries[0], "after")).To(BeTrue())
+
+		Expect(dirtyOf(entries[1], "before")).To(BeTrue())
+		Expect(dirtyOf(entries[1], "after")).To(BeFalse())
+
+		Expect(dirtyOf(entries[2], "before")).To(BeFalse())
+		Expect(dirtyOf(entries[2], "after")).To(BeTrue())
+
+		Expect(dirtyOf(entries[3], "before")).To(BeTrue())
+		Expect(entries[3]).ToNot(HaveKey("after"))
+	})
+
+	It("should not record failed changes", func() {
+		Expect(target.FinishMigration(ctx, "1")).To(MatchError(migrations.ErrMigrationNotFound))
+
+		Expect(listAuditEntries()).To(BeEmpty())
+	})
+
+	It("should record the changes in the transactions of the writes, with the values before them", func() {
+		target := NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit"), WithTransactionalWrites())
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			Expect(u.Unlock(ctx)).To(Succeed())
+		}()
+
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		time.Sleep(time.Millisecond)
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		time.Sleep(time.Millisecond)
+		Expect(target.Remove(ctx, "1")).To(Succeed())
+
+		entries := listAuditEntries()
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0]).ToNot(HaveKey("before"))
+		Expect(dirtyOf(entries[1], "before")).To(BeTrue())
+		Expect(dirtyOf(entries[1], "after")).To(BeFalse())
+		Expect(dirtyOf(entries[2], "before")).To(BeFalse())
+		Expect(entries[2]).ToNot(HaveKey("after"))
+	})
+
+	It("should not fail the change when the entry cannot be written", func() {
+		target := NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit-missing"))
+
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		Expect(target.Done(ctx)).To(Equal([]string{"1"}))
+		Expect(target.Stats().Errors).To(HaveKeyWithValue(operationAudit, int64(2)))
+	})
+
+	It("should record the repairs of the dirty migrations as repairs", func() {
+		target := NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit"), WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyRemove))
+		Expect(target.Add(ctx, "1")).To(Succeed())
+
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u.Unlock(ctx)).To(Succeed())
+
+		entries := listAuditEntries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[1]["operation"]).To(Equal(&types.AttributeValueMemberS{Value: operationRepairRemove}))
+		Expect(dirtyOf(entries[1], "before")).To(BeTrue())
+	})
+
+	It("should keep the audit table on Destroy", func() {
+		Expect(target.Destroy(ctx)).To(Succeed())
+
+		listTablesResponse, err := dynamoDBClient.ListTables(ctx, &dynamodb.ListTablesInput{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(listTablesResponse.TableNames).To(ConsistOf("_migrations-audit"))
+	})
+})
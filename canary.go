@@ -0,0 +1,166 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	attributeCanarySlices = "canary_slices"
+	attributePromotedAt   = "promoted_at"
+
+	// phaseCanary is the phase of a migration applied in some slices of the environment only, by MarkCanary, waiting
+	// for PromoteCanary or RevertCanary.
+	phaseCanary = "canary"
+)
+
+// MarkCanary marks a finished migration as applied in canary, in the given slices of the environment only (e.g. a
+// region or a cell), adding them to the slices it was marked before. The migration is still listed by Done, as it
+// was applied, but Status and History tell it is in canary, and where, until it is promoted to fully applied with
+// PromoteCanary, or reverted with RevertCanary. If the migration is not finished, it returns an ErrInvalidPhase.
+func (t *Target) MarkCanary(ctx context.Context, id string, canarySlices ...string) (err error) {
+	defer t.observe(ctx, operationMarkCanary, t.clock.Now(), &err)
+	defer wrapError(operationMarkCanary, t.tableName, &err)
+
+	if len(canarySlices) == 0 {
+		return errors.New("no canary slice given")
+	}
+	update := expression.
+		Set(expression.Name(attributePhase), expression.Value(phaseCanary)).
+		Add(expression.Name(attributeCanarySlices), expression.Value(types.AttributeValueMemberSS{Value: canarySlices}))
+	condition := expression.Name("dirty").Equal(expression.Value(false)).
+		And(expression.Or(
+			expression.AttributeNotExists(expression.Name(attributePhase)),
+			expression.Name(attributePhase).Equal(expression.Value(phaseCanary)),
+		))
+	before, err := t.updateCanary(ctx, operationMarkCanary, id, update, condition)
+	if err != nil {
+		return err
+	}
+
+	marked := canarySlices
+	if previous, ok := before[attributeCanarySlices].(*types.AttributeValueMemberSS); ok {
+		marked = mergeSlices(previous.Value, canarySlices)
+	}
+	after := withAttributes(before, map[string]types.AttributeValue{
+		attributePhase:        &types.AttributeValueMemberS{Value: phaseCanary},
+		attributeCanarySlices: &types.AttributeValueMemberSS{Value: marked},
+	})
+	t.audit(ctx, operationMarkCanary, id, before, after)
+	t.logger.Log(ctx, t.eventLogLevel, "migration marked as canary", "id", id, "slices", marked)
+	return nil
+}
+
+// PromoteCanary promotes a migration in canary, marked by MarkCanary, to fully applied. If the migration is not in
+// canary, it returns an ErrInvalidPhase.
+func (t *Target) PromoteCanary(ctx context.Context, id string) (err error) {
+	defer t.observe(ctx, operationPromoteCanary, t.clock.Now(), &err)
+	defer wrapError(operationPromoteCanary, t.tableName, &err)
+
+	promotedAt := t.codec.formatTimestamp(t.clock.Now())
+	update := expression.
+		Set(expression.Name(attributePromotedAt), expression.Value(promotedAt)).
+		Remove(expression.Name(attributePhase)).
+		Remove(expression.Name(attributeCanarySlices))
+	before, err := t.updateCanary(ctx, operationPromoteCanary, id, update, expression.Name(attributePhase).Equal(expression.Value(phaseCanary)))
+	if err != nil {
+		return err
+	}
+
+	after := withAttributes(before, map[string]types.AttributeValue{
+		attributePromotedAt: &types.AttributeValueMemberS{Value: promotedAt},
+	})
+	delete(after, attributePhase)
+	delete(after, attributeCanarySlices)
+	t.audit(ctx, operationPromoteCanary, id, before, after)
+	t.logger.Log(ctx, t.eventLogLevel, "migration promoted", "id", id)
+	return nil
+}
+
+// RevertCanary removes the record of a migration in canary, marked by MarkCanary, once it was undone in its slices,
+// so it is pending again. If the migration is not in canary, it returns an ErrInvalidPhase.
+func (t *Target) RevertCanary(ctx context.Context, id string) (err error) {
+	defer t.observe(ctx, operationRevertCanary, t.clock.Now(), &err)
+	defer wrapError(operationRevertCanary, t.tableName, &err)
+
+	expr, err := expression.NewBuilder().
+		WithCondition(t.withWriteCondition(expression.Name(attributePhase).Equal(expression.Value(phaseCanary)))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the revert expression: %w", err)
+	}
+	output, err := t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 &t.tableName,
+		Key:                       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllOld,
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	})
+	var conditionalCheckFailedException *types.ConditionalCheckFailedException
+	switch {
+	case errors.As(err, &conditionalCheckFailedException):
+		return fmt.Errorf("%w: %s", ErrInvalidPhase, id)
+	case err != nil:
+		return fmt.Errorf("failed to revert migration: %w", err)
+	}
+	t.capacity.record(operationRevertCanary, output.ConsumedCapacity)
+
+	t.audit(ctx, operationRevertCanary, id, output.Attributes, nil)
+	t.logger.Log(ctx, t.eventLogLevel, "migration reverted", "id", id)
+	return nil
+}
+
+// updateCanary updates the canary state of the migration, if the condition holds, returning the migration as it was
+// before.
+func (t *Target) updateCanary(ctx context.Context, operation, id string, update expression.UpdateBuilder, condition expression.ConditionBuilder) (map[string]types.AttributeValue, error) {
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(t.withWriteCondition(condition)).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the update expression: %w", err)
+	}
+	output, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &t.tableName,
+		Key:                       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllOld,
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	})
+	var conditionalCheckFailedException *types.ConditionalCheckFailedException
+	switch {
+	case errors.As(err, &conditionalCheckFailedException):
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPhase, id)
+	case err != nil:
+		return nil, fmt.Errorf("failed to update the canary of the migration: %w", err)
+	}
+	t.capacity.record(operation, output.ConsumedCapacity)
+	return output.Attributes, nil
+}
+
+// canaryOf returns the canary slices of a migration in the phase, or nil if it is not in canary, as the slices are
+// left behind when it is started again.
+func canaryOf(phase string, canarySlices []string) []string {
+	if phase != phaseCanary {
+		return nil
+	}
+	return canarySlices
+}
+
+// mergeSlices returns the slices of both, sorted, without duplicates.
+func mergeSlices(a, b []string) []string {
+	merged := append(append([]string{}, a...), b...)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
@@ -0,0 +1,65 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Canary", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+	})
+
+	It("should track the slices a migration in canary was applied in until promoted", func() {
+		Expect(target.MarkCanary(ctx, "1", "us-east-1")).To(Succeed())
+		Expect(target.MarkCanary(ctx, "1", "eu-west-1", "us-east-1")).To(Succeed())
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "1", Canary: true}}))
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history[0].CanarySlices).To(ConsistOf("eu-west-1", "us-east-1"))
+		Expect(target.Done(ctx)).To(Equal([]string{"1"}))
+
+		Expect(target.PromoteCanary(ctx, "1")).To(Succeed())
+
+		history, err = target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history[0].CanarySlices).To(BeNil())
+		Expect(history[0].PromotedAt).ToNot(BeZero())
+		Expect(target.PromoteCanary(ctx, "1")).To(MatchError(ErrInvalidPhase))
+	})
+
+	It("should remove the record of a migration in canary when reverted", func() {
+		Expect(target.MarkCanary(ctx, "1", "us-east-1")).To(Succeed())
+
+		Expect(target.RevertCanary(ctx, "1")).To(Succeed())
+		Expect(target.Done(ctx)).To(BeEmpty())
+	})
+
+	It("should not revert a migration that is not in canary", func() {
+		Expect(target.RevertCanary(ctx, "1")).To(MatchError(ErrInvalidPhase))
+		Expect(target.Done(ctx)).To(Equal([]string{"1"}))
+	})
+
+	It("should not mark a migration that is not finished", func() {
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+
+		Expect(target.MarkCanary(ctx, "1", "us-east-1")).To(MatchError(ErrInvalidPhase))
+	})
+})
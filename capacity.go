@@ -0,0 +1,90 @@
+package migrations_dynamodb
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	operationAdd              = "Add"
+	operationRemove           = "Remove"
+	operationRemoveAfter      = "RemoveAfter"
+	operationFinishMigration  = "FinishMigration"
+	operationStartMigration   = "StartMigration"
+	operationFailMigration    = "FailMigration"
+	operationPrepareMigration = "PrepareMigration"
+	operationCommitMigration  = "CommitMigration"
+	operationMarkCanary       = "MarkCanary"
+	operationPromoteCanary    = "PromoteCanary"
+	operationRevertCanary     = "RevertCanary"
+	operationSetMetadata      = "SetMetadata"
+	operationDone             = "Done"
+	operationDirty            = "Dirty"
+	operationLock             = "Lock"
+	operationLockMany         = "LockMany"
+	operationUnlock           = "Unlock"
+	operationCreate           = "Create"
+	operationDestroy          = "Destroy"
+	operationPing             = "Ping"
+	operationCurrent          = "Current"
+	operationStatus           = "Status"
+	operationExport           = "Export"
+	operationImport           = "Import"
+	operationImportGolang     = "ImportGolangMigrate"
+	operationImportApplied    = "ImportApplied"
+	operationDiagnose         = "Diagnose"
+	operationPreflight        = "Preflight"
+	operationHistory          = "History"
+	operationFreeze           = "Freeze"
+	operationUnfreeze         = "Unfreeze"
+	operationApproval         = "AwaitApproval"
+	operationApprove          = "Approve"
+	operationAudit            = "Audit"
+	// the repairs of the dirty migrations made by Lock, with the stale dirty and the startup repair policies.
+	operationRepairMarkFinished = "RepairMarkFinished"
+	operationRepairRemove       = "RepairRemove"
+)
+
+// CapacityStats holds the capacity units consumed by a Target.
+type CapacityStats struct {
+	// Total is the sum of the capacity units consumed by all operations.
+	Total float64
+	// ByOperation holds the capacity units consumed by each operation of the Target (Add, Remove, FinishMigration,
+	// StartMigration, Done, Lock and Unlock).
+	ByOperation map[string]float64
+}
+
+type capacityRecorder struct {
+	mu          sync.Mutex
+	byOperation map[string]float64
+}
+
+func newCapacityRecorder() *capacityRecorder {
+	return &capacityRecorder{
+		byOperation: make(map[string]float64),
+	}
+}
+
+func (r *capacityRecorder) record(operation string, capacity *types.ConsumedCapacity) {
+	if capacity == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byOperation[operation] += aws.ToFloat64(capacity.CapacityUnits)
+}
+
+func (r *capacityRecorder) stats() CapacityStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := CapacityStats{
+		ByOperation: make(map[string]float64, len(r.byOperation)),
+	}
+	for operation, units := range r.byOperation {
+		s.ByOperation[operation] = units
+		s.Total += units
+	}
+	return s
+}
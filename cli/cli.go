@@ -0,0 +1,197 @@
+// Package cli implements the migrations-dynamodb command line, wiring the DynamoDB Target with the migrations/v2
+// runner.
+//
+// The cmd/migrations-dynamodb binary uses it without migrations of its own: the commands that run migrations load them
+// from the items of a DynamoDB table, with the --source-table flag (see the ddbsource package). To run the migrations
+// defined in code, build a binary embedding their source:
+//
+//	func main() {
+//		cobra.CheckErr(cli.NewCommand(cli.WithSource(source)).Execute())
+//	}
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jamillosantos/migrations/v2"
+	"github.com/spf13/cobra"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+	"github.com/jamillosantos/migrations-dynamodb/ddbsource"
+)
+
+// ErrNoSource is returned by the commands that run migrations when the command was created without WithSource, and
+// the --source-table flag is not given.
+var ErrNoSource = errors.New("no migrations source: set --source-table, or build a binary embedding cli.NewCommand(cli.WithSource(...)), to run migrations")
+
+// Target is the state the commands operate on, implemented by *migrations_dynamodb.Target.
+type Target interface {
+	migrations.Target
+	Status(ctx context.Context) (migrations_dynamodb.Status, error)
+	Export(ctx context.Context) (migrations_dynamodb.Snapshot, error)
+	Import(ctx context.Context, snapshot migrations_dynamodb.Snapshot) error
+	ImportFrom(ctx context.Context, r io.Reader, mode migrations_dynamodb.ImportMode) (migrations_dynamodb.ImportResult, error)
+	ImportGolangMigrate(ctx context.Context, tableName string, ids []string) ([]string, error)
+	ImportApplied(ctx context.Context, r io.Reader, format migrations_dynamodb.AppliedFormat, column string) ([]string, error)
+	Diagnose(ctx context.Context) ([]migrations_dynamodb.Finding, error)
+	History(ctx context.Context) ([]migrations_dynamodb.MigrationRecord, error)
+	TableName() string
+}
+
+type opts struct {
+	source        migrations.Source
+	targetOptions []migrations_dynamodb.Option
+	newTarget     func(ctx context.Context, cfg config, targetOptions []migrations_dynamodb.Option) (Target, error)
+	newSource     func(ctx context.Context, cfg config) (migrations.Source, error)
+}
+
+type Option func(*opts)
+
+// WithSource sets the source of the migrations run by the `migrate` and `rollback` commands. The --source-table flag
+// takes precedence over it.
+func WithSource(source migrations.Source) Option {
+	return func(o *opts) {
+		o.source = source
+	}
+}
+
+// WithTargetOptions sets options of the Target created by the commands. The flags given in the command line take
+// precedence over them.
+func WithTargetOptions(targetOptions ...migrations_dynamodb.Option) Option {
+	return func(o *opts) {
+		o.targetOptions = append(o.targetOptions, targetOptions...)
+	}
+}
+
+func defaultOpts() opts {
+	return opts{
+		newTarget: newTarget,
+		newSource: newSource,
+	}
+}
+
+// config holds the global flags of the command line.
+type config struct {
+	tableName     string
+	lockTableName string
+	lockID        string
+	region        string
+	endpointURL   string
+	sourceTable   string
+}
+
+type app struct {
+	opts
+	cfg config
+}
+
+// NewCommand creates the root command of the command line. The AWS configuration (credentials, region, ...) is read
+// from the environment, as the AWS CLI does.
+func NewCommand(opts ...Option) *cobra.Command {
+	a := &app{opts: defaultOpts()}
+	for _, opt := range opts {
+		opt(&a.opts)
+	}
+
+	cmd := &cobra.Command{
+		Use:           "migrations-dynamodb",
+		Short:         "Manages the migrations state stored in the DynamoDB.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if a.cfg.sourceTable == "" {
+				return nil
+			}
+			source, err := a.newSource(cmd.Context(), a.cfg)
+			if err != nil {
+				return err
+			}
+			a.source = source
+			return nil
+		},
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&a.cfg.tableName, "table-name", "", "name of the migrations table (default \"_migrations\")")
+	flags.StringVar(&a.cfg.lockTableName, "lock-table-name", "", "name of the migrations lock table (default \"_migrations-lock\")")
+	flags.StringVar(&a.cfg.lockID, "lock-id", "", "ID of the lock (default \"migrations\")")
+	flags.StringVar(&a.cfg.region, "region", "", "AWS region, overriding the one from the environment")
+	flags.StringVar(&a.cfg.endpointURL, "endpoint-url", "", "DynamoDB endpoint URL (e.g. http://localhost:8000 for DynamoDB local)")
+	flags.StringVar(&a.cfg.sourceTable, "source-table", "", "name of the DynamoDB table the migrations are loaded from, as by the ddbsource package")
+
+	cmd.AddCommand(
+		a.statusCommand(),
+		a.historyCommand(),
+		a.migrateCommand(),
+		a.rollbackCommand(),
+		a.exportCommand(),
+		a.importCommand(),
+		a.doctorCommand(),
+		a.initCommand(),
+		a.destroyCommand(),
+		a.newCommand(),
+	)
+	return cmd
+}
+
+// target creates the Target the commands operate on. The options given are applied after the ones given to
+// NewCommand.
+func (a *app) target(ctx context.Context, targetOptions ...migrations_dynamodb.Option) (Target, error) {
+	return a.newTarget(ctx, a.cfg, append(append([]migrations_dynamodb.Option{}, a.targetOptions...), targetOptions...))
+}
+
+func newTarget(ctx context.Context, cfg config, targetOptions []migrations_dynamodb.Option) (Target, error) {
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return migrations_dynamodb.NewTarget(client, append(targetOptions, cfg.targetOptions()...)...), nil
+}
+
+// newSource creates the source loading the migrations from the items of the table of the --source-table flag. They
+// are applied with the same client, so to the account and region of the Target.
+func newSource(ctx context.Context, cfg config) (migrations.Source, error) {
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ddbsource.NewSource(client, cfg.sourceTable), nil
+}
+
+// newClient creates the DynamoDB client reaching the account and region of the AWS configuration of the environment.
+func newClient(ctx context.Context, cfg config) (*dynamodb.Client, error) {
+	var loadOptions []func(*awsconfig.LoadOptions) error
+	if cfg.region != "" {
+		loadOptions = append(loadOptions, awsconfig.WithRegion(cfg.region))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS config: %w", err)
+	}
+
+	return dynamodb.NewFromConfig(awsConfig, func(o *dynamodb.Options) {
+		if cfg.endpointURL != "" {
+			o.BaseEndpoint = aws.String(cfg.endpointURL)
+		}
+	}), nil
+}
+
+func (cfg config) targetOptions() []migrations_dynamodb.Option {
+	var r []migrations_dynamodb.Option
+	if cfg.tableName != "" {
+		r = append(r, migrations_dynamodb.WithTableName(cfg.tableName))
+	}
+	if cfg.lockTableName != "" {
+		r = append(r, migrations_dynamodb.WithLockTableName(cfg.lockTableName))
+	}
+	if cfg.lockID != "" {
+		r = append(r, migrations_dynamodb.WithLockID(cfg.lockID))
+	}
+	return r
+}
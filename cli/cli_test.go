@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+var _ = Describe("Command", func() {
+	var (
+		ctx    context.Context
+		target *memoryTarget
+		source migrations.Source
+		ran    []string
+		input  string
+	)
+
+	newSource := func(ids ...string) migrations.Source {
+		s := migrations.NewMemorySource()
+		for _, id := range ids {
+			Expect(s.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+				ran = append(ran, "do "+id)
+				return nil
+			}, func(context.Context) error {
+				ran = append(ran, "undo "+id)
+				return nil
+			}))).To(Succeed())
+		}
+		return s
+	}
+
+	execute := func(args ...string) (string, error) {
+		opts := []Option{
+			func(o *opts) {
+				o.newTarget = func(context.Context, config, []migrations_dynamodb.Option) (Target, error) {
+					return target, nil
+				}
+			},
+		}
+		if source != nil {
+			opts = append(opts, WithSource(source))
+		}
+		cmd := NewCommand(opts...)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetIn(strings.NewReader(input))
+		cmd.SetArgs(args)
+		err := cmd.ExecuteContext(ctx)
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		target = newMemoryTarget()
+		source = nil
+		ran = nil
+		input = ""
+	})
+
+	Describe("status", func() {
+		It("should list the migrations recorded and the lock", func() {
+			target.migrations["1"] = false
+			target.migrations["2"] = true
+			target.lockOwner = "runner-1"
+
+			out, err := execute("status")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(MatchRegexp(`1\s+applied`))
+			Expect(out).To(MatchRegexp(`2\s+dirty`))
+			Expect(out).To(ContainSubstring("Lock: held by runner-1"))
+		})
+
+		It("should list the migrations of the source not applied as pending", func() {
+			source = newSource("1", "2")
+			target.migrations["1"] = false
+
+			out, err := execute("status")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(MatchRegexp(`1\s+applied\s+migration 1`))
+			Expect(out).To(MatchRegexp(`2\s+pending\s+migration 2`))
+			Expect(out).To(ContainSubstring("Lock: free"))
+		})
+	})
+
+	Describe("migrate", func() {
+		It("should apply all pending migrations", func() {
+			source = newSource("1", "2", "3")
+			target.migrations["1"] = false
+
+			out, err := execute("migrate")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ran).To(Equal([]string{"do 2", "do 3"}))
+			Expect(target.Done(ctx)).To(Equal([]string{"1", "2", "3"}))
+			Expect(out).To(ContainSubstring("2 migration(s) executed"))
+		})
+
+		It("should apply only the given number of steps", func() {
+			source = newSource("1", "2", "3")
+
+			_, err := execute("migrate", "--steps", "1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ran).To(Equal([]string{"do 1"}))
+		})
+
+		It("should fail when there is no source", func() {
+			_, err := execute("migrate")
+			Expect(err).To(MatchError(ErrNoSource))
+		})
+
+		It("should apply the migrations of the source table", func() {
+			var sourceTable string
+			cmd := NewCommand(func(o *opts) {
+				o.newTarget = func(context.Context, config, []migrations_dynamodb.Option) (Target, error) {
+					return target, nil
+				}
+				o.newSource = func(_ context.Context, cfg config) (migrations.Source, error) {
+					sourceTable = cfg.sourceTable
+					return newSource("1"), nil
+				}
+			})
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetArgs([]string{"migrate", "--source-table", "migrations-source"})
+			Expect(cmd.ExecuteContext(ctx)).To(Succeed())
+			Expect(sourceTable).To(Equal("migrations-source"))
+			Expect(ran).To(Equal([]string{"do 1"}))
+		})
+	})
+
+	Describe("rollback", func() {
+		It("should undo the last migration", func() {
+			source = newSource("1", "2")
+			target.migrations["1"] = false
+			target.migrations["2"] = false
+
+			_, err := execute("rollback")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ran).To(Equal([]string{"undo 2"}))
+			Expect(target.Done(ctx)).To(Equal([]string{"1"}))
+		})
+
+		It("should fail when there is no source", func() {
+			_, err := execute("rollback")
+			Expect(err).To(MatchError(ErrNoSource))
+		})
+	})
+
+	Describe("export and import", func() {
+		It("should restore the exported state", func() {
+			target.migrations["1"] = false
+			target.migrations["2"] = true
+
+			out, err := execute("export", "--format", "json")
+			Expect(err).NotTo(HaveOccurred())
+
+			target = newMemoryTarget()
+			input = out
+			out, err = execute("import")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(target.migrations).To(Equal(map[string]bool{"1": false, "2": true}))
+			Expect(target.lockOwner).To(BeEmpty())
+			Expect(out).To(ContainSubstring("2 migration(s) imported"))
+		})
+
+		It("should merge the exported state, listing the conflicts", func() {
+			target.migrations["1"] = false
+			target.migrations["2"] = false
+
+			out, err := execute("export")
+			Expect(err).NotTo(HaveOccurred())
+
+			target = newMemoryTarget()
+			target.migrations["2"] = true
+			target.migrations["3"] = false
+			input = out
+			out, err = execute("import", "--mode", "merge")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(target.migrations).To(Equal(map[string]bool{"1": false, "2": true, "3": false}))
+			Expect(out).To(ContainSubstring("conflict: migration 2 is recorded differently"))
+			Expect(out).To(ContainSubstring("1 migration(s) imported, 0 removed, 1 conflict(s)"))
+		})
+
+		It("should record the migrations up to the version of golang-migrate", func() {
+			source = newSource("1", "2", "3")
+			target.golangMigrate = map[string]int{"schema_migrations": 2}
+
+			out, err := execute("import", "--from-golang-migrate", "schema_migrations")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target.migrations).To(Equal(map[string]bool{"1": false, "2": false}))
+			Expect(target.lockOwner).To(BeEmpty())
+			Expect(ran).To(BeEmpty())
+			Expect(out).To(ContainSubstring("2 migration(s) imported"))
+		})
+
+		It("should record the migrations listed by a SQL dump", func() {
+			target.migrations["1"] = false
+			input = "1\n2\n3\n"
+
+			out, err := execute("import", "--from-sql", "csv", "--column", "name")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target.migrations).To(Equal(map[string]bool{"1": false, "2": false, "3": false}))
+			Expect(target.lockOwner).To(BeEmpty())
+			Expect(out).To(ContainSubstring("2 migration(s) imported"))
+		})
+
+		It("should fail to import from golang-migrate when there is no source", func() {
+			_, err := execute("import", "--from-golang-migrate", "schema_migrations")
+			Expect(err).To(MatchError(ErrNoSource))
+		})
+
+		It("should fail with an unsupported format", func() {
+			_, err := execute("export", "--format", "csv")
+			Expect(err).To(MatchError(ContainSubstring("unsupported format")))
+		})
+	})
+
+	Describe("doctor", func() {
+		It("should succeed when no problem is found", func() {
+			out, err := execute("doctor")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("no problems found"))
+		})
+
+		It("should print the findings and fail when there are errors", func() {
+			target.migrations["1"] = true
+
+			out, err := execute("doctor")
+			Expect(err).To(MatchError("1 problem(s) found"))
+			Expect(out).To(ContainSubstring("[ERROR] dirty _migrations: migration 1 is dirty"))
+			Expect(out).To(ContainSubstring("fix: fix it"))
+		})
+	})
+
+	Describe("init", func() {
+		It("should create the tables", func() {
+			out, err := execute("init", "--billing-mode", "pay-per-request", "--tag", "team=platform", "--wait=false")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target.created).To(BeTrue())
+			Expect(out).To(ContainSubstring("tables created"))
+		})
+
+		It("should validate the tables with --validate-only", func() {
+			out, err := execute("init", "--validate-only")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("tables validated"))
+		})
+
+		It("should fail with an invalid billing mode", func() {
+			_, err := execute("init", "--billing-mode", "free")
+			Expect(err).To(MatchError("invalid billing mode: free"))
+			Expect(target.created).To(BeFalse())
+		})
+
+		It("should fail with an invalid tag", func() {
+			_, err := execute("init", "--tag", "team")
+			Expect(err).To(MatchError(ContainSubstring("expected key=value")))
+			Expect(target.created).To(BeFalse())
+		})
+	})
+
+	Describe("destroy", func() {
+		BeforeEach(func() {
+			target.created = true
+		})
+
+		It("should destroy the tables when the table name is typed", func() {
+			input = "_migrations\n"
+
+			out, err := execute("destroy")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target.created).To(BeFalse())
+			Expect(out).To(ContainSubstring("tables destroyed"))
+		})
+
+		It("should not destroy the tables when the typed name does not match", func() {
+			input = "prod\n"
+
+			_, err := execute("destroy")
+			Expect(err).To(MatchError(ContainSubstring("destroy canceled")))
+			Expect(target.created).To(BeTrue())
+		})
+
+		It("should not ask for confirmation with --yes", func() {
+			_, err := execute("destroy", "--yes")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(target.created).To(BeFalse())
+		})
+	})
+
+	Describe("history", func() {
+		BeforeEach(func() {
+			startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+			target.history = []migrations_dynamodb.MigrationRecord{
+				{ID: "1", StartedAt: startedAt, AppliedAt: startedAt.Add(1500 * time.Millisecond), Duration: 1500 * time.Millisecond, AppliedBy: "ci@runner"},
+				{ID: "2", Dirty: true, StartedAt: startedAt.Add(time.Minute)},
+			}
+		})
+
+		It("should print the history as a table", func() {
+			out, err := execute("history")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(MatchRegexp(`1\s+applied\s+2024-05-01T10:00:01Z\s+1.5s\s+ci@runner`))
+			Expect(out).To(MatchRegexp(`2\s+dirty\s+-\s+-\s+-`))
+		})
+
+		It("should print the history as JSON", func() {
+			out, err := execute("history", "--format", "json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(MatchJSON(`[
+				{"id": "1", "dirty": false, "startedAt": "2024-05-01T10:00:00Z", "appliedAt": "2024-05-01T10:00:01Z", "durationMs": 1500, "appliedBy": "ci@runner"},
+				{"id": "2", "dirty": true, "startedAt": "2024-05-01T10:01:00Z"}
+			]`))
+		})
+
+		It("should print the history in the order the migrations were applied", func() {
+			appliedAt := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+			target.history = []migrations_dynamodb.MigrationRecord{
+				{ID: "1", AppliedAt: appliedAt, AppliedSeq: 1},
+				{ID: "2", AppliedAt: appliedAt, AppliedSeq: 3},
+				{ID: "3", AppliedAt: appliedAt, AppliedSeq: 2},
+			}
+
+			out, err := execute("history", "--order", "applied", "--format", "json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(MatchJSON(`[
+				{"id": "1", "dirty": false, "appliedAt": "2024-05-02T10:00:00Z", "appliedSeq": 1},
+				{"id": "3", "dirty": false, "appliedAt": "2024-05-02T10:00:00Z", "appliedSeq": 2},
+				{"id": "2", "dirty": false, "appliedAt": "2024-05-02T10:00:00Z", "appliedSeq": 3}
+			]`))
+		})
+	})
+
+	Describe("new", func() {
+		It("should create the migration file after the applied migrations", func() {
+			dir := filepath.Join(GinkgoT().TempDir(), "migrations")
+			target.history = []migrations_dynamodb.MigrationRecord{{ID: "0001"}, {ID: "0002"}}
+
+			out, err := execute("new", "add orders index", "--dir", dir, "--template", "gsi", "--id-format", "sequence", "--from-history")
+			Expect(err).NotTo(HaveOccurred())
+			path := filepath.Join(dir, "0003_add_orders_index.go")
+			Expect(out).To(Equal("created " + path + "\n"))
+			Expect(os.ReadFile(path)).To(ContainSubstring("helpers.EnsureGSI"))
+		})
+
+		It("should refuse an unknown ID format", func() {
+			_, err := execute("new", "add orders index", "--dir", GinkgoT().TempDir(), "--id-format", "uuid")
+			Expect(err).To(MatchError(ContainSubstring("unsupported ID format")))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+func (a *app) doctorCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Runs preflight checks before running the migrations.",
+		Long: `Checks that the tables exist with the expected schema, their billing mode, the TTL configuration of the lock
+table, that the credentials are allowed to read and write the tables, that no migration is dirty and that the lock is
+not held. It fails when any problem that would make the migrations fail is found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			target, err := a.target(ctx)
+			if err != nil {
+				return err
+			}
+
+			findings, err := target.Diagnose(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to run the checks: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(findings) == 0 {
+				_, _ = fmt.Fprintln(out, "no problems found")
+				return nil
+			}
+
+			errs := 0
+			for _, finding := range findings {
+				if finding.Severity == migrations_dynamodb.SeverityError {
+					errs++
+				}
+				location := finding.Check
+				if finding.Table != "" {
+					location += " " + finding.Table
+				}
+				_, _ = fmt.Fprintf(out, "[%s] %s: %s\n", strings.ToUpper(string(finding.Severity)), location, finding.Message)
+				if finding.Fix != "" {
+					_, _ = fmt.Fprintf(out, "    fix: %s\n", finding.Fix)
+				}
+			}
+			if errs > 0 {
+				return fmt.Errorf("%d problem(s) found", errs)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+const formatJSON = "json"
+
+func (a *app) exportCommand() *cobra.Command {
+	var format, output string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Dumps the full state of the migrations table.",
+		Long: `Dumps every item of the migrations table, with all their attributes, so the state can be copied to another
+account, attached to incident reports or restored with the import command.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format != formatJSON {
+				return fmt.Errorf("unsupported format: %s", format)
+			}
+			ctx := cmd.Context()
+
+			target, err := a.target(ctx)
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := target.Export(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to export the migrations: %w", err)
+			}
+
+			w := cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create the output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(snapshot); err != nil {
+				return fmt.Errorf("failed to write the snapshot: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", formatJSON, "format of the output (only json is supported)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file the state is written to (default stdout)")
+	return cmd
+}
+
+func (a *app) importCommand() *cobra.Command {
+	var golangMigrateTable, sqlFormat, sqlColumn, mode string
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Restores the state dumped by the export command.",
+		Long: `Writes the items dumped by the export command to the migrations table, replacing the migrations with the same
+ID. The snapshot is read from the given file, or from stdin when no file (or "-") is given. The migrations lock is held
+while importing.
+
+With --from-golang-migrate, the version recorded by golang-migrate in the given table is converted instead: the
+migrations of the source up to the version are recorded as applied.
+
+With --from-sql, the file is a dump of the migrations table of a SQL database instead, in csv or json, and the
+migrations listed are recorded as applied. The IDs are read from the column set by --column.
+
+With --mode, the snapshot is restored in merge mode, keeping the migrations recorded differently and listing them as
+conflicts, or in replace mode, also removing the migrations not in the snapshot.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx := cmd.Context()
+
+			if golangMigrateTable != "" {
+				return a.importGolangMigrate(cmd, golangMigrateTable)
+			}
+
+			r := cmd.InOrStdin()
+			if len(args) == 1 && args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open the snapshot: %w", err)
+				}
+				defer f.Close()
+				r = f
+			}
+
+			if sqlFormat != "" {
+				return a.importApplied(cmd, r, migrations_dynamodb.AppliedFormat(sqlFormat), sqlColumn)
+			}
+			if mode != "" {
+				return a.importFrom(cmd, r, migrations_dynamodb.ImportMode(mode))
+			}
+
+			snapshot, err := readSnapshot(r)
+			if err != nil {
+				return err
+			}
+
+			target, err := a.target(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = withLock(ctx, target, func() error {
+				return target.Import(ctx, snapshot)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to import the migrations: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%d migration(s) imported\n", len(snapshot.Items))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&golangMigrateTable, "from-golang-migrate", "", "table of golang-migrate to convert the version of, instead of reading a snapshot")
+	cmd.Flags().StringVar(&sqlFormat, "from-sql", "", "format (csv or json) of the dump of a SQL migrations table read instead of a snapshot")
+	cmd.Flags().StringVar(&sqlColumn, "column", "version", "column of the SQL dump with the migration IDs")
+	cmd.Flags().StringVar(&mode, "mode", "", "restore the snapshot in merge or replace mode (default overwrite the migrations of the snapshot)")
+	return cmd
+}
+
+// importFrom restores the snapshot read from r with the mode, listing the conflicts found.
+func (a *app) importFrom(cmd *cobra.Command, r io.Reader, mode migrations_dynamodb.ImportMode) error {
+	ctx := cmd.Context()
+
+	target, err := a.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	var result migrations_dynamodb.ImportResult
+	err = withLock(ctx, target, func() (err error) {
+		result, err = target.ImportFrom(ctx, r, mode)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import the migrations: %w", err)
+	}
+	w := cmd.OutOrStdout()
+	for _, conflict := range result.Conflicts {
+		_, _ = fmt.Fprintf(w, "conflict: migration %s is recorded differently, kept as it is\n", conflict.ID)
+	}
+	_, _ = fmt.Fprintf(w, "%d migration(s) imported, %d removed, %d conflict(s)\n", len(result.Imported), len(result.Removed), len(result.Conflicts))
+	return nil
+}
+
+// importGolangMigrate records the migrations of the source up to the version recorded by golang-migrate in the table.
+func (a *app) importGolangMigrate(cmd *cobra.Command, tableName string) error {
+	if a.source == nil {
+		return ErrNoSource
+	}
+	ctx := cmd.Context()
+
+	list, err := a.sourceMigrations(cmd)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(list))
+	for _, migration := range list {
+		ids = append(ids, migration.ID())
+	}
+
+	target, err := a.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	var added []string
+	err = withLock(ctx, target, func() (err error) {
+		added, err = target.ImportGolangMigrate(ctx, tableName, ids)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import the golang-migrate version: %w", err)
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%d migration(s) imported\n", len(added))
+	return nil
+}
+
+// importApplied records the migrations listed by the dump of a SQL migrations table as applied.
+func (a *app) importApplied(cmd *cobra.Command, r io.Reader, format migrations_dynamodb.AppliedFormat, column string) error {
+	ctx := cmd.Context()
+
+	target, err := a.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	var added []string
+	err = withLock(ctx, target, func() (err error) {
+		added, err = target.ImportApplied(ctx, r, format, column)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import the applied migrations: %w", err)
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%d migration(s) imported\n", len(added))
+	return nil
+}
+
+// withLock runs fn holding the migrations lock.
+func withLock(ctx context.Context, target Target, fn func() error) (err error) {
+	unlocker, err := target.Lock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to lock the migrations: %w", err)
+	}
+	defer func() {
+		if unlockErr := unlocker.Unlock(ctx); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to unlock the migrations: %w", unlockErr)
+		}
+	}()
+
+	return fn()
+}
+
+func readSnapshot(r io.Reader) (migrations_dynamodb.Snapshot, error) {
+	var snapshot migrations_dynamodb.Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return migrations_dynamodb.Snapshot{}, fmt.Errorf("failed to read the snapshot: %w", err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+const (
+	formatTable = "table"
+
+	orderID      = "id"
+	orderApplied = "applied"
+)
+
+// historyEntry is the JSON representation of a migrations_dynamodb.MigrationRecord, leaving out what is unknown.
+type historyEntry struct {
+	ID            string `json:"id"`
+	Dirty         bool   `json:"dirty"`
+	StartedAt     string `json:"startedAt,omitempty"`
+	AppliedAt     string `json:"appliedAt,omitempty"`
+	AppliedSeq    int64  `json:"appliedSeq,omitempty"`
+	DurationMs    int64  `json:"durationMs,omitempty"`
+	AppliedBy     string `json:"appliedBy,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+func (a *app) historyCommand() *cobra.Command {
+	var format, order string
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Lists when and by whom each migration was applied.",
+		Long: `Lists when and by whom each migration was applied, sorted by ID. With --order applied, the migrations are
+sorted in the order they were applied instead, showing the ones applied out of order, as hotfixes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if format != formatTable && format != formatJSON {
+				return fmt.Errorf("unsupported format: %s", format)
+			}
+			if order != orderID && order != orderApplied {
+				return fmt.Errorf("unsupported order: %s", order)
+			}
+			ctx := cmd.Context()
+
+			target, err := a.target(ctx)
+			if err != nil {
+				return err
+			}
+
+			records, err := target.History(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get the history: %w", err)
+			}
+			if order == orderApplied {
+				migrations_dynamodb.SortByApplyOrder(records)
+			}
+
+			if format == formatJSON {
+				entries := make([]historyEntry, 0, len(records))
+				for _, record := range records {
+					entries = append(entries, historyEntry{
+						ID:            record.ID,
+						Dirty:         record.Dirty,
+						StartedAt:     formatTime(record.StartedAt),
+						AppliedAt:     formatTime(record.AppliedAt),
+						AppliedSeq:    record.AppliedSeq,
+						DurationMs:    record.Duration.Milliseconds(),
+						AppliedBy:     record.AppliedBy,
+						CorrelationID: record.CorrelationID,
+					})
+				}
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(entries)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tSTATE\tAPPLIED AT\tDURATION\tAPPLIED BY")
+			for _, record := range records {
+				state := stateApplied
+				if record.Dirty {
+					state = stateDirty
+				}
+				duration := "-"
+				if record.Duration > 0 {
+					duration = record.Duration.Round(time.Millisecond).String()
+				}
+				appliedAt := formatTime(record.AppliedAt)
+				if appliedAt == "" {
+					appliedAt = "-"
+				}
+				appliedBy := record.AppliedBy
+				if appliedBy == "" {
+					appliedBy = "-"
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", record.ID, state, appliedAt, duration, appliedBy)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", formatTable, "format of the output: table or json")
+	cmd.Flags().StringVar(&order, "order", orderID, "order of the migrations: id or applied")
+	return cmd
+}
+
+// formatTime formats the time in RFC 3339, or returns an empty string if it is zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
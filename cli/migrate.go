@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jamillosantos/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) migrateCommand() *cobra.Command {
+	var steps int
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Applies the pending migrations.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			planner := migrations.MigratePlanner
+			if steps > 0 {
+				planner = limitPlanner(migrations.MigratePlanner, steps)
+			}
+			return a.run(cmd, planner)
+		},
+	}
+	cmd.Flags().IntVar(&steps, "steps", 0, "number of migrations to apply (default all pending)")
+	return cmd
+}
+
+func (a *app) rollbackCommand() *cobra.Command {
+	var steps int
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Undoes the last applied migrations.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if steps <= 0 {
+				return fmt.Errorf("invalid number of steps: %d", steps)
+			}
+			return a.run(cmd, migrations.StepPlanner(-steps))
+		},
+	}
+	cmd.Flags().IntVar(&steps, "steps", 1, "number of migrations to undo")
+	return cmd
+}
+
+func (a *app) run(cmd *cobra.Command, planner migrations.ActionPLanner) error {
+	if a.source == nil {
+		return ErrNoSource
+	}
+	ctx := cmd.Context()
+
+	target, err := a.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	response, err := migrations.Migrate(ctx, a.source, target,
+		migrations.WithPlanner(planner),
+		migrations.WithRunnerOptions(migrations.WithReporter(&reporter{w: cmd.OutOrStdout()})),
+	)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%d migration(s) executed\n", len(response.Successful))
+	return nil
+}
+
+// limitPlanner limits the plan of the given planner to its first steps actions. It is used instead of
+// migrations.StepPlanner for applying migrations, as the latter fails when no migration was applied yet.
+func limitPlanner(planner migrations.ActionPLanner, steps int) migrations.ActionPLanner {
+	return func(source migrations.Source, target migrations.Target) migrations.Planner {
+		return limitedPlanner{next: planner(source, target), steps: steps}
+	}
+}
+
+type limitedPlanner struct {
+	next  migrations.Planner
+	steps int
+}
+
+func (p limitedPlanner) Plan(ctx context.Context) (migrations.Plan, error) {
+	plan, err := p.next.Plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan) > p.steps {
+		plan = plan[:p.steps]
+	}
+	return plan, nil
+}
+
+// reporter prints the progress of the runner.
+type reporter struct {
+	w io.Writer
+}
+
+func (r *reporter) BeforeExecute(_ context.Context, info *migrations.BeforeExecuteInfo) {
+	if len(info.Plan) == 0 {
+		_, _ = fmt.Fprintln(r.w, "nothing to execute")
+	}
+}
+
+func (r *reporter) BeforeExecuteMigration(_ context.Context, info *migrations.BeforeExecuteMigrationInfo) {
+	_, _ = fmt.Fprintf(r.w, "%s %s %s... ", info.ActionType, info.Migration.ID(), info.Migration.Description())
+}
+
+func (r *reporter) AfterExecuteMigration(_ context.Context, info *migrations.AfterExecuteMigrationInfo) {
+	if info.Err != nil {
+		_, _ = fmt.Fprintf(r.w, "failed: %s\n", info.Err)
+		return
+	}
+	_, _ = fmt.Fprintln(r.w, "done")
+}
+
+func (r *reporter) AfterExecute(context.Context, *migrations.AfterExecuteInfo) {}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+	"github.com/jamillosantos/migrations-dynamodb/scaffold"
+)
+
+const (
+	idFormatTimestamp = "timestamp"
+	idFormatULID      = "ulid"
+	idFormatSequence  = "sequence"
+)
+
+func (a *app) newCommand() *cobra.Command {
+	var (
+		dir, template, idFormat, packageName string
+		sequenceWidth                        int
+		fromHistory                          bool
+	)
+	templates := make([]string, 0, len(scaffold.Templates))
+	for _, t := range scaffold.Templates {
+		templates = append(templates, string(t))
+	}
+	cmd := &cobra.Command{
+		Use:   "new <name>",
+		Short: "Creates the file of a new Go migration.",
+		Long: `Creates the file of a new Go migration in the migrations directory, named by a new ID and the name given, from
+a template using the steps of the helpers package. The ID sorts after the ones of the migration files in the directory
+and, with --from-history, after the ones applied to the DynamoDB, so it must be in the format of the existing IDs.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var generator migrations_dynamodb.IDGenerator
+			switch idFormat {
+			case idFormatTimestamp:
+				generator = migrations_dynamodb.TimestampIDs(migrations_dynamodb.SystemClock)
+			case idFormatULID:
+				generator = migrations_dynamodb.ULIDs(migrations_dynamodb.SystemClock)
+			case idFormatSequence:
+				generator = migrations_dynamodb.SequenceIDs(sequenceWidth)
+			default:
+				return fmt.Errorf("unsupported ID format: %s", idFormat)
+			}
+			options := []scaffold.Option{
+				scaffold.WithTemplate(scaffold.Template(template)),
+				scaffold.WithIDGenerator(generator),
+			}
+			if packageName != "" {
+				options = append(options, scaffold.WithPackageName(packageName))
+			}
+			if fromHistory {
+				ctx := cmd.Context()
+
+				target, err := a.target(ctx)
+				if err != nil {
+					return err
+				}
+				records, err := target.History(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to read the history: %w", err)
+				}
+				for _, record := range records {
+					options = append(options, scaffold.WithExistingIDs(record.ID))
+				}
+			}
+
+			path, err := scaffold.Create(dir, args[0], options...)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "created %s\n", path)
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&dir, "dir", "migrations", "directory of the migrations")
+	flags.StringVar(&template, "template", string(scaffold.TemplateEmpty), "template of the migration: "+strings.Join(templates, ", "))
+	flags.StringVar(&idFormat, "id-format", idFormatTimestamp, "format of the ID: timestamp, ulid or sequence")
+	flags.IntVar(&sequenceWidth, "sequence-width", 4, "number of digits of the IDs of the sequence format")
+	flags.StringVar(&packageName, "package", "", "package of the migration (default the name of the directory)")
+	flags.BoolVar(&fromHistory, "from-history", false, "generate the ID after the ones applied to the DynamoDB too")
+	return cmd
+}
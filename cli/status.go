@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jamillosantos/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+const (
+	stateApplied = "applied"
+	stateDirty   = "dirty"
+	stateStale   = "dirty (stale)"
+	statePending = "pending"
+)
+
+func (a *app) statusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Lists the migrations and their state, and who holds the lock.",
+		Long: `Lists the migrations recorded in the migrations table as applied or dirty. When the command was built with a
+source, the migrations not applied yet are listed as pending.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			target, err := a.target(ctx)
+			if err != nil {
+				return err
+			}
+
+			status, err := target.Status(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get the status: %w", err)
+			}
+
+			type row struct {
+				id, state, description string
+			}
+			rows := make([]row, 0, len(status.Migrations))
+			recorded := make(map[string]struct{}, len(status.Migrations))
+			for _, m := range status.Migrations {
+				state := stateApplied
+				switch {
+				case m.Stale:
+					state = stateStale
+				case m.Dirty:
+					state = stateDirty
+				}
+				rows = append(rows, row{id: m.ID, state: state})
+				recorded[m.ID] = struct{}{}
+			}
+
+			if a.source != nil {
+				list, err := a.sourceMigrations(cmd)
+				if err != nil {
+					return err
+				}
+				descriptions := make(map[string]string, len(list))
+				for _, m := range list {
+					descriptions[m.ID()] = m.Description()
+					if _, ok := recorded[m.ID()]; !ok {
+						rows = append(rows, row{id: m.ID(), state: statePending})
+					}
+				}
+				for i := range rows {
+					rows[i].description = descriptions[rows[i].id]
+				}
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tSTATE\tDESCRIPTION")
+			for _, r := range rows {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", r.id, r.state, r.description)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if status.Lock.Held {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nLock: held by %s\n", status.Lock.Owner)
+			} else {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "\nLock: free")
+			}
+			return nil
+		},
+	}
+}
+
+// sourceMigrations lists the migrations of the source. A source without migrations is not an error for the commands.
+func (a *app) sourceMigrations(cmd *cobra.Command) ([]migrations.Migration, error) {
+	repo, err := a.source.Load(cmd.Context())
+	if errors.Is(err, migrations.ErrNoMigrationsAvailable) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the migrations: %w", err)
+	}
+	return repo.List(cmd.Context())
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/cobra"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+func (a *app) initCommand() *cobra.Command {
+	var (
+		wait         bool
+		billingMode  string
+		tags         []string
+		validateOnly bool
+	)
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Creates the migrations tables.",
+		Long: `Creates the migrations table and the migrations lock table (and the audit table, if configured). Tables that
+already exist are kept as they are.
+
+With --validate-only, for tables provisioned by other means (e.g. Terraform), no table is created: the existing tables
+are validated against the billing mode given instead, and the mismatches are reported.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			mode, err := parseBillingMode(billingMode)
+			if err != nil {
+				return err
+			}
+			tableTags, err := parseTags(tags)
+			if err != nil {
+				return err
+			}
+
+			targetOptions := []migrations_dynamodb.Option{migrations_dynamodb.WithTableWait(wait)}
+			if mode != "" {
+				targetOptions = append(targetOptions, migrations_dynamodb.WithBillingMode(mode))
+			}
+			if len(tableTags) > 0 {
+				targetOptions = append(targetOptions, migrations_dynamodb.WithTableTags(tableTags))
+			}
+			if validateOnly {
+				targetOptions = append(targetOptions, migrations_dynamodb.WithValidateOnly())
+			}
+			target, err := a.target(ctx, targetOptions...)
+			if err != nil {
+				return err
+			}
+
+			if validateOnly {
+				if err := target.Create(ctx); err != nil {
+					return fmt.Errorf("failed to validate the tables: %w", err)
+				}
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "tables validated")
+				return nil
+			}
+			if err := target.Create(ctx); err != nil {
+				return fmt.Errorf("failed to create the tables: %w", err)
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "tables created")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", true, "wait for the tables to be active")
+	cmd.Flags().StringVar(&billingMode, "billing-mode", "", "billing mode of the tables: provisioned or pay-per-request (default provisioned)")
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "tag of the tables, as key=value (can be repeated)")
+	cmd.Flags().BoolVar(&validateOnly, "validate-only", false, "validate the existing tables instead of creating them")
+	return cmd
+}
+
+func (a *app) destroyCommand() *cobra.Command {
+	var (
+		wait bool
+		yes  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "destroy",
+		Short: "Deletes the migrations tables.",
+		Long: `Deletes the migrations table and the migrations lock table, losing the record of the migrations applied. The
+name of the migrations table must be typed to confirm, unless --yes is given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			target, err := a.target(ctx, migrations_dynamodb.WithTableWait(wait))
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "This deletes the record of the migrations applied. Type the name of the migrations table (%s) to confirm: ", target.TableName())
+				answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && answer == "" {
+					return fmt.Errorf("failed to read the confirmation: %w", err)
+				}
+				if strings.TrimSpace(answer) != target.TableName() {
+					return fmt.Errorf("destroy canceled: the table name typed does not match %s", target.TableName())
+				}
+			}
+
+			if err := target.Destroy(ctx); err != nil {
+				return fmt.Errorf("failed to destroy the tables: %w", err)
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "tables destroyed")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for the tables to be deleted")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation")
+	return cmd
+}
+
+func parseBillingMode(mode string) (types.BillingMode, error) {
+	switch strings.ToLower(strings.ReplaceAll(mode, "_", "-")) {
+	case "":
+		return "", nil
+	case "provisioned":
+		return types.BillingModeProvisioned, nil
+	case "pay-per-request", "on-demand":
+		return types.BillingModePayPerRequest, nil
+	}
+	return "", fmt.Errorf("invalid billing mode: %s", mode)
+}
+
+func parseTags(tags []string) (map[string]string, error) {
+	r := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", tag)
+		}
+		r[key] = value
+	}
+	return r, nil
+}
@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamillosantos/migrations/v2"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// memoryTarget is a Target keeping the state in memory, so the commands can be tested without a DynamoDB.
+type memoryTarget struct {
+	migrations map[string]bool // the migrations recorded, and whether they are dirty.
+	// golangMigrate are the versions recorded by golang-migrate, by table.
+	golangMigrate map[string]int
+	lockOwner     string
+	created       bool
+	history       []migrations_dynamodb.MigrationRecord
+}
+
+func newMemoryTarget() *memoryTarget {
+	return &memoryTarget{migrations: map[string]bool{}}
+}
+
+func (t *memoryTarget) Current(ctx context.Context) (string, error) {
+	done, err := t.Done(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(done) == 0 {
+		return "", migrations.ErrNoCurrentMigration
+	}
+	return done[len(done)-1], nil
+}
+
+func (t *memoryTarget) Create(context.Context) error {
+	t.created = true
+	return nil
+}
+
+func (t *memoryTarget) Destroy(context.Context) error {
+	t.migrations = map[string]bool{}
+	t.created = false
+	return nil
+}
+
+func (t *memoryTarget) TableName() string {
+	return "_migrations"
+}
+
+func (t *memoryTarget) Done(context.Context) ([]string, error) {
+	r := make([]string, 0, len(t.migrations))
+	for id, dirty := range t.migrations {
+		if dirty {
+			return nil, migrations.ErrDirtyMigration
+		}
+		r = append(r, id)
+	}
+	sort.Strings(r)
+	return r, nil
+}
+
+func (t *memoryTarget) Add(_ context.Context, id string) error {
+	if _, ok := t.migrations[id]; ok {
+		return migrations.ErrMigrationAlreadyExists
+	}
+	t.migrations[id] = true
+	return nil
+}
+
+func (t *memoryTarget) Remove(_ context.Context, id string) error {
+	if _, ok := t.migrations[id]; !ok {
+		return migrations.ErrMigrationNotFound
+	}
+	delete(t.migrations, id)
+	return nil
+}
+
+func (t *memoryTarget) FinishMigration(_ context.Context, id string) error {
+	t.migrations[id] = false
+	return nil
+}
+
+func (t *memoryTarget) StartMigration(_ context.Context, id string) error {
+	t.migrations[id] = true
+	return nil
+}
+
+func (t *memoryTarget) Lock(context.Context) (migrations.Unlocker, error) {
+	t.lockOwner = "memory"
+	return t, nil
+}
+
+func (t *memoryTarget) Unlock(context.Context) error {
+	t.lockOwner = ""
+	return nil
+}
+
+func (t *memoryTarget) Status(context.Context) (migrations_dynamodb.Status, error) {
+	var status migrations_dynamodb.Status
+	for id, dirty := range t.migrations {
+		status.Migrations = append(status.Migrations, migrations_dynamodb.MigrationStatus{ID: id, Dirty: dirty})
+	}
+	slices.SortFunc(status.Migrations, func(a, b migrations_dynamodb.MigrationStatus) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	status.Lock = migrations_dynamodb.LockStatus{Held: t.lockOwner != "", Owner: t.lockOwner}
+	return status, nil
+}
+
+func (t *memoryTarget) Export(context.Context) (migrations_dynamodb.Snapshot, error) {
+	snapshot := migrations_dynamodb.Snapshot{Table: "_migrations", Items: []map[string]any{}}
+	for id, dirty := range t.migrations {
+		snapshot.Items = append(snapshot.Items, map[string]any{"id": id, "dirty": dirty})
+	}
+	slices.SortFunc(snapshot.Items, func(a, b map[string]any) int {
+		return strings.Compare(a["id"].(string), b["id"].(string))
+	})
+	return snapshot, nil
+}
+
+func (t *memoryTarget) Import(_ context.Context, snapshot migrations_dynamodb.Snapshot) error {
+	if t.lockOwner == "" {
+		return migrations_dynamodb.ErrLockNotHeld
+	}
+	for _, item := range snapshot.Items {
+		t.migrations[item["id"].(string)] = item["dirty"].(bool)
+	}
+	return nil
+}
+
+func (t *memoryTarget) ImportFrom(_ context.Context, r io.Reader, mode migrations_dynamodb.ImportMode) (migrations_dynamodb.ImportResult, error) {
+	if t.lockOwner == "" {
+		return migrations_dynamodb.ImportResult{}, migrations_dynamodb.ErrLockNotHeld
+	}
+	var snapshot migrations_dynamodb.Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return migrations_dynamodb.ImportResult{}, err
+	}
+	var result migrations_dynamodb.ImportResult
+	ids := map[string]bool{}
+	for _, item := range snapshot.Items {
+		id, dirty := item["id"].(string), item["dirty"].(bool)
+		ids[id] = true
+		current, ok := t.migrations[id]
+		switch {
+		case ok && current == dirty:
+			result.Unchanged = append(result.Unchanged, id)
+		case ok && mode == migrations_dynamodb.ImportMerge:
+			result.Conflicts = append(result.Conflicts, migrations_dynamodb.ImportConflict{ID: id})
+		default:
+			t.migrations[id] = dirty
+			result.Imported = append(result.Imported, id)
+		}
+	}
+	if mode == migrations_dynamodb.ImportReplace {
+		for id := range t.migrations {
+			if !ids[id] {
+				delete(t.migrations, id)
+				result.Removed = append(result.Removed, id)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (t *memoryTarget) ImportGolangMigrate(_ context.Context, tableName string, ids []string) ([]string, error) {
+	if t.lockOwner == "" {
+		return nil, migrations_dynamodb.ErrLockNotHeld
+	}
+	var added []string
+	for _, id := range ids {
+		version, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := t.migrations[id]; !ok && version <= t.golangMigrate[tableName] {
+			t.migrations[id] = false
+			added = append(added, id)
+		}
+	}
+	return added, nil
+}
+
+func (t *memoryTarget) ImportApplied(_ context.Context, r io.Reader, _ migrations_dynamodb.AppliedFormat, _ string) ([]string, error) {
+	if t.lockOwner == "" {
+		return nil, migrations_dynamodb.ErrLockNotHeld
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var added []string
+	for _, id := range strings.Fields(string(data)) {
+		if _, ok := t.migrations[id]; !ok {
+			t.migrations[id] = false
+			added = append(added, id)
+		}
+	}
+	return added, nil
+}
+
+func (t *memoryTarget) Diagnose(context.Context) ([]migrations_dynamodb.Finding, error) {
+	var findings []migrations_dynamodb.Finding
+	for id, dirty := range t.migrations {
+		if dirty {
+			findings = append(findings, migrations_dynamodb.Finding{
+				Severity: migrations_dynamodb.SeverityError,
+				Check:    "dirty",
+				Table:    "_migrations",
+				Message:  "migration " + id + " is dirty",
+				Fix:      "fix it",
+			})
+		}
+	}
+	return findings, nil
+}
+
+func (t *memoryTarget) History(context.Context) ([]migrations_dynamodb.MigrationRecord, error) {
+	return t.history, nil
+}
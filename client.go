@@ -0,0 +1,161 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// policyClient wraps a DynamoDBClient applying the retryer and the timeout configured for the Target to every call.
+// It also counts the retries of the calls.
+type policyClient struct {
+	next DynamoDBClient
+
+	retryer        aws.Retryer
+	timeout        time.Duration
+	defaultTimeout time.Duration
+	retries        *atomic.Int64
+	logger         *slog.Logger
+	retryLogLevel  slog.Level
+}
+
+// DefaultOperationTimeout is the maximum duration of the calls made to the DynamoDB, including their retries, when
+// the context of the caller has no deadline (see WithDefaultOperationTimeout).
+const DefaultOperationTimeout = 30 * time.Second
+
+func newPolicyClient(client DynamoDBClient, options opts, retries *atomic.Int64) DynamoDBClient {
+	return &policyClient{
+		next:           client,
+		retryer:        options.retryer,
+		timeout:        options.operationTimeout,
+		defaultTimeout: options.defaultTimeout,
+		retries:        retries,
+		logger:         options.logger,
+		retryLogLevel:  options.waitLogLevel,
+	}
+}
+
+// context bounds the context of a call with the timeout of the Target, or with the default timeout if the context has
+// no deadline.
+func (c *policyClient) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	if _, ok := ctx.Deadline(); !ok && c.defaultTimeout > 0 {
+		return context.WithTimeout(ctx, c.defaultTimeout)
+	}
+	return ctx, func() {}
+}
+
+func (c *policyClient) IgnoresCallOptions() bool {
+	return ignoresCallOptions(c.next)
+}
+
+func (c *policyClient) options(ctx context.Context, optFns []func(*dynamodb.Options)) []func(*dynamodb.Options) {
+	return append(optFns, func(o *dynamodb.Options) {
+		if c.retryer != nil {
+			o.Retryer = c.retryer
+		}
+		if o.Retryer != nil {
+			o.Retryer = newRetryCounter(ctx, o.Retryer, c.retries, c.logger, c.retryLogLevel)
+		}
+	})
+}
+
+func (c *policyClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.Scan(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.PutItem(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.DeleteItem(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.UpdateItem(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.TransactWriteItems(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.CreateTable(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.DescribeTable(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.DeleteTable(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) ListTables(ctx context.Context, input *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return c.next.ListTables(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	next, ok := c.next.(QueryDynamoDBClient)
+	if !ok {
+		return nil, errQueryUnsupported
+	}
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return next.Query(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) DeleteBackup(ctx context.Context, input *dynamodb.DeleteBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	next, ok := c.next.(BackupDynamoDBClient)
+	if !ok {
+		return nil, errBackupUnsupported
+	}
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return next.DeleteBackup(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) DescribeTimeToLive(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	next, ok := c.next.(TimeToLiveDynamoDBClient)
+	if !ok {
+		return nil, errTimeToLiveUnsupported
+	}
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return next.DescribeTimeToLive(ctx, input, c.options(ctx, optFns)...)
+}
+
+func (c *policyClient) GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	next, ok := c.next.(GetItemDynamoDBClient)
+	if !ok {
+		return nil, errGetItemUnsupported
+	}
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	return next.GetItem(ctx, input, c.options(ctx, optFns)...)
+}
@@ -0,0 +1,109 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client policies", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	When("a retryer is set", func() {
+		It("should be used by every call", func() {
+			retryer := &countingRetryer{RetryerV2: retry.NewStandard()}
+			target := NewTarget(dynamoDBClient, WithRetryer(retryer))
+
+			Expect(target.Create(ctx)).To(Succeed())
+			calls := retryer.calls.Load()
+			Expect(calls).To(BeNumerically(">", 0))
+
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			Expect(retryer.calls.Load()).To(BeNumerically(">", calls))
+		})
+	})
+
+	When("an operation timeout is set", func() {
+		It("should fail calls that take longer than the timeout", func() {
+			target := NewTarget(dynamoDBClient, WithOperationTimeout(time.Nanosecond))
+
+			Expect(target.Create(ctx)).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("should not fail calls that finish in time", func() {
+			target := NewTarget(dynamoDBClient, WithOperationTimeout(time.Minute))
+
+			Expect(target.Create(ctx)).To(Succeed())
+			Expect(target.Add(ctx, "1")).To(Succeed())
+		})
+	})
+
+	When("the context has no deadline", func() {
+		BeforeEach(func() {
+			Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+		})
+
+		It("should fail hung calls after the default timeout", func() {
+			target := NewTarget(&hangingClient{Client: dynamoDBClient}, WithDefaultOperationTimeout(50*time.Millisecond))
+
+			err := target.Add(ctx, "1")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(ErrorCodeOf(err)).To(Equal(CodeTimeout))
+		})
+
+		It("should not report the hung calls of Lock as a lock timeout", func() {
+			target := NewTarget(&hangingClient{Client: dynamoDBClient}, WithDefaultOperationTimeout(50*time.Millisecond))
+
+			_, err := target.Lock(ctx)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(ErrorCodeOf(err)).To(Equal(CodeTimeout))
+		})
+
+		It("should keep the deadline of the context of the caller", func() {
+			target := NewTarget(&hangingClient{Client: dynamoDBClient}, WithDefaultOperationTimeout(time.Millisecond))
+			ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+			defer cancel()
+
+			startedAt := time.Now()
+			Expect(target.Add(ctx, "1")).To(MatchError(context.DeadlineExceeded))
+			Expect(time.Since(startedAt)).To(BeNumerically(">=", 200*time.Millisecond))
+		})
+
+		It("should use DefaultOperationTimeout by default", func() {
+			Expect(defaultOpts().defaultTimeout).To(Equal(DefaultOperationTimeout))
+		})
+	})
+})
+
+// hangingClient hangs putting items until the context is done, as a call lost in the network.
+type hangingClient struct {
+	*dynamodb.Client
+}
+
+func (c *hangingClient) PutItem(ctx context.Context, _ *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type countingRetryer struct {
+	aws.RetryerV2
+
+	calls atomic.Int64
+}
+
+func (r *countingRetryer) MaxAttempts() int {
+	r.calls.Add(1)
+	return r.RetryerV2.MaxAttempts()
+}
@@ -0,0 +1,23 @@
+package migrations_dynamodb
+
+import "time"
+
+// Clock is the source of time of a Target: the timestamps recorded, the durations measured and the waits for the lock
+// and between throttled requests. It is meant to be replaced by a fake clock in tests, with WithClock, so waiting for
+// a lock does not take real seconds.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel receiving the current time once the duration has elapsed, as time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of the time package, the default one of a Target.
+var SystemClock Clock = systemClock{}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
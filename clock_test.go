@@ -0,0 +1,90 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithClock", func() {
+	It("should record the timestamps of the clock", func() {
+		ctx := context.Background()
+		deleteAllTables(ctx)
+
+		clock := newFakeClock()
+		target := NewTarget(dynamoDBClient, WithClock(clock))
+		Expect(target.Create(ctx)).To(Succeed())
+
+		startedAt := clock.Now()
+		Expect(target.Add(ctx, "0001")).To(Succeed())
+		clock.Advance(time.Minute)
+		Expect(target.FinishMigration(ctx, "0001")).To(Succeed())
+
+		records, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].StartedAt).To(BeTemporally("==", startedAt))
+		Expect(records[0].AppliedAt).To(BeTemporally("==", startedAt.Add(time.Minute)))
+		Expect(records[0].Duration).To(Equal(time.Minute))
+	})
+})
+
+// fakeClock is a Clock whose time only moves when advanced, waking up the waits it has elapsed.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Waiters returns the number of waits not elapsed yet.
+func (c *fakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Advance moves the time forward by d, waking up the waits elapsed.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
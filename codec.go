@@ -0,0 +1,72 @@
+package migrations_dynamodb
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultTimestampFormat is the layout of the timestamps recorded by the Target, unless set by WithTimestampFormat.
+const DefaultTimestampFormat = time.RFC3339Nano
+
+// recordTagKey is the struct tag naming the attributes of the records, whatever the TagKey set by WithDecoderOptions.
+const recordTagKey = "dynamodbav"
+
+// codec encodes and decodes the items of a Target.
+type codec struct {
+	encoder         *attributevalue.Encoder
+	decoder         *attributevalue.Decoder
+	recordDecoder   *attributevalue.Decoder
+	timestampFormat string
+}
+
+func newCodec(options opts) codec {
+	return codec{
+		encoder: attributevalue.NewEncoder(options.encoderOptions...),
+		decoder: attributevalue.NewDecoder(options.decoderOptions...),
+		recordDecoder: attributevalue.NewDecoder(append(options.decoderOptions, func(o *attributevalue.DecoderOptions) {
+			o.TagKey = recordTagKey
+		})...),
+		timestampFormat: options.timestampFormat,
+	}
+}
+
+// marshalMap encodes the value into an item.
+func (c codec) marshalMap(in any) (map[string]types.AttributeValue, error) {
+	av, err := c.encoder.Encode(in)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := av.(*types.AttributeValueMemberM)
+	if m == nil {
+		return map[string]types.AttributeValue{}, nil
+	}
+	return m.Value, nil
+}
+
+// unmarshalMap decodes the item into out, a value of the snapshots.
+func (c codec) unmarshalMap(item map[string]types.AttributeValue, out any) error {
+	return c.decoder.Decode(&types.AttributeValueMemberM{Value: item}, out)
+}
+
+// unmarshalRecord decodes the item into out, a record of the Target tagged with dynamodbav.
+func (c codec) unmarshalRecord(item map[string]types.AttributeValue, out any) error {
+	return c.recordDecoder.Decode(&types.AttributeValueMemberM{Value: item}, out)
+}
+
+// formatTimestamp formats the timestamps stored by the Target.
+func (c codec) formatTimestamp(t time.Time) string {
+	return t.UTC().Format(c.timestampFormat)
+}
+
+// parseTimestamp parses a timestamp stored by the Target, with its layout or the default one. Missing or invalid
+// timestamps are returned as zero.
+func (c codec) parseTimestamp(s string) time.Time {
+	for _, layout := range []string{c.timestampFormat, DefaultTimestampFormat} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,63 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Codec", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	It("should record the timestamps with the layout set", func() {
+		clock := newFakeClock()
+		target := NewTarget(dynamoDBClient, WithTimestampFormat(time.DateTime), WithClock(clock))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+
+		output, err := dynamoDBClient.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String("_migrations"),
+			Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Item).To(HaveKeyWithValue(attributeStartedAt, &types.AttributeValueMemberS{Value: "2024-01-01 00:00:00"}))
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].StartedAt).To(Equal(clock.Now()))
+	})
+
+	It("should read the timestamps recorded with the default layout", func() {
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+		Expect(NewTarget(dynamoDBClient).Add(ctx, "1")).To(Succeed())
+
+		history, err := NewTarget(dynamoDBClient, WithTimestampFormat(time.DateTime)).History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].StartedAt).ToNot(BeZero())
+	})
+
+	It("should decode the records whatever the tag key set", func() {
+		target := NewTarget(dynamoDBClient, WithDecoderOptions(func(o *attributevalue.DecoderOptions) {
+			o.TagKey = "json"
+		}))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		Expect(target.Done(ctx)).To(Equal([]string{"1"}))
+	})
+})
@@ -0,0 +1,148 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Compatibility describes the records written by other tools in the migrations table, so the Target can read them
+// while both are in use (see WithCompatibility).
+type Compatibility struct {
+	// StartedAtAttributes are the attributes read, in order, as the started_at of the records that have none. Their
+	// values are timestamps, as strings, or Unix times in seconds, as numbers.
+	StartedAtAttributes []string
+	// AppliedAtAttributes are the attributes read, in order, as the applied_at of the records that have none, as the
+	// StartedAtAttributes.
+	AppliedAtAttributes []string
+	// Rewrite makes StartMigration, FinishMigration and FailMigration rewrite the records they write in the format of
+	// the Target, removing the attributes above. The records are not rewritten with WithTransactionalWrites, as the
+	// transactions do not return them.
+	Rewrite bool
+}
+
+// normalizeItem converts the attributes of a record written by another tool into the format of the Target: dirty
+// stored as a number (0 is false) or as a string (as strconv.ParseBool) is converted into a boolean, and the missing
+// timestamps are read from the attributes of the Compatibility. The item is returned as is without WithCompatibility.
+func (t *Target) normalizeItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if t.compatibility == nil {
+		return item
+	}
+	normalized := make(map[string]types.AttributeValue, len(item))
+	for name, value := range item {
+		normalized[name] = value
+	}
+
+	switch dirty := item["dirty"].(type) {
+	case *types.AttributeValueMemberN:
+		normalized["dirty"] = &types.AttributeValueMemberBOOL{Value: dirty.Value != "0"}
+	case *types.AttributeValueMemberS:
+		if value, err := strconv.ParseBool(dirty.Value); err == nil {
+			normalized["dirty"] = &types.AttributeValueMemberBOOL{Value: value}
+		}
+	case *types.AttributeValueMemberNULL:
+		normalized["dirty"] = &types.AttributeValueMemberBOOL{Value: false}
+	}
+
+	for attribute, legacy := range t.legacyTimestamps(item) {
+		normalized[attribute] = &types.AttributeValueMemberS{Value: legacy}
+	}
+	return normalized
+}
+
+// legacyTimestamps returns the timestamps missing from the item, by their attribute in the format of the Target,
+// found in the attributes of the Compatibility.
+func (t *Target) legacyTimestamps(item map[string]types.AttributeValue) map[string]string {
+	timestamps := map[string]string{}
+	for attribute, legacyAttributes := range map[string][]string{
+		attributeStartedAt: t.compatibility.StartedAtAttributes,
+		attributeAppliedAt: t.compatibility.AppliedAtAttributes,
+	} {
+		if _, ok := item[attribute]; ok {
+			continue
+		}
+		for _, name := range legacyAttributes {
+			if ts := t.parseLegacyTimestamp(item[name]); !ts.IsZero() {
+				timestamps[attribute] = t.codec.formatTimestamp(ts)
+				break
+			}
+		}
+	}
+	return timestamps
+}
+
+// parseLegacyTimestamp parses a timestamp written by another tool. Missing or invalid timestamps are returned as zero.
+func (t *Target) parseLegacyTimestamp(value types.AttributeValue) time.Time {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		return t.codec.parseTimestamp(value.Value)
+	case *types.AttributeValueMemberN:
+		seconds, err := strconv.ParseFloat(value.Value, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(fraction*float64(time.Second))).UTC()
+	}
+	return time.Time{}
+}
+
+// rewriteLegacy rewrites the record, as it was before the write, in the format of the Target when Compatibility.Rewrite
+// is set: the missing timestamps are written from the attributes of the Compatibility, which are removed. The
+// attributes the write already set are left as they are.
+func (t *Target) rewriteLegacy(ctx context.Context, operation string, key, before map[string]types.AttributeValue, written map[string]string) error {
+	if t.compatibility == nil || !t.compatibility.Rewrite || before == nil {
+		return nil
+	}
+
+	var (
+		update  expression.UpdateBuilder
+		changed bool
+	)
+	for attribute, value := range t.legacyTimestamps(before) {
+		if _, ok := written[attribute]; ok {
+			continue
+		}
+		update = update.Set(expression.Name(attribute), expression.Value(value))
+		changed = true
+	}
+	for _, names := range [][]string{t.compatibility.StartedAtAttributes, t.compatibility.AppliedAtAttributes} {
+		for _, name := range names {
+			if _, ok := before[name]; ok {
+				update = update.Remove(expression.Name(name))
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(expression.AttributeExists(expression.Name("id"))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the rewrite expression: %w", err)
+	}
+	output, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 &t.tableName,
+		Key:                       key,
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rewrite the migration: %w", err)
+	}
+	t.capacity.record(operation, output.ConsumedCapacity)
+	return nil
+}
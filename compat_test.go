@@ -0,0 +1,101 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compatibility", func() {
+	var (
+		ctx           context.Context
+		compatibility Compatibility
+	)
+
+	putLegacy := func(item map[string]types.AttributeValue) {
+		_, err := dynamoDBClient.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("_migrations"),
+			Item:      item,
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	getItem := func(id string) map[string]types.AttributeValue {
+		output, err := dynamoDBClient.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String("_migrations"),
+			Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return output.Item
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		compatibility = Compatibility{StartedAtAttributes: []string{"created"}}
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+		putLegacy(map[string]types.AttributeValue{
+			"id":      &types.AttributeValueMemberS{Value: "1"},
+			"dirty":   &types.AttributeValueMemberN{Value: "0"},
+			"created": &types.AttributeValueMemberN{Value: "1714557600"},
+		})
+		putLegacy(map[string]types.AttributeValue{
+			"id":    &types.AttributeValueMemberS{Value: "2"},
+			"dirty": &types.AttributeValueMemberS{Value: "false"},
+		})
+	})
+
+	It("should read the dirty flags and the timestamps written by other tools", func() {
+		target := NewTarget(dynamoDBClient, WithCompatibility(compatibility))
+
+		Expect(target.Done(ctx)).To(Equal([]string{"1", "2"}))
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(2))
+		Expect(history[0].StartedAt).To(Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+	})
+
+	It("should detect the dirty migrations written by other tools", func() {
+		putLegacy(map[string]types.AttributeValue{
+			"id":    &types.AttributeValueMemberS{Value: "3"},
+			"dirty": &types.AttributeValueMemberN{Value: "1"},
+		})
+
+		_, err := NewTarget(dynamoDBClient, WithCompatibility(compatibility)).Done(ctx)
+		Expect(err).To(MatchError(migrations.ErrDirtyMigration))
+	})
+
+	It("should fail to read the records written by other tools without compatibility", func() {
+		_, err := NewTarget(dynamoDBClient).Done(ctx)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should rewrite the records on the next write", func() {
+		compatibility.Rewrite = true
+		target := NewTarget(dynamoDBClient, WithCompatibility(compatibility))
+
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		item := getItem("1")
+		Expect(item).ToNot(HaveKey("created"))
+		Expect(item).To(HaveKeyWithValue("dirty", &types.AttributeValueMemberBOOL{Value: false}))
+		Expect(item).To(HaveKeyWithValue(attributeStartedAt, &types.AttributeValueMemberS{Value: "2024-05-01T10:00:00Z"}))
+	})
+
+	It("should leave the records as they are without rewrite", func() {
+		target := NewTarget(dynamoDBClient, WithCompatibility(compatibility))
+
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		Expect(getItem("1")).To(HaveKeyWithValue("created", &types.AttributeValueMemberN{Value: "1714557600"}))
+	})
+})
@@ -0,0 +1,252 @@
+// Package conformance is a suite checking that a migrations_dynamodb.DynamoDBClient implementation (e.g. a DAX
+// adapter, an instrumented wrapper or a fake) behaves as the Target expects from the DynamoDB, so the wrappers stay
+// compatible across releases. It can be run by the standard testing package, with Run, or by Ginkgo, with Describe:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, mywrapper.New(dynamodbClient))
+//	}
+//
+// Every case runs against its own tables, with names starting with "_conformance-", removed when it finishes, so the
+// suite can run against a DynamoDB shared with other tests.
+package conformance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/jamillosantos/migrations/v2"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// Case is a behaviour of the DynamoDB the Target relies on.
+type Case struct {
+	Name string
+	// Options are the options of the Targets the case runs with, besides the names of the tables.
+	Options []migrations_dynamodb.Option
+	Run     func(ctx context.Context, env Env) error
+}
+
+// Env is what a case runs with.
+type Env struct {
+	// Target uses the client checked, with the tables already created.
+	Target *migrations_dynamodb.Target
+	// NewTarget creates another Target using the client and the same tables, as another runner would.
+	NewTarget func(options ...migrations_dynamodb.Option) *migrations_dynamodb.Target
+}
+
+// Cases returns the cases of the suite.
+func Cases() []Case {
+	return []Case{
+		{Name: "creates the tables idempotently", Run: createIdempotently},
+		{Name: "records the migrations in order", Run: recordInOrder},
+		{
+			Name:    "records the migrations with transactional writes",
+			Options: []migrations_dynamodb.Option{migrations_dynamodb.WithTransactionalWrites()},
+			Run:     locked(recordInOrder),
+		},
+		{Name: "rejects a migration added twice", Run: rejectDuplicate},
+		{Name: "reports a dirty migration", Run: reportDirty},
+		{Name: "removes a migration", Run: remove},
+		{Name: "holds the lock exclusively", Run: holdLock},
+	}
+}
+
+// Run runs every case of the suite, as a subtest of t, against the client.
+func Run(t *testing.T, client migrations_dynamodb.DynamoDBClient) {
+	t.Helper()
+
+	for _, c := range Cases() {
+		t.Run(c.Name, func(t *testing.T) {
+			if err := RunCase(context.Background(), client, c); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Describe registers a Ginkgo container with a spec for every case of the suite, against the client returned by
+// client, called when the spec runs, so it can be created by a BeforeSuite. It must be called while the Ginkgo tree is
+// built, e.g. in a var _ = declaration.
+func Describe(client func() migrations_dynamodb.DynamoDBClient) bool {
+	return ginkgo.Describe("DynamoDBClient conformance", func() {
+		for _, c := range Cases() {
+			ginkgo.It(c.Name, func(ctx context.Context) {
+				gomega.Expect(RunCase(ctx, client(), c)).To(gomega.Succeed())
+			})
+		}
+	})
+}
+
+// RunCase runs the case against the client, in its own tables. The tables are removed when it finishes.
+func RunCase(ctx context.Context, client migrations_dynamodb.DynamoDBClient, c Case) (err error) {
+	prefix := "_conformance-" + randomSuffix()
+	newTarget := func(options ...migrations_dynamodb.Option) *migrations_dynamodb.Target {
+		targetOptions := append([]migrations_dynamodb.Option{}, c.Options...)
+		targetOptions = append(targetOptions,
+			migrations_dynamodb.WithTableName(prefix),
+			migrations_dynamodb.WithLockTableName(prefix+"-lock"),
+		)
+		return migrations_dynamodb.NewTarget(client, append(targetOptions, options...)...)
+	}
+
+	target := newTarget()
+	if err := target.Create(ctx); err != nil {
+		return fmt.Errorf("failed to create the tables: %w", err)
+	}
+	defer func() {
+		if destroyErr := target.Destroy(context.WithoutCancel(ctx)); destroyErr != nil && err == nil {
+			err = fmt.Errorf("failed to destroy the tables: %w", destroyErr)
+		}
+	}()
+
+	return c.Run(ctx, Env{Target: target, NewTarget: newTarget})
+}
+
+// locked runs the case holding the lock, as required by the transactional writes.
+func locked(run func(ctx context.Context, env Env) error) func(ctx context.Context, env Env) error {
+	return func(ctx context.Context, env Env) (err error) {
+		unlocker, err := env.Target.Lock(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to lock: %w", err)
+		}
+		defer func() {
+			if unlockErr := unlocker.Unlock(ctx); unlockErr != nil && err == nil {
+				err = fmt.Errorf("failed to unlock: %w", unlockErr)
+			}
+		}()
+		return run(ctx, env)
+	}
+}
+
+func createIdempotently(ctx context.Context, env Env) error {
+	if err := env.Target.Create(ctx); err != nil {
+		return fmt.Errorf("failed to create the tables again: %w", err)
+	}
+	done, err := env.Target.Done(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the migrations done: %w", err)
+	}
+	if len(done) != 0 {
+		return fmt.Errorf("expected no migrations done, got %v", done)
+	}
+	return nil
+}
+
+func recordInOrder(ctx context.Context, env Env) error {
+	// Added out of order, as the Target cannot rely on the order of the items scanned.
+	for _, id := range []string{"0002", "0001", "0003"} {
+		if err := env.Target.Add(ctx, id); err != nil {
+			return fmt.Errorf("failed to add migration %s: %w", id, err)
+		}
+		if err := env.Target.FinishMigration(ctx, id); err != nil {
+			return fmt.Errorf("failed to finish migration %s: %w", id, err)
+		}
+	}
+	done, err := env.Target.Done(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the migrations done: %w", err)
+	}
+	if want := []string{"0001", "0002", "0003"}; !slices.Equal(done, want) {
+		return fmt.Errorf("expected the migrations done to be %v, got %v", want, done)
+	}
+	current, err := env.Target.Current(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the current migration: %w", err)
+	}
+	if current != "0003" {
+		return fmt.Errorf("expected the current migration to be 0003, got %s", current)
+	}
+	return nil
+}
+
+func rejectDuplicate(ctx context.Context, env Env) error {
+	if err := env.Target.Add(ctx, "0001"); err != nil {
+		return fmt.Errorf("failed to add the migration: %w", err)
+	}
+	err := env.Target.Add(ctx, "0001")
+	if !errors.Is(err, migrations.ErrMigrationAlreadyExists) {
+		return fmt.Errorf("expected adding the migration twice to fail with %q, got %v", migrations.ErrMigrationAlreadyExists, err)
+	}
+	return nil
+}
+
+func reportDirty(ctx context.Context, env Env) error {
+	if err := env.Target.Add(ctx, "0001"); err != nil {
+		return fmt.Errorf("failed to add the migration: %w", err)
+	}
+	_, err := env.Target.Done(ctx)
+	if !errors.Is(err, migrations.ErrDirtyMigration) {
+		return fmt.Errorf("expected listing a dirty migration to fail with %q, got %v", migrations.ErrDirtyMigration, err)
+	}
+	status, err := env.Target.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the status: %w", err)
+	}
+	if want := []migrations_dynamodb.MigrationStatus{{ID: "0001", Dirty: true}}; !slices.Equal(status.Migrations, want) {
+		return fmt.Errorf("expected the migrations to be %v, got %v", want, status.Migrations)
+	}
+	return nil
+}
+
+func remove(ctx context.Context, env Env) error {
+	if err := env.Target.Add(ctx, "0001"); err != nil {
+		return fmt.Errorf("failed to add the migration: %w", err)
+	}
+	if err := env.Target.FinishMigration(ctx, "0001"); err != nil {
+		return fmt.Errorf("failed to finish the migration: %w", err)
+	}
+	if err := env.Target.Remove(ctx, "0001"); err != nil {
+		return fmt.Errorf("failed to remove the migration: %w", err)
+	}
+	done, err := env.Target.Done(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the migrations done: %w", err)
+	}
+	if len(done) != 0 {
+		return fmt.Errorf("expected no migrations done after removing it, got %v", done)
+	}
+	return nil
+}
+
+func holdLock(ctx context.Context, env Env) error {
+	holder := env.NewTarget(migrations_dynamodb.WithOwnerID("conformance-holder"))
+	other := env.NewTarget(migrations_dynamodb.WithOwnerID("conformance-other"))
+
+	unlocker, err := holder.Lock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to lock: %w", err)
+	}
+	status, err := other.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the status: %w", err)
+	}
+	if want := (migrations_dynamodb.LockStatus{Held: true, Owner: "conformance-holder"}); status.Lock != want {
+		return fmt.Errorf("expected the lock to be %+v, got %+v", want, status.Lock)
+	}
+	if err := unlocker.Unlock(ctx); err != nil {
+		return fmt.Errorf("failed to unlock: %w", err)
+	}
+
+	unlocker, err = other.Lock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to lock after the lock was released: %w", err)
+	}
+	if err := unlocker.Unlock(ctx); err != nil {
+		return fmt.Errorf("failed to unlock: %w", err)
+	}
+	return nil
+}
+
+func randomSuffix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package migrations_dynamodb_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+	"github.com/jamillosantos/migrations-dynamodb/conformance"
+)
+
+// The conformance suite lives in a subpackage importing this one, so it is run from an external test package.
+
+func newLocalClient() *dynamodb.Client {
+	return dynamodb.NewFromConfig(aws.Config{
+		Region:       "sa-region-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("abcdef", "`12345", ""),
+		BaseEndpoint: aws.String("http://localhost:8000"),
+	})
+}
+
+// conditionlessClient hides the failed conditions, as a broken wrapper would.
+type conditionlessClient struct {
+	*dynamodb.Client
+}
+
+func (c conditionlessClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	output, err := c.Client.PutItem(ctx, input, optFns...)
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		return nil, errors.New("request failed")
+	}
+	return output, err
+}
+
+var _ = conformance.Describe(func() migrations_dynamodb.DynamoDBClient {
+	return newLocalClient()
+})
+
+var _ = Describe("conformance.RunCase", func() {
+	It("should fail when the client does not behave as DynamoDB", func(ctx context.Context) {
+		var duplicate conformance.Case
+		for _, c := range conformance.Cases() {
+			if c.Name == "rejects a migration added twice" {
+				duplicate = c
+			}
+		}
+
+		err := conformance.RunCase(ctx, conditionlessClient{newLocalClient()}, duplicate)
+		Expect(err).To(MatchError(ContainSubstring("expected adding the migration twice to fail")))
+	})
+})
@@ -0,0 +1,19 @@
+package migrations_dynamodb
+
+import (
+	"context"
+)
+
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a copy of ctx carrying the given correlation ID (e.g. the ID of the deploy pipeline
+// run). It is read by CorrelationIDFromContext, the default extractor of the Target.
+func ContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID set by ContextWithCorrelationID, or an empty string.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
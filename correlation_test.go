@@ -0,0 +1,79 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Correlation ID", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	scanTable := func(tableName string) []map[string]types.AttributeValue {
+		GinkgoHelper()
+
+		scanResponse, err := dynamoDBClient.Scan(ctx, &dynamodb.ScanInput{
+			TableName: aws.String(tableName),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return scanResponse.Items
+	}
+
+	It("should store the correlation ID of the context on the records and audit entries", func() {
+		target := NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit"))
+		Expect(target.Create(ctx)).To(Succeed())
+
+		Expect(target.Add(ContextWithCorrelationID(ctx, "deploy-1"), "1")).To(Succeed())
+		Expect(target.Add(ctx, "2")).To(Succeed())
+
+		items := scanTable("_migrations")
+		Expect(items).To(HaveLen(2))
+		for _, item := range items {
+			if item["id"].(*types.AttributeValueMemberS).Value == "1" {
+				Expect(item).To(HaveKeyWithValue("correlation_id", &types.AttributeValueMemberS{Value: "deploy-1"}))
+			} else {
+				Expect(item).ToNot(HaveKey("correlation_id"))
+			}
+		}
+
+		Expect(target.FinishMigration(ContextWithCorrelationID(ctx, "deploy-2"), "1")).To(Succeed())
+		for _, item := range scanTable("_migrations") {
+			if item["id"].(*types.AttributeValueMemberS).Value == "1" {
+				Expect(item).To(HaveKeyWithValue("correlation_id", &types.AttributeValueMemberS{Value: "deploy-2"}))
+			}
+		}
+
+		correlationIDs := make([]string, 0)
+		for _, entry := range scanTable("_migrations-audit") {
+			if v, ok := entry["correlation_id"].(*types.AttributeValueMemberS); ok {
+				correlationIDs = append(correlationIDs, v.Value)
+			}
+		}
+		Expect(correlationIDs).To(ConsistOf("deploy-1", "deploy-2"))
+	})
+
+	It("should use the extractor set", func() {
+		type traceKey struct{}
+		target := NewTarget(dynamoDBClient, WithCorrelationIDExtractor(func(ctx context.Context) string {
+			traceID, _ := ctx.Value(traceKey{}).(string)
+			return traceID
+		}))
+		Expect(target.Create(ctx)).To(Succeed())
+
+		Expect(target.Add(context.WithValue(ctx, traceKey{}, "trace-1"), "1")).To(Succeed())
+
+		items := scanTable("_migrations")
+		Expect(items).To(HaveLen(1))
+		Expect(items[0]).To(HaveKeyWithValue("correlation_id", &types.AttributeValueMemberS{Value: "trace-1"}))
+	})
+})
@@ -0,0 +1,257 @@
+// Package ddbsource loads migrations from the items of a DynamoDB table, so migrations managed centrally can be pulled
+// and applied by multiple services without redeploying them.
+//
+// Each item of the table is a migration with the following attributes:
+//
+//   - id (S): the ID of the migration, which sorts the migrations. It is the partition key of the table.
+//   - description (S): the description of the migration.
+//   - type (S): "partiql" or "table".
+//   - do (L of S): for "partiql" migrations, the statements run in order. For "table" migrations, the specs of the
+//     tables ensured in order, as the JSON of a dynamodb.CreateTableInput.
+//   - undo (L of S): optional. For "partiql" migrations, the statements run in order to undo the migration. For "table"
+//     migrations, the names of the tables deleted. Migrations without it cannot be undone.
+package ddbsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+
+	"github.com/jamillosantos/migrations-dynamodb/helpers"
+)
+
+const (
+	// TypePartiQL is the type of the migrations running PartiQL statements.
+	TypePartiQL = "partiql"
+
+	// TypeTable is the type of the migrations ensuring tables exist, with helpers.EnsureTable.
+	TypeTable = "table"
+)
+
+// ErrInvalidMigration is returned by Load when an item of the table is not a valid migration.
+var ErrInvalidMigration = errors.New("invalid migration")
+
+type Client interface {
+	helpers.EnsureTableClient
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	ExecuteStatement(ctx context.Context, params *dynamodb.ExecuteStatementInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error)
+	DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error)
+}
+
+type opts struct {
+	targetClient   Client
+	helperOptions  []helpers.Option
+	consistentRead bool
+}
+
+type Option func(*opts)
+
+// WithTargetClient sets the client the migrations are applied with. By default, they are applied with the client they
+// are loaded with: set it when the table of the migrations is in another account or region.
+func WithTargetClient(client Client) Option {
+	return func(o *opts) {
+		o.targetClient = client
+	}
+}
+
+// WithHelperOptions sets the options of helpers.EnsureTable, as its timeout, for the "table" migrations.
+func WithHelperOptions(options ...helpers.Option) Option {
+	return func(o *opts) {
+		o.helperOptions = options
+	}
+}
+
+// WithConsistentRead makes Load read the table with strongly consistent reads, so a migration put right before it is
+// not missed.
+func WithConsistentRead() Option {
+	return func(o *opts) {
+		o.consistentRead = true
+	}
+}
+
+// Source is a migrations.Source loading the migrations from the items of a DynamoDB table. Each Load scans the table
+// again, so the migrations added to it are picked up without restarting. The migrations added with Add, as the ones
+// defined in code, are loaded together with the ones of the table.
+type Source struct {
+	client         Client
+	targetClient   Client
+	tableName      string
+	helperOptions  []helpers.Option
+	consistentRead bool
+
+	added []migrations.Migration
+}
+
+var _ migrations.Source = (*Source)(nil)
+
+// NewSource creates a Source loading the migrations from the given table.
+func NewSource(client Client, tableName string, options ...Option) *Source {
+	o := opts{targetClient: client}
+	for _, option := range options {
+		option(&o)
+	}
+	return &Source{
+		client:         client,
+		targetClient:   o.targetClient,
+		tableName:      tableName,
+		helperOptions:  o.helperOptions,
+		consistentRead: o.consistentRead,
+	}
+}
+
+// Add adds a migration, to be loaded together with the ones of the table.
+func (s *Source) Add(_ context.Context, migration migrations.Migration) error {
+	for _, m := range s.added {
+		if m.ID() == migration.ID() {
+			return migrations.WrapMigrationID(migrations.ErrMigrationAlreadyExists, migration.ID())
+		}
+	}
+	s.added = append(s.added, migration)
+	return nil
+}
+
+// Load scans the table and returns its migrations, together with the ones added with Add. It fails if any item is not
+// a valid migration, before any migration is applied.
+func (s *Source) Load(ctx context.Context) (migrations.Repository, error) {
+	var repo migrations.Repository
+	for _, m := range s.added {
+		if err := repo.Add(m); err != nil {
+			return migrations.Repository{}, err
+		}
+	}
+
+	var startKey map[string]types.AttributeValue
+	for {
+		output, err := s.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(s.tableName),
+			ExclusiveStartKey: startKey,
+			ConsistentRead:    aws.Bool(s.consistentRead),
+		})
+		if err != nil {
+			return migrations.Repository{}, fmt.Errorf("failed to scan the migrations of table %s: %w", s.tableName, err)
+		}
+		for _, item := range output.Items {
+			m, err := s.migration(item)
+			if err != nil {
+				return migrations.Repository{}, err
+			}
+			if err := repo.Add(m); err != nil {
+				return migrations.Repository{}, err
+			}
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return repo, nil
+		}
+		startKey = output.LastEvaluatedKey
+	}
+}
+
+type ddbMigration struct {
+	ID          string   `dynamodbav:"id"`
+	Description string   `dynamodbav:"description"`
+	Type        string   `dynamodbav:"type"`
+	Do          []string `dynamodbav:"do"`
+	Undo        []string `dynamodbav:"undo"`
+}
+
+// migration parses the item into a migration.
+func (s *Source) migration(item map[string]types.AttributeValue) (migrations.Migration, error) {
+	var m ddbMigration
+	if err := attributevalue.UnmarshalMap(item, &m); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMigration, err)
+	}
+	if m.ID == "" {
+		return nil, fmt.Errorf("%w: missing id", ErrInvalidMigration)
+	}
+	if len(m.Do) == 0 {
+		return nil, fmt.Errorf("%w: %s: missing do", ErrInvalidMigration, m.ID)
+	}
+
+	var do, undo func(ctx context.Context) error
+	switch m.Type {
+	case TypePartiQL:
+		do = s.executeStatements(m.Do)
+		if len(m.Undo) > 0 {
+			undo = s.executeStatements(m.Undo)
+		}
+	case TypeTable:
+		inputs := make([]*dynamodb.CreateTableInput, 0, len(m.Do))
+		for _, spec := range m.Do {
+			input, err := parseTableSpec(spec)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMigration, m.ID, err)
+			}
+			inputs = append(inputs, input)
+		}
+		do = s.ensureTables(inputs)
+		if len(m.Undo) > 0 {
+			undo = s.deleteTables(m.Undo)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s: unknown type %q", ErrInvalidMigration, m.ID, m.Type)
+	}
+
+	return migrations.NewMigration(m.ID, m.Description, do, undo), nil
+}
+
+// parseTableSpec parses the JSON of a dynamodb.CreateTableInput. Unknown fields are rejected, so typos are not ignored.
+func parseTableSpec(spec string) (*dynamodb.CreateTableInput, error) {
+	decoder := json.NewDecoder(strings.NewReader(spec))
+	decoder.DisallowUnknownFields()
+	var input dynamodb.CreateTableInput
+	if err := decoder.Decode(&input); err != nil {
+		return nil, fmt.Errorf("failed to parse table spec: %w", err)
+	}
+	if aws.ToString(input.TableName) == "" {
+		return nil, errors.New("table spec without TableName")
+	}
+	return &input, nil
+}
+
+func (s *Source) executeStatements(statements []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, statement := range statements {
+			_, err := s.targetClient.ExecuteStatement(ctx, &dynamodb.ExecuteStatementInput{
+				Statement: aws.String(statement),
+			})
+			if err != nil {
+				return migrations.NewQueryError(err, statement)
+			}
+		}
+		return nil
+	}
+}
+
+func (s *Source) ensureTables(inputs []*dynamodb.CreateTableInput) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, input := range inputs {
+			if err := helpers.EnsureTable(ctx, s.targetClient, input, s.helperOptions...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (s *Source) deleteTables(tableNames []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, tableName := range tableNames {
+			_, err := s.targetClient.DeleteTable(ctx, &dynamodb.DeleteTableInput{
+				TableName: aws.String(tableName),
+			})
+			var resourceNotFoundException *types.ResourceNotFoundException
+			if err != nil && !errors.As(err, &resourceNotFoundException) {
+				return fmt.Errorf("failed to delete table %s: %w", tableName, err)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,236 @@
+package ddbsource
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeClient returns the migration items by Scan, a page per item, and records the statements run and the tables
+// created and deleted.
+type fakeClient struct {
+	items        []map[string]types.AttributeValue
+	statements   []string
+	tables       map[string]*dynamodb.CreateTableInput
+	statementErr error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{tables: map[string]*dynamodb.CreateTableInput{}}
+}
+
+func (c *fakeClient) Scan(_ context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		for i, item := range c.items {
+			if item["id"].(*types.AttributeValueMemberS).Value == input.ExclusiveStartKey["id"].(*types.AttributeValueMemberS).Value {
+				start = i + 1
+			}
+		}
+	}
+	output := &dynamodb.ScanOutput{}
+	if start < len(c.items) {
+		output.Items = c.items[start : start+1]
+		output.LastEvaluatedKey = map[string]types.AttributeValue{"id": c.items[start]["id"]}
+	}
+	return output, nil
+}
+
+func (c *fakeClient) ExecuteStatement(_ context.Context, input *dynamodb.ExecuteStatementInput, _ ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	if c.statementErr != nil {
+		return nil, c.statementErr
+	}
+	c.statements = append(c.statements, aws.ToString(input.Statement))
+	return &dynamodb.ExecuteStatementOutput{}, nil
+}
+
+func (c *fakeClient) DescribeTable(_ context.Context, input *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	table, ok := c.tables[aws.ToString(input.TableName)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found")}
+	}
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+		TableName:          table.TableName,
+		TableStatus:        types.TableStatusActive,
+		KeySchema:          table.KeySchema,
+		BillingModeSummary: &types.BillingModeSummary{BillingMode: table.BillingMode},
+	}}, nil
+}
+
+func (c *fakeClient) CreateTable(_ context.Context, input *dynamodb.CreateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.tables[aws.ToString(input.TableName)] = input
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (c *fakeClient) UpdateTable(context.Context, *dynamodb.UpdateTableInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	return &dynamodb.UpdateTableOutput{}, nil
+}
+
+func (c *fakeClient) ListTagsOfResource(context.Context, *dynamodb.ListTagsOfResourceInput, ...func(*dynamodb.Options)) (*dynamodb.ListTagsOfResourceOutput, error) {
+	return &dynamodb.ListTagsOfResourceOutput{}, nil
+}
+
+func (c *fakeClient) TagResource(context.Context, *dynamodb.TagResourceInput, ...func(*dynamodb.Options)) (*dynamodb.TagResourceOutput, error) {
+	return &dynamodb.TagResourceOutput{}, nil
+}
+
+func (c *fakeClient) DeleteTable(_ context.Context, input *dynamodb.DeleteTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	if _, ok := c.tables[aws.ToString(input.TableName)]; !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found")}
+	}
+	delete(c.tables, aws.ToString(input.TableName))
+	return &dynamodb.DeleteTableOutput{}, nil
+}
+
+func stringList(values ...string) types.AttributeValue {
+	list := &types.AttributeValueMemberL{}
+	for _, value := range values {
+		list.Value = append(list.Value, &types.AttributeValueMemberS{Value: value})
+	}
+	return list
+}
+
+func item(id, migrationType string, do, undo types.AttributeValue) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{
+		"id":          &types.AttributeValueMemberS{Value: id},
+		"description": &types.AttributeValueMemberS{Value: "migration " + id},
+		"type":        &types.AttributeValueMemberS{Value: migrationType},
+		"do":          do,
+	}
+	if undo != nil {
+		item["undo"] = undo
+	}
+	return item
+}
+
+const usersSpec = `{
+	"TableName": "users",
+	"AttributeDefinitions": [{"AttributeName": "pk", "AttributeType": "S"}],
+	"KeySchema": [{"AttributeName": "pk", "KeyType": "HASH"}],
+	"BillingMode": "PAY_PER_REQUEST"
+}`
+
+var _ = Describe("Source", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+	})
+
+	load := func(source *Source) []migrations.Migration {
+		repo, err := source.Load(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		list, err := repo.List(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		return list
+	}
+
+	It("should load the migrations of the table sorted by ID", func() {
+		client.items = []map[string]types.AttributeValue{
+			item("0002", TypePartiQL, stringList(`UPDATE "users" SET active = true WHERE pk = 'a'`), nil),
+			item("0001", TypeTable, stringList(usersSpec), stringList("users")),
+		}
+
+		list := load(NewSource(client, "migrations"))
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].ID()).To(Equal("0001"))
+		Expect(list[0].Description()).To(Equal("migration 0001"))
+		Expect(list[0].CanUndo()).To(BeTrue())
+		Expect(list[1].ID()).To(Equal("0002"))
+		Expect(list[1].CanUndo()).To(BeFalse())
+	})
+
+	It("should run the statements of the partiql migrations", func() {
+		client.items = []map[string]types.AttributeValue{
+			item("0001", TypePartiQL, stringList(`INSERT INTO "users" VALUE {'pk': 'a'}`, `INSERT INTO "users" VALUE {'pk': 'b'}`), stringList(`DELETE FROM "users" WHERE pk = 'a'`)),
+		}
+
+		m := load(NewSource(client, "migrations"))[0]
+		Expect(m.Do(ctx)).To(Succeed())
+		Expect(m.Undo(ctx)).To(Succeed())
+		Expect(client.statements).To(Equal([]string{
+			`INSERT INTO "users" VALUE {'pk': 'a'}`,
+			`INSERT INTO "users" VALUE {'pk': 'b'}`,
+			`DELETE FROM "users" WHERE pk = 'a'`,
+		}))
+	})
+
+	It("should return a query error when a statement fails", func() {
+		client.items = []map[string]types.AttributeValue{item("0001", TypePartiQL, stringList(`DELETE FROM "users"`), nil)}
+		client.statementErr = errors.New("validation error")
+
+		err := load(NewSource(client, "migrations"))[0].Do(ctx)
+		Expect(err).To(MatchError(ContainSubstring("validation error")))
+		var queryError migrations.QueryError
+		Expect(errors.As(err, &queryError)).To(BeTrue())
+		Expect(queryError.Query()).To(Equal(`DELETE FROM "users"`))
+	})
+
+	It("should ensure and delete the tables of the table migrations", func() {
+		client.items = []map[string]types.AttributeValue{item("0001", TypeTable, stringList(usersSpec), stringList("users"))}
+
+		m := load(NewSource(client, "migrations"))[0]
+		Expect(m.Do(ctx)).To(Succeed())
+		Expect(client.tables).To(HaveKey("users"))
+		Expect(client.tables["users"].BillingMode).To(Equal(types.BillingModePayPerRequest))
+
+		Expect(m.Undo(ctx)).To(Succeed())
+		Expect(client.tables).ToNot(HaveKey("users"))
+		Expect(m.Undo(ctx)).To(Succeed())
+	})
+
+	It("should apply the migrations with the target client", func() {
+		client.items = []map[string]types.AttributeValue{item("0001", TypePartiQL, stringList(`DELETE FROM "users"`), nil)}
+		target := newFakeClient()
+
+		Expect(load(NewSource(client, "migrations", WithTargetClient(target)))[0].Do(ctx)).To(Succeed())
+		Expect(client.statements).To(BeEmpty())
+		Expect(target.statements).To(HaveLen(1))
+	})
+
+	It("should load the migrations added together with the ones of the table", func() {
+		client.items = []map[string]types.AttributeValue{item("0002", TypePartiQL, stringList(`DELETE FROM "users"`), nil)}
+		source := NewSource(client, "migrations")
+		Expect(source.Add(ctx, migrations.NewMigration("0001", "in code", func(context.Context) error { return nil }, nil))).To(Succeed())
+
+		list := load(source)
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Description()).To(Equal("in code"))
+
+		client.items = append(client.items, item("0003", TypePartiQL, stringList(`DELETE FROM "users"`), nil))
+		Expect(load(source)).To(HaveLen(3))
+	})
+
+	It("should fail when a migration is both added and in the table", func() {
+		client.items = []map[string]types.AttributeValue{item("0001", TypePartiQL, stringList(`DELETE FROM "users"`), nil)}
+		source := NewSource(client, "migrations")
+		Expect(source.Add(ctx, migrations.NewMigration("0001", "in code", func(context.Context) error { return nil }, nil))).To(Succeed())
+
+		_, err := source.Load(ctx)
+		Expect(err).To(MatchError(migrations.ErrMigrationAlreadyExists))
+	})
+
+	DescribeTable("should fail with ErrInvalidMigration", func(item map[string]types.AttributeValue) {
+		client.items = []map[string]types.AttributeValue{item}
+
+		_, err := NewSource(client, "migrations").Load(ctx)
+		Expect(err).To(MatchError(ErrInvalidMigration))
+	},
+		Entry("without id", item("", TypePartiQL, stringList(`DELETE FROM "users"`), nil)),
+		Entry("without do", item("0001", TypePartiQL, stringList(), nil)),
+		Entry("with an unknown type", item("0001", "sql", stringList(`DELETE FROM users`), nil)),
+		Entry("with an invalid table spec", item("0001", TypeTable, stringList(`{"TableName": "users", "KeySchemas": []}`), nil)),
+		Entry("with a table spec without name", item("0001", TypeTable, stringList(`{}`), nil)),
+		Entry("with do not being a list", item("0001", TypePartiQL, &types.AttributeValueMemberN{Value: "1"}, nil)),
+	)
+})
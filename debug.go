@@ -0,0 +1,360 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// debugClient wraps a DynamoDBClient logging every call, at the debug level, with the parts of the request and
+// response that matter to diagnose it: tables, keys, expressions and errors. Items are sanitized, only the names of
+// their attributes are logged.
+type debugClient struct {
+	next   DynamoDBClient
+	logger *slog.Logger
+	clock  Clock
+}
+
+func newDebugClient(client DynamoDBClient, options opts) DynamoDBClient {
+	if !options.debug {
+		return client
+	}
+	return &debugClient{
+		next:   client,
+		logger: options.logger,
+		clock:  options.clock,
+	}
+}
+
+func (c *debugClient) IgnoresCallOptions() bool {
+	return ignoresCallOptions(c.next)
+}
+
+// debugCall executes the call and logs its request and response. When the debug level is not enabled, the call is
+// executed without building the log attributes.
+func debugCall[T any](ctx context.Context, c *debugClient, method string, request func() []any, call func() (T, error), response func(T) []any) (T, error) {
+	if !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return call()
+	}
+
+	startedAt := c.clock.Now()
+	output, err := call()
+	attrs := []any{
+		"method", method,
+		slog.Group("request", request()...),
+		"duration", c.clock.Now().Sub(startedAt),
+	}
+	if err != nil {
+		attrs = append(attrs, "error", debugError(err))
+	} else {
+		attrs = append(attrs, slog.Group("response", response(output)...))
+	}
+	c.logger.DebugContext(ctx, "dynamodb call", attrs...)
+	return output, err
+}
+
+func (c *debugClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return debugCall(ctx, c, "Scan", func() []any {
+		return []any{
+			"table", aws.ToString(input.TableName),
+			"segment", debugSegment(input.Segment, input.TotalSegments),
+			"exclusive_start_key", debugValues(input.ExclusiveStartKey),
+		}
+	}, func() (*dynamodb.ScanOutput, error) {
+		return c.next.Scan(ctx, input, optFns...)
+	}, func(output *dynamodb.ScanOutput) []any {
+		return []any{
+			"count", output.Count,
+			"last_evaluated_key", debugValues(output.LastEvaluatedKey),
+			"consumed_capacity", debugCapacity(output.ConsumedCapacity),
+		}
+	})
+}
+
+func (c *debugClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return debugCall(ctx, c, "PutItem", func() []any {
+		return []any{
+			"table", aws.ToString(input.TableName),
+			"item", debugAttributeNames(input.Item),
+			"condition", aws.ToString(input.ConditionExpression),
+			"names", input.ExpressionAttributeNames,
+			"values", debugValues(input.ExpressionAttributeValues),
+		}
+	}, func() (*dynamodb.PutItemOutput, error) {
+		return c.next.PutItem(ctx, input, optFns...)
+	}, func(output *dynamodb.PutItemOutput) []any {
+		return []any{"consumed_capacity", debugCapacity(output.ConsumedCapacity)}
+	})
+}
+
+func (c *debugClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return debugCall(ctx, c, "DeleteItem", func() []any {
+		return []any{
+			"table", aws.ToString(input.TableName),
+			"key", debugValues(input.Key),
+			"condition", aws.ToString(input.ConditionExpression),
+			"names", input.ExpressionAttributeNames,
+			"values", debugValues(input.ExpressionAttributeValues),
+		}
+	}, func() (*dynamodb.DeleteItemOutput, error) {
+		return c.next.DeleteItem(ctx, input, optFns...)
+	}, func(output *dynamodb.DeleteItemOutput) []any {
+		return []any{"consumed_capacity", debugCapacity(output.ConsumedCapacity)}
+	})
+}
+
+func (c *debugClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return debugCall(ctx, c, "UpdateItem", func() []any {
+		return []any{
+			"table", aws.ToString(input.TableName),
+			"key", debugValues(input.Key),
+			"update", aws.ToString(input.UpdateExpression),
+			"condition", aws.ToString(input.ConditionExpression),
+			"names", input.ExpressionAttributeNames,
+			"values", debugValues(input.ExpressionAttributeValues),
+		}
+	}, func() (*dynamodb.UpdateItemOutput, error) {
+		return c.next.UpdateItem(ctx, input, optFns...)
+	}, func(output *dynamodb.UpdateItemOutput) []any {
+		return []any{"consumed_capacity", debugCapacity(output.ConsumedCapacity)}
+	})
+}
+
+func (c *debugClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return debugCall(ctx, c, "TransactWriteItems", func() []any {
+		items := make([]string, len(input.TransactItems))
+		for i, item := range input.TransactItems {
+			items[i] = debugTransactItem(item)
+		}
+		return []any{"items", items}
+	}, func() (*dynamodb.TransactWriteItemsOutput, error) {
+		return c.next.TransactWriteItems(ctx, input, optFns...)
+	}, func(output *dynamodb.TransactWriteItemsOutput) []any {
+		capacity := make([]string, len(output.ConsumedCapacity))
+		for i := range output.ConsumedCapacity {
+			capacity[i] = debugCapacity(&output.ConsumedCapacity[i])
+		}
+		return []any{"consumed_capacity", capacity}
+	})
+}
+
+func (c *debugClient) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	return debugCall(ctx, c, "CreateTable", func() []any {
+		return []any{"table", aws.ToString(input.TableName)}
+	}, func() (*dynamodb.CreateTableOutput, error) {
+		return c.next.CreateTable(ctx, input, optFns...)
+	}, func(output *dynamodb.CreateTableOutput) []any {
+		if output.TableDescription == nil {
+			return nil
+		}
+		return []any{"status", output.TableDescription.TableStatus}
+	})
+}
+
+func (c *debugClient) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return debugCall(ctx, c, "DescribeTable", func() []any {
+		return []any{"table", aws.ToString(input.TableName)}
+	}, func() (*dynamodb.DescribeTableOutput, error) {
+		return c.next.DescribeTable(ctx, input, optFns...)
+	}, func(output *dynamodb.DescribeTableOutput) []any {
+		if output.Table == nil {
+			return nil
+		}
+		return []any{"status", output.Table.TableStatus}
+	})
+}
+
+func (c *debugClient) DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	return debugCall(ctx, c, "DeleteTable", func() []any {
+		return []any{"table", aws.ToString(input.TableName)}
+	}, func() (*dynamodb.DeleteTableOutput, error) {
+		return c.next.DeleteTable(ctx, input, optFns...)
+	}, func(output *dynamodb.DeleteTableOutput) []any {
+		return nil
+	})
+}
+
+func (c *debugClient) ListTables(ctx context.Context, input *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	return debugCall(ctx, c, "ListTables", func() []any {
+		return []any{"exclusive_start_table", aws.ToString(input.ExclusiveStartTableName)}
+	}, func() (*dynamodb.ListTablesOutput, error) {
+		return c.next.ListTables(ctx, input, optFns...)
+	}, func(output *dynamodb.ListTablesOutput) []any {
+		return []any{"tables", output.TableNames}
+	})
+}
+
+func (c *debugClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	next, ok := c.next.(QueryDynamoDBClient)
+	if !ok {
+		return nil, errQueryUnsupported
+	}
+	return debugCall(ctx, c, "Query", func() []any {
+		return []any{
+			"table", aws.ToString(input.TableName),
+			"index", aws.ToString(input.IndexName),
+			"key_condition", aws.ToString(input.KeyConditionExpression),
+			"exclusive_start_key", debugValues(input.ExclusiveStartKey),
+		}
+	}, func() (*dynamodb.QueryOutput, error) {
+		return next.Query(ctx, input, optFns...)
+	}, func(output *dynamodb.QueryOutput) []any {
+		return []any{
+			"count", output.Count,
+			"last_evaluated_key", debugValues(output.LastEvaluatedKey),
+			"consumed_capacity", debugCapacity(output.ConsumedCapacity),
+		}
+	})
+}
+
+func (c *debugClient) DescribeTimeToLive(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	next, ok := c.next.(TimeToLiveDynamoDBClient)
+	if !ok {
+		return nil, errTimeToLiveUnsupported
+	}
+	return debugCall(ctx, c, "DescribeTimeToLive", func() []any {
+		return []any{"table", aws.ToString(input.TableName)}
+	}, func() (*dynamodb.DescribeTimeToLiveOutput, error) {
+		return next.DescribeTimeToLive(ctx, input, optFns...)
+	}, func(output *dynamodb.DescribeTimeToLiveOutput) []any {
+		if output.TimeToLiveDescription == nil {
+			return nil
+		}
+		return []any{"status", output.TimeToLiveDescription.TimeToLiveStatus}
+	})
+}
+
+func (c *debugClient) DeleteBackup(ctx context.Context, input *dynamodb.DeleteBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	next, ok := c.next.(BackupDynamoDBClient)
+	if !ok {
+		return nil, errBackupUnsupported
+	}
+	return debugCall(ctx, c, "DeleteBackup", func() []any {
+		return []any{"backup_arn", aws.ToString(input.BackupArn)}
+	}, func() (*dynamodb.DeleteBackupOutput, error) {
+		return next.DeleteBackup(ctx, input, optFns...)
+	}, func(*dynamodb.DeleteBackupOutput) []any {
+		return nil
+	})
+}
+
+func (c *debugClient) GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	next, ok := c.next.(GetItemDynamoDBClient)
+	if !ok {
+		return nil, errGetItemUnsupported
+	}
+	return debugCall(ctx, c, "GetItem", func() []any {
+		return []any{"table", aws.ToString(input.TableName), "key", debugValues(input.Key)}
+	}, func() (*dynamodb.GetItemOutput, error) {
+		return next.GetItem(ctx, input, optFns...)
+	}, func(output *dynamodb.GetItemOutput) []any {
+		return []any{
+			"found", len(output.Item) > 0,
+			"consumed_capacity", debugCapacity(output.ConsumedCapacity),
+		}
+	})
+}
+
+// debugError describes the error, including the cancellation reasons of failed transactions.
+func debugError(err error) string {
+	var transactionCanceledException *types.TransactionCanceledException
+	if errors.As(err, &transactionCanceledException) {
+		reasons := make([]string, len(transactionCanceledException.CancellationReasons))
+		for i, reason := range transactionCanceledException.CancellationReasons {
+			reasons[i] = aws.ToString(reason.Code)
+		}
+		return fmt.Sprintf("%s (cancellation reasons: %v)", err, reasons)
+	}
+	return err.Error()
+}
+
+func debugTransactItem(item types.TransactWriteItem) string {
+	switch {
+	case item.ConditionCheck != nil:
+		return fmt.Sprintf("ConditionCheck %s key=%v condition=%q names=%v values=%v", aws.ToString(item.ConditionCheck.TableName),
+			debugValues(item.ConditionCheck.Key), aws.ToString(item.ConditionCheck.ConditionExpression),
+			item.ConditionCheck.ExpressionAttributeNames, debugValues(item.ConditionCheck.ExpressionAttributeValues))
+	case item.Put != nil:
+		return fmt.Sprintf("Put %s item=%v condition=%q names=%v values=%v", aws.ToString(item.Put.TableName),
+			debugAttributeNames(item.Put.Item), aws.ToString(item.Put.ConditionExpression),
+			item.Put.ExpressionAttributeNames, debugValues(item.Put.ExpressionAttributeValues))
+	case item.Delete != nil:
+		return fmt.Sprintf("Delete %s key=%v condition=%q names=%v values=%v", aws.ToString(item.Delete.TableName),
+			debugValues(item.Delete.Key), aws.ToString(item.Delete.ConditionExpression),
+			item.Delete.ExpressionAttributeNames, debugValues(item.Delete.ExpressionAttributeValues))
+	case item.Update != nil:
+		return fmt.Sprintf("Update %s key=%v update=%q condition=%q names=%v values=%v", aws.ToString(item.Update.TableName),
+			debugValues(item.Update.Key), aws.ToString(item.Update.UpdateExpression), aws.ToString(item.Update.ConditionExpression),
+			item.Update.ExpressionAttributeNames, debugValues(item.Update.ExpressionAttributeValues))
+	}
+	return "unknown"
+}
+
+func debugSegment(segment, totalSegments *int32) string {
+	if totalSegments == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", aws.ToInt32(segment), aws.ToInt32(totalSegments))
+}
+
+func debugCapacity(capacity *types.ConsumedCapacity) string {
+	if capacity == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s=%g", aws.ToString(capacity.TableName), aws.ToFloat64(capacity.CapacityUnits))
+}
+
+// debugAttributeNames returns the sorted attribute names of the item, leaving its values out of the logs.
+func debugAttributeNames(item map[string]types.AttributeValue) []string {
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// debugValues renders keys and expression values. Only scalars are rendered, documents are summarized by their size.
+func debugValues(values map[string]types.AttributeValue) map[string]string {
+	if values == nil {
+		return nil
+	}
+	r := make(map[string]string, len(values))
+	for k, v := range values {
+		r[k] = debugValue(v)
+	}
+	return r
+}
+
+func debugValue(value types.AttributeValue) string {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return strconv.Quote(v.Value)
+	case *types.AttributeValueMemberN:
+		return v.Value
+	case *types.AttributeValueMemberBOOL:
+		return strconv.FormatBool(v.Value)
+	case *types.AttributeValueMemberNULL:
+		return "null"
+	case *types.AttributeValueMemberB:
+		return fmt.Sprintf("<%d bytes>", len(v.Value))
+	case *types.AttributeValueMemberM:
+		return fmt.Sprintf("<map of %d>", len(v.Value))
+	case *types.AttributeValueMemberL:
+		return fmt.Sprintf("<list of %d>", len(v.Value))
+	case *types.AttributeValueMemberSS:
+		return fmt.Sprintf("<set of %d>", len(v.Value))
+	case *types.AttributeValueMemberNS:
+		return fmt.Sprintf("<set of %d>", len(v.Value))
+	case *types.AttributeValueMemberBS:
+		return fmt.Sprintf("<set of %d>", len(v.Value))
+	}
+	return "<unknown>"
+}
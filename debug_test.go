@@ -0,0 +1,79 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Debug", func() {
+	var (
+		ctx context.Context
+		buf *syncBuffer
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		buf = &syncBuffer{}
+	})
+
+	dynamoDBCalls := func() []map[string]any {
+		GinkgoHelper()
+
+		var calls []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var entry map[string]any
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+			if entry["msg"] == "dynamodb call" {
+				calls = append(calls, entry)
+			}
+		}
+		return calls
+	}
+
+	It("should log the requests and errors of the calls", func() {
+		target := NewTarget(dynamoDBClient, WithDebug(), WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(MatchError(migrations.ErrMigrationAlreadyExists))
+
+		calls := dynamoDBCalls()
+		var puts []map[string]any
+		for _, call := range calls {
+			if call["method"] == "PutItem" {
+				puts = append(puts, call)
+			}
+		}
+		Expect(puts).To(HaveLen(2))
+
+		request := puts[1]["request"].(map[string]any)
+		Expect(request["table"]).To(Equal("_migrations"))
+		Expect(request["item"]).To(Equal([]any{"applied_by", "dirty", "id", "started_at"}))
+		Expect(request["condition"]).To(ContainSubstring("attribute_not_exists"))
+		Expect(puts[1]["error"]).To(ContainSubstring("ConditionalCheckFailedException"))
+		Expect(puts[0]).To(HaveKey("response"))
+	})
+
+	It("should not log the calls when the debug level is disabled", func() {
+		target := NewTarget(dynamoDBClient, WithDebug(), WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelInfo,
+		}))))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+
+		Expect(dynamoDBCalls()).To(BeEmpty())
+	})
+})
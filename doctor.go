@@ -0,0 +1,281 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// TimeToLiveDynamoDBClient is implemented by the clients able to describe the TTL configuration of the tables, as the
+// *dynamodb.Client. It is optional: when the client given to NewTarget does not implement it, Diagnose skips the TTL
+// check.
+type TimeToLiveDynamoDBClient interface {
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+}
+
+// errTimeToLiveUnsupported is returned by the client wrappers when the wrapped client does not implement
+// TimeToLiveDynamoDBClient.
+var errTimeToLiveUnsupported = fmt.Errorf("the client does not support DescribeTimeToLive: %w", errors.ErrUnsupported)
+
+// Severity tells how much a Finding of Diagnose matters.
+type Severity string
+
+const (
+	// SeverityError findings make the migrations fail, they must be fixed before running them.
+	SeverityError Severity = "error"
+	// SeverityWarning findings may make the migrations fail or block, depending on the circumstances.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo findings are worth knowing, but need no action.
+	SeverityInfo Severity = "info"
+)
+
+// Finding is a problem found by Diagnose.
+type Finding struct {
+	Severity Severity
+	// Check is the name of the check that found the problem (e.g. "table", "permissions" or "lock").
+	Check string
+	// Table is the table the problem was found in, if any.
+	Table   string
+	Message string
+	// Fix is what should be done to fix the problem.
+	Fix string
+}
+
+// Diagnose runs preflight checks against the tables used by the Target, meant to be run before a deploy runs the
+// migrations: that the tables exist with the expected schema, their billing mode, the TTL configuration of the lock
+// table, that the credentials are allowed to read and write them, that no migration is dirty and that the lock is not
+// held. Permissions are probed with conditional writes that never succeed, so the state is not changed.
+//
+// The problems found are returned as findings, and no finding means no problem was found. An error is returned only
+// when the checks could not run (e.g. the context was canceled).
+func (t *Target) Diagnose(ctx context.Context) (_ []Finding, err error) {
+	defer t.observe(ctx, operationDiagnose, t.clock.Now(), &err)
+	defer wrapError(operationDiagnose, "", &err)
+
+	var findings []Finding
+	tables := []string{t.tableName, t.lockTableName}
+	if t.auditTableName != "" {
+		tables = append(tables, t.auditTableName)
+	}
+	available := make(map[string]bool, len(tables))
+	for _, tableName := range tables {
+		tableFindings, ok := t.diagnoseTable(ctx, tableName)
+		findings = append(findings, tableFindings...)
+		available[tableName] = ok
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if available[t.lockTableName] {
+		findings = append(findings, t.diagnoseTimeToLive(ctx)...)
+	}
+	if available[t.tableName] {
+		findings = append(findings, t.diagnoseWrites(ctx, t.tableName)...)
+	}
+	if available[t.lockTableName] {
+		findings = append(findings, t.diagnoseWrites(ctx, t.lockTableName)...)
+	}
+	if available[t.tableName] && available[t.lockTableName] {
+		findings = append(findings, t.diagnoseState(ctx)...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return findings, nil
+}
+
+// diagnoseTable checks that the table exists with the expected schema and reports its billing mode. It returns false
+// when the table could not be described.
+func (t *Target) diagnoseTable(ctx context.Context, tableName string) ([]Finding, bool) {
+	output, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: &tableName,
+	})
+	if err != nil {
+		return []Finding{callFinding("table", tableName, "DescribeTable", err)}, false
+	}
+
+	var findings []Finding
+	if problem := tableSchemaProblem(output.Table); problem != "" {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "schema",
+			Table:    tableName,
+			Message:  problem,
+			Fix:      "recreate the table with a single string hash key named \"id\"",
+		})
+	}
+	if tableName == t.lockTableName && len(output.Table.Replicas) > 0 && t.homeRegion == "" {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Check:    "region",
+			Table:    tableName,
+			Message:  "the lock table is a global table, the locks written in different regions can be held at the same time",
+			Fix:      "set the same home region with WithHomeRegion on every runner",
+		})
+	}
+	if output.Table.TableStatus != types.TableStatusActive {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Check:    "table",
+			Table:    tableName,
+			Message:  fmt.Sprintf("table is %s", output.Table.TableStatus),
+			Fix:      "wait for the table to be ACTIVE",
+		})
+	}
+	if !isPayPerRequest(output.Table) {
+		var read, write int64
+		if throughput := output.Table.ProvisionedThroughput; throughput != nil {
+			read, write = aws.ToInt64(throughput.ReadCapacityUnits), aws.ToInt64(throughput.WriteCapacityUnits)
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Check:    "billing",
+			Table:    tableName,
+			Message:  fmt.Sprintf("table uses provisioned capacity (%d read, %d write units), requests may be throttled", read, write),
+			Fix:      "switch the table to on-demand (PAY_PER_REQUEST) or raise its capacity",
+		})
+	}
+	return findings, true
+}
+
+// tableSchemaProblem describes why the schema of the table is not the one created by the Target, if it is not.
+func tableSchemaProblem(table *types.TableDescription) string {
+	if len(table.KeySchema) != 1 || aws.ToString(table.KeySchema[0].AttributeName) != "id" || table.KeySchema[0].KeyType != types.KeyTypeHash {
+		return "table key is not a single hash key named \"id\""
+	}
+	for _, definition := range table.AttributeDefinitions {
+		if aws.ToString(definition.AttributeName) == "id" && definition.AttributeType != types.ScalarAttributeTypeS {
+			return fmt.Sprintf("table key \"id\" is of type %s instead of S", definition.AttributeType)
+		}
+	}
+	return ""
+}
+
+func isPayPerRequest(table *types.TableDescription) bool {
+	return table.BillingModeSummary != nil && table.BillingModeSummary.BillingMode == types.BillingModePayPerRequest
+}
+
+// diagnoseTimeToLive reports the TTL configuration of the lock table. The lock item has no expiration attribute, so a
+// lock left behind by a crashed runner is never released by the TTL.
+func (t *Target) diagnoseTimeToLive(ctx context.Context) []Finding {
+	client, ok := t.client.(TimeToLiveDynamoDBClient)
+	if !ok {
+		return nil
+	}
+	output, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: &t.lockTableName,
+	})
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		return nil
+	case err != nil:
+		return []Finding{callFinding("ttl", t.lockTableName, "DescribeTimeToLive", err)}
+	}
+
+	description := output.TimeToLiveDescription
+	if description != nil && description.TimeToLiveStatus == types.TimeToLiveStatusEnabled {
+		return []Finding{{
+			Severity: SeverityInfo,
+			Check:    "ttl",
+			Table:    t.lockTableName,
+			Message:  fmt.Sprintf("TTL is enabled on the attribute %q, which is not set on the lock item", aws.ToString(description.AttributeName)),
+			Fix:      "a lock left by a crashed runner must be released by deleting the lock item",
+		}}
+	}
+	return []Finding{{
+		Severity: SeverityInfo,
+		Check:    "ttl",
+		Table:    t.lockTableName,
+		Message:  "TTL is disabled, a lock left by a crashed runner is never released automatically",
+		Fix:      "a lock left by a crashed runner must be released by deleting the lock item",
+	}}
+}
+
+// diagnoseWrites probes the permissions to read and write the table, with the probes of Preflight.
+func (t *Target) diagnoseWrites(ctx context.Context, tableName string) []Finding {
+	var findings []Finding
+	for _, probe := range t.writeProbes(ctx, tableName) {
+		err := probe.call()
+		var conditionalCheckFailedException *types.ConditionalCheckFailedException
+		if err == nil || errors.As(err, &conditionalCheckFailedException) {
+			continue
+		}
+		findings = append(findings, callFinding("permissions", tableName, strings.TrimPrefix(probe.action, "dynamodb:"), err))
+	}
+	return findings
+}
+
+// diagnoseState reports the dirty migrations and the lock, when held.
+func (t *Target) diagnoseState(ctx context.Context) []Finding {
+	status, err := t.Status(ctx)
+	if err != nil {
+		return []Finding{{
+			Severity: SeverityError,
+			Check:    "state",
+			Message:  fmt.Sprintf("failed to read the state: %s", err),
+		}}
+	}
+
+	var findings []Finding
+	for _, migration := range status.Migrations {
+		if migration.Dirty {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Check:    "dirty",
+				Table:    t.tableName,
+				Message:  fmt.Sprintf("migration %s is dirty, it failed or is still running", migration.ID),
+				Fix:      "check whether the migration was applied and fix its record before migrating",
+			})
+		}
+	}
+	if status.Lock.Held {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Check:    "lock",
+			Table:    t.lockTableName,
+			Message:  fmt.Sprintf("lock %s is held by %s", t.lockID, status.Lock.Owner),
+			Fix:      "wait for the running migrations to finish, or delete the lock item if its holder crashed",
+		})
+	}
+	return findings
+}
+
+// callFinding describes why a call to the DynamoDB failed.
+func callFinding(check, tableName, action string, err error) Finding {
+	var (
+		resourceNotFoundException *types.ResourceNotFoundException
+		apiErr                    smithy.APIError
+	)
+	switch {
+	case errors.As(err, &resourceNotFoundException):
+		return Finding{
+			Severity: SeverityError,
+			Check:    check,
+			Table:    tableName,
+			Message:  "table does not exist",
+			Fix:      "create the tables (e.g. with Target.Create) before migrating",
+		}
+	case errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDeniedException":
+		return Finding{
+			Severity: SeverityError,
+			Check:    check,
+			Table:    tableName,
+			Message:  fmt.Sprintf("not allowed to call %s", action),
+			Fix:      fmt.Sprintf("grant dynamodb:%s on the table to the credentials used", action),
+		}
+	}
+	return Finding{
+		Severity: SeverityError,
+		Check:    check,
+		Table:    tableName,
+		Message:  fmt.Sprintf("%s failed: %s", action, err),
+	}
+}
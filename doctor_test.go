@@ -0,0 +1,105 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ = Describe("Diagnose", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	findingsOf := func(findings []Finding, severity Severity) []Finding {
+		var r []Finding
+		for _, finding := range findings {
+			if finding.Severity == severity {
+				r = append(r, finding)
+			}
+		}
+		return r
+	}
+
+	When("the tables are healthy", func() {
+		It("should find no problems", func() {
+			target := NewTarget(dynamoDBClient)
+			Expect(target.Create(ctx)).To(Succeed())
+
+			findings, err := target.Diagnose(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(findingsOf(findings, SeverityError)).To(BeEmpty())
+			Expect(findingsOf(findings, SeverityWarning)).To(BeEmpty())
+			Expect(findings).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+				"Check": Equal("ttl"),
+				"Table": Equal("_migrations-lock"),
+			})))
+		})
+	})
+
+	When("the tables do not exist", func() {
+		It("should report them missing", func() {
+			target := NewTarget(dynamoDBClient)
+
+			findings, err := target.Diagnose(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(findings).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"Severity": Equal(SeverityError), "Check": Equal("table"), "Table": Equal("_migrations")}),
+				MatchFields(IgnoreExtras, Fields{"Severity": Equal(SeverityError), "Check": Equal("table"), "Table": Equal("_migrations-lock")}),
+			))
+		})
+	})
+
+	When("a migration is dirty and the lock is held", func() {
+		It("should report both", func() {
+			target := NewTarget(dynamoDBClient, WithOwnerID("owner-1"))
+			Expect(target.Create(ctx)).To(Succeed())
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			_, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			findings, err := target.Diagnose(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(findingsOf(findings, SeverityError)).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"Check": Equal("dirty"), "Message": ContainSubstring("migration 1")}),
+			))
+			Expect(findingsOf(findings, SeverityWarning)).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"Check": Equal("lock"), "Message": ContainSubstring("owner-1")}),
+			))
+		})
+	})
+
+	When("writing is not allowed", func() {
+		It("should report the missing permission", func() {
+			target := NewTarget(&readOnlyClient{Client: dynamoDBClient})
+			Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+
+			findings, err := target.Diagnose(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(findingsOf(findings, SeverityError)).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"Check": Equal("permissions"), "Table": Equal("_migrations"), "Fix": ContainSubstring("dynamodb:PutItem")}),
+				MatchFields(IgnoreExtras, Fields{"Check": Equal("permissions"), "Table": Equal("_migrations-lock"), "Fix": ContainSubstring("dynamodb:PutItem")}),
+			))
+		})
+	})
+})
+
+// readOnlyClient fails putting items with an AccessDeniedException.
+type readOnlyClient struct {
+	*dynamodb.Client
+}
+
+func (c *readOnlyClient) PutItem(_ context.Context, _ *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return nil, &smithy.GenericAPIError{
+		Code:    "AccessDeniedException",
+		Message: "User is not authorized to perform: dynamodb:PutItem",
+	}
+}
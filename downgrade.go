@@ -0,0 +1,52 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrDowngrade is returned by Add and StartMigration, when the downgrade protection is enabled, for a migration with an
+// ID lower than the current migration.
+var ErrDowngrade = errors.New("migration is older than the current migration")
+
+type allowDowngradeKey struct{}
+
+// ContextAllowingDowngrade returns a copy of ctx that lets Add and StartMigration write migrations older than the
+// current one, even with the downgrade protection enabled (see WithDowngradeProtection), e.g. to apply a hotfix out of
+// order on purpose.
+func ContextAllowingDowngrade(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowDowngradeKey{}, true)
+}
+
+// downgradeAllowed checks if the context was returned by ContextAllowingDowngrade.
+func downgradeAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(allowDowngradeKey{}).(bool)
+	return allowed
+}
+
+// guardDowngrade returns an ErrDowngrade if the downgrade protection is enabled and the ID is lower than the greatest
+// ID recorded, dirty or not, other than itself.
+func (t *Target) guardDowngrade(ctx context.Context, id string) error {
+	if !t.downgradeProtection || downgradeAllowed(ctx) {
+		return nil
+	}
+
+	items, err := t.scanMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	var current string
+	for _, item := range items {
+		itemID, ok := item["id"].(*types.AttributeValueMemberS)
+		if ok && itemID.Value != id && itemID.Value > current {
+			current = itemID.Value
+		}
+	}
+	if id < current {
+		return fmt.Errorf("%w: %s is lower than %s", ErrDowngrade, id, current)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Downgrade protection", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient, WithDowngradeProtection())
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "0001")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "0001")).To(Succeed())
+		Expect(target.Add(ctx, "0003")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "0003")).To(Succeed())
+	})
+
+	It("should refuse adding a migration lower than the current one", func() {
+		err := target.Add(ctx, "0002")
+		Expect(err).To(MatchError(ErrDowngrade))
+		Expect(err).To(MatchError(ContainSubstring("0002 is lower than 0003")))
+		Expect(ErrorCodeOf(err)).To(Equal(CodeDowngrade))
+		Expect(target.Done(ctx)).To(Equal([]string{"0001", "0003"}))
+	})
+
+	It("should refuse starting a migration lower than the current one", func() {
+		err := target.StartMigration(ctx, "0001")
+		Expect(err).To(MatchError(ErrDowngrade))
+
+		Expect(target.StartMigration(ctx, "0003")).To(Succeed())
+	})
+
+	It("should add the migrations greater than the current one", func() {
+		Expect(target.Add(ctx, "0004")).To(Succeed())
+	})
+
+	It("should add a migration lower than the current one when explicitly allowed", func() {
+		Expect(target.Add(ContextAllowingDowngrade(ctx), "0002")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "0002")).To(Succeed())
+		Expect(target.Done(ctx)).To(Equal([]string{"0001", "0002", "0003"}))
+	})
+
+	It("should not guard the Targets without the protection", func() {
+		Expect(NewTarget(dynamoDBClient).Add(ctx, "0002")).To(Succeed())
+	})
+})
@@ -0,0 +1,229 @@
+// Package dynamodbtest starts a DynamoDB Local, or LocalStack, container for integration tests of migrations, and
+// returns a client and a Target ready to use:
+//
+//	func TestMigrations(t *testing.T) {
+//		client, target := dynamodbtest.New(t)
+//		_, err := migrations.Migrate(ctx, source, target)
+//		...
+//	}
+//
+// The container is removed when the test finishes. Docker, or another provider supported by testcontainers, must be
+// available.
+package dynamodbtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+const (
+	// DynamoDBLocalImage is the image started by default.
+	DynamoDBLocalImage = "amazon/dynamodb-local:latest"
+	// LocalStackImage is the image started by WithLocalStack.
+	LocalStackImage = "localstack/localstack:latest"
+
+	region = "us-east-1"
+)
+
+type opts struct {
+	image          string
+	port           nat.Port
+	cmd            []string
+	env            map[string]string
+	startupTimeout time.Duration
+	targetOptions  []migrations_dynamodb.Option
+}
+
+// Option configures the container started.
+type Option func(*opts)
+
+func defaultOpts() opts {
+	return opts{
+		image: DynamoDBLocalImage,
+		port:  "8000/tcp",
+		// The shared DB makes the tables visible whatever the region and credentials of the clients.
+		cmd:            []string{"-jar", "DynamoDBLocal.jar", "-inMemory", "-sharedDb"},
+		startupTimeout: time.Minute,
+	}
+}
+
+// WithImage sets the image of DynamoDB Local started, e.g. to pin its version.
+func WithImage(image string) Option {
+	return func(o *opts) {
+		o.image = image
+	}
+}
+
+// WithLocalStack starts LocalStack, with only DynamoDB enabled, instead of DynamoDB Local.
+func WithLocalStack() Option {
+	return func(o *opts) {
+		o.image = LocalStackImage
+		o.port = "4566/tcp"
+		o.cmd = nil
+		o.env = map[string]string{"SERVICES": "dynamodb"}
+	}
+}
+
+// WithStartupTimeout sets how long to wait for the container to accept requests. Defaults to 1 minute.
+func WithStartupTimeout(timeout time.Duration) Option {
+	return func(o *opts) {
+		o.startupTimeout = timeout
+	}
+}
+
+// WithTargetOptions sets the options of the Target created.
+func WithTargetOptions(options ...migrations_dynamodb.Option) Option {
+	return func(o *opts) {
+		o.targetOptions = append(o.targetOptions, options...)
+	}
+}
+
+// Container is a DynamoDB container started by Start.
+type Container struct {
+	// Endpoint is the URL of DynamoDB in the container.
+	Endpoint string
+	// Client is a DynamoDB client calling the container.
+	Client *dynamodb.Client
+	// Target is a Target using the Client, with its tables already created.
+	Target *migrations_dynamodb.Target
+
+	container testcontainers.Container
+}
+
+// Available returns an error if no container provider (e.g. Docker) is available, so the tests can be skipped when
+// it is not.
+func Available(ctx context.Context) (err error) {
+	// testcontainers panics when it cannot find a Docker host.
+	defer recoverError(&err)
+
+	provider, err := testcontainers.ProviderDefault.GetProvider()
+	if err != nil {
+		return fmt.Errorf("failed to get the container provider: %w", err)
+	}
+	defer provider.Close()
+	if err := provider.Health(ctx); err != nil {
+		return fmt.Errorf("the container provider is not healthy: %w", err)
+	}
+	return nil
+}
+
+// Start starts the container, waits for it to accept requests and creates the tables of the Target. The caller must
+// call Terminate when done with it.
+func Start(ctx context.Context, options ...Option) (_ *Container, err error) {
+	defer recoverError(&err)
+
+	o := defaultOpts()
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        o.image,
+			ExposedPorts: []string{string(o.port)},
+			Cmd:          o.cmd,
+			Env:          o.env,
+			WaitingFor:   wait.ForListeningPort(o.port).WithStartupTimeout(o.startupTimeout),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the container: %w", err)
+	}
+	c := &Container{container: container}
+	if err := c.init(ctx, o); err != nil {
+		_ = container.Terminate(context.WithoutCancel(ctx))
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Container) init(ctx context.Context, o opts) error {
+	endpoint, err := c.container.PortEndpoint(ctx, o.port, "http")
+	if err != nil {
+		return fmt.Errorf("failed to get the endpoint of the container: %w", err)
+	}
+	c.Endpoint = endpoint
+	c.Client = newClient(endpoint)
+
+	if err := waitReady(ctx, c.Client, o.startupTimeout); err != nil {
+		return err
+	}
+
+	c.Target = migrations_dynamodb.NewTarget(c.Client, o.targetOptions...)
+	if err := c.Target.Create(ctx); err != nil {
+		return fmt.Errorf("failed to create the migrations tables: %w", err)
+	}
+	return nil
+}
+
+// Terminate removes the container.
+func (c *Container) Terminate(ctx context.Context) error {
+	if err := c.container.Terminate(ctx); err != nil {
+		return fmt.Errorf("failed to terminate the container: %w", err)
+	}
+	return nil
+}
+
+// TB is the part of testing.TB used by New, also implemented by GinkgoT().
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+// New starts a container, as Start, failing the test if it cannot, and removes it when the test finishes.
+func New(t TB, options ...Option) (*dynamodb.Client, *migrations_dynamodb.Target) {
+	t.Helper()
+
+	c, err := Start(context.Background(), options...)
+	if err != nil {
+		t.Fatalf("failed to start DynamoDB: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Terminate(context.Background())
+	})
+	return c.Client, c.Target
+}
+
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("no container provider available: %v", r)
+	}
+}
+
+func newClient(endpoint string) *dynamodb.Client {
+	return dynamodb.NewFromConfig(aws.Config{
+		Region:       region,
+		Credentials:  credentials.NewStaticCredentialsProvider("test", "test", ""),
+		BaseEndpoint: aws.String(endpoint),
+	})
+}
+
+// waitReady waits for DynamoDB to answer, as LocalStack accepts connections before its services are up.
+func waitReady(ctx context.Context, client *dynamodb.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		_, err := client.ListTables(ctx, &dynamodb.ListTablesInput{})
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for DynamoDB to be ready: %w", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
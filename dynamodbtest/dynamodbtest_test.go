@@ -0,0 +1,53 @@
+package dynamodbtest
+
+import (
+	"context"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+var _ = Describe("Options", func() {
+	It("should start DynamoDB Local by default", func() {
+		o := defaultOpts()
+		Expect(o.image).To(Equal(DynamoDBLocalImage))
+		Expect(string(o.port)).To(Equal("8000/tcp"))
+		Expect(o.cmd).To(ContainElement("-sharedDb"))
+	})
+
+	It("should start LocalStack with only DynamoDB", func() {
+		o := defaultOpts()
+		WithLocalStack()(&o)
+		Expect(o.image).To(Equal(LocalStackImage))
+		Expect(string(o.port)).To(Equal("4566/tcp"))
+		Expect(o.cmd).To(BeNil())
+		Expect(o.env).To(Equal(map[string]string{"SERVICES": "dynamodb"}))
+	})
+})
+
+var _ = Describe("New", func() {
+	BeforeEach(func() {
+		if err := Available(context.Background()); err != nil {
+			Skip(err.Error())
+		}
+	})
+
+	It("should return a client and a Target ready to migrate", func() {
+		ctx := context.Background()
+
+		_, target := New(GinkgoT(), WithTargetOptions(migrations_dynamodb.WithTableName("_test_migrations")))
+		Expect(target.TableName()).To(Equal("_test_migrations"))
+
+		source := migrations.NewMemorySource()
+		Expect(source.Add(ctx, migrations.NewMigration("0001", "create users", func(context.Context) error {
+			return nil
+		}, nil))).To(Succeed())
+
+		_, err := migrations.Migrate(ctx, source, target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Done(ctx)).To(Equal([]string{"0001"}))
+	})
+})
@@ -0,0 +1,148 @@
+package dynamodbtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// EnvUpdateGolden is the environment variable that, when set to a non-empty value, makes ExpectGolden write the golden
+// files instead of comparing the snapshots with them.
+const EnvUpdateGolden = "DYNAMODBTEST_UPDATE_GOLDEN"
+
+// Normalized replaces the values of the attributes that change between runs in the snapshots.
+const Normalized = "<normalized>"
+
+// defaultNormalizedAttributes are the attributes recorded by the Target that change between runs.
+var defaultNormalizedAttributes = []string{"started_at", "applied_at", "applied_by", "correlation_id"}
+
+type goldenOpts struct {
+	normalized map[string]struct{}
+	lockOwner  bool
+}
+
+// GoldenOption configures the snapshots of Snapshot and ExpectGolden.
+type GoldenOption func(*goldenOpts)
+
+// WithNormalizedAttributes normalizes the attributes of the migrations, besides the timestamps, the applier and the
+// correlation ID recorded by the Target, e.g. the attributes a migration stores with values that change between runs.
+func WithNormalizedAttributes(attributes ...string) GoldenOption {
+	return func(o *goldenOpts) {
+		for _, attribute := range attributes {
+			o.normalized[attribute] = struct{}{}
+		}
+	}
+}
+
+// WithLockOwner keeps the owner of the lock in the snapshots, for the Targets with a fixed owner ID (see
+// migrations_dynamodb.WithOwnerID). By default, it is normalized.
+func WithLockOwner() GoldenOption {
+	return func(o *goldenOpts) {
+		o.lockOwner = true
+	}
+}
+
+// goldenSnapshot is the document compared with the golden files.
+type goldenSnapshot struct {
+	Table string                         `json:"table"`
+	Lock  migrations_dynamodb.LockStatus `json:"lock"`
+	Items []map[string]any               `json:"items"`
+}
+
+// Snapshot dumps the migrations table of the target, and the state of its lock, as an indented JSON document with the
+// values that change between runs normalized, so the state of the runs is the same document every time. The items
+// are sorted by ID, and their attributes by name.
+func Snapshot(ctx context.Context, target *migrations_dynamodb.Target, options ...GoldenOption) ([]byte, error) {
+	o := goldenOpts{normalized: make(map[string]struct{}, len(defaultNormalizedAttributes))}
+	WithNormalizedAttributes(defaultNormalizedAttributes...)(&o)
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	export, err := target.Export(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export the migrations: %w", err)
+	}
+
+	snapshot := goldenSnapshot{Table: export.Table, Lock: export.Lock, Items: export.Items}
+	if snapshot.Lock.Owner != "" && !o.lockOwner {
+		snapshot.Lock.Owner = Normalized
+	}
+	for _, item := range snapshot.Items {
+		for attribute := range o.normalized {
+			if v, ok := item[attribute]; ok && v != "" {
+				item[attribute] = Normalized
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to encode the snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ExpectGolden compares the Snapshot of the target with the golden file at path, failing the test, with the first
+// line that differs, if they are not the same. When the EnvUpdateGolden environment variable is set, the golden file
+// is written with the snapshot instead.
+func ExpectGolden(t TB, target *migrations_dynamodb.Target, path string, options ...GoldenOption) {
+	t.Helper()
+
+	got, err := Snapshot(context.Background(), target, options...)
+	if err != nil {
+		t.Fatalf("failed to snapshot the state tables: %s", err)
+		return
+	}
+
+	if os.Getenv(EnvUpdateGolden) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create the directory of the golden file: %s", err)
+			return
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to write the golden file: %s", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the golden file (set %s=1 to create it): %s", EnvUpdateGolden, err)
+		return
+	}
+	if diff := firstDiff(want, got); diff != "" {
+		t.Fatalf("the state tables differ from the golden file %s (set %s=1 to update it):\n%s", path, EnvUpdateGolden, diff)
+	}
+}
+
+// firstDiff describes the first line that differs between want and got, or returns empty if they are the same.
+func firstDiff(want, got []byte) string {
+	if bytes.Equal(want, got) {
+		return ""
+	}
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %s\n   got: %s", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package dynamodbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+	"github.com/jamillosantos/migrations-dynamodb/mocks"
+)
+
+// recordingTB records the failures of the test instead of failing it.
+type recordingTB struct {
+	failures []string
+}
+
+func (*recordingTB) Helper() {}
+
+func (*recordingTB) Cleanup(func()) {}
+
+func (t *recordingTB) Fatalf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+var _ = Describe("Golden snapshots", func() {
+	var (
+		target     *migrations_dynamodb.Target
+		startedAt  string
+		lockHolder string
+	)
+
+	BeforeEach(func() {
+		startedAt = "2024-01-01T00:00:00Z"
+		lockHolder = "runner-1"
+
+		client := mocks.NewDynamoDBClient(gomock.NewController(GinkgoT()))
+		client.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			if aws.ToString(input.TableName) == "_migrations-lock" {
+				return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{{
+					"id":    &types.AttributeValueMemberS{Value: "migrations"},
+					"owner": &types.AttributeValueMemberS{Value: lockHolder},
+				}}}, nil
+			}
+			return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{
+				{
+					"id":         &types.AttributeValueMemberS{Value: "0002"},
+					"dirty":      &types.AttributeValueMemberBOOL{Value: true},
+					"started_at": &types.AttributeValueMemberS{Value: startedAt},
+				},
+				{
+					"id":         &types.AttributeValueMemberS{Value: "0001"},
+					"dirty":      &types.AttributeValueMemberBOOL{Value: false},
+					"started_at": &types.AttributeValueMemberS{Value: startedAt},
+					"applied_at": &types.AttributeValueMemberS{Value: startedAt},
+					"applied_by": &types.AttributeValueMemberS{Value: "ci"},
+				},
+			}}, nil
+		}).AnyTimes()
+		target = migrations_dynamodb.NewTarget(client)
+	})
+
+	It("should match the golden file whatever the values that change between runs", func() {
+		t := &recordingTB{}
+		ExpectGolden(t, target, "testdata/golden.json")
+		Expect(t.failures).To(BeEmpty())
+
+		startedAt = "2024-06-01T12:30:00Z"
+		lockHolder = "runner-2"
+		ExpectGolden(t, target, "testdata/golden.json")
+		Expect(t.failures).To(BeEmpty())
+	})
+
+	It("should fail with the first line that differs from the golden file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "golden.json")
+		Expect(os.WriteFile(path, []byte("{\n  \"table\": \"_other\"\n}\n"), 0o644)).To(Succeed())
+
+		t := &recordingTB{}
+		ExpectGolden(t, target, path)
+		Expect(t.failures).To(ConsistOf(And(
+			ContainSubstring("line 2:"),
+			ContainSubstring(`want:   "table": "_other"`),
+			ContainSubstring(`got:   "table": "_migrations",`),
+		)))
+	})
+
+	It("should keep the owner of the lock and normalize the attributes given", func() {
+		data, err := Snapshot(context.Background(), target, WithLockOwner(), WithNormalizedAttributes("dirty"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"owner": "runner-1"`))
+		Expect(string(data)).To(ContainSubstring(`"dirty": "<normalized>"`))
+	})
+
+	It("should write the golden file when updating", func() {
+		GinkgoT().Setenv(EnvUpdateGolden, "1")
+		path := filepath.Join(GinkgoT().TempDir(), "golden", "state.json")
+
+		t := &recordingTB{}
+		ExpectGolden(t, target, path)
+		Expect(t.failures).To(BeEmpty())
+
+		want, err := os.ReadFile("testdata/golden.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.ReadFile(path)).To(Equal(want))
+	})
+})
@@ -0,0 +1,13 @@
+package dynamodbtest
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "migrations/dynamodb/dynamodbtest")
+}
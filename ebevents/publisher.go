@@ -0,0 +1,180 @@
+// Package ebevents publishes the migrations applied by a Target to an Amazon EventBridge bus, so downstream
+// automations (cache warmers, smoke tests, ...) can be triggered when a run completes.
+package ebevents
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+const (
+	// DetailType is the detail type of the events published.
+	DetailType = "migrations.applied"
+
+	// DefaultSource is the source of the events published when WithSource is not set.
+	DefaultSource = "migrations-dynamodb"
+)
+
+type PutEventsClient interface {
+	PutEvents(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// Detail is the detail of the events published.
+type Detail struct {
+	RunID      string   `json:"runId"`
+	Migrations []string `json:"migrations"`
+	DurationMS int64    `json:"durationMs"`
+}
+
+type opts struct {
+	eventBusName string
+	source       string
+	errorHandler func(ctx context.Context, err error)
+}
+
+type Option func(*opts)
+
+// WithEventBusName sets the name (or ARN) of the event bus the events are put onto. By default, the default bus of the
+// account is used.
+func WithEventBusName(eventBusName string) Option {
+	return func(o *opts) {
+		o.eventBusName = eventBusName
+	}
+}
+
+// WithSource sets the source of the events published.
+func WithSource(source string) Option {
+	return func(o *opts) {
+		o.source = source
+	}
+}
+
+// WithErrorHandler sets a function called when an event could not be published. As publishing happens after the run
+// is complete, errors are not returned to the runner.
+func WithErrorHandler(handler func(ctx context.Context, err error)) Option {
+	return func(o *opts) {
+		o.errorHandler = handler
+	}
+}
+
+// Publisher is a migrations_dynamodb.Listener that puts a "migrations.applied" event onto an EventBridge bus after each
+// successful run. A run spans from the lock being acquired to it being released; it is successful when no
+// MigrationFailed event was emitted in between. Runs that applied no migrations are not published.
+type Publisher struct {
+	client PutEventsClient
+
+	eventBusName string
+	source       string
+	errorHandler func(ctx context.Context, err error)
+	now          func() time.Time
+
+	mu  sync.Mutex
+	run *run
+}
+
+type run struct {
+	id         string
+	startedAt  time.Time
+	migrations []string
+	failed     bool
+}
+
+// New creates a Publisher putting the events with the given client. The Publisher must be set with
+// migrations_dynamodb.WithListener.
+func New(client PutEventsClient, opts ...Option) *Publisher {
+	options := defaultOpts()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Publisher{
+		client:       client,
+		eventBusName: options.eventBusName,
+		source:       options.source,
+		errorHandler: options.errorHandler,
+		now:          time.Now,
+	}
+}
+
+func defaultOpts() opts {
+	return opts{
+		source:       DefaultSource,
+		errorHandler: func(context.Context, error) {},
+	}
+}
+
+// OnEvent implements migrations_dynamodb.Listener.
+func (p *Publisher) OnEvent(ctx context.Context, event migrations_dynamodb.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch e := event.(type) {
+	case migrations_dynamodb.LockAcquired:
+		p.run = &run{id: newRunID(), startedAt: p.now()}
+	case migrations_dynamodb.MigrationFinished:
+		if p.run != nil {
+			p.run.migrations = append(p.run.migrations, e.ID)
+		}
+	case migrations_dynamodb.MigrationFailed:
+		if p.run != nil {
+			p.run.failed = true
+		}
+	case migrations_dynamodb.LockReleased:
+		r := p.run
+		p.run = nil
+		if r == nil || r.failed || len(r.migrations) == 0 {
+			return
+		}
+		if err := p.publish(ctx, r); err != nil {
+			p.errorHandler(ctx, err)
+		}
+	}
+}
+
+func (p *Publisher) publish(ctx context.Context, r *run) error {
+	detail, err := json.Marshal(Detail{
+		RunID:      r.id,
+		Migrations: r.migrations,
+		DurationMS: p.now().Sub(r.startedAt).Milliseconds(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event detail: %w", err)
+	}
+
+	entry := types.PutEventsRequestEntry{
+		Source:     aws.String(p.source),
+		DetailType: aws.String(DetailType),
+		Detail:     aws.String(string(detail)),
+		Time:       aws.Time(p.now()),
+	}
+	if p.eventBusName != "" {
+		entry.EventBusName = aws.String(p.eventBusName)
+	}
+
+	output, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{entry},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put event: %w", err)
+	}
+	if output.FailedEntryCount > 0 && len(output.Entries) > 0 {
+		return fmt.Errorf("failed to put event: %s: %s", aws.ToString(output.Entries[0].ErrorCode), aws.ToString(output.Entries[0].ErrorMessage))
+	}
+	return nil
+}
+
+func newRunID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,133 @@
+package ebevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+type fakePutEventsClient struct {
+	inputs []*eventbridge.PutEventsInput
+	output *eventbridge.PutEventsOutput
+	err    error
+}
+
+func (c *fakePutEventsClient) PutEvents(_ context.Context, input *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	c.inputs = append(c.inputs, input)
+	if c.output == nil {
+		return &eventbridge.PutEventsOutput{}, c.err
+	}
+	return c.output, c.err
+}
+
+var _ = Describe("Publisher", func() {
+	var (
+		ctx    context.Context
+		client *fakePutEventsClient
+		now    time.Time
+		errs   []error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = &fakePutEventsClient{}
+		now = time.Unix(1700000000, 0)
+		errs = nil
+	})
+
+	newPublisher := func(opts ...Option) *Publisher {
+		opts = append(opts, WithErrorHandler(func(_ context.Context, err error) {
+			errs = append(errs, err)
+		}))
+		p := New(client, opts...)
+		p.now = func() time.Time { return now }
+		return p
+	}
+
+	run := func(p *Publisher, events ...migrations_dynamodb.Event) {
+		p.OnEvent(ctx, migrations_dynamodb.LockAcquired{LockID: "migrations"})
+		for _, event := range events {
+			p.OnEvent(ctx, event)
+		}
+		now = now.Add(3 * time.Second)
+		p.OnEvent(ctx, migrations_dynamodb.LockReleased{LockID: "migrations"})
+	}
+
+	It("should publish the migrations applied at the end of a successful run", func() {
+		p := newPublisher(WithEventBusName("deployments"), WithSource("billing"))
+		run(p,
+			migrations_dynamodb.MigrationAdded{ID: "1"},
+			migrations_dynamodb.MigrationFinished{ID: "1"},
+			migrations_dynamodb.MigrationAdded{ID: "2"},
+			migrations_dynamodb.MigrationFinished{ID: "2"},
+		)
+
+		Expect(errs).To(BeEmpty())
+		Expect(client.inputs).To(HaveLen(1))
+		Expect(client.inputs[0].Entries).To(HaveLen(1))
+		entry := client.inputs[0].Entries[0]
+		Expect(aws.ToString(entry.EventBusName)).To(Equal("deployments"))
+		Expect(aws.ToString(entry.Source)).To(Equal("billing"))
+		Expect(aws.ToString(entry.DetailType)).To(Equal(DetailType))
+
+		var detail Detail
+		Expect(json.Unmarshal([]byte(aws.ToString(entry.Detail)), &detail)).To(Succeed())
+		Expect(detail.RunID).ToNot(BeEmpty())
+		Expect(detail.Migrations).To(Equal([]string{"1", "2"}))
+		Expect(detail.DurationMS).To(BeEquivalentTo(3000))
+	})
+
+	It("should not publish when a migration failed", func() {
+		p := newPublisher()
+		run(p,
+			migrations_dynamodb.MigrationFinished{ID: "1"},
+			migrations_dynamodb.MigrationFailed{ID: "2", Operation: "FinishMigration", Err: errors.New("random error")},
+		)
+
+		Expect(client.inputs).To(BeEmpty())
+	})
+
+	It("should not publish when no migration was applied", func() {
+		p := newPublisher()
+		run(p)
+
+		Expect(client.inputs).To(BeEmpty())
+	})
+
+	It("should use a new run ID for each run", func() {
+		p := newPublisher()
+		run(p, migrations_dynamodb.MigrationFinished{ID: "1"})
+		run(p, migrations_dynamodb.MigrationFinished{ID: "2"})
+
+		Expect(client.inputs).To(HaveLen(2))
+		Expect(client.inputs[0].Entries[0].EventBusName).To(BeNil())
+		var first, second Detail
+		Expect(json.Unmarshal([]byte(aws.ToString(client.inputs[0].Entries[0].Detail)), &first)).To(Succeed())
+		Expect(json.Unmarshal([]byte(aws.ToString(client.inputs[1].Entries[0].Detail)), &second)).To(Succeed())
+		Expect(first.RunID).ToNot(Equal(second.RunID))
+		Expect(second.Migrations).To(Equal([]string{"2"}))
+	})
+
+	It("should report the errors to the error handler", func() {
+		client.output = &eventbridge.PutEventsOutput{
+			FailedEntryCount: 1,
+			Entries: []types.PutEventsResultEntry{
+				{ErrorCode: aws.String("InternalFailure"), ErrorMessage: aws.String("random error")},
+			},
+		}
+		p := newPublisher()
+		run(p, migrations_dynamodb.MigrationFinished{ID: "1"})
+
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError(ContainSubstring("InternalFailure")))
+	})
+})
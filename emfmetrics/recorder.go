@@ -0,0 +1,148 @@
+// Package emfmetrics implements a migrations_dynamodb.MetricsRecorder that writes the metrics as CloudWatch Embedded
+// Metric Format (EMF) log lines. When the lines are written to the standard output of a Lambda function (or any
+// runner shipping its logs to CloudWatch Logs), CloudWatch extracts the metrics without any extra infrastructure.
+package emfmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultNamespace is the CloudWatch namespace used when WithNamespace is not set.
+const DefaultNamespace = "migrations-dynamodb"
+
+type opts struct {
+	namespace  string
+	dimensions map[string]string
+}
+
+type Option func(*opts)
+
+// WithNamespace sets the CloudWatch namespace of the metrics.
+func WithNamespace(namespace string) Option {
+	return func(o *opts) {
+		o.namespace = namespace
+	}
+}
+
+// WithDimensions adds dimensions (e.g. the service or environment) to all the metrics written.
+func WithDimensions(dimensions map[string]string) Option {
+	return func(o *opts) {
+		for k, v := range dimensions {
+			o.dimensions[k] = v
+		}
+	}
+}
+
+// Recorder writes the metrics of a Target as EMF log lines, one JSON object per line.
+type Recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	namespace  string
+	dimensions map[string]string
+	now        func() time.Time
+}
+
+// New creates a Recorder writing to w. Writes are serialized, so w can be shared by concurrent Targets.
+func New(w io.Writer, opts ...Option) *Recorder {
+	options := defaultOpts()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Recorder{
+		w:          w,
+		namespace:  options.namespace,
+		dimensions: options.dimensions,
+		now:        time.Now,
+	}
+}
+
+func defaultOpts() opts {
+	return opts{
+		namespace:  DefaultNamespace,
+		dimensions: map[string]string{},
+	}
+}
+
+// RecordOperation implements migrations_dynamodb.MetricsRecorder. It writes the Duration (in milliseconds) and Errors
+// metrics with the Operation dimension.
+func (r *Recorder) RecordOperation(_ context.Context, operation string, duration time.Duration, err error) {
+	errs := 0
+	if err != nil {
+		errs = 1
+	}
+	r.write(map[string]string{"Operation": operation}, []metric{
+		{Name: "Duration", Unit: "Milliseconds", value: milliseconds(duration)},
+		{Name: "Errors", Unit: "Count", value: errs},
+	})
+}
+
+// RecordLockWait implements migrations_dynamodb.MetricsRecorder. It writes the LockWait metric (in milliseconds).
+func (r *Recorder) RecordLockWait(_ context.Context, duration time.Duration) {
+	r.write(nil, []metric{
+		{Name: "LockWait", Unit: "Milliseconds", value: milliseconds(duration)},
+	})
+}
+
+type metric struct {
+	Name  string `json:"Name"`
+	Unit  string `json:"Unit"`
+	value any
+}
+
+type metricDirective struct {
+	Namespace  string     `json:"Namespace"`
+	Dimensions [][]string `json:"Dimensions"`
+	Metrics    []metric   `json:"Metrics"`
+}
+
+type metadata struct {
+	Timestamp         int64             `json:"Timestamp"`
+	CloudWatchMetrics []metricDirective `json:"CloudWatchMetrics"`
+}
+
+// write writes a single EMF line with the given metrics and the dimensions of the recorder plus the given ones.
+// Errors writing are ignored, as metrics should never fail the migrations.
+func (r *Recorder) write(dimensions map[string]string, metrics []metric) {
+	line := make(map[string]any, len(r.dimensions)+len(dimensions)+len(metrics)+1)
+	keys := make([]string, 0, len(r.dimensions)+len(dimensions))
+	for _, d := range []map[string]string{r.dimensions, dimensions} {
+		for k, v := range d {
+			if _, ok := line[k]; !ok {
+				keys = append(keys, k)
+			}
+			line[k] = v
+		}
+	}
+	sort.Strings(keys)
+	for _, m := range metrics {
+		line[m.Name] = m.value
+	}
+	line["_aws"] = metadata{
+		Timestamp: r.now().UnixMilli(),
+		CloudWatchMetrics: []metricDirective{{
+			Namespace:  r.namespace,
+			Dimensions: [][]string{keys},
+			Metrics:    metrics,
+		}},
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(data)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,78 @@
+package emfmetrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recorder", func() {
+	var (
+		ctx context.Context
+		buf *bytes.Buffer
+		now = time.UnixMilli(1700000000000)
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		buf = &bytes.Buffer{}
+	})
+
+	newRecorder := func(opts ...Option) *Recorder {
+		r := New(buf, opts...)
+		r.now = func() time.Time { return now }
+		return r
+	}
+
+	It("should write operations as EMF lines", func() {
+		r := newRecorder()
+		r.RecordOperation(ctx, "Add", 1500*time.Microsecond, errors.New("random error"))
+
+		Expect(buf.String()).To(MatchJSON(`{
+			"_aws": {
+				"Timestamp": 1700000000000,
+				"CloudWatchMetrics": [{
+					"Namespace": "migrations-dynamodb",
+					"Dimensions": [["Operation"]],
+					"Metrics": [
+						{"Name": "Duration", "Unit": "Milliseconds"},
+						{"Name": "Errors", "Unit": "Count"}
+					]
+				}]
+			},
+			"Operation": "Add",
+			"Duration": 1.5,
+			"Errors": 1
+		}`))
+	})
+
+	It("should write the lock wait with the namespace and dimensions given", func() {
+		r := newRecorder(WithNamespace("custom"), WithDimensions(map[string]string{"Service": "billing"}))
+		r.RecordLockWait(ctx, 2*time.Second)
+
+		Expect(buf.String()).To(MatchJSON(`{
+			"_aws": {
+				"Timestamp": 1700000000000,
+				"CloudWatchMetrics": [{
+					"Namespace": "custom",
+					"Dimensions": [["Service"]],
+					"Metrics": [{"Name": "LockWait", "Unit": "Milliseconds"}]
+				}]
+			},
+			"Service": "billing",
+			"LockWait": 2000
+		}`))
+	})
+
+	It("should write one line per record", func() {
+		r := newRecorder()
+		r.RecordOperation(ctx, "Add", time.Millisecond, nil)
+		r.RecordOperation(ctx, "Done", time.Millisecond, nil)
+
+		Expect(bytes.Count(buf.Bytes(), []byte("\n"))).To(Equal(2))
+	})
+})
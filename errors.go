@@ -0,0 +1,225 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/jamillosantos/migrations/v2"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+var (
+	// ErrLockNotHeld is returned when a write is executed with transactional writes enabled, or a lock is released, but
+	// the lock is not held by the Target anymore.
+	ErrLockNotHeld = errors.New("migrations lock is not held by this target")
+
+	// ErrTableNotFound is returned when a table used by the Target does not exist.
+	ErrTableNotFound = errors.New("table not found")
+
+	// ErrPermissionDenied is returned when the credentials used by the DynamoDB client are not allowed to perform an
+	// operation.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrConnectivity is returned when the DynamoDB could not be reached.
+	ErrConnectivity = errors.New("could not reach the DynamoDB")
+)
+
+// kmsErrorCodes are the codes of the errors of the DynamoDB when the KMS key encrypting a table cannot be used.
+var kmsErrorCodes = []string{
+	"KMSAccessDeniedException", "KMSDisabledException", "KMSInvalidStateException", "KMSNotFoundException",
+}
+
+// PermissionDeniedError is the error returned by the operations of the Target when the credentials of the client are
+// not allowed to call the DynamoDB, or to use the KMS key encrypting the table. It names the operation and the table,
+// so a misconfigured IAM policy is explained by the error itself. It matches ErrPermissionDenied with `errors.Is`.
+type PermissionDeniedError struct {
+	// Operation is the Target method that was denied (e.g. "Create" or "Lock").
+	Operation string
+	// Table is the table the operation was executed against. It is empty for operations using more than one table.
+	Table string
+	// KMS is whether the permission missing is to use the KMS key encrypting the table.
+	KMS bool
+	Err error
+}
+
+func (e *PermissionDeniedError) Error() string {
+	subject := e.Operation
+	if e.Table != "" {
+		subject = fmt.Sprintf("%s on table %s", e.Operation, e.Table)
+	}
+	if e.KMS {
+		return fmt.Sprintf("permission denied to use the KMS key for %s: %s", subject, e.Err)
+	}
+	return fmt.Sprintf("permission denied for %s: %s", subject, e.Err)
+}
+
+func (e *PermissionDeniedError) Is(target error) bool {
+	return target == ErrPermissionDenied
+}
+
+func (e *PermissionDeniedError) Unwrap() error {
+	return e.Err
+}
+
+// accessDenied checks if the error was caused by the credentials not being allowed to call the DynamoDB, or to use the
+// KMS key of the table.
+func accessDenied(err error) (denied, kms bool) {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false, false
+	}
+	switch code := apiErr.ErrorCode(); {
+	case code == "AccessDeniedException":
+		return true, strings.Contains(apiErr.ErrorMessage(), "kms:")
+	case slices.Contains(kmsErrorCodes, code):
+		return true, true
+	}
+	return false, false
+}
+
+// ErrorCode is a stable, machine-readable code identifying the kind of an error returned by the Target, meant to be
+// used by alerting rules and tooling instead of matching the error messages.
+type ErrorCode string
+
+const (
+	CodeDirty            ErrorCode = "E_DIRTY"
+	CodeNotFound         ErrorCode = "E_NOT_FOUND"
+	CodeAlreadyExists    ErrorCode = "E_ALREADY_EXISTS"
+	CodeNoCurrent        ErrorCode = "E_NO_CURRENT"
+	CodeLockNotHeld      ErrorCode = "E_LOCK_NOT_HELD"
+	CodeLockTimeout      ErrorCode = "E_LOCK_TIMEOUT"
+	CodeTableMissing     ErrorCode = "E_TABLE_MISSING"
+	CodeTableMismatch    ErrorCode = "E_TABLE_MISMATCH"
+	CodeDowngrade        ErrorCode = "E_DOWNGRADE"
+	CodeMaintenance      ErrorCode = "E_MAINTENANCE"
+	CodeApprovalPending  ErrorCode = "E_APPROVAL_PENDING"
+	CodeInvalidPhase     ErrorCode = "E_INVALID_PHASE"
+	CodePermissionDenied ErrorCode = "E_PERMISSION_DENIED"
+	CodeThrottled        ErrorCode = "E_THROTTLED"
+	CodeConnectivity     ErrorCode = "E_CONNECTIVITY"
+	CodeTimeout          ErrorCode = "E_TIMEOUT"
+	CodeCanceled         ErrorCode = "E_CANCELED"
+	CodeUnknown          ErrorCode = "E_UNKNOWN"
+)
+
+// TargetError is the type of all errors returned by the Target. It keeps the original error, so `errors.Is` and
+// `errors.As` work as if it was not wrapped (e.g. `errors.Is(err, migrations.ErrMigrationNotFound)`).
+type TargetError struct {
+	// Operation is the Target method that failed (e.g. "Add" or "Lock").
+	Operation string
+	// Table is the table the operation was executed against. It is empty for operations using more than one table.
+	Table string
+	Code  ErrorCode
+	Err   error
+}
+
+func (e *TargetError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCodeOf returns the code of the TargetError in the chain of err. It returns an empty code if err is nil and
+// CodeUnknown if it was not returned by a Target.
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	var targetErr *TargetError
+	if errors.As(err, &targetErr) {
+		return targetErr.Code
+	}
+	return CodeUnknown
+}
+
+// wrapError wraps the error returned by an operation in a TargetError. It is meant to be deferred by the operations
+// with a pointer to their returned error, after any other deferred call that should see the wrapped error. Errors
+// already wrapped (e.g. returned by another operation) are kept as they are.
+func wrapError(operation, table string, err *error) {
+	if *err == nil {
+		return
+	}
+	var targetErr *TargetError
+	if errors.As(*err, &targetErr) {
+		return
+	}
+	if denied, kms := accessDenied(*err); denied && !errors.Is(*err, ErrPermissionDenied) {
+		*err = &PermissionDeniedError{Operation: operation, Table: table, KMS: kms, Err: *err}
+	}
+	*err = &TargetError{
+		Operation: operation,
+		Table:     table,
+		Code:      errorCode(*err),
+		Err:       *err,
+	}
+}
+
+// lockTimeoutError marks the errors of Lock caused by the deadline of its context, as opposed to the deadline of one of
+// the calls it makes (see WithOperationTimeout), which is an E_TIMEOUT.
+type lockTimeoutError struct {
+	error
+}
+
+func (e *lockTimeoutError) Unwrap() error {
+	return e.error
+}
+
+// markLockTimeout wraps the error in a lockTimeoutError when it was caused by the deadline of the context. It is meant
+// to be deferred by Lock after wrapError, so it runs before it.
+func markLockTimeout(ctx context.Context, err *error) {
+	if *err != nil && errors.Is(*err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		*err = &lockTimeoutError{*err}
+	}
+}
+
+func errorCode(err error) ErrorCode {
+	var (
+		resourceNotFoundException *types.ResourceNotFoundException
+		lockTimeout               *lockTimeoutError
+	)
+	switch {
+	case errors.Is(err, migrations.ErrDirtyMigration):
+		return CodeDirty
+	case errors.Is(err, migrations.ErrMigrationNotFound):
+		return CodeNotFound
+	case errors.Is(err, migrations.ErrMigrationAlreadyExists):
+		return CodeAlreadyExists
+	case errors.Is(err, migrations.ErrNoCurrentMigration):
+		return CodeNoCurrent
+	case errors.Is(err, ErrLockNotHeld):
+		return CodeLockNotHeld
+	case errors.Is(err, ErrTableNotFound), errors.As(err, &resourceNotFoundException):
+		return CodeTableMissing
+	case errors.Is(err, ErrTableMismatch):
+		return CodeTableMismatch
+	case errors.Is(err, ErrDowngrade):
+		return CodeDowngrade
+	case errors.Is(err, ErrMaintenanceClosed):
+		return CodeMaintenance
+	case errors.Is(err, ErrApprovalPending):
+		return CodeApprovalPending
+	case errors.Is(err, ErrInvalidPhase):
+		return CodeInvalidPhase
+	case errors.Is(err, ErrPermissionDenied):
+		return CodePermissionDenied
+	case throttle.IsThrottlingError(err):
+		return CodeThrottled
+	case errors.As(err, &lockTimeout):
+		return CodeLockTimeout
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case errors.Is(err, ErrConnectivity):
+		return CodeConnectivity
+	}
+	return CodeUnknown
+}
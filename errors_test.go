@@ -0,0 +1,121 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Error codes", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+	})
+
+	When("the tables exist", func() {
+		BeforeEach(func() {
+			Expect(target.Create(ctx)).To(Succeed())
+		})
+
+		It("should wrap the errors with the operation, table and code", func() {
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			err := target.Add(ctx, "1")
+
+			var targetErr *TargetError
+			Expect(errors.As(err, &targetErr)).To(BeTrue())
+			Expect(targetErr.Operation).To(Equal(operationAdd))
+			Expect(targetErr.Table).To(Equal("_migrations"))
+			Expect(targetErr.Code).To(Equal(CodeAlreadyExists))
+			Expect(err).To(MatchError(migrations.ErrMigrationAlreadyExists))
+		})
+
+		It("should return E_NOT_FOUND for a missing migration", func() {
+			Expect(ErrorCodeOf(target.FinishMigration(ctx, "1"))).To(Equal(CodeNotFound))
+		})
+
+		It("should return E_DIRTY for a dirty migration", func() {
+			Expect(target.Add(ctx, "1")).To(Succeed())
+
+			_, err := target.Done(ctx)
+			Expect(ErrorCodeOf(err)).To(Equal(CodeDirty))
+		})
+
+		It("should return E_NO_CURRENT when there is no current migration", func() {
+			_, err := target.Current(ctx)
+			Expect(ErrorCodeOf(err)).To(Equal(CodeNoCurrent))
+
+			var targetErr *TargetError
+			Expect(errors.As(err, &targetErr)).To(BeTrue())
+			Expect(targetErr.Operation).To(Equal(operationCurrent))
+		})
+	})
+
+	It("should return E_TABLE_MISSING when the table does not exist", func() {
+		_, err := target.Done(ctx)
+		Expect(ErrorCodeOf(err)).To(Equal(CodeTableMissing))
+
+		Expect(ErrorCodeOf(target.Ping(ctx))).To(Equal(CodeTableMissing))
+	})
+
+	It("should return a PermissionDeniedError naming the operation and table when the access is denied", func() {
+		Expect(target.Create(ctx)).To(Succeed())
+		target = NewTarget(&deniedClient{Client: dynamoDBClient, code: "AccessDeniedException",
+			message: "User is not authorized to perform: dynamodb:PutItem"})
+
+		err := target.Add(ctx, "1")
+		Expect(err).To(MatchError(ErrPermissionDenied))
+		Expect(err).To(MatchError(ContainSubstring("permission denied for Add on table _migrations")))
+		Expect(ErrorCodeOf(err)).To(Equal(CodePermissionDenied))
+
+		var deniedErr *PermissionDeniedError
+		Expect(errors.As(err, &deniedErr)).To(BeTrue())
+		Expect(deniedErr.Operation).To(Equal(operationAdd))
+		Expect(deniedErr.Table).To(Equal("_migrations"))
+		Expect(deniedErr.KMS).To(BeFalse())
+	})
+
+	DescribeTable("should return a PermissionDeniedError when the KMS key of the table cannot be used",
+		func(code, message string) {
+			Expect(target.Create(ctx)).To(Succeed())
+			target = NewTarget(&deniedClient{Client: dynamoDBClient, code: code, message: message})
+
+			err := target.Add(ctx, "1")
+			Expect(err).To(MatchError(ErrPermissionDenied))
+			Expect(err).To(MatchError(ContainSubstring("permission denied to use the KMS key for Add on table _migrations")))
+
+			var deniedErr *PermissionDeniedError
+			Expect(errors.As(err, &deniedErr)).To(BeTrue())
+			Expect(deniedErr.KMS).To(BeTrue())
+		},
+		Entry("with an access denied to the key", "AccessDeniedException", "User is not authorized to perform: kms:Decrypt"),
+		Entry("with the key disabled", "KMSDisabledException", "The KMS key is disabled"),
+	)
+
+	It("should return no code for nil errors and E_UNKNOWN for errors not returned by the Target", func() {
+		Expect(ErrorCodeOf(nil)).To(BeEmpty())
+		Expect(ErrorCodeOf(errors.New("random error"))).To(Equal(CodeUnknown))
+	})
+})
+
+// deniedClient fails putting items with an API error with the code and message.
+type deniedClient struct {
+	*dynamodb.Client
+	code, message string
+}
+
+func (c *deniedClient) PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return nil, &smithy.GenericAPIError{Code: c.code, Message: c.message}
+}
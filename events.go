@@ -0,0 +1,125 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+)
+
+// Event is an event of the lifecycle of the migrations emitted by a Target to its Listener. It is one of
+// MigrationAdded, MigrationStarted, MigrationPrepared, MigrationFinished, MigrationFailed, DirtyMigrationDetected,
+// LockAcquired or LockReleased.
+type Event interface {
+	event()
+}
+
+// MigrationAdded is emitted when a migration is added to the target.
+type MigrationAdded struct {
+	ID string
+}
+
+// MigrationStarted is emitted when a migration is marked as started.
+type MigrationStarted struct {
+	ID string
+}
+
+// MigrationPrepared is emitted when a migration is marked as prepared, by PrepareMigration.
+type MigrationPrepared struct {
+	ID string
+}
+
+// MigrationFinished is emitted when a migration is marked as finished, by FinishMigration or CommitMigration.
+type MigrationFinished struct {
+	ID string
+}
+
+// MigrationFailed is emitted when the state of a migration could not be written, or when its verification failed (see
+// Target.Verify). Operation is the Target method that failed (e.g. "Add" or "FinishMigration") and Err the error it
+// returned.
+type MigrationFailed struct {
+	ID        string
+	Operation string
+	Err       error
+}
+
+// DirtyMigrationDetected is emitted when Done finds a migration that was started but never finished, which usually
+// means a previous run crashed in the middle of it.
+type DirtyMigrationDetected struct {
+	ID string
+}
+
+// LockAcquired is emitted when the lock is acquired. Waited is how long the Target waited for it.
+type LockAcquired struct {
+	LockID  string
+	OwnerID string
+	Waited  time.Duration
+}
+
+// LockReleased is emitted when the lock is released.
+type LockReleased struct {
+	LockID string
+}
+
+func (MigrationAdded) event()         {}
+func (MigrationStarted) event()       {}
+func (MigrationPrepared) event()      {}
+func (MigrationFinished) event()      {}
+func (MigrationFailed) event()        {}
+func (DirtyMigrationDetected) event() {}
+func (LockAcquired) event()           {}
+func (LockReleased) event()           {}
+
+// Listener receives the events emitted by a Target. OnEvent is called synchronously, so implementations should not
+// block.
+type Listener interface {
+	OnEvent(ctx context.Context, event Event)
+}
+
+// ListenerFunc is an adapter to allow the use of ordinary functions as a Listener.
+type ListenerFunc func(ctx context.Context, event Event)
+
+// OnEvent calls f(ctx, event).
+func (f ListenerFunc) OnEvent(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+type noopListener struct{}
+
+func (noopListener) OnEvent(context.Context, Event) {}
+
+type multiListener []Listener
+
+func (l multiListener) OnEvent(ctx context.Context, event Event) {
+	for _, listener := range l {
+		listener.OnEvent(ctx, event)
+	}
+}
+
+func newListener(listeners []Listener) Listener {
+	switch len(listeners) {
+	case 0:
+		return noopListener{}
+	case 1:
+		return listeners[0]
+	default:
+		return multiListener(listeners)
+	}
+}
+
+// notifyMigration emits the event of a write to the state of a migration. It is meant to be deferred by the operations
+// with a pointer to their returned error.
+func (t *Target) notifyMigration(ctx context.Context, operation, id string, err *error) {
+	if *err != nil {
+		t.listener.OnEvent(ctx, MigrationFailed{ID: id, Operation: operation, Err: *err})
+		return
+	}
+	switch operation {
+	case operationAdd:
+		t.listener.OnEvent(ctx, MigrationAdded{ID: id})
+	case operationStartMigration:
+		t.listener.OnEvent(ctx, MigrationStarted{ID: id})
+	case operationPrepareMigration:
+		t.listener.OnEvent(ctx, MigrationPrepared{ID: id})
+	case operationFinishMigration, operationCommitMigration, operationRepairMarkFinished:
+		t.listener.OnEvent(ctx, MigrationFinished{ID: id})
+	}
+}
@@ -0,0 +1,94 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listener", func() {
+	var (
+		ctx context.Context
+
+		mu     sync.Mutex
+		events []Event
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		events = nil
+		target = NewTarget(dynamoDBClient, WithListener(ListenerFunc(func(_ context.Context, event Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		})))
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should emit the events of the migrations lifecycle", func() {
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		Expect(u.Unlock(ctx)).To(Succeed())
+
+		Expect(events).To(HaveLen(5))
+		Expect(events[0]).To(BeAssignableToTypeOf(LockAcquired{}))
+		Expect(events[0].(LockAcquired).LockID).To(Equal("migrations"))
+		Expect(events[1:4]).To(Equal([]Event{
+			MigrationAdded{ID: "1"},
+			MigrationStarted{ID: "1"},
+			MigrationFinished{ID: "1"},
+		}))
+		Expect(events[4]).To(Equal(LockReleased{LockID: "migrations"}))
+	})
+
+	It("should emit MigrationFailed when the state of a migration cannot be written", func() {
+		Expect(target.FinishMigration(ctx, "1")).To(MatchError(migrations.ErrMigrationNotFound))
+
+		Expect(events).To(HaveLen(1))
+		failed, ok := events[0].(MigrationFailed)
+		Expect(ok).To(BeTrue())
+		Expect(failed.ID).To(Equal("1"))
+		Expect(failed.Operation).To(Equal(operationFinishMigration))
+		Expect(failed.Err).To(MatchError(migrations.ErrMigrationNotFound))
+	})
+
+	It("should emit DirtyMigrationDetected when Done finds a dirty migration", func() {
+		Expect(target.Add(ctx, "1")).To(Succeed())
+
+		_, err := target.Done(ctx)
+		Expect(err).To(MatchError(migrations.ErrDirtyMigration))
+
+		Expect(events).To(Equal([]Event{
+			MigrationAdded{ID: "1"},
+			DirtyMigrationDetected{ID: "1"},
+		}))
+	})
+
+	It("should call all the listeners added", func() {
+		var second []Event
+		target = NewTarget(dynamoDBClient,
+			WithListener(ListenerFunc(func(_ context.Context, event Event) {
+				events = append(events, event)
+			})),
+			WithListener(ListenerFunc(func(_ context.Context, event Event) {
+				second = append(second, event)
+			})),
+		)
+		events = nil
+
+		Expect(target.Add(ctx, "1")).To(Succeed())
+
+		Expect(events).To(Equal([]Event{MigrationAdded{ID: "1"}}))
+		Expect(second).To(Equal([]Event{MigrationAdded{ID: "1"}}))
+	})
+})
@@ -0,0 +1,308 @@
+package migrations_dynamodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// SnapshotVersion is the version of the format of the snapshots made by Export.
+const SnapshotVersion = 1
+
+// ErrInvalidSnapshot is returned by Import when an item of the snapshot has no string "id" attribute, or the snapshot
+// was made by a newer version of the format.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// Snapshot is a copy of every item of the migrations table, including all their attributes (e.g. the dirty flag and
+// the correlation ID), that can be encoded as JSON. It is meant for copying the state between accounts, attaching it
+// to incident reports and restoring it after an accidental deletion.
+type Snapshot struct {
+	// Version is the version of the format of the snapshot, SnapshotVersion when made by Export. The snapshots made
+	// before the format was versioned have no version.
+	Version int `json:"version"`
+	// Table is the name of the migrations table exported.
+	Table      string    `json:"table"`
+	ExportedAt time.Time `json:"exportedAt"`
+	// Lock is the state of the lock when the snapshot was made. It is not restored by Import.
+	Lock LockStatus `json:"lock"`
+	// Items are sorted by ID.
+	Items []map[string]any `json:"items"`
+}
+
+// S3Uploader uploads objects to S3, for ExportToS3. It is meant to be implemented by a thin adapter of the PutObject
+// of the S3 client of the AWS SDK, so this package does not depend on it.
+type S3Uploader interface {
+	Upload(ctx context.Context, bucket, key string, body io.Reader) error
+}
+
+// S3UploaderFunc is an adapter to use a function as an S3Uploader.
+type S3UploaderFunc func(ctx context.Context, bucket, key string, body io.Reader) error
+
+func (f S3UploaderFunc) Upload(ctx context.Context, bucket, key string, body io.Reader) error {
+	return f(ctx, bucket, key, body)
+}
+
+// Export returns a snapshot of the migrations table and the lock.
+func (t *Target) Export(ctx context.Context) (_ Snapshot, err error) {
+	defer t.observe(ctx, operationExport, t.clock.Now(), &err)
+	defer wrapError(operationExport, t.tableName, &err)
+
+	items, err := t.scanMigrations(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	lock, err := t.lockStatus(ctx, operationExport)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{
+		Version:    SnapshotVersion,
+		Table:      t.tableName,
+		ExportedAt: t.clock.Now().UTC(),
+		Lock:       lock,
+		Items:      make([]map[string]any, 0, len(items)),
+	}
+	for _, item := range items {
+		m, err := t.unmarshalItem(item)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Items = append(snapshot.Items, m)
+	}
+	sort.Slice(snapshot.Items, func(i, j int) bool {
+		return fmt.Sprint(snapshot.Items[i]["id"]) < fmt.Sprint(snapshot.Items[j]["id"])
+	})
+
+	return snapshot, nil
+}
+
+// ExportTo writes the snapshot returned by Export to w, as an indented JSON document.
+func (t *Target) ExportTo(ctx context.Context, w io.Writer) error {
+	snapshot, err := t.Export(ctx)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to write the snapshot: %w", err)
+	}
+	return nil
+}
+
+// ExportToS3 uploads the JSON document written by ExportTo to the bucket, under the key, for backups and audits
+// without going through a local file.
+func (t *Target) ExportToS3(ctx context.Context, uploader S3Uploader, bucket, key string) error {
+	var buf bytes.Buffer
+	if err := t.ExportTo(ctx, &buf); err != nil {
+		return err
+	}
+	if err := uploader.Upload(ctx, bucket, key, &buf); err != nil {
+		return fmt.Errorf("failed to upload the snapshot to s3://%s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// ImportMode is how ImportFrom restores a snapshot over the migrations already recorded.
+type ImportMode string
+
+const (
+	// ImportMerge adds the migrations of the snapshot not recorded, keeping the ones recorded. The migrations recorded
+	// differently from the snapshot are not written, but reported as conflicts.
+	ImportMerge ImportMode = "merge"
+	// ImportReplace makes the migrations table match the snapshot: the migrations of the snapshot are written, replacing
+	// the ones recorded with the same ID, and the migrations not in the snapshot are removed.
+	ImportReplace ImportMode = "replace"
+)
+
+// ImportResult is what ImportFrom did to the migrations table.
+type ImportResult struct {
+	// Imported are the IDs of the migrations written.
+	Imported []string
+	// Unchanged are the IDs of the migrations already recorded as in the snapshot.
+	Unchanged []string
+	// Removed are the IDs of the migrations not in the snapshot removed by ImportReplace.
+	Removed []string
+	// Conflicts are the migrations recorded differently from the snapshot, left as they are by ImportMerge.
+	Conflicts []ImportConflict
+}
+
+// ImportConflict is a migration recorded differently from the snapshot imported.
+type ImportConflict struct {
+	ID string
+	// Recorded is the migration recorded in the table, and Snapshot is the one in the snapshot.
+	Recorded, Snapshot map[string]any
+}
+
+// Import writes the items of the snapshot to the migrations table, replacing the migrations with the same ID. The
+// migrations recorded in the table but not in the snapshot are kept. The snapshot is validated before anything is
+// written: if any item has no string "id" attribute, or the snapshot has a newer version, it returns an
+// ErrInvalidSnapshot.
+//
+// Import does not lock the migrations, the caller should hold the lock while importing.
+func (t *Target) Import(ctx context.Context, snapshot Snapshot) (err error) {
+	defer t.observe(ctx, operationImport, t.clock.Now(), &err)
+	defer wrapError(operationImport, t.tableName, &err)
+
+	items, err := t.snapshotItems(snapshot)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := t.putImported(ctx, item); err != nil {
+			return err
+		}
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "migrations imported", "count", len(items))
+
+	return nil
+}
+
+// ImportFrom restores the snapshot written by ExportTo, read from r, with the given mode, and returns what was done.
+// The snapshot is validated, as by Import, before anything is written. Differently from Import, an ImportMerge does
+// not overwrite the migrations recorded differently, but reports them as conflicts in the result, without failing.
+//
+// ImportFrom does not lock the migrations, the caller should hold the lock while importing.
+func (t *Target) ImportFrom(ctx context.Context, r io.Reader, mode ImportMode) (_ ImportResult, err error) {
+	defer t.observe(ctx, operationImport, t.clock.Now(), &err)
+	defer wrapError(operationImport, t.tableName, &err)
+
+	if mode != ImportMerge && mode != ImportReplace {
+		return ImportResult{}, fmt.Errorf("unknown import mode: %q", mode)
+	}
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return ImportResult{}, fmt.Errorf("%w: %w", ErrInvalidSnapshot, err)
+	}
+	items, err := t.snapshotItems(snapshot)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	recordedItems, err := t.scanMigrations(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	recorded := make(map[string]map[string]any, len(recordedItems))
+	for _, item := range recordedItems {
+		m, err := t.unmarshalItem(item)
+		if err != nil {
+			return ImportResult{}, err
+		}
+		if id, ok := m["id"].(string); ok {
+			recorded[id] = m
+		}
+	}
+
+	var result ImportResult
+	for _, item := range items {
+		id := item["id"].(*types.AttributeValueMemberS).Value
+		m, err := t.unmarshalItem(item)
+		if err != nil {
+			return result, err
+		}
+		current, ok := recorded[id]
+		delete(recorded, id)
+		switch {
+		case ok && reflect.DeepEqual(current, m):
+			result.Unchanged = append(result.Unchanged, id)
+			continue
+		case ok && mode == ImportMerge:
+			result.Conflicts = append(result.Conflicts, ImportConflict{ID: id, Recorded: current, Snapshot: m})
+			continue
+		}
+		if err := t.putImported(ctx, item); err != nil {
+			return result, err
+		}
+		result.Imported = append(result.Imported, id)
+	}
+
+	if mode == ImportReplace {
+		removed := make([]string, 0, len(recorded))
+		for id := range recorded {
+			removed = append(removed, id)
+		}
+		sort.Strings(removed)
+		for _, id := range removed {
+			if err := t.removeImported(ctx, id); err != nil {
+				return result, err
+			}
+			result.Removed = append(result.Removed, id)
+		}
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "migrations imported", "mode", mode, "imported", len(result.Imported),
+		"removed", len(result.Removed), "conflicts", len(result.Conflicts))
+
+	return result, nil
+}
+
+// snapshotItems validates the items of the snapshot and returns them as DynamoDB items.
+func (t *Target) snapshotItems(snapshot Snapshot) ([]map[string]types.AttributeValue, error) {
+	if snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, snapshot.Version)
+	}
+	items := make([]map[string]types.AttributeValue, 0, len(snapshot.Items))
+	for i, m := range snapshot.Items {
+		if id, ok := m["id"].(string); !ok || id == "" {
+			return nil, fmt.Errorf("%w: item %d has no id", ErrInvalidSnapshot, i)
+		}
+		item, err := t.codec.marshalMap(m)
+		if err != nil {
+			return nil, fmt.Errorf("%w: item %d: %w", ErrInvalidSnapshot, i, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (t *Target) unmarshalItem(item map[string]types.AttributeValue) (map[string]any, error) {
+	var m map[string]any
+	if err := t.codec.unmarshalMap(item, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal item: %w", err)
+	}
+	return m, nil
+}
+
+func (t *Target) putImported(ctx context.Context, item map[string]types.AttributeValue) error {
+	id := item["id"].(*types.AttributeValueMemberS).Value
+	output, err := t.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:              &t.tableName,
+		Item:                   item,
+		ReturnValues:           types.ReturnValueAllOld,
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import migration %s: %w", id, err)
+	}
+	t.capacity.record(operationImport, output.ConsumedCapacity)
+	t.audit(ctx, operationImport, id, output.Attributes, item)
+	return nil
+}
+
+func (t *Target) removeImported(ctx context.Context, id string) error {
+	output, err := t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &t.tableName,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ReturnValues:           types.ReturnValueAllOld,
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove migration %s: %w", id, err)
+	}
+	t.capacity.record(operationImport, output.ConsumedCapacity)
+	t.audit(ctx, operationImport, id, output.Attributes, nil)
+	return nil
+}
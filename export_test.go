@@ -0,0 +1,184 @@
+package migrations_dynamodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Export and Import", func() {
+	var (
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	It("should restore the exported state in another table", func() {
+		source := NewTarget(dynamoDBClient, WithTableName("source-migrations"))
+		Expect(source.Create(ctx)).To(Succeed())
+		Expect(source.Add(ctx, "2")).To(Succeed())
+		Expect(source.Add(ctx, "1")).To(Succeed())
+		Expect(source.FinishMigration(ctx, "1")).To(Succeed())
+
+		snapshot, err := source.Export(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot.Table).To(Equal("source-migrations"))
+		Expect(snapshot.Items).To(HaveLen(2))
+		Expect(snapshot.Items[0]).To(And(HaveKeyWithValue("id", "1"), HaveKeyWithValue("dirty", false), HaveKey("applied_at")))
+		Expect(snapshot.Items[1]).To(And(HaveKeyWithValue("id", "2"), HaveKeyWithValue("dirty", true), HaveKey("started_at")))
+
+		data, err := json.Marshal(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		var decoded Snapshot
+		Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+
+		destination := NewTarget(dynamoDBClient, WithTableName("destination-migrations"))
+		Expect(destination.Create(ctx)).To(Succeed())
+		Expect(destination.Import(ctx, decoded)).To(Succeed())
+
+		status, err := destination.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{
+			{ID: "1", Dirty: false},
+			{ID: "2", Dirty: true},
+		}))
+
+		history, err := destination.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		sourceHistory, err := source.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(Equal(sourceHistory))
+	})
+
+	It("should not write anything when an item has no id", func() {
+		target := NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+
+		err := target.Import(ctx, Snapshot{Items: []map[string]any{
+			{"id": "1", "dirty": false},
+			{"dirty": true},
+		}})
+		Expect(err).To(MatchError(ErrInvalidSnapshot))
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(BeEmpty())
+	})
+
+	It("should fail with ErrInvalidSnapshot when the snapshot has a newer version", func() {
+		target := NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+
+		err := target.Import(ctx, Snapshot{Version: SnapshotVersion + 1, Items: []map[string]any{{"id": "1"}}})
+		Expect(err).To(MatchError(ErrInvalidSnapshot))
+	})
+
+	It("should write the versioned snapshot with the lock to S3", func() {
+		target := NewTarget(dynamoDBClient, WithOwnerID("owner-1"))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			Expect(unlocker.Unlock(ctx)).To(Succeed())
+		}()
+
+		var bucket, key string
+		var body []byte
+		err = target.ExportToS3(ctx, S3UploaderFunc(func(_ context.Context, b, k string, r io.Reader) error {
+			bucket, key = b, k
+			body, err = io.ReadAll(r)
+			return err
+		}), "backups", "migrations/snapshot.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bucket).To(Equal("backups"))
+		Expect(key).To(Equal("migrations/snapshot.json"))
+
+		var snapshot Snapshot
+		Expect(json.Unmarshal(body, &snapshot)).To(Succeed())
+		Expect(snapshot.Version).To(Equal(SnapshotVersion))
+		Expect(snapshot.Lock).To(Equal(LockStatus{Held: true, Owner: "owner-1"}))
+		Expect(snapshot.Items).To(HaveLen(1))
+	})
+
+	Describe("ImportFrom", func() {
+		var (
+			target   *Target
+			exported bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			source := NewTarget(dynamoDBClient, WithTableName("source-migrations"))
+			Expect(source.Create(ctx)).To(Succeed())
+			for _, id := range []string{"1", "2"} {
+				Expect(source.Add(ctx, id)).To(Succeed())
+				Expect(source.FinishMigration(ctx, id)).To(Succeed())
+			}
+			exported.Reset()
+			Expect(source.ExportTo(ctx, &exported)).To(Succeed())
+
+			target = NewTarget(dynamoDBClient)
+			Expect(target.Create(ctx)).To(Succeed())
+		})
+
+		It("should merge the snapshot, reporting the migrations recorded differently", func() {
+			Expect(target.Add(ctx, "2")).To(Succeed())
+			Expect(target.Add(ctx, "3")).To(Succeed())
+
+			result, err := target.ImportFrom(ctx, &exported, ImportMerge)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Imported).To(Equal([]string{"1"}))
+			Expect(result.Removed).To(BeEmpty())
+			Expect(result.Conflicts).To(HaveLen(1))
+			Expect(result.Conflicts[0].ID).To(Equal("2"))
+			Expect(result.Conflicts[0].Recorded).To(HaveKeyWithValue("dirty", true))
+			Expect(result.Conflicts[0].Snapshot).To(HaveKeyWithValue("dirty", false))
+
+			status, err := target.Status(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Migrations).To(Equal([]MigrationStatus{
+				{ID: "1", Dirty: false},
+				{ID: "2", Dirty: true},
+				{ID: "3", Dirty: true},
+			}))
+		})
+
+		It("should replace the migrations recorded by the snapshot", func() {
+			Expect(target.Add(ctx, "2")).To(Succeed())
+			Expect(target.Add(ctx, "3")).To(Succeed())
+
+			result, err := target.ImportFrom(ctx, &exported, ImportReplace)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Imported).To(Equal([]string{"1", "2"}))
+			Expect(result.Removed).To(Equal([]string{"3"}))
+			Expect(result.Conflicts).To(BeEmpty())
+
+			Expect(target.Done(ctx)).To(Equal([]string{"1", "2"}))
+		})
+
+		It("should not write the migrations already recorded as in the snapshot", func() {
+			data := exported.Bytes()
+			_, err := target.ImportFrom(ctx, bytes.NewReader(data), ImportMerge)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := target.ImportFrom(ctx, bytes.NewReader(data), ImportMerge)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Imported).To(BeEmpty())
+			Expect(result.Unchanged).To(Equal([]string{"1", "2"}))
+		})
+
+		It("should fail with ErrInvalidSnapshot when the document cannot be read", func() {
+			_, err := target.ImportFrom(ctx, strings.NewReader("{"), ImportMerge)
+			Expect(err).To(MatchError(ErrInvalidSnapshot))
+		})
+	})
+})
@@ -0,0 +1,160 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// ErrAccountSkipped is the error of the accounts FanOut did not run, because an account failed before them.
+var ErrAccountSkipped = errors.New("account skipped after a failure")
+
+// Account is an account, and region, the migrations are applied to by FanOut.
+type Account struct {
+	// Name identifies the account in the results and the errors, as "payments-prod-eu".
+	Name string
+	// Region is the region of the Target of the account. If empty, it is the one of the aws.Config given to FanOut.
+	Region string
+	// RoleARN is the role assumed to reach the account, with NewTargetAssumingRole, sending the ExternalID unless
+	// empty. If empty, the account is reached with the credentials of the aws.Config given to FanOut.
+	RoleARN    string
+	ExternalID string
+	// Options are applied to the Target of the account, after the ones set by WithFanOutRunOptions.
+	Options []Option
+}
+
+// AccountResult is the outcome of the migrations of an account run by FanOut.
+type AccountResult struct {
+	Account string
+	// Result is what the Migrator of the account executed, nil if the account was skipped.
+	Result *MigratorResult
+	// Err is the error the migrations of the account failed with, an ErrAccountSkipped if it was skipped.
+	Err error
+}
+
+type fanOutOpts struct {
+	runOptions      []RunOption
+	concurrency     int
+	continueOnError bool
+}
+
+// FanOutOption configures FanOut.
+type FanOutOption func(*fanOutOpts)
+
+// WithFanOutRunOptions sets the options of the Migrator of every account, as the planner, the reporters and, with
+// WithTargetOptions, the options of their Targets.
+func WithFanOutRunOptions(options ...RunOption) FanOutOption {
+	return func(o *fanOutOpts) {
+		o.runOptions = append(o.runOptions, options...)
+	}
+}
+
+// WithFanOutConcurrency sets how many accounts FanOut runs at the same time. By default, it runs them one after the
+// other.
+func WithFanOutConcurrency(concurrency int) FanOutOption {
+	return func(o *fanOutOpts) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithFanOutContinueOnError makes FanOut go on with the next accounts when one fails, instead of stopping at it.
+func WithFanOutContinueOnError() FanOutOption {
+	return func(o *fanOutOpts) {
+		o.continueOnError = true
+	}
+}
+
+// FanOut applies the migrations of the source to every account, each with its own Migrator, for the platforms applying
+// the same control plane migrations to many accounts. The Target of an account uses a client built from the cfg, in
+// the region of the account and with the credentials of its role, if set.
+//
+// FanOut stops at the first account failing, unless WithFanOutContinueOnError is set: the accounts running at the time
+// finish, and the ones not started are skipped. It returns the results of all accounts, in the order given, along with
+// the errors of the ones that failed, joined.
+func FanOut(ctx context.Context, cfg aws.Config, source migrations.Source, accounts []Account, options ...FanOutOption) ([]AccountResult, error) {
+	o := fanOutOpts{concurrency: 1}
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	results := make([]AccountResult, len(accounts))
+	errs := make([]error, len(accounts))
+	// every account is given its own copy of the source, as the repositories loaded from a source share their list,
+	// which is sorted by each run.
+	sources := make([]migrations.Source, len(accounts))
+	for i := range accounts {
+		s, err := copySource(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = s
+	}
+
+	slots := make(chan struct{}, max(o.concurrency, 1))
+	var (
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	for i, account := range accounts {
+		slots <- struct{}{}
+		if failed.Load() && !o.continueOnError {
+			<-slots
+			results[i] = AccountResult{Account: account.Name, Err: ErrAccountSkipped}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			runOptions := append([]RunOption{}, o.runOptions...)
+			runOptions = append(runOptions, WithTargetOptions(account.Options...))
+			result, err := NewMigrator(accountClient(cfg, account), sources[i], runOptions...).Run(ctx)
+			results[i] = AccountResult{Account: account.Name, Result: result, Err: err}
+			if err != nil {
+				failed.Store(true)
+				errs[i] = fmt.Errorf("failed to migrate account %s: %w", account.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+// accountClient creates the client reaching the account.
+func accountClient(cfg aws.Config, account Account) *dynamodb.Client {
+	cfg = cfg.Copy()
+	if account.Region != "" {
+		cfg.Region = account.Region
+	}
+	if account.RoleARN != "" {
+		cfg = assumeRoleConfig(cfg, account.RoleARN, account.ExternalID)
+	}
+	return dynamodb.NewFromConfig(cfg)
+}
+
+// copySource copies the migrations of the source to a new memory source.
+func copySource(ctx context.Context, source migrations.Source) (migrations.Source, error) {
+	repository, err := source.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the migrations: %w", err)
+	}
+	list, err := repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the migrations: %w", err)
+	}
+	copied := migrations.NewMemorySource()
+	for _, migration := range list {
+		if err := copied.Add(ctx, migration); err != nil {
+			return nil, err
+		}
+	}
+	return copied, nil
+}
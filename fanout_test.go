@@ -0,0 +1,73 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FanOut", func() {
+	var (
+		ctx    context.Context
+		cfg    aws.Config
+		source migrations.Source
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		cfg = aws.Config{
+			Region:       "sa-region-1",
+			Credentials:  credentials.NewStaticCredentialsProvider("abcdef", "`12345", ""),
+			BaseEndpoint: aws.String("http://localhost:8000"),
+		}
+		source = migrations.NewMemorySource()
+		for _, id := range []string{"1", "2"} {
+			Expect(source.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+				return nil
+			}, nil))).To(Succeed())
+		}
+	})
+
+	account := func(name string, options ...Option) Account {
+		return Account{Name: name, Options: append([]Option{WithTableName("_migrations-" + name), WithLockID(name)}, options...)}
+	}
+
+	appliedIDs := func(results []AccountResult) map[string][]string {
+		ids := make(map[string][]string)
+		for _, result := range results {
+			if result.Result != nil {
+				ids[result.Account] = result.Result.AppliedIDs()
+			}
+		}
+		return ids
+	}
+
+	It("should apply the migrations to every account", func() {
+		results, err := FanOut(ctx, cfg, source, []Account{account("a"), account("b"), account("c")}, WithFanOutConcurrency(2))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(appliedIDs(results)).To(Equal(map[string][]string{"a": {"1", "2"}, "b": {"1", "2"}, "c": {"1", "2"}}))
+		Expect(NewTarget(dynamoDBClient, WithTableName("_migrations-b")).Done(ctx)).To(Equal([]string{"1", "2"}))
+	})
+
+	It("should skip the accounts after the first failing", func() {
+		results, err := FanOut(ctx, cfg, source, []Account{account("a"), account("b", WithValidateOnly()), account("c")})
+		Expect(err).To(MatchError(ContainSubstring("failed to migrate account b")))
+		Expect(results).To(HaveLen(3))
+		Expect(results[1].Err).To(MatchError(ErrTableNotFound))
+		Expect(results[2]).To(Equal(AccountResult{Account: "c", Err: ErrAccountSkipped}))
+		Expect(appliedIDs(results)).To(HaveKeyWithValue("a", []string{"1", "2"}))
+	})
+
+	It("should go on with the next accounts with WithFanOutContinueOnError", func() {
+		results, err := FanOut(ctx, cfg, source, []Account{account("a", WithValidateOnly()), account("b")}, WithFanOutContinueOnError())
+		Expect(err).To(MatchError(ContainSubstring("failed to migrate account a")))
+		Expect(appliedIDs(results)).To(HaveKeyWithValue("b", []string{"1", "2"}))
+	})
+})
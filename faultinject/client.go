@@ -0,0 +1,339 @@
+// Package faultinject wraps a migrations_dynamodb.DynamoDBClient injecting failures in its calls, for testing how the
+// migrations, and the pipelines running them, behave when the DynamoDB misbehaves halfway through:
+//
+//	client := faultinject.New(dynamodbClient,
+//		faultinject.WithRule(faultinject.Rule{Fault: faultinject.FaultThrottle, Probability: 0.1}),
+//		faultinject.WithRule(faultinject.Rule{
+//			Operations: []string{"PutItem"},
+//			Fault:      faultinject.FaultConditionalCheckFailed,
+//			After:      3,
+//			Times:      1,
+//		}),
+//	)
+//	target := migrations_dynamodb.NewTarget(client)
+//
+// It is meant for tests, never for production clients.
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// Fault is a failure injected in a call.
+type Fault string
+
+const (
+	// FaultThrottle fails the call with a ProvisionedThroughputExceededException, without calling the DynamoDB.
+	FaultThrottle Fault = "throttle"
+	// FaultConditionalCheckFailed fails the call with a ConditionalCheckFailedException, without calling the DynamoDB.
+	FaultConditionalCheckFailed Fault = "conditional_check_failed"
+	// FaultTimeout fails the call with a context.DeadlineExceeded, without calling the DynamoDB.
+	FaultTimeout Fault = "timeout"
+	// FaultLatency delays the call by the Delay of the rule, plus a random duration up to its Jitter, or until the
+	// context is done, before calling the DynamoDB.
+	FaultLatency Fault = "latency"
+	// FaultPartialScan makes a Scan return a single item per page, so the callers must follow the pages. It is ignored
+	// by the other operations.
+	FaultPartialScan Fault = "partial_scan"
+)
+
+// Rule describes when a fault is injected. The calls matching the operations are counted, and the fault is injected
+// in the calls after the first After ones, every Every calls, with the Probability, up to Times times.
+type Rule struct {
+	// Operations are the names of the methods of the client the rule applies to (e.g. "PutItem"), or all if empty.
+	Operations []string
+	Fault      Fault
+	// Delay is how long FaultLatency delays the calls, at least.
+	Delay time.Duration
+	// Jitter is the maximum random duration added to the Delay of each call delayed by FaultLatency.
+	Jitter time.Duration
+	// After is the number of matching calls that are never injected.
+	After int
+	// Every injects the fault in every Every-th matching call after the first After ones. 0 or 1 is every call.
+	Every int
+	// Probability is the chance of injecting the fault in a call scheduled by After and Every. 0 is always.
+	Probability float64
+	// Times is the maximum number of injections, unlimited if 0.
+	Times int
+}
+
+// Injection is a fault injected in a call.
+type Injection struct {
+	Operation string
+	Fault     Fault
+	// Delay is how long the call was delayed by FaultLatency.
+	Delay time.Duration
+}
+
+type opts struct {
+	rules []Rule
+	rand  *rand.Rand
+}
+
+// Option configures the Client.
+type Option func(*opts)
+
+// WithRule adds a rule. When more than one rule injects a fault in a call, the first one added wins.
+func WithRule(rule Rule) Option {
+	return func(o *opts) {
+		o.rules = append(o.rules, rule)
+	}
+}
+
+// WithSeed sets the seed of the random numbers of the probabilities, so the faults injected are reproducible.
+func WithSeed(seed uint64) Option {
+	return func(o *opts) {
+		o.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// Client is a DynamoDBClient injecting the faults of its rules in the calls to the wrapped client.
+type Client struct {
+	next migrations_dynamodb.DynamoDBClient
+
+	mu         sync.Mutex
+	rules      []*ruleState
+	rand       *rand.Rand
+	injections []Injection
+}
+
+type ruleState struct {
+	Rule
+	calls, injected int
+}
+
+var (
+	_ migrations_dynamodb.DynamoDBClient           = (*Client)(nil)
+	_ migrations_dynamodb.QueryDynamoDBClient      = (*Client)(nil)
+	_ migrations_dynamodb.GetItemDynamoDBClient    = (*Client)(nil)
+	_ migrations_dynamodb.TimeToLiveDynamoDBClient = (*Client)(nil)
+	_ migrations_dynamodb.BackupDynamoDBClient     = (*Client)(nil)
+
+	_ migrations_dynamodb.OptionsIgnoringDynamoDBClient = (*Client)(nil)
+)
+
+// New creates a Client wrapping next.
+func New(next migrations_dynamodb.DynamoDBClient, options ...Option) *Client {
+	o := opts{}
+	for _, opt := range options {
+		opt(&o)
+	}
+	if o.rand == nil {
+		o.rand = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	c := &Client{next: next, rand: o.rand}
+	for _, rule := range o.rules {
+		c.rules = append(c.rules, &ruleState{Rule: rule})
+	}
+	return c
+}
+
+// Injections returns the faults injected so far, in order.
+func (c *Client) Injections() []Injection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.injections)
+}
+
+// fault returns the rule of the fault to inject in the call to the operation, if any.
+func (c *Client) fault(operation string) *Rule {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var injected *Rule
+	for _, rule := range c.rules {
+		if len(rule.Operations) > 0 && !slices.Contains(rule.Operations, operation) {
+			continue
+		}
+		rule.calls++
+		if injected != nil || !rule.scheduled(c.rand) {
+			continue
+		}
+		rule.injected++
+		r := rule.Rule
+		if r.Fault == FaultLatency && r.Jitter > 0 {
+			r.Delay += time.Duration(c.rand.Int64N(int64(r.Jitter) + 1))
+		}
+		injected = &r
+		injection := Injection{Operation: operation, Fault: r.Fault}
+		if r.Fault == FaultLatency {
+			injection.Delay = r.Delay
+		}
+		c.injections = append(c.injections, injection)
+	}
+	return injected
+}
+
+func (r *ruleState) scheduled(random *rand.Rand) bool {
+	switch {
+	case r.calls <= r.After:
+		return false
+	case r.Times > 0 && r.injected >= r.Times:
+		return false
+	case r.Every > 1 && (r.calls-r.After)%r.Every != 0:
+		return false
+	case r.Probability > 0 && random.Float64() >= r.Probability:
+		return false
+	}
+	return true
+}
+
+// inject injects the fault of the rule, returning the error the call fails with, if it does not call the DynamoDB.
+func inject(ctx context.Context, operation string, rule *Rule) error {
+	if rule == nil {
+		return nil
+	}
+	message := aws.String(fmt.Sprintf("fault injected in %s", operation))
+	switch rule.Fault {
+	case FaultThrottle:
+		return &types.ProvisionedThroughputExceededException{Message: message}
+	case FaultConditionalCheckFailed:
+		return &types.ConditionalCheckFailedException{Message: message}
+	case FaultTimeout:
+		return fmt.Errorf("%s: %w", aws.ToString(message), context.DeadlineExceeded)
+	case FaultLatency:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rule.Delay):
+		}
+	}
+	return nil
+}
+
+func (c *Client) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	rule := c.fault("Scan")
+	if err := inject(ctx, "Scan", rule); err != nil {
+		return nil, err
+	}
+	if rule != nil && rule.Fault == FaultPartialScan {
+		partial := *input
+		partial.Limit = aws.Int32(1)
+		input = &partial
+	}
+	return c.next.Scan(ctx, input, optFns...)
+}
+
+func (c *Client) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := inject(ctx, "PutItem", c.fault("PutItem")); err != nil {
+		return nil, err
+	}
+	return c.next.PutItem(ctx, input, optFns...)
+}
+
+func (c *Client) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := inject(ctx, "DeleteItem", c.fault("DeleteItem")); err != nil {
+		return nil, err
+	}
+	return c.next.DeleteItem(ctx, input, optFns...)
+}
+
+func (c *Client) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := inject(ctx, "UpdateItem", c.fault("UpdateItem")); err != nil {
+		return nil, err
+	}
+	return c.next.UpdateItem(ctx, input, optFns...)
+}
+
+func (c *Client) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := inject(ctx, "TransactWriteItems", c.fault("TransactWriteItems")); err != nil {
+		return nil, err
+	}
+	return c.next.TransactWriteItems(ctx, input, optFns...)
+}
+
+func (c *Client) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	if err := inject(ctx, "CreateTable", c.fault("CreateTable")); err != nil {
+		return nil, err
+	}
+	return c.next.CreateTable(ctx, input, optFns...)
+}
+
+func (c *Client) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	if err := inject(ctx, "DescribeTable", c.fault("DescribeTable")); err != nil {
+		return nil, err
+	}
+	return c.next.DescribeTable(ctx, input, optFns...)
+}
+
+func (c *Client) DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	if err := inject(ctx, "DeleteTable", c.fault("DeleteTable")); err != nil {
+		return nil, err
+	}
+	return c.next.DeleteTable(ctx, input, optFns...)
+}
+
+func (c *Client) ListTables(ctx context.Context, input *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	if err := inject(ctx, "ListTables", c.fault("ListTables")); err != nil {
+		return nil, err
+	}
+	return c.next.ListTables(ctx, input, optFns...)
+}
+
+func (c *Client) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.QueryDynamoDBClient)
+	if !ok {
+		return nil, unsupported("Query")
+	}
+	if err := inject(ctx, "Query", c.fault("Query")); err != nil {
+		return nil, err
+	}
+	return next.Query(ctx, input, optFns...)
+}
+
+func (c *Client) GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.GetItemDynamoDBClient)
+	if !ok {
+		return nil, unsupported("GetItem")
+	}
+	if err := inject(ctx, "GetItem", c.fault("GetItem")); err != nil {
+		return nil, err
+	}
+	return next.GetItem(ctx, input, optFns...)
+}
+
+func (c *Client) DescribeTimeToLive(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.TimeToLiveDynamoDBClient)
+	if !ok {
+		return nil, unsupported("DescribeTimeToLive")
+	}
+	if err := inject(ctx, "DescribeTimeToLive", c.fault("DescribeTimeToLive")); err != nil {
+		return nil, err
+	}
+	return next.DescribeTimeToLive(ctx, input, optFns...)
+}
+
+func (c *Client) DeleteBackup(ctx context.Context, input *dynamodb.DeleteBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.BackupDynamoDBClient)
+	if !ok {
+		return nil, unsupported("DeleteBackup")
+	}
+	if err := inject(ctx, "DeleteBackup", c.fault("DeleteBackup")); err != nil {
+		return nil, err
+	}
+	return next.DeleteBackup(ctx, input, optFns...)
+}
+
+// IgnoresCallOptions forwards to the wrapped client, as the options of the calls are given to it.
+func (c *Client) IgnoresCallOptions() bool {
+	next, ok := c.next.(migrations_dynamodb.OptionsIgnoringDynamoDBClient)
+	return ok && next.IgnoresCallOptions()
+}
+
+// unsupported is the error of the optional operations the wrapped client does not implement. The Target handles it
+// as a client without the operation.
+func unsupported(operation string) error {
+	return fmt.Errorf("the client does not support %s: %w", operation, errors.ErrUnsupported)
+}
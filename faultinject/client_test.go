@@ -0,0 +1,123 @@
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/jamillosantos/migrations-dynamodb/mocks"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx  context.Context
+		next *mocks.DynamoDBClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		next = mocks.NewDynamoDBClient(gomock.NewController(GinkgoT()))
+	})
+
+	It("should throttle the calls of the operations of the rule", func() {
+		client := New(next, WithRule(Rule{Operations: []string{"PutItem"}, Fault: FaultThrottle}))
+		next.EXPECT().DeleteItem(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+		_, err := client.PutItem(ctx, &dynamodb.PutItemInput{})
+		var throttled *types.ProvisionedThroughputExceededException
+		Expect(errors.As(err, &throttled)).To(BeTrue())
+
+		_, err = client.DeleteItem(ctx, &dynamodb.DeleteItemInput{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.Injections()).To(Equal([]Injection{{Operation: "PutItem", Fault: FaultThrottle}}))
+	})
+
+	It("should inject the faults on the schedule of the rule", func() {
+		client := New(next, WithRule(Rule{Fault: FaultConditionalCheckFailed, After: 1, Every: 2, Times: 2}))
+		next.EXPECT().UpdateItem(gomock.Any(), gomock.Any()).Return(&dynamodb.UpdateItemOutput{}, nil).Times(5)
+
+		var failed []int
+		for i := 1; i <= 7; i++ {
+			_, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{})
+			var conditional *types.ConditionalCheckFailedException
+			if errors.As(err, &conditional) {
+				failed = append(failed, i)
+			}
+		}
+		Expect(failed).To(Equal([]int{3, 5}))
+	})
+
+	It("should inject the faults with the probability of the rule, reproducibly for a seed", func() {
+		injected := func() []bool {
+			client := New(next, WithSeed(42), WithRule(Rule{Fault: FaultTimeout, Probability: 0.5}))
+			var injected []bool
+			for range 20 {
+				_, err := client.ListTables(ctx, &dynamodb.ListTablesInput{})
+				injected = append(injected, err != nil)
+			}
+			return injected
+		}
+		next.EXPECT().ListTables(gomock.Any(), gomock.Any()).Return(&dynamodb.ListTablesOutput{}, nil).AnyTimes()
+
+		first := injected()
+		Expect(first).To(ContainElement(true))
+		Expect(first).To(ContainElement(false))
+		Expect(injected()).To(Equal(first))
+	})
+
+	It("should fail with a deadline exceeded when timing out", func() {
+		client := New(next, WithRule(Rule{Fault: FaultTimeout}))
+
+		_, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{})
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should delay the calls until the context is done", func() {
+		client := New(next, WithRule(Rule{Fault: FaultLatency, Delay: time.Hour}))
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{})
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should scan a single item per page for a partial scan", func() {
+		client := New(next, WithRule(Rule{Fault: FaultPartialScan}))
+		input := &dynamodb.ScanInput{TableName: aws.String("_migrations")}
+		next.EXPECT().Scan(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+			Expect(aws.ToInt32(input.Limit)).To(Equal(int32(1)))
+			return &dynamodb.ScanOutput{}, nil
+		})
+
+		_, err := client.Scan(ctx, input)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(input.Limit).To(BeNil())
+	})
+
+	It("should forward the optional operations only to the clients implementing them", func() {
+		_, err := New(next).DeleteBackup(ctx, &dynamodb.DeleteBackupInput{})
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+
+		client := New(backupClient{next}, WithRule(Rule{Operations: []string{"DeleteBackup"}, Fault: FaultThrottle, After: 1}))
+		_, err = client.DeleteBackup(ctx, &dynamodb.DeleteBackupInput{})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.DeleteBackup(ctx, &dynamodb.DeleteBackupInput{})
+		var throttled *types.ProvisionedThroughputExceededException
+		Expect(errors.As(err, &throttled)).To(BeTrue())
+	})
+})
+
+type backupClient struct {
+	*mocks.DynamoDBClient
+}
+
+func (backupClient) DeleteBackup(context.Context, *dynamodb.DeleteBackupInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	return &dynamodb.DeleteBackupOutput{}, nil
+}
@@ -0,0 +1,17 @@
+package faultinject
+
+import (
+	"time"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// NewLatency creates a Client delaying every call to next by base plus a random duration up to jitter, without
+// failing any of them, to simulate a slow network. Varying the latency of each call changes the order the calls of
+// concurrent Targets reach the DynamoDB, surfacing the races between Lock, Add and Done. The seed of the jitter can
+// be set with WithSeed. The rules of the options are checked before the latency, so the calls they fail are not
+// delayed.
+func NewLatency(next migrations_dynamodb.DynamoDBClient, base, jitter time.Duration, options ...Option) *Client {
+	options = append(options, WithRule(Rule{Fault: FaultLatency, Delay: base, Jitter: jitter}))
+	return New(next, options...)
+}
@@ -0,0 +1,56 @@
+package faultinject
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	"github.com/jamillosantos/migrations-dynamodb/mocks"
+)
+
+var _ = Describe("NewLatency", func() {
+	var (
+		ctx  context.Context
+		next *mocks.DynamoDBClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		next = mocks.NewDynamoDBClient(gomock.NewController(GinkgoT()))
+	})
+
+	It("should delay every call by the base latency plus a random jitter", func() {
+		client := NewLatency(next, time.Millisecond, 4*time.Millisecond, WithSeed(7))
+		next.EXPECT().PutItem(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil).Times(10)
+
+		for range 10 {
+			startedAt := time.Now()
+			_, err := client.PutItem(ctx, &dynamodb.PutItemInput{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(time.Since(startedAt)).To(BeNumerically(">=", time.Millisecond))
+		}
+
+		injections := client.Injections()
+		Expect(injections).To(HaveLen(10))
+		delays := make(map[time.Duration]struct{})
+		for _, injection := range injections {
+			Expect(injection.Fault).To(Equal(FaultLatency))
+			Expect(injection.Delay).To(BeNumerically("~", 3*time.Millisecond, 2*time.Millisecond))
+			delays[injection.Delay] = struct{}{}
+		}
+		Expect(len(delays)).To(BeNumerically(">", 1))
+	})
+
+	It("should not delay the calls failed by the rules of the options", func() {
+		client := NewLatency(next, time.Hour, 0, WithRule(Rule{Fault: FaultThrottle}))
+
+		_, err := client.Scan(ctx, &dynamodb.ScanInput{})
+		Expect(err).To(BeAssignableToTypeOf(&types.ProvisionedThroughputExceededException{}))
+		Expect(client.Injections()).To(Equal([]Injection{{Operation: "Scan", Fault: FaultThrottle}}))
+	})
+})
@@ -0,0 +1,79 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// GetItemDynamoDBClient is implemented by the clients able to read an item by its key, as the *dynamodb.Client. It is
+// optional: when the client given to NewTarget does not implement it, the items are read by scanning their table
+// filtered by the key instead.
+type GetItemDynamoDBClient interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+}
+
+// errGetItemUnsupported is returned by the client wrappers when the wrapped client does not implement
+// GetItemDynamoDBClient.
+var errGetItemUnsupported = fmt.Errorf("the client does not support GetItem: %w", errors.ErrUnsupported)
+
+// getItem reads the item with the ID from the table, with a strongly consistent read, so the writes made right before
+// are seen. It returns nil if there is no such item.
+func (t *Target) getItem(ctx context.Context, operation, tableName, id string) (map[string]types.AttributeValue, error) {
+	optFns := t.tableCallOptions(tableName)
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+	if client, ok := t.client.(GetItemDynamoDBClient); ok {
+		output, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:              &tableName,
+			Key:                    key,
+			ConsistentRead:         aws.Bool(true),
+			ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		}, optFns...)
+		switch {
+		case err == nil:
+			t.capacity.record(operation, output.ConsumedCapacity)
+			if len(output.Item) == 0 {
+				return nil, nil
+			}
+			return output.Item, nil
+		case !errors.Is(err, errors.ErrUnsupported):
+			return nil, fmt.Errorf("failed to get item %s of table %s: %w", id, tableName, err)
+		}
+	}
+
+	expr, err := expression.NewBuilder().
+		WithFilter(expression.Name("id").Equal(expression.Value(id))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the get item expression: %w", err)
+	}
+	input := &dynamodb.ScanInput{
+		TableName:                 &tableName,
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConsistentRead:            aws.Bool(true),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	}
+	for {
+		output, err := t.client.Scan(ctx, input, optFns...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table %s for item %s: %w", tableName, id, err)
+		}
+		t.capacity.record(operation, output.ConsumedCapacity)
+		if len(output.Items) > 0 {
+			return output.Items[0], nil
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return nil, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
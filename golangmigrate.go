@@ -0,0 +1,126 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// ErrInvalidVersion is returned by ImportGolangMigrate when the ID of a migration does not start with a version
+// number.
+var ErrInvalidVersion = errors.New("invalid migration version")
+
+// golangMigrateVersion is a version record of golang-migrate.
+type golangMigrateVersion struct {
+	Version int64 `dynamodbav:"version"`
+	Dirty   bool  `dynamodbav:"dirty"`
+}
+
+// ImportGolangMigrate converts the state recorded by golang-migrate in its table, with a numeric "version" and a
+// "dirty" flag, for the teams switching to this package: the migrations with the given IDs (e.g. the IDs of the source)
+// up to the version recorded are added to the migrations table, as finished. The version of a migration is the number
+// its ID starts with, as golang-migrate takes it from the name of the file, e.g. 3 for "0003_create_users". If the
+// table has several records, the highest version is used.
+//
+// The migrations already recorded are kept as they are, so the import can be run again. It returns the IDs of the
+// migrations added. If golang-migrate recorded the version as dirty, it returns a `migrations.ErrDirtyMigration`,
+// without adding anything: the version has to be fixed with golang-migrate first.
+//
+// ImportGolangMigrate does not lock the migrations, the caller should hold the lock while importing.
+func (t *Target) ImportGolangMigrate(ctx context.Context, tableName string, ids []string) (_ []string, err error) {
+	defer t.observe(ctx, operationImportGolang, t.clock.Now(), &err)
+	defer wrapError(operationImportGolang, tableName, &err)
+
+	current, err := t.golangMigrateVersion(ctx, tableName)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	if current.Dirty {
+		return nil, fmt.Errorf("%w: version %d of golang-migrate", migrations.ErrDirtyMigration, current.Version)
+	}
+
+	var applied []string
+	for _, id := range ids {
+		version, err := migrationVersion(id)
+		if err != nil {
+			return nil, err
+		}
+		if version <= current.Version {
+			applied = append(applied, id)
+		}
+	}
+	return t.baseline(ctx, applied)
+}
+
+// golangMigrateVersion returns the highest version recorded by golang-migrate in the table, or nil if there is none.
+func (t *Target) golangMigrateVersion(ctx context.Context, tableName string) (*golangMigrateVersion, error) {
+	var (
+		current  *golangMigrateVersion
+		startKey map[string]types.AttributeValue
+	)
+	for {
+		output, err := t.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:              &tableName,
+			ExclusiveStartKey:      startKey,
+			ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan golang-migrate table: %w", err)
+		}
+		t.capacity.record(operationImportGolang, output.ConsumedCapacity)
+
+		for _, item := range output.Items {
+			var version golangMigrateVersion
+			if err := attributevalue.UnmarshalMap(item, &version); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal golang-migrate version: %w", err)
+			}
+			if current == nil || version.Version > current.Version {
+				current = &version
+			}
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return current, nil
+		}
+		startKey = output.LastEvaluatedKey
+	}
+}
+
+// migrationVersion returns the number the ID starts with.
+func migrationVersion(id string) (int64, error) {
+	end := 0
+	for end < len(id) && id[end] >= '0' && id[end] <= '9' {
+		end++
+	}
+	version, err := strconv.ParseInt(id[:end], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidVersion, id)
+	}
+	return version, nil
+}
+
+// baseline adds the migrations to the migrations table as finished, keeping the ones already recorded, and returns the
+// IDs of the migrations added.
+func (t *Target) baseline(ctx context.Context, ids []string) ([]string, error) {
+	added := make([]string, 0, len(ids))
+	for _, id := range ids {
+		err := t.Add(ctx, id)
+		switch {
+		case errors.Is(err, migrations.ErrMigrationAlreadyExists):
+			continue
+		case err != nil:
+			return added, err
+		}
+		if err := t.FinishMigration(ctx, id); err != nil {
+			return added, err
+		}
+		added = append(added, id)
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "migrations baselined", "count", len(added))
+	return added, nil
+}
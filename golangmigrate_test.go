@@ -0,0 +1,85 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImportGolangMigrate", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	putVersion := func(version int, dirty bool) {
+		GinkgoHelper()
+
+		_, err := dynamoDBClient.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("schema_migrations"),
+			Item: map[string]types.AttributeValue{
+				"version": &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+				"dirty":   &types.AttributeValueMemberBOOL{Value: dirty},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+		_, err := dynamoDBClient.CreateTable(ctx, &dynamodb.CreateTableInput{
+			TableName:            aws.String("schema_migrations"),
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("version"), AttributeType: types.ScalarAttributeTypeN}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("version"), KeyType: types.KeyTypeHash}},
+			BillingMode:          types.BillingModePayPerRequest,
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should record the migrations up to the version as finished", func() {
+		putVersion(2, false)
+		Expect(target.Add(ctx, "0001_create_users")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "0001_create_users")).To(Succeed())
+
+		added, err := target.ImportGolangMigrate(ctx, "schema_migrations", []string{"0001_create_users", "0002_add_email", "0003_add_orders"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(Equal([]string{"0002_add_email"}))
+
+		Expect(target.Done(ctx)).To(Equal([]string{"0001_create_users", "0002_add_email"}))
+	})
+
+	It("should use the highest version recorded", func() {
+		putVersion(1, false)
+		putVersion(3, false)
+
+		added, err := target.ImportGolangMigrate(ctx, "schema_migrations", []string{"1", "2", "3", "4"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(added).To(Equal([]string{"1", "2", "3"}))
+	})
+
+	It("should not import anything when the version is dirty", func() {
+		putVersion(2, true)
+
+		_, err := target.ImportGolangMigrate(ctx, "schema_migrations", []string{"0001", "0002"})
+		Expect(err).To(MatchError(migrations.ErrDirtyMigration))
+		Expect(target.Done(ctx)).To(BeEmpty())
+	})
+
+	It("should fail with ErrInvalidVersion when an ID has no version", func() {
+		putVersion(2, false)
+
+		_, err := target.ImportGolangMigrate(ctx, "schema_migrations", []string{"create_users"})
+		Expect(err).To(MatchError(ErrInvalidVersion))
+	})
+})
@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrAttributeExists is returned by RenameAttribute when an item has both attributes with different values.
+var ErrAttributeExists = errors.New("attribute already exists")
+
+// RenameAttribute renames the attribute from to the attribute to, in every item of the table that has it. It is a
+// Backfill, so it accepts the same options: WithDryRun counts the items that would be renamed without writing them.
+//
+// Items that already have the attribute to are renamed only if both attributes have the same value, otherwise it fails
+// with ErrAttributeExists. Items already renamed are skipped, so it can be run again after being interrupted.
+func RenameAttribute(ctx context.Context, client BackfillClient, tableName, from, to string, options ...Option) (BackfillResult, error) {
+	return Backfill(ctx, client, tableName, func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+		value, ok := item[from]
+		if !ok {
+			return nil, nil
+		}
+		if current, ok := item[to]; ok && !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s", ErrAttributeExists, to)
+		}
+
+		newItem := maps.Clone(item)
+		delete(newItem, from)
+		newItem[to] = value
+		return newItem, nil
+	}, options...)
+}
+
+// RewriteFunc returns the new value of an attribute. It returns nil to remove the attribute.
+type RewriteFunc func(ctx context.Context, value types.AttributeValue) (types.AttributeValue, error)
+
+// RewriteAttribute rewrites the value of the attribute in every item of the table that has it. It is a Backfill, so it
+// accepts the same options: WithDryRun counts the items that would be rewritten without writing them. Items whose
+// value is not changed by rewrite are skipped, so a rewrite that leaves the values already rewritten unchanged can be
+// run again after being interrupted.
+func RewriteAttribute(ctx context.Context, client BackfillClient, tableName, name string, rewrite RewriteFunc, options ...Option) (BackfillResult, error) {
+	return Backfill(ctx, client, tableName, func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+		value, ok := item[name]
+		if !ok {
+			return nil, nil
+		}
+		newValue, err := rewrite(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+
+		newItem := maps.Clone(item)
+		if newValue == nil {
+			delete(newItem, name)
+		} else {
+			newItem[name] = newValue
+		}
+		return newItem, nil
+	}, options...)
+}
@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Attribute", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	key := func(i int) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("user#%02d", i)}}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String("users"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+		for i := range 6 {
+			item := key(i)
+			if i%3 != 0 {
+				item["adress"] = &types.AttributeValueMemberS{Value: fmt.Sprintf("Street %d", i)}
+			}
+			client.putItem("users", item)
+		}
+		client.calls = nil
+	})
+
+	Describe("RenameAttribute", func() {
+		It("should rename the attribute of the items that have it", func() {
+			result, err := RenameAttribute(ctx, client, "users", "adress", "address")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result).To(Equal(BackfillResult{Scanned: 6, Updated: 4, Skipped: 2, ConsumedWriteCapacity: 4}))
+			Expect(client.item("users", key(1))).To(Equal(map[string]types.AttributeValue{
+				"pk":      key(1)["pk"],
+				"address": &types.AttributeValueMemberS{Value: "Street 1"},
+			}))
+			Expect(client.item("users", key(3))).To(Equal(key(3)))
+		})
+
+		It("should skip the items already renamed when run again", func() {
+			_, err := RenameAttribute(ctx, client, "users", "adress", "address")
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := RenameAttribute(ctx, client, "users", "adress", "address")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(BackfillResult{Scanned: 6, Skipped: 6}))
+		})
+
+		It("should fail with ErrAttributeExists when the items have both attributes with different values", func() {
+			item := client.item("users", key(2))
+			item["address"] = &types.AttributeValueMemberS{Value: "Other street"}
+			client.putItem("users", item)
+
+			_, err := RenameAttribute(ctx, client, "users", "adress", "address")
+			Expect(err).To(MatchError(ErrAttributeExists))
+		})
+
+		It("should only count the items that would be renamed with WithDryRun", func() {
+			result, err := RenameAttribute(ctx, client, "users", "adress", "address", WithDryRun())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Updated).To(Equal(int64(4)))
+			Expect(result.Skipped).To(Equal(int64(2)))
+			Expect(client.calls).ToNot(ContainElement("UpdateItem"))
+			Expect(client.item("users", key(1))).To(HaveKey("adress"))
+		})
+	})
+
+	Describe("RewriteAttribute", func() {
+		upper := func(_ context.Context, value types.AttributeValue) (types.AttributeValue, error) {
+			return &types.AttributeValueMemberS{Value: strings.ToUpper(value.(*types.AttributeValueMemberS).Value)}, nil
+		}
+
+		It("should rewrite the attribute of the items that have it", func() {
+			result, err := RewriteAttribute(ctx, client, "users", "adress", upper)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result).To(Equal(BackfillResult{Scanned: 6, Updated: 4, Skipped: 2, ConsumedWriteCapacity: 4}))
+			Expect(client.item("users", key(4))).To(HaveKeyWithValue("adress", &types.AttributeValueMemberS{Value: "STREET 4"}))
+
+			result, err = RewriteAttribute(ctx, client, "users", "adress", upper)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Updated).To(BeZero())
+		})
+
+		It("should remove the attribute when the rewrite returns nil", func() {
+			_, err := RewriteAttribute(ctx, client, "users", "adress", func(context.Context, types.AttributeValue) (types.AttributeValue, error) {
+				return nil, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(client.item("users", key(1))).To(Equal(key(1)))
+		})
+	})
+})
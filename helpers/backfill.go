@@ -0,0 +1,334 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+var (
+	// ErrKeyChanged is returned by Backfill when the transform changes the key of an item. Items cannot be moved to
+	// another key in place: copy the table instead.
+	ErrKeyChanged = errors.New("transform changed the key of the item")
+
+	// ErrConflict is returned by Backfill when an item kept being changed concurrently after being transformed again
+	// the number of times set by WithConflictRetries.
+	ErrConflict = errors.New("item changed concurrently")
+)
+
+type BackfillClient interface {
+	DescribeTableClient
+	ScanClient
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// TransformFunc returns the new version of the item given, with the same key. It returns nil to leave the item
+// unchanged. The item given must not be modified.
+type TransformFunc func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error)
+
+// BackfillResult counts what a Backfill did to the items of the table.
+type BackfillResult struct {
+	// Scanned is the number of items read.
+	Scanned int64
+	// Updated is the number of items written.
+	Updated int64
+	// Skipped is the number of items the transform left unchanged.
+	Skipped int64
+	// Missing is the number of items deleted by someone else before they were written.
+	Missing int64
+	// Conflicts is the number of times an item was changed by someone else before it was written, and had to be
+	// transformed again.
+	Conflicts int64
+	// ConsumedWriteCapacity is the write capacity units consumed by the writes.
+	ConsumedWriteCapacity float64
+	// EstimatedWriteCapacity is, for a dry run, the write capacity units the writes would consume, estimated from the
+	// size of the items.
+	EstimatedWriteCapacity float64
+	// Diffs are, for a dry run, samples of the changes that would be made, up to the number set by
+	// WithDryRunSamples.
+	Diffs []ItemDiff
+}
+
+func (r *BackfillResult) add(other BackfillResult) {
+	r.Scanned += other.Scanned
+	r.Updated += other.Updated
+	r.Skipped += other.Skipped
+	r.Missing += other.Missing
+	r.Conflicts += other.Conflicts
+	r.ConsumedWriteCapacity += other.ConsumedWriteCapacity
+	r.EstimatedWriteCapacity += other.EstimatedWriteCapacity
+	r.Diffs = append(r.Diffs, other.Diffs...)
+}
+
+// Backfill scans the table and writes the items changed by the transform. The segments set by WithSegments are
+// scanned in parallel, and the writes of all segments share the budget set by WithWriteCapacity. Throttled requests
+// slow down the segment and are retried.
+//
+// Only the attributes changed by the transform are written, on the condition that the item still has the values it was
+// transformed from. If the item was changed in the meantime, it is read and transformed again, up to the retries set by
+// WithConflictRetries. Items deleted in the meantime are not written back. So, a Backfill with a transform that leaves
+// transformed items unchanged can be run again after being interrupted, and it can run while the table is in use.
+//
+// With WithDryRun, the items are transformed but not written, so the result tells how many items would be changed,
+// the write capacity it would take and samples of the changes.
+// With WithCheckpoint, the progress of each segment is saved after each page, so an interrupted Backfill resumes where
+// it stopped instead of scanning the table again.
+//
+// It stops at the first error, returning it along with what was done so far.
+func Backfill(ctx context.Context, client BackfillClient, tableName string, transform TransformFunc, options ...Option) (BackfillResult, error) {
+	o := newOpts(options)
+
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		return BackfillResult{}, fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+
+	b := &backfill{
+		client:    client,
+		tableName: tableName,
+		keys:      keyNames(output.Table.KeySchema),
+		transform: transform,
+		limiter:   throttle.NewRateLimiter(nil, o.writeCapacity),
+		options:   o,
+	}
+
+	return scanTable(ctx, client, tableName, o, b.backfillPage)
+}
+
+type backfill struct {
+	client    BackfillClient
+	tableName string
+	keys      []string
+	transform TransformFunc
+	limiter   *throttle.RateLimiter
+	options   opts
+}
+
+// backfillPage backfills the items of a page scanned.
+func (b *backfill) backfillPage(ctx context.Context, items []map[string]types.AttributeValue, backoff *throttle.Backoff, result *BackfillResult) error {
+	for _, item := range items {
+		if err := b.backfillItem(ctx, item, backoff, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillItem transforms the item and writes the attributes changed. If the item was changed concurrently, it is read
+// and transformed again.
+func (b *backfill) backfillItem(ctx context.Context, item map[string]types.AttributeValue, backoff *throttle.Backoff, result *BackfillResult) error {
+	key := b.key(item)
+	for conflicts := 0; ; conflicts++ {
+		newItem, err := b.transform(ctx, item)
+		if err != nil {
+			return fmt.Errorf("failed to transform item %s: %w", formatKey(key), err)
+		}
+		if newItem == nil {
+			result.Skipped++
+			return nil
+		}
+		if !reflect.DeepEqual(b.key(newItem), key) {
+			return fmt.Errorf("%w: %s", ErrKeyChanged, formatKey(key))
+		}
+
+		expr, changed, err := b.updateExpression(item, newItem)
+		if err != nil {
+			return fmt.Errorf("failed to build the update expression of item %s: %w", formatKey(key), err)
+		}
+		if !changed {
+			result.Skipped++
+			return nil
+		}
+		if b.options.dryRun {
+			result.Updated++
+			// An update consumes the capacity of the largest of the item before and after it.
+			result.EstimatedWriteCapacity += writeUnits(max(itemSize(item), itemSize(newItem)))
+			result.sample(b.options.dryRunSamples, ItemDiff{Key: key, Before: item, After: newItem})
+			return nil
+		}
+
+		err = b.update(ctx, key, expr, backoff, result)
+		var conditionalCheckFailedException *types.ConditionalCheckFailedException
+		switch {
+		case errors.As(err, &conditionalCheckFailedException):
+		case err != nil:
+			return fmt.Errorf("failed to update item %s: %w", formatKey(key), err)
+		default:
+			result.Updated++
+			return nil
+		}
+
+		item, err = b.get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			result.Missing++
+			return nil
+		}
+		if conflicts >= b.options.conflictRetries {
+			return fmt.Errorf("%w: %s", ErrConflict, formatKey(key))
+		}
+		result.Conflicts++
+	}
+}
+
+// updateExpression builds the update of the attributes changed from item to newItem, on the condition that the item
+// still has the values it was transformed from. It returns false if nothing changed.
+func (b *backfill) updateExpression(item, newItem map[string]types.AttributeValue) (expression.Expression, bool, error) {
+	var (
+		update  expression.UpdateBuilder
+		changed bool
+	)
+	for name, value := range newItem {
+		if slices.Contains(b.keys, name) || reflect.DeepEqual(item[name], value) {
+			continue
+		}
+		update = update.Set(expression.Name(name), expression.Value(value))
+		changed = true
+	}
+	for name := range item {
+		if _, ok := newItem[name]; !ok {
+			update = update.Remove(expression.Name(name))
+			changed = true
+		}
+	}
+	if !changed {
+		return expression.Expression{}, false, nil
+	}
+
+	names := make([]string, 0, len(item)+len(newItem))
+	for name := range item {
+		names = append(names, name)
+	}
+	for name := range newItem {
+		if _, ok := item[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	condition := expression.AttributeExists(expression.Name(b.keys[0]))
+	for _, name := range names {
+		switch value, ok := item[name]; {
+		case slices.Contains(b.keys, name):
+		case ok:
+			condition = condition.And(expression.Name(name).Equal(expression.Value(value)))
+		default:
+			condition = condition.And(expression.AttributeNotExists(expression.Name(name)))
+		}
+	}
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	return expr, true, err
+}
+
+// update writes the item, waiting for the write capacity budget. Throttled writes slow down the segment and are
+// retried.
+func (b *backfill) update(ctx context.Context, key map[string]types.AttributeValue, expr expression.Expression, backoff *throttle.Backoff, result *BackfillResult) error {
+	for {
+		if err := backoff.Wait(ctx); err != nil {
+			return err
+		}
+		if err := b.limiter.Wait(ctx, 1); err != nil {
+			return err
+		}
+
+		output, err := b.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 aws.String(b.tableName),
+			Key:                       key,
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		})
+		units := 1.0
+		if err == nil && output.ConsumedCapacity != nil {
+			units = aws.ToFloat64(output.ConsumedCapacity.CapacityUnits)
+		}
+		b.limiter.Consumed(1, units)
+		if throttle.IsThrottlingError(err) && backoff.Throttled() {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		backoff.Succeeded()
+		result.ConsumedWriteCapacity += units
+		return nil
+	}
+}
+
+// get reads the current version of the item. It returns nil if the item does not exist anymore.
+func (b *backfill) get(ctx context.Context, key map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	output, err := b.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(b.tableName),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item %s: %w", formatKey(key), err)
+	}
+	return output.Item, nil
+}
+
+// key returns the key attributes of the item.
+func (b *backfill) key(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	return itemKey(item, b.keys)
+}
+
+// keyNames returns the names of the key attributes of the key schema.
+func keyNames(schema []types.KeySchemaElement) []string {
+	names := make([]string, 0, len(schema))
+	for _, key := range schema {
+		names = append(names, aws.ToString(key.AttributeName))
+	}
+	return names
+}
+
+// itemKey returns the given key attributes of the item.
+func itemKey(item map[string]types.AttributeValue, names []string) map[string]types.AttributeValue {
+	key := make(map[string]types.AttributeValue, len(names))
+	for _, name := range names {
+		key[name] = item[name]
+	}
+	return key
+}
+
+// formatKey formats the key of an item for the error messages, as in "pk=a, sk=1".
+func formatKey(key map[string]types.AttributeValue) string {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		var value string
+		switch v := key[name].(type) {
+		case *types.AttributeValueMemberS:
+			value = v.Value
+		case *types.AttributeValueMemberN:
+			value = v.Value
+		case *types.AttributeValueMemberB:
+			value = fmt.Sprintf("%x", v.Value)
+		}
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, ", ")
+}
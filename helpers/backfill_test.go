@@ -0,0 +1,184 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backfill", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	key := func(i int) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("user#%02d", i)}}
+	}
+
+	// lowerName adds the lower case version of the name to the item, and removes its legacy attribute.
+	lowerName := func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+		name := item["name"].(*types.AttributeValueMemberS).Value
+		newItem := map[string]types.AttributeValue{}
+		for k, v := range item {
+			newItem[k] = v
+		}
+		newItem["name_lower"] = &types.AttributeValueMemberS{Value: strings.ToLower(name)}
+		delete(newItem, "legacy")
+		return newItem, nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String("users"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+		for i := range 10 {
+			item := key(i)
+			item["name"] = &types.AttributeValueMemberS{Value: fmt.Sprintf("User %02d", i)}
+			item["legacy"] = &types.AttributeValueMemberBOOL{Value: true}
+			client.putItem("users", item)
+		}
+		client.pageSize = 3
+		client.calls = nil
+	})
+
+	It("should write the items changed by the transform in all segments", func() {
+		result, err := Backfill(ctx, client, "users", lowerName, WithSegments(4))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(BackfillResult{Scanned: 10, Updated: 10, ConsumedWriteCapacity: 10}))
+		for i := range 10 {
+			Expect(client.item("users", key(i))).To(Equal(map[string]types.AttributeValue{
+				"pk":         key(i)["pk"],
+				"name":       &types.AttributeValueMemberS{Value: fmt.Sprintf("User %02d", i)},
+				"name_lower": &types.AttributeValueMemberS{Value: fmt.Sprintf("user %02d", i)},
+			}))
+		}
+	})
+
+	It("should skip the items already transformed when run again", func() {
+		_, err := Backfill(ctx, client, "users", lowerName)
+		Expect(err).ToNot(HaveOccurred())
+		client.calls = nil
+
+		result, err := Backfill(ctx, client, "users", lowerName)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(BackfillResult{Scanned: 10, Skipped: 10}))
+		Expect(client.calls).ToNot(ContainElement("UpdateItem"))
+	})
+
+	It("should skip the items the transform returns nil for", func() {
+		result, err := Backfill(ctx, client, "users", func(context.Context, map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			return nil, nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(BackfillResult{Scanned: 10, Skipped: 10}))
+	})
+
+	It("should not consume more than the write capacity budget", func() {
+		startedAt := time.Now()
+		result, err := Backfill(ctx, client, "users", lowerName, WithSegments(2), WithWriteCapacity(5))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.Updated).To(Equal(int64(10)))
+		// The first 5 writes use the initial budget, the other 5 wait for it to refill at 5 units per second.
+		Expect(time.Since(startedAt)).To(BeNumerically(">=", 900*time.Millisecond))
+	})
+
+	It("should retry the throttled writes", func() {
+		client.throttle = 3
+
+		result, err := Backfill(ctx, client, "users", lowerName)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.Updated).To(Equal(int64(10)))
+	})
+
+	When("an item is changed after being read", func() {
+		It("should transform it again", func() {
+			changed := false
+			result, err := Backfill(ctx, client, "users", func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+				if !changed && item["pk"].(*types.AttributeValueMemberS).Value == "user#03" {
+					changed = true
+					concurrent := key(3)
+					concurrent["name"] = &types.AttributeValueMemberS{Value: "Renamed"}
+					concurrent["legacy"] = &types.AttributeValueMemberBOOL{Value: true}
+					client.putItem("users", concurrent)
+				}
+				return lowerName(ctx, item)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result).To(Equal(BackfillResult{Scanned: 10, Updated: 10, Conflicts: 1, ConsumedWriteCapacity: 10}))
+			Expect(client.item("users", key(3))).To(HaveKeyWithValue("name_lower", &types.AttributeValueMemberS{Value: "renamed"}))
+		})
+
+		It("should fail when it keeps changing", func() {
+			_, err := Backfill(ctx, client, "users", func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+				concurrent := key(0)
+				concurrent["name"] = &types.AttributeValueMemberS{Value: time.Now().String()}
+				client.putItem("users", concurrent)
+				return lowerName(ctx, item)
+			}, WithConflictRetries(2))
+
+			Expect(err).To(MatchError(ErrConflict))
+			Expect(err).To(MatchError(ContainSubstring("pk=user#00")))
+		})
+	})
+
+	When("an item is deleted after being read", func() {
+		It("should not write it back", func() {
+			result, err := Backfill(ctx, client, "users", func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+				if item["pk"].(*types.AttributeValueMemberS).Value == "user#05" {
+					client.deleteItem("users", key(5))
+				}
+				return lowerName(ctx, item)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result).To(Equal(BackfillResult{Scanned: 10, Updated: 9, Missing: 1, ConsumedWriteCapacity: 9}))
+			Expect(client.item("users", key(5))).To(BeNil())
+		})
+	})
+
+	When("the transform changes the key", func() {
+		It("should fail with ErrKeyChanged", func() {
+			_, err := Backfill(ctx, client, "users", func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+				return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "other"}}, nil
+			})
+
+			Expect(err).To(MatchError(ErrKeyChanged))
+		})
+	})
+
+	When("the transform fails", func() {
+		It("should stop and return the error", func() {
+			errTransform := errors.New("invalid item")
+			result, err := Backfill(ctx, client, "users", func(context.Context, map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+				return nil, errTransform
+			})
+
+			Expect(err).To(MatchError(errTransform))
+			Expect(result.Updated).To(BeZero())
+		})
+	})
+})
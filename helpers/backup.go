@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrBackupDeleted is returned by CreateBackup when the backup was deleted before becoming available.
+var ErrBackupDeleted = errors.New("backup deleted")
+
+type BackupClient interface {
+	DescribeTableClient
+	CreateBackup(ctx context.Context, params *dynamodb.CreateBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateBackupOutput, error)
+	DescribeBackup(ctx context.Context, params *dynamodb.DescribeBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeBackupOutput, error)
+	ListBackups(ctx context.Context, params *dynamodb.ListBackupsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListBackupsOutput, error)
+	RestoreTableFromBackup(ctx context.Context, params *dynamodb.RestoreTableFromBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.RestoreTableFromBackupOutput, error)
+}
+
+// CreateBackup takes an on-demand backup of the table with the given name, so a migration can back the table up
+// before a risky change, and waits until the backup is available. If the table already has a backup with the name, as
+// taken by a previous run, CreateBackup only waits for it: the name should identify the migration, e.g. its ID.
+//
+// With WithMetadata, the ARN of the backup is recorded in the metadata of the migration under "backup_arn:<table>",
+// for the tooling to restore it with RestoreBackup.
+func CreateBackup(ctx context.Context, client BackupClient, tableName, backupName string, options ...Option) (types.BackupDetails, error) {
+	o := newOpts(options)
+
+	backupARN, err := findBackup(ctx, client, tableName, backupName)
+	if err != nil {
+		return types.BackupDetails{}, err
+	}
+	if backupARN == "" {
+		output, err := client.CreateBackup(ctx, &dynamodb.CreateBackupInput{
+			TableName:  aws.String(tableName),
+			BackupName: aws.String(backupName),
+		})
+		if err != nil {
+			return types.BackupDetails{}, fmt.Errorf("failed to back up table %s: %w", tableName, err)
+		}
+		backupARN = aws.ToString(output.BackupDetails.BackupArn)
+	}
+
+	if o.metadataRecorder != nil {
+		if err := o.metadataRecorder.SetMetadata(ctx, o.migrationID, "backup_arn:"+tableName, backupARN); err != nil {
+			return types.BackupDetails{}, fmt.Errorf("failed to record the backup of table %s: %w", tableName, err)
+		}
+	}
+
+	var details types.BackupDetails
+	err = poll(ctx, o, func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeBackup(ctx, &dynamodb.DescribeBackupInput{
+			BackupArn: aws.String(backupARN),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to describe backup %s: %w", backupARN, err)
+		}
+		details = *output.BackupDescription.BackupDetails
+		return details.BackupStatus != types.BackupStatusCreating, nil
+	})
+	if err != nil {
+		return details, fmt.Errorf("failed waiting for backup %s: %w", backupARN, err)
+	}
+	if details.BackupStatus != types.BackupStatusAvailable {
+		return details, fmt.Errorf("%w: %s of table %s", ErrBackupDeleted, backupARN, tableName)
+	}
+	return details, nil
+}
+
+// RestoreBackup restores the backup to a new table, and waits until the table is active. If the table exists, as
+// restored by a previous run, RestoreBackup only waits for it; if it was not restored from the backup, it returns an
+// ErrTableExists.
+//
+// With WithMetadata, the ARN of the backup is recorded in the metadata of the migration under
+// "restored_backup_arn:<table>".
+func RestoreBackup(ctx context.Context, client BackupClient, backupARN, tableName string, options ...Option) (*types.TableDescription, error) {
+	o := newOpts(options)
+
+	table, err := describeTable(ctx, client, tableName)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case table == nil:
+		_, err := client.RestoreTableFromBackup(ctx, &dynamodb.RestoreTableFromBackupInput{
+			BackupArn:       aws.String(backupARN),
+			TargetTableName: aws.String(tableName),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore backup %s to table %s: %w", backupARN, tableName, err)
+		}
+	case table.RestoreSummary == nil || aws.ToString(table.RestoreSummary.SourceBackupArn) != backupARN:
+		return nil, fmt.Errorf("%w: %s was not restored from backup %s", ErrTableExists, tableName, backupARN)
+	}
+
+	if o.metadataRecorder != nil {
+		if err := o.metadataRecorder.SetMetadata(ctx, o.migrationID, "restored_backup_arn:"+tableName, backupARN); err != nil {
+			return nil, fmt.Errorf("failed to record the restore of table %s: %w", tableName, err)
+		}
+	}
+
+	return waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		return tableActive(table) && (table.RestoreSummary == nil || !aws.ToBool(table.RestoreSummary.RestoreInProgress))
+	})
+}
+
+// findBackup returns the ARN of the backup of the table with the name, or an empty ARN if there is none.
+func findBackup(ctx context.Context, client BackupClient, tableName, backupName string) (string, error) {
+	input := &dynamodb.ListBackupsInput{
+		TableName:  aws.String(tableName),
+		BackupType: types.BackupTypeFilterUser,
+	}
+	for {
+		output, err := client.ListBackups(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("failed to list the backups of table %s: %w", tableName, err)
+		}
+		for _, summary := range output.BackupSummaries {
+			if aws.ToString(summary.BackupName) == backupName && summary.BackupStatus != types.BackupStatusDeleted {
+				return aws.ToString(summary.BackupArn), nil
+			}
+		}
+		if output.LastEvaluatedBackupArn == nil {
+			return "", nil
+		}
+		input.ExclusiveStartBackupArn = output.LastEvaluatedBackupArn
+	}
+}
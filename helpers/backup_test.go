@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backups", func() {
+	var (
+		ctx      context.Context
+		client   *fakeClient
+		metadata fakeMetadataRecorder
+	)
+
+	key := map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "user#1"}}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		metadata = fakeMetadataRecorder{}
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("users"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+		})).To(Succeed())
+		client.putItem("users", key)
+	})
+
+	Describe("CreateBackup", func() {
+		It("should back up the table, wait for it and record its ARN", func() {
+			details, err := CreateBackup(ctx, client, "users", "0044-split-names", WithMetadata(metadata, "0044"), WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(details.BackupStatus).To(Equal(types.BackupStatusAvailable))
+			Expect(aws.ToString(details.BackupName)).To(Equal("0044-split-names"))
+			Expect(metadata).To(Equal(fakeMetadataRecorder{
+				"0044": {"backup_arn:users": aws.ToString(details.BackupArn)},
+			}))
+		})
+
+		It("should wait for the backup with the same name when run again", func() {
+			first, err := CreateBackup(ctx, client, "users", "0044-split-names", WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+
+			second, err := CreateBackup(ctx, client, "users", "0044-split-names", WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(second.BackupArn).To(Equal(first.BackupArn))
+			Expect(client.backups).To(HaveLen(1))
+		})
+	})
+
+	Describe("RestoreBackup", func() {
+		var backupARN string
+
+		BeforeEach(func() {
+			details, err := CreateBackup(ctx, client, "users", "0044-split-names", WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+			backupARN = aws.ToString(details.BackupArn)
+			client.pending = 1
+		})
+
+		It("should restore the backup to a new table and wait for it", func() {
+			table, err := RestoreBackup(ctx, client, backupARN, "users_restored", WithMetadata(metadata, "0044"), WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(table.TableStatus).To(Equal(types.TableStatusActive))
+			Expect(client.item("users_restored", key)).ToNot(BeNil())
+			Expect(metadata).To(Equal(fakeMetadataRecorder{
+				"0044": {"restored_backup_arn:users_restored": backupARN},
+			}))
+		})
+
+		It("should only wait for the table when run again", func() {
+			_, err := RestoreBackup(ctx, client, backupARN, "users_restored", WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+			client.calls = nil
+
+			_, err = RestoreBackup(ctx, client, backupARN, "users_restored", WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.calls).ToNot(ContainElement("RestoreTableFromBackup"))
+		})
+
+		It("should fail with ErrTableExists when the table was not restored from the backup", func() {
+			_, err := RestoreBackup(ctx, client, backupARN, "users")
+			Expect(err).To(MatchError(ErrTableExists))
+		})
+	})
+})
@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+// batchWriteSize is the maximum number of items of a BatchWriteItem request.
+const batchWriteSize = 25
+
+type BatchWriteClient interface {
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+// BatchWriteResult counts what a BatchWrite wrote to the table.
+type BatchWriteResult struct {
+	// Written is the number of requests written.
+	Written int64
+	// ConsumedWriteCapacity is the write capacity units consumed by the writes.
+	ConsumedWriteCapacity float64
+}
+
+// BatchWrite writes the put and delete requests to the table, with the batching of the data steps, for the custom
+// steps of the migrations: the requests are sent in batches of up to 25, sharing the budget set by WithWriteCapacity.
+// The requests left unprocessed by DynamoDB and the throttled batches are retried after a delay doubling at each
+// attempt, failing if they are still left after the last one. As BatchWriteItem does, the requests must not have two
+// items with the same key.
+//
+// It stops at the first error, returning it along with the write capacity consumed so far.
+func BatchWrite(ctx context.Context, client BatchWriteClient, tableName string, requests []types.WriteRequest, options ...Option) (BatchWriteResult, error) {
+	o := newOpts(options)
+
+	var backoff throttle.Backoff
+	consumed, err := batchWriteRequests(ctx, client, tableName, requests, throttle.NewRateLimiter(nil, o.writeCapacity), &backoff)
+	if err != nil {
+		return BatchWriteResult{ConsumedWriteCapacity: consumed}, err
+	}
+	return BatchWriteResult{Written: int64(len(requests)), ConsumedWriteCapacity: consumed}, nil
+}
+
+// BatchPut puts the items in the table, as BatchWrite does.
+func BatchPut(ctx context.Context, client BatchWriteClient, tableName string, items []map[string]types.AttributeValue, options ...Option) (BatchWriteResult, error) {
+	return BatchWrite(ctx, client, tableName, putRequests(items), options...)
+}
+
+// batchWrite puts the items in the table, as batchWriteRequests does.
+func batchWrite(ctx context.Context, client BatchWriteClient, tableName string, items []map[string]types.AttributeValue, limiter *throttle.RateLimiter, backoff *throttle.Backoff) (float64, error) {
+	return batchWriteRequests(ctx, client, tableName, putRequests(items), limiter, backoff)
+}
+
+func putRequests(items []map[string]types.AttributeValue) []types.WriteRequest {
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+	return requests
+}
+
+// batchWriteRequests writes the requests to the table, in batches of up to 25 requests, waiting for the write capacity
+// budget. The requests left unprocessed by DynamoDB and the throttled batches slow down the worker and are retried. It
+// returns the write capacity units consumed.
+func batchWriteRequests(ctx context.Context, client BatchWriteClient, tableName string, writes []types.WriteRequest, limiter *throttle.RateLimiter, backoff *throttle.Backoff) (float64, error) {
+	var consumed float64
+	for start := 0; start < len(writes); start += batchWriteSize {
+		requests := writes[start:min(start+batchWriteSize, len(writes))]
+
+		for len(requests) > 0 {
+			if err := backoff.Wait(ctx); err != nil {
+				return consumed, err
+			}
+			reserved := float64(len(requests))
+			if err := limiter.Wait(ctx, reserved); err != nil {
+				return consumed, err
+			}
+
+			output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems:           map[string][]types.WriteRequest{tableName: requests},
+				ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+			})
+			units := reserved
+			if err == nil && len(output.ConsumedCapacity) > 0 {
+				units = 0
+				for _, capacity := range output.ConsumedCapacity {
+					units += aws.ToFloat64(capacity.CapacityUnits)
+				}
+			}
+			limiter.Consumed(reserved, units)
+			if throttle.IsThrottlingError(err) && backoff.Throttled() {
+				continue
+			}
+			if err != nil {
+				return consumed, fmt.Errorf("failed to write items to table %s: %w", tableName, err)
+			}
+			consumed += units
+
+			requests = output.UnprocessedItems[tableName]
+			switch {
+			case len(requests) == 0:
+				backoff.Succeeded()
+			case !backoff.Throttled():
+				return consumed, fmt.Errorf("failed to write items to table %s: %d items left unprocessed", tableName, len(requests))
+			}
+		}
+	}
+	return consumed, nil
+}
@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BatchWrite", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	key := func(pk string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: pk}}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("users"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should write the puts and deletes in batches", func() {
+		client.putItem("users", key("old"))
+
+		requests := []types.WriteRequest{{DeleteRequest: &types.DeleteRequest{Key: key("old")}}}
+		for i := range 30 {
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: key(fmt.Sprintf("user%02d", i))}})
+		}
+
+		result, err := BatchWrite(ctx, client, "users", requests)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(BatchWriteResult{Written: 31, ConsumedWriteCapacity: 31}))
+		Expect(client.calls).To(Equal([]string{"BatchWriteItem", "BatchWriteItem"}))
+		Expect(client.tables["users"].items).To(HaveLen(30))
+		Expect(client.item("users", key("old"))).To(BeNil())
+	})
+
+	It("should retry the unprocessed items", func() {
+		var items []map[string]types.AttributeValue
+		for i := range 10 {
+			items = append(items, key(fmt.Sprintf("user%02d", i)))
+		}
+		client.unprocessed = 4
+
+		result, err := BatchPut(ctx, client, "users", items)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.Written).To(Equal(int64(10)))
+		Expect(client.calls).To(Equal([]string{"BatchWriteItem", "BatchWriteItem"}))
+		Expect(client.tables["users"].items).To(HaveLen(10))
+	})
+})
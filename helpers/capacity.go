@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrCapacityLimit is returned by UpdateCapacity when DynamoDB does not allow changing the capacity of the table yet:
+// a table can be switched to on-demand once every 24 hours, and the provisioned throughput can be decreased only a few
+// times a day.
+var ErrCapacityLimit = errors.New("capacity change limit reached")
+
+// payPerRequestSwitchInterval is the minimum interval between two switches of a table to on-demand.
+const payPerRequestSwitchInterval = 24 * time.Hour
+
+type UpdateCapacityClient interface {
+	DescribeTableClient
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+}
+
+// Capacity is the billing mode and, for provisioned tables, the throughput of a table.
+type Capacity struct {
+	BillingMode types.BillingMode
+	// ReadCapacityUnits and WriteCapacityUnits are required by provisioned tables.
+	ReadCapacityUnits  int64
+	WriteCapacityUnits int64
+}
+
+// UpdateCapacity switches the table between provisioned and on-demand, or changes its provisioned throughput, and
+// waits until the table is active again. Nothing is done if the table already has the capacity. When switching a
+// table to provisioned, its global secondary indexes get the same throughput as the table.
+//
+// If DynamoDB does not allow the change yet, it returns an ErrCapacityLimit telling when it is allowed, if known.
+func UpdateCapacity(ctx context.Context, client UpdateCapacityClient, tableName string, capacity Capacity, options ...Option) error {
+	o := newOpts(options)
+
+	if capacity.BillingMode == "" {
+		capacity.BillingMode = types.BillingModeProvisioned
+	}
+	if capacity.BillingMode == types.BillingModeProvisioned && (capacity.ReadCapacityUnits <= 0 || capacity.WriteCapacityUnits <= 0) {
+		return fmt.Errorf("invalid capacity for table %s: provisioned tables require read and write capacity units", tableName)
+	}
+
+	table, err := waitTable(ctx, client, tableName, o, tableActive)
+	if err != nil {
+		return err
+	}
+
+	current := billingMode(table)
+	if current == capacity.BillingMode && (current == types.BillingModePayPerRequest || sameThroughput(table.ProvisionedThroughput, capacity)) {
+		return nil
+	}
+
+	input := &dynamodb.UpdateTableInput{
+		TableName:   aws.String(tableName),
+		BillingMode: capacity.BillingMode,
+	}
+	if capacity.BillingMode == types.BillingModePayPerRequest {
+		if summary := table.BillingModeSummary; summary != nil && summary.LastUpdateToPayPerRequestDateTime != nil {
+			if allowedAt := summary.LastUpdateToPayPerRequestDateTime.Add(payPerRequestSwitchInterval); time.Now().Before(allowedAt) {
+				return fmt.Errorf("%w: table %s can be switched to on-demand again at %s", ErrCapacityLimit, tableName, allowedAt.UTC().Format(time.RFC3339))
+			}
+		}
+	} else {
+		throughput := &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(capacity.ReadCapacityUnits),
+			WriteCapacityUnits: aws.Int64(capacity.WriteCapacityUnits),
+		}
+		input.ProvisionedThroughput = throughput
+		if current != types.BillingModeProvisioned {
+			for _, index := range table.GlobalSecondaryIndexes {
+				input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, types.GlobalSecondaryIndexUpdate{
+					Update: &types.UpdateGlobalSecondaryIndexAction{
+						IndexName:             index.IndexName,
+						ProvisionedThroughput: throughput,
+					},
+				})
+			}
+		}
+	}
+
+	_, err = client.UpdateTable(ctx, input)
+	var limitExceededException *types.LimitExceededException
+	switch {
+	case errors.As(err, &limitExceededException):
+		return fmt.Errorf("%w: table %s: %w", ErrCapacityLimit, tableName, err)
+	case err != nil:
+		return fmt.Errorf("failed to update the capacity of table %s: %w", tableName, err)
+	}
+
+	_, err = waitTable(ctx, client, tableName, o, tableActive)
+	return err
+}
+
+func sameThroughput(throughput *types.ProvisionedThroughputDescription, capacity Capacity) bool {
+	return throughput != nil &&
+		aws.ToInt64(throughput.ReadCapacityUnits) == capacity.ReadCapacityUnits &&
+		aws.ToInt64(throughput.WriteCapacityUnits) == capacity.WriteCapacityUnits
+}
@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpdateCapacity", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String("orders"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			ProvisionedThroughput: &types.ProvisionedThroughput{
+				ReadCapacityUnits:  aws.Int64(5),
+				WriteCapacityUnits: aws.Int64(5),
+			},
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should change the provisioned throughput and wait for the table", func() {
+		client.pending = 2
+
+		Expect(UpdateCapacity(ctx, client, "orders", Capacity{ReadCapacityUnits: 10, WriteCapacityUnits: 20}, WithPollInterval(time.Millisecond))).To(Succeed())
+
+		table := client.tables["orders"].description
+		Expect(table.TableStatus).To(Equal(types.TableStatusActive))
+		Expect(aws.ToInt64(table.ProvisionedThroughput.ReadCapacityUnits)).To(BeEquivalentTo(10))
+		Expect(aws.ToInt64(table.ProvisionedThroughput.WriteCapacityUnits)).To(BeEquivalentTo(20))
+	})
+
+	It("should do nothing when the table already has the capacity", func() {
+		Expect(UpdateCapacity(ctx, client, "orders", Capacity{ReadCapacityUnits: 5, WriteCapacityUnits: 5})).To(Succeed())
+		Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+	})
+
+	It("should switch the table between on-demand and provisioned", func() {
+		Expect(UpdateCapacity(ctx, client, "orders", Capacity{BillingMode: types.BillingModePayPerRequest})).To(Succeed())
+		Expect(billingMode(&client.tables["orders"].description)).To(Equal(types.BillingModePayPerRequest))
+
+		Expect(UpdateCapacity(ctx, client, "orders", Capacity{ReadCapacityUnits: 1, WriteCapacityUnits: 1})).To(Succeed())
+		Expect(billingMode(&client.tables["orders"].description)).To(Equal(types.BillingModeProvisioned))
+	})
+
+	It("should fail with ErrCapacityLimit when switched to on-demand in the last 24 hours", func() {
+		Expect(UpdateCapacity(ctx, client, "orders", Capacity{BillingMode: types.BillingModePayPerRequest})).To(Succeed())
+		Expect(UpdateCapacity(ctx, client, "orders", Capacity{ReadCapacityUnits: 1, WriteCapacityUnits: 1})).To(Succeed())
+		client.calls = nil
+
+		err := UpdateCapacity(ctx, client, "orders", Capacity{BillingMode: types.BillingModePayPerRequest})
+		Expect(err).To(MatchError(ErrCapacityLimit))
+		Expect(err).To(MatchError(ContainSubstring("can be switched to on-demand again at")))
+		Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+	})
+
+	It("should fail with ErrCapacityLimit when DynamoDB rejects the change", func() {
+		client.updateErr = &types.LimitExceededException{Message: aws.String("Subscriber limit exceeded: Provisioned throughput decreases are limited")}
+
+		err := UpdateCapacity(ctx, client, "orders", Capacity{ReadCapacityUnits: 1, WriteCapacityUnits: 1})
+		Expect(err).To(MatchError(ErrCapacityLimit))
+		Expect(err).To(MatchError(ContainSubstring("decreases are limited")))
+	})
+
+	It("should fail when a provisioned capacity has no units", func() {
+		Expect(UpdateCapacity(ctx, client, "orders", Capacity{BillingMode: types.BillingModeProvisioned})).To(MatchError(ContainSubstring("require read and write capacity units")))
+	})
+})
@@ -0,0 +1,255 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrCheckpointMismatch is returned by the data steps when the checkpoint saved was made with another number of
+// segments. The LastEvaluatedKey of a segment cannot be used by another number of segments: run the step with the same
+// segments as before, or with another checkpoint ID to start it over.
+var ErrCheckpointMismatch = errors.New("checkpoint does not match the step")
+
+// ErrCheckpointTable is returned by the TableCheckpointStore set up with the default migrations table, or lock table,
+// of the Target.
+var ErrCheckpointTable = errors.New("the checkpoints cannot be saved in the tables of the Target")
+
+// targetTableNames are the default names of the migrations table and lock table of the Target.
+var targetTableNames = []string{"_migrations", "_migrations-lock"}
+
+// Checkpoint is the progress of a data step, saved after each page scanned.
+type Checkpoint struct {
+	Segments []SegmentCheckpoint
+}
+
+// SegmentCheckpoint is the progress of a segment of a data step.
+type SegmentCheckpoint struct {
+	// StartKey is the LastEvaluatedKey of the last page processed, so the scan of the segment resumes after it.
+	StartKey map[string]types.AttributeValue
+	// Done tells whether the whole segment was processed.
+	Done bool
+	// Result is what was done by the pages processed.
+	Result BackfillResult
+}
+
+// Result is what was done by all the segments.
+func (c *Checkpoint) Result() BackfillResult {
+	var result BackfillResult
+	for _, segment := range c.Segments {
+		result.add(segment.Result)
+	}
+	return result
+}
+
+// CheckpointStore saves the progress of the data steps set up with WithCheckpoint.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the checkpoint saved with the ID, or nil if there is none.
+	LoadCheckpoint(ctx context.Context, id string) (*Checkpoint, error)
+	// SaveCheckpoint saves the checkpoint with the ID, replacing the one saved before.
+	SaveCheckpoint(ctx context.Context, id string, checkpoint *Checkpoint) error
+}
+
+// StepStore records the steps of a migration completed, for the steps wrapped by CheckpointStep.
+type StepStore interface {
+	// StepCompleted tells whether the step of the migration was completed.
+	StepCompleted(ctx context.Context, migrationID, step string) (bool, error)
+	// CompleteStep records the step of the migration as completed.
+	CompleteStep(ctx context.Context, migrationID, step string) error
+	// ResetStep forgets the step of the migration was completed, so it runs again, as after the migration is undone.
+	ResetStep(ctx context.Context, migrationID, step string) error
+}
+
+type CheckpointClient interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// TableCheckpointStore saves the checkpoints as items of a DynamoDB table with a string hash key named "id", such as
+// one created by EnsureTable. Completed checkpoints are kept, so running a completed step again with the same ID
+// does nothing. It is also a StepStore, recording the completed steps as items with the ID "<migration>#step#<step>".
+//
+// The table must not be the migrations table of the Target: the Target reads every item of it as a migration, so the
+// checkpoints and steps would be listed by Done as migrations applied. The store fails with an ErrCheckpointTable when
+// set up with the default tables of the Target, the ones set by WithTableName are up to the caller to keep apart.
+type TableCheckpointStore struct {
+	client    CheckpointClient
+	tableName string
+}
+
+// NewTableCheckpointStore creates a store saving the checkpoints in the given table.
+func NewTableCheckpointStore(client CheckpointClient, tableName string) *TableCheckpointStore {
+	return &TableCheckpointStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+type ddbSegmentCheckpoint struct {
+	Done                  bool    `dynamodbav:"done"`
+	Scanned               int64   `dynamodbav:"scanned"`
+	Updated               int64   `dynamodbav:"updated"`
+	Skipped               int64   `dynamodbav:"skipped"`
+	Missing               int64   `dynamodbav:"missing"`
+	Conflicts             int64   `dynamodbav:"conflicts"`
+	ConsumedWriteCapacity float64 `dynamodbav:"consumed_write_capacity"`
+}
+
+// checkTable fails with an ErrCheckpointTable if the table of the store is one of the tables of the Target.
+func (s *TableCheckpointStore) checkTable() error {
+	if slices.Contains(targetTableNames, s.tableName) {
+		return fmt.Errorf("%w: %s", ErrCheckpointTable, s.tableName)
+	}
+	return nil
+}
+
+func (s *TableCheckpointStore) LoadCheckpoint(ctx context.Context, id string) (*Checkpoint, error) {
+	if err := s.checkTable(); err != nil {
+		return nil, err
+	}
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint item: %w", err)
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	segments, ok := output.Item["segments"].(*types.AttributeValueMemberL)
+	if !ok {
+		return nil, fmt.Errorf("invalid checkpoint item %s: missing segments", id)
+	}
+	checkpoint := &Checkpoint{Segments: make([]SegmentCheckpoint, 0, len(segments.Value))}
+	for _, value := range segments.Value {
+		m, ok := value.(*types.AttributeValueMemberM)
+		if !ok {
+			return nil, fmt.Errorf("invalid checkpoint item %s: invalid segment", id)
+		}
+		var segment ddbSegmentCheckpoint
+		if err := attributevalue.UnmarshalMap(m.Value, &segment); err != nil {
+			return nil, fmt.Errorf("invalid checkpoint item %s: %w", id, err)
+		}
+		var startKey map[string]types.AttributeValue
+		if key, ok := m.Value["start_key"].(*types.AttributeValueMemberM); ok {
+			startKey = key.Value
+		}
+		checkpoint.Segments = append(checkpoint.Segments, SegmentCheckpoint{
+			StartKey: startKey,
+			Done:     segment.Done,
+			Result: BackfillResult{
+				Scanned:               segment.Scanned,
+				Updated:               segment.Updated,
+				Skipped:               segment.Skipped,
+				Missing:               segment.Missing,
+				Conflicts:             segment.Conflicts,
+				ConsumedWriteCapacity: segment.ConsumedWriteCapacity,
+			},
+		})
+	}
+	return checkpoint, nil
+}
+
+func (s *TableCheckpointStore) SaveCheckpoint(ctx context.Context, id string, checkpoint *Checkpoint) error {
+	if err := s.checkTable(); err != nil {
+		return err
+	}
+	segments := make([]types.AttributeValue, 0, len(checkpoint.Segments))
+	for _, segment := range checkpoint.Segments {
+		m, err := attributevalue.MarshalMap(ddbSegmentCheckpoint{
+			Done:                  segment.Done,
+			Scanned:               segment.Result.Scanned,
+			Updated:               segment.Result.Updated,
+			Skipped:               segment.Result.Skipped,
+			Missing:               segment.Result.Missing,
+			Conflicts:             segment.Result.Conflicts,
+			ConsumedWriteCapacity: segment.Result.ConsumedWriteCapacity,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal checkpoint segment: %w", err)
+		}
+		if segment.StartKey != nil {
+			m["start_key"] = &types.AttributeValueMemberM{Value: segment.StartKey}
+		}
+		segments = append(segments, &types.AttributeValueMemberM{Value: m})
+	}
+
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]types.AttributeValue{
+			"id":         &types.AttributeValueMemberS{Value: id},
+			"segments":   &types.AttributeValueMemberL{Value: segments},
+			"updated_at": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put checkpoint item: %w", err)
+	}
+	return nil
+}
+
+func (s *TableCheckpointStore) StepCompleted(ctx context.Context, migrationID, step string) (bool, error) {
+	if err := s.checkTable(); err != nil {
+		return false, err
+	}
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            stepKey(migrationID, step),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get step item: %w", err)
+	}
+	return output.Item != nil, nil
+}
+
+func (s *TableCheckpointStore) CompleteStep(ctx context.Context, migrationID, step string) error {
+	if err := s.checkTable(); err != nil {
+		return err
+	}
+	item := stepKey(migrationID, step)
+	item["migration_id"] = &types.AttributeValueMemberS{Value: migrationID}
+	item["step"] = &types.AttributeValueMemberS{Value: step}
+	item["completed_at"] = &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)}
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put step item: %w", err)
+	}
+	return nil
+}
+
+func (s *TableCheckpointStore) ResetStep(ctx context.Context, migrationID, step string) error {
+	if err := s.checkTable(); err != nil {
+		return err
+	}
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       stepKey(migrationID, step),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete step item: %w", err)
+	}
+	return nil
+}
+
+func stepKey(migrationID, step string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: migrationID + "#step#" + step},
+	}
+}
@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checkpoint", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+		store  *TableCheckpointStore
+	)
+
+	createTable := func(name, key string) {
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String(name),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String(key), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String(key), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+	}
+
+	// markVisited sets the visited attribute of the items, failing on the item with the given pk.
+	markVisited := func(failOn string) TransformFunc {
+		return func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			if item["pk"].(*types.AttributeValueMemberS).Value == failOn {
+				return nil, errors.New("interrupted")
+			}
+			newItem := map[string]types.AttributeValue{}
+			for k, v := range item {
+				newItem[k] = v
+			}
+			newItem["visited"] = &types.AttributeValueMemberBOOL{Value: true}
+			return newItem, nil
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		createTable("users", "pk")
+		createTable("progress", "id")
+		for i := range 10 {
+			client.putItem("users", map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("user#%02d", i)},
+			})
+		}
+		client.pageSize = 3
+		store = NewTableCheckpointStore(client, "progress")
+	})
+
+	It("should resume an interrupted backfill after the last page processed", func() {
+		result, err := Backfill(ctx, client, "users", markVisited("user#05"), WithCheckpoint(store, "0001_visited"))
+		Expect(err).To(MatchError(ContainSubstring("interrupted")))
+		Expect(result).To(Equal(BackfillResult{Scanned: 6, Updated: 5, ConsumedWriteCapacity: 5}))
+
+		checkpoint, err := store.LoadCheckpoint(ctx, "0001_visited")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint.Segments).To(HaveLen(1))
+		Expect(checkpoint.Segments[0].StartKey).To(HaveKeyWithValue("pk", &types.AttributeValueMemberS{Value: "user#02"}))
+		Expect(checkpoint.Segments[0].Done).To(BeFalse())
+		Expect(checkpoint.Result()).To(Equal(BackfillResult{Scanned: 3, Updated: 3, ConsumedWriteCapacity: 3}))
+
+		result, err = Backfill(ctx, client, "users", markVisited(""), WithCheckpoint(store, "0001_visited"))
+		Expect(err).ToNot(HaveOccurred())
+
+		// The items of the interrupted page are read again, but they were already updated.
+		Expect(result).To(Equal(BackfillResult{Scanned: 10, Updated: 8, Skipped: 2, ConsumedWriteCapacity: 8}))
+		for i := range 10 {
+			Expect(client.item("users", map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("user#%02d", i)},
+			})).To(HaveKey("visited"))
+		}
+	})
+
+	It("should not scan the table again once the backfill is completed", func() {
+		_, err := Backfill(ctx, client, "users", markVisited(""), WithSegments(2), WithCheckpoint(store, "0001_visited"))
+		Expect(err).ToNot(HaveOccurred())
+		client.calls = nil
+
+		result, err := Backfill(ctx, client, "users", markVisited(""), WithSegments(2), WithCheckpoint(store, "0001_visited"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(BackfillResult{Scanned: 10, Updated: 10, ConsumedWriteCapacity: 10}))
+		Expect(client.calls).ToNot(ContainElement("Scan"))
+	})
+
+	It("should not save the checkpoint of a dry run", func() {
+		_, err := Backfill(ctx, client, "users", markVisited(""), WithDryRun(), WithCheckpoint(store, "0001_visited"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(store.LoadCheckpoint(ctx, "0001_visited")).To(BeNil())
+	})
+
+	It("should fail with ErrCheckpointMismatch when the segments changed", func() {
+		_, err := Backfill(ctx, client, "users", markVisited(""), WithSegments(2), WithCheckpoint(store, "0001_visited"))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = Backfill(ctx, client, "users", markVisited(""), WithSegments(4), WithCheckpoint(store, "0001_visited"))
+		Expect(err).To(MatchError(ErrCheckpointMismatch))
+	})
+
+	It("should refuse to save the checkpoints in the migrations table", func() {
+		store := NewTableCheckpointStore(client, "_migrations")
+
+		_, err := Backfill(ctx, client, "users", markVisited(""), WithCheckpoint(store, "0001_visited"))
+		Expect(err).To(MatchError(ErrCheckpointTable))
+		Expect(store.CompleteStep(ctx, "0001", "backfill")).To(MatchError(ErrCheckpointTable))
+		Expect(client.calls).ToNot(ContainElement("Scan"))
+	})
+})
@@ -0,0 +1,443 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+const (
+	// MaxItemSize is the maximum size of a DynamoDB item, including the names of its attributes.
+	MaxItemSize = 400 * 1024
+
+	// DefaultChunkSize is the default size of the chunks of a payload written by PutChunked, leaving room under
+	// MaxItemSize for the key of the chunk.
+	DefaultChunkSize = 350 * 1024
+)
+
+const (
+	chunkCountAttribute    = "chunk_count"
+	chunkSizeAttribute     = "chunk_payload_size"
+	chunkChecksumAttribute = "chunk_checksum"
+	chunkVersionAttribute  = "chunk_version"
+	chunkDataAttribute     = "chunk_data"
+)
+
+var (
+	// ErrChunkKeySchema is returned by the chunk helpers when the table does not have a string sort key, which the keys
+	// of the chunks are made from.
+	ErrChunkKeySchema = errors.New("table has no string sort key for the chunks")
+
+	// ErrChunkedItemNotFound is returned by OpenChunked and DeleteChunked when the item does not exist, or was not
+	// written by PutChunked.
+	ErrChunkedItemNotFound = errors.New("chunked item not found")
+
+	// ErrChunkCorrupted is returned by the ChunkReader when a chunk is missing, or the payload read does not match the
+	// size or checksum of the manifest.
+	ErrChunkCorrupted = errors.New("chunked item corrupted")
+)
+
+type ChunkClient interface {
+	DescribeTableClient
+	BatchWriteClient
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// WithChunkSize sets the size, in bytes, of the chunks of a payload written by PutChunked. A chunk item must still fit
+// MaxItemSize along with its key.
+func WithChunkSize(size int) Option {
+	return func(o *opts) {
+		o.chunkSize = max(size, 1)
+	}
+}
+
+// ChunkManifest describes a payload written in chunks by PutChunked.
+type ChunkManifest struct {
+	// Item is the item written along with the payload, without the attributes of the manifest.
+	Item map[string]types.AttributeValue
+	// Size is the size of the payload, in bytes.
+	Size int64
+	// Chunks is the number of chunks the payload was split into.
+	Chunks int
+	// Checksum is the SHA-256 of the payload, hex encoded.
+	Checksum string
+	// Version identifies the chunks of this write of the payload.
+	Version string
+}
+
+// PutChunked writes a payload that may not fit the 400KB item limit, for the data migrations with a few jumbo records.
+// The payload is split into chunk items of up to the size set by WithChunkSize, written in batches sharing the budget
+// set by WithWriteCapacity. Then the item, which must have the key attributes of the table, is written as the manifest
+// of the chunks. The table must have a string sort key: the chunk items have the partition key of the item and the
+// sort key "<sort key>#chunk#<version>#<index>", so no other item of the table may have such sort keys.
+//
+// The manifest is written only after all its chunks, and the chunks of the payload it replaces are deleted only after
+// it, so a reader never sees a manifest with missing chunks, except when it is replaced while being read. If the
+// manifest was replaced since it was read by PutChunked, the chunks written are deleted and ErrConflict is returned.
+func PutChunked(ctx context.Context, client ChunkClient, tableName string, item map[string]types.AttributeValue, payload []byte, options ...Option) (ChunkManifest, error) {
+	o := newOpts(options)
+
+	keys, err := chunkKeys(ctx, client, tableName)
+	if err != nil {
+		return ChunkManifest{}, err
+	}
+	key := itemKey(item, keys)
+	for _, name := range keys {
+		if key[name] == nil {
+			return ChunkManifest{}, fmt.Errorf("item has no key attribute %s of table %s", name, tableName)
+		}
+	}
+	for _, name := range []string{chunkCountAttribute, chunkSizeAttribute, chunkChecksumAttribute, chunkVersionAttribute} {
+		if _, ok := item[name]; ok {
+			return ChunkManifest{}, fmt.Errorf("item %s has attribute %s, reserved for the chunk manifest", formatKey(key), name)
+		}
+	}
+	old, err := getManifest(ctx, client, tableName, key)
+	if err != nil {
+		return ChunkManifest{}, err
+	}
+
+	version, err := chunkVersion()
+	if err != nil {
+		return ChunkManifest{}, err
+	}
+	checksum := sha256.Sum256(payload)
+	manifest := ChunkManifest{
+		Item:     item,
+		Size:     int64(len(payload)),
+		Chunks:   (len(payload) + o.chunkSize - 1) / o.chunkSize,
+		Checksum: hex.EncodeToString(checksum[:]),
+		Version:  version,
+	}
+
+	var (
+		limiter = throttle.NewRateLimiter(nil, o.writeCapacity)
+		backoff throttle.Backoff
+	)
+	requests := make([]types.WriteRequest, 0, manifest.Chunks)
+	for i := 0; i < manifest.Chunks; i++ {
+		chunk := chunkKey(key, keys, version, i)
+		chunk[chunkDataAttribute] = &types.AttributeValueMemberB{Value: payload[i*o.chunkSize : min((i+1)*o.chunkSize, len(payload))]}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: chunk}})
+	}
+	if _, err := batchWriteRequests(ctx, client, tableName, requests, limiter, &backoff); err != nil {
+		return ChunkManifest{}, fmt.Errorf("failed to write the chunks of item %s: %w", formatKey(key), err)
+	}
+
+	if err := putManifest(ctx, client, tableName, keys[0], manifest, old); err != nil {
+		var conditionalCheckFailedException *types.ConditionalCheckFailedException
+		if !errors.As(err, &conditionalCheckFailedException) {
+			return ChunkManifest{}, fmt.Errorf("failed to put the manifest of item %s: %w", formatKey(key), err)
+		}
+		if err := deleteChunks(ctx, client, tableName, key, keys, manifest, limiter, &backoff); err != nil {
+			return ChunkManifest{}, err
+		}
+		return ChunkManifest{}, fmt.Errorf("%w: %s", ErrConflict, formatKey(key))
+	}
+
+	if old != nil {
+		if err := deleteChunks(ctx, client, tableName, key, keys, *old, limiter, &backoff); err != nil {
+			return ChunkManifest{}, err
+		}
+	}
+	return manifest, nil
+}
+
+// OpenChunked reads the manifest of the item with the key, returning a reader of the payload written by PutChunked.
+// The chunks are read as the payload is read, and the payload is checked against the manifest once it is read until
+// io.EOF.
+func OpenChunked(ctx context.Context, client ChunkClient, tableName string, key map[string]types.AttributeValue) (*ChunkReader, error) {
+	keys, err := chunkKeys(ctx, client, tableName)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := getManifest(ctx, client, tableName, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := chunked(manifest, key); err != nil {
+		return nil, err
+	}
+	return &ChunkReader{
+		ctx:       ctx,
+		client:    client,
+		tableName: tableName,
+		key:       key,
+		keys:      keys,
+		manifest:  *manifest,
+		hash:      sha256.New(),
+	}, nil
+}
+
+// DeleteChunked deletes the item with the key and its chunks, written by PutChunked. The manifest is deleted first, so
+// the item is not seen with missing chunks if the deletion is interrupted; the chunks left behind are not read again.
+func DeleteChunked(ctx context.Context, client ChunkClient, tableName string, key map[string]types.AttributeValue, options ...Option) error {
+	o := newOpts(options)
+
+	keys, err := chunkKeys(ctx, client, tableName)
+	if err != nil {
+		return err
+	}
+	manifest, err := getManifest(ctx, client, tableName, key)
+	if err != nil {
+		return err
+	}
+	if err := chunked(manifest, key); err != nil {
+		return err
+	}
+
+	var backoff throttle.Backoff
+	limiter := throttle.NewRateLimiter(nil, o.writeCapacity)
+	requests := []types.WriteRequest{{DeleteRequest: &types.DeleteRequest{Key: key}}}
+	if _, err := batchWriteRequests(ctx, client, tableName, requests, limiter, &backoff); err != nil {
+		return fmt.Errorf("failed to delete item %s: %w", formatKey(key), err)
+	}
+	return deleteChunks(ctx, client, tableName, key, keys, *manifest, limiter, &backoff)
+}
+
+// ChunkReader reads the payload of an item written by PutChunked, one chunk at a time.
+type ChunkReader struct {
+	ctx       context.Context
+	client    ChunkClient
+	tableName string
+	key       map[string]types.AttributeValue
+	keys      []string
+	manifest  ChunkManifest
+
+	next int
+	buf  []byte
+	hash hash.Hash
+	read int64
+	err  error
+}
+
+// Manifest returns the manifest of the payload being read.
+func (r *ChunkReader) Manifest() ChunkManifest {
+	return r.manifest
+}
+
+func (r *ChunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.next == r.manifest.Chunks {
+			r.err = r.verify()
+			continue
+		}
+		if err := r.fetch(); err != nil {
+			r.err = err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// fetch reads the next chunk.
+func (r *ChunkReader) fetch() error {
+	output, err := r.client.GetItem(r.ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            chunkKey(r.key, r.keys, r.manifest.Version, r.next),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get chunk %d of item %s: %w", r.next, formatKey(r.key), err)
+	}
+	data, ok := output.Item[chunkDataAttribute].(*types.AttributeValueMemberB)
+	if !ok {
+		return fmt.Errorf("%w: chunk %d of item %s is missing", ErrChunkCorrupted, r.next, formatKey(r.key))
+	}
+	r.next++
+	r.buf = data.Value
+	r.read += int64(len(data.Value))
+	r.hash.Write(data.Value)
+	return nil
+}
+
+// verify checks the payload read against the manifest, returning io.EOF if it matches.
+func (r *ChunkReader) verify() error {
+	if r.read != r.manifest.Size {
+		return fmt.Errorf("%w: item %s has %d bytes instead of %d", ErrChunkCorrupted, formatKey(r.key), r.read, r.manifest.Size)
+	}
+	if checksum := hex.EncodeToString(r.hash.Sum(nil)); checksum != r.manifest.Checksum {
+		return fmt.Errorf("%w: item %s has checksum %s instead of %s", ErrChunkCorrupted, formatKey(r.key), checksum, r.manifest.Checksum)
+	}
+	return io.EOF
+}
+
+// chunkKeys returns the names of the partition and sort keys of the table, failing with ErrChunkKeySchema if it has no
+// string sort key.
+func chunkKeys(ctx context.Context, client DescribeTableClient, tableName string) ([]string, error) {
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+
+	var partitionKey, sortKey string
+	for _, element := range output.Table.KeySchema {
+		switch element.KeyType {
+		case types.KeyTypeHash:
+			partitionKey = aws.ToString(element.AttributeName)
+		case types.KeyTypeRange:
+			sortKey = aws.ToString(element.AttributeName)
+		}
+	}
+	for _, definition := range output.Table.AttributeDefinitions {
+		if sortKey != "" && aws.ToString(definition.AttributeName) == sortKey && definition.AttributeType == types.ScalarAttributeTypeS {
+			return []string{partitionKey, sortKey}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrChunkKeySchema, tableName)
+}
+
+// chunkKey returns the key of the chunk of the item, given the partition and sort key names of the table.
+func chunkKey(key map[string]types.AttributeValue, keys []string, version string, index int) map[string]types.AttributeValue {
+	sortKey, _ := key[keys[1]].(*types.AttributeValueMemberS)
+	var sortValue string
+	if sortKey != nil {
+		sortValue = sortKey.Value
+	}
+	return map[string]types.AttributeValue{
+		keys[0]: key[keys[0]],
+		keys[1]: &types.AttributeValueMemberS{Value: fmt.Sprintf("%s#chunk#%s#%06d", sortValue, version, index)},
+	}
+}
+
+// chunkVersion generates a random version for the chunks of a write, so concurrent writes of the same item do not
+// overwrite each other's chunks.
+func chunkVersion() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate the chunk version: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// getManifest reads the manifest of the item. It returns nil if the item does not exist, and a manifest without version
+// if the item was not written by PutChunked.
+func getManifest(ctx context.Context, client ChunkClient, tableName string, key map[string]types.AttributeValue) (*ChunkManifest, error) {
+	output, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(tableName),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item %s: %w", formatKey(key), err)
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	manifest := &ChunkManifest{Item: make(map[string]types.AttributeValue, len(output.Item))}
+	for name, value := range output.Item {
+		switch name {
+		case chunkCountAttribute:
+			manifest.Chunks, err = numberAttribute[int](value, strconv.Atoi)
+		case chunkSizeAttribute:
+			manifest.Size, err = numberAttribute(value, func(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) })
+		case chunkChecksumAttribute:
+			manifest.Checksum, err = stringAttribute(value)
+		case chunkVersionAttribute:
+			manifest.Version, err = stringAttribute(value)
+		default:
+			manifest.Item[name] = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid attribute %s of item %s: %w", ErrChunkCorrupted, name, formatKey(key), err)
+		}
+	}
+	return manifest, nil
+}
+
+// chunked fails with ErrChunkedItemNotFound if the item does not exist or was not written by PutChunked.
+func chunked(manifest *ChunkManifest, key map[string]types.AttributeValue) error {
+	switch {
+	case manifest == nil:
+		return fmt.Errorf("%w: %s", ErrChunkedItemNotFound, formatKey(key))
+	case manifest.Version == "":
+		return fmt.Errorf("%w: item %s is not chunked", ErrChunkedItemNotFound, formatKey(key))
+	}
+	return nil
+}
+
+// putManifest writes the manifest, on the condition that the item still has the manifest read before writing the
+// chunks, or still does not exist.
+func putManifest(ctx context.Context, client ChunkClient, tableName, partitionKey string, manifest ChunkManifest, old *ChunkManifest) error {
+	item := make(map[string]types.AttributeValue, len(manifest.Item)+4)
+	for name, value := range manifest.Item {
+		item[name] = value
+	}
+	item[chunkCountAttribute] = &types.AttributeValueMemberN{Value: strconv.Itoa(manifest.Chunks)}
+	item[chunkSizeAttribute] = &types.AttributeValueMemberN{Value: strconv.FormatInt(manifest.Size, 10)}
+	item[chunkChecksumAttribute] = &types.AttributeValueMemberS{Value: manifest.Checksum}
+	item[chunkVersionAttribute] = &types.AttributeValueMemberS{Value: manifest.Version}
+
+	var condition expression.ConditionBuilder
+	switch {
+	case old == nil:
+		condition = expression.AttributeNotExists(expression.Name(partitionKey))
+	case old.Version == "":
+		condition = expression.AttributeNotExists(expression.Name(chunkVersionAttribute))
+	default:
+		condition = expression.Name(chunkVersionAttribute).Equal(expression.Value(old.Version))
+	}
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(tableName),
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	return err
+}
+
+// deleteChunks deletes the chunks of the manifest.
+func deleteChunks(ctx context.Context, client ChunkClient, tableName string, key map[string]types.AttributeValue, keys []string, manifest ChunkManifest, limiter *throttle.RateLimiter, backoff *throttle.Backoff) error {
+	requests := make([]types.WriteRequest, 0, manifest.Chunks)
+	for i := 0; i < manifest.Chunks; i++ {
+		requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: chunkKey(key, keys, manifest.Version, i)}})
+	}
+	if _, err := batchWriteRequests(ctx, client, tableName, requests, limiter, backoff); err != nil {
+		return fmt.Errorf("failed to delete the chunks of item %s: %w", formatKey(key), err)
+	}
+	return nil
+}
+
+func numberAttribute[T any](value types.AttributeValue, parse func(string) (T, error)) (T, error) {
+	n, ok := value.(*types.AttributeValueMemberN)
+	if !ok {
+		var zero T
+		return zero, errors.New("not a number")
+	}
+	return parse(n.Value)
+}
+
+func stringAttribute(value types.AttributeValue) (string, error) {
+	s, ok := value.(*types.AttributeValueMemberS)
+	if !ok {
+		return "", errors.New("not a string")
+	}
+	return s.Value, nil
+}
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chunked items", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	key := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "user#1"},
+		"sk": &types.AttributeValueMemberS{Value: "avatar"},
+	}
+	item := func() map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"pk":           key["pk"],
+			"sk":           key["sk"],
+			"content_type": &types.AttributeValueMemberS{Value: "image/png"},
+		}
+	}
+	read := func() ([]byte, error) {
+		reader, err := OpenChunked(ctx, client, "documents", key)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(reader)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:   aws.String("documents"),
+			BillingMode: types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+			},
+		})).To(Succeed())
+	})
+
+	It("should write the payload in chunks and read it back", func() {
+		payload := bytes.Repeat([]byte("0123456789"), 10)
+
+		manifest, err := PutChunked(ctx, client, "documents", item(), payload, WithChunkSize(30))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Chunks).To(Equal(4))
+		Expect(manifest.Size).To(Equal(int64(100)))
+		Expect(client.tables["documents"].items).To(HaveLen(5))
+
+		reader, err := OpenChunked(ctx, client, "documents", key)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reader.Manifest().Item).To(Equal(item()))
+		Expect(reader.Manifest().Checksum).To(Equal(manifest.Checksum))
+		data, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(payload))
+	})
+
+	It("should replace the chunks of the previous payload", func() {
+		_, err := PutChunked(ctx, client, "documents", item(), bytes.Repeat([]byte("a"), 100), WithChunkSize(30))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = PutChunked(ctx, client, "documents", item(), []byte("short"), WithChunkSize(30))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.tables["documents"].items).To(HaveLen(2))
+		Expect(read()).To(Equal([]byte("short")))
+	})
+
+	It("should fail with ErrConflict when the manifest was replaced concurrently", func() {
+		_, err := PutChunked(ctx, client, "documents", item(), []byte("first"))
+		Expect(err).ToNot(HaveOccurred())
+
+		racing := &racingChunkClient{fakeClient: client, race: func() {
+			_, err := PutChunked(ctx, client, "documents", item(), []byte("concurrent"))
+			Expect(err).ToNot(HaveOccurred())
+		}}
+		_, err = PutChunked(ctx, racing, "documents", item(), []byte("second"))
+		Expect(err).To(MatchError(ErrConflict))
+
+		// The chunks of the losing write are deleted.
+		Expect(client.tables["documents"].items).To(HaveLen(2))
+		Expect(read()).To(Equal([]byte("concurrent")))
+	})
+
+	It("should fail with ErrChunkCorrupted when a chunk is missing", func() {
+		manifest, err := PutChunked(ctx, client, "documents", item(), bytes.Repeat([]byte("a"), 100), WithChunkSize(30))
+		Expect(err).ToNot(HaveOccurred())
+		client.deleteItem("documents", map[string]types.AttributeValue{
+			"pk": key["pk"],
+			"sk": &types.AttributeValueMemberS{Value: "avatar#chunk#" + manifest.Version + "#000002"},
+		})
+
+		_, err = read()
+		Expect(err).To(MatchError(ErrChunkCorrupted))
+		Expect(err).To(MatchError(ContainSubstring("chunk 2")))
+	})
+
+	It("should fail with ErrChunkCorrupted when the payload does not match the checksum", func() {
+		manifest, err := PutChunked(ctx, client, "documents", item(), []byte("payload"))
+		Expect(err).ToNot(HaveOccurred())
+		client.putItem("documents", map[string]types.AttributeValue{
+			"pk":         key["pk"],
+			"sk":         &types.AttributeValueMemberS{Value: "avatar#chunk#" + manifest.Version + "#000000"},
+			"chunk_data": &types.AttributeValueMemberB{Value: []byte("PAYLOAD")},
+		})
+
+		_, err = read()
+		Expect(err).To(MatchError(ErrChunkCorrupted))
+		Expect(err).To(MatchError(ContainSubstring("checksum")))
+	})
+
+	It("should delete the item and its chunks", func() {
+		_, err := PutChunked(ctx, client, "documents", item(), bytes.Repeat([]byte("a"), 100), WithChunkSize(30))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(DeleteChunked(ctx, client, "documents", key)).To(Succeed())
+		Expect(client.tables["documents"].items).To(BeEmpty())
+
+		_, err = read()
+		Expect(err).To(MatchError(ErrChunkedItemNotFound))
+	})
+
+	It("should fail with ErrChunkKeySchema when the table has no string sort key", func() {
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("users"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+		})).To(Succeed())
+
+		_, err := PutChunked(ctx, client, "users", item(), []byte("payload"))
+		Expect(err).To(MatchError(ErrChunkKeySchema))
+	})
+})
+
+// racingChunkClient runs race before its first batch write, as a concurrent write of the same item.
+type racingChunkClient struct {
+	*fakeClient
+	race func()
+}
+
+func (c *racingChunkClient) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if race := c.race; race != nil {
+		c.race = nil
+		race()
+	}
+	return c.fakeClient.BatchWriteItem(ctx, input, optFns...)
+}
@@ -0,0 +1,997 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeClient keeps the descriptions of the tables in memory, so the steps can be tested without a DynamoDB. Tables
+// created or updated stay in a transitional status for `pending` describes before becoming active.
+type fakeClient struct {
+	mu      sync.Mutex
+	tables  map[string]*fakeTable
+	pending int
+	calls   []string
+	// updateErr, when set, is returned by UpdateTable.
+	updateErr error
+	// pageSize, when set, limits the number of items returned by each Scan.
+	pageSize int
+	// throttle is the number of UpdateItem calls to be throttled.
+	throttle int
+	// unprocessed is the number of items BatchWriteItem leaves unprocessed.
+	unprocessed int
+	// exports are the exports started, by client token.
+	exports map[string]*types.ExportDescription
+	// exportFailure, when set, makes the exports fail with it.
+	exportFailure string
+	// imports are the imports started, by ARN.
+	imports map[string]*types.ImportTableDescription
+	// importFailure, when set, makes the imports fail with it.
+	importFailure string
+	// backups are the backups taken, in the order they were taken.
+	backups []*fakeBackup
+	// kinesisFailure, when set, makes the kinesis destinations fail to be enabled with it.
+	kinesisFailure string
+	// replicaFailure, when set, makes the replicas fail to be created with it.
+	replicaFailure string
+	// insightsFailure, when set, makes contributor insights fail to be enabled with it.
+	insightsFailure string
+}
+
+type fakeBackup struct {
+	details   types.BackupDetails
+	tableName string
+	table     fakeTable
+}
+
+type fakeTable struct {
+	description types.TableDescription
+	tags        map[string]string
+	pending     int
+	ttl         types.TimeToLiveDescription
+	ttlPending  int
+	items       map[string]map[string]types.AttributeValue
+	// destinations are the kinesis streaming destinations of the table.
+	destinations []types.KinesisDataStreamDestination
+	// insights are the contributor insights statuses of the table, under "", and of its indexes.
+	insights map[string]types.ContributorInsightsStatus
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{tables: map[string]*fakeTable{}}
+}
+
+func (c *fakeClient) called(method string) {
+	c.calls = append(c.calls, method)
+}
+
+func (c *fakeClient) table(name *string) (*fakeTable, error) {
+	table, ok := c.tables[aws.ToString(name)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found")}
+	}
+	return table, nil
+}
+
+func (c *fakeClient) tableByARN(arn *string) (*fakeTable, error) {
+	for _, table := range c.tables {
+		if aws.ToString(table.description.TableArn) == aws.ToString(arn) {
+			return table, nil
+		}
+	}
+	return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found")}
+}
+
+func (c *fakeClient) DescribeTable(_ context.Context, input *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DescribeTable")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if table.pending > 0 {
+		table.pending--
+	} else {
+		table.description.TableStatus = types.TableStatusActive
+		for i := range table.description.GlobalSecondaryIndexes {
+			table.description.GlobalSecondaryIndexes[i].IndexStatus = types.IndexStatusActive
+			table.description.GlobalSecondaryIndexes[i].Backfilling = aws.Bool(false)
+		}
+		replicas := make([]types.ReplicaDescription, 0, len(table.description.Replicas))
+		for _, replica := range table.description.Replicas {
+			switch {
+			case replica.ReplicaStatus == types.ReplicaStatusDeleting:
+				continue
+			case replica.ReplicaStatus == types.ReplicaStatusCreating && c.replicaFailure != "":
+				replica.ReplicaStatus = types.ReplicaStatusCreationFailed
+				replica.ReplicaStatusDescription = aws.String(c.replicaFailure)
+			case replica.ReplicaStatus == types.ReplicaStatusCreating:
+				replica.ReplicaStatus = types.ReplicaStatusActive
+			}
+			replicas = append(replicas, replica)
+		}
+		table.description.Replicas = replicas
+	}
+	description := table.description
+	return &dynamodb.DescribeTableOutput{Table: &description}, nil
+}
+
+func (c *fakeClient) CreateTable(_ context.Context, input *dynamodb.CreateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("CreateTable")
+
+	name := aws.ToString(input.TableName)
+	if _, ok := c.tables[name]; ok {
+		return nil, &types.ResourceInUseException{Message: aws.String("Table already exists")}
+	}
+	table := &fakeTable{
+		description: types.TableDescription{
+			TableName:            input.TableName,
+			TableArn:             aws.String("arn:aws:dynamodb:us-east-1:000000000000:table/" + name),
+			TableStatus:          types.TableStatusCreating,
+			KeySchema:            input.KeySchema,
+			AttributeDefinitions: input.AttributeDefinitions,
+		},
+		tags:    map[string]string{},
+		pending: c.pending,
+		ttl:     types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled},
+	}
+	if input.BillingMode != "" {
+		table.description.BillingModeSummary = &types.BillingModeSummary{BillingMode: input.BillingMode}
+	}
+	if input.ProvisionedThroughput != nil {
+		table.description.ProvisionedThroughput = &types.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  input.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits: input.ProvisionedThroughput.WriteCapacityUnits,
+		}
+	}
+	for _, index := range input.GlobalSecondaryIndexes {
+		table.description.GlobalSecondaryIndexes = append(table.description.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:   index.IndexName,
+			KeySchema:   index.KeySchema,
+			Projection:  index.Projection,
+			IndexStatus: types.IndexStatusCreating,
+		})
+	}
+	if input.StreamSpecification != nil && aws.ToBool(input.StreamSpecification.StreamEnabled) {
+		table.description.StreamSpecification = input.StreamSpecification
+		table.description.LatestStreamArn = aws.String(aws.ToString(table.description.TableArn) + "/stream/" + time.Now().Format(time.RFC3339Nano))
+	}
+	for _, tag := range input.Tags {
+		table.tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	c.tables[name] = table
+
+	description := table.description
+	return &dynamodb.CreateTableOutput{TableDescription: &description}, nil
+}
+
+func (c *fakeClient) UpdateTable(_ context.Context, input *dynamodb.UpdateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("UpdateTable")
+
+	if c.updateErr != nil {
+		return nil, c.updateErr
+	}
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if input.BillingMode != "" {
+		summary := &types.BillingModeSummary{BillingMode: input.BillingMode}
+		if table.description.BillingModeSummary != nil {
+			summary.LastUpdateToPayPerRequestDateTime = table.description.BillingModeSummary.LastUpdateToPayPerRequestDateTime
+		}
+		if input.BillingMode == types.BillingModePayPerRequest {
+			summary.LastUpdateToPayPerRequestDateTime = aws.Time(time.Now())
+			table.description.ProvisionedThroughput = nil
+		}
+		table.description.BillingModeSummary = summary
+	}
+	if input.ProvisionedThroughput != nil {
+		table.description.ProvisionedThroughput = &types.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  input.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits: input.ProvisionedThroughput.WriteCapacityUnits,
+		}
+	}
+	for _, update := range input.GlobalSecondaryIndexUpdates {
+		switch {
+		case update.Create != nil:
+			table.description.GlobalSecondaryIndexes = append(table.description.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+				IndexName:   update.Create.IndexName,
+				KeySchema:   update.Create.KeySchema,
+				Projection:  update.Create.Projection,
+				IndexStatus: types.IndexStatusCreating,
+				Backfilling: aws.Bool(true),
+			})
+		case update.Delete != nil:
+			table.description.GlobalSecondaryIndexes = slices.DeleteFunc(table.description.GlobalSecondaryIndexes, func(index types.GlobalSecondaryIndexDescription) bool {
+				return aws.ToString(index.IndexName) == aws.ToString(update.Delete.IndexName)
+			})
+		}
+	}
+	replicas := slices.Clone(table.description.Replicas)
+	for _, update := range input.ReplicaUpdates {
+		switch {
+		case update.Create != nil:
+			replicas = append(replicas, types.ReplicaDescription{
+				RegionName:    update.Create.RegionName,
+				ReplicaStatus: types.ReplicaStatusCreating,
+			})
+		case update.Delete != nil:
+			for i := range replicas {
+				if aws.ToString(replicas[i].RegionName) == aws.ToString(update.Delete.RegionName) {
+					replicas[i].ReplicaStatus = types.ReplicaStatusDeleting
+				}
+			}
+		}
+	}
+	table.description.Replicas = replicas
+	if input.StreamSpecification != nil {
+		if aws.ToBool(input.StreamSpecification.StreamEnabled) {
+			table.description.StreamSpecification = input.StreamSpecification
+			table.description.LatestStreamArn = aws.String(aws.ToString(table.description.TableArn) + "/stream/" + time.Now().Format(time.RFC3339Nano))
+		} else {
+			table.description.StreamSpecification = nil
+		}
+	}
+	table.description.TableStatus = types.TableStatusUpdating
+	table.pending = c.pending
+
+	description := table.description
+	return &dynamodb.UpdateTableOutput{TableDescription: &description}, nil
+}
+
+func (c *fakeClient) ListTagsOfResource(_ context.Context, input *dynamodb.ListTagsOfResourceInput, _ ...func(*dynamodb.Options)) (*dynamodb.ListTagsOfResourceOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("ListTagsOfResource")
+
+	table, err := c.tableByARN(input.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+	output := &dynamodb.ListTagsOfResourceOutput{}
+	for key, value := range table.tags {
+		output.Tags = append(output.Tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return output, nil
+}
+
+func (c *fakeClient) TagResource(_ context.Context, input *dynamodb.TagResourceInput, _ ...func(*dynamodb.Options)) (*dynamodb.TagResourceOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("TagResource")
+
+	table, err := c.tableByARN(input.ResourceArn)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range input.Tags {
+		table.tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return &dynamodb.TagResourceOutput{}, nil
+}
+
+func (c *fakeClient) DescribeTimeToLive(_ context.Context, input *dynamodb.DescribeTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DescribeTimeToLive")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if table.ttlPending > 0 {
+		table.ttlPending--
+	} else {
+		switch table.ttl.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabling:
+			table.ttl.TimeToLiveStatus = types.TimeToLiveStatusEnabled
+		case types.TimeToLiveStatusDisabling:
+			table.ttl = types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+		}
+	}
+	ttl := table.ttl
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: &ttl}, nil
+}
+
+func (c *fakeClient) UpdateTimeToLive(_ context.Context, input *dynamodb.UpdateTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("UpdateTimeToLive")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToBool(input.TimeToLiveSpecification.Enabled) {
+		if table.ttl.TimeToLiveStatus != types.TimeToLiveStatusDisabled {
+			return nil, &smithy.GenericAPIError{Code: "ValidationException", Message: "TimeToLive is already enabled"}
+		}
+		table.ttl = types.TimeToLiveDescription{
+			TimeToLiveStatus: types.TimeToLiveStatusEnabling,
+			AttributeName:    input.TimeToLiveSpecification.AttributeName,
+		}
+	} else {
+		table.ttl.TimeToLiveStatus = types.TimeToLiveStatusDisabling
+	}
+	table.ttlPending = c.pending
+	return &dynamodb.UpdateTimeToLiveOutput{TimeToLiveSpecification: input.TimeToLiveSpecification}, nil
+}
+
+// putItem stores the item in the table, as if it was written by someone else.
+func (c *fakeClient) putItem(tableName string, item map[string]types.AttributeValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table := c.tables[tableName]
+	if table.items == nil {
+		table.items = map[string]map[string]types.AttributeValue{}
+	}
+	table.items[table.itemKey(item)] = item
+}
+
+// deleteItem deletes the item from the table, as if it was deleted by someone else.
+func (c *fakeClient) deleteItem(tableName string, key map[string]types.AttributeValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table := c.tables[tableName]
+	delete(table.items, table.itemKey(key))
+}
+
+// item returns the item of the table with the given key, or nil if it does not exist.
+func (c *fakeClient) item(tableName string, key map[string]types.AttributeValue) map[string]types.AttributeValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	table := c.tables[tableName]
+	return table.items[table.itemKey(key)]
+}
+
+func (t *fakeTable) itemKey(item map[string]types.AttributeValue) string {
+	key := map[string]types.AttributeValue{}
+	for _, element := range t.description.KeySchema {
+		key[aws.ToString(element.AttributeName)] = item[aws.ToString(element.AttributeName)]
+	}
+	return formatKey(key)
+}
+
+func (c *fakeClient) Scan(_ context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("Scan")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(table.items))
+	for key := range table.items {
+		if input.TotalSegments != nil {
+			hash := fnv.New32a()
+			_, _ = hash.Write([]byte(key))
+			if int32(hash.Sum32()%uint32(*input.TotalSegments)) != aws.ToInt32(input.Segment) {
+				continue
+			}
+		}
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	if input.ExclusiveStartKey != nil {
+		startKey := table.itemKey(input.ExclusiveStartKey)
+		keys = slices.DeleteFunc(keys, func(key string) bool { return key <= startKey })
+	}
+
+	output := &dynamodb.ScanOutput{}
+	for _, key := range keys {
+		if c.pageSize > 0 && len(output.Items) == c.pageSize {
+			output.LastEvaluatedKey = output.Items[len(output.Items)-1]
+			break
+		}
+		output.Items = append(output.Items, table.items[key])
+	}
+	output.Count = int32(len(output.Items))
+	output.ScannedCount = output.Count
+	return output, nil
+}
+
+func (c *fakeClient) GetItem(_ context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("GetItem")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.GetItemOutput{Item: table.items[table.itemKey(input.Key)]}, nil
+}
+
+func (c *fakeClient) UpdateItem(_ context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("UpdateItem")
+
+	if c.throttle > 0 {
+		c.throttle--
+		return nil, &types.ProvisionedThroughputExceededException{Message: aws.String("Throughput exceeded")}
+	}
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key := table.itemKey(input.Key)
+	item := table.items[key]
+	if input.ConditionExpression != nil && !evaluateCondition(aws.ToString(input.ConditionExpression), item, input.ExpressionAttributeNames, input.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+
+	newItem := map[string]types.AttributeValue{}
+	for name, value := range item {
+		newItem[name] = value
+	}
+	for name, value := range input.Key {
+		newItem[name] = value
+	}
+	applyUpdate(aws.ToString(input.UpdateExpression), newItem, input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	if table.items == nil {
+		table.items = map[string]map[string]types.AttributeValue{}
+	}
+	table.items[key] = newItem
+
+	return &dynamodb.UpdateItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{TableName: input.TableName, CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+// evaluateCondition evaluates the subset of the condition expressions built by the expression package that the steps
+// use: AND, OR, NOT, attribute_exists, attribute_not_exists, = and <> of top level attributes.
+func evaluateCondition(condition string, item map[string]types.AttributeValue, names map[string]string, values map[string]types.AttributeValue) bool {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(condition))
+	p := &conditionParser{tokens: tokens, item: item, names: names, values: values}
+	return p.or()
+}
+
+type conditionParser struct {
+	tokens []string
+	item   map[string]types.AttributeValue
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+func (p *conditionParser) next() string {
+	token := p.tokens[0]
+	p.tokens = p.tokens[1:]
+	return token
+}
+
+func (p *conditionParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *conditionParser) or() bool {
+	r := p.and()
+	for p.peek() == "OR" {
+		p.next()
+		right := p.and()
+		r = r || right
+	}
+	return r
+}
+
+func (p *conditionParser) and() bool {
+	r := p.unary()
+	for p.peek() == "AND" {
+		p.next()
+		right := p.unary()
+		r = r && right
+	}
+	return r
+}
+
+func (p *conditionParser) unary() bool {
+	switch token := p.next(); token {
+	case "NOT":
+		return !p.unary()
+	case "(":
+		r := p.or()
+		p.next()
+		return r
+	case "attribute_exists", "attribute_not_exists":
+		p.next()
+		_, ok := p.item[p.names[p.next()]]
+		p.next()
+		return ok == (token == "attribute_exists")
+	default:
+		left := p.operand(token)
+		operator := p.next()
+		right := p.operand(p.next())
+		return reflect.DeepEqual(left, right) == (operator == "=")
+	}
+}
+
+func (p *conditionParser) operand(token string) types.AttributeValue {
+	if strings.HasPrefix(token, ":") {
+		return p.values[token]
+	}
+	return p.item[p.names[token]]
+}
+
+// applyUpdate applies the subset of the update expressions built by the expression package that the steps use: SET
+// and REMOVE of top level attributes.
+func applyUpdate(update string, item map[string]types.AttributeValue, names map[string]string, values map[string]types.AttributeValue) {
+	tokens := strings.Fields(strings.ReplaceAll(update, ",", " "))
+	var action string
+	for i := 0; i < len(tokens); i++ {
+		switch token := tokens[i]; {
+		case token == "SET" || token == "REMOVE":
+			action = token
+		case action == "SET":
+			item[names[token]] = values[tokens[i+2]]
+			i += 2
+		case action == "REMOVE":
+			delete(item, names[token])
+		}
+	}
+}
+
+func (c *fakeClient) PutItem(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("PutItem")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	key := table.itemKey(input.Item)
+	if input.ConditionExpression != nil && !evaluateCondition(aws.ToString(input.ConditionExpression), table.items[key], input.ExpressionAttributeNames, input.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	if table.items == nil {
+		table.items = map[string]map[string]types.AttributeValue{}
+	}
+	table.items[key] = input.Item
+
+	return &dynamodb.PutItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{TableName: input.TableName, CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+func (c *fakeClient) DeleteItem(_ context.Context, input *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DeleteItem")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(table.items, table.itemKey(input.Key))
+
+	return &dynamodb.DeleteItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{TableName: input.TableName, CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+func (c *fakeClient) BatchWriteItem(_ context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("BatchWriteItem")
+
+	output := &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{}}
+	for tableName, requests := range input.RequestItems {
+		table, err := c.table(aws.String(tableName))
+		if err != nil {
+			return nil, err
+		}
+		if table.items == nil {
+			table.items = map[string]map[string]types.AttributeValue{}
+		}
+		if c.unprocessed > 0 {
+			n := min(c.unprocessed, len(requests))
+			c.unprocessed -= n
+			output.UnprocessedItems[tableName] = requests[len(requests)-n:]
+			requests = requests[:len(requests)-n]
+		}
+		for _, request := range requests {
+			switch {
+			case request.PutRequest != nil:
+				table.items[table.itemKey(request.PutRequest.Item)] = request.PutRequest.Item
+			case request.DeleteRequest != nil:
+				delete(table.items, table.itemKey(request.DeleteRequest.Key))
+			}
+		}
+		output.ConsumedCapacity = append(output.ConsumedCapacity, types.ConsumedCapacity{
+			TableName:     aws.String(tableName),
+			CapacityUnits: aws.Float64(float64(len(requests))),
+		})
+	}
+	return output, nil
+}
+
+func (c *fakeClient) ExportTableToPointInTime(_ context.Context, input *dynamodb.ExportTableToPointInTimeInput, _ ...func(*dynamodb.Options)) (*dynamodb.ExportTableToPointInTimeOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("ExportTableToPointInTime")
+
+	if _, err := c.tableByARN(input.TableArn); err != nil {
+		return nil, err
+	}
+	if c.exports == nil {
+		c.exports = map[string]*types.ExportDescription{}
+	}
+	export, ok := c.exports[aws.ToString(input.ClientToken)]
+	if !ok {
+		export = &types.ExportDescription{
+			ExportArn:    aws.String(fmt.Sprintf("%s/export/%d", aws.ToString(input.TableArn), len(c.exports))),
+			ExportStatus: types.ExportStatusInProgress,
+			TableArn:     input.TableArn,
+			S3Bucket:     input.S3Bucket,
+			S3Prefix:     input.S3Prefix,
+		}
+		c.exports[aws.ToString(input.ClientToken)] = export
+	}
+	description := *export
+	return &dynamodb.ExportTableToPointInTimeOutput{ExportDescription: &description}, nil
+}
+
+// DescribeExport finishes the export, failing it with exportFailure if set.
+func (c *fakeClient) DescribeExport(_ context.Context, input *dynamodb.DescribeExportInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeExportOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DescribeExport")
+
+	for _, export := range c.exports {
+		if aws.ToString(export.ExportArn) != aws.ToString(input.ExportArn) {
+			continue
+		}
+		if export.ExportStatus == types.ExportStatusInProgress {
+			export.ExportStatus = types.ExportStatusCompleted
+			if c.exportFailure != "" {
+				export.ExportStatus = types.ExportStatusFailed
+				export.FailureMessage = aws.String(c.exportFailure)
+			}
+		}
+		description := *export
+		return &dynamodb.DescribeExportOutput{ExportDescription: &description}, nil
+	}
+	return nil, &types.ExportNotFoundException{Message: aws.String("Export not found")}
+}
+
+// ImportTable creates the table, which stays being imported until the import is described.
+func (c *fakeClient) ImportTable(ctx context.Context, input *dynamodb.ImportTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.ImportTableOutput, error) {
+	parameters := input.TableCreationParameters
+	output, err := c.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:              parameters.TableName,
+		AttributeDefinitions:   parameters.AttributeDefinitions,
+		KeySchema:              parameters.KeySchema,
+		BillingMode:            parameters.BillingMode,
+		GlobalSecondaryIndexes: parameters.GlobalSecondaryIndexes,
+		ProvisionedThroughput:  parameters.ProvisionedThroughput,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("ImportTable")
+
+	if c.imports == nil {
+		c.imports = map[string]*types.ImportTableDescription{}
+	}
+	tableARN := output.TableDescription.TableArn
+	description := &types.ImportTableDescription{
+		ImportArn:               aws.String(fmt.Sprintf("%s/import/%d", aws.ToString(tableARN), len(c.imports))),
+		ImportStatus:            types.ImportStatusInProgress,
+		TableArn:                tableARN,
+		S3BucketSource:          input.S3BucketSource,
+		InputFormat:             input.InputFormat,
+		TableCreationParameters: parameters,
+	}
+	c.imports[aws.ToString(description.ImportArn)] = description
+	result := *description
+	return &dynamodb.ImportTableOutput{ImportTableDescription: &result}, nil
+}
+
+// DescribeImport finishes the import, failing it with importFailure if set.
+func (c *fakeClient) DescribeImport(_ context.Context, input *dynamodb.DescribeImportInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeImportOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DescribeImport")
+
+	description, ok := c.imports[aws.ToString(input.ImportArn)]
+	if !ok {
+		return nil, &types.ImportNotFoundException{Message: aws.String("Import not found")}
+	}
+	if description.ImportStatus == types.ImportStatusInProgress {
+		description.ImportStatus = types.ImportStatusCompleted
+		if c.importFailure != "" {
+			description.ImportStatus = types.ImportStatusFailed
+			description.FailureCode = aws.String("ItemValidationError")
+			description.FailureMessage = aws.String(c.importFailure)
+			description.ErrorCount = 1
+		}
+	}
+	result := *description
+	return &dynamodb.DescribeImportOutput{ImportTableDescription: &result}, nil
+}
+
+func (c *fakeClient) ListImports(_ context.Context, input *dynamodb.ListImportsInput, _ ...func(*dynamodb.Options)) (*dynamodb.ListImportsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("ListImports")
+
+	output := &dynamodb.ListImportsOutput{}
+	for _, description := range c.imports {
+		if input.TableArn != nil && aws.ToString(description.TableArn) != aws.ToString(input.TableArn) {
+			continue
+		}
+		output.ImportSummaryList = append(output.ImportSummaryList, types.ImportSummary{
+			ImportArn:      description.ImportArn,
+			ImportStatus:   description.ImportStatus,
+			TableArn:       description.TableArn,
+			S3BucketSource: description.S3BucketSource,
+			InputFormat:    description.InputFormat,
+		})
+	}
+	return output, nil
+}
+
+// CreateBackup keeps a copy of the table, which stays being created until the backup is described.
+func (c *fakeClient) CreateBackup(_ context.Context, input *dynamodb.CreateBackupInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateBackupOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("CreateBackup")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	backup := &fakeBackup{
+		details: types.BackupDetails{
+			BackupArn:    aws.String(fmt.Sprintf("%s/backup/%d", aws.ToString(table.description.TableArn), len(c.backups))),
+			BackupName:   input.BackupName,
+			BackupStatus: types.BackupStatusCreating,
+			BackupType:   types.BackupTypeUser,
+		},
+		tableName: aws.ToString(input.TableName),
+		table:     *table,
+	}
+	backup.table.items = maps.Clone(table.items)
+	c.backups = append(c.backups, backup)
+	details := backup.details
+	return &dynamodb.CreateBackupOutput{BackupDetails: &details}, nil
+}
+
+func (c *fakeClient) backup(arn *string) (*fakeBackup, error) {
+	for _, backup := range c.backups {
+		if aws.ToString(backup.details.BackupArn) == aws.ToString(arn) {
+			return backup, nil
+		}
+	}
+	return nil, &types.BackupNotFoundException{Message: aws.String("Backup not found")}
+}
+
+// DescribeBackup makes the backup available.
+func (c *fakeClient) DescribeBackup(_ context.Context, input *dynamodb.DescribeBackupInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeBackupOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DescribeBackup")
+
+	backup, err := c.backup(input.BackupArn)
+	if err != nil {
+		return nil, err
+	}
+	if backup.details.BackupStatus == types.BackupStatusCreating {
+		backup.details.BackupStatus = types.BackupStatusAvailable
+	}
+	details := backup.details
+	return &dynamodb.DescribeBackupOutput{BackupDescription: &types.BackupDescription{BackupDetails: &details}}, nil
+}
+
+func (c *fakeClient) ListBackups(_ context.Context, input *dynamodb.ListBackupsInput, _ ...func(*dynamodb.Options)) (*dynamodb.ListBackupsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("ListBackups")
+
+	output := &dynamodb.ListBackupsOutput{}
+	for _, backup := range c.backups {
+		if input.TableName != nil && backup.tableName != aws.ToString(input.TableName) {
+			continue
+		}
+		output.BackupSummaries = append(output.BackupSummaries, types.BackupSummary{
+			BackupArn:    backup.details.BackupArn,
+			BackupName:   backup.details.BackupName,
+			BackupStatus: backup.details.BackupStatus,
+			BackupType:   backup.details.BackupType,
+			TableName:    aws.String(backup.tableName),
+		})
+	}
+	return output, nil
+}
+
+// RestoreTableFromBackup creates the table with the description and items of the backup.
+func (c *fakeClient) RestoreTableFromBackup(_ context.Context, input *dynamodb.RestoreTableFromBackupInput, _ ...func(*dynamodb.Options)) (*dynamodb.RestoreTableFromBackupOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("RestoreTableFromBackup")
+
+	backup, err := c.backup(input.BackupArn)
+	if err != nil {
+		return nil, err
+	}
+	name := aws.ToString(input.TargetTableName)
+	if _, ok := c.tables[name]; ok {
+		return nil, &types.TableAlreadyExistsException{Message: aws.String("Table already exists")}
+	}
+	table := backup.table
+	table.items = maps.Clone(backup.table.items)
+	table.tags = map[string]string{}
+	table.pending = c.pending
+	table.description.TableName = input.TargetTableName
+	table.description.TableArn = aws.String("arn:aws:dynamodb:us-east-1:000000000000:table/" + name)
+	table.description.TableStatus = types.TableStatusCreating
+	table.description.RestoreSummary = &types.RestoreSummary{
+		SourceBackupArn:   input.BackupArn,
+		SourceTableArn:    backup.table.description.TableArn,
+		RestoreDateTime:   aws.Time(time.Now()),
+		RestoreInProgress: aws.Bool(false),
+	}
+	c.tables[name] = &table
+
+	description := table.description
+	return &dynamodb.RestoreTableFromBackupOutput{TableDescription: &description}, nil
+}
+
+func (c *fakeClient) EnableKinesisStreamingDestination(_ context.Context, input *dynamodb.EnableKinesisStreamingDestinationInput, _ ...func(*dynamodb.Options)) (*dynamodb.EnableKinesisStreamingDestinationOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("EnableKinesisStreamingDestination")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	table.destinations = slices.DeleteFunc(table.destinations, func(d types.KinesisDataStreamDestination) bool {
+		return aws.ToString(d.StreamArn) == aws.ToString(input.StreamArn)
+	})
+	table.destinations = append(table.destinations, types.KinesisDataStreamDestination{
+		StreamArn:         input.StreamArn,
+		DestinationStatus: types.DestinationStatusEnabling,
+	})
+	return &dynamodb.EnableKinesisStreamingDestinationOutput{
+		TableName:         input.TableName,
+		StreamArn:         input.StreamArn,
+		DestinationStatus: types.DestinationStatusEnabling,
+	}, nil
+}
+
+func (c *fakeClient) DisableKinesisStreamingDestination(_ context.Context, input *dynamodb.DisableKinesisStreamingDestinationInput, _ ...func(*dynamodb.Options)) (*dynamodb.DisableKinesisStreamingDestinationOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DisableKinesisStreamingDestination")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	for i := range table.destinations {
+		if aws.ToString(table.destinations[i].StreamArn) == aws.ToString(input.StreamArn) {
+			table.destinations[i].DestinationStatus = types.DestinationStatusDisabling
+		}
+	}
+	return &dynamodb.DisableKinesisStreamingDestinationOutput{
+		TableName:         input.TableName,
+		StreamArn:         input.StreamArn,
+		DestinationStatus: types.DestinationStatusDisabling,
+	}, nil
+}
+
+// DescribeKinesisStreamingDestination finishes enabling and disabling the destinations, failing to enable them with
+// kinesisFailure if set.
+func (c *fakeClient) DescribeKinesisStreamingDestination(_ context.Context, input *dynamodb.DescribeKinesisStreamingDestinationInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeKinesisStreamingDestinationOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DescribeKinesisStreamingDestination")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	output := &dynamodb.DescribeKinesisStreamingDestinationOutput{TableName: input.TableName}
+	for i := range table.destinations {
+		destination := &table.destinations[i]
+		output.KinesisDataStreamDestinations = append(output.KinesisDataStreamDestinations, *destination)
+		switch {
+		case destination.DestinationStatus == types.DestinationStatusEnabling && c.kinesisFailure != "":
+			destination.DestinationStatus = types.DestinationStatusEnableFailed
+			destination.DestinationStatusDescription = aws.String(c.kinesisFailure)
+		case destination.DestinationStatus == types.DestinationStatusEnabling:
+			destination.DestinationStatus = types.DestinationStatusActive
+		case destination.DestinationStatus == types.DestinationStatusDisabling:
+			destination.DestinationStatus = types.DestinationStatusDisabled
+		}
+	}
+	return output, nil
+}
+
+func (c *fakeClient) UpdateContributorInsights(_ context.Context, input *dynamodb.UpdateContributorInsightsInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateContributorInsightsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("UpdateContributorInsights")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if table.insights == nil {
+		table.insights = map[string]types.ContributorInsightsStatus{}
+	}
+	status := types.ContributorInsightsStatusEnabling
+	if input.ContributorInsightsAction == types.ContributorInsightsActionDisable {
+		status = types.ContributorInsightsStatusDisabling
+	}
+	table.insights[aws.ToString(input.IndexName)] = status
+	return &dynamodb.UpdateContributorInsightsOutput{
+		TableName:                 input.TableName,
+		IndexName:                 input.IndexName,
+		ContributorInsightsStatus: status,
+	}, nil
+}
+
+// DescribeContributorInsights finishes enabling and disabling contributor insights, failing to enable them with
+// insightsFailure if set.
+func (c *fakeClient) DescribeContributorInsights(_ context.Context, input *dynamodb.DescribeContributorInsightsInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeContributorInsightsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called("DescribeContributorInsights")
+
+	table, err := c.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	index := aws.ToString(input.IndexName)
+	output := &dynamodb.DescribeContributorInsightsOutput{
+		TableName:                 input.TableName,
+		IndexName:                 input.IndexName,
+		ContributorInsightsStatus: types.ContributorInsightsStatusDisabled,
+	}
+	if status, ok := table.insights[index]; ok {
+		output.ContributorInsightsStatus = status
+	}
+	switch {
+	case output.ContributorInsightsStatus == types.ContributorInsightsStatusFailed:
+		output.FailureException = &types.FailureException{ExceptionDescription: aws.String(c.insightsFailure)}
+	case output.ContributorInsightsStatus == types.ContributorInsightsStatusEnabling && c.insightsFailure != "":
+		table.insights[index] = types.ContributorInsightsStatusFailed
+	case output.ContributorInsightsStatus == types.ContributorInsightsStatusEnabling:
+		table.insights[index] = types.ContributorInsightsStatusEnabled
+	case output.ContributorInsightsStatus == types.ContributorInsightsStatusDisabling:
+		table.insights[index] = types.ContributorInsightsStatusDisabled
+	}
+	return output, nil
+}
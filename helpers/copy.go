@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+type CopyClient interface {
+	DescribeTableClient
+	ScanClient
+	BatchWriteClient
+}
+
+// CopyTable copies all items of the source table to the target table, for the migrations that recreate a table with
+// another key schema. The segments set by WithSegments are scanned in parallel, and the items are written in batches
+// sharing the budget set by WithWriteCapacity. It waits for the target table to be active before copying.
+//
+// If transform is not nil, the items are written as returned by it, and the items it returns nil for are not copied.
+// The items written must have the key attributes of the target table; when two items of a page end up with the same
+// key, the last one is written. In the result, Updated is the number of items written.
+//
+// With WithDryRun, the items are transformed but not written, so the result tells how many items would be copied, the
+// write capacity it would take and samples of the items.
+//
+// The items written replace the items with the same key. So, with WithCheckpoint, an interrupted CopyTable resumes
+// where it stopped, and without it the copy can be run again from the start. Items changed in the source table while
+// it is copied may be copied before the change: stop writing to it, or keep both tables in sync, until it is done.
+func CopyTable(ctx context.Context, client CopyClient, sourceTableName, targetTableName string, transform TransformFunc, options ...Option) (BackfillResult, error) {
+	o := newOpts(options)
+
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(sourceTableName),
+	})
+	if err != nil {
+		return BackfillResult{}, fmt.Errorf("failed to describe table %s: %w", sourceTableName, err)
+	}
+	target, err := waitTable(ctx, client, targetTableName, o, tableActive)
+	if err != nil {
+		return BackfillResult{}, err
+	}
+
+	c := &tableCopy{
+		client:          client,
+		targetTableName: targetTableName,
+		sourceKeys:      keyNames(output.Table.KeySchema),
+		targetKeys:      keyNames(target.KeySchema),
+		transform:       transform,
+		limiter:         throttle.NewRateLimiter(nil, o.writeCapacity),
+		dryRun:          o.dryRun,
+		dryRunSamples:   o.dryRunSamples,
+	}
+
+	return scanTable(ctx, client, sourceTableName, o, c.copyPage)
+}
+
+type tableCopy struct {
+	client          CopyClient
+	targetTableName string
+	sourceKeys      []string
+	targetKeys      []string
+	transform       TransformFunc
+	limiter         *throttle.RateLimiter
+	dryRun          bool
+	dryRunSamples   int
+}
+
+// copyPage transforms the items of a page scanned and writes them to the target table.
+func (c *tableCopy) copyPage(ctx context.Context, items []map[string]types.AttributeValue, backoff *throttle.Backoff, result *BackfillResult) error {
+	var (
+		batch   = make([]map[string]types.AttributeValue, 0, len(items))
+		indexes = make(map[string]int, len(items))
+	)
+	for _, item := range items {
+		if c.transform != nil {
+			newItem, err := c.transform(ctx, item)
+			if err != nil {
+				return fmt.Errorf("failed to transform item %s: %w", formatKey(itemKey(item, c.sourceKeys)), err)
+			}
+			if newItem == nil {
+				result.Skipped++
+				continue
+			}
+			item = newItem
+		}
+
+		key := itemKey(item, c.targetKeys)
+		for _, name := range c.targetKeys {
+			if key[name] == nil {
+				return fmt.Errorf("item has no key attribute %s of table %s", name, c.targetTableName)
+			}
+		}
+
+		// BatchWriteItem rejects batches with two items with the same key.
+		if i, ok := indexes[formatKey(key)]; ok {
+			batch[i] = item
+			continue
+		}
+		indexes[formatKey(key)] = len(batch)
+		batch = append(batch, item)
+	}
+
+	if c.dryRun {
+		for _, item := range batch {
+			result.EstimatedWriteCapacity += writeUnits(itemSize(item))
+			result.sample(c.dryRunSamples, ItemDiff{Key: itemKey(item, c.targetKeys), After: item})
+		}
+		result.Updated += int64(len(batch))
+		return nil
+	}
+
+	consumed, err := batchWrite(ctx, c.client, c.targetTableName, batch, c.limiter, backoff)
+	result.ConsumedWriteCapacity += consumed
+	if err != nil {
+		return err
+	}
+	result.Updated += int64(len(batch))
+	return nil
+}
@@ -0,0 +1,117 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CopyTable", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	createTable := func(name string, keys ...string) {
+		input := &dynamodb.CreateTableInput{
+			TableName:   aws.String(name),
+			BillingMode: types.BillingModePayPerRequest,
+		}
+		for i, key := range keys {
+			keyType := types.KeyTypeHash
+			if i > 0 {
+				keyType = types.KeyTypeRange
+			}
+			input.AttributeDefinitions = append(input.AttributeDefinitions, types.AttributeDefinition{AttributeName: aws.String(key), AttributeType: types.ScalarAttributeTypeS})
+			input.KeySchema = append(input.KeySchema, types.KeySchemaElement{AttributeName: aws.String(key), KeyType: keyType})
+		}
+		Expect(EnsureTable(ctx, client, input)).To(Succeed())
+	}
+
+	// splitKey moves the tenant and the user of the pk "tenant#user" to their own attributes.
+	splitKey := func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+		tenant, user, _ := strings.Cut(item["pk"].(*types.AttributeValueMemberS).Value, "#")
+		return map[string]types.AttributeValue{
+			"tenant": &types.AttributeValueMemberS{Value: tenant},
+			"user":   &types.AttributeValueMemberS{Value: user},
+			"name":   item["name"],
+		}, nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		createTable("users", "pk")
+		createTable("users_v2", "tenant", "user")
+		for i := range 10 {
+			client.putItem("users", map[string]types.AttributeValue{
+				"pk":   &types.AttributeValueMemberS{Value: fmt.Sprintf("tenant%d#user%02d", i%2, i)},
+				"name": &types.AttributeValueMemberS{Value: fmt.Sprintf("User %02d", i)},
+			})
+		}
+		client.pageSize = 3
+	})
+
+	It("should copy the items as returned by the transform", func() {
+		result, err := CopyTable(ctx, client, "users", "users_v2", splitKey, WithSegments(3))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(BackfillResult{Scanned: 10, Updated: 10, ConsumedWriteCapacity: 10}))
+		Expect(client.tables["users_v2"].items).To(HaveLen(10))
+		Expect(client.item("users_v2", map[string]types.AttributeValue{
+			"tenant": &types.AttributeValueMemberS{Value: "tenant1"},
+			"user":   &types.AttributeValueMemberS{Value: "user07"},
+		})).To(HaveKeyWithValue("name", &types.AttributeValueMemberS{Value: "User 07"}))
+	})
+
+	It("should copy the items unchanged without a transform", func() {
+		createTable("users_backup", "pk")
+
+		result, err := CopyTable(ctx, client, "users", "users_backup", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.Updated).To(Equal(int64(10)))
+		Expect(client.tables["users_backup"].items).To(Equal(client.tables["users"].items))
+	})
+
+	It("should not copy the items the transform returns nil for", func() {
+		result, err := CopyTable(ctx, client, "users", "users_v2", func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			if strings.HasPrefix(item["pk"].(*types.AttributeValueMemberS).Value, "tenant0#") {
+				return nil, nil
+			}
+			return splitKey(ctx, item)
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(BackfillResult{Scanned: 10, Updated: 5, Skipped: 5, ConsumedWriteCapacity: 5}))
+	})
+
+	It("should write only the last of the items of a page with the same key", func() {
+		client.pageSize = 10
+
+		result, err := CopyTable(ctx, client, "users", "users_v2", func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			tenant, _, _ := strings.Cut(item["pk"].(*types.AttributeValueMemberS).Value, "#")
+			return map[string]types.AttributeValue{
+				"tenant": &types.AttributeValueMemberS{Value: tenant},
+				"user":   &types.AttributeValueMemberS{Value: "owner"},
+				"name":   item["name"],
+			}, nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.Updated).To(Equal(int64(2)))
+		Expect(client.tables["users_v2"].items).To(HaveLen(2))
+	})
+
+	It("should fail when the items written miss a key attribute of the target table", func() {
+		_, err := CopyTable(ctx, client, "users", "users_v2", nil)
+
+		Expect(err).To(MatchError(ContainSubstring("no key attribute tenant of table users_v2")))
+	})
+})
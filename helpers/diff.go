@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultDryRunSamples is the default number of diffs kept by a dry run.
+const DefaultDryRunSamples = 10
+
+// ItemDiff is a change a dry run would make to an item.
+type ItemDiff struct {
+	// Key is the key of the item changed.
+	Key map[string]types.AttributeValue
+	// Before is the item before the change. It is nil for the items written to another table, as by CopyTable.
+	Before map[string]types.AttributeValue
+	// After is the item after the change.
+	After map[string]types.AttributeValue
+}
+
+// String formats the diff with an attribute per line: "+" for the attributes added, "-" for the attributes removed and
+// "~" for the attributes changed.
+func (d ItemDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(formatKey(d.Key))
+	for _, name := range attributeNames(d.Before, d.After) {
+		before, hadBefore := d.Before[name]
+		after, hasAfter := d.After[name]
+		switch {
+		case !hadBefore:
+			fmt.Fprintf(&sb, "\n  + %s: %s", name, formatValue(after))
+		case !hasAfter:
+			fmt.Fprintf(&sb, "\n  - %s: %s", name, formatValue(before))
+		case !reflect.DeepEqual(before, after):
+			fmt.Fprintf(&sb, "\n  ~ %s: %s -> %s", name, formatValue(before), formatValue(after))
+		}
+	}
+	return sb.String()
+}
+
+// Report writes the counts of the result and, for a dry run, the estimated capacity and the sample diffs, for
+// reviewers to check what a data step does before running it for real.
+func (r BackfillResult) Report(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "scanned: %d, updated: %d, skipped: %d, missing: %d, conflicts: %d, consumed write capacity: %g\n",
+		r.Scanned, r.Updated, r.Skipped, r.Missing, r.Conflicts, r.ConsumedWriteCapacity)
+	if err != nil {
+		return err
+	}
+	if r.EstimatedWriteCapacity > 0 {
+		if _, err := fmt.Fprintf(w, "estimated write capacity: %g\n", r.EstimatedWriteCapacity); err != nil {
+			return err
+		}
+	}
+	for _, diff := range r.Diffs {
+		if _, err := fmt.Fprintln(w, diff.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sample keeps the diff in the result, if it has less than the given number of diffs.
+func (r *BackfillResult) sample(limit int, diff ItemDiff) {
+	if len(r.Diffs) < limit {
+		r.Diffs = append(r.Diffs, diff)
+	}
+}
+
+func attributeNames(items ...map[string]types.AttributeValue) []string {
+	var names []string
+	for _, item := range items {
+		for name := range item {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// formatValue formats the attribute value as JSON.
+func formatValue(value types.AttributeValue) string {
+	var v any
+	if err := attributevalue.Unmarshal(value, &v); err != nil {
+		return fmt.Sprintf("%T", value)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// writeUnits estimates the write capacity units consumed by writing an item of the given size: one unit per KB.
+func writeUnits(size int) float64 {
+	return float64(max((size+1023)/1024, 1))
+}
+
+// itemSize estimates the size in bytes DynamoDB accounts for the item: the lengths of the attribute names plus the
+// sizes of their values.
+func itemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for name, value := range item {
+		size += len(name) + valueSize(value)
+	}
+	return size
+}
+
+func valueSize(value types.AttributeValue) int {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, n := range v.Value {
+			size += numberSize(n)
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, element := range v.Value {
+			size += 1 + valueSize(element)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := 3
+		for name, element := range v.Value {
+			size += 1 + len(name) + valueSize(element)
+		}
+		return size
+	}
+	return 0
+}
+
+// numberSize is the size of a number: about a byte per two significant digits, plus one.
+func numberSize(n string) int {
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(n), "0")
+	return (len(digits)+1)/2 + 1
+}
@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dry run", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	createTable := func(name string) {
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String(name),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+	}
+
+	// addBio adds a bio of 2KB to the items and removes their legacy attribute.
+	addBio := func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+		newItem := maps.Clone(item)
+		newItem["bio"] = &types.AttributeValueMemberS{Value: strings.Repeat("x", 2048)}
+		newItem["name"] = &types.AttributeValueMemberS{Value: strings.ToUpper(item["name"].(*types.AttributeValueMemberS).Value)}
+		delete(newItem, "legacy")
+		return newItem, nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		createTable("users")
+		for i := range 20 {
+			client.putItem("users", map[string]types.AttributeValue{
+				"pk":     &types.AttributeValueMemberS{Value: fmt.Sprintf("user#%02d", i)},
+				"name":   &types.AttributeValueMemberS{Value: fmt.Sprintf("user %02d", i)},
+				"legacy": &types.AttributeValueMemberN{Value: "1"},
+			})
+		}
+		client.pageSize = 7
+		client.calls = nil
+	})
+
+	It("should report the diffs and the estimated capacity of a Backfill", func() {
+		result, err := Backfill(ctx, client, "users", addBio, WithDryRun(), WithSegments(2), WithDryRunSamples(3))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.calls).ToNot(ContainElement("UpdateItem"))
+		Expect(result.Updated).To(Equal(int64(20)))
+		Expect(result.ConsumedWriteCapacity).To(BeZero())
+		// Each item grows to a bit more than 2KB, so each update takes 3 units.
+		Expect(result.EstimatedWriteCapacity).To(Equal(60.0))
+		Expect(result.Diffs).To(HaveLen(3))
+
+		diff := result.Diffs[0]
+		Expect(diff.Before).To(HaveKey("legacy"))
+		Expect(diff.After).To(HaveKey("bio"))
+		lines := strings.Split(diff.String(), "\n")
+		Expect(lines[0]).To(HavePrefix("pk=user#"))
+		Expect(lines[1]).To(HavePrefix(`  + bio: "xxx`))
+		Expect(lines[2]).To(Equal("  - legacy: 1"))
+		Expect(lines[3]).To(MatchRegexp(`^  ~ name: "user \d\d" -> "USER \d\d"$`))
+	})
+
+	It("should report the items a CopyTable would write", func() {
+		createTable("users_backup")
+
+		result, err := CopyTable(ctx, client, "users", "users_backup", nil, WithDryRun())
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.calls).ToNot(ContainElement("BatchWriteItem"))
+		Expect(client.tables["users_backup"].items).To(BeEmpty())
+		Expect(result.Updated).To(Equal(int64(20)))
+		Expect(result.EstimatedWriteCapacity).To(Equal(20.0))
+		Expect(result.Diffs).To(HaveLen(DefaultDryRunSamples))
+		Expect(result.Diffs[0].Before).To(BeNil())
+		Expect(result.Diffs[0].String()).To(ContainSubstring("+ legacy: 1"))
+	})
+
+	It("should write the report of the result", func() {
+		result, err := RenameAttribute(ctx, client, "users", "legacy", "version", WithDryRun(), WithDryRunSamples(1))
+		Expect(err).ToNot(HaveOccurred())
+
+		var sb strings.Builder
+		Expect(result.Report(&sb)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+		Expect(lines).To(HaveLen(5))
+		Expect(lines[0]).To(Equal("scanned: 20, updated: 20, skipped: 0, missing: 0, conflicts: 0, consumed write capacity: 0"))
+		Expect(lines[1]).To(Equal("estimated write capacity: 20"))
+		Expect(lines[3]).To(Equal("  - legacy: 1"))
+		Expect(lines[4]).To(Equal("  + version: 1"))
+	})
+})
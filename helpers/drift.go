@@ -0,0 +1,178 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrDrift is returned by DriftReport.Err when a table differs from its spec.
+var ErrDrift = errors.New("tables drifted from their specs")
+
+type DriftClient interface {
+	DescribeTableClient
+	ListTagsClient
+	DescribeTTLClient
+}
+
+// TableDrift is what differs between a table and its spec.
+type TableDrift struct {
+	TableName string
+	// Differences describes each difference, as "index by-email is missing".
+	Differences []string
+}
+
+// DriftReport is the result of Drift, with the tables that differ from their specs.
+type DriftReport struct {
+	// Tables are the tables that differ from their specs, in the order of the specs.
+	Tables []TableDrift
+}
+
+// HasDrift tells whether any table differs from its spec.
+func (r DriftReport) HasDrift() bool {
+	return len(r.Tables) > 0
+}
+
+// Err returns an ErrDrift listing the tables that differ from their specs, or nil if none does.
+func (r DriftReport) Err() error {
+	if !r.HasDrift() {
+		return nil
+	}
+	names := make([]string, 0, len(r.Tables))
+	for _, table := range r.Tables {
+		names = append(names, table.TableName)
+	}
+	return fmt.Errorf("%w: %s", ErrDrift, strings.Join(names, ", "))
+}
+
+// Report writes the differences of each table, a table per line followed by its differences.
+func (r DriftReport) Report(w io.Writer) error {
+	for _, table := range r.Tables {
+		if _, err := fmt.Fprintln(w, table.TableName); err != nil {
+			return err
+		}
+		for _, difference := range table.Differences {
+			if _, err := fmt.Fprintf(w, "  - %s\n", difference); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Drift compares the tables of the live account with their specs, without changing them, so CI can fail when a table
+// was changed outside of the migrations, as from the console. It reports the same differences Apply would fix, plus
+// the key schemas that differ, which Apply cannot fix. Tags not in the spec are not reported.
+func Drift(ctx context.Context, client DriftClient, specs []*TableSpec) (DriftReport, error) {
+	var report DriftReport
+	for _, spec := range specs {
+		if err := spec.validate(); err != nil {
+			return DriftReport{}, err
+		}
+		differences, err := tableDifferences(ctx, client, spec)
+		if err != nil {
+			return DriftReport{}, err
+		}
+		if len(differences) > 0 {
+			report.Tables = append(report.Tables, TableDrift{TableName: spec.name, Differences: differences})
+		}
+	}
+	return report, nil
+}
+
+func tableDifferences(ctx context.Context, client DriftClient, spec *TableSpec) ([]string, error) {
+	table, err := describeTable(ctx, client, spec.name)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return []string{"table does not exist"}, nil
+	}
+
+	var differences []string
+	if want := keySchema(spec.partitionKey, spec.sortKey); !sameKeySchema(table.KeySchema, want) {
+		differences = append(differences, fmt.Sprintf("key schema is %s instead of %s", formatKeySchema(table.KeySchema), formatKeySchema(want)))
+	}
+
+	ttl, err := describeTimeToLive(ctx, client, spec.name)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffTable(spec, table, ttl)
+	if diff.capacity != nil {
+		if current := billingMode(table); current != spec.billingMode {
+			differences = append(differences, fmt.Sprintf("billing mode is %s instead of %s", current, spec.billingMode))
+		} else {
+			var read, write int64
+			if throughput := table.ProvisionedThroughput; throughput != nil {
+				read, write = aws.ToInt64(throughput.ReadCapacityUnits), aws.ToInt64(throughput.WriteCapacityUnits)
+			}
+			differences = append(differences, fmt.Sprintf("throughput is %d/%d instead of %d/%d", read, write, spec.read, spec.write))
+		}
+	}
+	for _, indexName := range diff.deleteIndexes {
+		if !indexDeclared(spec, indexName) {
+			differences = append(differences, fmt.Sprintf("index %s is not in the spec", indexName))
+		} else {
+			differences = append(differences, fmt.Sprintf("index %s has another key schema or projection", indexName))
+		}
+	}
+	for _, index := range diff.createIndexes {
+		if findIndex(table, index.name) == nil {
+			differences = append(differences, fmt.Sprintf("index %s is missing", index.name))
+		}
+	}
+	if diff.stream != nil {
+		var current types.StreamViewType
+		if streamEnabled(table) {
+			current = table.StreamSpecification.StreamViewType
+		}
+		differences = append(differences, fmt.Sprintf("stream is %s instead of %s", formatOptional(string(current)), formatOptional(string(*diff.stream))))
+	}
+	if diff.ttl != nil {
+		var current string
+		if ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabled || ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabling {
+			current = aws.ToString(ttl.AttributeName)
+		}
+		differences = append(differences, fmt.Sprintf("TTL is %s instead of %s", formatOptional(current), formatOptional(*diff.ttl)))
+	}
+
+	missing, err := missingTags(ctx, client, table, spec.tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range missing {
+		differences = append(differences, fmt.Sprintf("tag %s=%s is missing", aws.ToString(tag.Key), aws.ToString(tag.Value)))
+	}
+	return differences, nil
+}
+
+func indexDeclared(spec *TableSpec, indexName string) bool {
+	for _, index := range spec.indexes {
+		if index.name == indexName {
+			return true
+		}
+	}
+	return false
+}
+
+func formatKeySchema(schema []types.KeySchemaElement) string {
+	elements := make([]string, 0, len(schema))
+	for _, element := range schema {
+		elements = append(elements, fmt.Sprintf("%s %s", aws.ToString(element.AttributeName), element.KeyType))
+	}
+	return strings.Join(elements, ", ")
+}
+
+// formatOptional formats the value of a setting, which is disabled when empty.
+func formatOptional(value string) string {
+	if value == "" {
+		return "disabled"
+	}
+	return value
+}
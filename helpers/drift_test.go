@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	usersSpec := func() *TableSpec {
+		return NewTableSpec("users").
+			PartitionKey("pk", types.ScalarAttributeTypeS).
+			GSI(NewIndexSpec("by-email").PartitionKey("email", types.ScalarAttributeTypeS)).
+			TTL("expires_at").
+			Tag("team", "accounts")
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(Apply(ctx, client, usersSpec(), WithPollInterval(time.Millisecond))).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should report no drift when the tables match their specs", func() {
+		report, err := Drift(ctx, client, []*TableSpec{usersSpec()})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(report.HasDrift()).To(BeFalse())
+		Expect(report.Err()).To(Succeed())
+		Expect(client.calls).ToNot(ContainElements("CreateTable", "UpdateTable", "UpdateTimeToLive", "TagResource"))
+	})
+
+	It("should report the changes made outside of the migrations", func() {
+		_, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:             aws.String("users"),
+			BillingMode:           types.BillingModeProvisioned,
+			ProvisionedThroughput: &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(5)},
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+				{Delete: &types.DeleteGlobalSecondaryIndexAction{IndexName: aws.String("by-email")}},
+				{Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName:  aws.String("by-name"),
+					KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String("name"), KeyType: types.KeyTypeHash}},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				}},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName:               aws.String("users"),
+			TimeToLiveSpecification: &types.TimeToLiveSpecification{AttributeName: aws.String("expires_at"), Enabled: aws.Bool(false)},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		client.tables["users"].tags["team"] = "billing"
+
+		report, err := Drift(ctx, client, []*TableSpec{
+			usersSpec(),
+			NewTableSpec("orders").PartitionKey("id", types.ScalarAttributeTypeS),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(report.HasDrift()).To(BeTrue())
+		Expect(report.Err()).To(MatchError(ErrDrift))
+		Expect(report.Err()).To(MatchError(ContainSubstring("users, orders")))
+		Expect(report.Tables).To(Equal([]TableDrift{
+			{TableName: "users", Differences: []string{
+				"billing mode is PROVISIONED instead of PAY_PER_REQUEST",
+				"index by-name is not in the spec",
+				"index by-email is missing",
+				"TTL is disabled instead of expires_at",
+				"tag team=accounts is missing",
+			}},
+			{TableName: "orders", Differences: []string{"table does not exist"}},
+		}))
+	})
+
+	It("should report the key schema that differs", func() {
+		report, err := Drift(ctx, client, []*TableSpec{
+			NewTableSpec("users").
+				PartitionKey("pk", types.ScalarAttributeTypeS).
+				SortKey("sk", types.ScalarAttributeTypeS).
+				GSI(NewIndexSpec("by-email").PartitionKey("email", types.ScalarAttributeTypeS)).
+				TTL("expires_at").
+				Stream(types.StreamViewTypeKeysOnly),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(report.Tables).To(HaveLen(1))
+		Expect(report.Tables[0].Differences).To(Equal([]string{
+			"key schema is pk HASH instead of pk HASH, sk RANGE",
+			"stream is disabled instead of KEYS_ONLY",
+		}))
+	})
+
+	It("should write the report", func() {
+		client.tables["users"].tags["team"] = "billing"
+
+		report, err := Drift(ctx, client, []*TableSpec{usersSpec()})
+		Expect(err).ToNot(HaveOccurred())
+
+		var sb strings.Builder
+		Expect(report.Report(&sb)).To(Succeed())
+		Expect(sb.String()).To(Equal("users\n  - tag team=accounts is missing\n"))
+	})
+})
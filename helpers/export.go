@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrExportFailed is returned by ExportTable when the export finished without completing.
+var ErrExportFailed = errors.New("table export failed")
+
+type ExportClient interface {
+	DescribeTableClient
+	ExportTableToPointInTime(ctx context.Context, params *dynamodb.ExportTableToPointInTimeInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ExportTableToPointInTimeOutput, error)
+	DescribeExport(ctx context.Context, params *dynamodb.DescribeExportInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeExportOutput, error)
+}
+
+// MetadataRecorder records what a step did in the metadata of the migration running it. The Target of the migrations
+// implements it.
+type MetadataRecorder interface {
+	SetMetadata(ctx context.Context, id, key, value string) error
+}
+
+// WithMetadata makes a step record what it did in the metadata of the migration with the given ID, as ExportTable
+// records the ARN of its export.
+func WithMetadata(recorder MetadataRecorder, migrationID string) Option {
+	return func(o *opts) {
+		o.metadataRecorder = recorder
+		o.migrationID = migrationID
+	}
+}
+
+// ExportTable exports the table to the S3 bucket, under the prefix, with ExportTableToPointInTime, so a migration can
+// snapshot the table before a destructive transformation. It waits for the export to complete, which can take a while
+// for big tables: the timeout set by WithTimeout should be set accordingly. The point in time recovery of the table
+// must be enabled.
+//
+// With WithMetadata, the ARN of the export is recorded in the metadata of the migration under "export_arn:<table>", as
+// soon as the export is started. The export is requested with a client token made from the table, bucket and prefix,
+// so running ExportTable again while DynamoDB still remembers the token (8 hours) waits for the same export instead of
+// starting another one.
+//
+// If the export fails, it returns an ErrExportFailed with the failure of the export.
+func ExportTable(ctx context.Context, client ExportClient, tableName, bucket, prefix string, options ...Option) (types.ExportDescription, error) {
+	o := newOpts(options)
+
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		return types.ExportDescription{}, fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+	token := sha256.Sum256([]byte(aws.ToString(output.Table.TableArn) + "\x00" + bucket + "\x00" + prefix))
+	export, err := client.ExportTableToPointInTime(ctx, &dynamodb.ExportTableToPointInTimeInput{
+		TableArn:     output.Table.TableArn,
+		S3Bucket:     aws.String(bucket),
+		S3Prefix:     aws.String(prefix),
+		ExportFormat: types.ExportFormatDynamodbJson,
+		ClientToken:  aws.String(hex.EncodeToString(token[:16])),
+	})
+	if err != nil {
+		return types.ExportDescription{}, fmt.Errorf("failed to export table %s: %w", tableName, err)
+	}
+	exportARN := aws.ToString(export.ExportDescription.ExportArn)
+
+	if o.metadataRecorder != nil {
+		if err := o.metadataRecorder.SetMetadata(ctx, o.migrationID, "export_arn:"+tableName, exportARN); err != nil {
+			return types.ExportDescription{}, fmt.Errorf("failed to record the export of table %s: %w", tableName, err)
+		}
+	}
+
+	description := *export.ExportDescription
+	err = poll(ctx, o, func(ctx context.Context) (bool, error) {
+		if description.ExportStatus != types.ExportStatusInProgress {
+			return true, nil
+		}
+		output, err := client.DescribeExport(ctx, &dynamodb.DescribeExportInput{
+			ExportArn: aws.String(exportARN),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to describe export %s: %w", exportARN, err)
+		}
+		description = *output.ExportDescription
+		return description.ExportStatus != types.ExportStatusInProgress, nil
+	})
+	if err != nil {
+		return description, fmt.Errorf("failed waiting for export %s: %w", exportARN, err)
+	}
+	if description.ExportStatus != types.ExportStatusCompleted {
+		return description, fmt.Errorf("%w: %s of table %s: %s", ErrExportFailed, exportARN, tableName, aws.ToString(description.FailureMessage))
+	}
+	return description, nil
+}
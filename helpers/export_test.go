@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeMetadataRecorder map[string]map[string]string
+
+func (r fakeMetadataRecorder) SetMetadata(_ context.Context, id, key, value string) error {
+	if r[id] == nil {
+		r[id] = map[string]string{}
+	}
+	r[id][key] = value
+	return nil
+}
+
+var _ = Describe("ExportTable", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("users"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should export the table, wait for it and record its ARN", func() {
+		metadata := fakeMetadataRecorder{}
+
+		export, err := ExportTable(ctx, client, "users", "backups", "migrations/0042", WithMetadata(metadata, "0042"), WithPollInterval(time.Millisecond))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(export.ExportStatus).To(Equal(types.ExportStatusCompleted))
+		Expect(aws.ToString(export.S3Bucket)).To(Equal("backups"))
+		Expect(aws.ToString(export.S3Prefix)).To(Equal("migrations/0042"))
+		Expect(metadata).To(Equal(fakeMetadataRecorder{
+			"0042": {"export_arn:users": aws.ToString(export.ExportArn)},
+		}))
+	})
+
+	It("should wait for the same export when run again", func() {
+		first, err := ExportTable(ctx, client, "users", "backups", "migrations/0042", WithPollInterval(time.Millisecond))
+		Expect(err).ToNot(HaveOccurred())
+
+		second, err := ExportTable(ctx, client, "users", "backups", "migrations/0042", WithPollInterval(time.Millisecond))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(second.ExportArn).To(Equal(first.ExportArn))
+		Expect(client.exports).To(HaveLen(1))
+	})
+
+	It("should fail with ErrExportFailed when the export fails", func() {
+		client.exportFailure = "Access denied to the bucket"
+
+		_, err := ExportTable(ctx, client, "users", "backups", "migrations/0042", WithPollInterval(time.Millisecond))
+		Expect(err).To(MatchError(ErrExportFailed))
+		Expect(err).To(MatchError(ContainSubstring("Access denied to the bucket")))
+	})
+})
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrIndexMismatch is returned by EnsureGSI when the index exists with a different key schema.
+var ErrIndexMismatch = errors.New("index exists with a different key schema")
+
+var (
+	// ErrIndexInUse is returned by DropGSI when the index was read within the period set by WithIndexUsage.
+	ErrIndexInUse = errors.New("index still in use")
+
+	// ErrIndexRemovalNotConfirmed is returned by DropGSI when neither WithIndexUsage nor WithConfirmedRemoval is given.
+	ErrIndexRemovalNotConfirmed = errors.New("index removal not confirmed")
+)
+
+// IndexUsage tells whether the indexes are still read, for DropGSI to refuse deleting an index in use.
+type IndexUsage interface {
+	// IndexReads returns the read capacity units consumed by the index since the given time. With CloudWatch, it is
+	// the sum of the ConsumedReadCapacityUnits metric of the AWS/DynamoDB namespace, with the TableName and
+	// GlobalSecondaryIndexName dimensions.
+	IndexReads(ctx context.Context, tableName, indexName string, since time.Time) (float64, error)
+}
+
+// IndexUsageFunc is a function implementing IndexUsage.
+type IndexUsageFunc func(ctx context.Context, tableName, indexName string, since time.Time) (float64, error)
+
+func (f IndexUsageFunc) IndexReads(ctx context.Context, tableName, indexName string, since time.Time) (float64, error) {
+	return f(ctx, tableName, indexName, since)
+}
+
+// WithIndexUsage makes DropGSI delete the index only if it had no reads for the given period, as told by usage.
+func WithIndexUsage(usage IndexUsage, unusedFor time.Duration) Option {
+	return func(o *opts) {
+		o.indexUsage = usage
+		o.unusedFor = unusedFor
+	}
+}
+
+// WithConfirmedRemoval makes DropGSI delete the index without checking its usage, for when it was checked otherwise.
+func WithConfirmedRemoval() Option {
+	return func(o *opts) {
+		o.removalConfirmed = true
+	}
+}
+
+type EnsureGSIClient interface {
+	DescribeTableClient
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+}
+
+// EnsureGSI makes sure the table has the global secondary index, and waits until the index finished backfilling. If
+// the index does not exist, it is created with the given attribute definitions, which must include the key
+// attributes of the index. If it is being created by a previous run, EnsureGSI only waits for it. If it is being
+// deleted, EnsureGSI waits for the deletion to finish and creates it again.
+//
+// Backfilling an index on a big table can take hours, the timeout set by WithTimeout should be set accordingly. If the
+// index exists with a different key schema, it returns an ErrIndexMismatch.
+func EnsureGSI(ctx context.Context, client EnsureGSIClient, tableName string, attributes []types.AttributeDefinition, index types.CreateGlobalSecondaryIndexAction, options ...Option) error {
+	o := newOpts(options)
+	indexName := aws.ToString(index.IndexName)
+
+	table, err := waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		current := findIndex(table, indexName)
+		return table.TableStatus == types.TableStatusActive && (current == nil || current.IndexStatus != types.IndexStatusDeleting)
+	})
+	if err != nil {
+		return err
+	}
+
+	if current := findIndex(table, indexName); current != nil {
+		if !sameKeySchema(current.KeySchema, index.KeySchema) {
+			return fmt.Errorf("%w: %s of table %s", ErrIndexMismatch, indexName, tableName)
+		}
+	} else {
+		_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:            aws.String(tableName),
+			AttributeDefinitions: attributes,
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+				{Create: &index},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create index %s of table %s: %w", indexName, tableName, err)
+		}
+	}
+
+	_, err = waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		current := findIndex(table, indexName)
+		return current != nil && current.IndexStatus == types.IndexStatusActive && !aws.ToBool(current.Backfilling)
+	})
+	return err
+}
+
+// DropGSI deletes the global secondary index of the table, and waits until it is gone. As a deleted index cannot be
+// brought back without backfilling it again, the deletion must be made safe by one of the options: WithIndexUsage
+// checks the index had no reads for a period, returning an ErrIndexInUse otherwise, and WithConfirmedRemoval skips the
+// check. Without them, it returns an ErrIndexRemovalNotConfirmed.
+//
+// If the index does not exist, DropGSI does nothing. If it is being deleted by a previous run, DropGSI only waits for
+// it.
+func DropGSI(ctx context.Context, client EnsureGSIClient, tableName, indexName string, options ...Option) error {
+	o := newOpts(options)
+
+	table, err := waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		current := findIndex(table, indexName)
+		return table.TableStatus == types.TableStatusActive && (current == nil || current.IndexStatus == types.IndexStatusActive || current.IndexStatus == types.IndexStatusDeleting)
+	})
+	if err != nil {
+		return err
+	}
+
+	current := findIndex(table, indexName)
+	if current == nil {
+		return nil
+	}
+	if current.IndexStatus != types.IndexStatusDeleting {
+		if err := checkIndexUnused(ctx, tableName, indexName, o); err != nil {
+			return err
+		}
+
+		_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName: aws.String(tableName),
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+				{Delete: &types.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(indexName)}},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete index %s of table %s: %w", indexName, tableName, err)
+		}
+	}
+
+	_, err = waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		return findIndex(table, indexName) == nil
+	})
+	return err
+}
+
+// checkIndexUnused checks the index can be deleted, as set by WithIndexUsage or WithConfirmedRemoval.
+func checkIndexUnused(ctx context.Context, tableName, indexName string, o opts) error {
+	switch {
+	case o.removalConfirmed:
+		return nil
+	case o.indexUsage == nil:
+		return fmt.Errorf("%w: %s of table %s", ErrIndexRemovalNotConfirmed, indexName, tableName)
+	}
+
+	reads, err := o.indexUsage.IndexReads(ctx, tableName, indexName, time.Now().Add(-o.unusedFor))
+	if err != nil {
+		return fmt.Errorf("failed to get the reads of index %s of table %s: %w", indexName, tableName, err)
+	}
+	if reads > 0 {
+		return fmt.Errorf("%w: %s of table %s consumed %g read capacity units in the last %s", ErrIndexInUse, indexName, tableName, reads, o.unusedFor)
+	}
+	return nil
+}
+
+func findIndex(table *types.TableDescription, indexName string) *types.GlobalSecondaryIndexDescription {
+	i := slices.IndexFunc(table.GlobalSecondaryIndexes, func(index types.GlobalSecondaryIndexDescription) bool {
+		return aws.ToString(index.IndexName) == indexName
+	})
+	if i < 0 {
+		return nil
+	}
+	return &table.GlobalSecondaryIndexes[i]
+}
+
+func sameKeySchema(a, b []types.KeySchemaElement) bool {
+	return slices.EqualFunc(a, b, func(a, b types.KeySchemaElement) bool {
+		return aws.ToString(a.AttributeName) == aws.ToString(b.AttributeName) && a.KeyType == b.KeyType
+	})
+}
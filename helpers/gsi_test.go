@@ -0,0 +1,159 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnsureGSI", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	attributes := []types.AttributeDefinition{
+		{AttributeName: aws.String("email"), AttributeType: types.ScalarAttributeTypeS},
+	}
+	newIndex := func() types.CreateGlobalSecondaryIndexAction {
+		return types.CreateGlobalSecondaryIndexAction{
+			IndexName: aws.String("by-email"),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("email"), KeyType: types.KeyTypeHash},
+			},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String("users"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	When("the index does not exist", func() {
+		It("should create it and wait for the backfill", func() {
+			client.pending = 3
+
+			Expect(EnsureGSI(ctx, client, "users", attributes, newIndex(), WithPollInterval(time.Millisecond))).To(Succeed())
+
+			index := findIndex(&client.tables["users"].description, "by-email")
+			Expect(index).ToNot(BeNil())
+			Expect(index.IndexStatus).To(Equal(types.IndexStatusActive))
+			Expect(aws.ToBool(index.Backfilling)).To(BeFalse())
+			Expect(client.calls).To(ContainElement("UpdateTable"))
+		})
+
+		It("should fail when the backfill does not finish in time", func() {
+			client.pending = 1000
+
+			err := EnsureGSI(ctx, client, "users", attributes, newIndex(), WithPollInterval(time.Millisecond), WithTimeout(10*time.Millisecond))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+
+	When("the index already exists", func() {
+		It("should not create it again", func() {
+			Expect(EnsureGSI(ctx, client, "users", attributes, newIndex())).To(Succeed())
+			client.calls = nil
+
+			Expect(EnsureGSI(ctx, client, "users", attributes, newIndex())).To(Succeed())
+			Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+		})
+
+		It("should fail with ErrIndexMismatch when the key schema is different", func() {
+			Expect(EnsureGSI(ctx, client, "users", attributes, newIndex())).To(Succeed())
+
+			index := newIndex()
+			index.KeySchema = append(index.KeySchema, types.KeySchemaElement{AttributeName: aws.String("pk"), KeyType: types.KeyTypeRange})
+			Expect(EnsureGSI(ctx, client, "users", attributes, index)).To(MatchError(ErrIndexMismatch))
+		})
+	})
+})
+
+var _ = Describe("DropGSI", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+		since  time.Time
+	)
+
+	usage := func(reads float64) IndexUsage {
+		return IndexUsageFunc(func(_ context.Context, tableName, indexName string, s time.Time) (float64, error) {
+			Expect(tableName).To(Equal("users"))
+			Expect(indexName).To(Equal("by-email"))
+			since = s
+			return reads, nil
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String("users"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("email"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+				IndexName:  aws.String("by-email"),
+				KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String("email"), KeyType: types.KeyTypeHash}},
+				Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			}},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should delete the index not read for the period", func() {
+		Expect(DropGSI(ctx, client, "users", "by-email", WithIndexUsage(usage(0), 30*24*time.Hour), WithPollInterval(time.Millisecond))).To(Succeed())
+
+		Expect(findIndex(&client.tables["users"].description, "by-email")).To(BeNil())
+		Expect(since).To(BeTemporally("~", time.Now().Add(-30*24*time.Hour), time.Minute))
+	})
+
+	It("should fail with ErrIndexInUse when the index was read in the period", func() {
+		err := DropGSI(ctx, client, "users", "by-email", WithIndexUsage(usage(12), 30*24*time.Hour))
+		Expect(err).To(MatchError(ErrIndexInUse))
+
+		Expect(findIndex(&client.tables["users"].description, "by-email")).ToNot(BeNil())
+		Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+	})
+
+	It("should delete the index when the removal is confirmed", func() {
+		Expect(DropGSI(ctx, client, "users", "by-email", WithConfirmedRemoval())).To(Succeed())
+
+		Expect(findIndex(&client.tables["users"].description, "by-email")).To(BeNil())
+	})
+
+	It("should fail with ErrIndexRemovalNotConfirmed without a safety check", func() {
+		Expect(DropGSI(ctx, client, "users", "by-email")).To(MatchError(ErrIndexRemovalNotConfirmed))
+
+		Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+	})
+
+	It("should do nothing when the index does not exist", func() {
+		Expect(DropGSI(ctx, client, "users", "by-name")).To(Succeed())
+
+		Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+	})
+})
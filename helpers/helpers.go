@@ -0,0 +1,195 @@
+// Package helpers implements idempotent steps for the migrations changing DynamoDB tables: they check the current
+// state of the table before changing it, so a migration interrupted halfway can be run again.
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// DefaultTimeout is the default maximum time a step waits for a table to be ready.
+	DefaultTimeout = 10 * time.Minute
+
+	// DefaultPollInterval is the default interval between the checks of a table a step is waiting for.
+	DefaultPollInterval = 5 * time.Second
+
+	// DefaultConflictRetries is the default number of times a data step transforms an item again after it was changed
+	// concurrently.
+	DefaultConflictRetries = 3
+)
+
+type DescribeTableClient interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+}
+
+type opts struct {
+	timeout          time.Duration
+	pollInterval     time.Duration
+	segments         int
+	writeCapacity    float64
+	conflictRetries  int
+	checkpointStore  CheckpointStore
+	checkpointID     string
+	templating       bool
+	templateData     any
+	dryRun           bool
+	dryRunSamples    int
+	chunkSize        int
+	indexUsage       IndexUsage
+	unusedFor        time.Duration
+	removalConfirmed bool
+	metadataRecorder MetadataRecorder
+	migrationID      string
+}
+
+func defaultOpts() opts {
+	return opts{
+		timeout:         DefaultTimeout,
+		pollInterval:    DefaultPollInterval,
+		segments:        1,
+		conflictRetries: DefaultConflictRetries,
+		dryRunSamples:   DefaultDryRunSamples,
+		chunkSize:       DefaultChunkSize,
+	}
+}
+
+type Option func(*opts)
+
+// WithTimeout sets the maximum time the step waits for the table to be ready.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *opts) {
+		o.timeout = timeout
+	}
+}
+
+// WithPollInterval sets the interval between the checks of the table the step is waiting for.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *opts) {
+		o.pollInterval = interval
+	}
+}
+
+// WithSegments sets the number of segments a data step scans in parallel. By default, the table is scanned
+// sequentially.
+func WithSegments(segments int) Option {
+	return func(o *opts) {
+		o.segments = max(segments, 1)
+	}
+}
+
+// WithWriteCapacity sets the write capacity units per second a data step may consume, shared by all its segments. By
+// default, the writes are not rate limited.
+func WithWriteCapacity(units float64) Option {
+	return func(o *opts) {
+		o.writeCapacity = units
+	}
+}
+
+// WithConflictRetries sets the number of times a data step transforms an item again, after the item was changed by
+// someone else between being read and written.
+func WithConflictRetries(retries int) Option {
+	return func(o *opts) {
+		o.conflictRetries = retries
+	}
+}
+
+// WithCheckpoint makes a data step save its progress in the store, under the given ID, and resume from the progress
+// saved by a previous run with the same ID. The ID must be unique per step, for example, the ID of the migration
+// followed by the name of the step.
+func WithCheckpoint(store CheckpointStore, id string) Option {
+	return func(o *opts) {
+		o.checkpointStore = store
+		o.checkpointID = id
+	}
+}
+
+// WithDryRun makes a data step scan the table and report the items it would change, without writing anything. With
+// it, the Updated of the result is the number of items that would be written, EstimatedWriteCapacity is the capacity
+// the writes would consume, and Diffs has samples of the changes. The checkpoint set by WithCheckpoint is neither
+// resumed from nor saved.
+func WithDryRun() Option {
+	return func(o *opts) {
+		o.dryRun = true
+	}
+}
+
+// WithDryRunSamples sets the number of diffs kept in the result of a dry run.
+func WithDryRunSamples(samples int) Option {
+	return func(o *opts) {
+		o.dryRunSamples = samples
+	}
+}
+
+// WithTemplate makes Seed execute the fixtures as a text/template with the given data, before parsing them. Besides the
+// functions of text/template, the template can call "env" to read an environment variable, as in `{{ env "STAGE" }}`.
+func WithTemplate(data any) Option {
+	return func(o *opts) {
+		o.templating = true
+		o.templateData = data
+	}
+}
+
+func newOpts(options []Option) opts {
+	o := defaultOpts()
+	for _, opt := range options {
+		opt(&o)
+	}
+	return o
+}
+
+// waitTable describes the table until ready returns true, or the timeout is reached.
+func waitTable(ctx context.Context, client DescribeTableClient, tableName string, options opts, ready func(*types.TableDescription) bool) (*types.TableDescription, error) {
+	var table *types.TableDescription
+	err := poll(ctx, options, func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to describe table %s: %w", tableName, err)
+		}
+		table = output.Table
+		return ready(table), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed waiting for table %s: %w", tableName, err)
+	}
+	return table, nil
+}
+
+// poll calls check every poll interval until it returns true or fails, or the timeout is reached.
+func poll(ctx context.Context, options opts, check func(ctx context.Context) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, options.timeout)
+	defer cancel()
+
+	for {
+		done, err := check(ctx)
+		if err != nil || done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(options.pollInterval):
+		}
+	}
+}
+
+// tableActive tells whether the table is active and none of its indexes is being created, updated or deleted.
+func tableActive(table *types.TableDescription) bool {
+	if table.TableStatus != types.TableStatusActive {
+		return false
+	}
+	for _, index := range table.GlobalSecondaryIndexes {
+		if index.IndexStatus != types.IndexStatusActive {
+			return false
+		}
+	}
+	return true
+}
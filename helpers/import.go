@@ -0,0 +1,141 @@
+package helpers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrImportFailed is matched by the *ImportError returned by ImportTable when the import failed or was cancelled.
+	ErrImportFailed = errors.New("table import failed")
+
+	// ErrTableExists is returned by ImportTable when the table exists, but was not imported from the given source.
+	ErrTableExists = errors.New("table already exists")
+)
+
+// ImportError is returned by ImportTable when the import finished without completing.
+type ImportError struct {
+	ImportARN string
+	TableName string
+	// Status is FAILED or CANCELLED.
+	Status      types.ImportStatus
+	FailureCode string
+	// FailureMessage is the failure of the import, as reported by DynamoDB.
+	FailureMessage string
+	// ErrorCount is the number of items of the source that could not be imported.
+	ErrorCount int64
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("%s: %s of table %s is %s: %s: %s (%d errors)", ErrImportFailed, e.ImportARN, e.TableName, e.Status, e.FailureCode, e.FailureMessage, e.ErrorCount)
+}
+
+func (e *ImportError) Is(target error) bool {
+	return target == ErrImportFailed
+}
+
+type ImportClient interface {
+	DescribeTableClient
+	ImportTable(ctx context.Context, params *dynamodb.ImportTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ImportTableOutput, error)
+	DescribeImport(ctx context.Context, params *dynamodb.DescribeImportInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeImportOutput, error)
+	ListImports(ctx context.Context, params *dynamodb.ListImportsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListImportsOutput, error)
+}
+
+// ImportTable creates a new table from the data of the S3 bucket under the prefix, with ImportTable, for the
+// migrations rebuilding big tables from an export or a data set. It waits for the import to complete, which can take
+// hours for big data sets: the timeout set by WithTimeout should be set accordingly.
+//
+// If the table exists, ImportTable looks for the import that created it: if it was imported from the same source, it
+// waits for the import instead of starting another one, so an interrupted migration can be run again. Otherwise, it
+// returns an ErrTableExists. With WithMetadata, the ARN of the import is recorded in the metadata of the migration
+// under "import_arn:<table>", as soon as the import is started.
+//
+// If the import fails or is cancelled, it returns an *ImportError, matching ErrImportFailed.
+func ImportTable(ctx context.Context, client ImportClient, bucket, prefix string, format types.InputFormat, table types.TableCreationParameters, options ...Option) (types.ImportTableDescription, error) {
+	o := newOpts(options)
+	tableName := aws.ToString(table.TableName)
+
+	importARN, err := findImport(ctx, client, tableName, bucket, prefix)
+	if err != nil {
+		return types.ImportTableDescription{}, err
+	}
+	if importARN == "" {
+		token := sha256.Sum256([]byte(tableName + "\x00" + bucket + "\x00" + prefix))
+		output, err := client.ImportTable(ctx, &dynamodb.ImportTableInput{
+			InputFormat:             format,
+			S3BucketSource:          &types.S3BucketSource{S3Bucket: aws.String(bucket), S3KeyPrefix: aws.String(prefix)},
+			TableCreationParameters: &table,
+			ClientToken:             aws.String(hex.EncodeToString(token[:16])),
+		})
+		if err != nil {
+			return types.ImportTableDescription{}, fmt.Errorf("failed to import table %s: %w", tableName, err)
+		}
+		importARN = aws.ToString(output.ImportTableDescription.ImportArn)
+	}
+
+	if o.metadataRecorder != nil {
+		if err := o.metadataRecorder.SetMetadata(ctx, o.migrationID, "import_arn:"+tableName, importARN); err != nil {
+			return types.ImportTableDescription{}, fmt.Errorf("failed to record the import of table %s: %w", tableName, err)
+		}
+	}
+
+	var description types.ImportTableDescription
+	err = poll(ctx, o, func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeImport(ctx, &dynamodb.DescribeImportInput{
+			ImportArn: aws.String(importARN),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to describe import %s: %w", importARN, err)
+		}
+		description = *output.ImportTableDescription
+		return description.ImportStatus != types.ImportStatusInProgress && description.ImportStatus != types.ImportStatusCancelling, nil
+	})
+	if err != nil {
+		return description, fmt.Errorf("failed waiting for import %s: %w", importARN, err)
+	}
+	if description.ImportStatus != types.ImportStatusCompleted {
+		return description, &ImportError{
+			ImportARN:      importARN,
+			TableName:      tableName,
+			Status:         description.ImportStatus,
+			FailureCode:    aws.ToString(description.FailureCode),
+			FailureMessage: aws.ToString(description.FailureMessage),
+			ErrorCount:     description.ErrorCount,
+		}
+	}
+	return description, nil
+}
+
+// findImport returns the ARN of the import that created the table from the source, or an empty ARN if the table does
+// not exist. If the table exists but was not imported from the source, it returns an ErrTableExists.
+func findImport(ctx context.Context, client ImportClient, tableName, bucket, prefix string) (string, error) {
+	table, err := describeTable(ctx, client, tableName)
+	if err != nil || table == nil {
+		return "", err
+	}
+
+	input := &dynamodb.ListImportsInput{TableArn: table.TableArn}
+	for {
+		output, err := client.ListImports(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("failed to list the imports of table %s: %w", tableName, err)
+		}
+		for _, summary := range output.ImportSummaryList {
+			source := summary.S3BucketSource
+			if source != nil && aws.ToString(source.S3Bucket) == bucket && aws.ToString(source.S3KeyPrefix) == prefix {
+				return aws.ToString(summary.ImportArn), nil
+			}
+		}
+		if output.NextToken == nil {
+			return "", fmt.Errorf("%w: %s was not imported from s3://%s/%s", ErrTableExists, tableName, bucket, prefix)
+		}
+		input.NextToken = output.NextToken
+	}
+}
@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ImportTable", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	parameters := types.TableCreationParameters{
+		TableName:            aws.String("users_v2"),
+		BillingMode:          types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+	}
+	importTable := func(options ...Option) (types.ImportTableDescription, error) {
+		return ImportTable(ctx, client, "backups", "users/export", types.InputFormatDynamodbJson, parameters, append(options, WithPollInterval(time.Millisecond))...)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+	})
+
+	It("should create the table from the source, wait for it and record its ARN", func() {
+		metadata := fakeMetadataRecorder{}
+
+		description, err := importTable(WithMetadata(metadata, "0043"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(description.ImportStatus).To(Equal(types.ImportStatusCompleted))
+		Expect(client.tables).To(HaveKey("users_v2"))
+		Expect(metadata).To(Equal(fakeMetadataRecorder{
+			"0043": {"import_arn:users_v2": aws.ToString(description.ImportArn)},
+		}))
+	})
+
+	It("should wait for the import that created the table when run again", func() {
+		first, err := importTable()
+		Expect(err).ToNot(HaveOccurred())
+		client.calls = nil
+
+		second, err := importTable()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(second.ImportArn).To(Equal(first.ImportArn))
+		Expect(client.calls).ToNot(ContainElement("ImportTable"))
+	})
+
+	It("should fail with ErrTableExists when the table was not imported from the source", func() {
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            parameters.TableName,
+			BillingMode:          parameters.BillingMode,
+			AttributeDefinitions: parameters.AttributeDefinitions,
+			KeySchema:            parameters.KeySchema,
+		})).To(Succeed())
+
+		_, err := importTable()
+		Expect(err).To(MatchError(ErrTableExists))
+	})
+
+	It("should fail with an ImportError when the import fails", func() {
+		client.importFailure = "Some items could not be parsed"
+
+		_, err := importTable()
+		Expect(err).To(MatchError(ErrImportFailed))
+		var importErr *ImportError
+		Expect(errors.As(err, &importErr)).To(BeTrue())
+		Expect(importErr.Status).To(Equal(types.ImportStatusFailed))
+		Expect(importErr.FailureCode).To(Equal("ItemValidationError"))
+		Expect(importErr.FailureMessage).To(Equal("Some items could not be parsed"))
+		Expect(importErr.ErrorCount).To(Equal(int64(1)))
+	})
+})
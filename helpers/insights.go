@@ -0,0 +1,134 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrContributorInsightsFailed is returned by EnableContributorInsights when DynamoDB failed to enable Contributor
+// Insights.
+var ErrContributorInsightsFailed = errors.New("contributor insights failed")
+
+type ContributorInsightsClient interface {
+	UpdateContributorInsights(ctx context.Context, params *dynamodb.UpdateContributorInsightsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateContributorInsightsOutput, error)
+	DescribeContributorInsights(ctx context.Context, params *dynamodb.DescribeContributorInsightsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeContributorInsightsOutput, error)
+}
+
+// EnableContributorInsights enables CloudWatch Contributor Insights on the index of the table, or on the table itself
+// if the index is empty, and waits until it is enabled, so the observability of a table can be changed by a
+// migration. If it is already enabled, it does nothing. If it is being disabled, it waits for the disabling to finish
+// and enables it again.
+//
+// If DynamoDB fails to enable Contributor Insights, it returns an ErrContributorInsightsFailed with the reason.
+func EnableContributorInsights(ctx context.Context, client ContributorInsightsClient, tableName, indexName string, options ...Option) error {
+	o := newOpts(options)
+
+	insights, err := waitContributorInsights(ctx, client, tableName, indexName, o, func(status types.ContributorInsightsStatus) bool {
+		return status != types.ContributorInsightsStatusDisabling
+	})
+	if err != nil {
+		return err
+	}
+
+	switch insights.ContributorInsightsStatus {
+	case types.ContributorInsightsStatusEnabled:
+		return nil
+	case types.ContributorInsightsStatusEnabling:
+	default:
+		if err := updateContributorInsights(ctx, client, tableName, indexName, types.ContributorInsightsActionEnable); err != nil {
+			return err
+		}
+	}
+
+	insights, err = waitContributorInsights(ctx, client, tableName, indexName, o, func(status types.ContributorInsightsStatus) bool {
+		return status == types.ContributorInsightsStatusEnabled || status == types.ContributorInsightsStatusFailed
+	})
+	if err != nil {
+		return err
+	}
+	if insights.ContributorInsightsStatus == types.ContributorInsightsStatusFailed {
+		reason := ""
+		if insights.FailureException != nil {
+			reason = aws.ToString(insights.FailureException.ExceptionDescription)
+		}
+		return fmt.Errorf("%w: %s: %s", ErrContributorInsightsFailed, contributorInsightsResource(tableName, indexName), reason)
+	}
+	return nil
+}
+
+// DisableContributorInsights disables CloudWatch Contributor Insights on the index of the table, or on the table
+// itself if the index is empty, and waits until it is disabled. If it is not enabled, it does nothing. If it is being
+// enabled, it waits for the enabling to finish before disabling it.
+func DisableContributorInsights(ctx context.Context, client ContributorInsightsClient, tableName, indexName string, options ...Option) error {
+	o := newOpts(options)
+
+	insights, err := waitContributorInsights(ctx, client, tableName, indexName, o, func(status types.ContributorInsightsStatus) bool {
+		return status != types.ContributorInsightsStatusEnabling
+	})
+	if err != nil {
+		return err
+	}
+
+	switch insights.ContributorInsightsStatus {
+	case types.ContributorInsightsStatusDisabled, types.ContributorInsightsStatusFailed:
+		return nil
+	case types.ContributorInsightsStatusDisabling:
+	default:
+		if err := updateContributorInsights(ctx, client, tableName, indexName, types.ContributorInsightsActionDisable); err != nil {
+			return err
+		}
+	}
+
+	_, err = waitContributorInsights(ctx, client, tableName, indexName, o, func(status types.ContributorInsightsStatus) bool {
+		return status == types.ContributorInsightsStatusDisabled
+	})
+	return err
+}
+
+func updateContributorInsights(ctx context.Context, client ContributorInsightsClient, tableName, indexName string, action types.ContributorInsightsAction) error {
+	input := &dynamodb.UpdateContributorInsightsInput{
+		TableName:                 aws.String(tableName),
+		ContributorInsightsAction: action,
+	}
+	if indexName != "" {
+		input.IndexName = aws.String(indexName)
+	}
+	if _, err := client.UpdateContributorInsights(ctx, input); err != nil {
+		return fmt.Errorf("failed to update the contributor insights of %s: %w", contributorInsightsResource(tableName, indexName), err)
+	}
+	return nil
+}
+
+// waitContributorInsights describes the Contributor Insights of the index of the table until ready returns true for
+// its status, or the timeout is reached.
+func waitContributorInsights(ctx context.Context, client ContributorInsightsClient, tableName, indexName string, options opts, ready func(types.ContributorInsightsStatus) bool) (*dynamodb.DescribeContributorInsightsOutput, error) {
+	input := &dynamodb.DescribeContributorInsightsInput{TableName: aws.String(tableName)}
+	if indexName != "" {
+		input.IndexName = aws.String(indexName)
+	}
+	var insights *dynamodb.DescribeContributorInsightsOutput
+	err := poll(ctx, options, func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeContributorInsights(ctx, input)
+		if err != nil {
+			return false, fmt.Errorf("failed to describe the contributor insights of %s: %w", contributorInsightsResource(tableName, indexName), err)
+		}
+		insights = output
+		return ready(insights.ContributorInsightsStatus), nil
+	})
+	if err != nil {
+		return insights, fmt.Errorf("failed waiting for the contributor insights of %s: %w", contributorInsightsResource(tableName, indexName), err)
+	}
+	return insights, nil
+}
+
+func contributorInsightsResource(tableName, indexName string) string {
+	if indexName == "" {
+		return "table " + tableName
+	}
+	return "index " + indexName + " of table " + tableName
+}
@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Contributor Insights", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("users"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should enable contributor insights on the table and wait until they are enabled", func() {
+		Expect(EnableContributorInsights(ctx, client, "users", "", WithPollInterval(time.Millisecond))).To(Succeed())
+
+		Expect(client.tables["users"].insights).To(Equal(map[string]types.ContributorInsightsStatus{"": types.ContributorInsightsStatusEnabled}))
+	})
+
+	It("should enable contributor insights on the index only", func() {
+		Expect(EnableContributorInsights(ctx, client, "users", "by-email", WithPollInterval(time.Millisecond))).To(Succeed())
+
+		Expect(client.tables["users"].insights).To(Equal(map[string]types.ContributorInsightsStatus{"by-email": types.ContributorInsightsStatusEnabled}))
+	})
+
+	It("should do nothing when contributor insights are already enabled", func() {
+		Expect(EnableContributorInsights(ctx, client, "users", "", WithPollInterval(time.Millisecond))).To(Succeed())
+		client.calls = nil
+
+		Expect(EnableContributorInsights(ctx, client, "users", "", WithPollInterval(time.Millisecond))).To(Succeed())
+		Expect(client.calls).To(Equal([]string{"DescribeContributorInsights"}))
+	})
+
+	It("should fail with ErrContributorInsightsFailed when they cannot be enabled", func() {
+		client.insightsFailure = "Contributor Insights rule limit exceeded"
+
+		err := EnableContributorInsights(ctx, client, "users", "", WithPollInterval(time.Millisecond))
+		Expect(err).To(MatchError(ErrContributorInsightsFailed))
+		Expect(err).To(MatchError(ContainSubstring("rule limit exceeded")))
+	})
+
+	It("should disable contributor insights and wait until they are disabled", func() {
+		Expect(EnableContributorInsights(ctx, client, "users", "", WithPollInterval(time.Millisecond))).To(Succeed())
+
+		Expect(DisableContributorInsights(ctx, client, "users", "", WithPollInterval(time.Millisecond))).To(Succeed())
+		Expect(client.tables["users"].insights[""]).To(Equal(types.ContributorInsightsStatusDisabled))
+	})
+
+	It("should do nothing when disabling contributor insights that are not enabled", func() {
+		Expect(DisableContributorInsights(ctx, client, "users", "")).To(Succeed())
+
+		Expect(client.calls).To(Equal([]string{"DescribeContributorInsights"}))
+	})
+})
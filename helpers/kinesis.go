@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrKinesisDestinationFailed is returned by EnableKinesisDestination when DynamoDB failed to enable the destination.
+var ErrKinesisDestinationFailed = errors.New("kinesis streaming destination failed")
+
+type KinesisDestinationClient interface {
+	EnableKinesisStreamingDestination(ctx context.Context, params *dynamodb.EnableKinesisStreamingDestinationInput, optFns ...func(*dynamodb.Options)) (*dynamodb.EnableKinesisStreamingDestinationOutput, error)
+	DisableKinesisStreamingDestination(ctx context.Context, params *dynamodb.DisableKinesisStreamingDestinationInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DisableKinesisStreamingDestinationOutput, error)
+	DescribeKinesisStreamingDestination(ctx context.Context, params *dynamodb.DescribeKinesisStreamingDestinationInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeKinesisStreamingDestinationOutput, error)
+}
+
+// EnableKinesisDestination makes the table stream its changes to the Kinesis data stream, and waits until the
+// destination is active. If it is already active, it does nothing. If it is being enabled by a previous run, it only
+// waits for it. If it is being disabled, it waits for the disabling to finish and enables it again.
+//
+// If DynamoDB fails to enable the destination, as when it has no access to the stream, it returns an
+// ErrKinesisDestinationFailed with the reason.
+func EnableKinesisDestination(ctx context.Context, client KinesisDestinationClient, tableName, streamARN string, options ...Option) error {
+	o := newOpts(options)
+
+	destination, err := waitKinesisDestination(ctx, client, tableName, streamARN, o, func(status types.DestinationStatus) bool {
+		return status != types.DestinationStatusDisabling && status != types.DestinationStatusUpdating
+	})
+	if err != nil {
+		return err
+	}
+
+	switch destination.DestinationStatus {
+	case types.DestinationStatusActive:
+		return nil
+	case types.DestinationStatusEnabling:
+	default:
+		_, err := client.EnableKinesisStreamingDestination(ctx, &dynamodb.EnableKinesisStreamingDestinationInput{
+			TableName: aws.String(tableName),
+			StreamArn: aws.String(streamARN),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to enable the kinesis destination %s of table %s: %w", streamARN, tableName, err)
+		}
+	}
+
+	destination, err = waitKinesisDestination(ctx, client, tableName, streamARN, o, func(status types.DestinationStatus) bool {
+		return status == types.DestinationStatusActive || status == types.DestinationStatusEnableFailed
+	})
+	if err != nil {
+		return err
+	}
+	if destination.DestinationStatus == types.DestinationStatusEnableFailed {
+		return fmt.Errorf("%w: %s of table %s: %s", ErrKinesisDestinationFailed, streamARN, tableName, aws.ToString(destination.DestinationStatusDescription))
+	}
+	return nil
+}
+
+// DisableKinesisDestination stops the table from streaming its changes to the Kinesis data stream, and waits until the
+// destination is disabled. If the destination is not enabled, it does nothing. If it is being enabled, it waits for
+// the enabling to finish before disabling it.
+func DisableKinesisDestination(ctx context.Context, client KinesisDestinationClient, tableName, streamARN string, options ...Option) error {
+	o := newOpts(options)
+
+	destination, err := waitKinesisDestination(ctx, client, tableName, streamARN, o, func(status types.DestinationStatus) bool {
+		return status != types.DestinationStatusEnabling && status != types.DestinationStatusUpdating
+	})
+	if err != nil {
+		return err
+	}
+
+	switch destination.DestinationStatus {
+	case types.DestinationStatusDisabled, types.DestinationStatusEnableFailed, "":
+		return nil
+	case types.DestinationStatusDisabling:
+	default:
+		_, err := client.DisableKinesisStreamingDestination(ctx, &dynamodb.DisableKinesisStreamingDestinationInput{
+			TableName: aws.String(tableName),
+			StreamArn: aws.String(streamARN),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to disable the kinesis destination %s of table %s: %w", streamARN, tableName, err)
+		}
+	}
+
+	_, err = waitKinesisDestination(ctx, client, tableName, streamARN, o, func(status types.DestinationStatus) bool {
+		return status == types.DestinationStatusDisabled || status == ""
+	})
+	return err
+}
+
+// waitKinesisDestination describes the destination of the table until ready returns true for its status, or the
+// timeout is reached. A destination the table does not have has an empty status.
+func waitKinesisDestination(ctx context.Context, client KinesisDestinationClient, tableName, streamARN string, options opts, ready func(types.DestinationStatus) bool) (types.KinesisDataStreamDestination, error) {
+	var destination types.KinesisDataStreamDestination
+	err := poll(ctx, options, func(ctx context.Context) (bool, error) {
+		output, err := client.DescribeKinesisStreamingDestination(ctx, &dynamodb.DescribeKinesisStreamingDestinationInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to describe the kinesis destinations of table %s: %w", tableName, err)
+		}
+		destination = types.KinesisDataStreamDestination{}
+		for _, d := range output.KinesisDataStreamDestinations {
+			if aws.ToString(d.StreamArn) == streamARN {
+				destination = d
+			}
+		}
+		return ready(destination.DestinationStatus), nil
+	})
+	if err != nil {
+		return destination, fmt.Errorf("failed waiting for the kinesis destination %s of table %s: %w", streamARN, tableName, err)
+	}
+	return destination, nil
+}
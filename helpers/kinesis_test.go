@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Kinesis destinations", func() {
+	const streamARN = "arn:aws:kinesis:us-east-1:000000000000:stream/analytics"
+
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	status := func() types.DestinationStatus {
+		for _, destination := range client.tables["users"].destinations {
+			if aws.ToString(destination.StreamArn) == streamARN {
+				return destination.DestinationStatus
+			}
+		}
+		return ""
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("users"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should enable the destination and wait until it is active", func() {
+		Expect(EnableKinesisDestination(ctx, client, "users", streamARN, WithPollInterval(time.Millisecond))).To(Succeed())
+
+		Expect(status()).To(Equal(types.DestinationStatusActive))
+	})
+
+	It("should do nothing when the destination is already active", func() {
+		Expect(EnableKinesisDestination(ctx, client, "users", streamARN, WithPollInterval(time.Millisecond))).To(Succeed())
+		client.calls = nil
+
+		Expect(EnableKinesisDestination(ctx, client, "users", streamARN, WithPollInterval(time.Millisecond))).To(Succeed())
+		Expect(client.calls).To(Equal([]string{"DescribeKinesisStreamingDestination"}))
+	})
+
+	It("should fail with ErrKinesisDestinationFailed when the destination cannot be enabled", func() {
+		client.kinesisFailure = "User does not have a permission to write to the stream"
+
+		err := EnableKinesisDestination(ctx, client, "users", streamARN, WithPollInterval(time.Millisecond))
+		Expect(err).To(MatchError(ErrKinesisDestinationFailed))
+		Expect(err).To(MatchError(ContainSubstring("permission to write to the stream")))
+	})
+
+	It("should disable the destination and wait until it is disabled", func() {
+		Expect(EnableKinesisDestination(ctx, client, "users", streamARN, WithPollInterval(time.Millisecond))).To(Succeed())
+
+		Expect(DisableKinesisDestination(ctx, client, "users", streamARN, WithPollInterval(time.Millisecond))).To(Succeed())
+		Expect(status()).To(Equal(types.DestinationStatusDisabled))
+	})
+
+	It("should do nothing when disabling a destination the table does not have", func() {
+		Expect(DisableKinesisDestination(ctx, client, "users", streamARN)).To(Succeed())
+
+		Expect(client.calls).To(Equal([]string{"DescribeKinesisStreamingDestination"}))
+	})
+})
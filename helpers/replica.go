@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrReplicaFailed is returned by AddReplica when DynamoDB failed to create the replica.
+var ErrReplicaFailed = errors.New("replica creation failed")
+
+// AddReplica adds a replica of the table in the region, turning it into a global table, and waits until the replica
+// is active, so a multi-region rollout can be a migration, and its rollback the undo removing the replica. The table
+// must have a stream with the new and old images. If the replica already exists, it does nothing but wait for it to
+// be active. If it is being removed, AddReplica waits for the removal to finish and adds it again.
+//
+// Creating a replica of a big table can take hours, the timeout set by WithTimeout should be set accordingly. If
+// DynamoDB fails to create the replica, it returns an ErrReplicaFailed with the reason.
+func AddReplica(ctx context.Context, client EnsureGSIClient, tableName, region string, options ...Option) error {
+	o := newOpts(options)
+
+	table, err := waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		current := findReplica(table, region)
+		return table.TableStatus == types.TableStatusActive && (current == nil || current.ReplicaStatus != types.ReplicaStatusDeleting)
+	})
+	if err != nil {
+		return err
+	}
+
+	if current := findReplica(table, region); current == nil || current.ReplicaStatus == types.ReplicaStatusCreationFailed {
+		_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName: aws.String(tableName),
+			ReplicaUpdates: []types.ReplicationGroupUpdate{
+				{Create: &types.CreateReplicationGroupMemberAction{RegionName: aws.String(region)}},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add replica %s of table %s: %w", region, tableName, err)
+		}
+	}
+
+	table, err = waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		current := findReplica(table, region)
+		return table.TableStatus == types.TableStatusActive && current != nil &&
+			(current.ReplicaStatus == types.ReplicaStatusActive || current.ReplicaStatus == types.ReplicaStatusCreationFailed)
+	})
+	if err != nil {
+		return err
+	}
+	if current := findReplica(table, region); current.ReplicaStatus == types.ReplicaStatusCreationFailed {
+		return fmt.Errorf("%w: %s of table %s: %s", ErrReplicaFailed, region, tableName, aws.ToString(current.ReplicaStatusDescription))
+	}
+	return nil
+}
+
+// RemoveReplica removes the replica of the table in the region, and waits until it is gone. The data of the replica
+// is deleted with it. If the table has no replica in the region, it does nothing. If the replica is being removed by
+// a previous run, RemoveReplica only waits for it.
+func RemoveReplica(ctx context.Context, client EnsureGSIClient, tableName, region string, options ...Option) error {
+	o := newOpts(options)
+
+	table, err := waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		current := findReplica(table, region)
+		return table.TableStatus == types.TableStatusActive && (current == nil || current.ReplicaStatus != types.ReplicaStatusCreating)
+	})
+	if err != nil {
+		return err
+	}
+
+	current := findReplica(table, region)
+	if current == nil {
+		return nil
+	}
+	if current.ReplicaStatus != types.ReplicaStatusDeleting {
+		_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName: aws.String(tableName),
+			ReplicaUpdates: []types.ReplicationGroupUpdate{
+				{Delete: &types.DeleteReplicationGroupMemberAction{RegionName: aws.String(region)}},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove replica %s of table %s: %w", region, tableName, err)
+		}
+	}
+
+	_, err = waitTable(ctx, client, tableName, o, func(table *types.TableDescription) bool {
+		return table.TableStatus == types.TableStatusActive && findReplica(table, region) == nil
+	})
+	return err
+}
+
+func findReplica(table *types.TableDescription, region string) *types.ReplicaDescription {
+	i := slices.IndexFunc(table.Replicas, func(replica types.ReplicaDescription) bool {
+		return aws.ToString(replica.RegionName) == region
+	})
+	if i < 0 {
+		return nil
+	}
+	return &table.Replicas[i]
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Replicas", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("users"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+			StreamSpecification:  &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: types.StreamViewTypeNewAndOldImages},
+		})).To(Succeed())
+		client.pending = 2
+		client.calls = nil
+	})
+
+	Describe("AddReplica", func() {
+		It("should add the replica and wait until it is active", func() {
+			Expect(AddReplica(ctx, client, "users", "eu-west-1", WithPollInterval(time.Millisecond))).To(Succeed())
+
+			replica := findReplica(&client.tables["users"].description, "eu-west-1")
+			Expect(replica).ToNot(BeNil())
+			Expect(replica.ReplicaStatus).To(Equal(types.ReplicaStatusActive))
+		})
+
+		It("should not add the replica again", func() {
+			Expect(AddReplica(ctx, client, "users", "eu-west-1", WithPollInterval(time.Millisecond))).To(Succeed())
+			client.calls = nil
+
+			Expect(AddReplica(ctx, client, "users", "eu-west-1", WithPollInterval(time.Millisecond))).To(Succeed())
+			Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+			Expect(client.tables["users"].description.Replicas).To(HaveLen(1))
+		})
+
+		It("should fail with ErrReplicaFailed when the replica cannot be created", func() {
+			client.replicaFailure = "KMS key is not accessible in the region"
+
+			err := AddReplica(ctx, client, "users", "eu-west-1", WithPollInterval(time.Millisecond))
+			Expect(err).To(MatchError(ErrReplicaFailed))
+			Expect(err).To(MatchError(ContainSubstring("KMS key is not accessible")))
+		})
+	})
+
+	Describe("RemoveReplica", func() {
+		It("should remove the replica and wait until it is gone", func() {
+			Expect(AddReplica(ctx, client, "users", "eu-west-1", WithPollInterval(time.Millisecond))).To(Succeed())
+
+			Expect(RemoveReplica(ctx, client, "users", "eu-west-1", WithPollInterval(time.Millisecond))).To(Succeed())
+			Expect(client.tables["users"].description.Replicas).To(BeEmpty())
+		})
+
+		It("should do nothing when the table has no replica in the region", func() {
+			Expect(RemoveReplica(ctx, client, "users", "eu-west-1", WithPollInterval(time.Millisecond))).To(Succeed())
+
+			Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+		})
+	})
+})
@@ -0,0 +1,180 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+type ScanClient interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// pageFunc processes the items of a page scanned, counting what was done in result.
+type pageFunc func(ctx context.Context, items []map[string]types.AttributeValue, backoff *throttle.Backoff, result *BackfillResult) error
+
+// scanTable scans the segments of the table in parallel, calling process for each page, and stops at the first error.
+// With the checkpoint set by WithCheckpoint, the scan resumes from the checkpoint saved, and the checkpoint is saved
+// after each page processed, unless it is a dry run.
+func scanTable(ctx context.Context, client ScanClient, tableName string, options opts, process pageFunc) (BackfillResult, error) {
+	if options.dryRun {
+		// A dry run must not make the real run skip the pages it scanned.
+		options.checkpointStore = nil
+	}
+	s := &scanner{
+		client:    client,
+		tableName: tableName,
+		options:   options,
+		process:   process,
+	}
+	if err := s.load(ctx); err != nil {
+		return BackfillResult{}, err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for segment := range s.checkpoint.Segments {
+		if s.checkpoint.Segments[segment].Done {
+			continue
+		}
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			if err := s.scanSegment(ctx, segment); err != nil {
+				cancel(err)
+			}
+		}(segment)
+	}
+	wg.Wait()
+
+	result := s.checkpoint.Result()
+	result.add(s.interrupted)
+	result.Diffs = truncateDiffs(result.Diffs, s.options.dryRunSamples)
+	return result, context.Cause(ctx)
+}
+
+func truncateDiffs(diffs []ItemDiff, limit int) []ItemDiff {
+	if len(diffs) > limit {
+		return diffs[:limit]
+	}
+	return diffs
+}
+
+type scanner struct {
+	client    ScanClient
+	tableName string
+	options   opts
+	process   pageFunc
+
+	// mu protects the checkpoint and the results of the pages interrupted, saveMu serializes the saves of the
+	// checkpoint.
+	mu          sync.Mutex
+	saveMu      sync.Mutex
+	checkpoint  Checkpoint
+	interrupted BackfillResult
+}
+
+// load reads the checkpoint to resume from, if any.
+func (s *scanner) load(ctx context.Context) error {
+	s.checkpoint = Checkpoint{Segments: make([]SegmentCheckpoint, s.options.segments)}
+	if s.options.checkpointStore == nil {
+		return nil
+	}
+
+	checkpoint, err := s.options.checkpointStore.LoadCheckpoint(ctx, s.options.checkpointID)
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to load checkpoint %s: %w", s.options.checkpointID, err)
+	case checkpoint == nil:
+		return nil
+	case len(checkpoint.Segments) != s.options.segments:
+		return fmt.Errorf("%w: checkpoint %s has %d segments instead of %d", ErrCheckpointMismatch, s.options.checkpointID,
+			len(checkpoint.Segments), s.options.segments)
+	}
+	s.checkpoint = *checkpoint
+	return nil
+}
+
+// save writes a copy of the current checkpoint, if the checkpoint was set.
+func (s *scanner) save(ctx context.Context) error {
+	if s.options.checkpointStore == nil {
+		return nil
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	checkpoint := Checkpoint{Segments: make([]SegmentCheckpoint, len(s.checkpoint.Segments))}
+	copy(checkpoint.Segments, s.checkpoint.Segments)
+	s.mu.Unlock()
+
+	if err := s.options.checkpointStore.SaveCheckpoint(ctx, s.options.checkpointID, &checkpoint); err != nil {
+		return fmt.Errorf("failed to save checkpoint %s: %w", s.options.checkpointID, err)
+	}
+	return nil
+}
+
+// scanSegment reads the pages of the segment, from where its checkpoint stopped, and processes their items.
+func (s *scanner) scanSegment(ctx context.Context, segment int) error {
+	s.mu.Lock()
+	startKey := s.checkpoint.Segments[segment].StartKey
+	s.mu.Unlock()
+
+	var backoff throttle.Backoff
+	for {
+		if err := backoff.Wait(ctx); err != nil {
+			return fmt.Errorf("failed to scan table %s: %w", s.tableName, err)
+		}
+
+		input := &dynamodb.ScanInput{
+			TableName:         aws.String(s.tableName),
+			ExclusiveStartKey: startKey,
+		}
+		if s.options.segments > 1 {
+			input.Segment = aws.Int32(int32(segment))
+			input.TotalSegments = aws.Int32(int32(s.options.segments))
+		}
+		output, err := s.client.Scan(ctx, input)
+		if throttle.IsThrottlingError(err) && backoff.Throttled() {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to scan table %s: %w", s.tableName, err)
+		}
+		backoff.Succeeded()
+
+		page := BackfillResult{Scanned: int64(len(output.Items))}
+		err = s.process(ctx, output.Items, &backoff, &page)
+
+		s.mu.Lock()
+		if err != nil {
+			// The page is processed again when resuming, so what was done is not saved in the checkpoint.
+			s.interrupted.add(page)
+			s.mu.Unlock()
+			return err
+		}
+		state := &s.checkpoint.Segments[segment]
+		state.Result.add(page)
+		state.Result.Diffs = truncateDiffs(state.Result.Diffs, s.options.dryRunSamples)
+		state.StartKey = output.LastEvaluatedKey
+		state.Done = len(output.LastEvaluatedKey) == 0
+		s.mu.Unlock()
+
+		if err := s.save(ctx); err != nil {
+			return err
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		startKey = output.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,203 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+// SeedFormat is the format of the fixtures loaded by Seed.
+type SeedFormat string
+
+const (
+	// SeedFormatJSON is a JSON array of objects, one per item.
+	SeedFormatJSON SeedFormat = "json"
+	// SeedFormatNDJSON is a JSON object per line, one per item. Blank lines are ignored.
+	SeedFormatNDJSON SeedFormat = "ndjson"
+	// SeedFormatCSV is a header with the attribute names followed by a row per item. The values are stored as strings,
+	// and empty values are left out of the item.
+	SeedFormatCSV SeedFormat = "csv"
+)
+
+// ErrUnknownSeedFormat is returned by SeedFile when the format of the file cannot be told from its extension.
+var ErrUnknownSeedFormat = errors.New("unknown seed format")
+
+// SeedResult counts what a Seed wrote to the table.
+type SeedResult struct {
+	// Written is the number of items written.
+	Written int64
+	// ConsumedWriteCapacity is the write capacity units consumed by the writes.
+	ConsumedWriteCapacity float64
+}
+
+// Seed loads the fixtures read from r into the table. The items are written in batches, sharing the budget set by
+// WithWriteCapacity, and replace the items with the same key, so a Seed interrupted halfway can be run again. The
+// fixtures must not have two items with the same key.
+//
+// With WithTemplate, the fixtures are executed as a text/template before being parsed, so they can hold the values
+// that change between environments.
+func Seed(ctx context.Context, client BatchWriteClient, tableName string, r io.Reader, format SeedFormat, options ...Option) (SeedResult, error) {
+	o := newOpts(options)
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return SeedResult{}, fmt.Errorf("failed to read the fixtures: %w", err)
+	}
+	if o.templating {
+		data, err = executeTemplate(data, o.templateData)
+		if err != nil {
+			return SeedResult{}, err
+		}
+	}
+
+	items, err := parseFixtures(data, format)
+	if err != nil {
+		return SeedResult{}, err
+	}
+
+	var backoff throttle.Backoff
+	consumed, err := batchWrite(ctx, client, tableName, items, throttle.NewRateLimiter(nil, o.writeCapacity), &backoff)
+	if err != nil {
+		return SeedResult{ConsumedWriteCapacity: consumed}, err
+	}
+	return SeedResult{Written: int64(len(items)), ConsumedWriteCapacity: consumed}, nil
+}
+
+// SeedFile loads the fixtures of the file into the table, as Seed does. The format is told from the extension of the
+// file: ".json", ".ndjson" (or ".jsonl") and ".csv". Files embedded with go:embed can be loaded from their embed.FS.
+func SeedFile(ctx context.Context, client BatchWriteClient, tableName string, fsys fs.FS, name string, options ...Option) (SeedResult, error) {
+	var format SeedFormat
+	switch path.Ext(name) {
+	case ".json":
+		format = SeedFormatJSON
+	case ".ndjson", ".jsonl":
+		format = SeedFormatNDJSON
+	case ".csv":
+		format = SeedFormatCSV
+	default:
+		return SeedResult{}, fmt.Errorf("%w: %s", ErrUnknownSeedFormat, name)
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return SeedResult{}, fmt.Errorf("failed to open fixtures %s: %w", name, err)
+	}
+	defer f.Close()
+
+	return Seed(ctx, client, tableName, f, format, options...)
+}
+
+// executeTemplate executes the fixtures as a template with the given data. Besides the functions of text/template,
+// "env" returns the value of an environment variable.
+func executeTemplate(data []byte, templateData any) ([]byte, error) {
+	tmpl, err := template.New("fixtures").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"env": os.Getenv}).
+		Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the fixtures template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData); err != nil {
+		return nil, fmt.Errorf("failed to execute the fixtures template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func parseFixtures(data []byte, format SeedFormat) ([]map[string]types.AttributeValue, error) {
+	switch format {
+	case SeedFormatJSON:
+		return parseJSONFixtures(data)
+	case SeedFormatNDJSON:
+		return parseNDJSONFixtures(data)
+	case SeedFormatCSV:
+		return parseCSVFixtures(data)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownSeedFormat, format)
+}
+
+func parseJSONFixtures(data []byte) ([]map[string]types.AttributeValue, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var objects []map[string]any
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, fmt.Errorf("failed to parse the JSON fixtures: %w", err)
+	}
+
+	items := make([]map[string]types.AttributeValue, 0, len(objects))
+	for i, object := range objects {
+		item, err := attributevalue.MarshalMap(object)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item %d: %w", i, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func parseNDJSONFixtures(data []byte) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 400*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil {
+			return nil, fmt.Errorf("failed to parse the NDJSON fixtures at line %d: %w", line, err)
+		}
+		item, err := attributevalue.MarshalMap(object)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item at line %d: %w", line, err)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the NDJSON fixtures: %w", err)
+	}
+	return items, nil
+}
+
+func parseCSVFixtures(data []byte) ([]map[string]types.AttributeValue, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the CSV fixtures: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	items := make([]map[string]types.AttributeValue, 0, len(records)-1)
+	for _, record := range records[1:] {
+		item := make(map[string]types.AttributeValue, len(header))
+		for i, value := range record {
+			if value != "" {
+				item[header[i]] = &types.AttributeValueMemberS{Value: value}
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Seed", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	key := func(pk string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: pk}}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String("countries"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	It("should load JSON fixtures keeping the types of the values", func() {
+		result, err := Seed(ctx, client, "countries", strings.NewReader(`[
+			{"pk": "BR", "name": "Brazil", "population": 203, "tags": ["south-america"], "active": true},
+			{"pk": "PT", "name": "Portugal", "population": 10.4, "capital": {"name": "Lisbon"}}
+		]`), SeedFormatJSON)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(SeedResult{Written: 2, ConsumedWriteCapacity: 2}))
+		Expect(client.item("countries", key("BR"))).To(Equal(map[string]types.AttributeValue{
+			"pk":         &types.AttributeValueMemberS{Value: "BR"},
+			"name":       &types.AttributeValueMemberS{Value: "Brazil"},
+			"population": &types.AttributeValueMemberN{Value: "203"},
+			"tags":       &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "south-america"}}},
+			"active":     &types.AttributeValueMemberBOOL{Value: true},
+		}))
+		Expect(client.item("countries", key("PT"))).To(HaveKeyWithValue("capital", &types.AttributeValueMemberM{
+			Value: map[string]types.AttributeValue{"name": &types.AttributeValueMemberS{Value: "Lisbon"}},
+		}))
+	})
+
+	It("should load NDJSON fixtures skipping blank lines", func() {
+		result, err := Seed(ctx, client, "countries", strings.NewReader("{\"pk\": \"BR\"}\n\n{\"pk\": \"PT\"}\n"), SeedFormatNDJSON)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.Written).To(Equal(int64(2)))
+		Expect(client.item("countries", key("PT"))).ToNot(BeNil())
+	})
+
+	It("should report the line of an invalid NDJSON item", func() {
+		_, err := Seed(ctx, client, "countries", strings.NewReader("{\"pk\": \"BR\"}\n{\"pk\": \n"), SeedFormatNDJSON)
+
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+	})
+
+	It("should load CSV fixtures as strings, leaving empty values out", func() {
+		_, err := Seed(ctx, client, "countries", strings.NewReader("pk,name,capital\nBR,Brazil,\nPT,Portugal,Lisbon\n"), SeedFormatCSV)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.item("countries", key("BR"))).To(Equal(map[string]types.AttributeValue{
+			"pk":   &types.AttributeValueMemberS{Value: "BR"},
+			"name": &types.AttributeValueMemberS{Value: "Brazil"},
+		}))
+		Expect(client.item("countries", key("PT"))).To(HaveKeyWithValue("capital", &types.AttributeValueMemberS{Value: "Lisbon"}))
+	})
+
+	It("should execute the fixtures as a template with WithTemplate", func() {
+		GinkgoT().Setenv("SEED_STAGE", "staging")
+
+		_, err := Seed(ctx, client, "countries", strings.NewReader(`[{"pk": "BR", "stage": "{{ env "SEED_STAGE" }}", "url": "{{ .BaseURL }}/br"}]`),
+			SeedFormatJSON, WithTemplate(map[string]string{"BaseURL": "https://staging.example.com"}))
+		Expect(err).ToNot(HaveOccurred())
+
+		item := client.item("countries", key("BR"))
+		Expect(item).To(HaveKeyWithValue("stage", &types.AttributeValueMemberS{Value: "staging"}))
+		Expect(item).To(HaveKeyWithValue("url", &types.AttributeValueMemberS{Value: "https://staging.example.com/br"}))
+	})
+
+	It("should write the items in batches and retry the unprocessed ones", func() {
+		var lines []string
+		for i := range 60 {
+			lines = append(lines, fmt.Sprintf(`{"pk": "C%02d"}`, i))
+		}
+		client.unprocessed = 5
+
+		result, err := Seed(ctx, client, "countries", strings.NewReader(strings.Join(lines, "\n")), SeedFormatNDJSON)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result).To(Equal(SeedResult{Written: 60, ConsumedWriteCapacity: 60}))
+		Expect(client.calls).To(HaveEach("BatchWriteItem"))
+		Expect(client.calls).To(HaveLen(4))
+		Expect(client.tables["countries"].items).To(HaveLen(60))
+	})
+
+	Describe("SeedFile", func() {
+		It("should tell the format from the extension", func() {
+			fsys := fstest.MapFS{
+				"fixtures/countries.csv": &fstest.MapFile{Data: []byte("pk,name\nBR,Brazil\n")},
+			}
+
+			result, err := SeedFile(ctx, client, "countries", fsys, "fixtures/countries.csv")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Written).To(Equal(int64(1)))
+			Expect(client.item("countries", key("BR"))).To(HaveKeyWithValue("name", &types.AttributeValueMemberS{Value: "Brazil"}))
+		})
+
+		It("should fail with ErrUnknownSeedFormat for other extensions", func() {
+			fsys := fstest.MapFS{
+				"countries.yaml": &fstest.MapFile{Data: []byte("- pk: BR\n")},
+			}
+
+			_, err := SeedFile(ctx, client, "countries", fsys, "countries.yaml")
+			Expect(err).To(MatchError(ErrUnknownSeedFormat))
+		})
+	})
+})
@@ -0,0 +1,474 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrInvalidSpec is returned by Apply when the TableSpec is not valid, as one without a partition key.
+var ErrInvalidSpec = errors.New("invalid table spec")
+
+// TableSpec declares the desired state of a table: its keys, billing, global secondary indexes, TTL, stream and tags.
+// It is built with NewTableSpec and its methods, and applied with Apply.
+type TableSpec struct {
+	name         string
+	partitionKey *types.AttributeDefinition
+	sortKey      *types.AttributeDefinition
+	billingMode  types.BillingMode
+	read, write  int64
+	indexes      []*IndexSpec
+	ttlAttribute string
+	streamView   types.StreamViewType
+	tags         []types.Tag
+}
+
+// NewTableSpec starts the spec of the table with the given name. Tables are on-demand unless Provisioned is called.
+func NewTableSpec(name string) *TableSpec {
+	return &TableSpec{
+		name:        name,
+		billingMode: types.BillingModePayPerRequest,
+	}
+}
+
+// Name returns the name of the table.
+func (s *TableSpec) Name() string {
+	return s.name
+}
+
+// PartitionKey sets the partition key of the table.
+func (s *TableSpec) PartitionKey(name string, attributeType types.ScalarAttributeType) *TableSpec {
+	s.partitionKey = &types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: attributeType}
+	return s
+}
+
+// SortKey sets the sort key of the table.
+func (s *TableSpec) SortKey(name string, attributeType types.ScalarAttributeType) *TableSpec {
+	s.sortKey = &types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: attributeType}
+	return s
+}
+
+// Provisioned makes the table provisioned with the given throughput, which its global secondary indexes get too.
+func (s *TableSpec) Provisioned(readCapacityUnits, writeCapacityUnits int64) *TableSpec {
+	s.billingMode = types.BillingModeProvisioned
+	s.read, s.write = readCapacityUnits, writeCapacityUnits
+	return s
+}
+
+// GSI adds a global secondary index to the table.
+func (s *TableSpec) GSI(index *IndexSpec) *TableSpec {
+	s.indexes = append(s.indexes, index)
+	return s
+}
+
+// TTL enables the TTL of the table on the given attribute.
+func (s *TableSpec) TTL(attributeName string) *TableSpec {
+	s.ttlAttribute = attributeName
+	return s
+}
+
+// Stream enables the stream of the table with the given view type.
+func (s *TableSpec) Stream(viewType types.StreamViewType) *TableSpec {
+	s.streamView = viewType
+	return s
+}
+
+// Tag sets a tag of the table.
+func (s *TableSpec) Tag(key, value string) *TableSpec {
+	s.tags = append(s.tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	return s
+}
+
+// IndexSpec declares a global secondary index of a TableSpec. It is built with NewIndexSpec and its methods.
+type IndexSpec struct {
+	name             string
+	partitionKey     *types.AttributeDefinition
+	sortKey          *types.AttributeDefinition
+	projectionType   types.ProjectionType
+	nonKeyAttributes []string
+}
+
+// NewIndexSpec starts the spec of the global secondary index with the given name. The index projects all attributes
+// unless KeysOnly or Include is called.
+func NewIndexSpec(name string) *IndexSpec {
+	return &IndexSpec{
+		name:           name,
+		projectionType: types.ProjectionTypeAll,
+	}
+}
+
+// PartitionKey sets the partition key of the index.
+func (i *IndexSpec) PartitionKey(name string, attributeType types.ScalarAttributeType) *IndexSpec {
+	i.partitionKey = &types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: attributeType}
+	return i
+}
+
+// SortKey sets the sort key of the index.
+func (i *IndexSpec) SortKey(name string, attributeType types.ScalarAttributeType) *IndexSpec {
+	i.sortKey = &types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: attributeType}
+	return i
+}
+
+// KeysOnly makes the index project only the keys.
+func (i *IndexSpec) KeysOnly() *IndexSpec {
+	i.projectionType = types.ProjectionTypeKeysOnly
+	i.nonKeyAttributes = nil
+	return i
+}
+
+// Include makes the index project the keys and the given attributes.
+func (i *IndexSpec) Include(attributeNames ...string) *IndexSpec {
+	i.projectionType = types.ProjectionTypeInclude
+	i.nonKeyAttributes = attributeNames
+	return i
+}
+
+func keySchema(partitionKey, sortKey *types.AttributeDefinition) []types.KeySchemaElement {
+	schema := []types.KeySchemaElement{{AttributeName: partitionKey.AttributeName, KeyType: types.KeyTypeHash}}
+	if sortKey != nil {
+		schema = append(schema, types.KeySchemaElement{AttributeName: sortKey.AttributeName, KeyType: types.KeyTypeRange})
+	}
+	return schema
+}
+
+func (i *IndexSpec) projection() *types.Projection {
+	projection := &types.Projection{ProjectionType: i.projectionType}
+	if i.projectionType == types.ProjectionTypeInclude {
+		projection.NonKeyAttributes = i.nonKeyAttributes
+	}
+	return projection
+}
+
+// validate checks the spec has the keys and the attribute types of the keys do not conflict.
+func (s *TableSpec) validate() error {
+	if s.name == "" {
+		return fmt.Errorf("%w: missing table name", ErrInvalidSpec)
+	}
+	if s.partitionKey == nil {
+		return fmt.Errorf("%w: table %s without partition key", ErrInvalidSpec, s.name)
+	}
+	if s.billingMode == types.BillingModeProvisioned && (s.read <= 0 || s.write <= 0) {
+		return fmt.Errorf("%w: table %s is provisioned without read and write capacity units", ErrInvalidSpec, s.name)
+	}
+	names := map[string]bool{}
+	for _, index := range s.indexes {
+		if index.name == "" || names[index.name] {
+			return fmt.Errorf("%w: table %s has an index without name or with a duplicate name", ErrInvalidSpec, s.name)
+		}
+		names[index.name] = true
+		if index.partitionKey == nil {
+			return fmt.Errorf("%w: index %s of table %s without partition key", ErrInvalidSpec, index.name, s.name)
+		}
+	}
+
+	attributeTypes := map[string]types.ScalarAttributeType{}
+	for _, attribute := range s.keyAttributes() {
+		name := aws.ToString(attribute.AttributeName)
+		if t, ok := attributeTypes[name]; ok && t != attribute.AttributeType {
+			return fmt.Errorf("%w: attribute %s of table %s has types %s and %s", ErrInvalidSpec, name, s.name, t, attribute.AttributeType)
+		}
+		attributeTypes[name] = attribute.AttributeType
+	}
+	return nil
+}
+
+// keyAttributes returns the keys of the table and of its indexes.
+func (s *TableSpec) keyAttributes() []types.AttributeDefinition {
+	var attributes []types.AttributeDefinition
+	for _, attribute := range []*types.AttributeDefinition{s.partitionKey, s.sortKey} {
+		if attribute != nil {
+			attributes = append(attributes, *attribute)
+		}
+	}
+	for _, index := range s.indexes {
+		for _, attribute := range []*types.AttributeDefinition{index.partitionKey, index.sortKey} {
+			if attribute != nil {
+				attributes = append(attributes, *attribute)
+			}
+		}
+	}
+	return attributes
+}
+
+// attributeDefinitions returns the definitions of the key attributes of the table and of its indexes, without
+// duplicates.
+func (s *TableSpec) attributeDefinitions() []types.AttributeDefinition {
+	var definitions []types.AttributeDefinition
+	for _, attribute := range s.keyAttributes() {
+		if !slices.ContainsFunc(definitions, func(definition types.AttributeDefinition) bool {
+			return aws.ToString(definition.AttributeName) == aws.ToString(attribute.AttributeName)
+		}) {
+			definitions = append(definitions, attribute)
+		}
+	}
+	return definitions
+}
+
+func (s *TableSpec) throughput() *types.ProvisionedThroughput {
+	if s.billingMode != types.BillingModeProvisioned {
+		return nil
+	}
+	return &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(s.read), WriteCapacityUnits: aws.Int64(s.write)}
+}
+
+func (s *TableSpec) createIndexAction(index *IndexSpec) *types.CreateGlobalSecondaryIndexAction {
+	return &types.CreateGlobalSecondaryIndexAction{
+		IndexName:             aws.String(index.name),
+		KeySchema:             keySchema(index.partitionKey, index.sortKey),
+		Projection:            index.projection(),
+		ProvisionedThroughput: s.throughput(),
+	}
+}
+
+// CreateTableInput returns the input creating the table of the spec, except for its TTL that is enabled apart.
+func (s *TableSpec) CreateTableInput() *dynamodb.CreateTableInput {
+	input := &dynamodb.CreateTableInput{
+		TableName:             aws.String(s.name),
+		AttributeDefinitions:  s.attributeDefinitions(),
+		KeySchema:             keySchema(s.partitionKey, s.sortKey),
+		BillingMode:           s.billingMode,
+		ProvisionedThroughput: s.throughput(),
+		Tags:                  s.tags,
+	}
+	for _, index := range s.indexes {
+		action := s.createIndexAction(index)
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, types.GlobalSecondaryIndex{
+			IndexName:             action.IndexName,
+			KeySchema:             action.KeySchema,
+			Projection:            action.Projection,
+			ProvisionedThroughput: action.ProvisionedThroughput,
+		})
+	}
+	if s.streamView != "" {
+		input.StreamSpecification = &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: s.streamView}
+	}
+	return input
+}
+
+// tableDiff is what differs between a TableSpec and the table described.
+type tableDiff struct {
+	// missing tells the table does not exist.
+	missing bool
+	// capacity is set when the billing mode or the throughput differ.
+	capacity *Capacity
+	// deleteIndexes are the indexes not in the spec, or with a different key schema or projection.
+	deleteIndexes []string
+	// createIndexes are the indexes of the spec the table does not have, including the ones deleted for differing.
+	createIndexes []*IndexSpec
+	// stream is set when the stream differs. Its view type is empty to disable the stream.
+	stream *types.StreamViewType
+	// ttl is set when the TTL differs. It is empty to disable the TTL.
+	ttl *string
+}
+
+// diffTable compares the spec with the table and its TTL. The table is nil if it does not exist. The key schema is not
+// compared, as it cannot be changed.
+func diffTable(spec *TableSpec, table *types.TableDescription, ttl *types.TimeToLiveDescription) tableDiff {
+	if table == nil {
+		diff := tableDiff{missing: true}
+		if spec.ttlAttribute != "" {
+			diff.ttl = aws.String(spec.ttlAttribute)
+		}
+		return diff
+	}
+
+	var diff tableDiff
+	wantCapacity := Capacity{BillingMode: spec.billingMode, ReadCapacityUnits: spec.read, WriteCapacityUnits: spec.write}
+	if current := billingMode(table); current != spec.billingMode ||
+		(current == types.BillingModeProvisioned && !sameThroughput(table.ProvisionedThroughput, wantCapacity)) {
+		diff.capacity = &wantCapacity
+	}
+
+	for _, current := range table.GlobalSecondaryIndexes {
+		i := slices.IndexFunc(spec.indexes, func(index *IndexSpec) bool { return index.name == aws.ToString(current.IndexName) })
+		if i < 0 || !sameIndex(spec.indexes[i], &current) {
+			diff.deleteIndexes = append(diff.deleteIndexes, aws.ToString(current.IndexName))
+		}
+	}
+	for _, index := range spec.indexes {
+		if current := findIndex(table, index.name); current == nil || slices.Contains(diff.deleteIndexes, index.name) {
+			diff.createIndexes = append(diff.createIndexes, index)
+		}
+	}
+
+	var currentStream types.StreamViewType
+	if streamEnabled(table) {
+		currentStream = table.StreamSpecification.StreamViewType
+	}
+	if currentStream != spec.streamView {
+		diff.stream = &spec.streamView
+	}
+
+	var currentTTL string
+	if ttl != nil && (ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabled || ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabling) {
+		currentTTL = aws.ToString(ttl.AttributeName)
+	}
+	if currentTTL != spec.ttlAttribute {
+		diff.ttl = &spec.ttlAttribute
+	}
+	return diff
+}
+
+func sameIndex(spec *IndexSpec, index *types.GlobalSecondaryIndexDescription) bool {
+	if !sameKeySchema(index.KeySchema, keySchema(spec.partitionKey, spec.sortKey)) || index.Projection == nil {
+		return false
+	}
+	if index.Projection.ProjectionType != spec.projectionType {
+		return false
+	}
+	want, got := slices.Clone(spec.nonKeyAttributes), slices.Clone(index.Projection.NonKeyAttributes)
+	slices.Sort(want)
+	slices.Sort(got)
+	return slices.Equal(want, got)
+}
+
+type ApplyClient interface {
+	EnsureTableClient
+	EnsureTTLClient
+}
+
+// Apply makes the table match the spec, with the minimal calls: the table is created if it does not exist, otherwise
+// only what differs is updated. Indexes not in the spec, or with a different key schema or projection, are deleted
+// (and created again if in the spec), the stream and the TTL are enabled, changed or disabled as declared, and the
+// tags of the spec are set, while other tags are kept. It waits until the table and its indexes are active, but not
+// for a TTL being disabled, which DynamoDB can take an hour to finish.
+//
+// DynamoDB creates or deletes a single index per update, so the changes are applied one at a time: a migration
+// interrupted halfway can be run again, picking up where it stopped. Backfilling an index on a big table can take
+// hours, the timeout set by WithTimeout should be set accordingly. If the table exists with a different key schema, it
+// returns an ErrKeySchemaMismatch, as the key schema cannot be changed.
+func Apply(ctx context.Context, client ApplyClient, spec *TableSpec, options ...Option) error {
+	o := newOpts(options)
+	if err := spec.validate(); err != nil {
+		return err
+	}
+
+	table, err := describeTable(ctx, client, spec.name)
+	if err != nil {
+		return err
+	}
+	if table == nil {
+		_, err := client.CreateTable(ctx, spec.CreateTableInput())
+		var resourceInUseException *types.ResourceInUseException
+		if err != nil && !errors.As(err, &resourceInUseException) {
+			return fmt.Errorf("failed to create table %s: %w", spec.name, err)
+		}
+	}
+	table, err = waitTable(ctx, client, spec.name, o, indexesReady)
+	if err != nil {
+		return err
+	}
+	if !sameKeySchema(table.KeySchema, keySchema(spec.partitionKey, spec.sortKey)) {
+		return fmt.Errorf("%w: %s", ErrKeySchemaMismatch, spec.name)
+	}
+
+	ttl, err := describeTimeToLive(ctx, client, spec.name)
+	if err != nil {
+		return err
+	}
+	diff := diffTable(spec, table, ttl)
+
+	if diff.capacity != nil {
+		if err := UpdateCapacity(ctx, client, spec.name, *diff.capacity, options...); err != nil {
+			return err
+		}
+	}
+	for _, indexName := range diff.deleteIndexes {
+		if err := updateTable(ctx, client, o, &dynamodb.UpdateTableInput{
+			TableName:                   aws.String(spec.name),
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{Delete: &types.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(indexName)}}},
+		}); err != nil {
+			return fmt.Errorf("failed to delete index %s of table %s: %w", indexName, spec.name, err)
+		}
+	}
+	for _, index := range diff.createIndexes {
+		if err := updateTable(ctx, client, o, &dynamodb.UpdateTableInput{
+			TableName:                   aws.String(spec.name),
+			AttributeDefinitions:        spec.attributeDefinitions(),
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{Create: spec.createIndexAction(index)}},
+		}); err != nil {
+			return fmt.Errorf("failed to create index %s of table %s: %w", index.name, spec.name, err)
+		}
+	}
+	if diff.stream != nil {
+		if streamEnabled(table) {
+			if err := updateTable(ctx, client, o, &dynamodb.UpdateTableInput{
+				TableName:           aws.String(spec.name),
+				StreamSpecification: &types.StreamSpecification{StreamEnabled: aws.Bool(false)},
+			}); err != nil {
+				return fmt.Errorf("failed to disable the stream of table %s: %w", spec.name, err)
+			}
+		}
+		if *diff.stream != "" {
+			if err := updateTable(ctx, client, o, &dynamodb.UpdateTableInput{
+				TableName:           aws.String(spec.name),
+				StreamSpecification: &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: *diff.stream},
+			}); err != nil {
+				return fmt.Errorf("failed to enable the stream of table %s: %w", spec.name, err)
+			}
+		}
+	}
+	if diff.ttl != nil {
+		if *diff.ttl == "" {
+			if err := disableTTL(ctx, client, spec.name, ttl); err != nil {
+				return err
+			}
+		} else if err := EnsureTTL(ctx, client, spec.name, *diff.ttl, options...); err != nil {
+			return err
+		}
+	}
+	return reconcileTags(ctx, client, table, spec.tags)
+}
+
+// describeTable describes the table, returning nil if it does not exist.
+func describeTable(ctx context.Context, client DescribeTableClient, tableName string) (*types.TableDescription, error) {
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	var resourceNotFoundException *types.ResourceNotFoundException
+	switch {
+	case errors.As(err, &resourceNotFoundException):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+	return output.Table, nil
+}
+
+// updateTable updates the table and waits until it and its indexes are active again.
+func updateTable(ctx context.Context, client EnsureGSIClient, options opts, input *dynamodb.UpdateTableInput) error {
+	if _, err := client.UpdateTable(ctx, input); err != nil {
+		return err
+	}
+	_, err := waitTable(ctx, client, aws.ToString(input.TableName), options, indexesReady)
+	return err
+}
+
+// indexesReady tells whether the table is active and its indexes are active and finished backfilling.
+func indexesReady(table *types.TableDescription) bool {
+	if !tableActive(table) {
+		return false
+	}
+	return !slices.ContainsFunc(table.GlobalSecondaryIndexes, func(index types.GlobalSecondaryIndexDescription) bool {
+		return aws.ToBool(index.Backfilling)
+	})
+}
+
+func disableTTL(ctx context.Context, client EnsureTTLClient, tableName string, ttl *types.TimeToLiveDescription) error {
+	_, err := client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: ttl.AttributeName,
+			Enabled:       aws.Bool(false),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable the TTL of table %s: %w", tableName, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Apply", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	usersSpec := func() *TableSpec {
+		return NewTableSpec("users").
+			PartitionKey("pk", types.ScalarAttributeTypeS).
+			SortKey("sk", types.ScalarAttributeTypeS).
+			GSI(NewIndexSpec("by-email").PartitionKey("email", types.ScalarAttributeTypeS).KeysOnly()).
+			TTL("expires_at").
+			Stream(types.StreamViewTypeNewAndOldImages).
+			Tag("team", "accounts")
+	}
+
+	apply := func(spec *TableSpec) error {
+		return Apply(ctx, client, spec, WithPollInterval(time.Millisecond))
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		client.pending = 1
+	})
+
+	It("should create the table of the spec", func() {
+		Expect(apply(usersSpec())).To(Succeed())
+
+		table := client.tables["users"]
+		Expect(table.description.TableStatus).To(Equal(types.TableStatusActive))
+		Expect(table.description.KeySchema).To(HaveLen(2))
+		Expect(table.description.BillingModeSummary.BillingMode).To(Equal(types.BillingModePayPerRequest))
+		Expect(table.description.GlobalSecondaryIndexes).To(HaveLen(1))
+		Expect(table.description.GlobalSecondaryIndexes[0].Projection.ProjectionType).To(Equal(types.ProjectionTypeKeysOnly))
+		Expect(table.description.StreamSpecification.StreamViewType).To(Equal(types.StreamViewTypeNewAndOldImages))
+		Expect(table.ttl.TimeToLiveStatus).To(Equal(types.TimeToLiveStatusEnabled))
+		Expect(aws.ToString(table.ttl.AttributeName)).To(Equal("expires_at"))
+		Expect(table.tags).To(HaveKeyWithValue("team", "accounts"))
+	})
+
+	It("should do nothing when the table matches the spec", func() {
+		Expect(apply(usersSpec())).To(Succeed())
+		client.calls = nil
+
+		Expect(apply(usersSpec())).To(Succeed())
+		Expect(client.calls).To(ConsistOf("DescribeTable", "DescribeTable", "DescribeTimeToLive", "ListTagsOfResource"))
+	})
+
+	It("should update only what differs from the spec", func() {
+		Expect(apply(usersSpec())).To(Succeed())
+		client.calls = nil
+
+		spec := NewTableSpec("users").
+			PartitionKey("pk", types.ScalarAttributeTypeS).
+			SortKey("sk", types.ScalarAttributeTypeS).
+			Provisioned(5, 5).
+			GSI(NewIndexSpec("by-email").PartitionKey("email", types.ScalarAttributeTypeS).Include("name")).
+			GSI(NewIndexSpec("by-team").PartitionKey("team", types.ScalarAttributeTypeS).SortKey("sk", types.ScalarAttributeTypeS)).
+			Stream(types.StreamViewTypeKeysOnly)
+		Expect(apply(spec)).To(Succeed())
+
+		table := client.tables["users"]
+		Expect(table.description.BillingModeSummary.BillingMode).To(Equal(types.BillingModeProvisioned))
+		Expect(aws.ToInt64(table.description.ProvisionedThroughput.ReadCapacityUnits)).To(Equal(int64(5)))
+		indexes := map[string]types.ProjectionType{}
+		for _, index := range table.description.GlobalSecondaryIndexes {
+			indexes[aws.ToString(index.IndexName)] = index.Projection.ProjectionType
+		}
+		Expect(indexes).To(Equal(map[string]types.ProjectionType{
+			"by-email": types.ProjectionTypeInclude,
+			"by-team":  types.ProjectionTypeAll,
+		}))
+		Expect(table.description.StreamSpecification.StreamViewType).To(Equal(types.StreamViewTypeKeysOnly))
+		Expect(table.ttl.TimeToLiveStatus).To(Equal(types.TimeToLiveStatusDisabling))
+		// Tags not in the spec are kept.
+		Expect(table.tags).To(HaveKeyWithValue("team", "accounts"))
+		Expect(client.calls).ToNot(ContainElement("CreateTable"))
+	})
+
+	It("should delete the indexes not in the spec", func() {
+		Expect(apply(usersSpec())).To(Succeed())
+
+		spec := NewTableSpec("users").PartitionKey("pk", types.ScalarAttributeTypeS).SortKey("sk", types.ScalarAttributeTypeS)
+		Expect(apply(spec)).To(Succeed())
+
+		table := client.tables["users"]
+		Expect(table.description.GlobalSecondaryIndexes).To(BeEmpty())
+		Expect(table.description.StreamSpecification).To(BeNil())
+	})
+
+	It("should fail with ErrKeySchemaMismatch when the table has another key schema", func() {
+		Expect(apply(usersSpec())).To(Succeed())
+
+		err := apply(NewTableSpec("users").PartitionKey("id", types.ScalarAttributeTypeS))
+		Expect(err).To(MatchError(ErrKeySchemaMismatch))
+	})
+
+	DescribeTable("should fail with ErrInvalidSpec", func(spec *TableSpec) {
+		Expect(apply(spec)).To(MatchError(ErrInvalidSpec))
+		Expect(client.calls).To(BeEmpty())
+	},
+		Entry("without partition key", NewTableSpec("users")),
+		Entry("with an index without partition key", NewTableSpec("users").PartitionKey("pk", types.ScalarAttributeTypeS).GSI(NewIndexSpec("by-email"))),
+		Entry("with duplicate indexes", NewTableSpec("users").PartitionKey("pk", types.ScalarAttributeTypeS).
+			GSI(NewIndexSpec("by-email").PartitionKey("email", types.ScalarAttributeTypeS)).
+			GSI(NewIndexSpec("by-email").PartitionKey("email", types.ScalarAttributeTypeS))),
+		Entry("with conflicting attribute types", NewTableSpec("users").PartitionKey("pk", types.ScalarAttributeTypeS).
+			GSI(NewIndexSpec("by-pk").PartitionKey("pk", types.ScalarAttributeTypeN))),
+		Entry("provisioned without capacity", NewTableSpec("users").PartitionKey("pk", types.ScalarAttributeTypeS).Provisioned(0, 0)),
+	)
+})
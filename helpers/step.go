@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStepCancelled is returned by a step wrapped by WrapStep when it did not finish before its timeout, or before the
+// context was cancelled.
+var ErrStepCancelled = errors.New("step cancelled")
+
+// Step is a step of a migration, such as one of the helpers with its arguments bound.
+type Step func(ctx context.Context) error
+
+// WrapStep returns a wrapper bounding a step by the timeout, so a step waiting for DynamoDB forever, as a hung
+// UpdateTable, does not block the deploy running the migrations. A timeout of 0 or less does not bound the step, it is
+// only cancelled with its context.
+//
+// When the step is cancelled, the wrapped step returns right away, even if the step does not stop on the cancellation
+// of its context, and runs onCancel, if not nil, to compensate what the step did, e.g. to remove an index half created.
+// onCancel is given a context that is not cancelled with the one of the step, bounded by the timeout again. The wrapped
+// step then returns an ErrStepCancelled, along with the error of onCancel, if any.
+func WrapStep(timeout time.Duration, onCancel func(ctx context.Context) error) func(Step) Step {
+	return func(step Step) Step {
+		return func(ctx context.Context) error {
+			stepCtx, cancel := withStepTimeout(ctx, timeout)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- step(stepCtx)
+			}()
+
+			var err error
+			select {
+			case err = <-done:
+				if err == nil || stepCtx.Err() == nil {
+					return err
+				}
+			case <-stepCtx.Done():
+				err = stepCtx.Err()
+			}
+
+			err = fmt.Errorf("%w: %w", ErrStepCancelled, err)
+			if onCancel == nil {
+				return err
+			}
+			cancelCtx, cancelCancel := withStepTimeout(context.WithoutCancel(ctx), timeout)
+			defer cancelCancel()
+			if cancelErr := onCancel(cancelCtx); cancelErr != nil {
+				return errors.Join(err, fmt.Errorf("failed to compensate the cancelled step: %w", cancelErr))
+			}
+			return err
+		}
+	}
+}
+
+func withStepTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// CheckpointStep returns a wrapper recording in the store that the step of the migration completed, and skipping it
+// when it was completed before. So a migration composed of several steps can be run again after being interrupted,
+// e.g. by a crash, without doing again the steps it completed. The name must be unique in the migration, and should
+// not change once the migration was run.
+//
+// The undo of the migration should reset the steps with ResetStep, for running the migration again to do them again.
+func CheckpointStep(store StepStore, migrationID, name string) func(Step) Step {
+	return func(step Step) Step {
+		return func(ctx context.Context) error {
+			completed, err := store.StepCompleted(ctx, migrationID, name)
+			if err != nil {
+				return fmt.Errorf("failed to load step %s of migration %s: %w", name, migrationID, err)
+			}
+			if completed {
+				return nil
+			}
+			if err := step(ctx); err != nil {
+				return err
+			}
+			if err := store.CompleteStep(ctx, migrationID, name); err != nil {
+				return fmt.Errorf("failed to complete step %s of migration %s: %w", name, migrationID, err)
+			}
+			return nil
+		}
+	}
+}
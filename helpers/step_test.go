@@ -0,0 +1,160 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WrapStep", func() {
+	var (
+		ctx       context.Context
+		cancelled int
+		onCancel  func(ctx context.Context) error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		cancelled = 0
+		onCancel = func(ctx context.Context) error {
+			Expect(ctx.Err()).ToNot(HaveOccurred())
+			cancelled++
+			return nil
+		}
+	})
+
+	It("should return the result of a step finishing in time", func() {
+		errStep := errors.New("step failed")
+
+		Expect(WrapStep(time.Second, onCancel)(func(ctx context.Context) error {
+			return nil
+		})(ctx)).To(Succeed())
+		Expect(WrapStep(time.Second, onCancel)(func(ctx context.Context) error {
+			return errStep
+		})(ctx)).To(MatchError(errStep))
+		Expect(cancelled).To(BeZero())
+	})
+
+	It("should cancel the step after the timeout and compensate it", func() {
+		err := WrapStep(10*time.Millisecond, onCancel)(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})(ctx)
+
+		Expect(err).To(MatchError(ErrStepCancelled))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(cancelled).To(Equal(1))
+	})
+
+	It("should return when a step ignoring the cancellation hangs", func() {
+		hung := make(chan struct{})
+		defer close(hung)
+
+		err := WrapStep(10*time.Millisecond, onCancel)(func(ctx context.Context) error {
+			<-hung
+			return nil
+		})(ctx)
+
+		Expect(err).To(MatchError(ErrStepCancelled))
+		Expect(cancelled).To(Equal(1))
+	})
+
+	It("should cancel the step with its context", func() {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := WrapStep(0, onCancel)(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})(ctx)
+
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(cancelled).To(Equal(1))
+	})
+
+	It("should return the error of the compensation", func() {
+		errCompensation := errors.New("compensation failed")
+
+		err := WrapStep(10*time.Millisecond, func(ctx context.Context) error {
+			return errCompensation
+		})(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})(ctx)
+
+		Expect(err).To(MatchError(ErrStepCancelled))
+		Expect(err).To(MatchError(errCompensation))
+	})
+})
+
+var _ = Describe("CheckpointStep", func() {
+	var (
+		ctx   context.Context
+		store *TableCheckpointStore
+		runs  map[string]int
+	)
+
+	// step counts its runs, failing with err if not nil.
+	step := func(name string, err *error) Step {
+		return func(ctx context.Context) error {
+			runs[name]++
+			return *err
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client := newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName:            aws.String("progress"),
+			BillingMode:          types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}},
+		})).To(Succeed())
+		store = NewTableCheckpointStore(client, "progress")
+		runs = map[string]int{}
+	})
+
+	It("should skip the steps completed by a previous run", func() {
+		var noErr error
+		errCrash := errors.New("crashed")
+		steps := []Step{
+			CheckpointStep(store, "0001_users", "create")(step("create", &noErr)),
+			CheckpointStep(store, "0001_users", "backfill")(step("backfill", &errCrash)),
+		}
+		run := func() error {
+			for _, step := range steps {
+				if err := step(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		Expect(run()).To(MatchError(errCrash))
+		errCrash = nil
+		Expect(run()).To(Succeed())
+		Expect(run()).To(Succeed())
+
+		Expect(runs).To(Equal(map[string]int{"create": 1, "backfill": 2}))
+		Expect(store.StepCompleted(ctx, "0001_users", "backfill")).To(BeTrue())
+		Expect(store.StepCompleted(ctx, "0002_orders", "backfill")).To(BeFalse())
+	})
+
+	It("should run a step again after it is reset", func() {
+		var noErr error
+		create := CheckpointStep(store, "0001_users", "create")(step("create", &noErr))
+
+		Expect(create(ctx)).To(Succeed())
+		Expect(store.ResetStep(ctx, "0001_users", "create")).To(Succeed())
+		Expect(create(ctx)).To(Succeed())
+
+		Expect(runs["create"]).To(Equal(2))
+	})
+})
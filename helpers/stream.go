@@ -0,0 +1,498 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+var (
+	// ErrStreamNotEnabled is returned by Replicate when the stream of the source table is not enabled. Enable it with
+	// EnableStream.
+	ErrStreamNotEnabled = errors.New("stream not enabled")
+
+	// ErrStreamViewType is returned by EnableStream and Replicate when the stream of the table does not have both the
+	// new and the old images of the items, which the replication needs. The view type of an enabled stream cannot be
+	// changed: disable it and enable it again.
+	ErrStreamViewType = errors.New("stream does not have the new and old images")
+
+	// errReplicationStopped is the cause of the replications stopped by Stop.
+	errReplicationStopped = errors.New("replication stopped")
+)
+
+// EnableStream enables the stream of the table, with the new and old images of the items, and waits until the table
+// is active again. It returns the ARN of the stream. Nothing is done if the stream is already enabled.
+func EnableStream(ctx context.Context, client UpdateCapacityClient, tableName string, options ...Option) (string, error) {
+	o := newOpts(options)
+
+	table, err := waitTable(ctx, client, tableName, o, tableActive)
+	if err != nil {
+		return "", err
+	}
+	if streamEnabled(table) {
+		if table.StreamSpecification.StreamViewType != types.StreamViewTypeNewAndOldImages {
+			return "", fmt.Errorf("%w: table %s has a stream of %s", ErrStreamViewType, tableName, table.StreamSpecification.StreamViewType)
+		}
+		return aws.ToString(table.LatestStreamArn), nil
+	}
+
+	_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String(tableName),
+		StreamSpecification: &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: types.StreamViewTypeNewAndOldImages,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to enable the stream of table %s: %w", tableName, err)
+	}
+
+	table, err = waitTable(ctx, client, tableName, o, tableActive)
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(table.LatestStreamArn), nil
+}
+
+// DisableStream disables the stream of the table, and waits until the table is active again. Nothing is done if the
+// stream is not enabled.
+func DisableStream(ctx context.Context, client UpdateCapacityClient, tableName string, options ...Option) error {
+	o := newOpts(options)
+
+	table, err := waitTable(ctx, client, tableName, o, tableActive)
+	if err != nil {
+		return err
+	}
+	if !streamEnabled(table) {
+		return nil
+	}
+
+	_, err = client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String(tableName),
+		StreamSpecification: &types.StreamSpecification{
+			StreamEnabled: aws.Bool(false),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable the stream of table %s: %w", tableName, err)
+	}
+
+	_, err = waitTable(ctx, client, tableName, o, tableActive)
+	return err
+}
+
+func streamEnabled(table *types.TableDescription) bool {
+	return table.StreamSpecification != nil && aws.ToBool(table.StreamSpecification.StreamEnabled)
+}
+
+type ReplicationClient interface {
+	DescribeTableClient
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+type StreamsClient interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
+// ReplicationStats counts the changes a Replication applied to the target table.
+type ReplicationStats struct {
+	// Written is the number of items inserted or modified in the source table and written to the target table.
+	Written int64
+	// Deleted is the number of items removed from the source table and deleted from the target table.
+	Deleted int64
+	// Skipped is the number of changes the transform returned nil for, or that did not change the item.
+	Skipped int64
+	// LastChangeAt is when the last change applied was made in the source table. Comparing it to the current time
+	// tells how far behind the replication is.
+	LastChangeAt time.Time
+}
+
+// Replication copies the changes made to a table to another table, or to the items of the same table, while a
+// migration moves from one to the other. It is started by Replicate and runs until Stop is called, its context is
+// done or it fails.
+type Replication struct {
+	client          ReplicationClient
+	streams         StreamsClient
+	sourceTableName string
+	targetTableName string
+	streamARN       string
+	targetKeys      []string
+	transform       TransformFunc
+	limiter         *throttle.RateLimiter
+	options         opts
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	shards map[string]*shardState
+	stats  ReplicationStats
+}
+
+type shardState struct {
+	finished bool
+}
+
+// Replicate starts replicating the changes read from the stream of the source table to the target table, so the
+// application can keep writing to the source table until it is moved to the target table. The items inserted and
+// modified are written to the target table as returned by transform (as they are, if transform is nil), and the items
+// removed are deleted from it. The records of the stream are read from the oldest one: so, enable the stream with
+// EnableStream, copy the table with CopyTable, and call Replicate afterwards, for the changes made during the copy to
+// be applied on top of it. The changes of an item are applied in order.
+//
+// The source and target tables can be the same, to move the items to a new layout: the items the transform leaves
+// unchanged are then skipped, so the writes of the replication do not replicate themselves forever.
+//
+// The stream is polled at the interval set by WithPollInterval, and the writes share the budget set by
+// WithWriteCapacity.
+func Replicate(ctx context.Context, client ReplicationClient, streams StreamsClient, sourceTableName, targetTableName string, transform TransformFunc, options ...Option) (*Replication, error) {
+	o := newOpts(options)
+
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(sourceTableName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s: %w", sourceTableName, err)
+	}
+	if !streamEnabled(output.Table) {
+		return nil, fmt.Errorf("%w: table %s", ErrStreamNotEnabled, sourceTableName)
+	}
+	if output.Table.StreamSpecification.StreamViewType != types.StreamViewTypeNewAndOldImages {
+		return nil, fmt.Errorf("%w: table %s has a stream of %s", ErrStreamViewType, sourceTableName, output.Table.StreamSpecification.StreamViewType)
+	}
+	target, err := waitTable(ctx, client, targetTableName, o, tableActive)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replication{
+		client:          client,
+		streams:         streams,
+		sourceTableName: sourceTableName,
+		targetTableName: targetTableName,
+		streamARN:       aws.ToString(output.Table.LatestStreamArn),
+		targetKeys:      keyNames(target.KeySchema),
+		transform:       transform,
+		limiter:         throttle.NewRateLimiter(nil, o.writeCapacity),
+		options:         o,
+		done:            make(chan struct{}),
+		shards:          map[string]*shardState{},
+	}
+	r.ctx, r.cancel = context.WithCancelCause(ctx)
+	go r.run()
+	return r, nil
+}
+
+// Stop stops the replication and waits for it to finish the changes being applied. It returns the error that made the
+// replication fail, if any.
+func (r *Replication) Stop() error {
+	r.cancel(errReplicationStopped)
+	<-r.done
+	return r.Err()
+}
+
+// Done is closed when the replication stops.
+func (r *Replication) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns the error that stopped the replication, or nil if it is running or was stopped by Stop.
+func (r *Replication) Err() error {
+	select {
+	case <-r.done:
+	default:
+		return nil
+	}
+	if err := context.Cause(r.ctx); !errors.Is(err, errReplicationStopped) {
+		return err
+	}
+	return nil
+}
+
+// Stats returns the changes applied so far.
+func (r *Replication) Stats() ReplicationStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// run discovers the shards of the stream, every poll interval, and starts reading them. The shards split from a parent
+// shard are read after the parent is finished, so the changes of an item are applied in order.
+func (r *Replication) run() {
+	var wg sync.WaitGroup
+	defer close(r.done)
+	defer wg.Wait()
+
+	for {
+		shards, err := r.describeShards(r.ctx)
+		if err != nil {
+			r.cancel(err)
+			return
+		}
+
+		r.mu.Lock()
+		for _, shard := range shards {
+			id := aws.ToString(shard.ShardId)
+			if _, ok := r.shards[id]; ok {
+				continue
+			}
+			// A parent no longer in the stream was trimmed, so its records are gone.
+			if parent, ok := r.shards[aws.ToString(shard.ParentShardId)]; ok && !parent.finished {
+				continue
+			} else if !ok && containsShard(shards, shard.ParentShardId) {
+				continue
+			}
+			state := &shardState{}
+			r.shards[id] = state
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := r.readShard(r.ctx, id); err != nil {
+					r.cancel(err)
+					return
+				}
+				r.mu.Lock()
+				state.finished = true
+				r.mu.Unlock()
+			}()
+		}
+		r.mu.Unlock()
+
+		if err := throttle.Sleep(r.ctx, nil, r.options.pollInterval); err != nil {
+			return
+		}
+	}
+}
+
+func containsShard(shards []streamstypes.Shard, id *string) bool {
+	for _, shard := range shards {
+		if aws.ToString(shard.ShardId) == aws.ToString(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeShards lists all shards of the stream.
+func (r *Replication) describeShards(ctx context.Context) ([]streamstypes.Shard, error) {
+	var (
+		shards       []streamstypes.Shard
+		startShardID *string
+	)
+	for {
+		output, err := r.streams.DescribeStream(ctx, &dynamodbstreams.DescribeStreamInput{
+			StreamArn:             aws.String(r.streamARN),
+			ExclusiveStartShardId: startShardID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stream %s: %w", r.streamARN, err)
+		}
+		shards = append(shards, output.StreamDescription.Shards...)
+		if output.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, nil
+		}
+		startShardID = output.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// readShard applies the records of the shard, from the oldest one, until the shard is closed.
+func (r *Replication) readShard(ctx context.Context, shardID string) error {
+	var (
+		backoff        throttle.Backoff
+		lastSequence   *string
+		shardIterator  *string
+		expiredIterErr *streamstypes.ExpiredIteratorException
+	)
+	for {
+		if shardIterator == nil {
+			input := &dynamodbstreams.GetShardIteratorInput{
+				StreamArn:         aws.String(r.streamARN),
+				ShardId:           aws.String(shardID),
+				ShardIteratorType: streamstypes.ShardIteratorTypeTrimHorizon,
+			}
+			if lastSequence != nil {
+				input.ShardIteratorType = streamstypes.ShardIteratorTypeAfterSequenceNumber
+				input.SequenceNumber = lastSequence
+			}
+			output, err := r.streams.GetShardIterator(ctx, input)
+			if err != nil {
+				return fmt.Errorf("failed to get the iterator of shard %s: %w", shardID, err)
+			}
+			shardIterator = output.ShardIterator
+		}
+
+		if err := backoff.Wait(ctx); err != nil {
+			return nil
+		}
+		output, err := r.streams.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: shardIterator,
+		})
+		switch {
+		case errors.As(err, &expiredIterErr):
+			shardIterator = nil
+			continue
+		case isStreamThrottlingError(err) && backoff.Throttled():
+			continue
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to get the records of shard %s: %w", shardID, err)
+		}
+		backoff.Succeeded()
+
+		for _, record := range output.Records {
+			if err := r.apply(ctx, record, &backoff); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			lastSequence = record.Dynamodb.SequenceNumber
+		}
+
+		shardIterator = output.NextShardIterator
+		if shardIterator == nil {
+			return nil
+		}
+		if len(output.Records) == 0 {
+			if err := throttle.Sleep(ctx, nil, r.options.pollInterval); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// apply writes the change of the record to the target table.
+func (r *Replication) apply(ctx context.Context, record streamstypes.Record, backoff *throttle.Backoff) error {
+	newImage, err := attributevalue.FromDynamoDBStreamsMap(record.Dynamodb.NewImage)
+	if err != nil {
+		return fmt.Errorf("invalid record %s: %w", aws.ToString(record.EventID), err)
+	}
+	oldImage, err := attributevalue.FromDynamoDBStreamsMap(record.Dynamodb.OldImage)
+	if err != nil {
+		return fmt.Errorf("invalid record %s: %w", aws.ToString(record.EventID), err)
+	}
+
+	switch record.EventName {
+	case streamstypes.OperationTypeInsert, streamstypes.OperationTypeModify:
+		item, err := r.transformed(ctx, newImage)
+		if err != nil {
+			return err
+		}
+		if item == nil || (r.sourceTableName == r.targetTableName && reflect.DeepEqual(item, newImage)) {
+			r.count(record, func(stats *ReplicationStats) { stats.Skipped++ })
+			return nil
+		}
+		if err := r.write(ctx, backoff, func(ctx context.Context) (*types.ConsumedCapacity, error) {
+			output, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName:              aws.String(r.targetTableName),
+				Item:                   item,
+				ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.ConsumedCapacity, nil
+		}); err != nil {
+			return fmt.Errorf("failed to write item %s: %w", formatKey(itemKey(item, r.targetKeys)), err)
+		}
+		r.count(record, func(stats *ReplicationStats) { stats.Written++ })
+
+	case streamstypes.OperationTypeRemove:
+		item, err := r.transformed(ctx, oldImage)
+		if err != nil {
+			return err
+		}
+		if item == nil || r.sourceTableName == r.targetTableName {
+			// Removing an item of the source table removes it from the target table too when both are the same.
+			r.count(record, func(stats *ReplicationStats) { stats.Skipped++ })
+			return nil
+		}
+		key := itemKey(item, r.targetKeys)
+		if err := r.write(ctx, backoff, func(ctx context.Context) (*types.ConsumedCapacity, error) {
+			output, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+				TableName:              aws.String(r.targetTableName),
+				Key:                    key,
+				ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return output.ConsumedCapacity, nil
+		}); err != nil {
+			return fmt.Errorf("failed to delete item %s: %w", formatKey(key), err)
+		}
+		r.count(record, func(stats *ReplicationStats) { stats.Deleted++ })
+	}
+	return nil
+}
+
+func (r *Replication) transformed(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	if r.transform == nil {
+		return item, nil
+	}
+	newItem, err := r.transform(ctx, item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform item %s: %w", formatKey(item), err)
+	}
+	return newItem, nil
+}
+
+// write calls the request, waiting for the write capacity budget. Throttled requests slow down the shard and are
+// retried.
+func (r *Replication) write(ctx context.Context, backoff *throttle.Backoff, request func(ctx context.Context) (*types.ConsumedCapacity, error)) error {
+	for {
+		if err := backoff.Wait(ctx); err != nil {
+			return err
+		}
+		if err := r.limiter.Wait(ctx, 1); err != nil {
+			return err
+		}
+		capacity, err := request(ctx)
+		units := 1.0
+		if capacity != nil {
+			units = aws.ToFloat64(capacity.CapacityUnits)
+		}
+		r.limiter.Consumed(1, units)
+		if throttle.IsThrottlingError(err) && backoff.Throttled() {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		backoff.Succeeded()
+		return nil
+	}
+}
+
+// count updates the stats with the record applied.
+func (r *Replication) count(record streamstypes.Record, update func(stats *ReplicationStats)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	update(&r.stats)
+	if createdAt := aws.ToTime(record.Dynamodb.ApproximateCreationDateTime); createdAt.After(r.stats.LastChangeAt) {
+		r.stats.LastChangeAt = createdAt
+	}
+}
+
+// isStreamThrottlingError checks if the error was caused by the DynamoDB Streams throttling the request.
+func isStreamThrottlingError(err error) bool {
+	var limitExceededException *streamstypes.LimitExceededException
+	return errors.As(err, &limitExceededException) || throttle.IsThrottlingError(err)
+}
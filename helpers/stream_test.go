@@ -0,0 +1,301 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamstypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeStreams keeps the shards of a stream in memory. The iterators are the shard ID and the position of the next
+// record, and GetRecords returns up to two records at a time.
+type fakeStreams struct {
+	mu     sync.Mutex
+	shards []*fakeShard
+	// expire is the number of GetRecords calls failing with an expired iterator.
+	expire int
+}
+
+type fakeShard struct {
+	id      string
+	parent  string
+	records []streamstypes.Record
+	closed  bool
+}
+
+func (s *fakeStreams) shard(id string) *fakeShard {
+	for _, shard := range s.shards {
+		if shard.id == id {
+			return shard
+		}
+	}
+	return nil
+}
+
+// addRecord appends a change of the item with the given string attributes to the shard.
+func (s *fakeStreams) addRecord(shardID string, event streamstypes.OperationType, oldImage, newImage map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shard := s.shard(shardID)
+	toImage := func(values map[string]string) map[string]streamstypes.AttributeValue {
+		if values == nil {
+			return nil
+		}
+		image := map[string]streamstypes.AttributeValue{}
+		for name, value := range values {
+			image[name] = &streamstypes.AttributeValueMemberS{Value: value}
+		}
+		return image
+	}
+	sequence := fmt.Sprintf("%s-%03d", shardID, len(shard.records))
+	shard.records = append(shard.records, streamstypes.Record{
+		EventID:   aws.String(sequence),
+		EventName: event,
+		Dynamodb: &streamstypes.StreamRecord{
+			SequenceNumber:              aws.String(sequence),
+			ApproximateCreationDateTime: aws.Time(time.Now()),
+			OldImage:                    toImage(oldImage),
+			NewImage:                    toImage(newImage),
+		},
+	})
+}
+
+func (s *fakeStreams) DescribeStream(_ context.Context, input *dynamodbstreams.DescribeStreamInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	description := &streamstypes.StreamDescription{StreamArn: input.StreamArn}
+	for _, shard := range s.shards {
+		description.Shards = append(description.Shards, streamstypes.Shard{
+			ShardId:       aws.String(shard.id),
+			ParentShardId: aws.String(shard.parent),
+		})
+		if shard.parent == "" {
+			description.Shards[len(description.Shards)-1].ParentShardId = nil
+		}
+	}
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: description}, nil
+}
+
+func (s *fakeStreams) GetShardIterator(_ context.Context, input *dynamodbstreams.GetShardIteratorInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shard := s.shard(aws.ToString(input.ShardId))
+	position := 0
+	if input.ShardIteratorType == streamstypes.ShardIteratorTypeAfterSequenceNumber {
+		for i, record := range shard.records {
+			if aws.ToString(record.Dynamodb.SequenceNumber) == aws.ToString(input.SequenceNumber) {
+				position = i + 1
+			}
+		}
+	}
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String(fmt.Sprintf("%s:%d", shard.id, position))}, nil
+}
+
+func (s *fakeStreams) GetRecords(_ context.Context, input *dynamodbstreams.GetRecordsInput, _ ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expire > 0 {
+		s.expire--
+		return nil, &streamstypes.ExpiredIteratorException{Message: aws.String("Iterator expired")}
+	}
+	id, position, _ := strings.Cut(aws.ToString(input.ShardIterator), ":")
+	start, _ := strconv.Atoi(position)
+	shard := s.shard(id)
+	end := min(start+2, len(shard.records))
+
+	output := &dynamodbstreams.GetRecordsOutput{Records: shard.records[start:end]}
+	if !shard.closed || end < len(shard.records) {
+		output.NextShardIterator = aws.String(fmt.Sprintf("%s:%d", id, end))
+	}
+	return output, nil
+}
+
+var _ = Describe("Stream", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	createTable := func(name string, stream *types.StreamSpecification) {
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String(name),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+		if stream != nil {
+			_, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{TableName: aws.String(name), StreamSpecification: stream})
+			Expect(err).ToNot(HaveOccurred())
+		}
+	}
+
+	key := func(pk string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: pk}}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+	})
+
+	Describe("EnableStream", func() {
+		It("should enable the stream with the new and old images", func() {
+			createTable("users", nil)
+
+			arn, err := EnableStream(ctx, client, "users", WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+
+			table := client.tables["users"].description
+			Expect(arn).ToNot(BeEmpty())
+			Expect(arn).To(Equal(aws.ToString(table.LatestStreamArn)))
+			Expect(table.TableStatus).To(Equal(types.TableStatusActive))
+			Expect(table.StreamSpecification.StreamViewType).To(Equal(types.StreamViewTypeNewAndOldImages))
+		})
+
+		It("should do nothing when the stream is already enabled", func() {
+			createTable("users", &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: types.StreamViewTypeNewAndOldImages})
+			client.calls = nil
+
+			arn, err := EnableStream(ctx, client, "users", WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arn).To(Equal(aws.ToString(client.tables["users"].description.LatestStreamArn)))
+			Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+		})
+
+		It("should fail with ErrStreamViewType when the stream does not have both images", func() {
+			createTable("users", &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: types.StreamViewTypeKeysOnly})
+
+			_, err := EnableStream(ctx, client, "users", WithPollInterval(time.Millisecond))
+			Expect(err).To(MatchError(ErrStreamViewType))
+		})
+	})
+
+	Describe("DisableStream", func() {
+		It("should disable the stream", func() {
+			createTable("users", &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: types.StreamViewTypeNewAndOldImages})
+
+			Expect(DisableStream(ctx, client, "users", WithPollInterval(time.Millisecond))).To(Succeed())
+			Expect(client.tables["users"].description.StreamSpecification).To(BeNil())
+
+			client.calls = nil
+			Expect(DisableStream(ctx, client, "users", WithPollInterval(time.Millisecond))).To(Succeed())
+			Expect(client.calls).ToNot(ContainElement("UpdateTable"))
+		})
+	})
+
+	Describe("Replicate", func() {
+		var streams *fakeStreams
+
+		// addVersion adds the version attribute to the items, to move them to the new layout.
+		addVersion := func(_ context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			newItem := maps.Clone(item)
+			newItem["version"] = &types.AttributeValueMemberN{Value: "2"}
+			return newItem, nil
+		}
+
+		BeforeEach(func() {
+			createTable("users", &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: types.StreamViewTypeNewAndOldImages})
+			createTable("users_v2", nil)
+			streams = &fakeStreams{shards: []*fakeShard{
+				{id: "shard-1", closed: true},
+				{id: "shard-2", parent: "shard-1"},
+			}}
+		})
+
+		It("should apply the changes of the source table to the target table in order", func() {
+			streams.addRecord("shard-1", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "a", "name": "Alice"})
+			streams.addRecord("shard-1", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "b", "name": "Bob"})
+			streams.addRecord("shard-1", streamstypes.OperationTypeModify, map[string]string{"pk": "a", "name": "Alice"}, map[string]string{"pk": "a", "name": "Alicia"})
+			streams.addRecord("shard-2", streamstypes.OperationTypeModify, map[string]string{"pk": "a", "name": "Alicia"}, map[string]string{"pk": "a", "name": "Ally"})
+			streams.addRecord("shard-2", streamstypes.OperationTypeRemove, map[string]string{"pk": "b", "name": "Bob"}, nil)
+
+			replication, err := Replicate(ctx, client, streams, "users", "users_v2", addVersion, WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(replication.Stats).Should(HaveField("Deleted", int64(1)))
+
+			// A change made while replicating.
+			streams.addRecord("shard-2", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "c", "name": "Carol"})
+			Eventually(replication.Stats).Should(HaveField("Written", int64(5)))
+			Expect(replication.Stop()).To(Succeed())
+
+			Expect(client.tables["users_v2"].items).To(HaveLen(2))
+			Expect(client.item("users_v2", key("a"))).To(Equal(map[string]types.AttributeValue{
+				"pk":      &types.AttributeValueMemberS{Value: "a"},
+				"name":    &types.AttributeValueMemberS{Value: "Ally"},
+				"version": &types.AttributeValueMemberN{Value: "2"},
+			}))
+			Expect(client.item("users_v2", key("c"))).ToNot(BeNil())
+			Expect(replication.Stats().LastChangeAt).ToNot(BeZero())
+		})
+
+		It("should skip the changes it writes itself when replicating to the same table", func() {
+			streams.addRecord("shard-1", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "a", "name": "Alice"})
+			// The change written by the replication itself.
+			streams.addRecord("shard-1", streamstypes.OperationTypeModify, map[string]string{"pk": "a", "name": "Alice"}, map[string]string{"pk": "a", "name": "Alice", "version": "2"})
+
+			replication, err := Replicate(ctx, client, streams, "users", "users", func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+				newItem := maps.Clone(item)
+				newItem["version"] = &types.AttributeValueMemberS{Value: "2"}
+				return newItem, nil
+			}, WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(replication.Stats).Should(And(HaveField("Written", int64(1)), HaveField("Skipped", int64(1))))
+			Expect(replication.Stop()).To(Succeed())
+
+			Expect(client.item("users", key("a"))).To(HaveKeyWithValue("version", &types.AttributeValueMemberS{Value: "2"}))
+		})
+
+		It("should resume after the last record applied when the iterator expires", func() {
+			streams.addRecord("shard-1", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "a"})
+			streams.addRecord("shard-1", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "b"})
+			streams.addRecord("shard-1", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "c"})
+			streams.expire = 1
+
+			replication, err := Replicate(ctx, client, streams, "users", "users_v2", nil, WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(replication.Stats).Should(HaveField("Written", int64(3)))
+			Consistently(replication.Stats, 20*time.Millisecond).Should(HaveField("Written", int64(3)))
+			Expect(replication.Stop()).To(Succeed())
+			Expect(client.tables["users_v2"].items).To(HaveLen(3))
+		})
+
+		It("should stop with the error of the transform", func() {
+			streams.addRecord("shard-1", streamstypes.OperationTypeInsert, nil, map[string]string{"pk": "a"})
+			errTransform := errors.New("transform failed")
+
+			replication, err := Replicate(ctx, client, streams, "users", "users_v2", func(context.Context, map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+				return nil, errTransform
+			}, WithPollInterval(time.Millisecond))
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(replication.Done()).Should(BeClosed())
+			Expect(replication.Err()).To(MatchError(errTransform))
+			Expect(replication.Stop()).To(MatchError(errTransform))
+		})
+
+		It("should fail with ErrStreamNotEnabled when the source table has no stream", func() {
+			_, err := Replicate(ctx, client, streams, "users_v2", "users", nil)
+			Expect(err).To(MatchError(ErrStreamNotEnabled))
+		})
+	})
+})
@@ -0,0 +1,184 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrKeySchemaMismatch is returned by EnsureTable when the table exists with a different key schema. The key schema
+	// of a table cannot be changed, the table must be recreated.
+	ErrKeySchemaMismatch = errors.New("table exists with a different key schema")
+
+	// ErrBillingModeMismatch is returned by EnsureTable when the table exists with a different billing mode. The
+	// billing mode can be switched with UpdateCapacity.
+	ErrBillingModeMismatch = errors.New("table exists with a different billing mode")
+)
+
+type ListTagsClient interface {
+	ListTagsOfResource(ctx context.Context, params *dynamodb.ListTagsOfResourceInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTagsOfResourceOutput, error)
+}
+
+type EnsureTableClient interface {
+	DescribeTableClient
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+	ListTagsClient
+	TagResource(ctx context.Context, params *dynamodb.TagResourceInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TagResourceOutput, error)
+}
+
+// EnsureTable makes sure the table described by input exists and waits until it is active. If the table does not
+// exist, it is created. If it exists, its provisioned throughput and tags are updated to the ones in the input: tags
+// missing or with a different value are set, while other tags are kept.
+//
+// The key schema and the billing mode of an existing table are not changed: if they differ from the input, it returns
+// an ErrKeySchemaMismatch or an ErrBillingModeMismatch. Indexes, streams and the other settings are not compared.
+func EnsureTable(ctx context.Context, client EnsureTableClient, input *dynamodb.CreateTableInput, options ...Option) error {
+	o := newOpts(options)
+	tableName := aws.ToString(input.TableName)
+
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: input.TableName,
+	})
+	var (
+		table                     *types.TableDescription
+		resourceNotFoundException *types.ResourceNotFoundException
+	)
+	switch {
+	case err == nil:
+		table = output.Table
+	case errors.As(err, &resourceNotFoundException):
+		_, err = client.CreateTable(ctx, input)
+		var resourceInUseException *types.ResourceInUseException
+		if err != nil && !errors.As(err, &resourceInUseException) {
+			return fmt.Errorf("failed to create table %s: %w", tableName, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to describe table %s: %w", tableName, err)
+	}
+
+	if table == nil || !tableActive(table) {
+		table, err = waitTable(ctx, client, tableName, o, tableActive)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := checkKeySchema(table, input); err != nil {
+		return err
+	}
+	updated, err := reconcileThroughput(ctx, client, table, input)
+	if err != nil {
+		return err
+	}
+	if err := reconcileTags(ctx, client, table, input.Tags); err != nil {
+		return err
+	}
+	if !updated {
+		return nil
+	}
+
+	_, err = waitTable(ctx, client, tableName, o, tableActive)
+	return err
+}
+
+func checkKeySchema(table *types.TableDescription, input *dynamodb.CreateTableInput) error {
+	if !sameKeySchema(table.KeySchema, input.KeySchema) {
+		return fmt.Errorf("%w: %s", ErrKeySchemaMismatch, aws.ToString(input.TableName))
+	}
+	return nil
+}
+
+// billingMode returns the billing mode of the table. Tables created with provisioned capacity may have no billing mode
+// summary.
+func billingMode(table *types.TableDescription) types.BillingMode {
+	if table.BillingModeSummary == nil || table.BillingModeSummary.BillingMode == "" {
+		return types.BillingModeProvisioned
+	}
+	return table.BillingModeSummary.BillingMode
+}
+
+// reconcileThroughput updates the provisioned throughput of the table to the one of the input. It returns whether the
+// table was updated.
+func reconcileThroughput(ctx context.Context, client EnsureTableClient, table *types.TableDescription, input *dynamodb.CreateTableInput) (bool, error) {
+	want := input.BillingMode
+	if want == "" {
+		want = types.BillingModeProvisioned
+	}
+	if got := billingMode(table); got != want {
+		return false, fmt.Errorf("%w: %s is %s instead of %s", ErrBillingModeMismatch, aws.ToString(input.TableName), got, want)
+	}
+	if want != types.BillingModeProvisioned || input.ProvisionedThroughput == nil || table.ProvisionedThroughput == nil {
+		return false, nil
+	}
+
+	if aws.ToInt64(table.ProvisionedThroughput.ReadCapacityUnits) == aws.ToInt64(input.ProvisionedThroughput.ReadCapacityUnits) &&
+		aws.ToInt64(table.ProvisionedThroughput.WriteCapacityUnits) == aws.ToInt64(input.ProvisionedThroughput.WriteCapacityUnits) {
+		return false, nil
+	}
+	_, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:             input.TableName,
+		ProvisionedThroughput: input.ProvisionedThroughput,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update the throughput of table %s: %w", aws.ToString(input.TableName), err)
+	}
+	return true, nil
+}
+
+func reconcileTags(ctx context.Context, client EnsureTableClient, table *types.TableDescription, tags []types.Tag) error {
+	missing, err := missingTags(ctx, client, table, tags)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	_, err = client.TagResource(ctx, &dynamodb.TagResourceInput{
+		ResourceArn: table.TableArn,
+		Tags:        missing,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag table %s: %w", aws.ToString(table.TableName), err)
+	}
+	return nil
+}
+
+// missingTags returns the tags the table does not have, or has with another value.
+func missingTags(ctx context.Context, client ListTagsClient, table *types.TableDescription, tags []types.Tag) ([]types.Tag, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	current := make(map[string]string)
+	var nextToken *string
+	for {
+		output, err := client.ListTagsOfResource(ctx, &dynamodb.ListTagsOfResourceInput{
+			ResourceArn: table.TableArn,
+			NextToken:   nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the tags of table %s: %w", aws.ToString(table.TableName), err)
+		}
+		for _, tag := range output.Tags {
+			current[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	var missing []types.Tag
+	for _, tag := range tags {
+		if value, ok := current[aws.ToString(tag.Key)]; !ok || value != aws.ToString(tag.Value) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing, nil
+}
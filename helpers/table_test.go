@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnsureTable", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	newInput := func() *dynamodb.CreateTableInput {
+		return &dynamodb.CreateTableInput{
+			TableName: aws.String("users"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			ProvisionedThroughput: &types.ProvisionedThroughput{
+				ReadCapacityUnits:  aws.Int64(5),
+				WriteCapacityUnits: aws.Int64(5),
+			},
+			Tags: []types.Tag{
+				{Key: aws.String("team"), Value: aws.String("platform")},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+	})
+
+	When("the table does not exist", func() {
+		It("should create it and wait until it is active", func() {
+			client.pending = 2
+
+			Expect(EnsureTable(ctx, client, newInput(), WithPollInterval(time.Millisecond))).To(Succeed())
+
+			Expect(client.tables).To(HaveKey("users"))
+			Expect(client.tables["users"].description.TableStatus).To(Equal(types.TableStatusActive))
+			Expect(client.calls).To(ContainElement("CreateTable"))
+		})
+
+		It("should fail when it does not become active in time", func() {
+			client.pending = 1000
+
+			err := EnsureTable(ctx, client, newInput(), WithPollInterval(time.Millisecond), WithTimeout(10*time.Millisecond))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+
+	When("the table already exists as described", func() {
+		It("should not change it", func() {
+			Expect(EnsureTable(ctx, client, newInput())).To(Succeed())
+			client.calls = nil
+
+			Expect(EnsureTable(ctx, client, newInput())).To(Succeed())
+			Expect(client.calls).To(Equal([]string{"DescribeTable", "ListTagsOfResource"}))
+		})
+	})
+
+	When("the table drifted", func() {
+		It("should update the throughput and tags", func() {
+			Expect(EnsureTable(ctx, client, newInput())).To(Succeed())
+			table := client.tables["users"]
+			table.description.ProvisionedThroughput.ReadCapacityUnits = aws.Int64(1)
+			table.tags["team"] = "other"
+			table.tags["owner"] = "someone"
+
+			Expect(EnsureTable(ctx, client, newInput(), WithPollInterval(time.Millisecond))).To(Succeed())
+
+			Expect(aws.ToInt64(table.description.ProvisionedThroughput.ReadCapacityUnits)).To(BeEquivalentTo(5))
+			Expect(table.tags).To(Equal(map[string]string{"team": "platform", "owner": "someone"}))
+		})
+	})
+
+	When("the table exists with a different key schema", func() {
+		It("should fail with ErrKeySchemaMismatch", func() {
+			Expect(EnsureTable(ctx, client, newInput())).To(Succeed())
+
+			input := newInput()
+			input.KeySchema = append(input.KeySchema, types.KeySchemaElement{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange})
+			Expect(EnsureTable(ctx, client, input)).To(MatchError(ErrKeySchemaMismatch))
+		})
+	})
+
+	When("the table exists with a different billing mode", func() {
+		It("should fail with ErrBillingModeMismatch", func() {
+			Expect(EnsureTable(ctx, client, newInput())).To(Succeed())
+
+			input := newInput()
+			input.BillingMode = types.BillingModePayPerRequest
+			input.ProvisionedThroughput = nil
+			Expect(EnsureTable(ctx, client, input)).To(MatchError(ErrBillingModeMismatch))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DescribeTTLClient interface {
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+}
+
+type EnsureTTLClient interface {
+	DescribeTTLClient
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+}
+
+// TTLAttributeMismatchError is returned by EnsureTTL when the TTL of the table is already enabled on another
+// attribute. DynamoDB does not change the attribute of an enabled TTL: it must be disabled first, and it can only be
+// enabled again an hour later.
+type TTLAttributeMismatchError struct {
+	TableName string
+	// AttributeName is the attribute the TTL is enabled on.
+	AttributeName string
+	// Want is the attribute given to EnsureTTL.
+	Want string
+}
+
+func (e *TTLAttributeMismatchError) Error() string {
+	return fmt.Sprintf("TTL of table %s is enabled on the attribute %q instead of %q: disable it and enable it again after an hour",
+		e.TableName, e.AttributeName, e.Want)
+}
+
+// EnsureTTL makes sure the TTL of the table is enabled on the given attribute, and waits until it is enabled. If the
+// TTL is being disabled, it waits for it to be disabled before enabling it again. If the TTL is enabled (or being
+// enabled) on another attribute, it returns a *TTLAttributeMismatchError.
+func EnsureTTL(ctx context.Context, client EnsureTTLClient, tableName, attributeName string, options ...Option) error {
+	o := newOpts(options)
+
+	var description *types.TimeToLiveDescription
+	err := poll(ctx, o, func(ctx context.Context) (bool, error) {
+		var err error
+		description, err = describeTimeToLive(ctx, client, tableName)
+		return err != nil || description.TimeToLiveStatus != types.TimeToLiveStatusDisabling, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed waiting for the TTL of table %s: %w", tableName, err)
+	}
+
+	switch description.TimeToLiveStatus {
+	case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+		if current := aws.ToString(description.AttributeName); current != attributeName {
+			return &TTLAttributeMismatchError{TableName: tableName, AttributeName: current, Want: attributeName}
+		}
+		if description.TimeToLiveStatus == types.TimeToLiveStatusEnabled {
+			return nil
+		}
+	default:
+		_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName: aws.String(tableName),
+			TimeToLiveSpecification: &types.TimeToLiveSpecification{
+				AttributeName: aws.String(attributeName),
+				Enabled:       aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to enable the TTL of table %s: %w", tableName, err)
+		}
+	}
+
+	err = poll(ctx, o, func(ctx context.Context) (bool, error) {
+		description, err := describeTimeToLive(ctx, client, tableName)
+		return err != nil || description.TimeToLiveStatus == types.TimeToLiveStatusEnabled, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed waiting for the TTL of table %s: %w", tableName, err)
+	}
+	return nil
+}
+
+func describeTimeToLive(ctx context.Context, client DescribeTTLClient, tableName string) (*types.TimeToLiveDescription, error) {
+	output, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the TTL of table %s: %w", tableName, err)
+	}
+	if output.TimeToLiveDescription == nil {
+		return &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}, nil
+	}
+	return output.TimeToLiveDescription, nil
+}
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnsureTTL", func() {
+	var (
+		ctx    context.Context
+		client *fakeClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = newFakeClient()
+		Expect(EnsureTable(ctx, client, &dynamodb.CreateTableInput{
+			TableName: aws.String("sessions"),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		})).To(Succeed())
+		client.calls = nil
+	})
+
+	When("the TTL is disabled", func() {
+		It("should enable it and wait until it is enabled", func() {
+			client.pending = 2
+
+			Expect(EnsureTTL(ctx, client, "sessions", "expires_at", WithPollInterval(time.Millisecond))).To(Succeed())
+
+			Expect(client.tables["sessions"].ttl).To(Equal(types.TimeToLiveDescription{
+				TimeToLiveStatus: types.TimeToLiveStatusEnabled,
+				AttributeName:    aws.String("expires_at"),
+			}))
+		})
+	})
+
+	When("the TTL is already enabled on the attribute", func() {
+		It("should not update it", func() {
+			Expect(EnsureTTL(ctx, client, "sessions", "expires_at", WithPollInterval(time.Millisecond))).To(Succeed())
+			client.calls = nil
+
+			Expect(EnsureTTL(ctx, client, "sessions", "expires_at")).To(Succeed())
+			Expect(client.calls).ToNot(ContainElement("UpdateTimeToLive"))
+		})
+	})
+
+	When("the TTL is enabled on another attribute", func() {
+		It("should fail with a TTLAttributeMismatchError", func() {
+			Expect(EnsureTTL(ctx, client, "sessions", "ttl", WithPollInterval(time.Millisecond))).To(Succeed())
+
+			err := EnsureTTL(ctx, client, "sessions", "expires_at")
+			var mismatchErr *TTLAttributeMismatchError
+			Expect(err).To(BeAssignableToTypeOf(mismatchErr))
+			Expect(err).To(MatchError(&TTLAttributeMismatchError{TableName: "sessions", AttributeName: "ttl", Want: "expires_at"}))
+		})
+	})
+
+	When("the TTL is being disabled", func() {
+		It("should wait for it to be disabled and enable it", func() {
+			client.tables["sessions"].ttl = types.TimeToLiveDescription{
+				TimeToLiveStatus: types.TimeToLiveStatusDisabling,
+				AttributeName:    aws.String("ttl"),
+			}
+			client.tables["sessions"].ttlPending = 2
+
+			Expect(EnsureTTL(ctx, client, "sessions", "expires_at", WithPollInterval(time.Millisecond))).To(Succeed())
+			Expect(aws.ToString(client.tables["sessions"].ttl.AttributeName)).To(Equal("expires_at"))
+		})
+	})
+})
@@ -0,0 +1,159 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	attributeStartedAt  = "started_at"
+	attributeAppliedAt  = "applied_at"
+	attributeAppliedBy  = "applied_by"
+	attributeAppliedSeq = "applied_seq"
+	attributeFailure    = "failure"
+	attributeMetadata   = "metadata"
+)
+
+// MigrationRecord is what the Target recorded about a migration. Migrations recorded by older versions of the Target
+// have no timestamps nor applier.
+type MigrationRecord struct {
+	ID    string
+	Dirty bool
+	// StartedAt is when the migration was last added or started.
+	StartedAt time.Time
+	// AppliedAt is when the migration was last finished. It is zero if it was never finished.
+	AppliedAt time.Time
+	// Duration is how long it took to apply the migration, from StartedAt to AppliedAt. It is zero when unknown.
+	Duration time.Duration
+	// AppliedSeq is the position of the migration in the order the migrations were applied, increasing with every
+	// migration finished, whatever its ID (see SortByApplyOrder). It is zero if unknown.
+	AppliedSeq int64
+	// AppliedBy is the identity of who last added or finished the migration (see WithAuditActor).
+	AppliedBy     string
+	CorrelationID string
+	// Prepared is whether the migration was prepared by PrepareMigration, and not committed, started or failed since.
+	Prepared bool
+	// PreparedAt is when the migration was last prepared. It is zero if it was never prepared.
+	PreparedAt time.Time
+	// CanarySlices are the slices of the environment the migration was applied in, while in canary (see MarkCanary).
+	CanarySlices []string
+	// PromotedAt is when the migration was last promoted from canary by PromoteCanary. It is zero if it never was.
+	PromotedAt time.Time
+	// Failure is why the migration was marked as failed by FailMigration, as its verification failing. It is empty
+	// once the migration is finished again.
+	Failure string
+	// Metadata is what the migration recorded with SetMetadata.
+	Metadata map[string]string
+}
+
+type ddbRecord struct {
+	ID            string            `dynamodbav:"id"`
+	Dirty         bool              `dynamodbav:"dirty"`
+	StartedAt     string            `dynamodbav:"started_at"`
+	AppliedAt     string            `dynamodbav:"applied_at"`
+	AppliedSeq    int64             `dynamodbav:"applied_seq"`
+	AppliedBy     string            `dynamodbav:"applied_by"`
+	CorrelationID string            `dynamodbav:"correlation_id"`
+	Failure       string            `dynamodbav:"failure"`
+	Phase         string            `dynamodbav:"phase"`
+	PreparedAt    string            `dynamodbav:"prepared_at"`
+	CanarySlices  []string          `dynamodbav:"canary_slices"`
+	PromotedAt    string            `dynamodbav:"promoted_at"`
+	Metadata      map[string]string `dynamodbav:"metadata"`
+}
+
+// History returns the records of all migrations, including the dirty ones, sorted by ID.
+func (t *Target) History(ctx context.Context) (_ []MigrationRecord, err error) {
+	defer t.observe(ctx, operationHistory, t.clock.Now(), &err)
+	defer wrapError(operationHistory, t.tableName, &err)
+
+	items, err := t.scanMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]MigrationRecord, 0, len(items))
+	for _, item := range items {
+		record, err := t.migrationRecord(item)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	return records, nil
+}
+
+// HistorySince returns the records of the migrations applied in the period, from inclusive to exclusive, sorted by
+// when they were applied. With WithAppliedAtIndex, they are queried from the applied_at index, reading only them
+// instead of the whole migrations table. The migrations applied before the index was added are not in it, as its key
+// is only written when a migration is finished. Without the index, or if the client does not implement
+// QueryDynamoDBClient, the migrations table is scanned. Dirty migrations are never returned.
+func (t *Target) HistorySince(ctx context.Context, from, to time.Time) (_ []MigrationRecord, err error) {
+	defer t.observe(ctx, operationHistory, t.clock.Now(), &err)
+	defer wrapError(operationHistory, t.tableName, &err)
+
+	var items []map[string]types.AttributeValue
+	if t.appliedAtIndex {
+		items, err = t.queryIndex(ctx, operationHistory, AppliedAtIndexName, expression.Key(attributeAppliedKey).Equal(expression.Value(appliedKeyValue)).
+			And(expression.Key(attributeAppliedAtMillis).Between(expression.Value(from.UnixMilli()), expression.Value(to.UnixMilli()))))
+	}
+	if !t.appliedAtIndex || errors.Is(err, errors.ErrUnsupported) {
+		items, err = t.scanMigrations(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]MigrationRecord, 0)
+	for _, item := range items {
+		record, err := t.migrationRecord(item)
+		if err != nil {
+			return nil, err
+		}
+		if !record.Dirty && !record.AppliedAt.IsZero() && !record.AppliedAt.Before(from) && record.AppliedAt.Before(to) {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].AppliedAt.Before(records[j].AppliedAt)
+	})
+
+	return records, nil
+}
+
+// migrationRecord returns the record of the migration stored in the item.
+func (t *Target) migrationRecord(item map[string]types.AttributeValue) (MigrationRecord, error) {
+	var r ddbRecord
+	if err := t.codec.unmarshalRecord(item, &r); err != nil {
+		return MigrationRecord{}, fmt.Errorf("failed to unmarshal item: %w", err)
+	}
+	record := MigrationRecord{
+		ID:            r.ID,
+		Dirty:         r.Dirty,
+		StartedAt:     t.codec.parseTimestamp(r.StartedAt),
+		AppliedAt:     t.codec.parseTimestamp(r.AppliedAt),
+		AppliedSeq:    r.AppliedSeq,
+		AppliedBy:     r.AppliedBy,
+		CorrelationID: r.CorrelationID,
+		Failure:       r.Failure,
+		Prepared:      r.Phase == phasePrepared,
+		PreparedAt:    t.codec.parseTimestamp(r.PreparedAt),
+		CanarySlices:  canaryOf(r.Phase, r.CanarySlices),
+		PromotedAt:    t.codec.parseTimestamp(r.PromotedAt),
+		Metadata:      r.Metadata,
+	}
+	if !record.StartedAt.IsZero() && !record.AppliedAt.IsZero() && !record.AppliedAt.Before(record.StartedAt) {
+		record.Duration = record.AppliedAt.Sub(record.StartedAt)
+	}
+	return record, nil
+}
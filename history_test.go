@@ -0,0 +1,71 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient, WithAuditActor("deployer"))
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should return when and by whom the migrations were applied", func() {
+		before := time.Now()
+		Expect(target.Add(ctx, "2")).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		time.Sleep(10 * time.Millisecond)
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(2))
+
+		Expect(history[0].ID).To(Equal("1"))
+		Expect(history[0].Dirty).To(BeFalse())
+		Expect(history[0].AppliedBy).To(Equal("deployer"))
+		Expect(history[0].StartedAt).To(BeTemporally(">=", before.Truncate(time.Millisecond)))
+		Expect(history[0].AppliedAt).To(BeTemporally(">", history[0].StartedAt))
+		Expect(history[0].Duration).To(Equal(history[0].AppliedAt.Sub(history[0].StartedAt)))
+
+		Expect(history[1].ID).To(Equal("2"))
+		Expect(history[1].Dirty).To(BeTrue())
+		Expect(history[1].AppliedAt).To(BeZero())
+		Expect(history[1].Duration).To(BeZero())
+	})
+	It("should record the order the migrations were applied, whatever their IDs", func() {
+		for _, id := range []string{"1", "3", "2"} {
+			Expect(target.Add(ctx, id)).To(Succeed())
+			Expect(target.StartMigration(ctx, id)).To(Succeed())
+		}
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "3")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "2")).To(Succeed())
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(3))
+		Expect(history[0].AppliedSeq).To(BeNumerically("<", history[2].AppliedSeq))
+		Expect(history[2].AppliedSeq).To(BeNumerically("<", history[1].AppliedSeq))
+
+		SortByApplyOrder(history)
+		ids := make([]string, 0, len(history))
+		for _, record := range history {
+			ids = append(ids, record.ID)
+		}
+		Expect(ids).To(Equal([]string{"1", "3", "2"}))
+	})
+})
@@ -0,0 +1,107 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// HistoryStats are the totals computed by HistoryStats over the records of all migrations, for the reviews of the
+// pace and the reliability of the schema changes. Differently from Stats, which counts what this Target did since it
+// was created, they cover what every runner recorded.
+type HistoryStats struct {
+	// Migrations is the number of migrations recorded, Applied the number of them finished, and Dirty the number of
+	// them started and not finished.
+	Migrations int `json:"migrations"`
+	Applied    int `json:"applied"`
+	Dirty      int `json:"dirty"`
+	// Failed is the number of migrations marked as failed by FailMigration (e.g. by their verification) and not
+	// finished since.
+	Failed int `json:"failed"`
+	// Runs is the number of runs that applied the migrations. The migrations of a run share the correlation ID of its
+	// context (see WithCorrelationIDExtractor), the ones recorded without a correlation ID count as a run each.
+	Runs int `json:"runs"`
+	// The durations are computed over the migrations whose duration is known (see MigrationRecord.Duration), the
+	// percentiles by the nearest rank. They are zero if none is known.
+	AverageDuration time.Duration `json:"average_duration"`
+	P50Duration     time.Duration `json:"p50_duration"`
+	P90Duration     time.Duration `json:"p90_duration"`
+	P99Duration     time.Duration `json:"p99_duration"`
+	MaxDuration     time.Duration `json:"max_duration"`
+	// FirstAppliedAt and LastAppliedAt are when the first and the last migrations were applied. They are zero if none
+	// was.
+	FirstAppliedAt time.Time `json:"first_applied_at"`
+	LastAppliedAt  time.Time `json:"last_applied_at"`
+	// LastAppliedAtBy is when the last migration was applied by each applier (see WithAuditActor), as the pipeline
+	// deploying each environment.
+	LastAppliedAtBy map[string]time.Time `json:"last_applied_at_by"`
+}
+
+// HistoryStats computes the totals over the records of all migrations, as returned by History.
+func (t *Target) HistoryStats(ctx context.Context) (HistoryStats, error) {
+	records, err := t.History(ctx)
+	if err != nil {
+		return HistoryStats{}, err
+	}
+	return historyStats(records), nil
+}
+
+func historyStats(records []MigrationRecord) HistoryStats {
+	stats := HistoryStats{
+		Migrations:      len(records),
+		LastAppliedAtBy: make(map[string]time.Time),
+	}
+	var (
+		durations []time.Duration
+		total     time.Duration
+		runs      = make(map[string]struct{})
+	)
+	for _, record := range records {
+		if record.Dirty {
+			stats.Dirty++
+		} else {
+			stats.Applied++
+		}
+		if record.Failure != "" {
+			stats.Failed++
+		}
+		if record.CorrelationID == "" {
+			stats.Runs++
+		} else {
+			runs[record.CorrelationID] = struct{}{}
+		}
+		if record.Duration > 0 {
+			durations = append(durations, record.Duration)
+			total += record.Duration
+		}
+		if record.Dirty || record.AppliedAt.IsZero() {
+			continue
+		}
+		if stats.FirstAppliedAt.IsZero() || record.AppliedAt.Before(stats.FirstAppliedAt) {
+			stats.FirstAppliedAt = record.AppliedAt
+		}
+		if record.AppliedAt.After(stats.LastAppliedAt) {
+			stats.LastAppliedAt = record.AppliedAt
+		}
+		if record.AppliedBy != "" && record.AppliedAt.After(stats.LastAppliedAtBy[record.AppliedBy]) {
+			stats.LastAppliedAtBy[record.AppliedBy] = record.AppliedAt
+		}
+	}
+	stats.Runs += len(runs)
+
+	if len(durations) > 0 {
+		slices.Sort(durations)
+		stats.AverageDuration = total / time.Duration(len(durations))
+		stats.P50Duration = percentile(durations, 50)
+		stats.P90Duration = percentile(durations, 90)
+		stats.P99Duration = percentile(durations, 99)
+		stats.MaxDuration = durations[len(durations)-1]
+	}
+	return stats
+}
+
+// percentile returns the p-th percentile of the sorted durations, by the nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,73 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HistoryStats", func() {
+	var (
+		ctx   context.Context
+		clock *fakeClock
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		clock = newFakeClock()
+	})
+
+	apply := func(target *Target, ctx context.Context, id string, duration time.Duration) {
+		GinkgoHelper()
+
+		Expect(target.Add(ctx, id)).To(Succeed())
+		clock.Advance(duration)
+		Expect(target.FinishMigration(ctx, id)).To(Succeed())
+	}
+
+	It("should compute the totals over the history", func() {
+		staging := NewTarget(dynamoDBClient, WithClock(clock), WithAuditActor("staging"))
+		production := NewTarget(dynamoDBClient, WithClock(clock), WithAuditActor("production"))
+		Expect(staging.Create(ctx)).To(Succeed())
+		startedAt := clock.Now()
+
+		run := ContextWithCorrelationID(ctx, "run-1")
+		apply(staging, run, "1", time.Second)
+		apply(staging, run, "2", 3*time.Second)
+		apply(production, ctx, "3", 2*time.Second)
+		lastAppliedAt := clock.Now()
+		Expect(production.Add(ctx, "4")).To(Succeed())
+		Expect(production.FailMigration(ctx, "4", context.Canceled)).To(Succeed())
+
+		stats, err := staging.HistoryStats(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stats.Migrations).To(Equal(4))
+		Expect(stats.Applied).To(Equal(3))
+		Expect(stats.Dirty).To(Equal(1))
+		Expect(stats.Failed).To(Equal(1))
+		Expect(stats.Runs).To(Equal(3))
+		Expect(stats.AverageDuration).To(Equal(2 * time.Second))
+		Expect(stats.P50Duration).To(Equal(2 * time.Second))
+		Expect(stats.P99Duration).To(Equal(3 * time.Second))
+		Expect(stats.MaxDuration).To(Equal(3 * time.Second))
+		Expect(stats.FirstAppliedAt).To(BeTemporally("==", startedAt.Add(time.Second)))
+		Expect(stats.LastAppliedAt).To(BeTemporally("==", lastAppliedAt))
+		Expect(stats.LastAppliedAtBy).To(HaveKeyWithValue("staging", BeTemporally("==", startedAt.Add(4*time.Second))))
+		Expect(stats.LastAppliedAtBy).To(HaveKeyWithValue("production", BeTemporally("==", lastAppliedAt)))
+	})
+
+	DescribeTable("percentile",
+		func(durations []time.Duration, p int, expected time.Duration) {
+			Expect(percentile(durations, p)).To(Equal(expected))
+		},
+		Entry("single", []time.Duration{time.Second}, 99, time.Second),
+		Entry("median", []time.Duration{1, 2, 3, 4}, 50, time.Duration(2)),
+		Entry("highest", []time.Duration{1, 2, 3, 4}, 99, time.Duration(4)),
+		Entry("lowest", []time.Duration{1, 2, 3, 4}, 0, time.Duration(1)),
+	)
+})
@@ -0,0 +1,81 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// OptionsIgnoringDynamoDBClient is implemented by the clients ignoring the options given to their calls, as the
+// sdkv1.Client, when IgnoresCallOptions returns true. The Target pins the calls to the lock table to the home region
+// through the options (see WithHomeRegion), so it refuses to use a global lock table with them. The clients wrapping
+// another client implement it forwarding to the wrapped client.
+type OptionsIgnoringDynamoDBClient interface {
+	IgnoresCallOptions() bool
+}
+
+// ignoresCallOptions checks if the client ignores the options given to its calls.
+func ignoresCallOptions(client DynamoDBClient) bool {
+	c, ok := client.(OptionsIgnoringDynamoDBClient)
+	return ok && c.IgnoresCallOptions()
+}
+
+// lockCallOptions returns the options of the calls to the lock table, pinning them to the home region, if set (see
+// WithHomeRegion).
+func (t *Target) lockCallOptions() []func(*dynamodb.Options) {
+	if t.homeRegion == "" {
+		return nil
+	}
+	return []func(*dynamodb.Options){func(o *dynamodb.Options) {
+		o.Region = t.homeRegion
+	}}
+}
+
+// tableCallOptions returns the options of the calls managing the table given: the lock table is managed in the home
+// region, the other tables in the region of the client.
+func (t *Target) tableCallOptions(tableName string) []func(*dynamodb.Options) {
+	if tableName != t.lockTableName {
+		return nil
+	}
+	return t.lockCallOptions()
+}
+
+// describeLockTable describes the lock table in the region the locks are written to, or returns nil if it does not
+// exist.
+func (t *Target) describeLockTable(ctx context.Context) (*types.TableDescription, error) {
+	output, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(t.lockTableName),
+	}, t.lockCallOptions()...)
+	var resourceNotFoundException *types.ResourceNotFoundException
+	if errors.As(err, &resourceNotFoundException) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the migrations lock table: %w", err)
+	}
+	return output.Table, nil
+}
+
+// ensureLockTable makes sure the lock table exists in the region the locks are written to, creating it and waiting
+// until it is active if not. It refuses to lock a global lock table when no home region is set, as the locks written
+// in different regions could be held at the same time.
+func (t *Target) ensureLockTable(ctx context.Context) error {
+	description, err := t.describeLockTable(ctx)
+	switch {
+	case err != nil:
+		return err
+	case description == nil:
+		if err := t.createTable(ctx, t.lockTableName, true); err != nil {
+			return fmt.Errorf("failed to create migrations lock table: %w", err)
+		}
+	case len(description.Replicas) > 0 && t.homeRegion == "":
+		return fmt.Errorf("the migrations lock table %s is a global table, the home region of the locks must be set with WithHomeRegion", t.lockTableName)
+	case len(description.Replicas) > 0 && ignoresCallOptions(t.client):
+		return fmt.Errorf("the migrations lock table %s is a global table, but the client ignores the options pinning the locks to the home region", t.lockTableName)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package migrations_dynamodb
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrIDOutOfOrder is returned by the IDGenerators when the ID generated would not sort after the existing IDs.
+var ErrIDOutOfOrder = errors.New("the migration ID does not sort after the existing ones")
+
+// IDGenerator generates the ID of a new migration, given the IDs of the existing ones (as the IDs in the history, see
+// History, and the ones of the source not applied yet). The migrations are sorted by their IDs, compared byte-wise as
+// strings by the migrations/v2 runner, so the ID generated sorts after all existing ones, or it fails with
+// ErrIDOutOfOrder. The scaffolding tools should all use the same IDGenerator, the one of the format of the team.
+type IDGenerator interface {
+	NextID(existing []string) (string, error)
+}
+
+// IDGeneratorFunc is an IDGenerator implemented by a function.
+type IDGeneratorFunc func(existing []string) (string, error)
+
+func (f IDGeneratorFunc) NextID(existing []string) (string, error) {
+	return f(existing)
+}
+
+// TimestampLayout is the layout of the IDs made by TimestampID: the UTC time, to the second, as 20240131235959.
+const TimestampLayout = "20060102150405"
+
+// TimestampID returns the ID of a migration created at the time given, in TimestampLayout.
+func TimestampID(t time.Time) string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+// MigrationTime returns the time of an ID made by TimestampID, ignoring what follows the timestamp, as the
+// "_add_index" of 20240131235959_add_index.
+func MigrationTime(id string) (time.Time, error) {
+	if len(id) < len(TimestampLayout) {
+		return time.Time{}, fmt.Errorf("the migration ID %q does not start with a timestamp", id)
+	}
+	t, err := time.Parse(TimestampLayout, id[:len(TimestampLayout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("the migration ID %q does not start with a timestamp: %w", id, err)
+	}
+	return t, nil
+}
+
+// TimestampIDs generates the IDs by the current time of the clock, with TimestampID. Two migrations generated in the
+// same second, or after an ID from a clock ahead of this one, are out of order.
+func TimestampIDs(clock Clock) IDGenerator {
+	return IDGeneratorFunc(func(existing []string) (string, error) {
+		return checkIDOrder(TimestampID(clock.Now()), existing)
+	})
+}
+
+// SequenceIDs generates the IDs by a sequence of numbers zero padded to the width given, as 0001, 0002... The ID
+// generated follows the highest number of the existing IDs, which must all start with one of the same width (as the
+// "0002" of 0002_add_index). It fails with ErrIDOutOfOrder when the sequence exceeds the width, as 10000 sorts before
+// 9999.
+func SequenceIDs(width int) IDGenerator {
+	return IDGeneratorFunc(func(existing []string) (string, error) {
+		next := 1
+		for _, id := range existing {
+			n, err := sequenceNumber(id, width)
+			if err != nil {
+				return "", err
+			}
+			next = max(next, n+1)
+		}
+		id := fmt.Sprintf("%0*d", width, next)
+		if len(id) > width {
+			return "", fmt.Errorf("%w: %s exceeds the %d digits of the sequence", ErrIDOutOfOrder, id, width)
+		}
+		return id, nil
+	})
+}
+
+// sequenceNumber returns the number the ID given starts with, of the width given.
+func sequenceNumber(id string, width int) (int, error) {
+	digits := id[:min(len(id), width)]
+	n, err := strconv.Atoi(digits)
+	if err != nil || len(digits) < width || (len(id) > width && isDigit(id[width])) {
+		return 0, fmt.Errorf("the migration ID %q does not start with a sequence number of %d digits", id, width)
+	}
+	return n, nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// crockfordBase32 is the alphabet of the ULIDs, sorted as its bytes.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDs generates the IDs as ULIDs: 26 characters encoding the milliseconds of the current time of the clock and 80
+// random bits, which sort by the time they were generated. When the last existing ID is a ULID of the same
+// millisecond, or ahead of the clock, the ID generated is the next ULID after it, so it still sorts after it.
+func ULIDs(clock Clock) IDGenerator {
+	return IDGeneratorFunc(func(existing []string) (string, error) {
+		var id [16]byte
+		ms := uint64(clock.Now().UnixMilli())
+		for i := range 6 {
+			id[i] = byte(ms >> (8 * (5 - i)))
+		}
+		if _, err := rand.Read(id[6:]); err != nil {
+			return "", fmt.Errorf("failed to generate the random part of the ULID: %w", err)
+		}
+		ulid := encodeULID(id)
+		if len(existing) > 0 {
+			if last := slices.Max(existing); ulid <= last {
+				if next, ok := nextULID(last); ok {
+					ulid = next
+				}
+			}
+		}
+		return checkIDOrder(ulid, existing)
+	})
+}
+
+// encodeULID encodes the 128 bits of a ULID in Crockford's base32, 5 bits per character, with the 2 leading bits
+// padded.
+func encodeULID(id [16]byte) string {
+	var sb strings.Builder
+	sb.Grow(26)
+	for i := 25; i >= 0; i-- {
+		bit := 5 * i
+		var v byte
+		for j := range 5 {
+			if b := bit + 4 - j; b < 128 && id[15-b/8]&(1<<(b%8)) != 0 {
+				v |= 1 << (4 - j)
+			}
+		}
+		sb.WriteByte(crockfordBase32[v])
+	}
+	return sb.String()
+}
+
+// nextULID returns the ULID following the one given, or false if the one given is not a ULID or is the last one.
+func nextULID(ulid string) (string, bool) {
+	if len(ulid) != 26 {
+		return "", false
+	}
+	next := []byte(ulid)
+	for i := len(next) - 1; i >= 0; i-- {
+		v := strings.IndexByte(crockfordBase32, next[i])
+		if v < 0 {
+			return "", false
+		}
+		if v < len(crockfordBase32)-1 {
+			next[i] = crockfordBase32[v+1]
+			return string(next), true
+		}
+		next[i] = crockfordBase32[0]
+	}
+	return "", false
+}
+
+// checkIDOrder checks the ID given sorts after the existing IDs.
+func checkIDOrder(id string, existing []string) (string, error) {
+	if len(existing) == 0 {
+		return id, nil
+	}
+	if last := slices.Max(existing); id <= last {
+		return "", fmt.Errorf("%w: %s does not sort after %s", ErrIDOutOfOrder, id, last)
+	}
+	return id, nil
+}
@@ -0,0 +1,65 @@
+package migrations_dynamodb
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IDGenerator", func() {
+	var clock *fakeClock
+
+	BeforeEach(func() {
+		clock = newFakeClock()
+	})
+
+	Describe("TimestampIDs", func() {
+		It("should generate the ID by the time of the clock", func() {
+			Expect(TimestampIDs(clock).NextID([]string{"20231231235959_create_table"})).To(Equal("20240101000000"))
+			Expect(MigrationTime("20240101000000_add_index")).To(BeTemporally("==", clock.Now()))
+		})
+
+		It("should fail when the ID does not sort after the existing ones", func() {
+			_, err := TimestampIDs(clock).NextID([]string{"20240101000000_create_table"})
+			Expect(err).To(MatchError(ErrIDOutOfOrder))
+		})
+	})
+
+	DescribeTable("SequenceIDs",
+		func(width int, existing []string, expected string) {
+			Expect(SequenceIDs(width).NextID(existing)).To(Equal(expected))
+		},
+		Entry("first", 4, nil, "0001"),
+		Entry("after the highest", 4, []string{"0002_add_index", "0001"}, "0003"),
+		Entry("up to the width", 2, []string{"98"}, "99"),
+	)
+
+	It("should fail when the sequence exceeds its width or the IDs are not a sequence", func() {
+		_, err := SequenceIDs(2).NextID([]string{"99"})
+		Expect(err).To(MatchError(ErrIDOutOfOrder))
+		_, err = SequenceIDs(4).NextID([]string{"00001"})
+		Expect(err).To(HaveOccurred())
+		_, err = SequenceIDs(4).NextID([]string{"20240101000000"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("ULIDs", func() {
+		It("should generate ULIDs sorting by the time of the clock", func() {
+			first, err := ULIDs(clock).NextID(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(first).To(HaveLen(26))
+			Expect(first).To(HavePrefix("01HK153X00"))
+
+			clock.Advance(time.Millisecond)
+			second, err := ULIDs(clock).NextID([]string{first})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second > first).To(BeTrue())
+		})
+
+		It("should generate the next ULID in the same millisecond", func() {
+			last := "01HK153X00ZZZZZZZZZZZZZZZZ"
+			Expect(ULIDs(clock).NextID([]string{last})).To(Equal("01HK153X010000000000000000"))
+		})
+	})
+})
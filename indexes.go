@@ -0,0 +1,196 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// DirtyIndexName is the name of the global secondary index of the migrations table over the dirty migrations,
+	// created with WithDirtyIndex.
+	DirtyIndexName = "dirty-index"
+
+	// attributeDirtyKey is the key of the dirty index. It is only set while the migration is dirty, so the index is
+	// sparse: it holds the dirty migrations only.
+	attributeDirtyKey = "dirty_key"
+	dirtyKeyValue     = "dirty"
+
+	// AppliedAtIndexName is the name of the global secondary index of the migrations table over the applied
+	// migrations, sorted by when they were applied, created with WithAppliedAtIndex.
+	AppliedAtIndexName = "applied-at-index"
+
+	// attributeAppliedKey and attributeAppliedAtMillis are the keys of the applied_at index. They are only set while
+	// the migration is applied. The time is stored as Unix milliseconds, as the applied_at timestamps do not sort as
+	// strings with every layout (see WithTimestampFormat).
+	attributeAppliedKey      = "applied_key"
+	attributeAppliedAtMillis = "applied_at_ms"
+	appliedKeyValue          = "applied"
+)
+
+// QueryDynamoDBClient is implemented by the clients able to query the tables, as the *dynamodb.Client. It is
+// optional: when the client given to NewTarget does not implement it, the reads made through the indexes scan the
+// migrations table instead.
+type QueryDynamoDBClient interface {
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// errQueryUnsupported is returned by the client wrappers of the package when the wrapped client does not implement
+// QueryDynamoDBClient.
+var errQueryUnsupported = fmt.Errorf("the client does not support Query: %w", errors.ErrUnsupported)
+
+// secondaryIndex is a global secondary index of the migrations table, projecting all the attributes.
+type secondaryIndex struct {
+	name     string
+	hashKey  types.AttributeDefinition
+	rangeKey *types.AttributeDefinition
+}
+
+// migrationsTableIndexes returns the indexes of the migrations table enabled by the options.
+func (t *Target) migrationsTableIndexes() []secondaryIndex {
+	var indexes []secondaryIndex
+	if t.dirtyIndex {
+		indexes = append(indexes, secondaryIndex{
+			name: DirtyIndexName,
+			hashKey: types.AttributeDefinition{
+				AttributeName: aws.String(attributeDirtyKey),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		})
+	}
+	if t.appliedAtIndex {
+		indexes = append(indexes, secondaryIndex{
+			name: AppliedAtIndexName,
+			hashKey: types.AttributeDefinition{
+				AttributeName: aws.String(attributeAppliedKey),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			rangeKey: &types.AttributeDefinition{
+				AttributeName: aws.String(attributeAppliedAtMillis),
+				AttributeType: types.ScalarAttributeTypeN,
+			},
+		})
+	}
+	return indexes
+}
+
+// addTo adds the index, and the definitions of its key attributes, to the table created.
+func (index secondaryIndex) addTo(input *dynamodb.CreateTableInput) {
+	keys := []types.AttributeDefinition{index.hashKey}
+	if index.rangeKey != nil {
+		keys = append(keys, *index.rangeKey)
+	}
+
+	gsi := types.GlobalSecondaryIndex{
+		IndexName:  aws.String(index.name),
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+	for i, key := range keys {
+		keyType := types.KeyTypeHash
+		if i > 0 {
+			keyType = types.KeyTypeRange
+		}
+		gsi.KeySchema = append(gsi.KeySchema, types.KeySchemaElement{AttributeName: key.AttributeName, KeyType: keyType})
+
+		defined := false
+		for _, definition := range input.AttributeDefinitions {
+			defined = defined || aws.ToString(definition.AttributeName) == aws.ToString(key.AttributeName)
+		}
+		if !defined {
+			input.AttributeDefinitions = append(input.AttributeDefinitions, key)
+		}
+	}
+	if input.ProvisionedThroughput != nil {
+		gsi.ProvisionedThroughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  input.ProvisionedThroughput.ReadCapacityUnits,
+			WriteCapacityUnits: input.ProvisionedThroughput.WriteCapacityUnits,
+		}
+	}
+	input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, gsi)
+}
+
+// queryIndex reads all pages of the items of the index of the migrations table matching the key condition. If the
+// client does not implement QueryDynamoDBClient, it returns an errQueryUnsupported.
+func (t *Target) queryIndex(ctx context.Context, operation, indexName string, keyCondition expression.KeyConditionBuilder) ([]map[string]types.AttributeValue, error) {
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the query expression: %w", err)
+	}
+
+	client, ok := t.client.(QueryDynamoDBClient)
+	if !ok {
+		return nil, errQueryUnsupported
+	}
+	var (
+		items    []map[string]types.AttributeValue
+		startKey map[string]types.AttributeValue
+	)
+	for {
+		output, err := client.Query(ctx, &dynamodb.QueryInput{
+			TableName:                 &t.tableName,
+			IndexName:                 aws.String(indexName),
+			KeyConditionExpression:    expr.KeyCondition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ExclusiveStartKey:         startKey,
+			ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		})
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query the index %s: %w", indexName, err)
+		}
+		t.capacity.record(operation, output.ConsumedCapacity)
+
+		for _, item := range output.Items {
+			items = append(items, t.normalizeItem(item))
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		startKey = output.LastEvaluatedKey
+	}
+}
+
+// Dirty returns the dirty migrations, sorted by ID. With WithDirtyIndex, they are queried from the dirty index, reading
+// only them instead of the whole migrations table, at the cost of the index being eventually consistent. The migrations
+// left dirty before the index was added are not in it, as the key of the index is only written by the writes leaving
+// a migration dirty: Add, StartMigration, PrepareMigration and FailMigration. Without the index, or if the client does not implement QueryDynamoDBClient, the migrations table is
+// scanned.
+func (t *Target) Dirty(ctx context.Context) (_ []MigrationStatus, err error) {
+	defer t.observe(ctx, operationDirty, t.clock.Now(), &err)
+	defer wrapError(operationDirty, t.tableName, &err)
+
+	var items []map[string]types.AttributeValue
+	if t.dirtyIndex {
+		items, err = t.queryIndex(ctx, operationDirty, DirtyIndexName, expression.Key(attributeDirtyKey).Equal(expression.Value(dirtyKeyValue)))
+	}
+	if !t.dirtyIndex || errors.Is(err, errors.ErrUnsupported) {
+		items, err = t.scanMigrations(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dirty := make([]MigrationStatus, 0)
+	for _, item := range items {
+		status, err := t.migrationStatus(item)
+		if err != nil {
+			return nil, err
+		}
+		if status.Dirty {
+			dirty = append(dirty, status)
+		}
+	}
+	sort.Slice(dirty, func(i, j int) bool {
+		return dirty[i].ID < dirty[j].ID
+	})
+	return dirty, nil
+}
@@ -0,0 +1,155 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// scanOnlyClient hides the Query of the client, as the clients not implementing QueryDynamoDBClient.
+type scanOnlyClient struct {
+	DynamoDBClient
+}
+
+var _ = Describe("Dirty index", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	It("should create the index with the migrations table", func() {
+		target := NewTarget(dynamoDBClient, WithDirtyIndex())
+		Expect(target.Create(ctx)).To(Succeed())
+
+		output, err := dynamoDBClient.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(target.TableName())})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Table.GlobalSecondaryIndexes).To(HaveLen(1))
+		Expect(aws.ToString(output.Table.GlobalSecondaryIndexes[0].IndexName)).To(Equal(DirtyIndexName))
+	})
+
+	It("should list the dirty migrations only, from the index", func() {
+		target := NewTarget(dynamoDBClient, WithDirtyIndex())
+		Expect(target.Create(ctx)).To(Succeed())
+		for _, id := range []string{"1", "2", "3"} {
+			Expect(target.Add(ctx, id)).To(Succeed())
+		}
+		Expect(target.FinishMigration(ctx, "2")).To(Succeed())
+		Expect(target.StartMigration(ctx, "2")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		Expect(target.PrepareMigration(ctx, "3")).To(Succeed())
+
+		Eventually(target.Dirty).WithArguments(ctx).Should(Equal([]MigrationStatus{
+			{ID: "2", Dirty: true},
+			{ID: "3", Dirty: true, Prepared: true},
+		}))
+	})
+
+	It("should list the migrations failing the verification, from the index", func() {
+		target := NewTarget(dynamoDBClient, WithDirtyIndex())
+		Expect(target.Create(ctx)).To(Succeed())
+		target.Verify("1", func(context.Context, DynamoDBClient) error {
+			return errors.New("orders not backfilled")
+		})
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(MatchError(ContainSubstring("orders not backfilled")))
+
+		Eventually(target.Dirty).WithArguments(ctx).Should(Equal([]MigrationStatus{{ID: "1", Dirty: true}}))
+	})
+
+	It("should scan the migrations table without the index", func() {
+		target := NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.Add(ctx, "2")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		Expect(target.Dirty(ctx)).To(Equal([]MigrationStatus{{ID: "2", Dirty: true}}))
+	})
+
+	It("should scan the migrations table when the client cannot query", func() {
+		Expect(NewTarget(dynamoDBClient, WithDirtyIndex()).Create(ctx)).To(Succeed())
+		target := NewTarget(scanOnlyClient{dynamoDBClient}, WithDirtyIndex())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+
+		Expect(target.Dirty(ctx)).To(Equal([]MigrationStatus{{ID: "1", Dirty: true}}))
+	})
+})
+
+var _ = Describe("Applied at index", func() {
+	var (
+		ctx   context.Context
+		clock *fakeClock
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		clock = newFakeClock()
+	})
+
+	apply := func(target *Target, id string) {
+		GinkgoHelper()
+
+		Expect(target.Add(ctx, id)).To(Succeed())
+		Expect(target.FinishMigration(ctx, id)).To(Succeed())
+		clock.Advance(24 * time.Hour)
+	}
+
+	appliedIDs := func(records []MigrationRecord) []string {
+		ids := make([]string, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		return ids
+	}
+
+	It("should list the migrations applied in the period, from the index", func() {
+		target := NewTarget(dynamoDBClient, WithAppliedAtIndex(), WithClock(clock))
+		Expect(target.Create(ctx)).To(Succeed())
+		from := clock.Now().Add(24 * time.Hour)
+		for _, id := range []string{"3", "1", "2", "4"} {
+			apply(target, id)
+		}
+		Expect(target.StartMigration(ctx, "2")).To(Succeed())
+
+		Eventually(func() ([]string, error) {
+			records, err := target.HistorySince(ctx, from, from.Add(48*time.Hour))
+			return appliedIDs(records), err
+		}).Should(Equal([]string{"1"}))
+	})
+
+	It("should list the migrations failing the verification, from the index", func() {
+		target := NewTarget(dynamoDBClient, WithDirtyIndex())
+		Expect(target.Create(ctx)).To(Succeed())
+		target.Verify("1", func(context.Context, DynamoDBClient) error {
+			return errors.New("orders not backfilled")
+		})
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(MatchError(ContainSubstring("orders not backfilled")))
+
+		Eventually(target.Dirty).WithArguments(ctx).Should(Equal([]MigrationStatus{{ID: "1", Dirty: true}}))
+	})
+
+	It("should scan the migrations table without the index", func() {
+		target := NewTarget(dynamoDBClient, WithClock(clock))
+		Expect(target.Create(ctx)).To(Succeed())
+		from := clock.Now().Add(24 * time.Hour)
+		for _, id := range []string{"3", "1", "2", "4"} {
+			apply(target, id)
+		}
+
+		records, err := target.HistorySince(ctx, from, from.Add(48*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(appliedIDs(records)).To(Equal([]string{"1", "2"}))
+	})
+})
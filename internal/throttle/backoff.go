@@ -0,0 +1,56 @@
+package throttle
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	backoffMinDelay    = 25 * time.Millisecond
+	backoffMaxDelay    = time.Second
+	backoffMaxThrottle = 8
+)
+
+// Backoff controls the pace of a single worker of a multi-worker operation (e.g. the segments of a scan). Every
+// throttled request doubles the delay before the next request of the worker, and every successful request halves it.
+// So, only the workers hitting the throttling slow down, instead of all of them retrying blindly. The zero value is
+// ready to use, with the system clock.
+type Backoff struct {
+	Clock Clock
+
+	delay     time.Duration
+	throttles int
+}
+
+// Wait sleeps for the current delay of the worker, returning early if the context is done.
+func (b *Backoff) Wait(ctx context.Context) error {
+	if b.delay == 0 {
+		return ctx.Err()
+	}
+	return Sleep(ctx, b.Clock, b.delay)
+}
+
+// Throttled increases the delay of the worker. It returns false if the worker was throttled too many times in a row and
+// should give up.
+func (b *Backoff) Throttled() bool {
+	b.throttles++
+	if b.throttles >= backoffMaxThrottle {
+		return false
+	}
+	b.delay = min(max(2*b.delay, backoffMinDelay), backoffMaxDelay)
+	return true
+}
+
+// Succeeded decreases the delay of the worker.
+func (b *Backoff) Succeeded() {
+	b.throttles = 0
+	b.delay /= 2
+	if b.delay < backoffMinDelay {
+		b.delay = 0
+	}
+}
+
+// Delay returns the current delay of the worker.
+func (b *Backoff) Delay() time.Duration {
+	return b.delay
+}
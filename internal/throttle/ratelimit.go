@@ -0,0 +1,76 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter shares a budget of capacity units per second between the workers of an operation. The budget refills
+// continuously, up to one second worth of units (at least one). Each request reserves the units it is expected to
+// consume before it is sent, if known, and is charged the capacity it actually consumed after it returns, so the
+// budget can go negative and the next requests wait for it to refill.
+type RateLimiter struct {
+	clock Clock
+
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter of the given units per second, timed by the clock (the system one if nil). A nil
+// limiter, returned for a non-positive rate, does not limit anything.
+func NewRateLimiter(clock Clock, rate float64) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	clock = clockOrSystem(clock)
+	return &RateLimiter{
+		clock:  clock,
+		rate:   rate,
+		tokens: max(rate, 1),
+		last:   clock.Now(),
+	}
+}
+
+// Wait blocks until the budget has the units available, or is full, and reserves them. With no units to reserve, it
+// waits for the budget to be positive.
+func (l *RateLimiter) Wait(ctx context.Context, units float64) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	for {
+		l.mu.Lock()
+		l.refill()
+		needed := min(units, max(l.rate, 1))
+		if l.tokens > 0 && l.tokens >= needed {
+			l.tokens -= units
+			l.mu.Unlock()
+			return nil
+		}
+		// with no units to reserve, it waits for a whole unit, as any request consumes at least a fraction of one.
+		delay := time.Duration((max(needed, 1) - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := Sleep(ctx, l.clock, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Consumed charges the budget the units consumed by a request, minus the units reserved by Wait.
+func (l *RateLimiter) Consumed(reserved, units float64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens -= units - reserved
+}
+
+func (l *RateLimiter) refill() {
+	now := l.clock.Now()
+	l.tokens = min(max(l.rate, 1), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
@@ -0,0 +1,54 @@
+// Package throttle paces the requests sent to the DynamoDB, for the Target and the helpers: Backoff slows down the
+// workers being throttled, and RateLimiter shares a capacity budget between them.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// Clock is the source of time of the pacing, as the migrations_dynamodb.Clock, so the tests control it.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock of the time package, used when no Clock is given.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return systemClock{}
+	}
+	return clock
+}
+
+// Sleep waits for the duration of the clock, returning early if the context is done. A nil clock is the system one.
+func Sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clockOrSystem(clock).After(d):
+		return nil
+	}
+}
+
+// IsThrottlingError checks if the error was caused by the DynamoDB throttling the request.
+func IsThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded":
+		return true
+	}
+	return false
+}
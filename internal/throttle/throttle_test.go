@@ -0,0 +1,101 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// stepClock is a Clock whose time only passes when After is called, by the duration waited.
+type stepClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *stepClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+var _ = Describe("Backoff", func() {
+	It("should double the delay when throttled and halve it when succeeding", func() {
+		var backoff Backoff
+		Expect(backoff.Throttled()).To(BeTrue())
+		Expect(backoff.Delay()).To(Equal(25 * time.Millisecond))
+		Expect(backoff.Throttled()).To(BeTrue())
+		Expect(backoff.Delay()).To(Equal(50 * time.Millisecond))
+
+		backoff.Succeeded()
+		Expect(backoff.Delay()).To(Equal(25 * time.Millisecond))
+		backoff.Succeeded()
+		Expect(backoff.Delay()).To(BeZero())
+	})
+
+	It("should give up after being throttled too many times in a row", func() {
+		var backoff Backoff
+		for range backoffMaxThrottle - 1 {
+			Expect(backoff.Throttled()).To(BeTrue())
+		}
+		Expect(backoff.Throttled()).To(BeFalse())
+		Expect(backoff.Delay()).To(Equal(time.Second))
+	})
+
+	It("should wait the delay on its clock", func() {
+		clock := &stepClock{}
+		backoff := Backoff{Clock: clock}
+		backoff.Throttled()
+
+		Expect(backoff.Wait(context.Background())).To(Succeed())
+		Expect(clock.Now()).To(Equal(time.Time{}.Add(25 * time.Millisecond)))
+	})
+})
+
+var _ = Describe("RateLimiter", func() {
+	It("should wait for the budget to refill once consumed", func() {
+		clock := &stepClock{}
+		limiter := NewRateLimiter(clock, 10)
+
+		Expect(limiter.Wait(context.Background(), 0)).To(Succeed())
+		limiter.Consumed(0, 15)
+		Expect(limiter.Wait(context.Background(), 0)).To(Succeed())
+		Expect(clock.Now()).To(Equal(time.Time{}.Add(600 * time.Millisecond)))
+	})
+
+	It("should reserve the units before the requests", func() {
+		clock := &stepClock{}
+		limiter := NewRateLimiter(clock, 10)
+
+		Expect(limiter.Wait(context.Background(), 10)).To(Succeed())
+		Expect(limiter.Wait(context.Background(), 5)).To(Succeed())
+		Expect(clock.Now()).To(Equal(time.Time{}.Add(500 * time.Millisecond)))
+	})
+
+	It("should not limit anything without a rate", func() {
+		limiter := NewRateLimiter(nil, 0)
+		Expect(limiter).To(BeNil())
+		Expect(limiter.Wait(context.Background(), 100)).To(Succeed())
+		limiter.Consumed(0, 100)
+	})
+})
+
+var _ = Describe("IsThrottlingError", func() {
+	It("should match the throttling errors of the DynamoDB only", func() {
+		Expect(IsThrottlingError(&smithy.GenericAPIError{Code: "ThrottlingException"})).To(BeTrue())
+		Expect(IsThrottlingError(&smithy.GenericAPIError{Code: "ValidationException"})).To(BeFalse())
+		Expect(IsThrottlingError(context.Canceled)).To(BeFalse())
+	})
+})
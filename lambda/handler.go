@@ -0,0 +1,171 @@
+// Package lambda adapts the migrations runner to an AWS Lambda handler, for running the migrations on deploy, as a
+// CloudFormation (or CDK) custom resource or a post-deploy hook. The handler does not depend on the Lambda runtime,
+// so it is started by the function as any other handler:
+//
+//	func main() {
+//		lambda.Start(migrationslambda.NewHandler(source).Handle)
+//	}
+//
+// The Target is created from the environment of the function: the AWS configuration is read as by the AWS SDK, and
+// the tables and the lock ID are read from the MIGRATIONS_* variables.
+package lambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jamillosantos/migrations/v2"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// The environment variables the Target is configured from. The defaults of the Target are used for the ones not set.
+const (
+	EnvTableName     = "MIGRATIONS_TABLE_NAME"
+	EnvLockTableName = "MIGRATIONS_LOCK_TABLE_NAME"
+	EnvLockID        = "MIGRATIONS_LOCK_ID"
+)
+
+// RequestTypeDelete is the RequestType of the event sent by CloudFormation when the custom resource is deleted.
+const RequestTypeDelete = "Delete"
+
+// Event is the event the handler is invoked with. Any event works for a post-deploy hook, the fields are only set
+// when invoked as a custom resource.
+type Event struct {
+	// RequestType is "Create", "Update" or "Delete" when invoked as a custom resource. The migrations are run on any
+	// request but the deletion, as the state is kept when the resource is deleted.
+	RequestType string `json:"RequestType,omitempty"`
+	// PhysicalResourceID is the ID of the custom resource, set on updates and deletions.
+	PhysicalResourceID string `json:"PhysicalResourceId,omitempty"`
+}
+
+// Result is what the handler did, returned along with the error when a migration fails.
+type Result struct {
+	// PhysicalResourceID is the ID of the custom resource, the name of the migrations table, as expected by the
+	// custom resource providers.
+	PhysicalResourceID string `json:"PhysicalResourceId"`
+	// Applied are the IDs of the migrations run, and Failed the ones that failed.
+	Applied []string `json:"applied"`
+	Failed  []string `json:"failed,omitempty"`
+	// Current is the ID of the last migration applied, if any.
+	Current string `json:"current,omitempty"`
+}
+
+type opts struct {
+	planner       migrations.ActionPLanner
+	targetOptions []migrations_dynamodb.Option
+	newTarget     func(ctx context.Context, targetOptions []migrations_dynamodb.Option) (Target, error)
+	getenv        func(key string) string
+}
+
+// Option configures the Handler.
+type Option func(*opts)
+
+func defaultOpts() opts {
+	return opts{
+		planner:   migrations.MigratePlanner,
+		newTarget: newTarget,
+		getenv:    os.Getenv,
+	}
+}
+
+// WithPlanner sets the planner of the migrations run. Defaults to migrations.MigratePlanner.
+func WithPlanner(planner migrations.ActionPLanner) Option {
+	return func(o *opts) {
+		o.planner = planner
+	}
+}
+
+// WithTargetOptions sets options of the Target created by the handler. The environment variables take precedence over
+// them.
+func WithTargetOptions(targetOptions ...migrations_dynamodb.Option) Option {
+	return func(o *opts) {
+		o.targetOptions = append(o.targetOptions, targetOptions...)
+	}
+}
+
+// Target is the part of *migrations_dynamodb.Target used by the Handler.
+type Target interface {
+	migrations.Target
+	TableName() string
+}
+
+// Handler runs the migrations of a source on every invocation.
+type Handler struct {
+	opts
+	source migrations.Source
+}
+
+// NewHandler creates a Handler running the migrations of the source.
+func NewHandler(source migrations.Source, options ...Option) *Handler {
+	h := &Handler{opts: defaultOpts(), source: source}
+	for _, opt := range options {
+		opt(&h.opts)
+	}
+	return h
+}
+
+// Handle creates the tables, if they do not exist, and runs the migrations under the lock. The Target is created on
+// every invocation, so a function kept warm picks up the changes of its environment.
+func (h *Handler) Handle(ctx context.Context, event Event) (Result, error) {
+	targetOptions := append(append([]migrations_dynamodb.Option{}, h.targetOptions...), h.envTargetOptions()...)
+	target, err := h.newTarget(ctx, targetOptions)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{PhysicalResourceID: target.TableName(), Applied: []string{}}
+	if event.RequestType == RequestTypeDelete {
+		return result, nil
+	}
+
+	if err := target.Create(ctx); err != nil {
+		return result, fmt.Errorf("failed to create the tables: %w", err)
+	}
+
+	response, err := migrations.Migrate(ctx, h.source, target, migrations.WithPlanner(h.planner))
+	for _, action := range response.Successful {
+		result.Applied = append(result.Applied, action.Migration.ID())
+	}
+	for _, action := range response.Errored {
+		result.Failed = append(result.Failed, action.Migration.ID())
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to migrate: %w", err)
+	}
+
+	current, err := target.Current(ctx)
+	switch {
+	case errors.Is(err, migrations.ErrNoCurrentMigration):
+	case err != nil:
+		return result, fmt.Errorf("failed to get the current migration: %w", err)
+	default:
+		result.Current = current
+	}
+	return result, nil
+}
+
+func (h *Handler) envTargetOptions() []migrations_dynamodb.Option {
+	var r []migrations_dynamodb.Option
+	if tableName := h.getenv(EnvTableName); tableName != "" {
+		r = append(r, migrations_dynamodb.WithTableName(tableName))
+	}
+	if lockTableName := h.getenv(EnvLockTableName); lockTableName != "" {
+		r = append(r, migrations_dynamodb.WithLockTableName(lockTableName))
+	}
+	if lockID := h.getenv(EnvLockID); lockID != "" {
+		r = append(r, migrations_dynamodb.WithLockID(lockID))
+	}
+	return r
+}
+
+func newTarget(ctx context.Context, targetOptions []migrations_dynamodb.Option) (Target, error) {
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the AWS config: %w", err)
+	}
+	return migrations_dynamodb.NewTarget(dynamodb.NewFromConfig(awsConfig), targetOptions...), nil
+}
@@ -0,0 +1,181 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// memoryTarget is a Target keeping the state in memory, so the handler can be tested without a DynamoDB.
+type memoryTarget struct {
+	tableName  string
+	migrations map[string]bool // the migrations recorded, and whether they are dirty.
+	created    bool
+	locked     bool
+}
+
+func (t *memoryTarget) Current(ctx context.Context) (string, error) {
+	done, err := t.Done(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(done) == 0 {
+		return "", migrations.ErrNoCurrentMigration
+	}
+	return done[len(done)-1], nil
+}
+
+func (t *memoryTarget) Create(context.Context) error {
+	t.created = true
+	return nil
+}
+
+func (t *memoryTarget) Destroy(context.Context) error {
+	t.migrations = map[string]bool{}
+	return nil
+}
+
+func (t *memoryTarget) TableName() string {
+	return t.tableName
+}
+
+func (t *memoryTarget) Done(context.Context) ([]string, error) {
+	r := make([]string, 0, len(t.migrations))
+	for id, dirty := range t.migrations {
+		if dirty {
+			return nil, migrations.ErrDirtyMigration
+		}
+		r = append(r, id)
+	}
+	sort.Strings(r)
+	return r, nil
+}
+
+func (t *memoryTarget) Add(_ context.Context, id string) error {
+	if _, ok := t.migrations[id]; ok {
+		return migrations.ErrMigrationAlreadyExists
+	}
+	t.migrations[id] = true
+	return nil
+}
+
+func (t *memoryTarget) Remove(_ context.Context, id string) error {
+	delete(t.migrations, id)
+	return nil
+}
+
+func (t *memoryTarget) FinishMigration(_ context.Context, id string) error {
+	t.migrations[id] = false
+	return nil
+}
+
+func (t *memoryTarget) StartMigration(_ context.Context, id string) error {
+	t.migrations[id] = true
+	return nil
+}
+
+func (t *memoryTarget) Lock(context.Context) (migrations.Unlocker, error) {
+	t.locked = true
+	return t, nil
+}
+
+func (t *memoryTarget) Unlock(context.Context) error {
+	t.locked = false
+	return nil
+}
+
+var _ = Describe("Handler", func() {
+	var (
+		ctx    context.Context
+		target *memoryTarget
+		env    map[string]string
+		source migrations.Source
+	)
+
+	newHandler := func(options ...Option) *Handler {
+		return NewHandler(source, append([]Option{func(o *opts) {
+			o.getenv = func(key string) string {
+				return env[key]
+			}
+			o.newTarget = func(_ context.Context, targetOptions []migrations_dynamodb.Option) (Target, error) {
+				target.tableName = migrations_dynamodb.NewTarget(nil, targetOptions...).TableName()
+				return target, nil
+			}
+		}}, options...)...)
+	}
+
+	addMigration := func(id string, err error) {
+		GinkgoHelper()
+
+		Expect(source.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+			return err
+		}, nil))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		target = &memoryTarget{migrations: map[string]bool{}}
+		env = map[string]string{}
+		source = migrations.NewMemorySource()
+	})
+
+	It("should create the tables and run the migrations", func() {
+		addMigration("0001", nil)
+		addMigration("0002", nil)
+		env[EnvTableName] = "orders-migrations"
+
+		result, err := newHandler().Handle(ctx, Event{RequestType: "Create"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(Result{
+			PhysicalResourceID: "orders-migrations",
+			Applied:            []string{"0001", "0002"},
+			Current:            "0002",
+		}))
+		Expect(target.created).To(BeTrue())
+		Expect(target.locked).To(BeFalse())
+	})
+
+	It("should apply only the pending migrations", func() {
+		addMigration("0001", nil)
+		addMigration("0002", nil)
+		target.migrations["0001"] = false
+
+		result, err := newHandler().Handle(ctx, Event{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Applied).To(Equal([]string{"0002"}))
+	})
+
+	It("should return the failed migration along with the error", func() {
+		errFailed := errors.New("migration failed")
+		addMigration("0001", nil)
+		addMigration("0002", errFailed)
+
+		result, err := newHandler().Handle(ctx, Event{})
+		Expect(err).To(MatchError(errFailed))
+		Expect(result.Applied).To(Equal([]string{"0001"}))
+		Expect(result.Failed).To(Equal([]string{"0002"}))
+	})
+
+	It("should not run the migrations when the custom resource is deleted", func() {
+		addMigration("0001", nil)
+
+		result, err := newHandler().Handle(ctx, Event{RequestType: RequestTypeDelete, PhysicalResourceID: "_migrations"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(Result{PhysicalResourceID: "_migrations", Applied: []string{}}))
+		Expect(target.migrations).To(BeEmpty())
+	})
+
+	It("should prefer the environment over the target options", func() {
+		env[EnvTableName] = "from-env"
+
+		result, err := newHandler(WithTargetOptions(migrations_dynamodb.WithTableName("from-options"))).Handle(ctx, Event{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.PhysicalResourceID).To(Equal("from-env"))
+	})
+})
@@ -0,0 +1,75 @@
+package migrations_dynamodb
+
+import (
+	"context"
+)
+
+// LockedTarget is the Target bound to the lock it acquired with LockTarget (or Lock), for the code changing the
+// migration state outside of a migrations.Migrator. Its writes are made in a transaction checking the lock is still
+// owned by the Target, as with WithTransactionalWrites: when the lock was released, or overwritten by another runner
+// (e.g. by hand), they fail with an ErrLockNotHeld instead of changing the state.
+//
+// It does not make writing without the lock a compile-time error: the Target still has Add, Remove, StartMigration,
+// FinishMigration and FailMigration, as the migrations.Target interface the migrations.Migrator uses requires them.
+// They keep writing unchecked, unless WithTransactionalWrites is set.
+type LockedTarget struct {
+	target   *Target
+	unlocker *unlocker
+}
+
+func newLockedTarget(t *Target, u *unlocker) *LockedTarget {
+	// the copy shares the client, the recorders and the locks held with the Target, only its writes change.
+	locked := *t
+	locked.transactionalWrites = true
+	return &LockedTarget{target: &locked, unlocker: u}
+}
+
+// Current returns the ID of the last migration applied, see Target.Current.
+func (l *LockedTarget) Current(ctx context.Context) (string, error) {
+	return l.target.Current(ctx)
+}
+
+// Done returns the IDs of the migrations applied, see Target.Done.
+func (l *LockedTarget) Done(ctx context.Context) ([]string, error) {
+	return l.target.Done(ctx)
+}
+
+// Add adds a migration marked as dirty, see Target.Add.
+func (l *LockedTarget) Add(ctx context.Context, id string) error {
+	return l.target.Add(ctx, id)
+}
+
+// Remove removes a migration, see Target.Remove.
+func (l *LockedTarget) Remove(ctx context.Context, id string) error {
+	return l.target.Remove(ctx, id)
+}
+
+// StartMigration marks a migration as started, see Target.StartMigration.
+func (l *LockedTarget) StartMigration(ctx context.Context, id string) error {
+	return l.target.StartMigration(ctx, id)
+}
+
+// FinishMigration marks a migration as finished, see Target.FinishMigration.
+func (l *LockedTarget) FinishMigration(ctx context.Context, id string) error {
+	return l.target.FinishMigration(ctx, id)
+}
+
+// FailMigration marks a migration as failed, see Target.FailMigration.
+func (l *LockedTarget) FailMigration(ctx context.Context, id string, reason error) error {
+	return l.target.FailMigration(ctx, id, reason)
+}
+
+// PrepareMigration marks a started migration as prepared, see Target.PrepareMigration.
+func (l *LockedTarget) PrepareMigration(ctx context.Context, id string) error {
+	return l.target.PrepareMigration(ctx, id)
+}
+
+// CommitMigration marks a prepared migration as finished, see Target.CommitMigration.
+func (l *LockedTarget) CommitMigration(ctx context.Context, id string) error {
+	return l.target.CommitMigration(ctx, id)
+}
+
+// Unlock releases the lock. The LockedTarget must not be used afterwards: its writes fail with an ErrLockNotHeld.
+func (l *LockedTarget) Unlock(ctx context.Context) error {
+	return l.unlocker.Unlock(ctx)
+}
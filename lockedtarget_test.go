@@ -0,0 +1,80 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LockedTarget", func() {
+	var (
+		ctx context.Context
+
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should apply the changes while the lock is held", func() {
+		locked, err := target.LockTarget(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = locked.Unlock(ctx)
+		}()
+
+		Expect(locked.Add(ctx, "1")).To(Succeed())
+		Expect(locked.FinishMigration(ctx, "1")).To(Succeed())
+		Expect(locked.Add(ctx, "2")).To(Succeed())
+		Expect(locked.Remove(ctx, "2")).To(Succeed())
+
+		Expect(locked.Current(ctx)).To(Equal("1"))
+		Expect(listMigrations(ctx)).To(Equal([]ddbMigration{
+			{ID: "1", Dirty: false},
+		}))
+	})
+
+	It("should be the Unlocker returned by Lock", func() {
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		locked, ok := u.(*LockedTarget)
+		Expect(ok).To(BeTrue())
+
+		Expect(locked.Add(ctx, "1")).To(Succeed())
+		Expect(u.Unlock(ctx)).To(Succeed())
+		Expect(locked.FinishMigration(ctx, "1")).To(MatchError(ErrLockNotHeld))
+	})
+
+	It("should not apply the changes when the lock was taken by another owner", func() {
+		locked, err := target.LockTarget(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(locked.Add(ctx, "1")).To(Succeed())
+
+		_, err = dynamoDBClient.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("_migrations-lock"),
+			Item: map[string]types.AttributeValue{
+				"id":    &types.AttributeValueMemberS{Value: "migrations"},
+				"owner": &types.AttributeValueMemberS{Value: "another-owner"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(locked.StartMigration(ctx, "1")).To(MatchError(ErrLockNotHeld))
+		Expect(locked.FinishMigration(ctx, "1")).To(MatchError(ErrLockNotHeld))
+		Expect(locked.Remove(ctx, "1")).To(MatchError(ErrLockNotHeld))
+
+		Expect(listMigrations(ctx)).To(Equal([]ddbMigration{
+			{ID: "1", Dirty: true},
+		}))
+	})
+})
@@ -0,0 +1,193 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// LockMany acquires the locks of the scopes all at once, for the migrations spanning several of them, waiting while
+// any of them is held by another runner. The lock of a scope is the one of the Target of the scope, as made by
+// Orchestrator and IterateTenants: the lock ID of this Target followed by "-" and the scope. The locks are written by
+// a single transaction, so either all of them or none are acquired, and a runner never holds some of the locks while
+// waiting for the others, which could deadlock with a runner waiting for the ones it holds. The Unlocker returned
+// releases them together.
+func (t *Target) LockMany(ctx context.Context, scopes ...string) (_ migrations.Unlocker, err error) {
+	defer t.observe(ctx, operationLockMany, t.clock.Now(), &err)
+	defer wrapError(operationLockMany, t.lockTableName, &err)
+
+	lockIDs := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		lockIDs = append(lockIDs, t.lockID+"-"+scope)
+	}
+	// the locks are written in the same order by every runner, so the transactions conflict the same way.
+	slices.Sort(lockIDs)
+	lockIDs = slices.Compact(lockIDs)
+	switch {
+	case len(lockIDs) == 0:
+		return nil, errors.New("no scope to lock")
+	case len(lockIDs) > maxTransactItems:
+		return nil, fmt.Errorf("too many scopes to lock at once: %d, the maximum is %d", len(lockIDs), maxTransactItems)
+	}
+
+	err = t.ensureLockTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.guardMaintenance(ctx, operationLockMany); err != nil {
+		return nil, err
+	}
+
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.AttributeNotExists(expression.Name("id"))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the lock expression: %w", err)
+	}
+	items := make([]types.TransactWriteItem, 0, len(lockIDs))
+	for _, lockID := range lockIDs {
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: &t.lockTableName,
+				Item: map[string]types.AttributeValue{
+					"id":    &types.AttributeValueMemberS{Value: lockID},
+					"owner": &types.AttributeValueMemberS{Value: t.ownerID},
+				},
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+	}
+
+	startedAt := t.clock.Now()
+	for {
+		output, err := t.client.TransactWriteItems(context.WithoutCancel(ctx), &dynamodb.TransactWriteItemsInput{
+			TransactItems:          items,
+			ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		}, t.lockCallOptions()...)
+		if isLockContention(err) {
+			t.logger.Log(ctx, t.waitLogLevel, "locks are held by other runners, waiting", "lock_ids", lockIDs)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("failed to wait for the locks: %w", ctx.Err())
+			case <-t.clock.After(time.Second):
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock before migrating: %w", err)
+		}
+		for i := range output.ConsumedCapacity {
+			t.capacity.record(operationLockMany, &output.ConsumedCapacity[i])
+		}
+		break
+	}
+	waited := t.clock.Now().Sub(startedAt)
+	t.metrics.RecordLockWait(ctx, waited)
+	t.logger.Log(ctx, t.eventLogLevel, "locks acquired", "lock_ids", lockIDs, "owner_id", t.ownerID, "waited", waited)
+	for _, lockID := range lockIDs {
+		t.listener.OnEvent(ctx, LockAcquired{LockID: lockID, OwnerID: t.ownerID, Waited: waited})
+	}
+
+	return &manyUnlocker{target: t, lockIDs: lockIDs}, nil
+}
+
+// isLockContention checks if the transaction acquiring the locks was canceled because one of the locks is held, or
+// because another transaction was acquiring them at the same time.
+func isLockContention(err error) bool {
+	var transactionCanceledException *types.TransactionCanceledException
+	if !errors.As(err, &transactionCanceledException) {
+		return false
+	}
+	for _, reason := range transactionCanceledException.CancellationReasons {
+		switch aws.ToString(reason.Code) {
+		case "ConditionalCheckFailed", "TransactionConflict":
+			return true
+		}
+	}
+	return false
+}
+
+// isConditionCheckFailure checks if the transaction was canceled because the condition of one of its writes failed.
+func isConditionCheckFailure(err error) bool {
+	var transactionCanceledException *types.TransactionCanceledException
+	if !errors.As(err, &transactionCanceledException) {
+		return false
+	}
+	for _, reason := range transactionCanceledException.CancellationReasons {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return true
+		}
+	}
+	return false
+}
+
+// manyUnlocker releases the locks acquired by LockMany together.
+type manyUnlocker struct {
+	target   *Target
+	lockIDs  []string
+	released atomic.Bool
+}
+
+func (u *manyUnlocker) Unlock(ctx context.Context) (err error) {
+	t := u.target
+	defer t.observe(ctx, operationUnlock, t.clock.Now(), &err)
+	defer wrapError(operationUnlock, t.lockTableName, &err)
+
+	// the locks are only released while they are all owned by the Target, not after being taken over by another runner.
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.Name("owner").Equal(expression.Value(t.ownerID))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the unlock expression: %w", err)
+	}
+	items := make([]types.TransactWriteItem, 0, len(u.lockIDs))
+	for _, lockID := range u.lockIDs {
+		items = append(items, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: &t.lockTableName,
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: lockID},
+				},
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+	}
+	output, err := t.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems:          items,
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	switch {
+	case isConditionCheckFailure(err):
+		// releasing the locks again is a no-op.
+		if u.released.Swap(true) {
+			return nil
+		}
+		return ErrLockNotHeld
+	case err != nil:
+		return fmt.Errorf("failed to release the locks: %w", err)
+	}
+	for i := range output.ConsumedCapacity {
+		t.capacity.record(operationUnlock, &output.ConsumedCapacity[i])
+	}
+	if u.released.Swap(true) {
+		return nil
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "locks released", "lock_ids", u.lockIDs)
+	for _, lockID := range u.lockIDs {
+		t.listener.OnEvent(ctx, LockReleased{LockID: lockID})
+	}
+	return nil
+}
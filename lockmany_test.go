@@ -0,0 +1,79 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LockMany", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should acquire the locks of all scopes and release them together", func() {
+		unlocker, err := target.LockMany(ctx, "b", "a", "b")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(NewTarget(dynamoDBClient, WithLockID("migrations-a")).Status(ctx)).To(HaveField("Lock.Held", true))
+		Expect(NewTarget(dynamoDBClient, WithLockID("migrations-b")).Status(ctx)).To(HaveField("Lock.Held", true))
+
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+		Expect(NewTarget(dynamoDBClient, WithLockID("migrations-a")).Status(ctx)).To(HaveField("Lock.Held", false))
+		Expect(NewTarget(dynamoDBClient, WithLockID("migrations-b")).Status(ctx)).To(HaveField("Lock.Held", false))
+	})
+
+	It("should not acquire any lock while one of them is held", func() {
+		unlocker, err := NewTarget(dynamoDBClient, WithLockID("migrations-b")).Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err = target.LockMany(waitCtx, "a", "b")
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(NewTarget(dynamoDBClient, WithLockID("migrations-a")).Status(ctx)).To(HaveField("Lock.Held", false))
+
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+		manyUnlocker, err := target.LockMany(ctx, "a", "b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manyUnlocker.Unlock(ctx)).To(Succeed())
+	})
+
+	It("should not release the locks when one of them was taken by another owner", func() {
+		unlocker, err := target.LockMany(ctx, "a", "b")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = dynamoDBClient.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String("_migrations-lock"),
+			Item: map[string]types.AttributeValue{
+				"id":    &types.AttributeValueMemberS{Value: "migrations-b"},
+				"owner": &types.AttributeValueMemberS{Value: "another-owner"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(unlocker.Unlock(ctx)).To(MatchError(ErrLockNotHeld))
+		Expect(NewTarget(dynamoDBClient, WithLockID("migrations-a")).Status(ctx)).To(HaveField("Lock.Held", true))
+		Expect(NewTarget(dynamoDBClient, WithLockID("migrations-b")).Status(ctx)).To(HaveField("Lock.Held", true))
+	})
+
+	It("should refuse locking no scope", func() {
+		_, err := target.LockMany(ctx)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,148 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// lockWaiterInfix is appended to the lock ID, followed by the owner ID, to make the key of the items of the runners
+	// waiting for the lock with a priority, in the lock table.
+	lockWaiterInfix = "#waiter#"
+
+	// lockTicketSuffix is appended to the lock ID to make the key of the item counting the tickets given to the runners
+	// waiting for the lock, in the lock table.
+	lockTicketSuffix = "#ticket"
+
+	// lockWaiterTimeout is how long a waiter is taken into account after it last tried to acquire the lock, so the
+	// runners that crashed while waiting do not hold the ones with a lower priority back.
+	lockWaiterTimeout = 10 * time.Second
+)
+
+// yieldLock checks if a runner is ahead of the Target in the lock queue (see WithLockPriority), so the Target does not
+// take the lock before it: a runner waiting in a lane with a higher priority, or in the same lane with an earlier
+// ticket. A Target with no ticket yet is behind every runner of its lane.
+//
+// The lock table has no index, so the waiters are read by scanning it. To bound the cost, the waiters are only read
+// when the lock is free: while it is held, the Target waits without scanning, reading the lock alone.
+func (t *Target) yieldLock(ctx context.Context, ticket int64) (bool, error) {
+	if t.lockPriority == nil {
+		return false, nil
+	}
+	lock, err := t.lockTableItem(ctx, operationLock, t.lockID)
+	if err != nil || lock != nil {
+		return false, err
+	}
+	items, err := t.lockTableItemsWithPrefix(ctx, t.lockID+lockWaiterInfix)
+	if err != nil {
+		return false, err
+	}
+	now := t.clock.Now()
+	for _, item := range items {
+		if lockHolder(item) == t.ownerID {
+			continue
+		}
+		var waiter struct {
+			Priority int    `dynamodbav:"priority"`
+			Ticket   int64  `dynamodbav:"ticket"`
+			SeenAt   string `dynamodbav:"seen_at"`
+		}
+		if err := t.codec.unmarshalRecord(item, &waiter); err != nil {
+			return false, fmt.Errorf("failed to unmarshal the lock waiter: %w", err)
+		}
+		if now.Sub(t.codec.parseTimestamp(waiter.SeenAt)) >= lockWaiterTimeout {
+			continue
+		}
+		switch {
+		case waiter.Priority > *t.lockPriority:
+			return true, nil
+		case waiter.Priority == *t.lockPriority && (ticket == 0 || waiter.Ticket < ticket):
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// joinLockQueue records the Target as waiting for the lock with its priority, or refreshes the record, so the runners
+// behind it yield the lock to it. The first time, it takes the next ticket of the lock, setting it to ticket, which
+// orders the waiters of the same lane.
+func (t *Target) joinLockQueue(ctx context.Context, ticket *int64) error {
+	if t.lockPriority == nil {
+		return nil
+	}
+	if *ticket == 0 {
+		next, err := t.takeLockTicket(ctx)
+		if err != nil {
+			return err
+		}
+		*ticket = next
+	}
+	output, err := t.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &t.lockTableName,
+		Item: map[string]types.AttributeValue{
+			"id":       &types.AttributeValueMemberS{Value: t.lockID + lockWaiterInfix + t.ownerID},
+			"owner":    &types.AttributeValueMemberS{Value: t.ownerID},
+			"priority": &types.AttributeValueMemberN{Value: strconv.Itoa(*t.lockPriority)},
+			"ticket":   &types.AttributeValueMemberN{Value: strconv.FormatInt(*ticket, 10)},
+			"seen_at":  &types.AttributeValueMemberS{Value: t.codec.formatTimestamp(t.clock.Now())},
+		},
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to wait in the lock queue: %w", err)
+	}
+	t.capacity.record(operationLock, output.ConsumedCapacity)
+	return nil
+}
+
+// takeLockTicket increments the ticket counter of the lock, returning the new value. The counter is never reset, so
+// the tickets keep increasing across the runs.
+func (t *Target) takeLockTicket(ctx context.Context) (int64, error) {
+	output, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &t.lockTableName,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: t.lockID + lockTicketSuffix},
+		},
+		UpdateExpression:          aws.String("ADD #next :one"),
+		ExpressionAttributeNames:  map[string]string{"#next": "next"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": &types.AttributeValueMemberN{Value: "1"}},
+		ReturnValues:              types.ReturnValueUpdatedNew,
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to take a ticket of the lock queue: %w", err)
+	}
+	t.capacity.record(operationLock, output.ConsumedCapacity)
+	next, ok := output.Attributes["next"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, errors.New("failed to take a ticket of the lock queue: no ticket returned")
+	}
+	ticket, err := strconv.ParseInt(next.Value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the ticket of the lock queue: %w", err)
+	}
+	return ticket, nil
+}
+
+// leaveLockQueue removes the record of the Target waiting for the lock, once it acquired the lock or gave up.
+func (t *Target) leaveLockQueue(ctx context.Context) {
+	output, err := t.client.DeleteItem(context.WithoutCancel(ctx), &dynamodb.DeleteItemInput{
+		TableName: &t.lockTableName,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: t.lockID + lockWaiterInfix + t.ownerID},
+		},
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err != nil {
+		t.logger.WarnContext(ctx, "failed to leave the lock queue", "lock_id", t.lockID, "error", err)
+		return
+	}
+	t.capacity.record(operationLock, output.ConsumedCapacity)
+}
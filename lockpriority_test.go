@@ -0,0 +1,90 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lock priority", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+	})
+
+	It("should give the lock to the waiter with the highest priority first", func() {
+		holder, err := NewTarget(dynamoDBClient).Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		acquired := make(chan string, 2)
+		lock := func(name string, priority int) {
+			go func() {
+				defer GinkgoRecover()
+				unlocker, err := NewTarget(dynamoDBClient, WithLockPriority(priority)).Lock(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				acquired <- name
+				Expect(unlocker.Unlock(ctx)).To(Succeed())
+			}()
+		}
+		waiters := func() (int, error) {
+			items, err := NewTarget(dynamoDBClient).lockTableItemsWithPrefix(ctx, "migrations"+lockWaiterInfix)
+			return len(items), err
+		}
+
+		lock("routine", 0)
+		Eventually(waiters).Should(Equal(1))
+		lock("emergency", 10)
+		Eventually(waiters).Should(Equal(2))
+
+		Expect(holder.Unlock(ctx)).To(Succeed())
+		Eventually(acquired, "5s").Should(Receive(Equal("emergency")))
+		Eventually(acquired, "5s").Should(Receive(Equal("routine")))
+		Eventually(waiters).Should(BeZero())
+	})
+
+	It("should give the lock to the waiters of the same priority in the order they started waiting", func() {
+		holder, err := NewTarget(dynamoDBClient).Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		acquired := make(chan string, 3)
+		lock := func(name string) {
+			go func() {
+				defer GinkgoRecover()
+				unlocker, err := NewTarget(dynamoDBClient, WithLockPriority(0)).Lock(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				acquired <- name
+				Expect(unlocker.Unlock(ctx)).To(Succeed())
+			}()
+		}
+		waiters := func() (int, error) {
+			items, err := NewTarget(dynamoDBClient).lockTableItemsWithPrefix(ctx, "migrations"+lockWaiterInfix)
+			return len(items), err
+		}
+
+		for i, name := range []string{"first", "second", "third"} {
+			lock(name)
+			Eventually(waiters).Should(Equal(i + 1))
+		}
+
+		Expect(holder.Unlock(ctx)).To(Succeed())
+		for _, name := range []string{"first", "second", "third"} {
+			Eventually(acquired, "5s").Should(Receive(Equal(name)))
+		}
+		Eventually(waiters).Should(BeZero())
+	})
+
+	It("should take the lock right away when no one waits for it", func() {
+		target := NewTarget(dynamoDBClient, WithLockPriority(0))
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Status(ctx)).To(HaveField("Lock.Held", true))
+		Expect(target.lockTableItemsWithPrefix(ctx, "migrations"+lockWaiterInfix)).To(BeEmpty())
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+	})
+})
@@ -0,0 +1,23 @@
+package migrations_dynamodb
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// StuckLock describes a Lock call that has been waiting for longer than the threshold set by WithStuckLockAlarm.
+type StuckLock struct {
+	LockID string
+	// Holder is the owner ID of the Target holding the lock, when known.
+	Holder string
+	Waited time.Duration
+}
+
+// lockHolder returns the owner of the given lock item, or an empty string if it is unknown.
+func lockHolder(item map[string]types.AttributeValue) string {
+	if owner, ok := item["owner"].(*types.AttributeValueMemberS); ok {
+		return owner.Value
+	}
+	return ""
+}
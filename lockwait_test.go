@@ -0,0 +1,195 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stuck lock alarm", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	It("should call the hook once when waiting for longer than the threshold", func() {
+		holder := NewTarget(dynamoDBClient, WithOwnerID("holder"))
+		Expect(holder.Create(ctx)).To(Succeed())
+		u, err := holder.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		clock := newFakeClock()
+		stuck := make(chan StuckLock, 10)
+		waiter := NewTarget(dynamoDBClient, WithClock(clock), WithStuckLockAlarm(1500*time.Millisecond, func(_ context.Context, s StuckLock) {
+			stuck <- s
+		}))
+
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+
+			u2, err := waiter.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u2.Unlock(ctx)).To(Succeed())
+		}()
+
+		// waits for the Target to sleep between the attempts to acquire the lock before moving the time forward.
+		advance := func() {
+			GinkgoHelper()
+			Eventually(clock.Waiters).Should(Equal(1))
+			clock.Advance(time.Second)
+		}
+
+		advance()
+		Consistently(stuck, 50*time.Millisecond).ShouldNot(Receive())
+		advance()
+
+		var s StuckLock
+		Eventually(stuck).Should(Receive(&s))
+		Expect(s.LockID).To(Equal("migrations"))
+		Expect(s.Holder).To(Equal("holder"))
+		Expect(s.Waited).To(Equal(2 * time.Second))
+
+		advance()
+		advance()
+		Consistently(stuck, 50*time.Millisecond).ShouldNot(Receive())
+
+		Expect(u.Unlock(ctx)).To(Succeed())
+		advance()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("should not call the hook when the lock is acquired right away", func() {
+		called := false
+		target := NewTarget(dynamoDBClient, WithStuckLockAlarm(0, func(context.Context, StuckLock) {
+			called = true
+		}))
+		Expect(target.Create(ctx)).To(Succeed())
+
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u.Unlock(ctx)).To(Succeed())
+		Expect(called).To(BeFalse())
+	})
+
+	It("should stop waiting for the lock when the context is done", func() {
+		holder := NewTarget(dynamoDBClient, WithOwnerID("holder"))
+		Expect(holder.Create(ctx)).To(Succeed())
+		u, err := holder.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(u.Unlock, ctx)
+
+		lockCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+		_, err = NewTarget(dynamoDBClient).Lock(lockCtx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(ErrorCodeOf(err)).To(Equal(CodeLockTimeout))
+	})
+})
+
+// regionRecordingClient records the region of the calls writing to the lock table, calling the client without the
+// options of the calls, so the items stay in the region of DynamoDB Local.
+type regionRecordingClient struct {
+	*dynamodb.Client
+
+	regions []string
+}
+
+func (c *regionRecordingClient) record(optFns []func(*dynamodb.Options)) {
+	options := c.Client.Options()
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	c.regions = append(c.regions, options.Region)
+}
+
+func (c *regionRecordingClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if aws.ToString(input.TableName) == "_migrations-lock" {
+		c.record(optFns)
+	}
+	return c.Client.PutItem(ctx, input)
+}
+
+func (c *regionRecordingClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if aws.ToString(input.TableName) == "_migrations-lock" {
+		c.record(optFns)
+	}
+	return c.Client.DeleteItem(ctx, input)
+}
+
+func (c *regionRecordingClient) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return c.Client.DescribeTable(ctx, input)
+}
+
+// globalTableClient describes the tables as global tables, replicated to another region.
+type globalTableClient struct {
+	*dynamodb.Client
+}
+
+func (c *globalTableClient) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	output, err := c.Client.DescribeTable(ctx, input, optFns...)
+	if err == nil {
+		output.Table.Replicas = []types.ReplicaDescription{{RegionName: aws.String("eu-west-1")}}
+	}
+	return output, err
+}
+
+// optionsIgnoringClient is a globalTableClient ignoring the options of the calls, as the sdkv1.Client.
+type optionsIgnoringClient struct {
+	globalTableClient
+}
+
+func (*optionsIgnoringClient) IgnoresCallOptions() bool {
+	return true
+}
+
+var _ = Describe("Home region", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+	})
+
+	It("should write the lock to the home region", func() {
+		client := &regionRecordingClient{Client: dynamoDBClient}
+		target := NewTarget(client, WithHomeRegion("eu-west-1"))
+
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+		Expect(client.regions).To(Equal([]string{"eu-west-1", "eu-west-1"}))
+	})
+
+	It("should write the lock to the region of the client without a home region", func() {
+		client := &regionRecordingClient{Client: dynamoDBClient}
+		target := NewTarget(client)
+
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+		Expect(client.regions).To(Equal([]string{dynamoDBClient.Options().Region, dynamoDBClient.Options().Region}))
+	})
+
+	It("should refuse to lock a global table without a home region", func() {
+		_, err := NewTarget(&globalTableClient{Client: dynamoDBClient}).Lock(ctx)
+		Expect(err).To(MatchError(ContainSubstring("WithHomeRegion")))
+	})
+
+	It("should refuse to lock a global table with a client ignoring the home region", func() {
+		client := &optionsIgnoringClient{globalTableClient{Client: dynamoDBClient}}
+		_, err := NewTarget(client, WithHomeRegion("eu-west-1"), WithDebug()).Lock(ctx)
+		Expect(err).To(MatchError(ContainSubstring("the client ignores the options")))
+	})
+})
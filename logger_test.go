@@ -0,0 +1,132 @@
+package migrations_dynamodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		ctx context.Context
+
+		buf    *syncBuffer
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		buf = &syncBuffer{}
+		target = NewTarget(dynamoDBClient, WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))))
+	})
+
+	It("should log the significant events", func() {
+		Expect(target.Create(ctx)).To(Succeed())
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+		Expect(target.Remove(ctx, "1")).To(Succeed())
+		Expect(u.Unlock(ctx)).To(Succeed())
+
+		Expect(logMessages(buf)).To(Equal([]string{
+			"table created",
+			"table created",
+			"lock acquired",
+			"migration added",
+			"migration finished",
+			"migration started",
+			"migration removed",
+			"lock released",
+		}))
+	})
+
+	It("should log the waits for the lock at the debug level", func() {
+		Expect(target.Create(ctx)).To(Succeed())
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+
+			u2, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u2.Unlock(ctx)).To(Succeed())
+		}()
+
+		Eventually(buf.String).Should(ContainSubstring(`"level":"DEBUG","msg":"lock is held by another runner, waiting"`))
+		Expect(u.Unlock(ctx)).To(Succeed())
+		Eventually(done).WithTimeout(5 * time.Second).Should(BeClosed())
+	})
+
+	It("should log the retries, with their attempt and error, at the levels set", func() {
+		transport := &failingTransport{next: http.DefaultTransport}
+		transport.failures.Store(1)
+		client := dynamodb.New(dynamoDBClient.Options(), func(o *dynamodb.Options) {
+			o.HTTPClient = &http.Client{Transport: transport}
+		})
+		target := NewTarget(client,
+			WithLogger(slog.New(slog.NewJSONHandler(buf, nil))),
+			WithLogLevels(slog.LevelWarn, slog.LevelInfo),
+			WithRetryer(retry.NewStandard(func(o *retry.StandardOptions) {
+				o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			})),
+		)
+
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring(`"level":"INFO","msg":"request retried","attempt":1,`))
+		Expect(buf.String()).To(ContainSubstring(`"error":`))
+		Expect(buf.String()).To(ContainSubstring(`"level":"WARN","msg":"table created"`))
+	})
+})
+
+// syncBuffer is a bytes.Buffer safe to be written and read concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func logMessages(buf *syncBuffer) []string {
+	GinkgoHelper()
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry struct {
+			Msg string `json:"msg"`
+		}
+		Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+		messages = append(messages, entry.Msg)
+	}
+	return messages
+}
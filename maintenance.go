@@ -0,0 +1,157 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// freezeSuffix is appended to the lock ID to make the key of the item freezing the migrations, in the lock table.
+const freezeSuffix = "#freeze"
+
+// ErrMaintenanceClosed is returned by Lock, Add, StartMigration, Remove and RemoveAfter when the migrations are not
+// allowed to run: outside the maintenance windows set by WithMaintenanceWindows, or while they are frozen, with
+// WithFreezeCheck (see Target.Freeze).
+var ErrMaintenanceClosed = errors.New("migrations are not allowed to run now")
+
+// MaintenanceError is the error returned when the migrations are not allowed to run. It matches ErrMaintenanceClosed
+// with `errors.Is`.
+type MaintenanceError struct {
+	// Operation is the Target method that was rejected (e.g. "Lock" or "Add").
+	Operation string
+	// Frozen is whether the migrations are frozen. If false, the operation was outside the maintenance windows.
+	Frozen bool
+	// Reason is the reason given to Target.Freeze, when frozen.
+	Reason string
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.Frozen {
+		return fmt.Sprintf("%s rejected, migrations are frozen: %s", e.Operation, e.Reason)
+	}
+	return fmt.Sprintf("%s rejected, outside the maintenance windows", e.Operation)
+}
+
+func (e *MaintenanceError) Is(target error) bool {
+	return target == ErrMaintenanceClosed
+}
+
+// MaintenanceWindow is a time of the day, on some days of the week, when the migrations are allowed to run (see
+// WithMaintenanceWindows).
+type MaintenanceWindow struct {
+	// Days are the days of the week of the window, when it starts. Every day, if empty.
+	Days []time.Weekday
+	// Start and End are the times of the day, since midnight, when the window opens and closes. A window ending before
+	// it starts ends on the next day, e.g. from 22h to 2h.
+	Start, End time.Duration
+	// Location is the time zone of the window. Defaults to UTC.
+	Location *time.Location
+}
+
+// Contains checks if the time is in the window.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	location := w.Location
+	if location == nil {
+		location = time.UTC
+	}
+	t = t.In(location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+	elapsed := t.Sub(midnight)
+
+	switch {
+	case w.Start <= w.End:
+		return w.onDay(t.Weekday()) && elapsed >= w.Start && elapsed < w.End
+	case elapsed >= w.Start:
+		return w.onDay(t.Weekday())
+	case elapsed < w.End:
+		// the window started on the day before.
+		return w.onDay(midnight.AddDate(0, 0, -1).Weekday())
+	}
+	return false
+}
+
+func (w MaintenanceWindow) onDay(day time.Weekday) bool {
+	return len(w.Days) == 0 || slices.Contains(w.Days, day)
+}
+
+// guardMaintenance returns a MaintenanceError if the operation is outside the maintenance windows, or if the
+// migrations are frozen, with WithFreezeCheck.
+func (t *Target) guardMaintenance(ctx context.Context, operation string) error {
+	if len(t.maintenanceWindows) > 0 {
+		now := t.clock.Now()
+		if !slices.ContainsFunc(t.maintenanceWindows, func(w MaintenanceWindow) bool { return w.Contains(now) }) {
+			return &MaintenanceError{Operation: operation}
+		}
+	}
+	if !t.freezeCheck {
+		return nil
+	}
+	frozen, reason, err := t.frozen(ctx, operation)
+	if err != nil {
+		return err
+	}
+	if frozen {
+		return &MaintenanceError{Operation: operation, Frozen: true, Reason: reason}
+	}
+	return nil
+}
+
+// frozen checks if the freeze item exists, returning its reason.
+func (t *Target) frozen(ctx context.Context, operation string) (bool, string, error) {
+	item, err := t.lockTableItem(ctx, operation, t.lockID+freezeSuffix)
+	if err != nil || item == nil {
+		return false, "", err
+	}
+	if reason, ok := item["reason"].(*types.AttributeValueMemberS); ok {
+		return true, reason.Value, nil
+	}
+	return true, "", nil
+}
+
+// Freeze writes the item freezing the migrations in the lock table, so the Targets checking it (see WithFreezeCheck)
+// reject the migrations until Unfreeze is called, e.g. during an incident or a release freeze. The reason is returned
+// in their MaintenanceError.
+func (t *Target) Freeze(ctx context.Context, reason string) (err error) {
+	defer wrapError(operationFreeze, t.lockTableName, &err)
+
+	output, err := t.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &t.lockTableName,
+		Item: map[string]types.AttributeValue{
+			"id":         &types.AttributeValueMemberS{Value: t.lockID + freezeSuffix},
+			"reason":     &types.AttributeValueMemberS{Value: reason},
+			"frozen_at":  &types.AttributeValueMemberS{Value: t.codec.formatTimestamp(t.clock.Now())},
+			"applied_by": &types.AttributeValueMemberS{Value: t.auditActor},
+		},
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to freeze the migrations: %w", err)
+	}
+	t.capacity.record(operationFreeze, output.ConsumedCapacity)
+	t.logger.WarnContext(ctx, "migrations frozen", "reason", reason)
+	return nil
+}
+
+// Unfreeze removes the item written by Freeze. It does nothing if the migrations are not frozen.
+func (t *Target) Unfreeze(ctx context.Context) (err error) {
+	defer wrapError(operationUnfreeze, t.lockTableName, &err)
+
+	output, err := t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: &t.lockTableName,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: t.lockID + freezeSuffix},
+		},
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to unfreeze the migrations: %w", err)
+	}
+	t.capacity.record(operationUnfreeze, output.ConsumedCapacity)
+	t.logger.Log(ctx, t.eventLogLevel, "migrations unfrozen")
+	return nil
+}
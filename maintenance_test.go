@@ -0,0 +1,77 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaintenanceWindow", func() {
+	DescribeTable("Contains",
+		func(window MaintenanceWindow, at string, expected bool) {
+			t, err := time.Parse(time.RFC3339, at)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(window.Contains(t)).To(Equal(expected))
+		},
+		Entry("in the window", MaintenanceWindow{Start: 2 * time.Hour, End: 4 * time.Hour}, "2024-01-01T03:00:00Z", true),
+		Entry("at its end", MaintenanceWindow{Start: 2 * time.Hour, End: 4 * time.Hour}, "2024-01-01T04:00:00Z", false),
+		Entry("on another day", MaintenanceWindow{Days: []time.Weekday{time.Sunday}, Start: 2 * time.Hour, End: 4 * time.Hour}, "2024-01-01T03:00:00Z", false),
+		Entry("after midnight, started the day before", MaintenanceWindow{Days: []time.Weekday{time.Sunday}, Start: 22 * time.Hour, End: 2 * time.Hour}, "2024-01-01T01:00:00Z", true),
+		Entry("after midnight, started on another day", MaintenanceWindow{Days: []time.Weekday{time.Monday}, Start: 22 * time.Hour, End: 2 * time.Hour}, "2024-01-01T01:00:00Z", false),
+		Entry("in its location", MaintenanceWindow{Start: 0, End: time.Hour, Location: time.FixedZone("UTC-3", -3*60*60)}, "2024-01-01T03:30:00Z", true),
+	)
+})
+
+var _ = Describe("Maintenance", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	It("should reject the migrations outside the maintenance windows", func() {
+		clock := newFakeClock()
+		target := NewTarget(dynamoDBClient, WithClock(clock), WithMaintenanceWindows(MaintenanceWindow{
+			Start: 2 * time.Hour,
+			End:   4 * time.Hour,
+		}))
+		Expect(target.Create(ctx)).To(Succeed())
+
+		_, err := target.Lock(ctx)
+		Expect(err).To(MatchError(ErrMaintenanceClosed))
+		Expect(ErrorCodeOf(err)).To(Equal(CodeMaintenance))
+		Expect(target.Add(ctx, "1")).To(MatchError(ErrMaintenanceClosed))
+
+		clock.Advance(3 * time.Hour)
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+
+		clock.Advance(2 * time.Hour)
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+	})
+
+	It("should reject the migrations while they are frozen", func() {
+		target := NewTarget(dynamoDBClient, WithFreezeCheck())
+		Expect(target.Create(ctx)).To(Succeed())
+
+		Expect(target.Freeze(ctx, "incident 42")).To(Succeed())
+		_, err := target.Lock(ctx)
+		Expect(err).To(MatchError(ErrMaintenanceClosed))
+		var maintenanceErr *MaintenanceError
+		Expect(errors.As(err, &maintenanceErr)).To(BeTrue())
+		Expect(maintenanceErr.Frozen).To(BeTrue())
+		Expect(maintenanceErr.Reason).To(Equal("incident 42"))
+
+		Expect(NewTarget(dynamoDBClient).Add(ctx, "1")).To(Succeed())
+
+		Expect(target.Unfreeze(ctx)).To(Succeed())
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u.Unlock(ctx)).To(Succeed())
+	})
+})
@@ -0,0 +1,85 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// SetMetadata records the value under the key in the metadata of the migration, replacing the value recorded before,
+// so what a migration did (e.g. the ARN of the export made before changing a table) can be found in its History. If
+// the migration does not exist, it will return an `migrations.ErrMigrationNotFound`.
+func (t *Target) SetMetadata(ctx context.Context, id, key, value string) (err error) {
+	defer t.observe(ctx, operationSetMetadata, t.clock.Now(), &err)
+	defer wrapError(operationSetMetadata, t.tableName, &err)
+
+	itemKey := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+	// The value is set in the metadata map if it exists, else the map is created with it. A map created concurrently
+	// between the two makes the second fail, and the first is tried again.
+	var before map[string]types.AttributeValue
+	for attempt := 0; ; attempt++ {
+		update, condition := metadataUpdate(attempt, key, value)
+		expr, err := expression.NewBuilder().
+			WithUpdate(update).
+			WithCondition(t.withWriteCondition(expression.AttributeExists(expression.Name("id")).And(condition))).
+			Build()
+		if err != nil {
+			return fmt.Errorf("failed to build the update expression: %w", err)
+		}
+
+		output, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 &t.tableName,
+			Key:                       itemKey,
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ReturnValues:              types.ReturnValueAllOld,
+			ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		})
+		var conditionalCheckFailedException *types.ConditionalCheckFailedException
+		switch {
+		case errors.As(err, &conditionalCheckFailedException) && attempt < 2:
+			continue
+		case errors.As(err, &conditionalCheckFailedException):
+			return migrations.ErrMigrationNotFound
+		case err != nil:
+			return fmt.Errorf("failed to set migration metadata: %w", err)
+		}
+		t.capacity.record(operationSetMetadata, output.ConsumedCapacity)
+		before = output.Attributes
+		break
+	}
+
+	metadata := map[string]types.AttributeValue{}
+	if m, ok := before[attributeMetadata].(*types.AttributeValueMemberM); ok {
+		for k, v := range m.Value {
+			metadata[k] = v
+		}
+	}
+	metadata[key] = &types.AttributeValueMemberS{Value: value}
+	after := withAttributes(before, map[string]types.AttributeValue{
+		attributeMetadata: &types.AttributeValueMemberM{Value: metadata},
+	})
+	t.audit(ctx, operationSetMetadata, id, before, after)
+	t.logger.Log(ctx, t.eventLogLevel, "migration metadata set", "id", id, "key", key)
+	return nil
+}
+
+// metadataUpdate returns the update setting the metadata value, and its condition, for the attempt: the even ones set
+// the value in the existing map, and the odd ones create the map.
+func metadataUpdate(attempt int, key, value string) (expression.UpdateBuilder, expression.ConditionBuilder) {
+	if attempt%2 == 0 {
+		return expression.Set(expression.Name(attributeMetadata).AppendName(expression.NameNoDotSplit(key)), expression.Value(value)),
+			expression.AttributeExists(expression.Name(attributeMetadata))
+	}
+	return expression.Set(expression.Name(attributeMetadata), expression.Value(map[string]string{key: value})),
+		expression.AttributeNotExists(expression.Name(attributeMetadata))
+}
@@ -0,0 +1,50 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/jamillosantos/migrations-dynamodb/helpers"
+)
+
+var _ helpers.MetadataRecorder = (*Target)(nil)
+
+var _ = Describe("SetMetadata", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should record the metadata in the history of the migration", func() {
+		Expect(target.Add(ctx, "1")).To(Succeed())
+
+		Expect(target.SetMetadata(ctx, "1", "export_arn:users", "arn:aws:dynamodb:us-east-1:000000000000:table/users/export/1")).To(Succeed())
+		Expect(target.SetMetadata(ctx, "1", "users.rows", "10")).To(Succeed())
+		Expect(target.SetMetadata(ctx, "1", "users.rows", "12")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].Metadata).To(Equal(map[string]string{
+			"export_arn:users": "arn:aws:dynamodb:us-east-1:000000000000:table/users/export/1",
+			"users.rows":       "12",
+		}))
+	})
+
+	It("should fail with ErrMigrationNotFound when the migration does not exist", func() {
+		Expect(target.SetMetadata(ctx, "1", "key", "value")).To(MatchError(migrations.ErrMigrationNotFound))
+	})
+})
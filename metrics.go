@@ -0,0 +1,29 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsRecorder receives the metrics of the operations executed by a Target. The `otelmetrics` and `prommetrics`
+// packages provide implementations for OpenTelemetry and Prometheus.
+type MetricsRecorder interface {
+	// RecordOperation is called after each operation of the Target (Create, Destroy, Done, Add, Remove,
+	// StartMigration, FinishMigration, Lock, Unlock and Ping) with its duration and the error it returned, if any.
+	RecordOperation(ctx context.Context, operation string, duration time.Duration, err error)
+
+	// RecordLockWait is called when the lock is acquired with how long the Target waited for it.
+	RecordLockWait(ctx context.Context, duration time.Duration)
+}
+
+type noopMetricsRecorder struct{}
+
+func (noopMetricsRecorder) RecordOperation(context.Context, string, time.Duration, error) {}
+
+func (noopMetricsRecorder) RecordLockWait(context.Context, time.Duration) {}
+
+// observe records the metrics of an operation started at startedAt. It is meant to be deferred by the operations with
+// a pointer to their returned error.
+func (t *Target) observe(ctx context.Context, operation string, startedAt time.Time, err *error) {
+	t.metrics.RecordOperation(ctx, operation, t.clock.Now().Sub(startedAt), *err)
+}
@@ -0,0 +1,81 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordedOperation struct {
+	operation string
+	failed    bool
+}
+
+type fakeMetricsRecorder struct {
+	mu         sync.Mutex
+	operations []recordedOperation
+	lockWaits  []time.Duration
+}
+
+func (r *fakeMetricsRecorder) RecordOperation(_ context.Context, operation string, _ time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, recordedOperation{operation: operation, failed: err != nil})
+}
+
+func (r *fakeMetricsRecorder) RecordLockWait(_ context.Context, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lockWaits = append(r.lockWaits, duration)
+}
+
+var _ = Describe("Metrics", func() {
+	var (
+		ctx context.Context
+
+		recorder *fakeMetricsRecorder
+		target   *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		recorder = &fakeMetricsRecorder{}
+		target = NewTarget(dynamoDBClient, WithMetricsRecorder(recorder))
+	})
+
+	It("should record each operation of the target", func() {
+		Expect(target.Create(ctx)).To(Succeed())
+
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(MatchError(migrations.ErrMigrationAlreadyExists))
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		_, err = target.Done(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Remove(ctx, "1")).To(Succeed())
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+
+		Expect(recorder.operations).To(Equal([]recordedOperation{
+			{operation: operationCreate},
+			{operation: operationLock},
+			{operation: operationAdd},
+			{operation: operationAdd, failed: true},
+			{operation: operationStartMigration},
+			{operation: operationFinishMigration},
+			{operation: operationDone},
+			{operation: operationRemove},
+			{operation: operationUnlock},
+		}))
+		Expect(recorder.lockWaits).To(HaveLen(1))
+	})
+})
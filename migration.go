@@ -3,4 +3,5 @@ package migrations_dynamodb
 type ddbMigration struct {
 	ID    string
 	Dirty bool
+	Phase string `dynamodbav:"phase"`
 }
@@ -0,0 +1,155 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// Migrator runs the migrations of a source on a Target under its lock, reporting their progress, as the single entry
+// point of the services running their migrations on start. The Target, the planner, the reporters and the approval are
+// configured with the same options as RunMigrations, and the repair of what crashed runners left behind with
+// WithRepairPolicy.
+type Migrator struct {
+	source migrations.Source
+	target *Target
+	opts   runOpts
+}
+
+// MigrationOutcome is the outcome of a migration executed by Migrator.Run.
+type MigrationOutcome struct {
+	ID          string
+	Description string
+	Action      migrations.ActionType
+	Duration    time.Duration
+	// Err is the error the migration failed with, if it failed.
+	Err error
+}
+
+// MigratorResult is the result of Migrator.Run.
+type MigratorResult struct {
+	// Applied are the migrations executed successfully, in order.
+	Applied []MigrationOutcome
+	// Failed are the migrations that failed. As the run stops at the first failure, there is at most one.
+	Failed []MigrationOutcome
+	// Skipped are the IDs of the migrations planned but not executed, because a migration before them failed.
+	Skipped []string
+	// Current is the ID of the last migration applied once the run finished, or empty if there is none.
+	Current string
+	// Duration is how long the run took, including waiting for the lock.
+	Duration time.Duration
+}
+
+// AppliedIDs returns the IDs of the migrations executed successfully, in order.
+func (r *MigratorResult) AppliedIDs() []string {
+	ids := make([]string, 0, len(r.Applied))
+	for _, outcome := range r.Applied {
+		ids = append(ids, outcome.ID)
+	}
+	return ids
+}
+
+// NewMigrator creates a Migrator for the migrations of the source, on a Target created with the client and the options
+// set by WithTargetOptions.
+func NewMigrator(client DynamoDBClient, source migrations.Source, options ...RunOption) *Migrator {
+	o := runOpts{planner: migrations.MigratePlanner}
+	for _, opt := range options {
+		opt(&o)
+	}
+	return &Migrator{
+		source: source,
+		target: NewTarget(client, o.targetOptions...),
+		opts:   o,
+	}
+}
+
+// Target returns the Target the migrations are run on, e.g. to check its Status.
+func (m *Migrator) Target() *Target {
+	return m.target
+}
+
+// Run creates the tables of the Target if they do not exist and runs the migrations under the lock. The result is
+// returned even if the run fails, with what was executed until then.
+func (m *Migrator) Run(ctx context.Context) (*MigratorResult, error) {
+	startedAt := m.target.clock.Now()
+	reporter := &resultReporter{clock: m.target.clock}
+
+	_, err := m.migrate(ctx, reporter)
+
+	result := &reporter.result
+	result.Skipped = reporter.skipped()
+	result.Duration = m.target.clock.Now().Sub(startedAt)
+	if len(result.Applied) > 0 {
+		result.Current = result.Applied[len(result.Applied)-1].ID
+	} else if current, currentErr := m.target.Current(ctx); currentErr == nil {
+		result.Current = current
+	}
+	return result, err
+}
+
+// migrate runs the migrations, reporting their progress to the reporters of the options and to the given ones.
+func (m *Migrator) migrate(ctx context.Context, reporters ...migrations.RunnerReporter) (migrations.ExecutionResponse, error) {
+	if err := m.target.Create(ctx); err != nil {
+		return migrations.ExecutionResponse{}, fmt.Errorf("failed to create the migrations tables: %w", err)
+	}
+
+	planner := m.opts.planner
+	if m.opts.approval {
+		planner = ApprovalPlanner(planner, m.target, m.opts.approvalWait)
+	}
+
+	all := append([]migrations.RunnerReporter{&logReporter{logger: m.target.logger, level: m.target.eventLogLevel, clock: m.target.clock}}, m.opts.reporters...)
+	return migrations.Migrate(ctx, m.source, m.target,
+		migrations.WithPlanner(planner),
+		migrations.WithRunnerOptions(migrations.WithReporter(multiReporter(append(all, reporters...)))),
+	)
+}
+
+// resultReporter builds the MigratorResult from the progress of the runner.
+type resultReporter struct {
+	clock     Clock
+	plan      migrations.Plan
+	executed  int
+	startedAt time.Time
+	result    MigratorResult
+}
+
+func (r *resultReporter) BeforeExecute(_ context.Context, info *migrations.BeforeExecuteInfo) {
+	r.plan = info.Plan
+}
+
+func (r *resultReporter) BeforeExecuteMigration(context.Context, *migrations.BeforeExecuteMigrationInfo) {
+	r.startedAt = r.clock.Now()
+}
+
+func (r *resultReporter) AfterExecuteMigration(_ context.Context, info *migrations.AfterExecuteMigrationInfo) {
+	r.executed++
+	outcome := MigrationOutcome{
+		ID:          info.Migration.ID(),
+		Description: info.Migration.Description(),
+		Action:      info.ActionType,
+		Duration:    r.clock.Now().Sub(r.startedAt),
+		Err:         info.Err,
+	}
+	if info.Err != nil {
+		r.result.Failed = append(r.result.Failed, outcome)
+		return
+	}
+	r.result.Applied = append(r.result.Applied, outcome)
+}
+
+func (r *resultReporter) AfterExecute(context.Context, *migrations.AfterExecuteInfo) {}
+
+// skipped returns the IDs of the migrations planned after the ones executed.
+func (r *resultReporter) skipped() []string {
+	if r.executed >= len(r.plan) {
+		return nil
+	}
+	ids := make([]string, 0, len(r.plan)-r.executed)
+	for _, action := range r.plan[r.executed:] {
+		ids = append(ids, action.Migration.ID())
+	}
+	return ids
+}
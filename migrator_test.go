@@ -0,0 +1,84 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrator", func() {
+	var (
+		ctx    context.Context
+		source migrations.Source
+	)
+
+	addMigration := func(id string, err error) {
+		GinkgoHelper()
+
+		Expect(source.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+			return err
+		}, nil))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		source = migrations.NewMemorySource()
+	})
+
+	It("should run the migrations and return what was applied", func() {
+		addMigration("0001", nil)
+		addMigration("0002", nil)
+		reporter := &recordingReporter{}
+
+		migrator := NewMigrator(dynamoDBClient, source, WithReporter(reporter))
+		result, err := migrator.Run(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.AppliedIDs()).To(Equal([]string{"0001", "0002"}))
+		Expect(result.Applied[0].Description).To(Equal("migration 0001"))
+		Expect(result.Applied[0].Action).To(Equal(migrations.ActionTypeDo))
+		Expect(result.Failed).To(BeEmpty())
+		Expect(result.Skipped).To(BeEmpty())
+		Expect(result.Current).To(Equal("0002"))
+		Expect(reporter.executed).To(Equal([]string{"do 0001", "do 0002"}))
+		Expect(migrator.Target().Done(ctx)).To(Equal([]string{"0001", "0002"}))
+
+		result, err = migrator.Run(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Applied).To(BeEmpty())
+		Expect(result.Current).To(Equal("0002"))
+	})
+
+	It("should return the migration that failed and the ones skipped after it", func() {
+		errFailed := errors.New("migration failed")
+		addMigration("0001", nil)
+		addMigration("0002", errFailed)
+		addMigration("0003", nil)
+
+		result, err := NewMigrator(dynamoDBClient, source).Run(ctx)
+		Expect(err).To(MatchError(errFailed))
+		Expect(result.AppliedIDs()).To(Equal([]string{"0001"}))
+		Expect(result.Failed).To(HaveLen(1))
+		Expect(result.Failed[0].ID).To(Equal("0002"))
+		Expect(result.Failed[0].Err).To(MatchError(errFailed))
+		Expect(result.Skipped).To(Equal([]string{"0003"}))
+	})
+
+	It("should repair the stale dirty migrations with the repair policy", func() {
+		addMigration("0001", nil)
+		target := NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "0001")).To(Succeed())
+		Expect(target.StartMigration(ctx, "0001")).To(Succeed())
+
+		result, err := NewMigrator(dynamoDBClient, source, WithRepairPolicy(StaleDirtyRemove, time.Nanosecond)).Run(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.AppliedIDs()).To(Equal([]string{"0001"}))
+	})
+})
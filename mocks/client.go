@@ -0,0 +1,266 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/jamillosantos/migrations-dynamodb (interfaces: DynamoDBClient,UnlockDynamoDBClient)
+//
+// Generated by this command:
+//
+//	mockgen -destination=client.go -package=mocks -mock_names=DynamoDBClient=DynamoDBClient,UnlockDynamoDBClient=UnlockDynamoDBClient github.com/jamillosantos/migrations-dynamodb DynamoDBClient,UnlockDynamoDBClient
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	dynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// DynamoDBClient is a mock of DynamoDBClient interface.
+type DynamoDBClient struct {
+	ctrl     *gomock.Controller
+	recorder *DynamoDBClientMockRecorder
+	isgomock struct{}
+}
+
+// DynamoDBClientMockRecorder is the mock recorder for DynamoDBClient.
+type DynamoDBClientMockRecorder struct {
+	mock *DynamoDBClient
+}
+
+// NewDynamoDBClient creates a new mock instance.
+func NewDynamoDBClient(ctrl *gomock.Controller) *DynamoDBClient {
+	mock := &DynamoDBClient{ctrl: ctrl}
+	mock.recorder = &DynamoDBClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *DynamoDBClient) EXPECT() *DynamoDBClientMockRecorder {
+	return m.recorder
+}
+
+// CreateTable mocks base method.
+func (m *DynamoDBClient) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateTable", varargs...)
+	ret0, _ := ret[0].(*dynamodb.CreateTableOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTable indicates an expected call of CreateTable.
+func (mr *DynamoDBClientMockRecorder) CreateTable(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTable", reflect.TypeOf((*DynamoDBClient)(nil).CreateTable), varargs...)
+}
+
+// DeleteItem mocks base method.
+func (m *DynamoDBClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteItem", varargs...)
+	ret0, _ := ret[0].(*dynamodb.DeleteItemOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteItem indicates an expected call of DeleteItem.
+func (mr *DynamoDBClientMockRecorder) DeleteItem(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItem", reflect.TypeOf((*DynamoDBClient)(nil).DeleteItem), varargs...)
+}
+
+// DeleteTable mocks base method.
+func (m *DynamoDBClient) DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteTable", varargs...)
+	ret0, _ := ret[0].(*dynamodb.DeleteTableOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTable indicates an expected call of DeleteTable.
+func (mr *DynamoDBClientMockRecorder) DeleteTable(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTable", reflect.TypeOf((*DynamoDBClient)(nil).DeleteTable), varargs...)
+}
+
+// DescribeTable mocks base method.
+func (m *DynamoDBClient) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DescribeTable", varargs...)
+	ret0, _ := ret[0].(*dynamodb.DescribeTableOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeTable indicates an expected call of DescribeTable.
+func (mr *DynamoDBClientMockRecorder) DescribeTable(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeTable", reflect.TypeOf((*DynamoDBClient)(nil).DescribeTable), varargs...)
+}
+
+// ListTables mocks base method.
+func (m *DynamoDBClient) ListTables(ctx context.Context, d *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, d}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListTables", varargs...)
+	ret0, _ := ret[0].(*dynamodb.ListTablesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTables indicates an expected call of ListTables.
+func (mr *DynamoDBClientMockRecorder) ListTables(ctx, d any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, d}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTables", reflect.TypeOf((*DynamoDBClient)(nil).ListTables), varargs...)
+}
+
+// PutItem mocks base method.
+func (m *DynamoDBClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutItem", varargs...)
+	ret0, _ := ret[0].(*dynamodb.PutItemOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutItem indicates an expected call of PutItem.
+func (mr *DynamoDBClientMockRecorder) PutItem(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutItem", reflect.TypeOf((*DynamoDBClient)(nil).PutItem), varargs...)
+}
+
+// Scan mocks base method.
+func (m *DynamoDBClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(*dynamodb.ScanOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *DynamoDBClientMockRecorder) Scan(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*DynamoDBClient)(nil).Scan), varargs...)
+}
+
+// TransactWriteItems mocks base method.
+func (m *DynamoDBClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "TransactWriteItems", varargs...)
+	ret0, _ := ret[0].(*dynamodb.TransactWriteItemsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransactWriteItems indicates an expected call of TransactWriteItems.
+func (mr *DynamoDBClientMockRecorder) TransactWriteItems(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactWriteItems", reflect.TypeOf((*DynamoDBClient)(nil).TransactWriteItems), varargs...)
+}
+
+// UpdateItem mocks base method.
+func (m *DynamoDBClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateItem", varargs...)
+	ret0, _ := ret[0].(*dynamodb.UpdateItemOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateItem indicates an expected call of UpdateItem.
+func (mr *DynamoDBClientMockRecorder) UpdateItem(ctx, input any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItem", reflect.TypeOf((*DynamoDBClient)(nil).UpdateItem), varargs...)
+}
+
+// UnlockDynamoDBClient is a mock of UnlockDynamoDBClient interface.
+type UnlockDynamoDBClient struct {
+	ctrl     *gomock.Controller
+	recorder *UnlockDynamoDBClientMockRecorder
+	isgomock struct{}
+}
+
+// UnlockDynamoDBClientMockRecorder is the mock recorder for UnlockDynamoDBClient.
+type UnlockDynamoDBClientMockRecorder struct {
+	mock *UnlockDynamoDBClient
+}
+
+// NewUnlockDynamoDBClient creates a new mock instance.
+func NewUnlockDynamoDBClient(ctrl *gomock.Controller) *UnlockDynamoDBClient {
+	mock := &UnlockDynamoDBClient{ctrl: ctrl}
+	mock.recorder = &UnlockDynamoDBClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *UnlockDynamoDBClient) EXPECT() *UnlockDynamoDBClientMockRecorder {
+	return m.recorder
+}
+
+// DeleteItem mocks base method.
+func (m *UnlockDynamoDBClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteItem", varargs...)
+	ret0, _ := ret[0].(*dynamodb.DeleteItemOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteItem indicates an expected call of DeleteItem.
+func (mr *UnlockDynamoDBClientMockRecorder) DeleteItem(ctx, params any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItem", reflect.TypeOf((*UnlockDynamoDBClient)(nil).DeleteItem), varargs...)
+}
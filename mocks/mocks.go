@@ -0,0 +1,5 @@
+// Package mocks provides gomock mocks of the clients used by the migrations_dynamodb package, for unit tests injecting
+// failures (e.g. throttling or failed conditions) without a DynamoDB.
+package mocks
+
+//go:generate go run go.uber.org/mock/mockgen -destination=client.go -package=mocks -mock_names=DynamoDBClient=DynamoDBClient,UnlockDynamoDBClient=UnlockDynamoDBClient github.com/jamillosantos/migrations-dynamodb DynamoDBClient,UnlockDynamoDBClient
@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+var (
+	_ migrations_dynamodb.DynamoDBClient       = (*DynamoDBClient)(nil)
+	_ migrations_dynamodb.UnlockDynamoDBClient = (*UnlockDynamoDBClient)(nil)
+)
+
+var _ = Describe("DynamoDBClient", func() {
+	var (
+		ctx    context.Context
+		client *DynamoDBClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = NewDynamoDBClient(gomock.NewController(GinkgoT()))
+	})
+
+	It("should inject a throttled scan into the Target", func() {
+		throttled := &types.ProvisionedThroughputExceededException{Message: new(string)}
+		client.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, throttled).MinTimes(1)
+
+		_, err := migrations_dynamodb.NewTarget(client).Done(ctx)
+		Expect(errors.Is(err, throttled)).To(BeTrue())
+	})
+
+	It("should inject a denied access into the creation of the tables", func() {
+		denied := errors.New("access denied")
+		client.EXPECT().ListTables(gomock.Any(), gomock.Any(), gomock.Any()).Return(&dynamodb.ListTablesOutput{}, nil)
+		client.EXPECT().CreateTable(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, denied).Times(2)
+
+		err := migrations_dynamodb.NewTarget(client).Create(ctx)
+		Expect(err).To(MatchError(denied))
+	})
+})
@@ -1,16 +1,81 @@
 package migrations_dynamodb
 
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
 type opts struct {
-	lockID        string
-	lockTableName string
-	tableName     string
+	lockID              string
+	lockTableName       string
+	tableName           string
+	ownerID             string
+	transactionalWrites bool
+	retryer             aws.Retryer
+	operationTimeout    time.Duration
+	defaultTimeout      time.Duration
+	writeCondition      expression.ConditionBuilder
+	scanSegments        int
+	scanPageSize        int32
+	scanLimit           float64
+	logger              *slog.Logger
+	eventLogLevel       slog.Level
+	waitLogLevel        slog.Level
+	metrics             MetricsRecorder
+	listeners           []Listener
+	auditTableName      string
+	auditActor          string
+	debug               bool
+	stuckLockThreshold  time.Duration
+	stuckLockHook       func(ctx context.Context, stuck StuckLock)
+	correlationID       func(ctx context.Context) string
+	billingMode         types.BillingMode
+	tags                []types.Tag
+	createWait          bool
+	destroyWait         bool
+	destroyPrune        []PruneResource
+	validateOnly        bool
+	expectedTimeToLive  string
+	clock               Clock
+	staleDirtyThreshold time.Duration
+	staleDirtyPolicy    StaleDirtyPolicy
+	startupRepair       StaleDirtyPolicy
+	runnerID            string
+	downgradeProtection bool
+	encoderOptions      []func(*attributevalue.EncoderOptions)
+	decoderOptions      []func(*attributevalue.DecoderOptions)
+	timestampFormat     string
+	compatibility       *Compatibility
+	maintenanceWindows  []MaintenanceWindow
+	freezeCheck         bool
+	consistentRead      *bool
+	lockPriority        *int
+	homeRegion          string
+	dirtyIndex          bool
+	appliedAtIndex      bool
 }
 
 func defaultOpts() opts {
 	return opts{
-		lockID:        "migrations",
-		tableName:     "_migrations",
-		lockTableName: "_migrations-lock",
+		lockID:          "migrations",
+		tableName:       "_migrations",
+		lockTableName:   "_migrations-lock",
+		logger:          slog.New(discardHandler{}),
+		eventLogLevel:   slog.LevelInfo,
+		waitLogLevel:    slog.LevelDebug,
+		metrics:         noopMetricsRecorder{},
+		correlationID:   CorrelationIDFromContext,
+		createWait:      true,
+		defaultTimeout:  DefaultOperationTimeout,
+		clock:           systemClock{},
+		timestampFormat: DefaultTimestampFormat,
 	}
 }
 
@@ -36,3 +101,392 @@ func WithTableName(tableName string) Option {
 		o.tableName = tableName
 	}
 }
+
+// WithOwnerID sets the ID stored in the lock item to identify the Target holding the lock. If not set, a random ID is
+// generated for each Target.
+func WithOwnerID(ownerID string) Option {
+	return func(o *opts) {
+		o.ownerID = ownerID
+	}
+}
+
+// WithTransactionalWrites makes Add, StartMigration, FinishMigration and Remove run in a transaction that also checks
+// that the lock is still held by the Target. If the lock is not held anymore, the write is not applied and an
+// ErrLockNotHeld is returned.
+func WithTransactionalWrites() Option {
+	return func(o *opts) {
+		o.transactionalWrites = true
+	}
+}
+
+// WithRetryer sets the retryer used by every call the Target makes to the DynamoDB, overriding the one configured in the
+// client.
+func WithRetryer(retryer aws.Retryer) Option {
+	return func(o *opts) {
+		o.retryer = retryer
+	}
+}
+
+// WithOperationTimeout sets the maximum duration of each call the Target makes to the DynamoDB, including its retries,
+// whether the context of the caller has a deadline or not.
+func WithOperationTimeout(timeout time.Duration) Option {
+	return func(o *opts) {
+		o.operationTimeout = timeout
+	}
+}
+
+// WithDefaultOperationTimeout sets the maximum duration of the calls the Target makes to the DynamoDB when the context
+// of the caller has no deadline, so a hung call does not stall the deploy forever. Defaults to
+// DefaultOperationTimeout. A timeout of 0 disables it. It is ignored when WithOperationTimeout is set.
+func WithDefaultOperationTimeout(timeout time.Duration) Option {
+	return func(o *opts) {
+		o.defaultTimeout = timeout
+	}
+}
+
+// WithWriteCondition sets an extra condition that must be satisfied by every write made to the migrations table (Add,
+// StartMigration, FinishMigration and Remove). The condition is combined with the Target's own conditions using AND.
+// If it fails, the write fails as if the Target's own condition had failed.
+func WithWriteCondition(condition expression.ConditionBuilder) Option {
+	return func(o *opts) {
+		o.writeCondition = condition
+	}
+}
+
+// WithScanSegments sets the number of segments scanned in parallel when reading the migrations table. Each segment slows
+// down on its own when its requests are throttled. By default, the table is scanned sequentially.
+func WithScanSegments(segments int) Option {
+	return func(o *opts) {
+		o.scanSegments = segments
+	}
+}
+
+// WithScanPageSize sets the maximum number of items read by each request scanning the migrations table, so the reads of
+// Done, Status and History are split in smaller pages, each consuming less read capacity. By default, each page reads
+// up to 1 MB.
+func WithScanPageSize(items int32) Option {
+	return func(o *opts) {
+		o.scanPageSize = items
+	}
+}
+
+// WithScanLimit sets the read capacity units per second the scans of the migrations table may consume, shared by all
+// their segments, smoothing the spikes of the large reads on the tables with a constrained provisioned capacity. It
+// is best combined with WithScanPageSize, as a page is only charged once read. By default, the scans are not rate
+// limited.
+func WithScanLimit(readCapacityUnits float64) Option {
+	return func(o *opts) {
+		o.scanLimit = readCapacityUnits
+	}
+}
+
+// WithLogger sets the logger used to report what the Target does: the tables created, the migrations added, started,
+// finished and removed and the lock acquired and released are logged at the info level, while waits for the lock,
+// throttled requests and the retries of the SDK, with their attempt and error, are logged at the debug level (see
+// WithLogLevels). The failures are logged at the warn and error levels. By default, nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *opts) {
+		o.logger = logger
+	}
+}
+
+// WithLogLevels sets the levels of the logs of the Target (see WithLogger): events is the level of the significant
+// events, info by default, and waits the level of the waits, throttled requests and retries, debug by default. E.g.,
+// logging the retries at the info level helps to tell the DynamoDB is throttling a run.
+func WithLogLevels(events, waits slog.Level) Option {
+	return func(o *opts) {
+		o.eventLogLevel = events
+		o.waitLogLevel = waits
+	}
+}
+
+// WithMetricsRecorder sets the recorder that receives the metrics of the operations executed by the Target.
+func WithMetricsRecorder(recorder MetricsRecorder) Option {
+	return func(o *opts) {
+		o.metrics = recorder
+	}
+}
+
+// WithListener adds a listener that receives the events of the migrations lifecycle (see Event). When set multiple
+// times, the listeners are called in the order they were added.
+func WithListener(listener Listener) Option {
+	return func(o *opts) {
+		o.listeners = append(o.listeners, listener)
+	}
+}
+
+// WithAuditTable enables the audit table: every change made by Add, StartMigration, FinishMigration and Remove, and
+// the repairs of the dirty migrations made by Lock (as the RepairMarkFinished and RepairRemove operations), is appended
+// to the given table with the actor, timestamp and the values of the migration before and after the change. The table
+// is created by Create and it is not deleted by Destroy. With WithTransactionalWrites (and a LockedTarget), the entry
+// is written in the transaction of the change, after reading the values before it. Otherwise, it is written right
+// after the change: as the change is already done, failing to write the entry is logged, and recorded as a failed
+// Audit operation (see Stats.Errors), instead of failing the change.
+func WithAuditTable(tableName string) Option {
+	return func(o *opts) {
+		o.auditTableName = tableName
+	}
+}
+
+// WithAuditActor sets the identity recorded as the actor of the audit entries and as the applier of the migrations.
+// By default, it is "user@host" of the process running the Target.
+func WithAuditActor(actor string) Option {
+	return func(o *opts) {
+		o.auditActor = actor
+	}
+}
+
+// WithDebug logs every call made to the DynamoDB, with the tables, keys, expressions and errors of the requests, at
+// the debug level of the logger set by WithLogger. Items are sanitized: only the names of their attributes are
+// logged.
+func WithDebug() Option {
+	return func(o *opts) {
+		o.debug = true
+	}
+}
+
+// WithStuckLockAlarm sets a hook called when Lock has been waiting for the lock for longer than the threshold. The hook
+// is called at most once per Lock call, while it is still waiting, so deploys stuck behind the lock can be alerted on.
+func WithStuckLockAlarm(threshold time.Duration, hook func(ctx context.Context, stuck StuckLock)) Option {
+	return func(o *opts) {
+		o.stuckLockThreshold = threshold
+		o.stuckLockHook = hook
+	}
+}
+
+// WithClock sets the clock used by the Target for the timestamps it records, the durations it measures and its waits,
+// for the lock and between throttled scans. By default, the system clock is used.
+func WithClock(clock Clock) Option {
+	return func(o *opts) {
+		o.clock = clock
+	}
+}
+
+// WithStaleDirtyThreshold makes the migrations dirty since longer than the threshold stale: they are flagged by Status,
+// and repaired by Lock with the policy set by WithStaleDirtyPolicy. A migration is dirty since it was added or last
+// started, so the threshold must be longer than the slowest migration takes to apply. The migrations failed by
+// FailMigration (e.g. by a failed verification) are never stale.
+func WithStaleDirtyThreshold(threshold time.Duration) Option {
+	return func(o *opts) {
+		o.staleDirtyThreshold = threshold
+	}
+}
+
+// WithStaleDirtyPolicy sets what Lock does, right after acquiring the lock, with the stale dirty migrations (see
+// WithStaleDirtyThreshold), so a crashed runner does not block the next runs until someone intervenes. By default,
+// nothing is done.
+func WithStaleDirtyPolicy(policy StaleDirtyPolicy) Option {
+	return func(o *opts) {
+		o.staleDirtyPolicy = policy
+	}
+}
+
+// WithStartupRepair makes Lock apply the policy, right after acquiring the lock, to the migrations left dirty by a
+// previous run of the same runner that crashed, so the unattended pipelines heal themselves: with StaleDirtyRemove,
+// they are applied again by the run. A run is told to be of the same runner by the runner ID of its Target, which must
+// be set with WithRunnerID: without it, the option is ignored and NewTarget logs a warning. As the lock is held, they
+// cannot be being applied, whatever the time since they were started, differently from the migrations repaired by
+// WithStaleDirtyPolicy. Only the migrations added or started by a Target with the option are repaired.
+func WithStartupRepair(policy StaleDirtyPolicy) Option {
+	return func(o *opts) {
+		o.startupRepair = policy
+	}
+}
+
+// WithRunnerID sets the identity of the runner of the Target, the same across its runs (e.g. the name of the pipeline
+// or of the deployment), recorded in the migrations it adds or starts for WithStartupRepair. Differently from the
+// owner ID, it is shared by the Targets of all replicas of the runner, so the owner ID must still be unique per Target
+// for the lock to tell them apart.
+func WithRunnerID(runnerID string) Option {
+	return func(o *opts) {
+		o.runnerID = runnerID
+	}
+}
+
+// WithDowngradeProtection makes Add and StartMigration refuse, with an ErrDowngrade, the migrations with an ID lower
+// than the greatest ID recorded, so a rollout of an old binary does not apply the migrations it knows of out of order.
+// The migrations table is read before every Add and StartMigration. Contexts returned by ContextAllowingDowngrade
+// bypass the protection.
+func WithDowngradeProtection() Option {
+	return func(o *opts) {
+		o.downgradeProtection = true
+	}
+}
+
+// WithCorrelationIDExtractor sets the function that reads the correlation ID from the context of the calls. The
+// correlation ID is stored on the migration records and audit entries written, so they can be joined to the trace
+// that caused them. By default, CorrelationIDFromContext is used.
+func WithCorrelationIDExtractor(extractor func(ctx context.Context) string) Option {
+	return func(o *opts) {
+		o.correlationID = extractor
+	}
+}
+
+// WithBillingMode sets the billing mode of the tables created by the Target. With types.BillingModeProvisioned, the
+// default, the tables are created with 1 read and 1 write capacity units.
+func WithBillingMode(mode types.BillingMode) Option {
+	return func(o *opts) {
+		o.billingMode = mode
+	}
+}
+
+// WithTableTags sets the tags of the tables created by the Target.
+func WithTableTags(tags map[string]string) Option {
+	return func(o *opts) {
+		keys := make([]string, 0, len(tags))
+		for key := range tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		o.tags = make([]types.Tag, 0, len(tags))
+		for _, key := range keys {
+			o.tags = append(o.tags, types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+		}
+	}
+}
+
+// WithTableWait sets whether Create waits for the tables created to be active and Destroy waits for the tables deleted
+// to be gone. By default, Create waits and Destroy does not. Lock always waits for the lock table it creates.
+func WithTableWait(wait bool) Option {
+	return func(o *opts) {
+		o.createWait = wait
+		o.destroyWait = wait
+	}
+}
+
+// WithValidateOnly makes the Target never create tables, for when they are provisioned by other means (e.g.
+// Terraform). Create validates the existing tables instead: that they have the key schema and the billing mode the
+// Target would create them with (see WithBillingMode), and the TTL set by WithExpectedTimeToLive. The mismatches are
+// returned in a TableMismatchError. Lock fails with an ErrTableNotFound when the lock table does not exist.
+func WithValidateOnly() Option {
+	return func(o *opts) {
+		o.validateOnly = true
+	}
+}
+
+// WithExpectedTimeToLive sets the attribute the TTL of the lock table is expected to be enabled on, validated by Create
+// in validate-only mode. By default, the TTL is not validated.
+func WithExpectedTimeToLive(attribute string) Option {
+	return func(o *opts) {
+		o.expectedTimeToLive = attribute
+	}
+}
+
+// WithEncoderOptions sets the options of the attributevalue encoder used for the items the Target writes from Go
+// values, as the snapshots imported (see Import), e.g. to match the conventions of the organization for the empty sets
+// and the null attributes.
+func WithEncoderOptions(optFns ...func(*attributevalue.EncoderOptions)) Option {
+	return func(o *opts) {
+		o.encoderOptions = append(o.encoderOptions, optFns...)
+	}
+}
+
+// WithDecoderOptions sets the options of the attributevalue decoder used for the items the Target reads: the
+// migrations, by Done, Status and History, and the snapshots exported (see Export). The TagKey only applies to the
+// snapshots, as the attributes of the migrations are named by the Target.
+func WithDecoderOptions(optFns ...func(*attributevalue.DecoderOptions)) Option {
+	return func(o *opts) {
+		o.decoderOptions = append(o.decoderOptions, optFns...)
+	}
+}
+
+// WithTimestampFormat sets the layout, as in time.Format, of the timestamps recorded by the Target (started_at,
+// applied_at and the timestamp of the audit entries). Defaults to DefaultTimestampFormat. Timestamps recorded with the
+// default layout, before it was changed, are still read.
+func WithTimestampFormat(layout string) Option {
+	return func(o *opts) {
+		o.timestampFormat = layout
+	}
+}
+
+// WithCompatibility makes the Target read the records written by other tools in the migrations table, as described by
+// the compatibility, converting them on read (and rewriting them on the next write, with Compatibility.Rewrite), to
+// ease the transition between the tools.
+func WithCompatibility(compatibility Compatibility) Option {
+	return func(o *opts) {
+		o.compatibility = &compatibility
+	}
+}
+
+// WithMaintenanceWindows makes Lock, Add, StartMigration, Remove and RemoveAfter fail with a MaintenanceError outside
+// the windows, so the migrations only run when approved. The migrations started in a window can still be finished
+// after it closes, so they are not left dirty.
+func WithMaintenanceWindows(windows ...MaintenanceWindow) Option {
+	return func(o *opts) {
+		o.maintenanceWindows = append(o.maintenanceWindows, windows...)
+	}
+}
+
+// WithFreezeCheck makes Lock, Add, StartMigration, Remove and RemoveAfter fail with a MaintenanceError while the
+// migrations are frozen (see Target.Freeze), at the cost of a read of the lock table for each of them.
+func WithFreezeCheck() Option {
+	return func(o *opts) {
+		o.freezeCheck = true
+	}
+}
+
+// WithConsistentRead overrides whether the migrations table is read with strongly consistent reads. By default, the
+// reads are strongly consistent only while the lock is held by the Target (between Lock and Unlock), so Done, Current
+// and Status are not fooled by the replication lag during a run, and eventually consistent otherwise, as they cost
+// half the capacity.
+func WithConsistentRead(consistent bool) Option {
+	return func(o *opts) {
+		o.consistentRead = &consistent
+	}
+}
+
+// WithDirtyIndex makes Create add a sparse global secondary index over the dirty migrations to the migrations table
+// (see DirtyIndexName), so Dirty queries the handful of dirty migrations instead of scanning the whole history of a
+// long-lived service. Create only adds the index when creating the table: for an existing table, it can be added with
+// helpers.EnsureGSI. The client must implement QueryDynamoDBClient to read from the index.
+func WithDirtyIndex() Option {
+	return func(o *opts) {
+		o.dirtyIndex = true
+	}
+}
+
+// WithAppliedAtIndex makes Create add a global secondary index over the applied migrations, sorted by when they were
+// applied, to the migrations table (see AppliedAtIndexName), so HistorySince queries the migrations applied in a
+// period instead of scanning the whole table. As for WithDirtyIndex, Create only adds the index when creating the
+// table, and the client must implement QueryDynamoDBClient to read from it.
+func WithAppliedAtIndex() Option {
+	return func(o *opts) {
+		o.appliedAtIndex = true
+	}
+}
+
+// WithLockPriority makes Lock wait for the lock in the priority lane, so a runner with a higher priority, as the one of
+// an emergency fix, takes the lock before the runners with a lower priority waiting for it, as the ones of the routine
+// deploys. Within a lane, the runners take the lock in the order they started waiting, by the ticket each takes when it
+// joins the queue. Only the runners with a priority set take the lanes into account: every runner of the lock should
+// have one. While the lock is free, each waiter scans the lock table every second to find the runners ahead of it.
+func WithLockPriority(priority int) Option {
+	return func(o *opts) {
+		o.lockPriority = &priority
+	}
+}
+
+// WithHomeRegion makes every call to the lock table, and the transactional writes checking the lock (see
+// WithTransactionalWrites), go to the region given, whatever the region of the client: the lock table is created,
+// written and deleted there. When the lock table is a global table, the conditional writes made in different regions
+// are only reconciled by the replication, last writer wins, so two runners in different regions could both acquire the
+// lock: pinning the lock to a single home region keeps the mutual exclusion sound. Lock and LockMany refuse to lock a
+// global lock table without a home region, and Diagnose reports it. That every runner sets the same home region cannot
+// be checked though, it is up to their configuration. The client must honor the region of the options of the calls, as
+// the *dynamodb.Client does: a global lock table is refused with the clients ignoring them, as the sdkv1.Client (see
+// OptionsIgnoringDynamoDBClient).
+func WithHomeRegion(region string) Option {
+	return func(o *opts) {
+		o.homeRegion = region
+	}
+}
+
+// WithDestroyPrune makes Destroy also delete the resources given, so tearing down an ephemeral environment leaves
+// nothing behind: the audit table (PruneAuditTable) and the backups taken by the migrations (PruneBackups). The TTL
+// configuration of the lock table goes with the table. The package creates no other resource: the auto scaling of the
+// tables, or the tables created by the migrations themselves, are up to whoever set them up.
+func WithDestroyPrune(resources ...PruneResource) Option {
+	return func(o *opts) {
+		o.destroyPrune = append(o.destroyPrune, resources...)
+	}
+}
@@ -0,0 +1,159 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// ErrDuplicateGroup is returned by Orchestrator.Add when the orchestrator already has a group with the name.
+var ErrDuplicateGroup = errors.New("duplicate migration group")
+
+// Orchestrator runs the migrations of several groups concurrently, for the services owning many tables that cannot
+// afford running all their migrations serially. Each group, e.g. the migrations of a table, has its own Target, with
+// its own migrations table and lock, so the groups do not wait for each other.
+type Orchestrator struct {
+	client      DynamoDBClient
+	options     []Option
+	concurrency int
+
+	mu     sync.Mutex
+	groups []*migrationGroup
+}
+
+type migrationGroup struct {
+	name   string
+	source migrations.Source
+	target *Target
+}
+
+// GroupResult is the outcome of running the migrations of a group.
+type GroupResult struct {
+	Name     string
+	Response migrations.ExecutionResponse
+	// Err is the error that stopped the migrations of the group, if any.
+	Err error
+}
+
+// NewOrchestrator creates an orchestrator running, at most, concurrency groups at the same time, or all of them if
+// concurrency is 0 or less. The options are applied to the Target of every group.
+func NewOrchestrator(client DynamoDBClient, concurrency int, options ...Option) *Orchestrator {
+	return &Orchestrator{
+		client:      client,
+		options:     options,
+		concurrency: concurrency,
+	}
+}
+
+// Add adds a group with the migrations of the source, and returns its Target. The migrations of the group are recorded
+// in the table "<table>-<name>", and it is locked with the lock ID "<lock ID>-<name>", in the lock table shared by the
+// groups, where the table and lock ID are the ones set by the options of the orchestrator. The options given are
+// applied after them, so they can override both. If the orchestrator already has a group with the name, it returns
+// an ErrDuplicateGroup.
+func (o *Orchestrator) Add(name string, source migrations.Source, options ...Option) (*Target, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, group := range o.groups {
+		if group.name == name {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateGroup, name)
+		}
+	}
+
+	base := defaultOpts()
+	for _, opt := range o.options {
+		opt(&base)
+	}
+	targetOptions := append([]Option{}, o.options...)
+	targetOptions = append(targetOptions,
+		WithTableName(base.tableName+"-"+name),
+		WithLockID(base.lockID+"-"+name),
+	)
+	targetOptions = append(targetOptions, options...)
+
+	target := NewTarget(o.client, targetOptions...)
+	o.groups = append(o.groups, &migrationGroup{
+		name:   name,
+		source: source,
+		target: target,
+	})
+	return target, nil
+}
+
+// Migrate runs the plan of the planner in every group, such as migrations.MigratePlanner, each under the lock of its
+// group. A group failing does not stop the others: Migrate waits for all of them, and returns their results, in the
+// order they were added, along with the errors of the groups that failed, joined.
+func (o *Orchestrator) Migrate(ctx context.Context, planner migrations.ActionPLanner) ([]GroupResult, error) {
+	o.mu.Lock()
+	groups := append([]*migrationGroup{}, o.groups...)
+	o.mu.Unlock()
+
+	// The groups share the lock table: it is created before they run, instead of by each of them concurrently.
+	if err := o.createLockTables(ctx, groups); err != nil {
+		return nil, err
+	}
+
+	concurrency := o.concurrency
+	if concurrency <= 0 {
+		concurrency = len(groups)
+	}
+	slots := make(chan struct{}, max(concurrency, 1))
+
+	results := make([]GroupResult, len(groups))
+	errs := make([]error, len(groups))
+	var wg sync.WaitGroup
+	wg.Add(len(groups))
+	for i, group := range groups {
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			response, err := migrations.Migrate(ctx, group.source, group.target, migrations.WithPlanner(planner))
+			results[i] = GroupResult{Name: group.name, Response: response, Err: err}
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to migrate group %s: %w", group.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+// Status returns the status of every group, by its name.
+func (o *Orchestrator) Status(ctx context.Context) (map[string]Status, error) {
+	o.mu.Lock()
+	groups := append([]*migrationGroup{}, o.groups...)
+	o.mu.Unlock()
+
+	statuses := make(map[string]Status, len(groups))
+	for _, group := range groups {
+		status, err := group.target.Status(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the status of group %s: %w", group.name, err)
+		}
+		statuses[group.name] = status
+	}
+	return statuses, nil
+}
+
+func (o *Orchestrator) createLockTables(ctx context.Context, groups []*migrationGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	tables, err := groups[0].target.generateTablesMap(ctx)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if err := group.target.createLockTable(ctx, tables, true); err != nil {
+			return err
+		}
+		tables[group.target.lockTableName] = struct{}{}
+	}
+	return nil
+}
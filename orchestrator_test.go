@@ -0,0 +1,89 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Orchestrator", func() {
+	var (
+		ctx          context.Context
+		orchestrator *Orchestrator
+	)
+
+	source := func(ids ...string) migrations.Source {
+		GinkgoHelper()
+
+		s := migrations.NewMemorySource()
+		for _, id := range ids {
+			Expect(s.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+				return nil
+			}, nil))).To(Succeed())
+		}
+		return s
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		orchestrator = NewOrchestrator(dynamoDBClient, 2)
+	})
+
+	It("should run the migrations of every group in its own table", func() {
+		users, err := orchestrator.Add("users", source("0001", "0002"))
+		Expect(err).ToNot(HaveOccurred())
+		orders, err := orchestrator.Add("orders", source("0001"))
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := orchestrator.Migrate(ctx, migrations.MigratePlanner)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Name).To(Equal("users"))
+		Expect(results[0].Response.Successful).To(HaveLen(2))
+		Expect(results[1].Name).To(Equal("orders"))
+		Expect(results[1].Response.Successful).To(HaveLen(1))
+
+		Expect(users.TableName()).To(Equal("_migrations-users"))
+		Expect(users.Done(ctx)).To(Equal([]string{"0001", "0002"}))
+		Expect(orders.Done(ctx)).To(Equal([]string{"0001"}))
+
+		statuses, err := orchestrator.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(HaveKey("users"))
+		Expect(statuses["orders"].Migrations).To(Equal([]MigrationStatus{{ID: "0001"}}))
+		Expect(statuses["orders"].Lock.Held).To(BeFalse())
+	})
+
+	It("should run the other groups when one fails", func() {
+		errFailed := errors.New("migration failed")
+		s := migrations.NewMemorySource()
+		Expect(s.Add(ctx, migrations.NewMigration("0001", "failing", func(context.Context) error {
+			return errFailed
+		}, nil))).To(Succeed())
+		_, err := orchestrator.Add("users", s)
+		Expect(err).ToNot(HaveOccurred())
+		orders, err := orchestrator.Add("orders", source("0001"))
+		Expect(err).ToNot(HaveOccurred())
+
+		results, err := orchestrator.Migrate(ctx, migrations.MigratePlanner)
+		Expect(err).To(MatchError(errFailed))
+		Expect(err).To(MatchError(ContainSubstring("group users")))
+		Expect(results[0].Err).To(MatchError(errFailed))
+		Expect(results[1].Err).ToNot(HaveOccurred())
+		Expect(orders.Done(ctx)).To(Equal([]string{"0001"}))
+	})
+
+	It("should fail with ErrDuplicateGroup when adding a group twice", func() {
+		_, err := orchestrator.Add("users", source())
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = orchestrator.Add("users", source())
+		Expect(err).To(MatchError(ErrDuplicateGroup))
+	})
+})
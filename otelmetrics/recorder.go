@@ -0,0 +1,74 @@
+// Package otelmetrics implements a migrations_dynamodb.MetricsRecorder on top of OpenTelemetry metrics.
+package otelmetrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Recorder records the metrics of a Target as OpenTelemetry instruments.
+type Recorder struct {
+	operations metric.Int64Counter
+	errors     metric.Int64Counter
+	durations  metric.Float64Histogram
+	lockWaits  metric.Float64Histogram
+}
+
+// New creates the instruments of the Recorder using the given meter.
+func New(meter metric.Meter) (*Recorder, error) {
+	operations, err := meter.Int64Counter("migrations_dynamodb.operations",
+		metric.WithDescription("Number of operations executed by the target."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create operations counter: %w", err)
+	}
+
+	errs, err := meter.Int64Counter("migrations_dynamodb.errors",
+		metric.WithDescription("Number of operations executed by the target that failed."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create errors counter: %w", err)
+	}
+
+	durations, err := meter.Float64Histogram("migrations_dynamodb.operation.duration",
+		metric.WithDescription("Duration of the operations executed by the target."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create operation duration histogram: %w", err)
+	}
+
+	lockWaits, err := meter.Float64Histogram("migrations_dynamodb.lock.wait",
+		metric.WithDescription("Time waited to acquire the lock."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock wait histogram: %w", err)
+	}
+
+	return &Recorder{
+		operations: operations,
+		errors:     errs,
+		durations:  durations,
+		lockWaits:  lockWaits,
+	}, nil
+}
+
+// RecordOperation implements migrations_dynamodb.MetricsRecorder.
+func (r *Recorder) RecordOperation(ctx context.Context, operation string, duration time.Duration, err error) {
+	attrs := metric.WithAttributes(attribute.String("operation", operation))
+	r.operations.Add(ctx, 1, attrs)
+	r.durations.Record(ctx, duration.Seconds(), attrs)
+	if err != nil {
+		r.errors.Add(ctx, 1, attrs)
+	}
+}
+
+// RecordLockWait implements migrations_dynamodb.MetricsRecorder.
+func (r *Recorder) RecordLockWait(ctx context.Context, duration time.Duration) {
+	r.lockWaits.Record(ctx, duration.Seconds())
+}
@@ -0,0 +1,81 @@
+package otelmetrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var _ = Describe("Recorder", func() {
+	var (
+		ctx      context.Context
+		reader   *sdkmetric.ManualReader
+		recorder *Recorder
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		reader = sdkmetric.NewManualReader()
+		provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		var err error
+		recorder, err = New(provider.Meter("migrations_dynamodb"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	collect := func() map[string]metricdata.Aggregation {
+		var rm metricdata.ResourceMetrics
+		Expect(reader.Collect(ctx, &rm)).To(Succeed())
+
+		metrics := make(map[string]metricdata.Aggregation)
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				metrics[m.Name] = m.Data
+			}
+		}
+		return metrics
+	}
+
+	sumOf := func(data metricdata.Aggregation, operation string) int64 {
+		for _, dp := range data.(metricdata.Sum[int64]).DataPoints {
+			if v, _ := dp.Attributes.Value(attribute.Key("operation")); v.AsString() == operation {
+				return dp.Value
+			}
+		}
+		return 0
+	}
+
+	It("should count operations and errors", func() {
+		recorder.RecordOperation(ctx, "Add", time.Millisecond, nil)
+		recorder.RecordOperation(ctx, "Add", time.Millisecond, errors.New("random error"))
+		recorder.RecordOperation(ctx, "Done", time.Millisecond, nil)
+
+		metrics := collect()
+		Expect(sumOf(metrics["migrations_dynamodb.operations"], "Add")).To(BeEquivalentTo(2))
+		Expect(sumOf(metrics["migrations_dynamodb.operations"], "Done")).To(BeEquivalentTo(1))
+		Expect(sumOf(metrics["migrations_dynamodb.errors"], "Add")).To(BeEquivalentTo(1))
+		Expect(sumOf(metrics["migrations_dynamodb.errors"], "Done")).To(BeEquivalentTo(0))
+	})
+
+	It("should record durations and lock waits", func() {
+		recorder.RecordOperation(ctx, "Lock", 2*time.Second, nil)
+		recorder.RecordLockWait(ctx, time.Second)
+
+		metrics := collect()
+
+		durations := metrics["migrations_dynamodb.operation.duration"].(metricdata.Histogram[float64]).DataPoints
+		Expect(durations).To(HaveLen(1))
+		Expect(durations[0].Sum).To(Equal(2.0))
+
+		lockWaits := metrics["migrations_dynamodb.lock.wait"].(metricdata.Histogram[float64]).DataPoints
+		Expect(lockWaits).To(HaveLen(1))
+		Expect(lockWaits[0].Count).To(BeEquivalentTo(1))
+		Expect(lockWaits[0].Sum).To(Equal(1.0))
+	})
+})
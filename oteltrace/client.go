@@ -0,0 +1,355 @@
+// Package oteltrace traces the calls a Target makes to the DynamoDB with OpenTelemetry. The spans are annotated with
+// the semantic convention attributes of DynamoDB, so APM tools aggregate them with the rest of the application's
+// DynamoDB traffic.
+//
+//	target := migrations_dynamodb.NewTarget(oteltrace.NewClient(client))
+package oteltrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+const (
+	instrumentationName = "github.com/jamillosantos/migrations-dynamodb/oteltrace"
+
+	// ConditionOutcomeKey is the attribute set on the spans of conditional writes with whether the condition "passed"
+	// or "failed". Failed conditions are expected by the Target (e.g. while waiting for the lock), so they do not set
+	// the status of the span to error.
+	ConditionOutcomeKey = attribute.Key("migrations_dynamodb.condition.outcome")
+)
+
+type opts struct {
+	tracerProvider trace.TracerProvider
+}
+
+type Option func(*opts)
+
+// WithTracerProvider sets the provider of the tracer. By default, the global provider is used.
+func WithTracerProvider(tracerProvider trace.TracerProvider) Option {
+	return func(o *opts) {
+		o.tracerProvider = tracerProvider
+	}
+}
+
+func defaultOpts() opts {
+	return opts{
+		tracerProvider: otel.GetTracerProvider(),
+	}
+}
+
+// Client wraps a migrations_dynamodb.DynamoDBClient creating a span for each call.
+type Client struct {
+	next   migrations_dynamodb.DynamoDBClient
+	tracer trace.Tracer
+}
+
+var (
+	_ migrations_dynamodb.DynamoDBClient           = (*Client)(nil)
+	_ migrations_dynamodb.QueryDynamoDBClient      = (*Client)(nil)
+	_ migrations_dynamodb.GetItemDynamoDBClient    = (*Client)(nil)
+	_ migrations_dynamodb.TimeToLiveDynamoDBClient = (*Client)(nil)
+	_ migrations_dynamodb.BackupDynamoDBClient     = (*Client)(nil)
+
+	_ migrations_dynamodb.OptionsIgnoringDynamoDBClient = (*Client)(nil)
+)
+
+// NewClient wraps the client creating a span for each call.
+func NewClient(client migrations_dynamodb.DynamoDBClient, opts ...Option) *Client {
+	options := defaultOpts()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Client{
+		next:   client,
+		tracer: options.tracerProvider.Tracer(instrumentationName),
+	}
+}
+
+// traced executes the call inside a span with the given attributes, adding the attributes of the response to it.
+func traced[T any](ctx context.Context, c *Client, method string, attrs []attribute.KeyValue, conditional bool, call func(ctx context.Context) (T, error), response func(T) []attribute.KeyValue) (T, error) {
+	attrs = append(attrs,
+		semconv.RPCSystemKey.String("aws-api"),
+		semconv.RPCService("DynamoDB"),
+		semconv.RPCMethod(method),
+		semconv.DBSystemDynamoDB,
+	)
+	ctx, span := c.tracer.Start(ctx, "DynamoDB."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	output, err := call(ctx)
+
+	var (
+		conditionalCheckFailedException *types.ConditionalCheckFailedException
+		transactionCanceledException    *types.TransactionCanceledException
+	)
+	switch {
+	case err == nil:
+		span.SetAttributes(response(output)...)
+		if conditional {
+			span.SetAttributes(ConditionOutcomeKey.String("passed"))
+		}
+	case errors.As(err, &conditionalCheckFailedException):
+		span.SetAttributes(ConditionOutcomeKey.String("failed"))
+	case errors.As(err, &transactionCanceledException) && conditionFailed(transactionCanceledException):
+		span.SetAttributes(ConditionOutcomeKey.String("failed"))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return output, err
+}
+
+func conditionFailed(err *types.TransactionCanceledException) bool {
+	for _, reason := range err.CancellationReasons {
+		if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	if input.TotalSegments != nil {
+		attrs = append(attrs,
+			semconv.AWSDynamoDBSegment(int(aws.ToInt32(input.Segment))),
+			semconv.AWSDynamoDBTotalSegments(int(aws.ToInt32(input.TotalSegments))),
+		)
+	}
+	if input.Limit != nil {
+		attrs = append(attrs, semconv.AWSDynamoDBLimit(int(aws.ToInt32(input.Limit))))
+	}
+	return traced(ctx, c, "Scan", attrs, false, func(ctx context.Context) (*dynamodb.ScanOutput, error) {
+		return c.next.Scan(ctx, input, optFns...)
+	}, func(output *dynamodb.ScanOutput) []attribute.KeyValue {
+		return append(consumedCapacity(output.ConsumedCapacity),
+			semconv.AWSDynamoDBCount(int(output.Count)),
+			semconv.AWSDynamoDBScannedCount(int(output.ScannedCount)),
+		)
+	})
+}
+
+func (c *Client) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	return traced(ctx, c, "PutItem", attrs, input.ConditionExpression != nil, func(ctx context.Context) (*dynamodb.PutItemOutput, error) {
+		return c.next.PutItem(ctx, input, optFns...)
+	}, func(output *dynamodb.PutItemOutput) []attribute.KeyValue {
+		return consumedCapacity(output.ConsumedCapacity)
+	})
+}
+
+func (c *Client) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	return traced(ctx, c, "DeleteItem", attrs, input.ConditionExpression != nil, func(ctx context.Context) (*dynamodb.DeleteItemOutput, error) {
+		return c.next.DeleteItem(ctx, input, optFns...)
+	}, func(output *dynamodb.DeleteItemOutput) []attribute.KeyValue {
+		return consumedCapacity(output.ConsumedCapacity)
+	})
+}
+
+func (c *Client) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	return traced(ctx, c, "UpdateItem", attrs, input.ConditionExpression != nil, func(ctx context.Context) (*dynamodb.UpdateItemOutput, error) {
+		return c.next.UpdateItem(ctx, input, optFns...)
+	}, func(output *dynamodb.UpdateItemOutput) []attribute.KeyValue {
+		return consumedCapacity(output.ConsumedCapacity)
+	})
+}
+
+func (c *Client) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	var (
+		tableNames  []string
+		seen        = make(map[string]struct{})
+		conditional bool
+	)
+	for _, item := range input.TransactItems {
+		var tableName *string
+		switch {
+		case item.ConditionCheck != nil:
+			tableName, conditional = item.ConditionCheck.TableName, true
+		case item.Put != nil:
+			tableName, conditional = item.Put.TableName, conditional || item.Put.ConditionExpression != nil
+		case item.Delete != nil:
+			tableName, conditional = item.Delete.TableName, conditional || item.Delete.ConditionExpression != nil
+		case item.Update != nil:
+			tableName, conditional = item.Update.TableName, conditional || item.Update.ConditionExpression != nil
+		}
+		if _, ok := seen[aws.ToString(tableName)]; tableName != nil && !ok {
+			seen[aws.ToString(tableName)] = struct{}{}
+			tableNames = append(tableNames, aws.ToString(tableName))
+		}
+	}
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(tableNames...)}
+	return traced(ctx, c, "TransactWriteItems", attrs, conditional, func(ctx context.Context) (*dynamodb.TransactWriteItemsOutput, error) {
+		return c.next.TransactWriteItems(ctx, input, optFns...)
+	}, func(output *dynamodb.TransactWriteItemsOutput) []attribute.KeyValue {
+		capacity := make([]*types.ConsumedCapacity, len(output.ConsumedCapacity))
+		for i := range output.ConsumedCapacity {
+			capacity[i] = &output.ConsumedCapacity[i]
+		}
+		return consumedCapacity(capacity...)
+	})
+}
+
+func (c *Client) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	if input.ProvisionedThroughput != nil {
+		attrs = append(attrs,
+			semconv.AWSDynamoDBProvisionedReadCapacity(float64(aws.ToInt64(input.ProvisionedThroughput.ReadCapacityUnits))),
+			semconv.AWSDynamoDBProvisionedWriteCapacity(float64(aws.ToInt64(input.ProvisionedThroughput.WriteCapacityUnits))),
+		)
+	}
+	return traced(ctx, c, "CreateTable", attrs, false, func(ctx context.Context) (*dynamodb.CreateTableOutput, error) {
+		return c.next.CreateTable(ctx, input, optFns...)
+	}, func(*dynamodb.CreateTableOutput) []attribute.KeyValue {
+		return nil
+	})
+}
+
+func (c *Client) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	return traced(ctx, c, "DescribeTable", attrs, false, func(ctx context.Context) (*dynamodb.DescribeTableOutput, error) {
+		return c.next.DescribeTable(ctx, input, optFns...)
+	}, func(*dynamodb.DescribeTableOutput) []attribute.KeyValue {
+		return nil
+	})
+}
+
+func (c *Client) DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	return traced(ctx, c, "DeleteTable", attrs, false, func(ctx context.Context) (*dynamodb.DeleteTableOutput, error) {
+		return c.next.DeleteTable(ctx, input, optFns...)
+	}, func(*dynamodb.DeleteTableOutput) []attribute.KeyValue {
+		return nil
+	})
+}
+
+func (c *Client) ListTables(ctx context.Context, input *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	var attrs []attribute.KeyValue
+	if input.ExclusiveStartTableName != nil {
+		attrs = append(attrs, semconv.AWSDynamoDBExclusiveStartTable(aws.ToString(input.ExclusiveStartTableName)))
+	}
+	if input.Limit != nil {
+		attrs = append(attrs, semconv.AWSDynamoDBLimit(int(aws.ToInt32(input.Limit))))
+	}
+	return traced(ctx, c, "ListTables", attrs, false, func(ctx context.Context) (*dynamodb.ListTablesOutput, error) {
+		return c.next.ListTables(ctx, input, optFns...)
+	}, func(output *dynamodb.ListTablesOutput) []attribute.KeyValue {
+		return []attribute.KeyValue{semconv.AWSDynamoDBTableCount(len(output.TableNames))}
+	})
+}
+
+func (c *Client) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.QueryDynamoDBClient)
+	if !ok {
+		return nil, unsupported("Query")
+	}
+	attrs := []attribute.KeyValue{
+		semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName)),
+		semconv.AWSDynamoDBConsistentRead(aws.ToBool(input.ConsistentRead)),
+	}
+	if input.IndexName != nil {
+		attrs = append(attrs, semconv.AWSDynamoDBIndexName(aws.ToString(input.IndexName)))
+	}
+	if input.Limit != nil {
+		attrs = append(attrs, semconv.AWSDynamoDBLimit(int(aws.ToInt32(input.Limit))))
+	}
+	if input.ScanIndexForward != nil {
+		attrs = append(attrs, semconv.AWSDynamoDBScanForward(aws.ToBool(input.ScanIndexForward)))
+	}
+	return traced(ctx, c, "Query", attrs, false, func(ctx context.Context) (*dynamodb.QueryOutput, error) {
+		return next.Query(ctx, input, optFns...)
+	}, func(output *dynamodb.QueryOutput) []attribute.KeyValue {
+		return append(consumedCapacity(output.ConsumedCapacity),
+			semconv.AWSDynamoDBCount(int(output.Count)),
+			semconv.AWSDynamoDBScannedCount(int(output.ScannedCount)),
+		)
+	})
+}
+
+func (c *Client) GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.GetItemDynamoDBClient)
+	if !ok {
+		return nil, unsupported("GetItem")
+	}
+	attrs := []attribute.KeyValue{
+		semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName)),
+		semconv.AWSDynamoDBConsistentRead(aws.ToBool(input.ConsistentRead)),
+	}
+	return traced(ctx, c, "GetItem", attrs, false, func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+		return next.GetItem(ctx, input, optFns...)
+	}, func(output *dynamodb.GetItemOutput) []attribute.KeyValue {
+		return consumedCapacity(output.ConsumedCapacity)
+	})
+}
+
+func (c *Client) DescribeTimeToLive(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.TimeToLiveDynamoDBClient)
+	if !ok {
+		return nil, unsupported("DescribeTimeToLive")
+	}
+	attrs := []attribute.KeyValue{semconv.AWSDynamoDBTableNames(aws.ToString(input.TableName))}
+	return traced(ctx, c, "DescribeTimeToLive", attrs, false, func(ctx context.Context) (*dynamodb.DescribeTimeToLiveOutput, error) {
+		return next.DescribeTimeToLive(ctx, input, optFns...)
+	}, func(*dynamodb.DescribeTimeToLiveOutput) []attribute.KeyValue {
+		return nil
+	})
+}
+
+func (c *Client) DeleteBackup(ctx context.Context, input *dynamodb.DeleteBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	next, ok := c.next.(migrations_dynamodb.BackupDynamoDBClient)
+	if !ok {
+		return nil, unsupported("DeleteBackup")
+	}
+	return traced(ctx, c, "DeleteBackup", nil, false, func(ctx context.Context) (*dynamodb.DeleteBackupOutput, error) {
+		return next.DeleteBackup(ctx, input, optFns...)
+	}, func(*dynamodb.DeleteBackupOutput) []attribute.KeyValue {
+		return nil
+	})
+}
+
+// IgnoresCallOptions forwards to the wrapped client, as the options of the calls are given to it.
+func (c *Client) IgnoresCallOptions() bool {
+	next, ok := c.next.(migrations_dynamodb.OptionsIgnoringDynamoDBClient)
+	return ok && next.IgnoresCallOptions()
+}
+
+// unsupported is the error of the optional calls the wrapped client does not implement, without creating a span as
+// no call is made. The Target handles it as a client without the call.
+func unsupported(method string) error {
+	return fmt.Errorf("the client does not support %s: %w", method, errors.ErrUnsupported)
+}
+
+// consumedCapacity returns the aws.dynamodb.consumed_capacity attribute, with each capacity encoded as JSON as the
+// semantic conventions require.
+func consumedCapacity(capacity ...*types.ConsumedCapacity) []attribute.KeyValue {
+	values := make([]string, 0, len(capacity))
+	for _, c := range capacity {
+		if c == nil {
+			continue
+		}
+		data, err := json.Marshal(c)
+		if err != nil {
+			continue
+		}
+		values = append(values, string(data))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return []attribute.KeyValue{semconv.AWSDynamoDBConsumedCapacity(values...)}
+}
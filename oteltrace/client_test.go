@@ -0,0 +1,177 @@
+package oteltrace
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// stubClient answers the calls with the outputs and errors set.
+type stubClient struct {
+	migrations_dynamodb.DynamoDBClient
+
+	err error
+}
+
+func (c *stubClient) PutItem(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &dynamodb.PutItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{TableName: input.TableName, CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+func (c *stubClient) Scan(_ context.Context, _ *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &dynamodb.ScanOutput{Count: 2, ScannedCount: 3}, nil
+}
+
+func (c *stubClient) Query(_ context.Context, _ *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &dynamodb.QueryOutput{Count: 1, ScannedCount: 1}, nil
+}
+
+func (c *stubClient) TransactWriteItems(_ context.Context, _ *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return nil, c.err
+}
+
+var _ = Describe("Client", func() {
+	var (
+		ctx      context.Context
+		recorder *tracetest.SpanRecorder
+		stub     *stubClient
+		client   *Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		recorder = tracetest.NewSpanRecorder()
+		stub = &stubClient{}
+		client = NewClient(stub, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+	})
+
+	lastSpan := func() (sdktrace.ReadOnlySpan, map[attribute.Key]attribute.Value) {
+		GinkgoHelper()
+
+		spans := recorder.Ended()
+		Expect(spans).ToNot(BeEmpty())
+		span := spans[len(spans)-1]
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		return span, attrs
+	}
+
+	It("should annotate the spans with the DynamoDB attributes", func() {
+		_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           aws.String("_migrations"),
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		span, attrs := lastSpan()
+		Expect(span.Name()).To(Equal("DynamoDB.PutItem"))
+		Expect(attrs[semconv.RPCSystemKey].AsString()).To(Equal("aws-api"))
+		Expect(attrs[semconv.RPCServiceKey].AsString()).To(Equal("DynamoDB"))
+		Expect(attrs[semconv.RPCMethodKey].AsString()).To(Equal("PutItem"))
+		Expect(attrs[semconv.DBSystemKey].AsString()).To(Equal("dynamodb"))
+		Expect(attrs[semconv.AWSDynamoDBTableNamesKey].AsStringSlice()).To(Equal([]string{"_migrations"}))
+		Expect(attrs[semconv.AWSDynamoDBConsumedCapacityKey].AsStringSlice()).To(HaveLen(1))
+		Expect(attrs[ConditionOutcomeKey].AsString()).To(Equal("passed"))
+		Expect(span.Status().Code).To(Equal(codes.Unset))
+	})
+
+	It("should annotate the scans with the segments and counts", func() {
+		_, err := client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:     aws.String("_migrations"),
+			Segment:       aws.Int32(1),
+			TotalSegments: aws.Int32(4),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, attrs := lastSpan()
+		Expect(attrs[semconv.AWSDynamoDBSegmentKey].AsInt64()).To(BeEquivalentTo(1))
+		Expect(attrs[semconv.AWSDynamoDBTotalSegmentsKey].AsInt64()).To(BeEquivalentTo(4))
+		Expect(attrs[semconv.AWSDynamoDBCountKey].AsInt64()).To(BeEquivalentTo(2))
+		Expect(attrs[semconv.AWSDynamoDBScannedCountKey].AsInt64()).To(BeEquivalentTo(3))
+		Expect(attrs).ToNot(HaveKey(ConditionOutcomeKey))
+	})
+
+	It("should record failed conditions without setting the span as error", func() {
+		stub.err = &types.ConditionalCheckFailedException{Message: aws.String("condition failed")}
+		_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           aws.String("_migrations-lock"),
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		})
+		Expect(err).To(HaveOccurred())
+
+		span, attrs := lastSpan()
+		Expect(attrs[ConditionOutcomeKey].AsString()).To(Equal("failed"))
+		Expect(span.Status().Code).To(Equal(codes.Unset))
+	})
+
+	It("should record failed conditions of transactions", func() {
+		stub.err = &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("ConditionalCheckFailed")},
+			{Code: aws.String("None")},
+		}}
+		_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{ConditionCheck: &types.ConditionCheck{TableName: aws.String("_migrations-lock")}},
+				{Put: &types.Put{TableName: aws.String("_migrations")}},
+			},
+		})
+		Expect(err).To(HaveOccurred())
+
+		_, attrs := lastSpan()
+		Expect(attrs[semconv.AWSDynamoDBTableNamesKey].AsStringSlice()).To(Equal([]string{"_migrations-lock", "_migrations"}))
+		Expect(attrs[ConditionOutcomeKey].AsString()).To(Equal("failed"))
+	})
+
+	It("should set the span as error when the call fails", func() {
+		stub.err = errors.New("random error")
+		_, err := client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("_migrations")})
+		Expect(err).To(HaveOccurred())
+
+		span, _ := lastSpan()
+		Expect(span.Status().Code).To(Equal(codes.Error))
+		Expect(span.Events()).To(HaveLen(1))
+	})
+
+	It("should trace the optional calls the wrapped client implements", func() {
+		_, err := client.Query(ctx, &dynamodb.QueryInput{
+			TableName: aws.String("_migrations"),
+			IndexName: aws.String("dirty-index"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		span, attrs := lastSpan()
+		Expect(span.Name()).To(Equal("DynamoDB.Query"))
+		Expect(attrs).To(HaveKeyWithValue(semconv.AWSDynamoDBIndexNameKey, attribute.StringValue("dirty-index")))
+		Expect(attrs).To(HaveKeyWithValue(semconv.AWSDynamoDBCountKey, attribute.IntValue(1)))
+	})
+
+	It("should not support the optional calls the wrapped client does not implement", func() {
+		_, err := client.DeleteBackup(ctx, &dynamodb.DeleteBackupInput{})
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+		Expect(recorder.Ended()).To(BeEmpty())
+	})
+})
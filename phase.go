@@ -0,0 +1,38 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	attributePhase      = "phase"
+	attributePreparedAt = "prepared_at"
+
+	// phasePrepared is the phase of a migration prepared by PrepareMigration, waiting for CommitMigration.
+	phasePrepared = "prepared"
+)
+
+// ErrInvalidPhase is returned by PrepareMigration for a migration that is not started, by CommitMigration for a
+// migration that is not prepared, by MarkCanary for a migration that is not finished, and by PromoteCanary and
+// RevertCanary for a migration that is not in canary, including when the migration does not exist.
+var ErrInvalidPhase = errors.New("invalid migration phase")
+
+// PrepareMigration marks a started migration as prepared: its changes are done, but not validated yet. The migration
+// stays dirty until CommitMigration, so a run aborted between the two phases is stopped at it, and its record tells it
+// was prepared (see MigrationRecord.Prepared), so it can be validated and committed, or started again, instead of
+// guessed. If the migration is not started, it returns an ErrInvalidPhase.
+func (t *Target) PrepareMigration(ctx context.Context, id string) error {
+	return t.setDirty(ctx, operationPrepareMigration, id, true, "")
+}
+
+// CommitMigration marks a prepared migration as finished, as FinishMigration, once it was validated after
+// PrepareMigration. If the migration is not prepared, e.g. because it was started again after being prepared, it
+// returns an ErrInvalidPhase. The checks registered for the migration with Verify are evaluated afterwards, as by
+// FinishMigration.
+func (t *Target) CommitMigration(ctx context.Context, id string) error {
+	if err := t.setDirty(ctx, operationCommitMigration, id, false, ""); err != nil {
+		return err
+	}
+	return t.verify(ctx, id)
+}
@@ -0,0 +1,67 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Two-phase migrations", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+	})
+
+	It("should keep a prepared migration dirty until it is committed", func() {
+		Expect(target.PrepareMigration(ctx, "1")).To(Succeed())
+
+		_, err := target.Done(ctx)
+		Expect(err).To(MatchError(migrations.ErrDirtyMigration))
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "1", Dirty: true, Prepared: true}}))
+
+		Expect(target.CommitMigration(ctx, "1")).To(Succeed())
+		Expect(target.Done(ctx)).To(Equal([]string{"1"}))
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history[0].Prepared).To(BeFalse())
+		Expect(history[0].PreparedAt).ToNot(BeZero())
+		Expect(history[0].AppliedSeq).ToNot(BeZero())
+	})
+
+	It("should not commit a migration that is not prepared", func() {
+		err := target.CommitMigration(ctx, "1")
+		Expect(err).To(MatchError(ErrInvalidPhase))
+		Expect(ErrorCodeOf(err)).To(Equal(CodeInvalidPhase))
+
+		Expect(target.CommitMigration(ctx, "2")).To(MatchError(ErrInvalidPhase))
+	})
+
+	It("should not commit a prepared migration started again", func() {
+		Expect(target.PrepareMigration(ctx, "1")).To(Succeed())
+		Expect(target.StartMigration(ctx, "1")).To(Succeed())
+
+		Expect(target.CommitMigration(ctx, "1")).To(MatchError(ErrInvalidPhase))
+	})
+
+	It("should not prepare a migration that is not started", func() {
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		Expect(target.PrepareMigration(ctx, "1")).To(MatchError(ErrInvalidPhase))
+	})
+})
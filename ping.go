@@ -0,0 +1,51 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// Ping checks that the migrations table and the migrations lock table can be reached. It is meant to be used by
+// readiness probes of services that run migrations at startup.
+//
+// The errors returned distinguish why a table could not be reached: `ErrTableNotFound` when the table does not exist,
+// `ErrPermissionDenied` (a PermissionDeniedError) when the credentials are not allowed to describe it, and
+// `ErrConnectivity` when the DynamoDB could not be reached at all.
+func (t *Target) Ping(ctx context.Context) (err error) {
+	defer t.observe(ctx, operationPing, t.clock.Now(), &err)
+	defer wrapError(operationPing, "", &err)
+
+	return errors.Join(
+		t.pingTable(ctx, t.tableName),
+		t.pingTable(ctx, t.lockTableName),
+	)
+}
+
+func (t *Target) pingTable(ctx context.Context, tableName string) error {
+	_, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: &tableName,
+	})
+	if err == nil {
+		return nil
+	}
+
+	var (
+		resourceNotFoundException *types.ResourceNotFoundException
+		apiErr                    smithy.APIError
+	)
+	denied, kms := accessDenied(err)
+	switch {
+	case errors.As(err, &resourceNotFoundException):
+		return fmt.Errorf("%w: %s: %w", ErrTableNotFound, tableName, err)
+	case denied:
+		return &PermissionDeniedError{Operation: operationPing, Table: tableName, KMS: kms, Err: err}
+	case !errors.As(err, &apiErr):
+		return fmt.Errorf("%w: %s: %w", ErrConnectivity, tableName, err)
+	}
+	return fmt.Errorf("failed to describe table %s: %w", tableName, err)
+}
@@ -0,0 +1,70 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ping", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	When("the tables exist", func() {
+		It("should succeed", func() {
+			target := NewTarget(dynamoDBClient)
+			Expect(target.Create(ctx)).To(Succeed())
+
+			Expect(target.Ping(ctx)).To(Succeed())
+		})
+	})
+
+	When("the tables do not exist", func() {
+		It("should fail with ErrTableNotFound", func() {
+			target := NewTarget(dynamoDBClient)
+
+			err := target.Ping(ctx)
+			Expect(err).To(MatchError(ErrTableNotFound))
+			Expect(err).To(MatchError(ContainSubstring("_migrations-lock")))
+		})
+	})
+
+	When("the permission is denied", func() {
+		It("should fail with ErrPermissionDenied", func() {
+			target := NewTarget(&accessDeniedClient{Client: dynamoDBClient})
+
+			Expect(target.Ping(ctx)).To(MatchError(ErrPermissionDenied))
+		})
+	})
+
+	When("the DynamoDB cannot be reached", func() {
+		It("should fail with ErrConnectivity", func() {
+			client := dynamodb.New(dynamoDBClient.Options(), dynamodb.WithEndpointResolverV2(endpointResolver("http://127.0.0.1:1")), func(o *dynamodb.Options) {
+				o.RetryMaxAttempts = 1
+			})
+			target := NewTarget(client)
+
+			Expect(target.Ping(ctx)).To(MatchError(ErrConnectivity))
+		})
+	})
+})
+
+// accessDeniedClient fails describing tables with an AccessDeniedException.
+type accessDeniedClient struct {
+	*dynamodb.Client
+}
+
+func (c *accessDeniedClient) DescribeTable(_ context.Context, _ *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return nil, &smithy.GenericAPIError{
+		Code:    "AccessDeniedException",
+		Message: "User is not authorized to perform: dynamodb:DescribeTable",
+	}
+}
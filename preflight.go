@@ -0,0 +1,297 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PermissionStatus is what a probe of Preflight found about an action.
+type PermissionStatus string
+
+const (
+	// PermissionAllowed is the status of the actions the credentials are allowed to perform.
+	PermissionAllowed PermissionStatus = "allowed"
+	// PermissionDenied is the status of the actions the credentials are not allowed to perform, or whose KMS key they
+	// are not allowed to use.
+	PermissionDenied PermissionStatus = "denied"
+	// PermissionUnknown is the status of the actions whose probe failed for another reason, and of the ones that
+	// cannot be probed without side effects, as creating a table.
+	PermissionUnknown PermissionStatus = "unknown"
+)
+
+// PermissionCheck is the outcome of probing an action of the DynamoDB, on a table.
+type PermissionCheck struct {
+	// Action is the IAM action probed, as "dynamodb:PutItem".
+	Action string `json:"action"`
+	// Table is the table the action was probed on, empty for the actions not on a table, as dynamodb:ListTables.
+	Table  string           `json:"table,omitempty"`
+	Status PermissionStatus `json:"status"`
+	// Detail explains the status, when it is not allowed, or when the table does not exist.
+	Detail string `json:"detail,omitempty"`
+}
+
+// PreflightReport is the report of Preflight, with a check of every action the Target needs.
+type PreflightReport struct {
+	Checks []PermissionCheck `json:"checks"`
+}
+
+// Denied returns the checks of the actions denied.
+func (r PreflightReport) Denied() []PermissionCheck {
+	var denied []PermissionCheck
+	for _, check := range r.Checks {
+		if check.Status == PermissionDenied {
+			denied = append(denied, check)
+		}
+	}
+	return denied
+}
+
+// Err returns an error matching ErrPermissionDenied, listing the actions denied, or nil if none was.
+func (r PreflightReport) Err() error {
+	denied := r.Denied()
+	if len(denied) == 0 {
+		return nil
+	}
+	actions := make([]string, 0, len(denied))
+	for _, check := range denied {
+		if check.Table == "" {
+			actions = append(actions, check.Action)
+			continue
+		}
+		actions = append(actions, check.Action+" on "+check.Table)
+	}
+	return fmt.Errorf("%w: %s", ErrPermissionDenied, strings.Join(actions, ", "))
+}
+
+// permissionProbe calls an action of the DynamoDB, in a way that cannot change anything.
+type permissionProbe struct {
+	action string
+	call   func() error
+}
+
+// Preflight checks the credentials of the client are allowed to perform every action the Target needs to run the
+// migrations, before the run starts: describing and listing the tables, the reads and the conditional writes of the
+// migrations, lock and audit tables, the transactions and the queries of the indexes, when enabled. As Diagnose, the
+// writes are probed with conditions that never hold, so the state is not changed. The tables are created when they do
+// not exist, which cannot be probed: their dynamodb:CreateTable is reported as unknown.
+//
+// An error is returned only when the probes could not run (e.g. the context was canceled). The report tells what is
+// denied, and its Err fails a deploy early.
+func (t *Target) Preflight(ctx context.Context) (_ PreflightReport, err error) {
+	defer t.observe(ctx, operationPreflight, t.clock.Now(), &err)
+	defer wrapError(operationPreflight, "", &err)
+
+	var report PreflightReport
+	report.Checks = append(report.Checks, runProbe("", permissionProbe{"dynamodb:ListTables", func() error {
+		_, err := t.client.ListTables(ctx, &dynamodb.ListTablesInput{Limit: aws.Int32(1)})
+		return err
+	}}))
+
+	tables := []string{t.tableName, t.lockTableName}
+	if t.auditTableName != "" {
+		tables = append(tables, t.auditTableName)
+	}
+	for _, tableName := range tables {
+		describe := runProbe(tableName, permissionProbe{"dynamodb:DescribeTable", func() error {
+			_, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+				TableName: aws.String(tableName),
+			}, t.tableCallOptions(tableName)...)
+			return err
+		}})
+		report.Checks = append(report.Checks, describe)
+		if describe.Detail == tableMissingDetail {
+			report.Checks = append(report.Checks, PermissionCheck{
+				Action: "dynamodb:CreateTable",
+				Table:  tableName,
+				Status: PermissionUnknown,
+				Detail: "the table does not exist and is created by the Target, which cannot be probed",
+			})
+		}
+		for _, probe := range t.tableProbes(ctx, tableName) {
+			report.Checks = append(report.Checks, runProbe(tableName, probe))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return PreflightReport{}, err
+	}
+	return report, nil
+}
+
+// tableMissingDetail is the detail of the checks made on a table that does not exist.
+const tableMissingDetail = "the table does not exist"
+
+// runProbe runs the probe and reports its outcome. A conditional check failure, or the table not existing, means the
+// action was allowed, as the permissions are checked first.
+func runProbe(tableName string, probe permissionProbe) PermissionCheck {
+	check := PermissionCheck{Action: probe.action, Table: tableName}
+	err := probe.call()
+	var (
+		conditionalCheckFailedException *types.ConditionalCheckFailedException
+		transactionCanceledException    *types.TransactionCanceledException
+		resourceNotFoundException       *types.ResourceNotFoundException
+	)
+	switch denied, kms := accessDenied(err); {
+	case err == nil, errors.As(err, &conditionalCheckFailedException), errors.As(err, &transactionCanceledException):
+		check.Status = PermissionAllowed
+	case errors.As(err, &resourceNotFoundException):
+		check.Status = PermissionAllowed
+		check.Detail = tableMissingDetail
+	case errors.Is(err, errors.ErrUnsupported):
+		check.Status = PermissionUnknown
+		check.Detail = err.Error() + ", so the action cannot be probed"
+	case denied && kms:
+		check.Status = PermissionDenied
+		check.Detail = "not allowed to use the KMS key of the table"
+	case denied:
+		check.Status = PermissionDenied
+		check.Detail = fmt.Sprintf("grant %s on the table to the credentials used", probe.action)
+	default:
+		check.Status = PermissionUnknown
+		check.Detail = err.Error()
+	}
+	return check
+}
+
+// writeProbes returns the probes of the reads and the writes of the items of the table: Scan, PutItem, UpdateItem and
+// DeleteItem. They read at most an item, and their writes are conditioned on the existence of an item that does not
+// exist, so they fail with a conditional check failure when they are allowed.
+func (t *Target) writeProbes(ctx context.Context, tableName string) []permissionProbe {
+	optFns := t.tableCallOptions(tableName)
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: "doctor-probe-" + randomID()},
+	}
+	expr, exprErr := expression.NewBuilder().
+		WithCondition(expression.AttributeExists(expression.Name("id"))).
+		Build()
+	put := permissionProbe{"dynamodb:PutItem", func() error {
+		if exprErr != nil {
+			return exprErr
+		}
+		_, err := t.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 &tableName,
+			Item:                      key,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		}, optFns...)
+		return err
+	}}
+	probes := []permissionProbe{
+		{"dynamodb:Scan", func() error {
+			_, err := t.client.Scan(ctx, &dynamodb.ScanInput{TableName: &tableName, Limit: aws.Int32(1)}, optFns...)
+			return err
+		}},
+		put,
+		{"dynamodb:UpdateItem", func() error {
+			update, err := expression.NewBuilder().
+				WithCondition(expression.AttributeExists(expression.Name("id"))).
+				WithUpdate(expression.Set(expression.Name("dirty"), expression.Value(false))).
+				Build()
+			if err != nil {
+				return err
+			}
+			_, err = t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName:                 &tableName,
+				Key:                       key,
+				UpdateExpression:          update.Update(),
+				ConditionExpression:       update.Condition(),
+				ExpressionAttributeNames:  update.Names(),
+				ExpressionAttributeValues: update.Values(),
+			}, optFns...)
+			return err
+		}},
+		{"dynamodb:DeleteItem", func() error {
+			if exprErr != nil {
+				return exprErr
+			}
+			_, err := t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+				TableName:                 &tableName,
+				Key:                       key,
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			}, optFns...)
+			return err
+		}},
+	}
+	return probes
+}
+
+// tableProbes returns the probes of the actions the Target performs on the table.
+func (t *Target) tableProbes(ctx context.Context, tableName string) []permissionProbe {
+	optFns := t.tableCallOptions(tableName)
+	probes := t.writeProbes(ctx, tableName)
+	// the audit table is only appended to, with PutItem.
+	if tableName == t.auditTableName {
+		return []permissionProbe{probes[1]}
+	}
+
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: "doctor-probe-" + randomID()},
+	}
+	expr, exprErr := expression.NewBuilder().
+		WithCondition(expression.AttributeExists(expression.Name("id"))).
+		Build()
+	// the transactions write the lock table (LockMany), and the migrations table with WithTransactionalWrites.
+	if tableName == t.lockTableName || t.transactionalWrites {
+		probes = append(probes, permissionProbe{"dynamodb:ConditionCheckItem", func() error {
+			if exprErr != nil {
+				return exprErr
+			}
+			_, err := t.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+				TransactItems: []types.TransactWriteItem{{
+					ConditionCheck: &types.ConditionCheck{
+						TableName:                 &tableName,
+						Key:                       key,
+						ConditionExpression:       expr.Condition(),
+						ExpressionAttributeNames:  expr.Names(),
+						ExpressionAttributeValues: expr.Values(),
+					},
+				}},
+			}, optFns...)
+			return err
+		}})
+	}
+	if tableName == t.lockTableName {
+		if client, ok := t.client.(TimeToLiveDynamoDBClient); ok {
+			probes = append(probes, permissionProbe{"dynamodb:DescribeTimeToLive", func() error {
+				// a client not supporting it is reported as unknown, by its error.
+				_, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: &tableName}, optFns...)
+				return err
+			}})
+		}
+	}
+	if tableName == t.tableName {
+		for _, index := range t.migrationsTableIndexes() {
+			client, ok := t.client.(QueryDynamoDBClient)
+			if !ok {
+				break
+			}
+			probes = append(probes, permissionProbe{"dynamodb:Query", func() error {
+				keyCondition, err := expression.NewBuilder().
+					WithKeyCondition(expression.Key(aws.ToString(index.hashKey.AttributeName)).Equal(expression.Value("doctor-probe"))).
+					Build()
+				if err != nil {
+					return err
+				}
+				_, err = client.Query(ctx, &dynamodb.QueryInput{
+					TableName:                 &tableName,
+					IndexName:                 aws.String(index.name),
+					KeyConditionExpression:    keyCondition.KeyCondition(),
+					ExpressionAttributeNames:  keyCondition.Names(),
+					ExpressionAttributeValues: keyCondition.Values(),
+					Limit:                     aws.Int32(1),
+				})
+				return err
+			}})
+		}
+	}
+	return probes
+}
@@ -0,0 +1,70 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Preflight", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	It("should report every action as allowed", func() {
+		target := NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit"), WithDirtyIndex())
+		Expect(target.Create(ctx)).To(Succeed())
+
+		report, err := target.Preflight(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Err()).ToNot(HaveOccurred())
+		Expect(report.Checks).To(ContainElements(
+			PermissionCheck{Action: "dynamodb:ListTables", Status: PermissionAllowed},
+			PermissionCheck{Action: "dynamodb:PutItem", Table: "_migrations-audit", Status: PermissionAllowed},
+			PermissionCheck{Action: "dynamodb:Query", Table: "_migrations", Status: PermissionAllowed},
+			PermissionCheck{Action: "dynamodb:ConditionCheckItem", Table: "_migrations-lock", Status: PermissionAllowed},
+		))
+		Expect(report.Checks).ToNot(ContainElement(SatisfyAll(HaveField("Action", "dynamodb:UpdateItem"), HaveField("Table", "_migrations-audit"))))
+		Expect(report.Checks).ToNot(ContainElement(HaveField("Action", "dynamodb:CreateTable")))
+	})
+
+	It("should report the creation of the tables missing as unknown", func() {
+		report, err := NewTarget(dynamoDBClient).Preflight(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Checks).To(ContainElements(
+			PermissionCheck{Action: "dynamodb:DescribeTable", Table: "_migrations", Status: PermissionAllowed, Detail: tableMissingDetail},
+			SatisfyAll(HaveField("Action", "dynamodb:CreateTable"), HaveField("Status", PermissionUnknown)),
+		))
+		Expect(report.Err()).ToNot(HaveOccurred())
+	})
+
+	It("should report the actions the client does not support as unknown", func() {
+		Expect(NewTarget(dynamoDBClient, WithDirtyIndex()).Create(ctx)).To(Succeed())
+
+		report, err := NewTarget(scanOnlyClient{dynamoDBClient}, WithDirtyIndex()).Preflight(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Checks).To(ContainElements(
+			SatisfyAll(HaveField("Action", "dynamodb:Query"), HaveField("Status", PermissionUnknown), HaveField("Detail", ContainSubstring("does not support Query"))),
+			SatisfyAll(HaveField("Action", "dynamodb:DescribeTimeToLive"), HaveField("Status", PermissionUnknown)),
+		))
+	})
+
+	It("should report the actions denied", func() {
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+
+		report, err := NewTarget(&readOnlyClient{Client: dynamoDBClient}).Preflight(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Denied()).To(ConsistOf(
+			HaveField("Table", "_migrations"),
+			HaveField("Table", "_migrations-lock"),
+		))
+		Expect(report.Denied()[0].Action).To(Equal("dynamodb:PutItem"))
+		Expect(report.Err()).To(MatchError(ErrPermissionDenied))
+		Expect(report.Err()).To(MatchError(ContainSubstring("dynamodb:PutItem on _migrations-lock")))
+	})
+})
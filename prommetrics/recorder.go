@@ -0,0 +1,64 @@
+// Package prommetrics implements a migrations_dynamodb.MetricsRecorder on top of Prometheus metrics.
+package prommetrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Recorder records the metrics of a Target as Prometheus collectors.
+type Recorder struct {
+	operations *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	durations  *prometheus.HistogramVec
+	lockWaits  prometheus.Histogram
+}
+
+// New creates the collectors of the Recorder and registers them in the given registerer.
+func New(registerer prometheus.Registerer) (*Recorder, error) {
+	r := &Recorder{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "migrations_dynamodb_operations_total",
+			Help: "Number of operations executed by the target.",
+		}, []string{"operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "migrations_dynamodb_errors_total",
+			Help: "Number of operations executed by the target that failed.",
+		}, []string{"operation"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "migrations_dynamodb_operation_duration_seconds",
+			Help:    "Duration of the operations executed by the target.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation"}),
+		lockWaits: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "migrations_dynamodb_lock_wait_seconds",
+			Help:    "Time waited to acquire the lock.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{r.operations, r.errors, r.durations, r.lockWaits} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("failed to register collector: %w", err)
+		}
+	}
+
+	return r, nil
+}
+
+// RecordOperation implements migrations_dynamodb.MetricsRecorder.
+func (r *Recorder) RecordOperation(_ context.Context, operation string, duration time.Duration, err error) {
+	r.operations.WithLabelValues(operation).Inc()
+	r.durations.WithLabelValues(operation).Observe(duration.Seconds())
+	if err != nil {
+		r.errors.WithLabelValues(operation).Inc()
+	}
+}
+
+// RecordLockWait implements migrations_dynamodb.MetricsRecorder.
+func (r *Recorder) RecordLockWait(_ context.Context, duration time.Duration) {
+	r.lockWaits.Observe(duration.Seconds())
+}
@@ -0,0 +1,68 @@
+package prommetrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Recorder", func() {
+	var (
+		ctx      context.Context
+		registry *prometheus.Registry
+		recorder *Recorder
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		registry = prometheus.NewRegistry()
+
+		var err error
+		recorder, err = New(registry)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should count operations and errors", func() {
+		recorder.RecordOperation(ctx, "Add", time.Millisecond, nil)
+		recorder.RecordOperation(ctx, "Add", time.Millisecond, errors.New("random error"))
+		recorder.RecordOperation(ctx, "Done", time.Millisecond, nil)
+
+		Expect(testutil.ToFloat64(recorder.operations.WithLabelValues("Add"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(recorder.operations.WithLabelValues("Done"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(recorder.errors.WithLabelValues("Add"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(recorder.errors.WithLabelValues("Done"))).To(Equal(0.0))
+	})
+
+	It("should observe durations and lock waits", func() {
+		recorder.RecordOperation(ctx, "Lock", 2*time.Second, nil)
+		recorder.RecordLockWait(ctx, time.Second)
+
+		Expect(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP migrations_dynamodb_lock_wait_seconds Time waited to acquire the lock.
+# TYPE migrations_dynamodb_lock_wait_seconds histogram
+migrations_dynamodb_lock_wait_seconds_bucket{le="0.01"} 0
+migrations_dynamodb_lock_wait_seconds_bucket{le="0.04"} 0
+migrations_dynamodb_lock_wait_seconds_bucket{le="0.16"} 0
+migrations_dynamodb_lock_wait_seconds_bucket{le="0.64"} 0
+migrations_dynamodb_lock_wait_seconds_bucket{le="2.56"} 1
+migrations_dynamodb_lock_wait_seconds_bucket{le="10.24"} 1
+migrations_dynamodb_lock_wait_seconds_bucket{le="40.96"} 1
+migrations_dynamodb_lock_wait_seconds_bucket{le="163.84"} 1
+migrations_dynamodb_lock_wait_seconds_bucket{le="+Inf"} 1
+migrations_dynamodb_lock_wait_seconds_sum 1
+migrations_dynamodb_lock_wait_seconds_count 1
+`), "migrations_dynamodb_lock_wait_seconds")).To(Succeed())
+		Expect(testutil.CollectAndCount(recorder.durations, "migrations_dynamodb_operation_duration_seconds")).To(Equal(1))
+	})
+
+	It("should fail registering twice in the same registerer", func() {
+		_, err := New(registry)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,95 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PruneResource is a resource created along with the migrations, deleted by Destroy when set by WithDestroyPrune.
+type PruneResource string
+
+const (
+	// PruneAuditTable deletes the audit table set by WithAuditTable.
+	PruneAuditTable PruneResource = "audit_table"
+
+	// PruneBackups deletes the on-demand backups recorded in the metadata of the migrations under the "backup_arn:"
+	// keys, as the ones taken by helpers.CreateBackup with helpers.WithMetadata. The backups deleted already are
+	// skipped.
+	PruneBackups PruneResource = "backups"
+)
+
+// backupMetadataPrefix is the prefix of the metadata keys of the backups taken by the migrations, followed by the name
+// of the table backed up (see helpers.CreateBackup).
+const backupMetadataPrefix = "backup_arn:"
+
+// BackupDynamoDBClient is implemented by the clients able to delete backups, as the *dynamodb.Client. It is optional:
+// it is only needed by Destroy to delete the backups with PruneBackups.
+type BackupDynamoDBClient interface {
+	DeleteBackup(ctx context.Context, params *dynamodb.DeleteBackupInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error)
+}
+
+// errBackupUnsupported is returned by the client wrappers when the wrapped client does not implement
+// BackupDynamoDBClient.
+var errBackupUnsupported = fmt.Errorf("the client does not support DeleteBackup: %w", errors.ErrUnsupported)
+
+// prunes checks if Destroy deletes the resource.
+func (t *Target) prunes(resource PruneResource) bool {
+	return slices.Contains(t.destroyPrune, resource)
+}
+
+// backupARNs returns the ARNs of the backups recorded in the metadata of the migrations, sorted.
+func (t *Target) backupARNs(ctx context.Context) ([]string, error) {
+	records, err := t.History(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var arns []string
+	for _, record := range records {
+		for key, value := range record.Metadata {
+			if strings.HasPrefix(key, backupMetadataPrefix) && value != "" {
+				arns = append(arns, value)
+			}
+		}
+	}
+	sort.Strings(arns)
+	return slices.Compact(arns), nil
+}
+
+// deleteBackups deletes the backups recorded in the metadata of the migrations, before the migrations table holding
+// them is deleted.
+func (t *Target) deleteBackups(ctx context.Context) error {
+	arns, err := t.backupARNs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the backups of the migrations: %w", err)
+	}
+	if len(arns) == 0 {
+		return nil
+	}
+	client, ok := t.client.(BackupDynamoDBClient)
+	if !ok {
+		return errBackupUnsupported
+	}
+	for _, arn := range arns {
+		_, err := client.DeleteBackup(ctx, &dynamodb.DeleteBackupInput{
+			BackupArn: aws.String(arn),
+		})
+		var backupNotFoundException *types.BackupNotFoundException
+		switch {
+		case errors.As(err, &backupNotFoundException):
+			t.logger.DebugContext(ctx, "backup already deleted", "backup_arn", arn)
+		case err != nil:
+			return fmt.Errorf("failed to delete the backup %s: %w", arn, err)
+		default:
+			t.logger.Log(ctx, t.eventLogLevel, "backup deleted", "backup_arn", arn)
+		}
+	}
+	return nil
+}
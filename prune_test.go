@@ -0,0 +1,88 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// backupClient records the backups deleted, as DynamoDB Local does not support the backups. The backups of the ARNs
+// in missing do not exist.
+type backupClient struct {
+	*dynamodb.Client
+
+	missing []string
+	deleted []string
+}
+
+func (c *backupClient) DeleteBackup(_ context.Context, input *dynamodb.DeleteBackupInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	arn := aws.ToString(input.BackupArn)
+	for _, missing := range c.missing {
+		if arn == missing {
+			return nil, &types.BackupNotFoundException{Message: aws.String("backup not found")}
+		}
+	}
+	c.deleted = append(c.deleted, arn)
+	return &dynamodb.DeleteBackupOutput{}, nil
+}
+
+var _ = Describe("Destroy prune", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	tableNames := func() []string {
+		GinkgoHelper()
+
+		output, err := dynamoDBClient.ListTables(ctx, &dynamodb.ListTablesInput{})
+		Expect(err).ToNot(HaveOccurred())
+		return output.TableNames
+	}
+
+	It("should keep the audit table unless pruned", func() {
+		target := NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit"), WithTableWait(true))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Destroy(ctx)).To(Succeed())
+		Expect(tableNames()).To(Equal([]string{"_migrations-audit"}))
+
+		target = NewTarget(dynamoDBClient, WithAuditTable("_migrations-audit"), WithTableWait(true), WithDestroyPrune(PruneAuditTable))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Destroy(ctx)).To(Succeed())
+		Expect(tableNames()).To(BeEmpty())
+	})
+
+	It("should delete the backups recorded by the migrations", func() {
+		client := &backupClient{Client: dynamoDBClient, missing: []string{"arn:backup/3"}}
+		target := NewTarget(client, WithDestroyPrune(PruneBackups))
+		Expect(target.Create(ctx)).To(Succeed())
+		for _, id := range []string{"1", "2"} {
+			Expect(target.Add(ctx, id)).To(Succeed())
+			Expect(target.FinishMigration(ctx, id)).To(Succeed())
+		}
+		Expect(target.SetMetadata(ctx, "1", "backup_arn:orders", "arn:backup/1")).To(Succeed())
+		Expect(target.SetMetadata(ctx, "1", "export_arn:orders", "arn:export/1")).To(Succeed())
+		Expect(target.SetMetadata(ctx, "2", "backup_arn:orders", "arn:backup/2")).To(Succeed())
+		Expect(target.SetMetadata(ctx, "2", "backup_arn:customers", "arn:backup/3")).To(Succeed())
+
+		Expect(target.Destroy(ctx)).To(Succeed())
+		Expect(client.deleted).To(Equal([]string{"arn:backup/1", "arn:backup/2"}))
+	})
+
+	It("should not delete the tables when the backups cannot be deleted", func() {
+		target := NewTarget(scanOnlyClient{dynamoDBClient}, WithDestroyPrune(PruneBackups))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.SetMetadata(ctx, "1", "backup_arn:orders", "arn:backup/1")).To(Succeed())
+
+		Expect(target.Destroy(ctx)).To(MatchError(errBackupUnsupported))
+		Expect(tableNames()).To(ContainElement(target.TableName()))
+	})
+})
@@ -0,0 +1,104 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// maxTransactItems is the maximum number of items of a TransactWriteItems call.
+const maxTransactItems = 100
+
+// RemoveAfter removes the records of all migrations with an ID greater than the given one, rolling the migrations
+// table back to that migration, and returns the IDs removed, sorted. If the migration does not exist, it returns an
+// `migrations.ErrMigrationNotFound` without removing anything.
+//
+// The records are removed in transactions that also check that the lock is still held by the Target, whether
+// WithTransactionalWrites is set or not, so the caller must hold the lock (e.g. with Lock). Each transaction removes
+// up to 99 records, starting by the greatest IDs: if one fails, the records removed by the previous ones are still a
+// rollback to a migration after the given one.
+func (t *Target) RemoveAfter(ctx context.Context, id string) (_ []string, err error) {
+	defer t.observe(ctx, operationRemoveAfter, t.clock.Now(), &err)
+	defer wrapError(operationRemoveAfter, t.tableName, &err)
+
+	if err := t.guardMaintenance(ctx, operationRemoveAfter); err != nil {
+		return nil, err
+	}
+
+	items, err := t.scanMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	after := make(map[string]map[string]types.AttributeValue)
+	for _, item := range items {
+		itemID, ok := item["id"].(*types.AttributeValueMemberS)
+		switch {
+		case !ok:
+			continue
+		case itemID.Value == id:
+			found = true
+		case itemID.Value > id:
+			after[itemID.Value] = item
+		}
+	}
+	if !found {
+		return nil, migrations.ErrMigrationNotFound
+	}
+
+	ids := make([]string, 0, len(after))
+	for itemID := range after {
+		ids = append(ids, itemID)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+
+	expr, err := expression.NewBuilder().
+		WithCondition(t.withWriteCondition(expression.AttributeExists(expression.Name("id")))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the remove expression: %w", err)
+	}
+
+	removed := make([]string, 0, len(ids))
+	for len(ids) > 0 {
+		batch := ids[:min(len(ids), maxTransactItems-1)]
+		ids = ids[len(batch):]
+
+		deletes := make([]types.TransactWriteItem, 0, len(batch))
+		for _, itemID := range batch {
+			deletes = append(deletes, types.TransactWriteItem{
+				Delete: &types.Delete{
+					TableName: &t.tableName,
+					Key: map[string]types.AttributeValue{
+						"id": &types.AttributeValueMemberS{Value: itemID},
+					},
+					ConditionExpression:       expr.Condition(),
+					ExpressionAttributeNames:  expr.Names(),
+					ExpressionAttributeValues: expr.Values(),
+				},
+			})
+		}
+		err := t.transactWrite(ctx, operationRemoveAfter, deletes...)
+		var conditionalCheckFailedException *types.ConditionalCheckFailedException
+		switch {
+		case errors.As(err, &conditionalCheckFailedException):
+			return removed, fmt.Errorf("failed to remove the migrations after %s: %w", id, migrations.ErrMigrationNotFound)
+		case err != nil:
+			return removed, fmt.Errorf("failed to remove the migrations after %s: %w", id, err)
+		}
+
+		for _, itemID := range batch {
+			t.audit(ctx, operationRemoveAfter, itemID, after[itemID], nil)
+			removed = append(removed, itemID)
+		}
+	}
+	sort.Strings(removed)
+	t.logger.Log(ctx, t.eventLogLevel, "migrations removed", "after", id, "count", len(removed))
+
+	return removed, nil
+}
@@ -0,0 +1,96 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RemoveAfter", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	add := func(ids ...string) {
+		GinkgoHelper()
+
+		for _, id := range ids {
+			Expect(target.Add(ctx, id)).To(Succeed())
+			Expect(target.FinishMigration(ctx, id)).To(Succeed())
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	When("the lock is held", func() {
+		BeforeEach(func() {
+			u, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(u.Unlock, ctx)
+		})
+
+		It("should remove the migrations after the given one", func() {
+			add("0001", "0002", "0003", "0004")
+
+			removed, err := target.RemoveAfter(ctx, "0002")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(Equal([]string{"0003", "0004"}))
+			Expect(target.Done(ctx)).To(Equal([]string{"0001", "0002"}))
+		})
+
+		It("should remove the migrations in batches of transactions", func() {
+			ids := make([]string, 0, 120)
+			for i := range 120 {
+				ids = append(ids, fmt.Sprintf("%04d", i))
+			}
+			for _, id := range ids {
+				Expect(target.Add(ctx, id)).To(Succeed())
+			}
+
+			removed, err := target.RemoveAfter(ctx, "0000")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(Equal(ids[1:]))
+
+			status, err := target.Status(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "0000", Dirty: true}}))
+		})
+
+		It("should remove nothing when the migration is the last one", func() {
+			add("0001", "0002")
+
+			removed, err := target.RemoveAfter(ctx, "0002")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(BeEmpty())
+			Expect(target.Done(ctx)).To(Equal([]string{"0001", "0002"}))
+		})
+
+		It("should fail with ErrMigrationNotFound when the migration does not exist", func() {
+			add("0001", "0003")
+
+			_, err := target.RemoveAfter(ctx, "0002")
+			Expect(err).To(MatchError(migrations.ErrMigrationNotFound))
+			Expect(ErrorCodeOf(err)).To(Equal(CodeNotFound))
+			Expect(target.Done(ctx)).To(Equal([]string{"0001", "0003"}))
+		})
+	})
+
+	It("should fail with ErrLockNotHeld when the lock is not held", func() {
+		add("0001", "0002")
+
+		_, err := target.RemoveAfter(ctx, "0001")
+		Expect(err).To(MatchError(ErrLockNotHeld))
+		Expect(target.Done(ctx)).To(Equal([]string{"0001", "0002"}))
+	})
+})
@@ -0,0 +1,133 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+)
+
+type runOpts struct {
+	targetOptions []Option
+	planner       migrations.ActionPLanner
+	reporters     []migrations.RunnerReporter
+	approval      bool
+	approvalWait  time.Duration
+}
+
+// RunOption configures RunMigrations and NewMigrator.
+type RunOption func(*runOpts)
+
+// WithTargetOptions sets the options of the Target created by RunMigrations.
+func WithTargetOptions(options ...Option) RunOption {
+	return func(o *runOpts) {
+		o.targetOptions = append(o.targetOptions, options...)
+	}
+}
+
+// WithPlanner sets the planner of the migrations run by RunMigrations. Defaults to migrations.MigratePlanner.
+func WithPlanner(planner migrations.ActionPLanner) RunOption {
+	return func(o *runOpts) {
+		o.planner = planner
+	}
+}
+
+// WithReporter adds a reporter of the progress of RunMigrations, along with the one logging it with the logger of the
+// Target (see WithLogger).
+func WithReporter(reporter migrations.RunnerReporter) RunOption {
+	return func(o *runOpts) {
+		o.reporters = append(o.reporters, reporter)
+	}
+}
+
+// WithApproval makes RunMigrations run the plan only once it is approved (see ApprovalPlanner), checking for the
+// approval every interval, or failing with an ErrApprovalPending right away if interval is 0.
+func WithApproval(interval time.Duration) RunOption {
+	return func(o *runOpts) {
+		o.approval = true
+		o.approvalWait = interval
+	}
+}
+
+// WithRepairPolicy makes the Target repair the migrations left dirty for longer than the threshold by crashed runners
+// with the policy, right after acquiring the lock (see WithStaleDirtyThreshold and WithStaleDirtyPolicy).
+func WithRepairPolicy(policy StaleDirtyPolicy, threshold time.Duration) RunOption {
+	return WithTargetOptions(WithStaleDirtyThreshold(threshold), WithStaleDirtyPolicy(policy))
+}
+
+// RunMigrations creates a Target with the client, creates its tables if they do not exist, and runs the migrations of
+// the source under the lock, replacing the bootstrap code of the services running their migrations on start. The
+// progress of every migration is logged with the logger of the Target, and reported to the reporters set by
+// WithReporter.
+func RunMigrations(ctx context.Context, client DynamoDBClient, source migrations.Source, options ...RunOption) (migrations.ExecutionResponse, error) {
+	return NewMigrator(client, source, options...).migrate(ctx)
+}
+
+// logReporter logs the progress of the runner.
+type logReporter struct {
+	logger    *slog.Logger
+	level     slog.Level
+	clock     Clock
+	startedAt time.Time
+}
+
+func (r *logReporter) BeforeExecute(ctx context.Context, info *migrations.BeforeExecuteInfo) {
+	r.logger.Log(ctx, r.level, "running migrations", "planned", len(info.Plan))
+}
+
+func (r *logReporter) BeforeExecuteMigration(ctx context.Context, info *migrations.BeforeExecuteMigrationInfo) {
+	r.startedAt = r.clock.Now()
+	r.logger.Log(ctx, r.level, "executing migration", "migration_id", info.Migration.ID(), "action", info.ActionType,
+		"description", info.Migration.Description())
+}
+
+func (r *logReporter) AfterExecuteMigration(ctx context.Context, info *migrations.AfterExecuteMigrationInfo) {
+	elapsed := r.clock.Now().Sub(r.startedAt)
+	if info.Err != nil {
+		r.logger.ErrorContext(ctx, "migration failed", "migration_id", info.Migration.ID(), "action", info.ActionType,
+			"elapsed", elapsed, "error", info.Err)
+		return
+	}
+	r.logger.Log(ctx, r.level, "migration executed", "migration_id", info.Migration.ID(), "action", info.ActionType,
+		"elapsed", elapsed)
+}
+
+func (r *logReporter) AfterExecute(ctx context.Context, info *migrations.AfterExecuteInfo) {
+	if info.Err != nil {
+		r.logger.ErrorContext(ctx, "migrations failed", "error", info.Err)
+		return
+	}
+	var executed int
+	if info.Stats != nil {
+		executed = len(info.Stats.Successful)
+	}
+	r.logger.Log(ctx, r.level, "migrations finished", "executed", executed)
+}
+
+// multiReporter reports the progress to every reporter, in order.
+type multiReporter []migrations.RunnerReporter
+
+func (m multiReporter) BeforeExecute(ctx context.Context, info *migrations.BeforeExecuteInfo) {
+	for _, r := range m {
+		r.BeforeExecute(ctx, info)
+	}
+}
+
+func (m multiReporter) BeforeExecuteMigration(ctx context.Context, info *migrations.BeforeExecuteMigrationInfo) {
+	for _, r := range m {
+		r.BeforeExecuteMigration(ctx, info)
+	}
+}
+
+func (m multiReporter) AfterExecuteMigration(ctx context.Context, info *migrations.AfterExecuteMigrationInfo) {
+	for _, r := range m {
+		r.AfterExecuteMigration(ctx, info)
+	}
+}
+
+func (m multiReporter) AfterExecute(ctx context.Context, info *migrations.AfterExecuteInfo) {
+	for _, r := range m {
+		r.AfterExecute(ctx, info)
+	}
+}
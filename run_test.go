@@ -0,0 +1,78 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recordingReporter records the migrations reported.
+type recordingReporter struct {
+	executed []string
+}
+
+func (r *recordingReporter) BeforeExecute(context.Context, *migrations.BeforeExecuteInfo) {}
+
+func (r *recordingReporter) BeforeExecuteMigration(context.Context, *migrations.BeforeExecuteMigrationInfo) {
+}
+
+func (r *recordingReporter) AfterExecuteMigration(_ context.Context, info *migrations.AfterExecuteMigrationInfo) {
+	r.executed = append(r.executed, string(info.ActionType)+" "+info.Migration.ID())
+}
+
+func (r *recordingReporter) AfterExecute(context.Context, *migrations.AfterExecuteInfo) {}
+
+var _ = Describe("RunMigrations", func() {
+	var (
+		ctx    context.Context
+		source migrations.Source
+	)
+
+	addMigration := func(id string, err error) {
+		GinkgoHelper()
+
+		Expect(source.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+			return err
+		}, nil))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		source = migrations.NewMemorySource()
+	})
+
+	It("should create the tables, run the migrations and report their progress", func() {
+		addMigration("0001", nil)
+		addMigration("0002", nil)
+		buf := &syncBuffer{}
+		reporter := &recordingReporter{}
+
+		response, err := RunMigrations(ctx, dynamoDBClient, source,
+			WithTargetOptions(WithTableName("_run_migrations"), WithLogger(slog.New(slog.NewJSONHandler(buf, nil)))),
+			WithReporter(reporter),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Successful).To(HaveLen(2))
+		Expect(reporter.executed).To(Equal([]string{"do 0001", "do 0002"}))
+		Expect(logMessages(buf)).To(ContainElements("running migrations", "executing migration", "migration executed", "migrations finished"))
+
+		Expect(NewTarget(dynamoDBClient, WithTableName("_run_migrations")).Done(ctx)).To(Equal([]string{"0001", "0002"}))
+	})
+
+	It("should log the migration that failed", func() {
+		errFailed := errors.New("migration failed")
+		addMigration("0001", errFailed)
+		buf := &syncBuffer{}
+
+		_, err := RunMigrations(ctx, dynamoDBClient, source, WithTargetOptions(WithLogger(slog.New(slog.NewJSONHandler(buf, nil)))))
+		Expect(err).To(MatchError(errFailed))
+		Expect(logMessages(buf)).To(ContainElement("migration failed"))
+	})
+})
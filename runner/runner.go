@@ -0,0 +1,231 @@
+// Package runner is an entrypoint running the migrations once and exiting, for Kubernetes init containers and ECS
+// tasks. The outcome is reported by the exit code and by a JSON summary written to stdout, so the orchestration can
+// branch on it:
+//
+//	func main() {
+//		target := migrations_dynamodb.NewTarget(dynamodb.NewFromConfig(cfg))
+//		runner.Main(source, target, runner.WithLockTimeout(5*time.Minute))
+//	}
+//
+// By default, only the applied outcome exits with 0. Kubernetes does not start a pod while an init container exits
+// with anything but 0, so init containers usually also map the nothing to do outcome to 0, with WithExitCodes.
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// Outcome is the result of a run.
+type Outcome string
+
+const (
+	// OutcomeApplied is when at least one migration was applied.
+	OutcomeApplied Outcome = "applied"
+	// OutcomeNothingToDo is when there was no pending migration.
+	OutcomeNothingToDo Outcome = "nothing_to_do"
+	// OutcomeDirty is when a migration was left dirty by a previous run, and must be fixed by hand.
+	OutcomeDirty Outcome = "dirty"
+	// OutcomeLockTimeout is when the lock was not acquired within the timeout set by WithLockTimeout.
+	OutcomeLockTimeout Outcome = "lock_timeout"
+	// OutcomeFailed is when the run failed for any other reason, e.g. a migration failed.
+	OutcomeFailed Outcome = "failed"
+)
+
+// ExitCodes are the exit codes of the outcomes.
+type ExitCodes struct {
+	Applied     int
+	NothingToDo int
+	Dirty       int
+	LockTimeout int
+	Failed      int
+}
+
+// DefaultExitCodes are the exit codes used unless set by WithExitCodes. The exit code 2 is left for the usage errors.
+var DefaultExitCodes = ExitCodes{
+	Applied:     0,
+	NothingToDo: 3,
+	Dirty:       4,
+	LockTimeout: 5,
+	Failed:      1,
+}
+
+func (c ExitCodes) of(outcome Outcome) int {
+	switch outcome {
+	case OutcomeApplied:
+		return c.Applied
+	case OutcomeNothingToDo:
+		return c.NothingToDo
+	case OutcomeDirty:
+		return c.Dirty
+	case OutcomeLockTimeout:
+		return c.LockTimeout
+	}
+	return c.Failed
+}
+
+// Summary is the JSON document written to the output at the end of the run.
+type Summary struct {
+	Outcome  Outcome `json:"outcome"`
+	ExitCode int     `json:"exitCode"`
+	// Applied are the IDs of the migrations applied, and Failed the ones that failed.
+	Applied []string `json:"applied"`
+	Failed  []string `json:"failed,omitempty"`
+	// Current is the ID of the last migration applied, if any.
+	Current string `json:"current,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// DurationMs is how long the run took, in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+}
+
+type opts struct {
+	planner     migrations.ActionPLanner
+	lockTimeout time.Duration
+	exitCodes   ExitCodes
+	output      io.Writer
+}
+
+// Option configures the run.
+type Option func(*opts)
+
+func defaultOpts() opts {
+	return opts{
+		planner:   migrations.MigratePlanner,
+		exitCodes: DefaultExitCodes,
+		output:    os.Stdout,
+	}
+}
+
+// WithPlanner sets the planner of the migrations run. Defaults to migrations.MigratePlanner.
+func WithPlanner(planner migrations.ActionPLanner) Option {
+	return func(o *opts) {
+		o.planner = planner
+	}
+}
+
+// WithLockTimeout sets how long to wait for the lock held by another runner before giving up with the lock timeout
+// outcome. By default, it waits for as long as the context allows.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *opts) {
+		o.lockTimeout = timeout
+	}
+}
+
+// WithExitCodes sets the exit codes of the outcomes.
+func WithExitCodes(codes ExitCodes) Option {
+	return func(o *opts) {
+		o.exitCodes = codes
+	}
+}
+
+// WithOutput sets where the summary is written. Defaults to stdout.
+func WithOutput(w io.Writer) Option {
+	return func(o *opts) {
+		o.output = w
+	}
+}
+
+// Main runs the migrations, as Run, and exits with the exit code of the outcome.
+func Main(source migrations.Source, target migrations.Target, options ...Option) {
+	os.Exit(Run(context.Background(), source, target, options...))
+}
+
+// Run creates the tables of the target, if they do not exist, runs the migrations of the source under the lock, writes
+// the summary to the output and returns the exit code of the outcome.
+func Run(ctx context.Context, source migrations.Source, target migrations.Target, options ...Option) int {
+	o := defaultOpts()
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	startedAt := time.Now()
+	summary := run(ctx, source, target, o)
+	summary.ExitCode = o.exitCodes.of(summary.Outcome)
+	summary.DurationMs = time.Since(startedAt).Milliseconds()
+
+	if err := json.NewEncoder(o.output).Encode(summary); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to write the summary: %s\n", err)
+	}
+	return summary.ExitCode
+}
+
+func run(ctx context.Context, source migrations.Source, target migrations.Target, o opts) Summary {
+	summary := Summary{Applied: []string{}}
+	fail := func(err error) Summary {
+		summary.Outcome = outcomeOf(err)
+		summary.Error = err.Error()
+		return summary
+	}
+
+	if err := target.Create(ctx); err != nil {
+		return fail(fmt.Errorf("failed to create the tables: %w", err))
+	}
+
+	response, err := migrations.Migrate(ctx, source, lockTimeoutTarget{Target: target, timeout: o.lockTimeout},
+		migrations.WithPlanner(o.planner))
+	for _, action := range response.Successful {
+		summary.Applied = append(summary.Applied, action.Migration.ID())
+	}
+	for _, action := range response.Errored {
+		summary.Failed = append(summary.Failed, action.Migration.ID())
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	current, err := target.Current(ctx)
+	switch {
+	case errors.Is(err, migrations.ErrNoCurrentMigration):
+	case err != nil:
+		return fail(fmt.Errorf("failed to get the current migration: %w", err))
+	default:
+		summary.Current = current
+	}
+
+	summary.Outcome = OutcomeNothingToDo
+	if len(summary.Applied) > 0 {
+		summary.Outcome = OutcomeApplied
+	}
+	return summary
+}
+
+func outcomeOf(err error) Outcome {
+	switch {
+	case errors.Is(err, migrations.ErrDirtyMigration):
+		return OutcomeDirty
+	case errors.Is(err, errLockTimeout), migrations_dynamodb.ErrorCodeOf(err) == migrations_dynamodb.CodeLockTimeout:
+		return OutcomeLockTimeout
+	}
+	return OutcomeFailed
+}
+
+var errLockTimeout = errors.New("timed out waiting for the lock")
+
+// lockTimeoutTarget bounds the time the Lock of the target waits for the lock.
+type lockTimeoutTarget struct {
+	migrations.Target
+	timeout time.Duration
+}
+
+func (t lockTimeoutTarget) Lock(ctx context.Context) (migrations.Unlocker, error) {
+	if t.timeout <= 0 {
+		return t.Target.Lock(ctx)
+	}
+	lockCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	unlocker, err := t.Target.Lock(lockCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s: %w", errLockTimeout, t.timeout, err)
+	}
+	return unlocker, err
+}
@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// memoryTarget is a Target keeping the state in memory, so the runner can be tested without a DynamoDB.
+type memoryTarget struct {
+	migrations map[string]bool // the migrations recorded, and whether they are dirty.
+	// lockHeld makes Lock wait for the context, as when the lock is held by another runner.
+	lockHeld bool
+}
+
+func (t *memoryTarget) Current(ctx context.Context) (string, error) {
+	done, err := t.Done(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(done) == 0 {
+		return "", migrations.ErrNoCurrentMigration
+	}
+	return done[len(done)-1], nil
+}
+
+func (t *memoryTarget) Create(context.Context) error {
+	return nil
+}
+
+func (t *memoryTarget) Destroy(context.Context) error {
+	t.migrations = map[string]bool{}
+	return nil
+}
+
+func (t *memoryTarget) Done(context.Context) ([]string, error) {
+	r := make([]string, 0, len(t.migrations))
+	for id, dirty := range t.migrations {
+		if dirty {
+			return nil, migrations.ErrDirtyMigration
+		}
+		r = append(r, id)
+	}
+	sort.Strings(r)
+	return r, nil
+}
+
+func (t *memoryTarget) Add(_ context.Context, id string) error {
+	if _, ok := t.migrations[id]; ok {
+		return migrations.ErrMigrationAlreadyExists
+	}
+	t.migrations[id] = true
+	return nil
+}
+
+func (t *memoryTarget) Remove(_ context.Context, id string) error {
+	delete(t.migrations, id)
+	return nil
+}
+
+func (t *memoryTarget) FinishMigration(_ context.Context, id string) error {
+	t.migrations[id] = false
+	return nil
+}
+
+func (t *memoryTarget) StartMigration(_ context.Context, id string) error {
+	t.migrations[id] = true
+	return nil
+}
+
+func (t *memoryTarget) Lock(ctx context.Context) (migrations.Unlocker, error) {
+	if t.lockHeld {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return t, nil
+}
+
+func (t *memoryTarget) Unlock(context.Context) error {
+	return nil
+}
+
+var _ = Describe("Run", func() {
+	var (
+		ctx    context.Context
+		target *memoryTarget
+		source migrations.Source
+		output *bytes.Buffer
+	)
+
+	addMigration := func(id string, err error) {
+		GinkgoHelper()
+
+		Expect(source.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+			return err
+		}, nil))).To(Succeed())
+	}
+
+	run := func(options ...Option) (int, Summary) {
+		GinkgoHelper()
+
+		code := Run(ctx, source, target, append([]Option{WithOutput(output)}, options...)...)
+		var summary Summary
+		Expect(json.Unmarshal(output.Bytes(), &summary)).To(Succeed())
+		return code, summary
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		target = &memoryTarget{migrations: map[string]bool{}}
+		source = migrations.NewMemorySource()
+		output = &bytes.Buffer{}
+	})
+
+	It("should exit with the applied code and list the migrations applied", func() {
+		addMigration("0001", nil)
+		addMigration("0002", nil)
+
+		code, summary := run()
+		Expect(code).To(Equal(DefaultExitCodes.Applied))
+		Expect(summary.Outcome).To(Equal(OutcomeApplied))
+		Expect(summary.Applied).To(Equal([]string{"0001", "0002"}))
+		Expect(summary.Current).To(Equal("0002"))
+		Expect(summary.ExitCode).To(Equal(code))
+	})
+
+	It("should exit with the nothing to do code when no migration is pending", func() {
+		addMigration("0001", nil)
+		target.migrations["0001"] = false
+
+		code, summary := run()
+		Expect(code).To(Equal(DefaultExitCodes.NothingToDo))
+		Expect(summary.Outcome).To(Equal(OutcomeNothingToDo))
+		Expect(summary.Applied).To(BeEmpty())
+		Expect(summary.Current).To(Equal("0001"))
+	})
+
+	It("should exit with the code set by WithExitCodes", func() {
+		codes := DefaultExitCodes
+		codes.NothingToDo = 0
+
+		code, _ := run(WithExitCodes(codes))
+		Expect(code).To(Equal(0))
+	})
+
+	It("should exit with the dirty code when a migration is dirty", func() {
+		addMigration("0001", nil)
+		target.migrations["0001"] = true
+
+		code, summary := run()
+		Expect(code).To(Equal(DefaultExitCodes.Dirty))
+		Expect(summary.Outcome).To(Equal(OutcomeDirty))
+		Expect(summary.Error).ToNot(BeEmpty())
+	})
+
+	It("should exit with the lock timeout code when the lock is not acquired in time", func() {
+		addMigration("0001", nil)
+		target.lockHeld = true
+
+		code, summary := run(WithLockTimeout(50 * time.Millisecond))
+		Expect(code).To(Equal(DefaultExitCodes.LockTimeout))
+		Expect(summary.Outcome).To(Equal(OutcomeLockTimeout))
+		Expect(summary.Error).To(ContainSubstring("timed out waiting for the lock after 50ms"))
+	})
+
+	It("should exit with the failed code when a migration fails", func() {
+		addMigration("0001", nil)
+		addMigration("0002", errors.New("migration failed"))
+
+		code, summary := run()
+		Expect(code).To(Equal(DefaultExitCodes.Failed))
+		Expect(summary.Outcome).To(Equal(OutcomeFailed))
+		Expect(summary.Applied).To(Equal([]string{"0001"}))
+		Expect(summary.Failed).To(Equal([]string{"0002"}))
+		Expect(summary.Error).To(ContainSubstring("migration failed"))
+	})
+})
@@ -0,0 +1,268 @@
+// Package scaffold generates the files of new Go migrations, registered with the fnc package of migrations/v2 and
+// written with the steps of the helpers package, so writing a DynamoDB migration starts from a file that builds rather
+// than from scratch.
+//
+// The file of a migration is named by its ID and its name, as 20240131235959_add_orders_table.go: fnc reads the ID and
+// the description of the migration from it. The code generated refers to two variables the package of the migrations
+// declares, the client the helpers are called with and the source the migrations are added to:
+//
+//	var (
+//		Client *dynamodb.Client
+//		Source = migrations.NewMemorySource()
+//	)
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// Template is the kind of migration generated.
+type Template string
+
+const (
+	// TemplateEmpty generates a migration doing nothing yet.
+	TemplateEmpty Template = "empty"
+
+	// TemplateTable generates a migration creating a table with helpers.EnsureTable, and deleting it when undone.
+	TemplateTable Template = "table"
+
+	// TemplateGSI generates a migration creating a global secondary index with helpers.EnsureGSI, and dropping it with
+	// helpers.DropGSI when undone.
+	TemplateGSI Template = "gsi"
+
+	// TemplateBackfill generates a migration transforming the items of a table with helpers.Backfill.
+	TemplateBackfill Template = "backfill"
+)
+
+// Templates are all templates, in the order they are listed by the command line.
+var Templates = []Template{TemplateEmpty, TemplateTable, TemplateGSI, TemplateBackfill}
+
+var (
+	// ErrInvalidName is returned when the name of the migration has no letter or digit to name its file with.
+	ErrInvalidName = errors.New("invalid migration name")
+
+	// ErrUnknownTemplate is returned when the template set by WithTemplate is not one of Templates.
+	ErrUnknownTemplate = errors.New("unknown template")
+)
+
+// File is the file of a new migration.
+type File struct {
+	// ID is the ID of the migration.
+	ID string
+	// Name is the name of the file, the ID followed by the name of the migration, as 20240131235959_add_orders_table.go.
+	Name string
+	// Content is the Go code of the file, formatted.
+	Content []byte
+}
+
+type opts struct {
+	template    Template
+	idGenerator migrations_dynamodb.IDGenerator
+	packageName string
+	clientVar   string
+	sourceVar   string
+	existingIDs []string
+}
+
+type Option func(*opts)
+
+// WithTemplate sets the kind of migration generated. By default, it is TemplateEmpty.
+func WithTemplate(template Template) Option {
+	return func(o *opts) {
+		o.template = template
+	}
+}
+
+// WithIDGenerator sets how the ID of the migration is generated. By default, it is the current time, with
+// migrations_dynamodb.TimestampIDs. It must be the format of the IDs of the existing migrations, so they sort together.
+func WithIDGenerator(generator migrations_dynamodb.IDGenerator) Option {
+	return func(o *opts) {
+		o.idGenerator = generator
+	}
+}
+
+// WithPackageName sets the package of the file generated. By default, it is the name of the directory of the
+// migrations, for Create, and "migrations" for Generate.
+func WithPackageName(name string) Option {
+	return func(o *opts) {
+		o.packageName = name
+	}
+}
+
+// WithVariables sets the names of the variables of the package the code generated refers to: the client the helpers
+// are called with and the source the migration is added to. By default, they are Client and Source.
+func WithVariables(client, source string) Option {
+	return func(o *opts) {
+		o.clientVar = client
+		o.sourceVar = source
+	}
+}
+
+// WithExistingIDs adds IDs of existing migrations the ID generated must sort after, as the ones in the history of the
+// Target (see migrations_dynamodb.Target.History) for the migrations whose files are not around.
+func WithExistingIDs(ids ...string) Option {
+	return func(o *opts) {
+		o.existingIDs = append(o.existingIDs, ids...)
+	}
+}
+
+func newOpts(options []Option) opts {
+	o := opts{
+		template:    TemplateEmpty,
+		idGenerator: migrations_dynamodb.TimestampIDs(migrations_dynamodb.SystemClock),
+		packageName: "migrations",
+		clientVar:   "Client",
+		sourceVar:   "Source",
+	}
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+// Generate generates the file of a new migration with the name given, as "add orders table". Its ID sorts after the
+// ones set by WithExistingIDs.
+func Generate(name string, options ...Option) (File, error) {
+	return generate(name, newOpts(options))
+}
+
+// Create generates the file of a new migration with the name given, as Generate, and writes it to the directory of the
+// migrations, which is created if it does not exist. Its ID sorts after the ones of the migration files already in the
+// directory, and the ones set by WithExistingIDs. It returns the path of the file written.
+func Create(dir, name string, options ...Option) (string, error) {
+	o := newOpts(append([]Option{WithPackageName(packageName(dir))}, options...))
+
+	ids, err := existingIDs(dir)
+	if err != nil {
+		return "", err
+	}
+	o.existingIDs = append(o.existingIDs, ids...)
+
+	file, err := generate(name, o)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create the migrations directory: %w", err)
+	}
+	path := filepath.Join(dir, file.Name)
+	// the file is never overwritten, even if the ID generator repeated an ID.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the migration file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(file.Content); err != nil {
+		return "", fmt.Errorf("failed to write the migration file: %w", err)
+	}
+	return path, f.Close()
+}
+
+func generate(name string, o opts) (File, error) {
+	tmpl, ok := templates[o.template]
+	if !ok {
+		return File{}, fmt.Errorf("%w: %q", ErrUnknownTemplate, o.template)
+	}
+	fileName := snakeCase(name)
+	if fileName == "" {
+		return File{}, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	id, err := o.idGenerator.NextID(o.existingIDs)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to generate the migration ID: %w", err)
+	}
+	if strings.Contains(id, "_") {
+		return File{}, fmt.Errorf("the migration ID %q cannot contain an underscore, it separates the ID from the name of the file", id)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, templateData{
+		Package: o.packageName,
+		Client:  o.clientVar,
+		Source:  o.sourceVar,
+	})
+	if err != nil {
+		return File{}, fmt.Errorf("failed to render the migration: %w", err)
+	}
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return File{}, fmt.Errorf("failed to format the migration: %w", err)
+	}
+	return File{
+		ID:      id,
+		Name:    id + "_" + fileName + ".go",
+		Content: content,
+	}, nil
+}
+
+// existingIDs returns the IDs of the migration files in the directory, named as fnc expects. A directory that does not
+// exist has none.
+func existingIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the migrations directory: %w", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if id, _, ok := strings.Cut(name, "_"); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// snakeCase returns the name given in lower snake case, keeping only its letters and digits.
+func snakeCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.ToLower(strings.Join(words, "_"))
+}
+
+// packageName returns the name of the package of the directory, from its base name.
+func packageName(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "migrations"
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(abs))
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		return "migrations"
+	}
+	return name
+}
+
+type templateData struct {
+	Package string
+	Client  string
+	Source  string
+}
+
+var templates = map[Template]*template.Template{
+	TemplateEmpty:    template.Must(template.New(string(TemplateEmpty)).Parse(emptyTemplate)),
+	TemplateTable:    template.Must(template.New(string(TemplateTable)).Parse(tableTemplate)),
+	TemplateGSI:      template.Must(template.New(string(TemplateGSI)).Parse(gsiTemplate)),
+	TemplateBackfill: template.Must(template.New(string(TemplateBackfill)).Parse(backfillTemplate)),
+}
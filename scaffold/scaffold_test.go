@@ -0,0 +1,74 @@
+package scaffold
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+var _ = Describe("Scaffold", func() {
+	DescribeTable("Generate",
+		func(template Template, helper string) {
+			file, err := Generate("Add orders table!", WithTemplate(template), WithIDGenerator(migrations_dynamodb.SequenceIDs(4)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(file.ID).To(Equal("0001"))
+			Expect(file.Name).To(Equal("0001_add_orders_table.go"))
+
+			_, err = parser.ParseFile(token.NewFileSet(), file.Name, file.Content, parser.AllErrors)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(file.Content)).To(ContainSubstring("package migrations\n"))
+			Expect(string(file.Content)).To(ContainSubstring("fnc.WithSource(Source)"))
+			Expect(string(file.Content)).To(ContainSubstring(helper))
+		},
+		Entry("empty", TemplateEmpty, "fnc.Migration2("),
+		Entry("table", TemplateTable, "helpers.EnsureTable(ctx, Client,"),
+		Entry("gsi", TemplateGSI, "helpers.EnsureGSI(ctx, Client,"),
+		Entry("backfill", TemplateBackfill, "helpers.Backfill(ctx, Client,"),
+	)
+
+	It("should refuse the names without letters nor digits and the unknown templates", func() {
+		_, err := Generate(" - ")
+		Expect(err).To(MatchError(ErrInvalidName))
+		_, err = Generate("add index", WithTemplate("lsi"))
+		Expect(err).To(MatchError(ErrUnknownTemplate))
+	})
+
+	Describe("Create", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir = filepath.Join(GinkgoT().TempDir(), "dynamodb_migrations")
+		})
+
+		It("should write the migration after the ones of the directory and of the history", func() {
+			path, err := Create(dir, "create orders", WithIDGenerator(migrations_dynamodb.SequenceIDs(4)), WithExistingIDs("0002"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(dir, "0003_create_orders.go")))
+			Expect(os.WriteFile(filepath.Join(dir, "0005_fix_orders.go"), nil, 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "0009_test.go"), nil, 0o644)).To(Succeed())
+
+			path, err = Create(dir, "add orders index", WithTemplate(TemplateGSI), WithIDGenerator(migrations_dynamodb.SequenceIDs(4)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(dir, "0006_add_orders_index.go")))
+			content, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(HavePrefix("package dynamodb_migrations\n"))
+		})
+
+		It("should not overwrite an existing file", func() {
+			fixed := migrations_dynamodb.IDGeneratorFunc(func([]string) (string, error) {
+				return "20240101000000", nil
+			})
+			_, err := Create(dir, "create orders", WithIDGenerator(fixed))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = Create(dir, "create orders", WithIDGenerator(fixed))
+			Expect(err).To(MatchError(os.ErrExist))
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package scaffold
+
+const emptyTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/jamillosantos/migrations/v2/fnc"
+)
+
+var _ = fnc.Migration2(func(ctx context.Context) error {
+	// TODO: apply the migration.
+	return nil
+}, func(ctx context.Context) error {
+	// TODO: undo the migration.
+	return nil
+}, fnc.WithSource({{.Source}}))
+`
+
+const tableTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2/fnc"
+
+	"github.com/jamillosantos/migrations-dynamodb/helpers"
+)
+
+var _ = fnc.Migration2(func(ctx context.Context) error {
+	// TODO: set the name, the key and the billing mode of the table.
+	return helpers.EnsureTable(ctx, {{.Client}}, &dynamodb.CreateTableInput{
+		TableName: aws.String("TODO"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+}, func(ctx context.Context) error {
+	_, err := {{.Client}}.DeleteTable(ctx, &dynamodb.DeleteTableInput{
+		TableName: aws.String("TODO"),
+	})
+	return err
+}, fnc.WithSource({{.Source}}))
+`
+
+const gsiTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2/fnc"
+
+	"github.com/jamillosantos/migrations-dynamodb/helpers"
+)
+
+var _ = fnc.Migration2(func(ctx context.Context) error {
+	// TODO: set the table, the name and the key of the index. Backfilling the index of a big table can take hours, set
+	// helpers.WithTimeout accordingly.
+	return helpers.EnsureGSI(ctx, {{.Client}}, "TODO", []types.AttributeDefinition{
+		{AttributeName: aws.String("TODO"), AttributeType: types.ScalarAttributeTypeS},
+	}, types.CreateGlobalSecondaryIndexAction{
+		IndexName: aws.String("TODO-index"),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("TODO"), KeyType: types.KeyTypeHash},
+		},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	})
+}, func(ctx context.Context) error {
+	return helpers.DropGSI(ctx, {{.Client}}, "TODO", "TODO-index", helpers.WithConfirmedRemoval())
+}, fnc.WithSource({{.Source}}))
+`
+
+const backfillTemplate = `package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2/fnc"
+
+	"github.com/jamillosantos/migrations-dynamodb/helpers"
+)
+
+var _ = fnc.Migration(func(ctx context.Context) error {
+	// TODO: set the table backfilled, and its write capacity with helpers.WithWriteCapacity.
+	_, err := helpers.Backfill(ctx, {{.Client}}, "TODO", func(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+		// TODO: return the new version of the item, with the same key, or nil to leave it unchanged.
+		return nil, nil
+	})
+	return err
+}, fnc.WithSource({{.Source}}))
+`
@@ -0,0 +1,112 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/jamillosantos/migrations-dynamodb/internal/throttle"
+)
+
+// scanMigrations reads all items of the migrations table. If more than one scan segment is configured, the segments
+// are scanned in parallel, sharing the read capacity set by WithScanLimit.
+func (t *Target) scanMigrations(ctx context.Context) ([]map[string]types.AttributeValue, error) {
+	limiter := throttle.NewRateLimiter(t.clock, t.scanLimit)
+	if t.scanSegments <= 1 {
+		return t.scanSegment(ctx, nil, limiter)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		segments = make([][]map[string]types.AttributeValue, t.scanSegments)
+		errs     = make([]error, t.scanSegments)
+	)
+	for i := range segments {
+		wg.Add(1)
+		go func(segment int32) {
+			defer wg.Done()
+			segments[segment], errs[segment] = t.scanSegment(ctx, aws.Int32(segment), limiter)
+		}(int32(i))
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var items []map[string]types.AttributeValue
+	for _, segment := range segments {
+		items = append(items, segment...)
+	}
+	return items, nil
+}
+
+// scanSegment reads all pages of a segment of the migrations table. If segment is nil, the whole table is scanned.
+// Throttled requests slow down the segment and are retried, and every page waits for the limiter.
+func (t *Target) scanSegment(ctx context.Context, segment *int32, limiter *throttle.RateLimiter) ([]map[string]types.AttributeValue, error) {
+	var (
+		items    []map[string]types.AttributeValue
+		startKey map[string]types.AttributeValue
+		backoff  = throttle.Backoff{Clock: t.clock}
+	)
+	for {
+		if err := backoff.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("failed to scan migrations table: %w", err)
+		}
+		if err := limiter.Wait(ctx, 0); err != nil {
+			return nil, fmt.Errorf("failed to scan migrations table: %w", err)
+		}
+
+		input := &dynamodb.ScanInput{
+			TableName:              &t.tableName,
+			ExclusiveStartKey:      startKey,
+			ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+		}
+		if t.scanPageSize > 0 {
+			input.Limit = aws.Int32(t.scanPageSize)
+		}
+		if t.consistentReads() {
+			input.ConsistentRead = aws.Bool(true)
+		}
+		if segment != nil {
+			input.Segment = segment
+			input.TotalSegments = aws.Int32(int32(t.scanSegments))
+		}
+		scanResponse, err := t.client.Scan(ctx, input)
+		if throttle.IsThrottlingError(err) && backoff.Throttled() {
+			t.stats.retries.Add(1)
+			t.logger.Log(ctx, t.waitLogLevel, "scan throttled, slowing down", "segment", aws.ToInt32(segment), "delay", backoff.Delay())
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan migrations table: %w", err)
+		}
+		backoff.Succeeded()
+		t.capacity.record(operationDone, scanResponse.ConsumedCapacity)
+		if scanResponse.ConsumedCapacity != nil {
+			limiter.Consumed(0, aws.ToFloat64(scanResponse.ConsumedCapacity.CapacityUnits))
+		}
+
+		for _, item := range scanResponse.Items {
+			items = append(items, t.normalizeItem(item))
+		}
+		if len(scanResponse.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		startKey = scanResponse.LastEvaluatedKey
+	}
+}
+
+// consistentReads checks if the migrations table must be read with strongly consistent reads: while the lock is held,
+// unless overridden by WithConsistentRead.
+func (t *Target) consistentReads() bool {
+	if t.consistentRead != nil {
+		return *t.consistentRead
+	}
+	return t.locksHeld.Load() > 0
+}
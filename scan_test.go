@@ -0,0 +1,163 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scan segments", func() {
+	var (
+		ctx context.Context
+
+		expectedIDs []string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target := NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+
+		expectedIDs = make([]string, 0, 20)
+		for i := 0; i < 20; i++ {
+			id := fmt.Sprintf("%02d", i)
+			Expect(target.Add(ctx, id)).To(Succeed())
+			Expect(target.FinishMigration(ctx, id)).To(Succeed())
+			expectedIDs = append(expectedIDs, id)
+		}
+	})
+
+	When("scanning in parallel", func() {
+		It("should list all migrations", func() {
+			target := NewTarget(dynamoDBClient, WithScanSegments(4))
+
+			ms, err := target.Done(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(Equal(expectedIDs))
+		})
+	})
+
+	When("the scan is throttled", func() {
+		It("should slow down and retry the throttled requests", func() {
+			client := &throttlingScanClient{Client: dynamoDBClient}
+			client.throttles.Store(5)
+			target := NewTarget(client, WithScanSegments(4))
+
+			ms, err := target.Done(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(Equal(expectedIDs))
+			Expect(client.throttles.Load()).To(BeNumerically("<=", 0))
+		})
+
+		It("should give up when the requests keep being throttled", func() {
+			client := &throttlingScanClient{Client: dynamoDBClient}
+			client.throttles.Store(1000)
+			target := NewTarget(client, WithScanSegments(2))
+
+			_, err := target.Done(ctx)
+			var throughputErr *types.ProvisionedThroughputExceededException
+			Expect(errors.As(err, &throughputErr)).To(BeTrue())
+		})
+	})
+
+	When("the scan is paged and rate limited", func() {
+		It("should read the pages with the page size", func() {
+			client := &consistencyScanClient{Client: dynamoDBClient}
+			target := NewTarget(client, WithScanPageSize(6))
+
+			Expect(target.Done(ctx)).To(Equal(expectedIDs))
+			Expect(client.consistent).To(HaveLen(4))
+		})
+
+		It("should wait for the read capacity between the pages", func() {
+			clock := newFakeClock()
+			client := &consistencyScanClient{Client: dynamoDBClient}
+			target := NewTarget(client, WithScanPageSize(5), WithScanLimit(0.5), WithClock(clock))
+
+			done := make(chan []string, 1)
+			go func() {
+				defer GinkgoRecover()
+				ms, err := target.Done(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				done <- ms
+			}()
+
+			Eventually(clock.Waiters).Should(Equal(1))
+			Consistently(done, 50*time.Millisecond).ShouldNot(Receive())
+			Eventually(func() <-chan []string {
+				if clock.Waiters() > 0 {
+					clock.Advance(time.Second)
+				}
+				return done
+			}).Should(Receive(Equal(expectedIDs)))
+		})
+	})
+
+	When("the lock is held", func() {
+		It("should read the migrations with strongly consistent reads until it is released", func() {
+			client := &consistencyScanClient{Client: dynamoDBClient}
+			target := NewTarget(client)
+
+			Expect(target.Done(ctx)).To(Equal(expectedIDs))
+			Expect(client.consistent).To(Equal([]bool{false}))
+
+			unlocker, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Current(ctx)).To(Equal("19"))
+			Expect(unlocker.Unlock(ctx)).To(Succeed())
+			Expect(target.Done(ctx)).To(Equal(expectedIDs))
+			Expect(client.consistent).To(Equal([]bool{false, true, false}))
+		})
+
+		It("should follow the override", func() {
+			client := &consistencyScanClient{Client: dynamoDBClient}
+			target := NewTarget(client, WithConsistentRead(false))
+
+			unlocker, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Done(ctx)).To(Equal(expectedIDs))
+			Expect(unlocker.Unlock(ctx)).To(Succeed())
+			Expect(NewTarget(client, WithConsistentRead(true)).Done(ctx)).To(Equal(expectedIDs))
+			Expect(client.consistent).To(Equal([]bool{false, true}))
+		})
+	})
+})
+
+// consistencyScanClient records whether the scans of the migrations table were strongly consistent.
+type consistencyScanClient struct {
+	*dynamodb.Client
+
+	consistent []bool
+}
+
+func (c *consistencyScanClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if aws.ToString(input.TableName) == "_migrations" {
+		c.consistent = append(c.consistent, aws.ToBool(input.ConsistentRead))
+	}
+	return c.Client.Scan(ctx, input, optFns...)
+}
+
+// throttlingScanClient fails the scans with a ProvisionedThroughputExceededException while there are throttles left.
+type throttlingScanClient struct {
+	*dynamodb.Client
+
+	throttles atomic.Int64
+}
+
+func (c *throttlingScanClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if c.throttles.Add(-1) >= 0 {
+		return nil, &types.ProvisionedThroughputExceededException{Message: aws.String("throttled")}
+	}
+	return c.Client.Scan(ctx, input, optFns...)
+}
@@ -0,0 +1,349 @@
+// Package sdkv1 adapts a DynamoDB client of the aws-sdk-go (v1) to the migrations_dynamodb.DynamoDBClient, so the
+// services still on the v1 of the SDK can use the Target without migrating to the v2 first.
+//
+// Only the parameters used by the Target are converted between the versions of the SDK. The errors of the v1 are
+// converted to the errors of the v2 the Target relies on (e.g. *types.ConditionalCheckFailedException), and the other
+// API errors to a smithy.APIError with the same code.
+package sdkv1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	awsv1 "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	dynamodbv1 "github.com/aws/aws-sdk-go/service/dynamodb"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+// API is the part of the v1 *dynamodb.DynamoDB used by the Client.
+type API interface {
+	ScanWithContext(ctx awsv1.Context, input *dynamodbv1.ScanInput, opts ...request.Option) (*dynamodbv1.ScanOutput, error)
+	PutItemWithContext(ctx awsv1.Context, input *dynamodbv1.PutItemInput, opts ...request.Option) (*dynamodbv1.PutItemOutput, error)
+	DeleteItemWithContext(ctx awsv1.Context, input *dynamodbv1.DeleteItemInput, opts ...request.Option) (*dynamodbv1.DeleteItemOutput, error)
+	UpdateItemWithContext(ctx awsv1.Context, input *dynamodbv1.UpdateItemInput, opts ...request.Option) (*dynamodbv1.UpdateItemOutput, error)
+	TransactWriteItemsWithContext(ctx awsv1.Context, input *dynamodbv1.TransactWriteItemsInput, opts ...request.Option) (*dynamodbv1.TransactWriteItemsOutput, error)
+	CreateTableWithContext(ctx awsv1.Context, input *dynamodbv1.CreateTableInput, opts ...request.Option) (*dynamodbv1.CreateTableOutput, error)
+	DescribeTableWithContext(ctx awsv1.Context, input *dynamodbv1.DescribeTableInput, opts ...request.Option) (*dynamodbv1.DescribeTableOutput, error)
+	DeleteTableWithContext(ctx awsv1.Context, input *dynamodbv1.DeleteTableInput, opts ...request.Option) (*dynamodbv1.DeleteTableOutput, error)
+	ListTablesWithContext(ctx awsv1.Context, input *dynamodbv1.ListTablesInput, opts ...request.Option) (*dynamodbv1.ListTablesOutput, error)
+}
+
+// The optional parts of the v1 *dynamodb.DynamoDB, forwarded by the Client when the API implements them. They are not
+// part of the API, so the existing implementations of it keep working.
+type (
+	queryAPI interface {
+		QueryWithContext(ctx awsv1.Context, input *dynamodbv1.QueryInput, opts ...request.Option) (*dynamodbv1.QueryOutput, error)
+	}
+	getItemAPI interface {
+		GetItemWithContext(ctx awsv1.Context, input *dynamodbv1.GetItemInput, opts ...request.Option) (*dynamodbv1.GetItemOutput, error)
+	}
+	timeToLiveAPI interface {
+		DescribeTimeToLiveWithContext(ctx awsv1.Context, input *dynamodbv1.DescribeTimeToLiveInput, opts ...request.Option) (*dynamodbv1.DescribeTimeToLiveOutput, error)
+	}
+	backupAPI interface {
+		DeleteBackupWithContext(ctx awsv1.Context, input *dynamodbv1.DeleteBackupInput, opts ...request.Option) (*dynamodbv1.DeleteBackupOutput, error)
+	}
+)
+
+// Client is a migrations_dynamodb.DynamoDBClient calling a v1 client. The options of the v2 given to its methods
+// are ignored, so it cannot be used with a global lock table (see migrations_dynamodb.WithHomeRegion).
+type Client struct {
+	api API
+}
+
+var (
+	_ migrations_dynamodb.DynamoDBClient           = (*Client)(nil)
+	_ migrations_dynamodb.QueryDynamoDBClient      = (*Client)(nil)
+	_ migrations_dynamodb.GetItemDynamoDBClient    = (*Client)(nil)
+	_ migrations_dynamodb.TimeToLiveDynamoDBClient = (*Client)(nil)
+	_ migrations_dynamodb.BackupDynamoDBClient     = (*Client)(nil)
+
+	_ migrations_dynamodb.OptionsIgnoringDynamoDBClient = (*Client)(nil)
+)
+
+// New creates a Client calling the v1 client, usually a *dynamodb.DynamoDB.
+func New(api API) *Client {
+	return &Client{api: api}
+}
+
+func (c *Client) Scan(ctx context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	output, err := c.api.ScanWithContext(ctx, &dynamodbv1.ScanInput{
+		TableName:                 input.TableName,
+		IndexName:                 input.IndexName,
+		ExclusiveStartKey:         toItem(input.ExclusiveStartKey),
+		FilterExpression:          input.FilterExpression,
+		ProjectionExpression:      input.ProjectionExpression,
+		ExpressionAttributeNames:  toNames(input.ExpressionAttributeNames),
+		ExpressionAttributeValues: toItem(input.ExpressionAttributeValues),
+		ConsistentRead:            input.ConsistentRead,
+		Limit:                     toInt64(input.Limit),
+		Segment:                   toInt64(input.Segment),
+		TotalSegments:             toInt64(input.TotalSegments),
+		Select:                    toEnum(input.Select),
+		ReturnConsumedCapacity:    toEnum(input.ReturnConsumedCapacity),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	items := make([]map[string]types.AttributeValue, 0, len(output.Items))
+	for _, item := range output.Items {
+		items = append(items, fromItem(item))
+	}
+	return &dynamodb.ScanOutput{
+		Items:            items,
+		LastEvaluatedKey: fromItem(output.LastEvaluatedKey),
+		Count:            int32(aws.ToInt64(output.Count)),
+		ScannedCount:     int32(aws.ToInt64(output.ScannedCount)),
+		ConsumedCapacity: fromConsumedCapacity(output.ConsumedCapacity),
+	}, nil
+}
+
+func (c *Client) PutItem(ctx context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	output, err := c.api.PutItemWithContext(ctx, &dynamodbv1.PutItemInput{
+		TableName:                           input.TableName,
+		Item:                                toItem(input.Item),
+		ConditionExpression:                 input.ConditionExpression,
+		ExpressionAttributeNames:            toNames(input.ExpressionAttributeNames),
+		ExpressionAttributeValues:           toItem(input.ExpressionAttributeValues),
+		ReturnValues:                        toEnum(input.ReturnValues),
+		ReturnValuesOnConditionCheckFailure: toEnum(input.ReturnValuesOnConditionCheckFailure),
+		ReturnConsumedCapacity:              toEnum(input.ReturnConsumedCapacity),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.PutItemOutput{
+		Attributes:       fromItem(output.Attributes),
+		ConsumedCapacity: fromConsumedCapacity(output.ConsumedCapacity),
+	}, nil
+}
+
+func (c *Client) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	output, err := c.api.DeleteItemWithContext(ctx, &dynamodbv1.DeleteItemInput{
+		TableName:                           input.TableName,
+		Key:                                 toItem(input.Key),
+		ConditionExpression:                 input.ConditionExpression,
+		ExpressionAttributeNames:            toNames(input.ExpressionAttributeNames),
+		ExpressionAttributeValues:           toItem(input.ExpressionAttributeValues),
+		ReturnValues:                        toEnum(input.ReturnValues),
+		ReturnValuesOnConditionCheckFailure: toEnum(input.ReturnValuesOnConditionCheckFailure),
+		ReturnConsumedCapacity:              toEnum(input.ReturnConsumedCapacity),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.DeleteItemOutput{
+		Attributes:       fromItem(output.Attributes),
+		ConsumedCapacity: fromConsumedCapacity(output.ConsumedCapacity),
+	}, nil
+}
+
+func (c *Client) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	output, err := c.api.UpdateItemWithContext(ctx, &dynamodbv1.UpdateItemInput{
+		TableName:                           input.TableName,
+		Key:                                 toItem(input.Key),
+		UpdateExpression:                    input.UpdateExpression,
+		ConditionExpression:                 input.ConditionExpression,
+		ExpressionAttributeNames:            toNames(input.ExpressionAttributeNames),
+		ExpressionAttributeValues:           toItem(input.ExpressionAttributeValues),
+		ReturnValues:                        toEnum(input.ReturnValues),
+		ReturnValuesOnConditionCheckFailure: toEnum(input.ReturnValuesOnConditionCheckFailure),
+		ReturnConsumedCapacity:              toEnum(input.ReturnConsumedCapacity),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.UpdateItemOutput{
+		Attributes:       fromItem(output.Attributes),
+		ConsumedCapacity: fromConsumedCapacity(output.ConsumedCapacity),
+	}, nil
+}
+
+func (c *Client) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	items := make([]*dynamodbv1.TransactWriteItem, 0, len(input.TransactItems))
+	for _, item := range input.TransactItems {
+		items = append(items, toTransactWriteItem(item))
+	}
+	output, err := c.api.TransactWriteItemsWithContext(ctx, &dynamodbv1.TransactWriteItemsInput{
+		TransactItems:          items,
+		ClientRequestToken:     input.ClientRequestToken,
+		ReturnConsumedCapacity: toEnum(input.ReturnConsumedCapacity),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	consumed := make([]types.ConsumedCapacity, 0, len(output.ConsumedCapacity))
+	for _, capacity := range output.ConsumedCapacity {
+		consumed = append(consumed, *fromConsumedCapacity(capacity))
+	}
+	return &dynamodb.TransactWriteItemsOutput{ConsumedCapacity: consumed}, nil
+}
+
+func (c *Client) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	v1Input := &dynamodbv1.CreateTableInput{
+		TableName:   input.TableName,
+		BillingMode: toEnum(input.BillingMode),
+	}
+	for _, definition := range input.AttributeDefinitions {
+		v1Input.AttributeDefinitions = append(v1Input.AttributeDefinitions, &dynamodbv1.AttributeDefinition{
+			AttributeName: definition.AttributeName,
+			AttributeType: toEnum(definition.AttributeType),
+		})
+	}
+	for _, element := range input.KeySchema {
+		v1Input.KeySchema = append(v1Input.KeySchema, &dynamodbv1.KeySchemaElement{
+			AttributeName: element.AttributeName,
+			KeyType:       toEnum(element.KeyType),
+		})
+	}
+	if throughput := input.ProvisionedThroughput; throughput != nil {
+		v1Input.ProvisionedThroughput = &dynamodbv1.ProvisionedThroughput{
+			ReadCapacityUnits:  throughput.ReadCapacityUnits,
+			WriteCapacityUnits: throughput.WriteCapacityUnits,
+		}
+	}
+	for _, tag := range input.Tags {
+		v1Input.Tags = append(v1Input.Tags, &dynamodbv1.Tag{Key: tag.Key, Value: tag.Value})
+	}
+	output, err := c.api.CreateTableWithContext(ctx, v1Input)
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.CreateTableOutput{TableDescription: fromTableDescription(output.TableDescription)}, nil
+}
+
+func (c *Client) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	output, err := c.api.DescribeTableWithContext(ctx, &dynamodbv1.DescribeTableInput{TableName: input.TableName})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.DescribeTableOutput{Table: fromTableDescription(output.Table)}, nil
+}
+
+func (c *Client) DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	output, err := c.api.DeleteTableWithContext(ctx, &dynamodbv1.DeleteTableInput{TableName: input.TableName})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.DeleteTableOutput{TableDescription: fromTableDescription(output.TableDescription)}, nil
+}
+
+func (c *Client) ListTables(ctx context.Context, input *dynamodb.ListTablesInput, _ ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	output, err := c.api.ListTablesWithContext(ctx, &dynamodbv1.ListTablesInput{
+		ExclusiveStartTableName: input.ExclusiveStartTableName,
+		Limit:                   toInt64(input.Limit),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.ListTablesOutput{
+		TableNames:             awsv1.StringValueSlice(output.TableNames),
+		LastEvaluatedTableName: output.LastEvaluatedTableName,
+	}, nil
+}
+
+func (c *Client) Query(ctx context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	api, ok := c.api.(queryAPI)
+	if !ok {
+		return nil, unsupported("Query")
+	}
+	output, err := api.QueryWithContext(ctx, &dynamodbv1.QueryInput{
+		TableName:                 input.TableName,
+		IndexName:                 input.IndexName,
+		KeyConditionExpression:    input.KeyConditionExpression,
+		ExclusiveStartKey:         toItem(input.ExclusiveStartKey),
+		FilterExpression:          input.FilterExpression,
+		ProjectionExpression:      input.ProjectionExpression,
+		ExpressionAttributeNames:  toNames(input.ExpressionAttributeNames),
+		ExpressionAttributeValues: toItem(input.ExpressionAttributeValues),
+		ConsistentRead:            input.ConsistentRead,
+		ScanIndexForward:          input.ScanIndexForward,
+		Limit:                     toInt64(input.Limit),
+		Select:                    toEnum(input.Select),
+		ReturnConsumedCapacity:    toEnum(input.ReturnConsumedCapacity),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	items := make([]map[string]types.AttributeValue, 0, len(output.Items))
+	for _, item := range output.Items {
+		items = append(items, fromItem(item))
+	}
+	return &dynamodb.QueryOutput{
+		Items:            items,
+		LastEvaluatedKey: fromItem(output.LastEvaluatedKey),
+		Count:            int32(aws.ToInt64(output.Count)),
+		ScannedCount:     int32(aws.ToInt64(output.ScannedCount)),
+		ConsumedCapacity: fromConsumedCapacity(output.ConsumedCapacity),
+	}, nil
+}
+
+func (c *Client) GetItem(ctx context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	api, ok := c.api.(getItemAPI)
+	if !ok {
+		return nil, unsupported("GetItem")
+	}
+	output, err := api.GetItemWithContext(ctx, &dynamodbv1.GetItemInput{
+		TableName:                input.TableName,
+		Key:                      toItem(input.Key),
+		ProjectionExpression:     input.ProjectionExpression,
+		ExpressionAttributeNames: toNames(input.ExpressionAttributeNames),
+		ConsistentRead:           input.ConsistentRead,
+		ReturnConsumedCapacity:   toEnum(input.ReturnConsumedCapacity),
+	})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.GetItemOutput{
+		Item:             fromItem(output.Item),
+		ConsumedCapacity: fromConsumedCapacity(output.ConsumedCapacity),
+	}, nil
+}
+
+func (c *Client) DescribeTimeToLive(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	api, ok := c.api.(timeToLiveAPI)
+	if !ok {
+		return nil, unsupported("DescribeTimeToLive")
+	}
+	output, err := api.DescribeTimeToLiveWithContext(ctx, &dynamodbv1.DescribeTimeToLiveInput{TableName: input.TableName})
+	if err != nil {
+		return nil, fromError(err)
+	}
+	r := &dynamodb.DescribeTimeToLiveOutput{}
+	if description := output.TimeToLiveDescription; description != nil {
+		r.TimeToLiveDescription = &types.TimeToLiveDescription{
+			AttributeName:    description.AttributeName,
+			TimeToLiveStatus: types.TimeToLiveStatus(aws.ToString(description.TimeToLiveStatus)),
+		}
+	}
+	return r, nil
+}
+
+func (c *Client) DeleteBackup(ctx context.Context, input *dynamodb.DeleteBackupInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteBackupOutput, error) {
+	api, ok := c.api.(backupAPI)
+	if !ok {
+		return nil, unsupported("DeleteBackup")
+	}
+	if _, err := api.DeleteBackupWithContext(ctx, &dynamodbv1.DeleteBackupInput{BackupArn: input.BackupArn}); err != nil {
+		return nil, fromError(err)
+	}
+	return &dynamodb.DeleteBackupOutput{}, nil
+}
+
+// IgnoresCallOptions returns true: the options of the v2 cannot be applied to the v1 client, so the Target refuses to
+// pin the calls to a global lock table to its home region with it.
+func (c *Client) IgnoresCallOptions() bool {
+	return true
+}
+
+// unsupported is the error of the optional methods the API does not implement. The Target handles it as a client
+// without the method.
+func unsupported(method string) error {
+	return fmt.Errorf("the client does not support %s: %w", method, errors.ErrUnsupported)
+}
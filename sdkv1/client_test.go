@@ -0,0 +1,223 @@
+package sdkv1
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	awsv1 "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	dynamodbv1 "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/smithy-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeAPI struct {
+	dynamodbiface.DynamoDBAPI
+
+	putInput  *dynamodbv1.PutItemInput
+	putOutput *dynamodbv1.PutItemOutput
+	scanInput *dynamodbv1.ScanInput
+	table     *dynamodbv1.TableDescription
+	err       error
+}
+
+func (f *fakeAPI) PutItemWithContext(_ awsv1.Context, input *dynamodbv1.PutItemInput, _ ...request.Option) (*dynamodbv1.PutItemOutput, error) {
+	f.putInput = input
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.putOutput, nil
+}
+
+func (f *fakeAPI) ScanWithContext(_ awsv1.Context, input *dynamodbv1.ScanInput, _ ...request.Option) (*dynamodbv1.ScanOutput, error) {
+	f.scanInput = input
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodbv1.ScanOutput{
+		Items: []map[string]*dynamodbv1.AttributeValue{
+			{"id": {S: awsv1.String("0001")}},
+		},
+		Count:            awsv1.Int64(1),
+		ScannedCount:     awsv1.Int64(1),
+		LastEvaluatedKey: map[string]*dynamodbv1.AttributeValue{"id": {S: awsv1.String("0001")}},
+	}, nil
+}
+
+func (f *fakeAPI) GetItemWithContext(_ awsv1.Context, input *dynamodbv1.GetItemInput, _ ...request.Option) (*dynamodbv1.GetItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodbv1.GetItemOutput{Item: input.Key}, nil
+}
+
+func (f *fakeAPI) TransactWriteItemsWithContext(_ awsv1.Context, _ *dynamodbv1.TransactWriteItemsInput, _ ...request.Option) (*dynamodbv1.TransactWriteItemsOutput, error) {
+	return nil, f.err
+}
+
+func (f *fakeAPI) DescribeTableWithContext(_ awsv1.Context, _ *dynamodbv1.DescribeTableInput, _ ...request.Option) (*dynamodbv1.DescribeTableOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodbv1.DescribeTableOutput{Table: f.table}, nil
+}
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    context.Context
+		api    *fakeAPI
+		client *Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		api = &fakeAPI{putOutput: &dynamodbv1.PutItemOutput{}}
+		client = New(api)
+	})
+
+	It("should convert the attribute values both ways", func() {
+		item := map[string]types.AttributeValue{
+			"id":    &types.AttributeValueMemberS{Value: "0001"},
+			"order": &types.AttributeValueMemberN{Value: "1"},
+			"dirty": &types.AttributeValueMemberBOOL{Value: true},
+			"data":  &types.AttributeValueMemberB{Value: []byte("data")},
+			"none":  &types.AttributeValueMemberNULL{Value: true},
+			"tags":  &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+			"steps": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+				&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+					"name": &types.AttributeValueMemberS{Value: "backfill"},
+				}},
+			}},
+		}
+		api.putOutput.Attributes = toItem(item)
+
+		output, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:    aws.String("_migrations"),
+			Item:         item,
+			ReturnValues: types.ReturnValueAllOld,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Attributes).To(Equal(item))
+
+		Expect(api.putInput.TableName).To(Equal(aws.String("_migrations")))
+		Expect(api.putInput.ReturnValues).To(Equal(aws.String("ALL_OLD")))
+		Expect(api.putInput.ReturnConsumedCapacity).To(BeNil())
+		Expect(api.putInput.ExpressionAttributeNames).To(BeNil())
+		Expect(api.putInput.Item["steps"].L[0].M["name"].S).To(Equal(aws.String("backfill")))
+	})
+
+	It("should convert the scan", func() {
+		output, err := client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:                aws.String("_migrations"),
+			Limit:                    aws.Int32(10),
+			ExpressionAttributeNames: map[string]string{"#id": "id"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(api.scanInput.Limit).To(Equal(awsv1.Int64(10)))
+		Expect(api.scanInput.ExpressionAttributeNames).To(Equal(map[string]*string{"#id": awsv1.String("id")}))
+		Expect(output.Count).To(Equal(int32(1)))
+		Expect(output.Items).To(Equal([]map[string]types.AttributeValue{
+			{"id": &types.AttributeValueMemberS{Value: "0001"}},
+		}))
+		Expect(output.LastEvaluatedKey).To(HaveKey("id"))
+	})
+
+	It("should convert the get item", func() {
+		key := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "0001"}}
+		output, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String("_migrations"),
+			Key:            key,
+			ConsistentRead: aws.Bool(true),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Item).To(Equal(key))
+	})
+
+	It("should not support the optional methods the API does not implement", func() {
+		client := New(struct{ API }{api})
+
+		_, err := client.GetItem(ctx, &dynamodb.GetItemInput{})
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+		_, err = client.DeleteBackup(ctx, &dynamodb.DeleteBackupInput{})
+		Expect(err).To(MatchError(errors.ErrUnsupported))
+	})
+
+	It("should convert the table description", func() {
+		api.table = &dynamodbv1.TableDescription{
+			TableName:   awsv1.String("_migrations"),
+			TableStatus: awsv1.String(dynamodbv1.TableStatusActive),
+			KeySchema: []*dynamodbv1.KeySchemaElement{
+				{AttributeName: awsv1.String("id"), KeyType: awsv1.String(dynamodbv1.KeyTypeHash)},
+			},
+		}
+
+		output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String("_migrations")})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Table.TableStatus).To(Equal(types.TableStatusActive))
+		Expect(output.Table.KeySchema).To(Equal([]types.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+		}))
+	})
+
+	Describe("errors", func() {
+		It("should convert a failed condition, with the item", func() {
+			api.err = &dynamodbv1.ConditionalCheckFailedException{
+				Message_: awsv1.String("the conditional request failed"),
+				Item:     map[string]*dynamodbv1.AttributeValue{"owner": {S: awsv1.String("host-1")}},
+			}
+
+			_, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("_migrations-lock")})
+			var conditionalCheckFailed *types.ConditionalCheckFailedException
+			Expect(errors.As(err, &conditionalCheckFailed)).To(BeTrue())
+			Expect(conditionalCheckFailed.Item).To(Equal(map[string]types.AttributeValue{
+				"owner": &types.AttributeValueMemberS{Value: "host-1"},
+			}))
+		})
+
+		It("should convert a cancelled transaction, with the reasons", func() {
+			api.err = &dynamodbv1.TransactionCanceledException{
+				CancellationReasons: []*dynamodbv1.CancellationReason{
+					{Code: awsv1.String("None")},
+					{Code: awsv1.String("ConditionalCheckFailed")},
+				},
+			}
+
+			_, err := client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{})
+			var transactionCanceled *types.TransactionCanceledException
+			Expect(errors.As(err, &transactionCanceled)).To(BeTrue())
+			Expect(transactionCanceled.CancellationReasons).To(HaveLen(2))
+			Expect(transactionCanceled.CancellationReasons[1].Code).To(Equal(aws.String("ConditionalCheckFailed")))
+		})
+
+		It("should convert a missing table", func() {
+			api.err = &dynamodbv1.ResourceNotFoundException{Message_: awsv1.String("table not found")}
+
+			_, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String("_migrations")})
+			var resourceNotFound *types.ResourceNotFoundException
+			Expect(errors.As(err, &resourceNotFound)).To(BeTrue())
+		})
+
+		It("should keep the code of the other API errors", func() {
+			api.err = awserr.New("ThrottlingException", "rate exceeded", nil)
+
+			_, err := client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("_migrations")})
+			var apiErr smithy.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.ErrorCode()).To(Equal("ThrottlingException"))
+			Expect(apiErr.ErrorMessage()).To(Equal("rate exceeded"))
+		})
+
+		It("should unwrap the error of a cancelled request", func() {
+			api.err = awserr.New(request.CanceledErrorCode, "request context canceled", context.Canceled)
+
+			_, err := client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("_migrations")})
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+})
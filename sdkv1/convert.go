@@ -0,0 +1,268 @@
+package sdkv1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	dynamodbv1 "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/smithy-go"
+)
+
+func toValue(value types.AttributeValue) *dynamodbv1.AttributeValue {
+	switch value := value.(type) {
+	case *types.AttributeValueMemberS:
+		return &dynamodbv1.AttributeValue{S: aws.String(value.Value)}
+	case *types.AttributeValueMemberN:
+		return &dynamodbv1.AttributeValue{N: aws.String(value.Value)}
+	case *types.AttributeValueMemberB:
+		return &dynamodbv1.AttributeValue{B: value.Value}
+	case *types.AttributeValueMemberBOOL:
+		return &dynamodbv1.AttributeValue{BOOL: aws.Bool(value.Value)}
+	case *types.AttributeValueMemberNULL:
+		return &dynamodbv1.AttributeValue{NULL: aws.Bool(value.Value)}
+	case *types.AttributeValueMemberSS:
+		return &dynamodbv1.AttributeValue{SS: aws.StringSlice(value.Value)}
+	case *types.AttributeValueMemberNS:
+		return &dynamodbv1.AttributeValue{NS: aws.StringSlice(value.Value)}
+	case *types.AttributeValueMemberBS:
+		return &dynamodbv1.AttributeValue{BS: value.Value}
+	case *types.AttributeValueMemberL:
+		l := make([]*dynamodbv1.AttributeValue, 0, len(value.Value))
+		for _, v := range value.Value {
+			l = append(l, toValue(v))
+		}
+		return &dynamodbv1.AttributeValue{L: l}
+	case *types.AttributeValueMemberM:
+		return &dynamodbv1.AttributeValue{M: toItem(value.Value)}
+	}
+	return nil
+}
+
+func fromValue(value *dynamodbv1.AttributeValue) types.AttributeValue {
+	switch {
+	case value == nil:
+		return nil
+	case value.S != nil:
+		return &types.AttributeValueMemberS{Value: *value.S}
+	case value.N != nil:
+		return &types.AttributeValueMemberN{Value: *value.N}
+	case value.B != nil:
+		return &types.AttributeValueMemberB{Value: value.B}
+	case value.BOOL != nil:
+		return &types.AttributeValueMemberBOOL{Value: *value.BOOL}
+	case value.NULL != nil:
+		return &types.AttributeValueMemberNULL{Value: *value.NULL}
+	case value.SS != nil:
+		return &types.AttributeValueMemberSS{Value: aws.ToStringSlice(value.SS)}
+	case value.NS != nil:
+		return &types.AttributeValueMemberNS{Value: aws.ToStringSlice(value.NS)}
+	case value.BS != nil:
+		return &types.AttributeValueMemberBS{Value: value.BS}
+	case value.L != nil:
+		l := make([]types.AttributeValue, 0, len(value.L))
+		for _, v := range value.L {
+			l = append(l, fromValue(v))
+		}
+		return &types.AttributeValueMemberL{Value: l}
+	case value.M != nil:
+		return &types.AttributeValueMemberM{Value: fromItem(value.M)}
+	}
+	return nil
+}
+
+// toItem converts the item, keeping it nil when empty, as the SDK v1 validates the length of the maps it sends.
+func toItem(item map[string]types.AttributeValue) map[string]*dynamodbv1.AttributeValue {
+	if len(item) == 0 {
+		return nil
+	}
+	r := make(map[string]*dynamodbv1.AttributeValue, len(item))
+	for k, v := range item {
+		r[k] = toValue(v)
+	}
+	return r
+}
+
+func fromItem(item map[string]*dynamodbv1.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	r := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		r[k] = fromValue(v)
+	}
+	return r
+}
+
+func toNames(names map[string]string) map[string]*string {
+	if len(names) == 0 {
+		return nil
+	}
+	return aws.StringMap(names)
+}
+
+func toInt64(v *int32) *int64 {
+	if v == nil {
+		return nil
+	}
+	return aws.Int64(int64(*v))
+}
+
+// toEnum converts an enum of the v2 to the string of the v1, nil when not set.
+func toEnum[T ~string](v T) *string {
+	if v == "" {
+		return nil
+	}
+	return aws.String(string(v))
+}
+
+func toTransactWriteItem(item types.TransactWriteItem) *dynamodbv1.TransactWriteItem {
+	r := &dynamodbv1.TransactWriteItem{}
+	if put := item.Put; put != nil {
+		r.Put = &dynamodbv1.Put{
+			TableName:                           put.TableName,
+			Item:                                toItem(put.Item),
+			ConditionExpression:                 put.ConditionExpression,
+			ExpressionAttributeNames:            toNames(put.ExpressionAttributeNames),
+			ExpressionAttributeValues:           toItem(put.ExpressionAttributeValues),
+			ReturnValuesOnConditionCheckFailure: toEnum(put.ReturnValuesOnConditionCheckFailure),
+		}
+	}
+	if update := item.Update; update != nil {
+		r.Update = &dynamodbv1.Update{
+			TableName:                           update.TableName,
+			Key:                                 toItem(update.Key),
+			UpdateExpression:                    update.UpdateExpression,
+			ConditionExpression:                 update.ConditionExpression,
+			ExpressionAttributeNames:            toNames(update.ExpressionAttributeNames),
+			ExpressionAttributeValues:           toItem(update.ExpressionAttributeValues),
+			ReturnValuesOnConditionCheckFailure: toEnum(update.ReturnValuesOnConditionCheckFailure),
+		}
+	}
+	if del := item.Delete; del != nil {
+		r.Delete = &dynamodbv1.Delete{
+			TableName:                           del.TableName,
+			Key:                                 toItem(del.Key),
+			ConditionExpression:                 del.ConditionExpression,
+			ExpressionAttributeNames:            toNames(del.ExpressionAttributeNames),
+			ExpressionAttributeValues:           toItem(del.ExpressionAttributeValues),
+			ReturnValuesOnConditionCheckFailure: toEnum(del.ReturnValuesOnConditionCheckFailure),
+		}
+	}
+	if check := item.ConditionCheck; check != nil {
+		r.ConditionCheck = &dynamodbv1.ConditionCheck{
+			TableName:                           check.TableName,
+			Key:                                 toItem(check.Key),
+			ConditionExpression:                 check.ConditionExpression,
+			ExpressionAttributeNames:            toNames(check.ExpressionAttributeNames),
+			ExpressionAttributeValues:           toItem(check.ExpressionAttributeValues),
+			ReturnValuesOnConditionCheckFailure: toEnum(check.ReturnValuesOnConditionCheckFailure),
+		}
+	}
+	return r
+}
+
+func fromConsumedCapacity(capacity *dynamodbv1.ConsumedCapacity) *types.ConsumedCapacity {
+	if capacity == nil {
+		return nil
+	}
+	return &types.ConsumedCapacity{
+		TableName:          capacity.TableName,
+		CapacityUnits:      capacity.CapacityUnits,
+		ReadCapacityUnits:  capacity.ReadCapacityUnits,
+		WriteCapacityUnits: capacity.WriteCapacityUnits,
+	}
+}
+
+func fromTableDescription(table *dynamodbv1.TableDescription) *types.TableDescription {
+	if table == nil {
+		return nil
+	}
+	r := &types.TableDescription{
+		TableName:        table.TableName,
+		TableArn:         table.TableArn,
+		TableStatus:      types.TableStatus(aws.ToString(table.TableStatus)),
+		CreationDateTime: table.CreationDateTime,
+		ItemCount:        table.ItemCount,
+		TableSizeBytes:   table.TableSizeBytes,
+	}
+	for _, definition := range table.AttributeDefinitions {
+		r.AttributeDefinitions = append(r.AttributeDefinitions, types.AttributeDefinition{
+			AttributeName: definition.AttributeName,
+			AttributeType: types.ScalarAttributeType(aws.ToString(definition.AttributeType)),
+		})
+	}
+	for _, element := range table.KeySchema {
+		r.KeySchema = append(r.KeySchema, types.KeySchemaElement{
+			AttributeName: element.AttributeName,
+			KeyType:       types.KeyType(aws.ToString(element.KeyType)),
+		})
+	}
+	if summary := table.BillingModeSummary; summary != nil {
+		r.BillingModeSummary = &types.BillingModeSummary{
+			BillingMode:                       types.BillingMode(aws.ToString(summary.BillingMode)),
+			LastUpdateToPayPerRequestDateTime: summary.LastUpdateToPayPerRequestDateTime,
+		}
+	}
+	if throughput := table.ProvisionedThroughput; throughput != nil {
+		r.ProvisionedThroughput = &types.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  throughput.ReadCapacityUnits,
+			WriteCapacityUnits: throughput.WriteCapacityUnits,
+		}
+	}
+	for _, index := range table.GlobalSecondaryIndexes {
+		r.GlobalSecondaryIndexes = append(r.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:   index.IndexName,
+			IndexStatus: types.IndexStatus(aws.ToString(index.IndexStatus)),
+			Backfilling: index.Backfilling,
+		})
+	}
+	return r
+}
+
+func fromCancellationReasons(reasons []*dynamodbv1.CancellationReason) []types.CancellationReason {
+	r := make([]types.CancellationReason, 0, len(reasons))
+	for _, reason := range reasons {
+		r = append(r, types.CancellationReason{
+			Code:    reason.Code,
+			Message: reason.Message,
+			Item:    fromItem(reason.Item),
+		})
+	}
+	return r
+}
+
+// fromError converts the error returned by the v1 client to the error the v2 client would return.
+func fromError(err error) error {
+	var (
+		conditionalCheckFailed *dynamodbv1.ConditionalCheckFailedException
+		transactionCanceled    *dynamodbv1.TransactionCanceledException
+		resourceNotFound       *dynamodbv1.ResourceNotFoundException
+		resourceInUse          *dynamodbv1.ResourceInUseException
+		backupNotFound         *dynamodbv1.BackupNotFoundException
+		awsErr                 awserr.Error
+	)
+	switch {
+	case errors.As(err, &conditionalCheckFailed):
+		return &types.ConditionalCheckFailedException{Message: conditionalCheckFailed.Message_, Item: fromItem(conditionalCheckFailed.Item)}
+	case errors.As(err, &transactionCanceled):
+		return &types.TransactionCanceledException{
+			Message:             transactionCanceled.Message_,
+			CancellationReasons: fromCancellationReasons(transactionCanceled.CancellationReasons),
+		}
+	case errors.As(err, &resourceNotFound):
+		return &types.ResourceNotFoundException{Message: resourceNotFound.Message_}
+	case errors.As(err, &resourceInUse):
+		return &types.ResourceInUseException{Message: resourceInUse.Message_}
+	case errors.As(err, &backupNotFound):
+		return &types.BackupNotFoundException{Message: backupNotFound.Message_}
+	case errors.As(err, &awsErr) && awsErr.Code() == request.CanceledErrorCode && awsErr.OrigErr() != nil:
+		return fmt.Errorf("%s: %w", awsErr.Message(), awsErr.OrigErr())
+	case errors.As(err, &awsErr):
+		return &smithy.GenericAPIError{Code: awsErr.Code(), Message: awsErr.Message()}
+	}
+	return err
+}
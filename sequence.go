@@ -0,0 +1,81 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// appliedSeqSuffix is appended to the lock ID to make the key of the item counting the migrations applied, in the
+// lock table.
+const appliedSeqSuffix = "#applied_seq"
+
+// nextAppliedSeq increments the counter of the migrations applied and returns its new value. The counter is kept in the
+// lock table, next to the lock, so it is never listed with the migrations.
+func (t *Target) nextAppliedSeq(ctx context.Context, operation string) (int64, error) {
+	expr, err := expression.NewBuilder().
+		WithUpdate(expression.Add(expression.Name("seq"), expression.Value(1))).
+		Build()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build the sequence expression: %w", err)
+	}
+
+	output, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &t.lockTableName,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: t.lockID + appliedSeqSuffix},
+		},
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueUpdatedNew,
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment the applied sequence: %w", err)
+	}
+	t.capacity.record(operation, output.ConsumedCapacity)
+
+	seq, ok := output.Attributes["seq"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("failed to increment the applied sequence: no sequence returned")
+	}
+	n, err := strconv.ParseInt(seq.Value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the applied sequence: %w", err)
+	}
+	return n, nil
+}
+
+// SortByApplyOrder sorts the records, as returned by History, in the order the migrations were applied, instead of by
+// ID. The migrations applied before the order was recorded come first, by when they were applied, and the ones never
+// finished come last, by ID.
+func SortByApplyOrder(records []MigrationRecord) {
+	rank := func(r MigrationRecord) int {
+		switch {
+		case r.AppliedSeq > 0:
+			return 1
+		case r.AppliedAt.IsZero():
+			return 2
+		}
+		return 0
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		switch {
+		case a.AppliedSeq != b.AppliedSeq:
+			return a.AppliedSeq < b.AppliedSeq
+		case !a.AppliedAt.Equal(b.AppliedAt):
+			return a.AppliedAt.Before(b.AppliedAt)
+		}
+		return a.ID < b.ID
+	})
+}
@@ -0,0 +1,135 @@
+package migrations_dynamodb_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+	"github.com/jamillosantos/migrations-dynamodb/faultinject"
+)
+
+// simulation runs the migrations of a source from several runners at the same time, as an auto-scaled fleet deploying
+// at once would. Each runner has its own Target, over its own client injecting a random latency in the calls, and they all
+// share the same migrations and lock tables.
+type simulation struct {
+	runners    int
+	migrations int
+	latency    time.Duration
+	options    []migrations_dynamodb.Option
+}
+
+// simulationReport is what the runners of a simulation did.
+type simulationReport struct {
+	// applied is how many times each migration was applied.
+	applied map[string]int
+	// maxRunning is the maximum number of migrations applied at the same time, by any runners.
+	maxRunning int32
+	errs       []error
+	status     migrations_dynamodb.Status
+	// target reads the state tables shared by the runners.
+	target *migrations_dynamodb.Target
+}
+
+func (s simulation) run(ctx context.Context) simulationReport {
+	GinkgoHelper()
+
+	var (
+		mu      sync.Mutex
+		applied = make(map[string]int, s.migrations)
+		running atomic.Int32
+		report  simulationReport
+	)
+	source := migrations.NewMemorySource()
+	for i := range s.migrations {
+		id := fmt.Sprintf("%04d", i+1)
+		Expect(source.Add(ctx, migrations.NewMigration(id, "migration "+id, func(context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				current := atomic.LoadInt32(&report.maxRunning)
+				if n <= current || atomic.CompareAndSwapInt32(&report.maxRunning, current, n) {
+					break
+				}
+			}
+			time.Sleep(s.latency)
+
+			mu.Lock()
+			defer mu.Unlock()
+			applied[id]++
+			return nil
+		}, nil))).To(Succeed())
+	}
+
+	tableName := fmt.Sprintf("_simulation-%d", time.Now().UnixNano())
+	options := append([]migrations_dynamodb.Option{
+		migrations_dynamodb.WithTableName(tableName),
+		migrations_dynamodb.WithLockTableName(tableName + "-lock"),
+	}, s.options...)
+
+	report.target = migrations_dynamodb.NewTarget(newLocalClient(), options...)
+	Expect(report.target.Create(ctx)).To(Succeed())
+	DeferCleanup(report.target.Destroy)
+
+	errs := make([]error, s.runners)
+	var wg sync.WaitGroup
+	wg.Add(s.runners)
+	for i := range s.runners {
+		go func() {
+			defer wg.Done()
+
+			client := faultinject.NewLatency(newLocalClient(), 0, s.latency, faultinject.WithSeed(uint64(i)))
+			runnerOptions := append([]migrations_dynamodb.Option{migrations_dynamodb.WithOwnerID(fmt.Sprintf("runner-%d", i))}, options...)
+			_, errs[i] = migrations.Migrate(ctx, source, migrations_dynamodb.NewTarget(client, runnerOptions...))
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			report.errs = append(report.errs, err)
+		}
+	}
+	report.applied = applied
+	status, err := report.target.Status(ctx)
+	Expect(err).ToNot(HaveOccurred())
+	report.status = status
+	return report
+}
+
+// expectInvariants checks that the runners applied every migration exactly once, one at a time, and left no lock held
+// nor migration dirty behind.
+func (r simulationReport) expectInvariants(migrationsCount int) {
+	GinkgoHelper()
+
+	Expect(r.errs).To(BeEmpty())
+	Expect(r.applied).To(HaveLen(migrationsCount))
+	for id, times := range r.applied {
+		Expect(times).To(Equal(1), "migration %s applied %d times", id, times)
+	}
+	Expect(r.maxRunning).To(BeEquivalentTo(1), "migrations applied by more than one runner at the same time")
+	Expect(r.status.Lock.Held).To(BeFalse(), "lock left held by %s", r.status.Lock.Owner)
+	Expect(r.status.Migrations).To(HaveLen(migrationsCount))
+	for _, migration := range r.status.Migrations {
+		Expect(migration.Dirty).To(BeFalse(), "migration %s left dirty", migration.ID)
+	}
+}
+
+var _ = Describe("Concurrent runners", func() {
+	DescribeTable("should apply every migration exactly once",
+		func(ctx context.Context, options ...migrations_dynamodb.Option) {
+			s := simulation{runners: 4, migrations: 5, latency: 10 * time.Millisecond, options: options}
+
+			s.run(ctx).expectInvariants(s.migrations)
+		},
+		Entry("with independent writes"),
+		Entry("with transactional writes", migrations_dynamodb.WithTransactionalWrites()),
+	)
+
+})
@@ -0,0 +1,160 @@
+// Package snsnotify publishes a message to an Amazon SNS topic when a migration fails or a dirty migration is
+// detected, so on-call can be paged when a migration crashes in the middle of a run.
+package snsnotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+const (
+	// EventMigrationFailed is the event of the messages published when the state of a migration could not be written.
+	EventMigrationFailed = "migration.failed"
+
+	// EventDirtyMigration is the event of the messages published when a dirty migration is detected.
+	EventDirtyMigration = "migration.dirty"
+)
+
+type PublishClient interface {
+	Publish(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// Message is the body of the messages published, encoded as JSON.
+type Message struct {
+	Event     string `json:"event"`
+	ID        string `json:"id"`
+	Operation string `json:"operation,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type opts struct {
+	subject      string
+	errorHandler func(ctx context.Context, err error)
+}
+
+type Option func(*opts)
+
+// WithSubject sets the subject of the messages published (used by the email subscriptions).
+func WithSubject(subject string) Option {
+	return func(o *opts) {
+		o.subject = subject
+	}
+}
+
+// WithErrorHandler sets a function called when a message could not be published.
+func WithErrorHandler(handler func(ctx context.Context, err error)) Option {
+	return func(o *opts) {
+		o.errorHandler = handler
+	}
+}
+
+// Notifier is a migrations_dynamodb.Listener that publishes a Message to an SNS topic for each MigrationFailed and
+// DirtyMigrationDetected event. As every Done finding a dirty migration emits a DirtyMigrationDetected, a dirty
+// migration is only published once by the Notifier, until the migration is added or finished again.
+type Notifier struct {
+	client   PublishClient
+	topicARN string
+
+	subject      string
+	errorHandler func(ctx context.Context, err error)
+
+	mu    sync.Mutex
+	dirty map[string]struct{}
+}
+
+// New creates a Notifier publishing to the topic with the given ARN. The Notifier must be set with
+// migrations_dynamodb.WithListener.
+func New(client PublishClient, topicARN string, opts ...Option) *Notifier {
+	options := defaultOpts()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Notifier{
+		client:       client,
+		topicARN:     topicARN,
+		subject:      options.subject,
+		errorHandler: options.errorHandler,
+		dirty:        make(map[string]struct{}),
+	}
+}
+
+func defaultOpts() opts {
+	return opts{
+		subject:      "migrations-dynamodb alert",
+		errorHandler: func(context.Context, error) {},
+	}
+}
+
+// OnEvent implements migrations_dynamodb.Listener.
+func (n *Notifier) OnEvent(ctx context.Context, event migrations_dynamodb.Event) {
+	var msg Message
+	switch e := event.(type) {
+	case migrations_dynamodb.MigrationFailed:
+		msg = Message{Event: EventMigrationFailed, ID: e.ID, Operation: e.Operation}
+		if e.Err != nil {
+			msg.Error = e.Err.Error()
+		}
+	case migrations_dynamodb.DirtyMigrationDetected:
+		if !n.markDirty(e.ID) {
+			return
+		}
+		msg = Message{Event: EventDirtyMigration, ID: e.ID}
+	case migrations_dynamodb.MigrationAdded:
+		n.forgetDirty(e.ID)
+		return
+	case migrations_dynamodb.MigrationFinished:
+		n.forgetDirty(e.ID)
+		return
+	default:
+		return
+	}
+
+	if err := n.publish(ctx, msg); err != nil {
+		if msg.Event == EventDirtyMigration {
+			// published again by the next Done finding it.
+			n.forgetDirty(msg.ID)
+		}
+		n.errorHandler(ctx, err)
+	}
+}
+
+// markDirty records the migration as published dirty, returning false if it already was.
+func (n *Notifier) markDirty(id string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.dirty[id]; ok {
+		return false
+	}
+	n.dirty[id] = struct{}{}
+	return true
+}
+
+func (n *Notifier) forgetDirty(id string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.dirty, id)
+}
+
+func (n *Notifier) publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	_, err = n.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(n.topicARN),
+		Subject:  aws.String(n.subject),
+		Message:  aws.String(string(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
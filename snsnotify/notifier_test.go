@@ -0,0 +1,104 @@
+package snsnotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	migrations_dynamodb "github.com/jamillosantos/migrations-dynamodb"
+)
+
+type fakePublishClient struct {
+	inputs []*sns.PublishInput
+	err    error
+}
+
+func (c *fakePublishClient) Publish(_ context.Context, input *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	c.inputs = append(c.inputs, input)
+	return &sns.PublishOutput{}, c.err
+}
+
+var _ = Describe("Notifier", func() {
+	const topicARN = "arn:aws:sns:us-east-1:123456789012:migrations"
+
+	var (
+		ctx    context.Context
+		client *fakePublishClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		client = &fakePublishClient{}
+	})
+
+	message := func(input *sns.PublishInput) Message {
+		var msg Message
+		Expect(json.Unmarshal([]byte(aws.ToString(input.Message)), &msg)).To(Succeed())
+		return msg
+	}
+
+	It("should publish when a migration fails", func() {
+		n := New(client, topicARN, WithSubject("prod migrations"))
+		n.OnEvent(ctx, migrations_dynamodb.MigrationFailed{ID: "1", Operation: "FinishMigration", Err: errors.New("random error")})
+
+		Expect(client.inputs).To(HaveLen(1))
+		Expect(aws.ToString(client.inputs[0].TopicArn)).To(Equal(topicARN))
+		Expect(aws.ToString(client.inputs[0].Subject)).To(Equal("prod migrations"))
+		Expect(message(client.inputs[0])).To(Equal(Message{
+			Event:     EventMigrationFailed,
+			ID:        "1",
+			Operation: "FinishMigration",
+			Error:     "random error",
+		}))
+	})
+
+	It("should publish when a dirty migration is detected", func() {
+		n := New(client, topicARN)
+		n.OnEvent(ctx, migrations_dynamodb.DirtyMigrationDetected{ID: "2"})
+
+		Expect(client.inputs).To(HaveLen(1))
+		Expect(message(client.inputs[0])).To(Equal(Message{Event: EventDirtyMigration, ID: "2"}))
+	})
+
+	It("should publish each dirty migration once, until it is finished", func() {
+		n := New(client, topicARN)
+		n.OnEvent(ctx, migrations_dynamodb.DirtyMigrationDetected{ID: "2"})
+		n.OnEvent(ctx, migrations_dynamodb.DirtyMigrationDetected{ID: "2"})
+		n.OnEvent(ctx, migrations_dynamodb.DirtyMigrationDetected{ID: "3"})
+		Expect(client.inputs).To(HaveLen(2))
+
+		n.OnEvent(ctx, migrations_dynamodb.MigrationFinished{ID: "2"})
+		n.OnEvent(ctx, migrations_dynamodb.DirtyMigrationDetected{ID: "2"})
+		Expect(client.inputs).To(HaveLen(3))
+		Expect(message(client.inputs[2])).To(Equal(Message{Event: EventDirtyMigration, ID: "2"}))
+	})
+
+	It("should ignore the other events", func() {
+		n := New(client, topicARN)
+		n.OnEvent(ctx, migrations_dynamodb.MigrationAdded{ID: "1"})
+		n.OnEvent(ctx, migrations_dynamodb.MigrationFinished{ID: "1"})
+		n.OnEvent(ctx, migrations_dynamodb.LockReleased{LockID: "migrations"})
+
+		Expect(client.inputs).To(BeEmpty())
+	})
+
+	It("should report the errors to the error handler", func() {
+		var errs []error
+		client.err = errors.New("random error")
+		n := New(client, topicARN, WithErrorHandler(func(_ context.Context, err error) {
+			errs = append(errs, err)
+		}))
+		n.OnEvent(ctx, migrations_dynamodb.DirtyMigrationDetected{ID: "2"})
+
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError(client.err))
+
+		n.OnEvent(ctx, migrations_dynamodb.DirtyMigrationDetected{ID: "2"})
+		Expect(client.inputs).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,101 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// StaleDirtyPolicy is what Lock does with the stale dirty migrations, left behind by runners that crashed while
+// applying them (see WithStaleDirtyThreshold).
+type StaleDirtyPolicy string
+
+const (
+	// StaleDirtyFail makes Lock fail with an ErrStaleDirtyMigration, releasing the lock, so the migrations are
+	// repaired by hand.
+	StaleDirtyFail StaleDirtyPolicy = "fail"
+	// StaleDirtyMarkFinished marks the stale dirty migrations as finished, for the migrations that are idempotent or
+	// known to be applied.
+	StaleDirtyMarkFinished StaleDirtyPolicy = "mark-finished"
+	// StaleDirtyRemove removes the stale dirty migrations, so they are applied again by the next run.
+	StaleDirtyRemove StaleDirtyPolicy = "remove"
+)
+
+// ErrStaleDirtyMigration is returned by Lock, with the StaleDirtyFail policy, when there are stale dirty migrations.
+// It also matches `migrations.ErrDirtyMigration`.
+var ErrStaleDirtyMigration = errors.New("stale dirty migration")
+
+// isStale checks if the migration is dirty since longer than the stale dirty threshold. Migrations recorded with no
+// started_at (e.g. by older versions of the Target) are never stale, as it cannot be told when they started, neither
+// are the migrations failed by FailMigration: their runner did not crash, it recorded why they failed, so they are
+// left for someone to look at instead of being repaired.
+func (t *Target) isStale(item map[string]types.AttributeValue) (bool, error) {
+	if t.staleDirtyThreshold <= 0 {
+		return false, nil
+	}
+	var record ddbRecord
+	if err := t.codec.unmarshalRecord(item, &record); err != nil {
+		return false, fmt.Errorf("failed to unmarshal item: %w", err)
+	}
+	startedAt := t.codec.parseTimestamp(record.StartedAt)
+	if !record.Dirty || startedAt.IsZero() || record.Failure != "" {
+		return false, nil
+	}
+	return t.clock.Now().Sub(startedAt) >= t.staleDirtyThreshold, nil
+}
+
+// repairStaleDirty applies the stale dirty policy to the stale dirty migrations. It is called by Lock, right after the
+// lock is acquired.
+func (t *Target) repairStaleDirty(ctx context.Context) error {
+	if t.staleDirtyPolicy == "" || t.staleDirtyThreshold <= 0 {
+		return nil
+	}
+
+	items, err := t.scanMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for _, item := range items {
+		ok, err := t.isStale(item)
+		if err != nil {
+			return err
+		}
+		if id, isString := item["id"].(*types.AttributeValueMemberS); ok && isString {
+			stale = append(stale, id.Value)
+		}
+	}
+	sort.Strings(stale)
+
+	if err := t.repairDirty(ctx, t.staleDirtyPolicy, "stale dirty migration", stale); err != nil {
+		return err
+	}
+	if t.staleDirtyPolicy == StaleDirtyFail && len(stale) > 0 {
+		return fmt.Errorf("%w: %w: %s", ErrStaleDirtyMigration, migrations.ErrDirtyMigration, strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+// repairDirty applies the policy to the dirty migrations with the IDs, described by kind. The StaleDirtyFail policy
+// leaves them as they are.
+func (t *Target) repairDirty(ctx context.Context, policy StaleDirtyPolicy, kind string, ids []string) error {
+	for _, id := range ids {
+		t.logger.WarnContext(ctx, kind+" found", "id", id, "policy", policy)
+		var err error
+		switch policy {
+		case StaleDirtyMarkFinished:
+			err = t.setDirty(ctx, operationRepairMarkFinished, id, false, "")
+		case StaleDirtyRemove:
+			err = t.remove(ctx, operationRepairRemove, id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to repair %s %s: %w", kind, id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stale dirty migrations", func() {
+	var (
+		ctx   context.Context
+		clock *fakeClock
+	)
+
+	newTarget := func(options ...Option) *Target {
+		return NewTarget(dynamoDBClient, append([]Option{WithClock(clock), WithStaleDirtyThreshold(time.Hour)}, options...)...)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		clock = newFakeClock()
+
+		deleteAllTables(ctx)
+
+		target := newTarget()
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "0001")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "0001")).To(Succeed())
+		Expect(target.Add(ctx, "0002")).To(Succeed())
+		clock.Advance(30 * time.Minute)
+		Expect(target.Add(ctx, "0003")).To(Succeed())
+		clock.Advance(45 * time.Minute)
+	})
+
+	It("should flag the migrations dirty since longer than the threshold in the status", func() {
+		status, err := newTarget().Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{
+			{ID: "0001"},
+			{ID: "0002", Dirty: true, Stale: true},
+			{ID: "0003", Dirty: true},
+		}))
+	})
+
+	It("should not flag any migration without a threshold", func() {
+		status, err := NewTarget(dynamoDBClient, WithClock(clock)).Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations[1]).To(Equal(MigrationStatus{ID: "0002", Dirty: true}))
+	})
+
+	It("should mark the stale dirty migrations as finished when locking", func() {
+		target := newTarget(WithStaleDirtyPolicy(StaleDirtyMarkFinished))
+
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u.Unlock(ctx)).To(Succeed())
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "0001"}, {ID: "0002"}, {ID: "0003", Dirty: true}}))
+	})
+
+	It("should not mark the failed migrations as finished when locking", func() {
+		target := newTarget(WithStaleDirtyPolicy(StaleDirtyMarkFinished))
+		Expect(target.FailMigration(ctx, "0002", errors.New("verification failed"))).To(Succeed())
+
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u.Unlock(ctx)).To(Succeed())
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "0001"}, {ID: "0002", Dirty: true}, {ID: "0003", Dirty: true}}))
+	})
+
+	It("should remove the stale dirty migrations when locking", func() {
+		target := newTarget(WithStaleDirtyPolicy(StaleDirtyRemove), WithTransactionalWrites())
+
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u.Unlock(ctx)).To(Succeed())
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "0001"}, {ID: "0003", Dirty: true}}))
+	})
+
+	It("should fail locking, releasing the lock, when there are stale dirty migrations", func() {
+		target := newTarget(WithStaleDirtyPolicy(StaleDirtyFail))
+
+		_, err := target.Lock(ctx)
+		Expect(err).To(MatchError(ErrStaleDirtyMigration))
+		Expect(err).To(MatchError(migrations.ErrDirtyMigration))
+		Expect(err).To(MatchError(ContainSubstring("0002")))
+		Expect(ErrorCodeOf(err)).To(Equal(CodeDirty))
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Lock.Held).To(BeFalse())
+		Expect(status.Migrations[1].Dirty).To(BeTrue())
+	})
+})
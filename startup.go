@@ -0,0 +1,63 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/jamillosantos/migrations/v2"
+)
+
+// attributeStartedByOwner is the runner ID of the Target that last added or started the migration, recorded with
+// WithStartupRepair.
+const attributeStartedByOwner = "started_by_owner"
+
+// repairStartupDirty applies the startup repair policy to the dirty migrations left behind by a previous run of this
+// runner, the ones started by a Target with the same runner ID. The migrations the runner failed with FailMigration are
+// skipped: they did not crash, they recorded why they failed. It is called by Lock, right after the lock is acquired:
+// as the lock is held, no other run of the runner can be applying them.
+func (t *Target) repairStartupDirty(ctx context.Context) error {
+	if t.startupRepair == "" {
+		return nil
+	}
+
+	items, err := t.scanMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	var crashed []string
+	for _, item := range items {
+		var record struct {
+			ID             string `dynamodbav:"id"`
+			Dirty          bool   `dynamodbav:"dirty"`
+			StartedByOwner string `dynamodbav:"started_by_owner"`
+			Failure        string `dynamodbav:"failure"`
+		}
+		if err := t.codec.unmarshalRecord(item, &record); err != nil {
+			return fmt.Errorf("failed to unmarshal item: %w", err)
+		}
+		if record.Dirty && record.StartedByOwner == t.runnerID && record.Failure == "" {
+			crashed = append(crashed, record.ID)
+		}
+	}
+	sort.Strings(crashed)
+
+	if err := t.repairDirty(ctx, t.startupRepair, "dirty migration of a previous run", crashed); err != nil {
+		return err
+	}
+	if t.startupRepair == StaleDirtyFail && len(crashed) > 0 {
+		return fmt.Errorf("%w: %s", migrations.ErrDirtyMigration, strings.Join(crashed, ", "))
+	}
+	return nil
+}
+
+// startedByOwner returns the attribute recording the runner ID of the Target starting a migration, or nil if it is not
+// recorded.
+func (t *Target) startedByOwner() types.AttributeValue {
+	if t.startupRepair == "" {
+		return nil
+	}
+	return &types.AttributeValueMemberS{Value: t.runnerID}
+}
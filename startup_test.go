@@ -0,0 +1,110 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Startup repair", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+	})
+
+	// crash leaves the migrations dirty, as a run of the runner that crashed while applying them.
+	crash := func(target *Target, ids ...string) {
+		GinkgoHelper()
+
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		for _, id := range ids {
+			Expect(target.Add(ctx, id)).To(Succeed())
+		}
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+	}
+
+	It("should remove the dirty migrations of a previous run of the runner, so they are applied again", func() {
+		crash(NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyRemove)), "1")
+		crash(NewTarget(dynamoDBClient, WithRunnerID("runner-2"), WithStartupRepair(StaleDirtyRemove)), "2")
+
+		target := NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyRemove))
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			Expect(unlocker.Unlock(ctx)).To(Succeed())
+		}()
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "2", Dirty: true}}))
+	})
+
+	It("should mark the dirty migrations of a previous run as finished", func() {
+		crash(NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyMarkFinished)), "1")
+
+		target := NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyMarkFinished))
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Done(ctx)).To(Equal([]string{"1"}))
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+	})
+
+	It("should not repair the migrations the previous run failed", func() {
+		target := NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyMarkFinished))
+		crash(target, "1", "2")
+		Expect(target.FailMigration(ctx, "2", errors.New("verification failed"))).To(Succeed())
+
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			Expect(unlocker.Unlock(ctx)).To(Succeed())
+		}()
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{{ID: "1"}, {ID: "2", Dirty: true}}))
+	})
+
+	It("should keep the owner ID of the Targets of the runner unique", func() {
+		crash(NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyRemove)), "1")
+
+		// another replica of the runner, holding the lock at the same time, is not the owner of the lock.
+		replica := NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyRemove), WithTransactionalWrites())
+		unlocker, err := NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyRemove)).Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(replica.Add(ctx, "2")).To(MatchError(ErrLockNotHeld))
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+	})
+
+	It("should not repair, warning, without the runner ID", func() {
+		crash(NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyRemove)), "1")
+
+		buf := &syncBuffer{}
+		target := NewTarget(dynamoDBClient, WithStartupRepair(StaleDirtyRemove), WithLogger(slog.New(slog.NewJSONHandler(buf, nil))))
+		Expect(logMessages(buf)).To(Equal([]string{"startup repair disabled, set the runner ID with WithRunnerID"}))
+
+		unlocker, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Status(ctx)).To(HaveField("Migrations", Equal([]MigrationStatus{{ID: "1", Dirty: true}})))
+		Expect(unlocker.Unlock(ctx)).To(Succeed())
+	})
+
+	It("should fail, releasing the lock, with the fail policy", func() {
+		crash(NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyFail)), "1")
+
+		target := NewTarget(dynamoDBClient, WithRunnerID("runner-1"), WithStartupRepair(StaleDirtyFail))
+		_, err := target.Lock(ctx)
+		Expect(err).To(MatchError(migrations.ErrDirtyMigration))
+		Expect(target.Status(ctx)).To(HaveField("Lock.Held", false))
+	})
+})
@@ -0,0 +1,145 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Stats is a snapshot of the counters of a Target since it was created.
+type Stats struct {
+	// Operations is the number of operations executed by the Target, by operation (e.g. "Add" or "Lock").
+	Operations map[string]int64 `json:"operations"`
+	// Errors is the number of operations that failed, by operation.
+	Errors map[string]int64 `json:"errors"`
+	// LockWaits is the number of times the lock was acquired and LockWaitTime the total time waited for it.
+	LockWaits    int64         `json:"lock_waits"`
+	LockWaitTime time.Duration `json:"lock_wait_time"`
+	// Retries is the number of calls to the DynamoDB that were retried, either by the SDK retryer or by the Target
+	// backing off from throttling.
+	Retries int64 `json:"retries"`
+}
+
+// statsRecorder is the MetricsRecorder that keeps the counters returned by Target.Stats.
+type statsRecorder struct {
+	mu           sync.Mutex
+	operations   map[string]int64
+	errors       map[string]int64
+	lockWaits    int64
+	lockWaitTime time.Duration
+
+	retries atomic.Int64
+}
+
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{
+		operations: make(map[string]int64),
+		errors:     make(map[string]int64),
+	}
+}
+
+func (r *statsRecorder) RecordOperation(_ context.Context, operation string, _ time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.operations[operation]++
+	if err != nil {
+		r.errors[operation]++
+	}
+}
+
+func (r *statsRecorder) RecordLockWait(_ context.Context, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lockWaits++
+	r.lockWaitTime += duration
+}
+
+func (r *statsRecorder) stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := Stats{
+		Operations:   make(map[string]int64, len(r.operations)),
+		Errors:       make(map[string]int64, len(r.errors)),
+		LockWaits:    r.lockWaits,
+		LockWaitTime: r.lockWaitTime,
+		Retries:      r.retries.Load(),
+	}
+	for k, v := range r.operations {
+		s.Operations[k] = v
+	}
+	for k, v := range r.errors {
+		s.Errors[k] = v
+	}
+	return s
+}
+
+// Stats returns a snapshot of the counters of the Target (and the unlockers it created) so far.
+func (t *Target) Stats() Stats {
+	return t.stats.stats()
+}
+
+// Expvar returns an expvar.Var exposing the Stats of the Target, so it can be published with
+// `expvar.Publish("migrations", target.Expvar())`.
+func (t *Target) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		return t.Stats()
+	})
+}
+
+type multiMetricsRecorder []MetricsRecorder
+
+func (m multiMetricsRecorder) RecordOperation(ctx context.Context, operation string, duration time.Duration, err error) {
+	for _, r := range m {
+		r.RecordOperation(ctx, operation, duration, err)
+	}
+}
+
+func (m multiMetricsRecorder) RecordLockWait(ctx context.Context, duration time.Duration) {
+	for _, r := range m {
+		r.RecordLockWait(ctx, duration)
+	}
+}
+
+// retryCounter wraps the retryer of the calls counting the retries in the stats, and logging them with the context of
+// the call.
+type retryCounter struct {
+	aws.RetryerV2
+	ctx      context.Context
+	retries  *atomic.Int64
+	logger   *slog.Logger
+	logLevel slog.Level
+}
+
+func newRetryCounter(ctx context.Context, retryer aws.Retryer, retries *atomic.Int64, logger *slog.Logger, logLevel slog.Level) aws.Retryer {
+	v2, ok := retryer.(aws.RetryerV2)
+	if !ok {
+		v2 = retryerV2{retryer}
+	}
+	return &retryCounter{RetryerV2: v2, ctx: ctx, retries: retries, logger: logger, logLevel: logLevel}
+}
+
+func (r *retryCounter) RetryDelay(attempt int, err error) (time.Duration, error) {
+	r.retries.Add(1)
+	delay, delayErr := r.RetryerV2.RetryDelay(attempt, err)
+	if delayErr == nil {
+		r.logger.Log(r.ctx, r.logLevel, "request retried", "attempt", attempt, "delay", delay, "error", err)
+	}
+	return delay, delayErr
+}
+
+// retryerV2 adapts an aws.Retryer to aws.RetryerV2 the same way the SDK does.
+type retryerV2 struct {
+	aws.Retryer
+}
+
+func (r retryerV2) GetAttemptToken(context.Context) (func(error) error, error) {
+	return r.GetInitialToken(), nil
+}
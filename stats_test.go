@@ -0,0 +1,90 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jamillosantos/migrations/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stats", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	It("should count the operations, errors and lock waits", func() {
+		target := NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(MatchError(migrations.ErrMigrationAlreadyExists))
+		Expect(u.Unlock(ctx)).To(Succeed())
+
+		stats := target.Stats()
+		Expect(stats.Operations).To(Equal(map[string]int64{
+			operationCreate: 1,
+			operationLock:   1,
+			operationAdd:    2,
+			operationUnlock: 1,
+		}))
+		Expect(stats.Errors).To(Equal(map[string]int64{operationAdd: 1}))
+		Expect(stats.LockWaits).To(BeEquivalentTo(1))
+		Expect(stats.Retries).To(BeZero())
+	})
+
+	It("should count the retries of the calls", func() {
+		transport := &failingTransport{next: http.DefaultTransport}
+		transport.failures.Store(2)
+		client := dynamodb.New(dynamoDBClient.Options(), func(o *dynamodb.Options) {
+			o.HTTPClient = &http.Client{Transport: transport}
+		})
+		target := NewTarget(client, WithRetryer(retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		})))
+
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Stats().Retries).To(BeEquivalentTo(2))
+	})
+
+	It("should expose the stats as an expvar", func() {
+		target := NewTarget(dynamoDBClient)
+		Expect(target.Create(ctx)).To(Succeed())
+
+		var stats Stats
+		Expect(json.Unmarshal([]byte(target.Expvar().String()), &stats)).To(Succeed())
+		Expect(stats.Operations).To(HaveKeyWithValue(operationCreate, BeEquivalentTo(1)))
+	})
+})
+
+// failingTransport responds the requests with a retryable InternalServerError while there are failures left.
+type failingTransport struct {
+	next     http.RoundTripper
+	failures atomic.Int64
+}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.failures.Add(-1) >= 0 {
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.0"}},
+			Body:       io.NopCloser(strings.NewReader(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"random error"}`)),
+			Request:    req,
+		}, nil
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,135 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Status is a snapshot of the state stored by the Target: every migration recorded, including the dirty ones, and the
+// state of the lock.
+type Status struct {
+	// Migrations are sorted by ID.
+	Migrations []MigrationStatus
+	Lock       LockStatus
+}
+
+type MigrationStatus struct {
+	ID    string
+	Dirty bool
+	// Stale is whether the migration is dirty since longer than the threshold set by WithStaleDirtyThreshold.
+	Stale bool
+	// Prepared is whether the migration was prepared by PrepareMigration, waiting for CommitMigration.
+	Prepared bool
+	// Canary is whether the migration is applied in canary, in some slices of the environment only (see MarkCanary).
+	// The slices are listed by History.
+	Canary bool
+}
+
+type LockStatus struct {
+	Held bool `json:"held"`
+	// Owner is the owner ID of the Target holding the lock, when held.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Status returns the state stored by the Target. Differently from Done, dirty migrations are listed instead of
+// returning an `migrations.ErrDirtyMigration`.
+func (t *Target) Status(ctx context.Context) (_ Status, err error) {
+	defer t.observe(ctx, operationStatus, t.clock.Now(), &err)
+	defer wrapError(operationStatus, "", &err)
+
+	items, err := t.scanMigrations(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{
+		Migrations: make([]MigrationStatus, 0, len(items)),
+	}
+	for _, item := range items {
+		migration, err := t.migrationStatus(item)
+		if err != nil {
+			return Status{}, err
+		}
+		status.Migrations = append(status.Migrations, migration)
+	}
+	sort.Slice(status.Migrations, func(i, j int) bool {
+		return status.Migrations[i].ID < status.Migrations[j].ID
+	})
+
+	status.Lock, err = t.lockStatus(ctx, operationStatus)
+	if err != nil {
+		return Status{}, err
+	}
+
+	return status, nil
+}
+
+// migrationStatus returns the status of the migration recorded by the item.
+func (t *Target) migrationStatus(item map[string]types.AttributeValue) (MigrationStatus, error) {
+	var migration ddbMigration
+	if err := t.codec.unmarshalRecord(item, &migration); err != nil {
+		return MigrationStatus{}, fmt.Errorf("failed to unmarshal item: %w", err)
+	}
+	stale, err := t.isStale(item)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	return MigrationStatus{
+		ID:       migration.ID,
+		Dirty:    migration.Dirty,
+		Stale:    stale,
+		Prepared: migration.Phase == phasePrepared,
+		Canary:   migration.Phase == phaseCanary,
+	}, nil
+}
+
+func (t *Target) lockStatus(ctx context.Context, operation string) (LockStatus, error) {
+	item, err := t.lockTableItem(ctx, operation, t.lockID)
+	if err != nil || item == nil {
+		return LockStatus{}, err
+	}
+	return LockStatus{Held: true, Owner: lockHolder(item)}, nil
+}
+
+// lockTableItem reads the item with the ID from the lock table, as the lock, or returns nil if there is none. The read
+// is strongly consistent, as the approvals, freezes and locks it reads are checked right after being written.
+func (t *Target) lockTableItem(ctx context.Context, operation, id string) (map[string]types.AttributeValue, error) {
+	return t.getItem(ctx, operation, t.lockTableName, id)
+}
+
+// lockTableItemsWithPrefix reads the items with an ID starting with the prefix from the lock table.
+func (t *Target) lockTableItemsWithPrefix(ctx context.Context, prefix string) ([]map[string]types.AttributeValue, error) {
+	expr, err := expression.NewBuilder().
+		WithFilter(expression.Name("id").BeginsWith(prefix)).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the lock table expression: %w", err)
+	}
+
+	var (
+		items             []map[string]types.AttributeValue
+		exclusiveStartKey map[string]types.AttributeValue
+	)
+	for {
+		output, err := t.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:                 &t.lockTableName,
+			FilterExpression:          expr.Filter(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ExclusiveStartKey:         exclusiveStartKey,
+		}, t.lockCallOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan the lock table: %w", err)
+		}
+		items = append(items, output.Items...)
+		if len(output.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		exclusiveStartKey = output.LastEvaluatedKey
+	}
+}
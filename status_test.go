@@ -0,0 +1,53 @@
+package migrations_dynamodb
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Status", func() {
+	var (
+		ctx    context.Context
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient, WithOwnerID("owner-1"))
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should list all migrations, including the dirty ones", func() {
+		Expect(target.Add(ctx, "2")).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(Equal([]MigrationStatus{
+			{ID: "1", Dirty: false},
+			{ID: "2", Dirty: true},
+		}))
+		Expect(status.Lock).To(Equal(LockStatus{}))
+	})
+
+	It("should return the holder of the lock", func() {
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		status, err := target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Migrations).To(BeEmpty())
+		Expect(status.Lock).To(Equal(LockStatus{Held: true, Owner: "owner-1"}))
+
+		Expect(u.Unlock(ctx)).To(Succeed())
+		status, err = target.Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Lock.Held).To(BeFalse())
+	})
+})
@@ -2,26 +2,42 @@ package migrations_dynamodb
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/jamillosantos/migrations/v2"
 )
 
+// tableActiveTimeout is the maximum time to wait for a created table to become active, or a deleted table to be gone.
+const tableActiveTimeout = 5 * time.Minute
+
+// DynamoDBClient is the part of the *dynamodb.Client used by the Target. The other methods it may use are optional and
+// declared by their own interfaces: QueryDynamoDBClient, GetItemDynamoDBClient, TimeToLiveDynamoDBClient and
+// BackupDynamoDBClient. The clients wrapping another client (as oteltrace.Client) implement all of them, returning an
+// error matching errors.ErrUnsupported when the wrapped client does not: the Target handles the error as if the method
+// was not implemented.
 type DynamoDBClient interface {
 	Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
 	PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 	DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
 	UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 
 	CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
 	DeleteTable(ctx context.Context, input *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error)
 	ListTables(ctx context.Context, d *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error)
 }
@@ -29,9 +45,54 @@ type DynamoDBClient interface {
 type Target struct {
 	client DynamoDBClient
 
-	tableName     string
-	lockTableName string
-	lockID        string
+	tableName           string
+	lockTableName       string
+	lockID              string
+	ownerID             string
+	transactionalWrites bool
+	writeCondition      expression.ConditionBuilder
+	scanSegments        int
+	scanPageSize        int32
+	scanLimit           float64
+	logger              *slog.Logger
+	eventLogLevel       slog.Level
+	waitLogLevel        slog.Level
+	metrics             MetricsRecorder
+	listener            Listener
+	auditTableName      string
+	auditActor          string
+	stuckLockThreshold  time.Duration
+	stuckLockHook       func(ctx context.Context, stuck StuckLock)
+	correlationID       func(ctx context.Context) string
+	billingMode         types.BillingMode
+	tags                []types.Tag
+	createWait          bool
+	destroyWait         bool
+	destroyPrune        []PruneResource
+	validateOnly        bool
+	expectedTimeToLive  string
+	clock               Clock
+	staleDirtyThreshold time.Duration
+	staleDirtyPolicy    StaleDirtyPolicy
+	startupRepair       StaleDirtyPolicy
+	runnerID            string
+	downgradeProtection bool
+	codec               codec
+	compatibility       *Compatibility
+	maintenanceWindows  []MaintenanceWindow
+	freezeCheck         bool
+	consistentRead      *bool
+	lockPriority        *int
+	homeRegion          string
+	dirtyIndex          bool
+	appliedAtIndex      bool
+
+	capacity      *capacityRecorder
+	stats         *statsRecorder
+	verifications *verifications
+	// locksHeld counts the locks acquired by Lock and not released yet, so the reads made while holding the lock are
+	// strongly consistent.
+	locksHeld *atomic.Int32
 }
 
 func NewTarget(client DynamoDBClient, opts ...Option) *Target {
@@ -39,19 +100,88 @@ func NewTarget(client DynamoDBClient, opts ...Option) *Target {
 	for _, opt := range opts {
 		opt(&options)
 	}
+	if options.ownerID == "" {
+		options.ownerID = newOwnerID()
+	}
+	if options.auditActor == "" {
+		options.auditActor = defaultAuditActor()
+	}
+	if options.startupRepair != "" && options.runnerID == "" {
+		// the previous runs of the runner cannot be told apart from the other runners without its identity.
+		options.logger.Warn("startup repair disabled, set the runner ID with WithRunnerID", "policy", options.startupRepair)
+		options.startupRepair = ""
+	}
+	stats := newStatsRecorder()
 	return &Target{
-		client: client,
-
-		tableName:     options.tableName,
-		lockTableName: options.lockTableName,
-		lockID:        options.lockID,
+		client: newDebugClient(newPolicyClient(client, options, &stats.retries), options),
+
+		tableName:           options.tableName,
+		lockTableName:       options.lockTableName,
+		lockID:              options.lockID,
+		ownerID:             options.ownerID,
+		transactionalWrites: options.transactionalWrites,
+		writeCondition:      options.writeCondition,
+		scanSegments:        options.scanSegments,
+		scanPageSize:        options.scanPageSize,
+		scanLimit:           options.scanLimit,
+		logger:              options.logger,
+		eventLogLevel:       options.eventLogLevel,
+		waitLogLevel:        options.waitLogLevel,
+		metrics:             multiMetricsRecorder{stats, options.metrics},
+		listener:            newListener(options.listeners),
+		auditTableName:      options.auditTableName,
+		auditActor:          options.auditActor,
+		stuckLockThreshold:  options.stuckLockThreshold,
+		stuckLockHook:       options.stuckLockHook,
+		correlationID:       options.correlationID,
+		billingMode:         options.billingMode,
+		validateOnly:        options.validateOnly,
+		expectedTimeToLive:  options.expectedTimeToLive,
+		tags:                options.tags,
+		createWait:          options.createWait,
+		destroyWait:         options.destroyWait,
+		destroyPrune:        options.destroyPrune,
+		clock:               options.clock,
+		staleDirtyThreshold: options.staleDirtyThreshold,
+		staleDirtyPolicy:    options.staleDirtyPolicy,
+		startupRepair:       options.startupRepair,
+		runnerID:            options.runnerID,
+		downgradeProtection: options.downgradeProtection,
+		codec:               newCodec(options),
+		compatibility:       options.compatibility,
+		maintenanceWindows:  options.maintenanceWindows,
+		freezeCheck:         options.freezeCheck,
+		consistentRead:      options.consistentRead,
+		lockPriority:        options.lockPriority,
+		homeRegion:          options.homeRegion,
+		dirtyIndex:          options.dirtyIndex,
+		appliedAtIndex:      options.appliedAtIndex,
+
+		capacity:      newCapacityRecorder(),
+		stats:         stats,
+		verifications: newVerifications(),
+		locksHeld:     &atomic.Int32{},
 	}
 }
 
+// newOwnerID generates a random ID to identify the Target as the holder of the lock.
+func newOwnerID() string {
+	return randomID()
+}
+
+// randomID generates a random hex encoded ID.
+func randomID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 // Current will return the current migration ID. If there is no current migration, it will return a
 // migrations.ErrNoCurrentMigration error. Also, this implementation uses Done, so all errors Done would return
 // can be returned by this method.
-func (t *Target) Current(ctx context.Context) (string, error) {
+func (t *Target) Current(ctx context.Context) (_ string, err error) {
+	defer wrapError(operationCurrent, t.tableName, &err)
+
 	done, err := t.Done(ctx)
 	if err != nil {
 		return "", err
@@ -64,40 +194,37 @@ func (t *Target) Current(ctx context.Context) (string, error) {
 	return done[len(done)-1], nil
 }
 
-// Create will create the migrations table and the migrations lock table in the DynamoDB.
-func (t *Target) Create(ctx context.Context) error {
+// Create will create the migrations table and the migrations lock table (and the audit table, if set) in the
+// DynamoDB. The tables are created in parallel and Create waits until they are active, unless disabled by
+// WithTableWait. With WithValidateOnly, the existing tables are validated instead.
+func (t *Target) Create(ctx context.Context) (err error) {
+	defer t.observe(ctx, operationCreate, t.clock.Now(), &err)
+	defer wrapError(operationCreate, "", &err)
+
+	if t.validateOnly {
+		return t.validateTables(ctx)
+	}
+
 	tables, _ := t.generateTablesMap(ctx)
 
-	if _, ok := tables[t.tableName]; !ok {
-		_, err := t.client.CreateTable(ctx, &dynamodb.CreateTableInput{
-			TableName: &t.tableName,
-			AttributeDefinitions: []types.AttributeDefinition{
-				{
-					AttributeName: aws.String("id"),
-					AttributeType: types.ScalarAttributeTypeS,
-				},
-			},
-			KeySchema: []types.KeySchemaElement{
-				{
-					AttributeName: aws.String("id"),
-					KeyType:       types.KeyTypeHash,
-				},
-			},
-			ProvisionedThroughput: &types.ProvisionedThroughput{
-				ReadCapacityUnits:  aws.Int64(1),
-				WriteCapacityUnits: aws.Int64(1),
-			},
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create migrations table: %w", err)
-		}
+	creators := []func(context.Context, map[string]struct{}, bool) error{
+		t.createMigrationsTable,
+		t.createLockTable,
+		t.createAuditTable,
 	}
 
-	if err := t.createLockTable(ctx, tables); err != nil {
-		return err
+	var wg sync.WaitGroup
+	errs := make([]error, len(creators))
+	wg.Add(len(creators))
+	for i, create := range creators {
+		go func() {
+			defer wg.Done()
+			errs[i] = create(ctx, tables, t.createWait)
+		}()
 	}
+	wg.Wait()
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func (t *Target) generateTablesMap(ctx context.Context) (map[string]struct{}, error) {
@@ -113,28 +240,28 @@ func (t *Target) generateTablesMap(ctx context.Context) (map[string]struct{}, er
 	return tables, nil
 }
 
-func (t *Target) createLockTable(ctx context.Context, tables map[string]struct{}) error {
-	if _, ok := tables[t.lockTableName]; !ok {
-		_, err := t.client.CreateTable(ctx, &dynamodb.CreateTableInput{
-			TableName: &t.lockTableName,
-			AttributeDefinitions: []types.AttributeDefinition{
-				{
-					AttributeName: aws.String("id"),
-					AttributeType: types.ScalarAttributeTypeS,
-				},
-			},
-			KeySchema: []types.KeySchemaElement{
-				{
-					AttributeName: aws.String("id"),
-					KeyType:       types.KeyTypeHash,
-				},
-			},
-			ProvisionedThroughput: &types.ProvisionedThroughput{
-				ReadCapacityUnits:  aws.Int64(1),
-				WriteCapacityUnits: aws.Int64(1),
-			},
-		})
+func (t *Target) createMigrationsTable(ctx context.Context, tables map[string]struct{}, wait bool) error {
+	if _, ok := tables[t.tableName]; !ok {
+		if err := t.createTable(ctx, t.tableName, wait, t.migrationsTableIndexes()...); err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (t *Target) createLockTable(ctx context.Context, tables map[string]struct{}, wait bool) error {
+	_, ok := tables[t.lockTableName]
+	if t.homeRegion != "" {
+		// the tables listed are the ones of the region of the client, not the ones of the home region.
+		description, err := t.describeLockTable(ctx)
 		if err != nil {
+			return err
+		}
+		ok = description != nil
+	}
+	if !ok {
+		if err := t.createTable(ctx, t.lockTableName, wait); err != nil {
 			return fmt.Errorf("failed to create migrations lock table: %w", err)
 		}
 	}
@@ -142,9 +269,75 @@ func (t *Target) createLockTable(ctx context.Context, tables map[string]struct{}
 	return nil
 }
 
-// Destroy will delete the migrations table and the migrations lock table in the DynamoDB.
-func (t *Target) Destroy(ctx context.Context) error {
-	_, err := t.client.DeleteTable(ctx, &dynamodb.DeleteTableInput{
+// createTable creates a table keyed by the `id` attribute, with the billing mode and tags of the Target, and the
+// indexes given. If wait is set, it waits until the table is active.
+func (t *Target) createTable(ctx context.Context, tableName string, wait bool, indexes ...secondaryIndex) error {
+	if t.validateOnly {
+		return fmt.Errorf("%w: %s, it is not created in validate-only mode", ErrTableNotFound, tableName)
+	}
+	input := &dynamodb.CreateTableInput{
+		TableName: aws.String(tableName),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeHash,
+			},
+		},
+		BillingMode: t.billingMode,
+		Tags:        t.tags,
+	}
+	if t.billingMode != types.BillingModePayPerRequest {
+		input.ProvisionedThroughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(1),
+			WriteCapacityUnits: aws.Int64(1),
+		}
+	}
+	for _, index := range indexes {
+		index.addTo(input)
+	}
+	optFns := t.tableCallOptions(tableName)
+	_, err := t.client.CreateTable(ctx, input, optFns...)
+	if err != nil {
+		return err
+	}
+	if !wait {
+		t.logger.Log(ctx, t.eventLogLevel, "table creation started", "table", tableName)
+		return nil
+	}
+
+	err = dynamodb.NewTableExistsWaiter(t.client).Wait(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	}, tableActiveTimeout, func(o *dynamodb.TableExistsWaiterOptions) {
+		o.ClientOptions = append(o.ClientOptions, optFns...)
+	})
+	if err != nil {
+		return fmt.Errorf("failed waiting for the table to be active: %w", err)
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "table created", "table", tableName)
+
+	return nil
+}
+
+// Destroy will delete the migrations table and the migrations lock table in the DynamoDB, and the other resources set
+// by WithDestroyPrune. It does not wait for the tables to be deleted, unless enabled by WithTableWait.
+func (t *Target) Destroy(ctx context.Context) (err error) {
+	defer t.observe(ctx, operationDestroy, t.clock.Now(), &err)
+	defer wrapError(operationDestroy, "", &err)
+
+	// the backups are found in the migrations table, so they are deleted first.
+	if t.prunes(PruneBackups) {
+		if err := t.deleteBackups(ctx); err != nil {
+			return err
+		}
+	}
+
+	_, err = t.client.DeleteTable(ctx, &dynamodb.DeleteTableInput{
 		TableName: &t.tableName,
 	})
 	if err != nil {
@@ -153,34 +346,67 @@ func (t *Target) Destroy(ctx context.Context) error {
 
 	_, err = t.client.DeleteTable(ctx, &dynamodb.DeleteTableInput{
 		TableName: &t.lockTableName,
-	})
+	}, t.lockCallOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to delete migrations lock table: %w", err)
 	}
 
+	tableNames := []string{t.tableName, t.lockTableName}
+	if t.auditTableName != "" && t.prunes(PruneAuditTable) {
+		_, err = t.client.DeleteTable(ctx, &dynamodb.DeleteTableInput{
+			TableName: &t.auditTableName,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete migrations audit table: %w", err)
+		}
+		tableNames = append(tableNames, t.auditTableName)
+	}
+
+	if !t.destroyWait {
+		return nil
+	}
+	waiter := dynamodb.NewTableNotExistsWaiter(t.client)
+	for _, tableName := range tableNames {
+		err = waiter.Wait(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		}, tableActiveTimeout, func(o *dynamodb.TableNotExistsWaiterOptions) {
+			o.ClientOptions = append(o.ClientOptions, t.tableCallOptions(tableName)...)
+		})
+		if err != nil {
+			return fmt.Errorf("failed waiting for the table %s to be deleted: %w", tableName, err)
+		}
+	}
+
 	return nil
 }
 
+// TableName returns the name of the migrations table.
+func (t *Target) TableName() string {
+	return t.tableName
+}
+
 // Done will list all migrations IDs done in the target. If a dirty migration is found, it will return an
 // `migrations.ErrDirtyMigration`.
 // The result will sorted by ID.
-func (t *Target) Done(ctx context.Context) ([]string, error) {
+func (t *Target) Done(ctx context.Context) (_ []string, err error) {
+	defer t.observe(ctx, operationDone, t.clock.Now(), &err)
+	defer wrapError(operationDone, t.tableName, &err)
+
 	r := make([]string, 0)
-	scanResponse, err := t.client.Scan(ctx, &dynamodb.ScanInput{
-		TableName: &t.tableName,
-	})
+	items, err := t.scanMigrations(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan migrations table: %w", err)
+		return nil, err
 	}
 
 	var migration ddbMigration
-	for _, item := range scanResponse.Items {
-		err = attributevalue.UnmarshalMap(item, &migration)
+	for _, item := range items {
+		err = t.codec.unmarshalRecord(item, &migration)
 		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal item: %w", err)
 		}
 
 		if migration.Dirty {
+			t.listener.OnEvent(ctx, DirtyMigrationDetected{ID: migration.ID})
 			return nil, migrations.ErrDirtyMigration
 		}
 
@@ -191,15 +417,71 @@ func (t *Target) Done(ctx context.Context) ([]string, error) {
 	return r, nil
 }
 
-func (t *Target) Add(ctx context.Context, id string) error {
-	_, err := t.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: &t.tableName,
-		Item: map[string]types.AttributeValue{
-			"id":    &types.AttributeValueMemberS{Value: id},
-			"dirty": &types.AttributeValueMemberBOOL{Value: true},
-		},
-		ConditionExpression: aws.String("attribute_not_exists(id)"),
-	})
+// Add will add a migration to the target marked as dirty. If the migration already exists, it returns an
+// `migrations.ErrMigrationAlreadyExists`.
+func (t *Target) Add(ctx context.Context, id string) (err error) {
+	defer t.observe(ctx, operationAdd, t.clock.Now(), &err)
+	defer t.notifyMigration(ctx, operationAdd, id, &err)
+	defer wrapError(operationAdd, t.tableName, &err)
+
+	if err := t.guardMaintenance(ctx, operationAdd); err != nil {
+		return err
+	}
+	if err := t.guardDowngrade(ctx, id); err != nil {
+		return err
+	}
+
+	item := map[string]types.AttributeValue{
+		"id":               &types.AttributeValueMemberS{Value: id},
+		"dirty":            &types.AttributeValueMemberBOOL{Value: true},
+		attributeStartedAt: &types.AttributeValueMemberS{Value: t.codec.formatTimestamp(t.clock.Now())},
+		attributeAppliedBy: &types.AttributeValueMemberS{Value: t.auditActor},
+	}
+	if correlationID := t.correlationID(ctx); correlationID != "" {
+		item["correlation_id"] = &types.AttributeValueMemberS{Value: correlationID}
+	}
+	if t.dirtyIndex {
+		item[attributeDirtyKey] = &types.AttributeValueMemberS{Value: dirtyKeyValue}
+	}
+	if owner := t.startedByOwner(); owner != nil {
+		item[attributeStartedByOwner] = owner
+	}
+	expr, err := expression.NewBuilder().
+		WithCondition(t.withWriteCondition(expression.AttributeNotExists(expression.Name("id")))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the add expression: %w", err)
+	}
+
+	if t.transactionalWrites {
+		var writes []types.TransactWriteItem
+		writes, err = t.withAudit(ctx, operationAdd, id, nil, item, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:                 &t.tableName,
+				Item:                      item,
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		err = t.transactWrite(ctx, operationAdd, writes...)
+	} else {
+		var output *dynamodb.PutItemOutput
+		output, err = t.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 &t.tableName,
+			Item:                      item,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		})
+		if err == nil {
+			t.capacity.record(operationAdd, output.ConsumedCapacity)
+		}
+	}
 	// if the record already exists, we can ignore the error.
 	var conditionalCheckFailedException *types.ConditionalCheckFailedException
 	switch {
@@ -208,19 +490,75 @@ func (t *Target) Add(ctx context.Context, id string) error {
 	case err != nil:
 		return fmt.Errorf("failed to add migration: %w", err)
 	}
+	if !t.transactionalWrites {
+		t.audit(ctx, operationAdd, id, nil, item)
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "migration added", "id", id)
 
 	return nil
 }
 
 // Remove will remove a migration from the target. If the migration does not exist, it returns an `migrations.ErrMigrationNotFound`.
 func (t *Target) Remove(ctx context.Context, id string) error {
-	_, err := t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: &t.tableName,
-		Key: map[string]types.AttributeValue{
-			"id": &types.AttributeValueMemberS{Value: id},
-		},
-		ConditionExpression: aws.String("attribute_exists(id)"),
-	})
+	return t.remove(ctx, operationRemove, id)
+}
+
+// remove removes a migration, recording the change as the operation given: Remove or the repair of a dirty migration.
+func (t *Target) remove(ctx context.Context, operation, id string) (err error) {
+	defer t.observe(ctx, operation, t.clock.Now(), &err)
+	defer t.notifyMigration(ctx, operation, id, &err)
+	defer wrapError(operation, t.tableName, &err)
+
+	if err := t.guardMaintenance(ctx, operation); err != nil {
+		return err
+	}
+
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+	expr, err := expression.NewBuilder().
+		WithCondition(t.withWriteCondition(expression.AttributeExists(expression.Name("id")))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the remove expression: %w", err)
+	}
+
+	var before map[string]types.AttributeValue
+	if t.transactionalWrites {
+		before, err = t.auditBefore(ctx, operation, id)
+		if err != nil {
+			return err
+		}
+		var writes []types.TransactWriteItem
+		writes, err = t.withAudit(ctx, operation, id, before, nil, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName:                 &t.tableName,
+				Key:                       key,
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		err = t.transactWrite(ctx, operation, writes...)
+	} else {
+		var output *dynamodb.DeleteItemOutput
+		output, err = t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:                 &t.tableName,
+			Key:                       key,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ReturnValues:              types.ReturnValueAllOld,
+			ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		})
+		if err == nil {
+			t.capacity.record(operation, output.ConsumedCapacity)
+			before = output.Attributes
+		}
+	}
 	var conditionalCheckFailedException *types.ConditionalCheckFailedException
 	switch {
 	case errors.As(err, &conditionalCheckFailedException):
@@ -228,91 +566,414 @@ func (t *Target) Remove(ctx context.Context, id string) error {
 	case err != nil:
 		return fmt.Errorf("failed to remove migration: %w", err)
 	}
+	if !t.transactionalWrites {
+		t.audit(ctx, operation, id, before, nil)
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "migration removed", "id", id)
 
 	return nil
 }
 
 // FinishMigration will mark a migration as finished (dirty = false). If the migration does not exist, it will return an `migrations.ErrMigrationNotFound`.
+// The checks registered for the migration with Verify are evaluated afterwards: if any fails, the migration is
+// marked as failed with FailMigration and a *VerificationError is returned.
 func (t *Target) FinishMigration(ctx context.Context, id string) error {
-	_, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: &t.tableName,
-		Key: map[string]types.AttributeValue{
-			"id": &types.AttributeValueMemberS{Value: id},
-		},
-		UpdateExpression: aws.String("SET dirty = :dirty"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":dirty": &types.AttributeValueMemberBOOL{Value: false},
-		},
-		ConditionExpression: aws.String("attribute_exists(id)"),
-	})
-	var conditionalCheckFailedException *types.ConditionalCheckFailedException
-	switch {
-	case errors.As(err, &conditionalCheckFailedException):
-		return migrations.ErrMigrationNotFound
-	case err != nil:
-		return fmt.Errorf("failed to finish migration: %w", err)
+	if err := t.setDirty(ctx, operationFinishMigration, id, false, ""); err != nil {
+		return err
 	}
-
-	return nil
+	return t.verify(ctx, id)
 }
 
 // StartMigration will mark a migration as started (dirty = true). If the migration does not exist, it will return an `migrations.ErrMigrationNotFound`.
 func (t *Target) StartMigration(ctx context.Context, id string) error {
-	_, err := t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: &t.tableName,
-		Key: map[string]types.AttributeValue{
-			"id": &types.AttributeValueMemberS{Value: id},
-		},
-		UpdateExpression: aws.String("SET dirty = :dirty"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":dirty": &types.AttributeValueMemberBOOL{Value: true},
-		},
-		ConditionExpression: aws.String("attribute_exists(id)"),
-	})
+	return t.setDirty(ctx, operationStartMigration, id, true, "")
+}
+
+// FailMigration marks a migration as dirty again, recording the reason in its failure attribute, so the next runs
+// stop at it until it is fixed. If the migration does not exist, it will return an `migrations.ErrMigrationNotFound`.
+func (t *Target) FailMigration(ctx context.Context, id string, reason error) error {
+	return t.setDirty(ctx, operationFailMigration, id, true, reason.Error())
+}
+
+// setDirty updates the dirty flag of an existing migration. The failure is recorded when failing the migration, and
+// removed when finishing it. The prepared phase is recorded when preparing the migration, and removed by any other
+// write. If the migration does not exist, it will return an `migrations.ErrMigrationNotFound`.
+func (t *Target) setDirty(ctx context.Context, operation, id string, dirty bool, failure string) (err error) {
+	defer t.observe(ctx, operation, t.clock.Now(), &err)
+	defer t.notifyMigration(ctx, operation, id, &err)
+	defer wrapError(operation, t.tableName, &err)
+
+	if operation == operationStartMigration {
+		if err := t.guardMaintenance(ctx, operation); err != nil {
+			return err
+		}
+		if err := t.guardDowngrade(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+	now := t.clock.Now()
+	values := map[string]string{}
+	switch {
+	case operation == operationFailMigration:
+		values[attributeFailure] = failure
+	case operation == operationPrepareMigration:
+		values[attributePhase] = phasePrepared
+		values[attributePreparedAt] = t.codec.formatTimestamp(now)
+	case dirty:
+		values[attributeStartedAt] = t.codec.formatTimestamp(now)
+		if t.startupRepair != "" {
+			values[attributeStartedByOwner] = t.runnerID
+		}
+	default:
+		values[attributeAppliedAt] = t.codec.formatTimestamp(now)
+		values[attributeAppliedBy] = t.auditActor
+	}
+	// every write leaving the migration dirty puts it in the dirty index, as failing a finished migration does.
+	if dirty && t.dirtyIndex {
+		values[attributeDirtyKey] = dirtyKeyValue
+	}
+	if correlationID := t.correlationID(ctx); correlationID != "" {
+		values["correlation_id"] = correlationID
+	}
+	update := expression.Set(expression.Name("dirty"), expression.Value(dirty))
+	attributes := map[string]types.AttributeValue{
+		"dirty": &types.AttributeValueMemberBOOL{Value: dirty},
+	}
+	for name, value := range values {
+		update = update.Set(expression.Name(name), expression.Value(value))
+		attributes[name] = &types.AttributeValueMemberS{Value: value}
+	}
+	if !dirty && (operation == operationFinishMigration || operation == operationCommitMigration || operation == operationRepairMarkFinished) {
+		// the sequence is incremented even if the update fails, so it may have gaps, but it never goes back.
+		seq, err := t.nextAppliedSeq(ctx, operation)
+		if err != nil {
+			return err
+		}
+		update = update.Set(expression.Name(attributeAppliedSeq), expression.Value(seq))
+		attributes[attributeAppliedSeq] = &types.AttributeValueMemberN{Value: strconv.FormatInt(seq, 10)}
+	}
+	switch {
+	case !dirty && t.appliedAtIndex:
+		appliedAt := now.UnixMilli()
+		update = update.
+			Set(expression.Name(attributeAppliedKey), expression.Value(appliedKeyValue)).
+			Set(expression.Name(attributeAppliedAtMillis), expression.Value(appliedAt))
+		attributes[attributeAppliedKey] = &types.AttributeValueMemberS{Value: appliedKeyValue}
+		attributes[attributeAppliedAtMillis] = &types.AttributeValueMemberN{Value: strconv.FormatInt(appliedAt, 10)}
+	case dirty && t.appliedAtIndex:
+		update = update.Remove(expression.Name(attributeAppliedKey)).Remove(expression.Name(attributeAppliedAtMillis))
+	}
+	if !dirty {
+		update = update.Remove(expression.Name(attributeFailure))
+	}
+	if !dirty && t.dirtyIndex {
+		update = update.Remove(expression.Name(attributeDirtyKey))
+	}
+	if operation != operationPrepareMigration {
+		update = update.Remove(expression.Name(attributePhase))
+	}
+	condition := expression.AttributeExists(expression.Name("id"))
+	switch operation {
+	case operationPrepareMigration:
+		condition = condition.And(expression.Name("dirty").Equal(expression.Value(true)))
+	case operationCommitMigration:
+		condition = expression.Name(attributePhase).Equal(expression.Value(phasePrepared))
+	}
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(t.withWriteCondition(condition)).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the update expression: %w", err)
+	}
+
+	// auditAfter returns the migration as written by the update, from the one before it.
+	auditAfter := func(before map[string]types.AttributeValue) map[string]types.AttributeValue {
+		after := withAttributes(key, attributes)
+		if before != nil {
+			after = withAttributes(before, attributes)
+		}
+		if !dirty {
+			delete(after, attributeFailure)
+			delete(after, attributeDirtyKey)
+		} else {
+			delete(after, attributeAppliedKey)
+			delete(after, attributeAppliedAtMillis)
+		}
+		return after
+	}
+
+	var before map[string]types.AttributeValue
+	if t.transactionalWrites {
+		before, err = t.auditBefore(ctx, operation, id)
+		if err != nil {
+			return err
+		}
+		var writes []types.TransactWriteItem
+		writes, err = t.withAudit(ctx, operation, id, before, auditAfter(before), types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:                 &t.tableName,
+				Key:                       key,
+				UpdateExpression:          expr.Update(),
+				ConditionExpression:       expr.Condition(),
+				ExpressionAttributeNames:  expr.Names(),
+				ExpressionAttributeValues: expr.Values(),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		err = t.transactWrite(ctx, operation, writes...)
+	} else {
+		var output *dynamodb.UpdateItemOutput
+		output, err = t.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 &t.tableName,
+			Key:                       key,
+			UpdateExpression:          expr.Update(),
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ReturnValues:              types.ReturnValueAllOld,
+			ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+		})
+		if err == nil {
+			t.capacity.record(operation, output.ConsumedCapacity)
+			before = output.Attributes
+		}
+	}
 	var conditionalCheckFailedException *types.ConditionalCheckFailedException
 	switch {
+	case errors.As(err, &conditionalCheckFailedException) && (operation == operationPrepareMigration || operation == operationCommitMigration):
+		return fmt.Errorf("%w: %s", ErrInvalidPhase, id)
 	case errors.As(err, &conditionalCheckFailedException):
 		return migrations.ErrMigrationNotFound
-	case err != nil:
+	case err != nil && operation == operationFailMigration:
+		return fmt.Errorf("failed to mark migration as failed: %w", err)
+	case err != nil && operation == operationPrepareMigration:
+		return fmt.Errorf("failed to prepare migration: %w", err)
+	case err != nil && operation == operationCommitMigration:
+		return fmt.Errorf("failed to commit migration: %w", err)
+	case err != nil && dirty:
 		return fmt.Errorf("failed to start migration: %w", err)
+	case err != nil:
+		return fmt.Errorf("failed to finish migration: %w", err)
+	}
+	if err := t.rewriteLegacy(ctx, operation, key, before, values); err != nil {
+		return err
+	}
+	if !t.transactionalWrites {
+		t.audit(ctx, operation, id, before, auditAfter(before))
+	}
+	switch {
+	case operation == operationFailMigration:
+		t.logger.WarnContext(ctx, "migration failed", "id", id, "failure", failure)
+	case operation == operationPrepareMigration:
+		t.logger.Log(ctx, t.eventLogLevel, "migration prepared", "id", id)
+	case dirty:
+		t.logger.Log(ctx, t.eventLogLevel, "migration started", "id", id)
+	default:
+		t.logger.Log(ctx, t.eventLogLevel, "migration finished", "id", id)
 	}
 
 	return nil
 }
 
+// withWriteCondition combines the given condition with the one set by WithWriteCondition, if any.
+func (t *Target) withWriteCondition(condition expression.ConditionBuilder) expression.ConditionBuilder {
+	if !t.writeCondition.IsSet() {
+		return condition
+	}
+	return condition.And(t.writeCondition)
+}
+
+// transactWrite executes the given writes in a transaction together with a check that the lock is still held by this
+// Target. If the lock is not held anymore, it returns an `ErrLockNotHeld`. If the condition of a write fails, the
+// `types.ConditionalCheckFailedException` is returned as if the write was executed alone.
+func (t *Target) transactWrite(ctx context.Context, operation string, items ...types.TransactWriteItem) error {
+	lockExpr, err := expression.NewBuilder().
+		WithCondition(expression.Name("owner").Equal(expression.Value(t.ownerID))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the lock ownership expression: %w", err)
+	}
+
+	output, err := t.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: append([]types.TransactWriteItem{
+			{
+				ConditionCheck: &types.ConditionCheck{
+					TableName: &t.lockTableName,
+					Key: map[string]types.AttributeValue{
+						"id": &types.AttributeValueMemberS{Value: t.lockID},
+					},
+					ConditionExpression:       lockExpr.Condition(),
+					ExpressionAttributeNames:  lockExpr.Names(),
+					ExpressionAttributeValues: lockExpr.Values(),
+				},
+			},
+		}, items...),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}, t.lockCallOptions()...)
+	if err == nil {
+		for i := range output.ConsumedCapacity {
+			t.capacity.record(operation, &output.ConsumedCapacity[i])
+		}
+	}
+	var transactionCanceledException *types.TransactionCanceledException
+	if errors.As(err, &transactionCanceledException) {
+		reasons := transactionCanceledException.CancellationReasons
+		if len(reasons) > 0 && aws.ToString(reasons[0].Code) == "ConditionalCheckFailed" {
+			return ErrLockNotHeld
+		}
+		for _, reason := range reasons[min(len(reasons), 1):] {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return &types.ConditionalCheckFailedException{Message: reason.Message}
+			}
+		}
+	}
+	return err
+}
+
+// ConsumedCapacity returns the capacity units consumed by all calls made to the DynamoDB by this Target (and the
+// unlockers it created) so far.
+func (t *Target) ConsumedCapacity() CapacityStats {
+	return t.capacity.stats()
+}
+
+// Lock acquires the migrations lock, waiting while it is held by another runner. When the context is done while
+// waiting, it fails with the error of the context (an E_LOCK_TIMEOUT when its deadline is exceeded, while a call timing
+// out on its own, see WithOperationTimeout, is an E_TIMEOUT). The Unlocker returned is a *LockedTarget, see LockTarget.
 func (t *Target) Lock(ctx context.Context) (migrations.Unlocker, error) {
-	tables, err := t.generateTablesMap(ctx)
+	locked, err := t.LockTarget(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return locked, nil
+}
+
+// LockTarget acquires the migrations lock, as Lock, returning the LockedTarget the migration state is changed with
+// while the lock is held.
+func (t *Target) LockTarget(ctx context.Context) (_ *LockedTarget, err error) {
+	defer t.observe(ctx, operationLock, t.clock.Now(), &err)
+	defer wrapError(operationLock, t.lockTableName, &err)
+	defer markLockTimeout(ctx, &err)
 
-	err = t.createLockTable(ctx, tables)
+	// the lock is written right after, so the table must be active even if Create does not wait for it.
+	err = t.ensureLockTable(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := t.guardMaintenance(ctx, operationLock); err != nil {
+		return nil, err
+	}
 
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.AttributeNotExists(expression.Name("id"))).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the lock expression: %w", err)
+	}
+
+	if t.lockPriority != nil {
+		defer t.leaveLockQueue(ctx)
+	}
+	wait := func() error {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for the lock: %w", ctx.Err())
+		case <-t.clock.After(time.Second):
+			return nil
+		}
+	}
+
+	startedAt := t.clock.Now()
+	alarmed := false
+	var ticket int64
 	for {
-		_, err := t.client.PutItem(context.WithoutCancel(ctx), &dynamodb.PutItemInput{
+		yield, err := t.yieldLock(ctx, ticket)
+		if err != nil {
+			return nil, err
+		}
+		if yield {
+			t.logger.Log(ctx, t.waitLogLevel, "lock is wanted by a runner ahead in the queue, waiting", "lock_id", t.lockID)
+			if err := t.joinLockQueue(ctx, &ticket); err != nil {
+				return nil, err
+			}
+			if err := wait(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		output, err := t.client.PutItem(context.WithoutCancel(ctx), &dynamodb.PutItemInput{
 			TableName: &t.lockTableName,
 			Item: map[string]types.AttributeValue{
-				"id": &types.AttributeValueMemberS{Value: t.lockID},
+				"id":    &types.AttributeValueMemberS{Value: t.lockID},
+				"owner": &types.AttributeValueMemberS{Value: t.ownerID},
 			},
-			ConditionExpression: aws.String("attribute_not_exists(id)"),
-		})
+			ConditionExpression:                 expr.Condition(),
+			ExpressionAttributeNames:            expr.Names(),
+			ExpressionAttributeValues:           expr.Values(),
+			ReturnConsumedCapacity:              types.ReturnConsumedCapacityTotal,
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+		}, t.lockCallOptions()...)
 		var conditionalCheckFailedException *types.ConditionalCheckFailedException
 		switch {
 		case errors.As(err, &conditionalCheckFailedException):
-			time.Sleep(time.Second)
+			t.logger.Log(ctx, t.waitLogLevel, "lock is held by another runner, waiting", "lock_id", t.lockID)
+			if waited := t.clock.Now().Sub(startedAt); !alarmed && t.stuckLockHook != nil && waited >= t.stuckLockThreshold {
+				alarmed = true
+				t.stuckLockHook(ctx, StuckLock{
+					LockID: t.lockID,
+					Holder: lockHolder(conditionalCheckFailedException.Item),
+					Waited: waited,
+				})
+			}
+			if err := t.joinLockQueue(ctx, &ticket); err != nil {
+				return nil, err
+			}
+			if err := wait(); err != nil {
+				return nil, err
+			}
 			continue
 		case err != nil:
 			return nil, fmt.Errorf("failed to lock before migrating: %w", err)
 		}
+		t.capacity.record(operationLock, output.ConsumedCapacity)
 		break
 	}
+	waited := t.clock.Now().Sub(startedAt)
+	t.metrics.RecordLockWait(ctx, waited)
+	t.logger.Log(ctx, t.eventLogLevel, "lock acquired", "lock_id", t.lockID, "owner_id", t.ownerID, "waited", waited)
+	t.listener.OnEvent(ctx, LockAcquired{LockID: t.lockID, OwnerID: t.ownerID, Waited: waited})
 
-	return &unlocker{
+	u := &unlocker{
 		client:        t.client,
 		lockTableName: t.lockTableName,
 		lockID:        t.lockID,
-	}, nil
+		ownerID:       t.ownerID,
+		capacity:      t.capacity,
+		logger:        t.logger,
+		logLevel:      t.eventLogLevel,
+		metrics:       t.metrics,
+		listener:      t.listener,
+		clock:         t.clock,
+		options:       t.lockCallOptions(),
+		locksHeld:     t.locksHeld,
+	}
+	t.locksHeld.Add(1)
+	err = t.repairStaleDirty(ctx)
+	if err == nil {
+		err = t.repairStartupDirty(ctx)
+	}
+	if err != nil {
+		if unlockErr := u.Unlock(ctx); unlockErr != nil {
+			err = errors.Join(err, unlockErr)
+		}
+		return nil, err
+	}
+	return newLockedTarget(t, u), nil
 }
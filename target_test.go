@@ -2,12 +2,15 @@ package migrations_dynamodb
 
 import (
 	"context"
-	"log"
+	"errors"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/jamillosantos/migrations/v2"
@@ -45,6 +48,26 @@ var _ = Describe("Current", func() {
 			})
 		})
 
+		When("both tables do not exist", func() {
+			It("should create them in parallel", func() {
+				client := &barrierCreateTableClient{
+					Client:  dynamoDBClient,
+					parties: 2,
+					release: make(chan struct{}),
+				}
+				target = NewTarget(client)
+
+				Expect(target.Create(ctx)).To(Succeed())
+
+				listTablesResponse, err := dynamoDBClient.ListTables(ctx, &dynamodb.ListTablesInput{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(listTablesResponse.TableNames).To(ConsistOf(
+					"_migrations",
+					"_migrations-lock",
+				))
+			})
+		})
+
 		When("the migrations table already exists but not the lock table", func() {
 			It("should create the lock table", func() {
 				_, err := dynamoDBClient.CreateTable(ctx, &dynamodb.CreateTableInput{
@@ -119,6 +142,33 @@ var _ = Describe("Current", func() {
 				Expect(target.Create(ctx)).To(Succeed())
 			})
 		})
+
+		When("the billing mode and tags are set", func() {
+			It("should create the tables with them", func() {
+				target = NewTarget(dynamoDBClient,
+					WithBillingMode(types.BillingModePayPerRequest),
+					WithTableTags(map[string]string{"team": "platform", "env": "test"}),
+				)
+				Expect(target.Create(ctx)).To(Succeed())
+
+				for _, tableName := range []string{"_migrations", "_migrations-lock"} {
+					describeResponse, err := dynamoDBClient.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+						TableName: aws.String(tableName),
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(describeResponse.Table.BillingModeSummary.BillingMode).To(Equal(types.BillingModePayPerRequest))
+
+					tagsResponse, err := dynamoDBClient.ListTagsOfResource(ctx, &dynamodb.ListTagsOfResourceInput{
+						ResourceArn: describeResponse.Table.TableArn,
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(tagsResponse.Tags).To(Equal([]types.Tag{
+						{Key: aws.String("env"), Value: aws.String("test")},
+						{Key: aws.String("team"), Value: aws.String("platform")},
+					}))
+				}
+			})
+		})
 	})
 
 	Context("Destroy", func() {
@@ -133,6 +183,19 @@ var _ = Describe("Current", func() {
 				Expect(listTablesResponse.TableNames).To(BeEmpty())
 			})
 		})
+
+		When("waiting for the tables is enabled", func() {
+			It("should return once the tables are deleted", func() {
+				target = NewTarget(dynamoDBClient, WithTableWait(true))
+				Expect(target.Create(ctx)).To(Succeed())
+				Expect(target.Destroy(ctx)).To(Succeed())
+
+				listTablesResponse, err := dynamoDBClient.ListTables(ctx, &dynamodb.ListTablesInput{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(listTablesResponse.TableNames).To(BeEmpty())
+			})
+		})
 	})
 
 	Context("Add", func() {
@@ -297,6 +360,31 @@ var _ = Describe("Current", func() {
 		})
 	})
 
+	Context("ConsumedCapacity", func() {
+		BeforeEach(func() {
+			Expect(target.Create(ctx)).To(Succeed())
+		})
+
+		It("should aggregate the capacity consumed by each operation", func() {
+			u, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+			_, err = target.Done(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u.Unlock(ctx)).To(Succeed())
+
+			stats := target.ConsumedCapacity()
+			Expect(stats.ByOperation).To(HaveLen(5))
+			total := 0.0
+			for _, operation := range []string{"Lock", "Add", "FinishMigration", "Done", "Unlock"} {
+				Expect(stats.ByOperation).To(HaveKeyWithValue(operation, BeNumerically(">", 0)))
+				total += stats.ByOperation[operation]
+			}
+			Expect(stats.Total).To(BeNumerically("~", total))
+		})
+	})
+
 	Context("Lock", func() {
 		BeforeEach(func() {
 			Expect(target.Create(ctx)).To(Succeed())
@@ -334,32 +422,40 @@ var _ = Describe("Current", func() {
 		})
 
 		When("there is no dirty migrations", func() {
-			It("should return the list of migration finished", func() {
-				parallelCounter := 0
-
-				for i := 1; i < 11; i++ {
-					go func(i int) {
+			It("should be held by a single runner at a time", func() {
+				const runners = 10
+
+				// the runners hold the lock, and wait for it, in the time of the clock, so no time really passes.
+				clock := newFakeClock()
+				target := NewTarget(dynamoDBClient, WithClock(clock))
+				var (
+					held, finished atomic.Int32
+					overlapped     atomic.Bool
+				)
+				for i := 0; i < runners; i++ {
+					go func() {
 						defer GinkgoRecover()
+						defer finished.Add(1)
 
-						log.Println("Locking", i)
 						u, err := target.Lock(ctx)
 						Expect(err).ToNot(HaveOccurred())
-						log.Println("Locked", i)
-						parallelCounter++
-						time.Sleep(1 * time.Second)
-						defer func() {
-							parallelCounter--
-						}()
-						defer func() {
-							_ = u.Unlock(ctx)
-							log.Println("Unlocked", i)
-						}()
-					}(i)
+						if held.Add(1) > 1 {
+							overlapped.Store(true)
+						}
+						<-clock.After(time.Second)
+						held.Add(-1)
+						Expect(u.Unlock(ctx)).To(Succeed())
+					}()
 				}
 
-				Consistently(func() int {
-					return parallelCounter
-				}, 15*time.Second).Should(Or(Equal(0), Equal(1)))
+				// the clock is advanced once every runner left is waiting, either holding the lock or for it.
+				Eventually(func() int32 {
+					if running := runners - int(finished.Load()); running > 0 && clock.Waiters() == running {
+						clock.Advance(time.Second)
+					}
+					return finished.Load()
+				}, 15*time.Second, 10*time.Millisecond).Should(Equal(int32(runners)))
+				Expect(overlapped.Load()).To(BeFalse())
 			})
 		})
 
@@ -376,6 +472,144 @@ var _ = Describe("Current", func() {
 	})
 })
 
+var _ = Describe("Transactional writes", func() {
+	var (
+		ctx context.Context
+
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient, WithTransactionalWrites())
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	When("the lock is not held", func() {
+		It("should fail with ErrLockNotHeld", func() {
+			Expect(target.Add(ctx, "1")).To(MatchError(ErrLockNotHeld))
+
+			Expect(listMigrations(ctx)).To(BeEmpty())
+		})
+	})
+
+	When("the lock is held", func() {
+		It("should apply the changes", func() {
+			u, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			defer func() {
+				_ = u.Unlock(ctx)
+			}()
+
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+			Expect(target.Add(ctx, "2")).To(Succeed())
+			Expect(target.Remove(ctx, "2")).To(Succeed())
+
+			Expect(listMigrations(ctx)).To(Equal([]ddbMigration{
+				{ID: "1", Dirty: false},
+			}))
+		})
+
+		It("should keep the migration errors", func() {
+			u, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			defer func() {
+				_ = u.Unlock(ctx)
+			}()
+
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			Expect(target.Add(ctx, "1")).To(MatchError(migrations.ErrMigrationAlreadyExists))
+			Expect(target.FinishMigration(ctx, "2")).To(MatchError(migrations.ErrMigrationNotFound))
+			Expect(target.StartMigration(ctx, "2")).To(MatchError(migrations.ErrMigrationNotFound))
+			Expect(target.Remove(ctx, "2")).To(MatchError(migrations.ErrMigrationNotFound))
+		})
+	})
+
+	When("the lock was taken by another owner", func() {
+		It("should not apply the changes", func() {
+			u, err := target.Lock(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Add(ctx, "1")).To(Succeed())
+
+			_, err = dynamoDBClient.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName: aws.String("_migrations-lock"),
+				Item: map[string]types.AttributeValue{
+					"id":    &types.AttributeValueMemberS{Value: "migrations"},
+					"owner": &types.AttributeValueMemberS{Value: "another-owner"},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(target.FinishMigration(ctx, "1")).To(MatchError(ErrLockNotHeld))
+			Expect(target.Remove(ctx, "1")).To(MatchError(ErrLockNotHeld))
+
+			Expect(listMigrations(ctx)).To(Equal([]ddbMigration{
+				{ID: "1", Dirty: true},
+			}))
+
+			// the lock of the other owner is not released.
+			Expect(u.Unlock(ctx)).To(MatchError(ErrLockNotHeld))
+			Expect(u.Unlock(ctx)).To(Succeed())
+			Expect(target.Status(ctx)).To(HaveField("Lock.Held", true))
+		})
+	})
+})
+
+var _ = Describe("Write condition", func() {
+	var (
+		ctx context.Context
+
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient, WithWriteCondition(
+			expression.AttributeNotExists(expression.Name("frozen")),
+		))
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	When("the condition is satisfied", func() {
+		It("should apply the changes", func() {
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+			Expect(listMigrations(ctx)).To(Equal([]ddbMigration{
+				{ID: "1", Dirty: false},
+			}))
+		})
+	})
+
+	When("the condition is not satisfied", func() {
+		It("should not apply the changes", func() {
+			_, err := dynamoDBClient.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName: aws.String("_migrations"),
+				Item: map[string]types.AttributeValue{
+					"id":     &types.AttributeValueMemberS{Value: "1"},
+					"dirty":  &types.AttributeValueMemberBOOL{Value: true},
+					"frozen": &types.AttributeValueMemberBOOL{Value: true},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(target.FinishMigration(ctx, "1")).To(MatchError(migrations.ErrMigrationNotFound))
+			Expect(target.Remove(ctx, "1")).To(MatchError(migrations.ErrMigrationNotFound))
+
+			Expect(listMigrations(ctx)).To(Equal([]ddbMigration{
+				{ID: "1", Dirty: true},
+			}))
+		})
+	})
+})
+
 func deleteAllTables(ctx context.Context) {
 	GinkgoHelper()
 
@@ -422,3 +656,29 @@ func (s sortMigrations) Less(i, j int) bool {
 func (s sortMigrations) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
+
+// barrierCreateTableClient holds all CreateTable calls until the given number of calls are in flight, failing if that
+// does not happen in time.
+type barrierCreateTableClient struct {
+	*dynamodb.Client
+
+	mu      sync.Mutex
+	parties int
+	release chan struct{}
+}
+
+func (c *barrierCreateTableClient) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.mu.Lock()
+	c.parties--
+	if c.parties == 0 {
+		close(c.release)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.release:
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("create table calls were not made in parallel")
+	}
+	return c.Client.CreateTable(ctx, input, optFns...)
+}
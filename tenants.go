@@ -0,0 +1,152 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// TenantLister lists the tenants whose migrations are run by IterateTenants.
+type TenantLister interface {
+	ListTenants(ctx context.Context) ([]string, error)
+}
+
+// TenantListerFunc is an adapter to allow the use of ordinary functions as a TenantLister.
+type TenantListerFunc func(ctx context.Context) ([]string, error)
+
+// ListTenants calls f(ctx).
+func (f TenantListerFunc) ListTenants(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// TenantFunc runs the migrations of a tenant, with the Target of the tenant, while IterateTenants holds its lock, e.g.
+// by calling migrations.Migrate with the Target.
+type TenantFunc func(ctx context.Context, tenant string, target *Target) error
+
+// TenantProgress is reported by IterateTenants after each tenant (see WithTenantProgress).
+type TenantProgress struct {
+	Tenant string
+	// Done is how many tenants were iterated so far, including this one, out of Total.
+	Done  int
+	Total int
+	// Err is the error of the tenant, if any.
+	Err error
+}
+
+// TenantResult is the outcome of a tenant iterated by IterateTenants.
+type TenantResult struct {
+	Tenant string
+	Err    error
+}
+
+type iterateOpts struct {
+	targetOptions   []Option
+	tenantOptions   func(tenant string) []Option
+	progress        func(ctx context.Context, progress TenantProgress)
+	continueOnError bool
+}
+
+// IterateOption configures IterateTenants.
+type IterateOption func(*iterateOpts)
+
+// WithTenantTargetOptions sets the options applied to the Target of every tenant.
+func WithTenantTargetOptions(options ...Option) IterateOption {
+	return func(o *iterateOpts) {
+		o.targetOptions = append(o.targetOptions, options...)
+	}
+}
+
+// WithTenantOptions sets the options applied to the Target of each tenant, after the ones set by
+// WithTenantTargetOptions, e.g. to record the migrations of the tenants somewhere else than their default table.
+func WithTenantOptions(options func(tenant string) []Option) IterateOption {
+	return func(o *iterateOpts) {
+		o.tenantOptions = options
+	}
+}
+
+// WithTenantProgress sets the function called by IterateTenants after each tenant, to track the progress of a run
+// over many tenants.
+func WithTenantProgress(progress func(ctx context.Context, progress TenantProgress)) IterateOption {
+	return func(o *iterateOpts) {
+		o.progress = progress
+	}
+}
+
+// WithContinueOnError makes IterateTenants go on with the next tenants when one fails, instead of stopping at it.
+func WithContinueOnError() IterateOption {
+	return func(o *iterateOpts) {
+		o.continueOnError = true
+	}
+}
+
+// IterateTenants runs fn for every tenant listed by the lister, one after the other, to roll out the migrations over
+// the tenants. As the groups of an Orchestrator, each tenant has its own Target, recording its migrations in the table
+// "<table>-<tenant>", and locked with the lock ID "<lock ID>-<tenant>" in the shared lock table, where the table and
+// lock ID are the ones set by WithTenantTargetOptions. The tables of the tenant are created if they do not exist, and
+// fn is called while its lock is held.
+//
+// Each tenant has a migrations table of its own, as the Target reads the whole table to tell the migrations applied:
+// the tables count against the quota of tables per region of the AWS account (2,500 by default, raised through the
+// Service Quotas), along with the tables of the application. IterateTenants suits a bounded number of tenants: for many
+// more, keep the tenants' data partitioned in shared tables and migrate them with a single Target.
+//
+// IterateTenants stops at the first tenant failing, unless WithContinueOnError is set, and returns the results of the
+// tenants iterated, in the order they were listed, along with the errors of the ones that failed, joined.
+func IterateTenants(ctx context.Context, client DynamoDBClient, lister TenantLister, fn TenantFunc, options ...IterateOption) ([]TenantResult, error) {
+	o := iterateOpts{}
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	tenants, err := lister.ListTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tenants: %w", err)
+	}
+
+	base := defaultOpts()
+	for _, opt := range o.targetOptions {
+		opt(&base)
+	}
+
+	results := make([]TenantResult, 0, len(tenants))
+	var errs []error
+	for i, tenant := range tenants {
+		targetOptions := append([]Option{}, o.targetOptions...)
+		targetOptions = append(targetOptions,
+			WithTableName(base.tableName+"-"+tenant),
+			WithLockID(base.lockID+"-"+tenant),
+		)
+		if o.tenantOptions != nil {
+			targetOptions = append(targetOptions, o.tenantOptions(tenant)...)
+		}
+
+		err := runTenant(ctx, NewTarget(client, targetOptions...), tenant, fn)
+		if err != nil {
+			err = fmt.Errorf("failed to migrate tenant %s: %w", tenant, err)
+			errs = append(errs, err)
+		}
+		results = append(results, TenantResult{Tenant: tenant, Err: err})
+		if o.progress != nil {
+			o.progress(ctx, TenantProgress{Tenant: tenant, Done: i + 1, Total: len(tenants), Err: err})
+		}
+		if err != nil && !o.continueOnError {
+			break
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// runTenant creates the tables of the tenant and calls fn under its lock.
+func runTenant(ctx context.Context, target *Target, tenant string, fn TenantFunc) (err error) {
+	if err := target.Create(ctx); err != nil {
+		return fmt.Errorf("failed to create the migrations tables: %w", err)
+	}
+	unlocker, err := target.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, unlocker.Unlock(ctx))
+	}()
+	return fn(ctx, tenant, target)
+}
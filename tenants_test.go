@@ -0,0 +1,72 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IterateTenants", func() {
+	var (
+		ctx    context.Context
+		lister TenantLister
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		lister = TenantListerFunc(func(context.Context) ([]string, error) {
+			return []string{"acme", "globex", "initech"}, nil
+		})
+	})
+
+	It("should run the migrations of every tenant under its lock, reporting the progress", func() {
+		var progress []TenantProgress
+		results, err := IterateTenants(ctx, dynamoDBClient, lister, func(ctx context.Context, tenant string, target *Target) error {
+			Expect(target.Add(ctx, "1")).To(Succeed())
+			return target.FinishMigration(ctx, "1")
+		}, WithTenantProgress(func(_ context.Context, p TenantProgress) {
+			progress = append(progress, p)
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(Equal([]TenantResult{{Tenant: "acme"}, {Tenant: "globex"}, {Tenant: "initech"}}))
+		Expect(progress).To(HaveLen(3))
+		Expect(progress[2]).To(Equal(TenantProgress{Tenant: "initech", Done: 3, Total: 3}))
+
+		Expect(NewTarget(dynamoDBClient, WithTableName("_migrations-globex")).Done(ctx)).To(Equal([]string{"1"}))
+
+		status, err := NewTarget(dynamoDBClient, WithLockID("migrations-globex")).Status(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Lock.Held).To(BeFalse())
+	})
+
+	It("should stop at the first tenant failing", func() {
+		failure := errors.New("failed")
+		results, err := IterateTenants(ctx, dynamoDBClient, lister, func(_ context.Context, tenant string, _ *Target) error {
+			if tenant == "globex" {
+				return failure
+			}
+			return nil
+		})
+		Expect(err).To(MatchError(failure))
+		Expect(results).To(HaveLen(2))
+		Expect(results[1].Tenant).To(Equal("globex"))
+	})
+
+	It("should go on with the next tenants with WithContinueOnError", func() {
+		failure := errors.New("failed")
+		results, err := IterateTenants(ctx, dynamoDBClient, lister, func(_ context.Context, tenant string, _ *Target) error {
+			if tenant == "acme" {
+				return failure
+			}
+			return nil
+		}, WithContinueOnError())
+		Expect(err).To(MatchError(failure))
+		Expect(results).To(HaveLen(3))
+		Expect(results[2].Err).ToNot(HaveOccurred())
+	})
+})
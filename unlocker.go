@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/jamillosantos/migrations/v2"
 )
 
 type UnlockDynamoDBClient interface {
@@ -17,23 +20,61 @@ type UnlockDynamoDBClient interface {
 type unlocker struct {
 	client                UnlockDynamoDBClient
 	lockTableName, lockID string
+	ownerID               string
+	capacity              *capacityRecorder
+	logger                *slog.Logger
+	logLevel              slog.Level
+	metrics               MetricsRecorder
+	listener              Listener
+	clock                 Clock
+	options               []func(*dynamodb.Options)
+	locksHeld             *atomic.Int32
+	released              atomic.Bool
 }
 
-func (u *unlocker) Unlock(ctx context.Context) error {
-	_, err := u.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+func (u *unlocker) Unlock(ctx context.Context) (err error) {
+	defer func(startedAt time.Time) {
+		u.metrics.RecordOperation(ctx, operationUnlock, u.clock.Now().Sub(startedAt), err)
+	}(u.clock.Now())
+	defer wrapError(operationUnlock, u.lockTableName, &err)
+
+	// the lock is only released while it is owned by the Target, not after being taken over by another runner.
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.Name("owner").Equal(expression.Value(u.ownerID))).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to build the unlock expression: %w", err)
+	}
+
+	output, err := u.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: &u.lockTableName,
 		Key: map[string]types.AttributeValue{
 			"id": &types.AttributeValueMemberS{
 				Value: u.lockID,
 			},
 		},
-	})
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	}, u.options...)
 	var conditionalCheckFailedException *types.ConditionalCheckFailedException
 	switch {
 	case errors.As(err, &conditionalCheckFailedException):
-		return migrations.ErrMigrationAlreadyExists
+		// releasing the lock again is a no-op.
+		if u.released.Swap(true) {
+			return nil
+		}
+		u.locksHeld.Add(-1)
+		return ErrLockNotHeld
 	case err != nil:
-		return fmt.Errorf("failed to add migration: %w", err)
+		return fmt.Errorf("failed to release the lock: %w", err)
+	}
+	if !u.released.Swap(true) {
+		u.locksHeld.Add(-1)
 	}
+	u.capacity.record(operationUnlock, output.ConsumedCapacity)
+	u.logger.Log(ctx, u.logLevel, "lock released", "lock_id", u.lockID)
+	u.listener.OnEvent(ctx, LockReleased{LockID: u.lockID})
 	return err
 }
@@ -0,0 +1,159 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrTableMismatch is matched by the TableMismatchError returned by Create, in validate-only mode, when the tables do
+// not match the settings of the Target.
+var ErrTableMismatch = errors.New("tables do not match the expected settings")
+
+// TableMismatch is a setting of a table that does not match the one expected by the Target.
+type TableMismatch struct {
+	Table string
+	// Setting is the setting that does not match: "table", "key schema", "billing mode" or "ttl".
+	Setting  string
+	Expected string
+	Actual   string
+}
+
+func (m TableMismatch) String() string {
+	return fmt.Sprintf("%s: %s is %s, expected %s", m.Table, m.Setting, m.Actual, m.Expected)
+}
+
+// TableMismatchError is returned by Create, in validate-only mode, with every mismatch found.
+type TableMismatchError struct {
+	Mismatches []TableMismatch
+}
+
+func (e *TableMismatchError) Error() string {
+	mismatches := make([]string, 0, len(e.Mismatches))
+	for _, mismatch := range e.Mismatches {
+		mismatches = append(mismatches, mismatch.String())
+	}
+	return ErrTableMismatch.Error() + ": " + strings.Join(mismatches, "; ")
+}
+
+func (e *TableMismatchError) Is(target error) bool {
+	return target == ErrTableMismatch
+}
+
+// validateTables checks that the tables used by the Target exist with the settings it would create them with, and the
+// TTL expected on the lock table, if set. The mismatches found are returned in a TableMismatchError.
+func (t *Target) validateTables(ctx context.Context) error {
+	tables := []string{t.tableName, t.lockTableName}
+	if t.auditTableName != "" {
+		tables = append(tables, t.auditTableName)
+	}
+
+	var mismatches []TableMismatch
+	for _, tableName := range tables {
+		tableMismatches, err := t.validateTable(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		mismatches = append(mismatches, tableMismatches...)
+	}
+	if len(mismatches) > 0 {
+		return &TableMismatchError{Mismatches: mismatches}
+	}
+	t.logger.Log(ctx, t.eventLogLevel, "tables validated", "tables", tables)
+	return nil
+}
+
+func (t *Target) validateTable(ctx context.Context, tableName string) ([]TableMismatch, error) {
+	output, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: &tableName,
+	})
+	var resourceNotFoundException *types.ResourceNotFoundException
+	switch {
+	case errors.As(err, &resourceNotFoundException):
+		return []TableMismatch{{Table: tableName, Setting: "table", Expected: "existing", Actual: "missing"}}, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to describe the table %s: %w", tableName, err)
+	}
+
+	var mismatches []TableMismatch
+	if problem := tableSchemaProblem(output.Table); problem != "" {
+		mismatches = append(mismatches, TableMismatch{
+			Table:    tableName,
+			Setting:  "key schema",
+			Expected: "a single string hash key named \"id\"",
+			Actual:   describeKeySchema(output.Table),
+		})
+	}
+	expected, actual := types.BillingModeProvisioned, types.BillingModeProvisioned
+	if t.billingMode == types.BillingModePayPerRequest {
+		expected = types.BillingModePayPerRequest
+	}
+	if isPayPerRequest(output.Table) {
+		actual = types.BillingModePayPerRequest
+	}
+	if expected != actual {
+		mismatches = append(mismatches, TableMismatch{
+			Table:    tableName,
+			Setting:  "billing mode",
+			Expected: string(expected),
+			Actual:   string(actual),
+		})
+	}
+
+	if tableName == t.lockTableName && t.expectedTimeToLive != "" {
+		mismatch, err := t.validateTimeToLive(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if mismatch != nil {
+			mismatches = append(mismatches, *mismatch)
+		}
+	}
+	return mismatches, nil
+}
+
+// validateTimeToLive checks the TTL of the lock table is enabled on the expected attribute. The check is skipped when
+// the client does not implement TimeToLiveDynamoDBClient.
+func (t *Target) validateTimeToLive(ctx context.Context) (*TableMismatch, error) {
+	client, ok := t.client.(TimeToLiveDynamoDBClient)
+	if !ok {
+		return nil, nil
+	}
+	output, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: &t.lockTableName,
+	})
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to describe the TTL of the table %s: %w", t.lockTableName, err)
+	}
+
+	actual := "disabled"
+	if description := output.TimeToLiveDescription; description != nil && description.TimeToLiveStatus == types.TimeToLiveStatusEnabled {
+		actual = fmt.Sprintf("enabled on %q", aws.ToString(description.AttributeName))
+	}
+	expected := fmt.Sprintf("enabled on %q", t.expectedTimeToLive)
+	if actual == expected {
+		return nil, nil
+	}
+	return &TableMismatch{Table: t.lockTableName, Setting: "ttl", Expected: expected, Actual: actual}, nil
+}
+
+func describeKeySchema(table *types.TableDescription) string {
+	attributeTypes := make(map[string]types.ScalarAttributeType, len(table.AttributeDefinitions))
+	for _, definition := range table.AttributeDefinitions {
+		attributeTypes[aws.ToString(definition.AttributeName)] = definition.AttributeType
+	}
+	keys := make([]string, 0, len(table.KeySchema))
+	for _, element := range table.KeySchema {
+		name := aws.ToString(element.AttributeName)
+		keys = append(keys, fmt.Sprintf("%s %q (%s)", strings.ToLower(string(element.KeyType)), name, attributeTypes[name]))
+	}
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,96 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithValidateOnly", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+	})
+
+	mismatchesOf := func(err error) []TableMismatch {
+		GinkgoHelper()
+
+		var mismatchErr *TableMismatchError
+		Expect(errors.As(err, &mismatchErr)).To(BeTrue(), "expected a TableMismatchError, got %v", err)
+		return mismatchErr.Mismatches
+	}
+
+	It("should accept the tables matching the settings", func() {
+		Expect(NewTarget(dynamoDBClient, WithBillingMode(types.BillingModePayPerRequest)).Create(ctx)).To(Succeed())
+
+		target := NewTarget(dynamoDBClient, WithBillingMode(types.BillingModePayPerRequest), WithValidateOnly())
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+
+	It("should report the missing tables without creating them", func() {
+		target := NewTarget(dynamoDBClient, WithValidateOnly())
+
+		err := target.Create(ctx)
+		Expect(err).To(MatchError(ErrTableMismatch))
+		Expect(ErrorCodeOf(err)).To(Equal(CodeTableMismatch))
+		Expect(mismatchesOf(err)).To(Equal([]TableMismatch{
+			{Table: "_migrations", Setting: "table", Expected: "existing", Actual: "missing"},
+			{Table: "_migrations-lock", Setting: "table", Expected: "existing", Actual: "missing"},
+		}))
+
+		tables, err := dynamoDBClient.ListTables(ctx, &dynamodb.ListTablesInput{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tables.TableNames).To(BeEmpty())
+
+		_, err = target.Lock(ctx)
+		Expect(err).To(MatchError(ErrTableNotFound))
+	})
+
+	It("should report the billing mode and the key schema not matching", func() {
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+		_, err := dynamoDBClient.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: aws.String("_migrations")})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = dynamoDBClient.CreateTable(ctx, &dynamodb.CreateTableInput{
+			TableName:            aws.String("_migrations"),
+			AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("version"), AttributeType: types.ScalarAttributeTypeN}},
+			KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("version"), KeyType: types.KeyTypeHash}},
+			BillingMode:          types.BillingModePayPerRequest,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = NewTarget(dynamoDBClient, WithValidateOnly()).Create(ctx)
+		Expect(mismatchesOf(err)).To(Equal([]TableMismatch{
+			{Table: "_migrations", Setting: "key schema", Expected: `a single string hash key named "id"`, Actual: `hash "version" (N)`},
+			{Table: "_migrations", Setting: "billing mode", Expected: "PROVISIONED", Actual: "PAY_PER_REQUEST"},
+		}))
+		Expect(err).To(MatchError(ContainSubstring("_migrations: billing mode is PAY_PER_REQUEST, expected PROVISIONED")))
+	})
+
+	It("should report the TTL of the lock table not matching", func() {
+		Expect(NewTarget(dynamoDBClient).Create(ctx)).To(Succeed())
+
+		target := NewTarget(dynamoDBClient, WithValidateOnly(), WithExpectedTimeToLive("expires_at"))
+		err := target.Create(ctx)
+		Expect(mismatchesOf(err)).To(Equal([]TableMismatch{
+			{Table: "_migrations-lock", Setting: "ttl", Expected: `enabled on "expires_at"`, Actual: "disabled"},
+		}))
+
+		_, err = dynamoDBClient.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName: aws.String("_migrations-lock"),
+			TimeToLiveSpecification: &types.TimeToLiveSpecification{
+				AttributeName: aws.String("expires_at"),
+				Enabled:       aws.Bool(true),
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.Create(ctx)).To(Succeed())
+	})
+})
@@ -0,0 +1,163 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrVerificationFailed is matched by the *VerificationError returned by FinishMigration when a check registered
+// with Verify fails.
+var ErrVerificationFailed = errors.New("migration verification failed")
+
+// Check asserts the result of a migration, with the client of the Target. It returns an error describing what is
+// wrong, or nil if the result is as expected.
+type Check func(ctx context.Context, client DynamoDBClient) error
+
+// VerificationError is returned by FinishMigration when checks registered for the migration failed.
+type VerificationError struct {
+	ID string
+	// Failures are the errors returned by the checks that failed.
+	Failures []error
+}
+
+func (e *VerificationError) Error() string {
+	failures := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		failures = append(failures, failure.Error())
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrVerificationFailed, e.ID, strings.Join(failures, "; "))
+}
+
+func (e *VerificationError) Is(target error) bool {
+	return target == ErrVerificationFailed
+}
+
+func (e *VerificationError) Unwrap() []error {
+	return e.Failures
+}
+
+type verifications struct {
+	mu     sync.Mutex
+	checks map[string][]Check
+}
+
+func newVerifications() *verifications {
+	return &verifications{checks: map[string][]Check{}}
+}
+
+// Verify registers checks evaluated after the migration is finished by FinishMigration, to catch data migrations
+// that silently broke something. The migration can register them from its Do, once it knows what to expect. When a
+// check fails, the migration is marked as failed with FailMigration, with the details of the failures, so the next
+// runs stop at it until it is fixed. All checks are evaluated, even after one fails.
+func (t *Target) Verify(id string, checks ...Check) {
+	t.verifications.mu.Lock()
+	defer t.verifications.mu.Unlock()
+	t.verifications.checks[id] = append(t.verifications.checks[id], checks...)
+}
+
+// verify evaluates the checks registered for the migration, marking it as failed if any fails. The checks
+// are discarded afterwards, so a migration applied again registers them again.
+func (t *Target) verify(ctx context.Context, id string) error {
+	t.verifications.mu.Lock()
+	checks := t.verifications.checks[id]
+	delete(t.verifications.checks, id)
+	t.verifications.mu.Unlock()
+
+	var failures []error
+	for _, check := range checks {
+		if err := check(ctx, t.client); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	err := &VerificationError{ID: id, Failures: failures}
+	t.listener.OnEvent(ctx, MigrationFailed{ID: id, Operation: operationFinishMigration, Err: err})
+	if failErr := t.FailMigration(ctx, id, err); failErr != nil {
+		return errors.Join(err, failErr)
+	}
+	return err
+}
+
+// ItemCountBetween asserts the table has from min to max items, both inclusive. The items are counted with a scan.
+func ItemCountBetween(tableName string, min, max int64) Check {
+	return func(ctx context.Context, client DynamoDBClient) error {
+		var (
+			count    int64
+			startKey map[string]types.AttributeValue
+		)
+		for {
+			output, err := client.Scan(ctx, &dynamodb.ScanInput{
+				TableName:         aws.String(tableName),
+				Select:            types.SelectCount,
+				ExclusiveStartKey: startKey,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to count the items of table %s: %w", tableName, err)
+			}
+			count += int64(output.Count)
+			if len(output.LastEvaluatedKey) == 0 {
+				break
+			}
+			startKey = output.LastEvaluatedKey
+		}
+		if count < min || count > max {
+			return fmt.Errorf("table %s has %d items, expected from %d to %d", tableName, count, min, max)
+		}
+		return nil
+	}
+}
+
+// AttributeExistsOnSample asserts the first items scanned from the table, up to sampleSize, all have the attribute.
+func AttributeExistsOnSample(tableName, attributeName string, sampleSize int32) Check {
+	return func(ctx context.Context, client DynamoDBClient) error {
+		output, err := client.Scan(ctx, &dynamodb.ScanInput{
+			TableName: aws.String(tableName),
+			Limit:     aws.Int32(sampleSize),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sample the items of table %s: %w", tableName, err)
+		}
+		missing := 0
+		for _, item := range output.Items {
+			if _, ok := item[attributeName]; !ok {
+				missing++
+			}
+		}
+		if missing > 0 {
+			return fmt.Errorf("%d of %d items sampled from table %s do not have attribute %s", missing, len(output.Items), tableName, attributeName)
+		}
+		return nil
+	}
+}
+
+// IndexActive asserts the global secondary index of the table exists, is active and finished backfilling.
+func IndexActive(tableName, indexName string) Check {
+	return func(ctx context.Context, client DynamoDBClient) error {
+		output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to describe table %s: %w", tableName, err)
+		}
+		for _, index := range output.Table.GlobalSecondaryIndexes {
+			if aws.ToString(index.IndexName) != indexName {
+				continue
+			}
+			if index.IndexStatus != types.IndexStatusActive || aws.ToBool(index.Backfilling) {
+				return fmt.Errorf("index %s of table %s is %s", indexName, tableName, index.IndexStatus)
+			}
+			return nil
+		}
+		return fmt.Errorf("index %s of table %s does not exist", indexName, tableName)
+	}
+}
@@ -0,0 +1,115 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verify", func() {
+	var (
+		ctx    context.Context
+		target *Target
+		events []Event
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		deleteAllTables(ctx)
+
+		events = nil
+		target = NewTarget(dynamoDBClient, WithListener(ListenerFunc(func(_ context.Context, event Event) {
+			events = append(events, event)
+		})))
+		Expect(target.Create(ctx)).To(Succeed())
+
+		_, err := dynamoDBClient.CreateTable(ctx, &dynamodb.CreateTableInput{
+			TableName:   aws.String("users"),
+			BillingMode: types.BillingModePayPerRequest,
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("email"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}},
+			GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+				IndexName:  aws.String("by-email"),
+				KeySchema:  []types.KeySchemaElement{{AttributeName: aws.String("email"), KeyType: types.KeyTypeHash}},
+				Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		for i := 0; i < 3; i++ {
+			_, err := dynamoDBClient.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName: aws.String("users"),
+				Item: map[string]types.AttributeValue{
+					"id":    &types.AttributeValueMemberS{Value: fmt.Sprint(i)},
+					"email": &types.AttributeValueMemberS{Value: fmt.Sprintf("%d@example.com", i)},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(target.Add(ctx, "1")).To(Succeed())
+	})
+
+	It("should finish the migration when the checks pass", func() {
+		target.Verify("1",
+			ItemCountBetween("users", 1, 3),
+			AttributeExistsOnSample("users", "email", 10),
+			IndexActive("users", "by-email"),
+		)
+
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].Dirty).To(BeFalse())
+		Expect(history[0].Failure).To(BeEmpty())
+	})
+
+	It("should fail the migration with the details of the checks that failed", func() {
+		target.Verify("1",
+			ItemCountBetween("users", 5, 10),
+			AttributeExistsOnSample("users", "name", 10),
+			IndexActive("users", "by-email"),
+			IndexActive("users", "by-name"),
+		)
+
+		err := target.FinishMigration(ctx, "1")
+		Expect(err).To(MatchError(ErrVerificationFailed))
+		var verificationErr *VerificationError
+		Expect(errors.As(err, &verificationErr)).To(BeTrue())
+		Expect(verificationErr.ID).To(Equal("1"))
+		Expect(verificationErr.Failures).To(HaveLen(3))
+		Expect(err).To(MatchError(ContainSubstring("table users has 3 items, expected from 5 to 10")))
+		Expect(err).To(MatchError(ContainSubstring("3 of 3 items sampled from table users do not have attribute name")))
+		Expect(err).To(MatchError(ContainSubstring("index by-name of table users does not exist")))
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].Dirty).To(BeTrue())
+		Expect(history[0].Failure).To(ContainSubstring("index by-name of table users does not exist"))
+		Expect(events).To(ContainElement(MigrationFailed{ID: "1", Operation: "FinishMigration", Err: verificationErr}))
+	})
+
+	It("should discard the checks once evaluated", func() {
+		target.Verify("1", ItemCountBetween("users", 5, 10))
+		Expect(target.FinishMigration(ctx, "1")).To(MatchError(ErrVerificationFailed))
+
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+
+		history, err := target.History(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history[0].Dirty).To(BeFalse())
+		Expect(history[0].Failure).To(BeEmpty())
+	})
+})
@@ -0,0 +1,108 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchInterval is how often Watch polls the state of the Target, unless set by WithWatchInterval.
+const DefaultWatchInterval = 5 * time.Second
+
+type watchOpts struct {
+	interval time.Duration
+}
+
+// WatchOption configures Target.Watch.
+type WatchOption func(*watchOpts)
+
+// WithWatchInterval sets how often Watch polls the state of the Target. Defaults to DefaultWatchInterval.
+func WithWatchInterval(interval time.Duration) WatchOption {
+	return func(o *watchOpts) {
+		o.interval = interval
+	}
+}
+
+// Watch polls the state of the Target (see Status) and sends an event for every change found between two polls, so
+// dashboards and deploy jobs waiting for a run can react to them without polling themselves:
+//
+//   - MigrationAdded, for a migration recorded since the last poll;
+//   - MigrationStarted, for a finished migration that became dirty again;
+//   - MigrationFinished, for a dirty migration that was finished, or a migration recorded already finished;
+//   - LockAcquired and LockReleased, when the lock is taken or released. A lock taken by another owner between two
+//     polls is sent as released, then acquired.
+//
+// The state of the first poll is the baseline, so no events are sent for it. Changes that are undone between two
+// polls (e.g. a lock acquired and released) are not seen. A poll that fails is logged and retried at the next
+// interval. The channel is closed when ctx is done.
+func (t *Target) Watch(ctx context.Context, options ...WatchOption) <-chan Event {
+	o := watchOpts{interval: DefaultWatchInterval}
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		var last *Status
+		for {
+			status, err := t.Status(ctx)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				return
+			case err != nil:
+				t.logger.WarnContext(ctx, "failed to watch the migrations", "error", err)
+			default:
+				if last != nil {
+					for _, event := range t.statusChanges(*last, status) {
+						select {
+						case events <- event:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+				last = &status
+			}
+
+			select {
+			case <-t.clock.After(o.interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// statusChanges returns the events turning the state from into the state to. Migrations removed are not reported.
+func (t *Target) statusChanges(from, to Status) []Event {
+	dirty := make(map[string]bool, len(from.Migrations))
+	for _, migration := range from.Migrations {
+		dirty[migration.ID] = migration.Dirty
+	}
+
+	var events []Event
+	for _, migration := range to.Migrations {
+		wasDirty, ok := dirty[migration.ID]
+		switch {
+		case !ok:
+			events = append(events, MigrationAdded{ID: migration.ID})
+			if !migration.Dirty {
+				events = append(events, MigrationFinished{ID: migration.ID})
+			}
+		case wasDirty && !migration.Dirty:
+			events = append(events, MigrationFinished{ID: migration.ID})
+		case !wasDirty && migration.Dirty:
+			events = append(events, MigrationStarted{ID: migration.ID})
+		}
+	}
+
+	if from.Lock.Held && (!to.Lock.Held || from.Lock.Owner != to.Lock.Owner) {
+		events = append(events, LockReleased{LockID: t.lockID})
+	}
+	if to.Lock.Held && (!from.Lock.Held || from.Lock.Owner != to.Lock.Owner) {
+		events = append(events, LockAcquired{LockID: t.lockID, OwnerID: to.Lock.Owner})
+	}
+	return events
+}
@@ -0,0 +1,52 @@
+package migrations_dynamodb
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watch", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		target *Target
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(func() { cancel() })
+
+		deleteAllTables(ctx)
+
+		target = NewTarget(dynamoDBClient, WithOwnerID("runner-1"))
+		Expect(target.Create(ctx)).To(Succeed())
+		Expect(target.Add(ctx, "1")).To(Succeed())
+	})
+
+	It("should send the changes of the lock and the migrations since the first poll", func() {
+		events := target.Watch(ctx, WithWatchInterval(10*time.Millisecond))
+		// give the first poll, the baseline, the time to run.
+		time.Sleep(50 * time.Millisecond)
+
+		u, err := target.Lock(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(events).Should(Receive(Equal(LockAcquired{LockID: "migrations", OwnerID: "runner-1"})))
+
+		Expect(target.FinishMigration(ctx, "1")).To(Succeed())
+		Expect(target.Add(ctx, "2")).To(Succeed())
+		Eventually(events).Should(Receive(Equal(MigrationFinished{ID: "1"})))
+		Eventually(events).Should(Receive(Equal(MigrationAdded{ID: "2"})))
+
+		Expect(u.Unlock(ctx)).To(Succeed())
+		Eventually(events).Should(Receive(Equal(LockReleased{LockID: "migrations"})))
+	})
+
+	It("should close the channel when the context is done", func() {
+		events := target.Watch(ctx, WithWatchInterval(10*time.Millisecond))
+		cancel()
+		Eventually(events).Should(BeClosed())
+	})
+})